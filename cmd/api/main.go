@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -11,12 +14,17 @@ import (
 	httpSwagger "github.com/swaggo/http-swagger"
 
 	_ "github.com/noggrj/autorepair/docs" // for swagger docs
+	identityApp "github.com/noggrj/autorepair/internal/identity/application"
 	identityHttp "github.com/noggrj/autorepair/internal/identity/delivery/http"
+	identityDomain "github.com/noggrj/autorepair/internal/identity/domain"
 	identityInfra "github.com/noggrj/autorepair/internal/identity/infrastructure"
+	inventoryApp "github.com/noggrj/autorepair/internal/inventory/application"
 	inventoryInfra "github.com/noggrj/autorepair/internal/inventory/infrastructure"
 	notificationInfra "github.com/noggrj/autorepair/internal/notification/infrastructure"
+	"github.com/noggrj/autorepair/internal/platform/auth"
 	"github.com/noggrj/autorepair/internal/platform/config"
 	"github.com/noggrj/autorepair/internal/platform/db"
+	"github.com/noggrj/autorepair/internal/platform/jobs"
 	authMiddleware "github.com/noggrj/autorepair/internal/platform/middleware"
 	serviceApp "github.com/noggrj/autorepair/internal/service/application"
 	serviceHttp "github.com/noggrj/autorepair/internal/service/delivery/http"
@@ -45,6 +53,31 @@ func main() {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	// Tokens are signed with keys from JWT_KEYS_DIR. Only the development
+	// profile may fall back to a key that dies with the process.
+	keyring, err := auth.LoadKeyring(cfg.JWTKeysDir, cfg.JWTSigningKey)
+	if errors.Is(err, auth.ErrNoSigningKey) && cfg.IsDevelopment() {
+		log.Println("No JWT keys configured; signing with an ephemeral development key")
+		keyring, err = auth.NewEphemeralKeyring()
+	}
+	if err != nil {
+		log.Fatalf("Failed to load JWT keys: %v", err)
+	}
+	auth.Use(keyring)
+	auth.UseIssuer(cfg.JWTIssuer, cfg.JWTAudience)
+	log.Printf("Signing tokens with key %q", keyring.SigningKeyID())
+
+	// Budget approval links are signed with BUDGET_LINK_SECRET, under the
+	// same development fallback.
+	linkSigner, err := auth.NewLinkSigner([]byte(cfg.BudgetLinkSecret))
+	if errors.Is(err, auth.ErrNoLinkKey) && cfg.IsDevelopment() {
+		log.Println("No BUDGET_LINK_SECRET configured; signing budget links with an ephemeral development key")
+		linkSigner, err = auth.NewEphemeralLinkSigner()
+	}
+	if err != nil {
+		log.Fatalf("Failed to load budget link secret: %v", err)
+	}
+
 	// 2. Connect to DB
 	database, err := db.New(cfg.DBURL)
 	if err != nil {
@@ -54,36 +87,79 @@ func main() {
 
 	// 3. Setup Repositories
 	userRepo := identityInfra.NewPostgresUserRepository(database.Pool)
+	refreshTokenRepo := identityInfra.NewPostgresRefreshTokenRepository(database.Pool)
+	loginAttemptRepo := identityInfra.NewPostgresLoginAttemptRepository(database.Pool)
+	auditLog := identityInfra.NewPostgresAuditLog(database.Pool)
+	userTokenRepo := identityInfra.NewPostgresUserTokenRepository(database.Pool)
+	recoveryCodeRepo := identityInfra.NewPostgresRecoveryCodeRepository(database.Pool)
 	clientRepo := serviceInfra.NewPostgresClientRepository(database.Pool)
 	vehicleRepo := serviceInfra.NewPostgresVehicleRepository(database.Pool)
 	partRepo := inventoryInfra.NewPostgresPartRepository(database.Pool)
+	supplierRepo := inventoryInfra.NewPostgresSupplierRepository(database.Pool)
+	purchaseOrderRepo := inventoryInfra.NewPostgresPurchaseOrderRepository(database.Pool)
 	serviceRepo := serviceInfra.NewPostgresServiceRepository(database.Pool)
 	orderRepo := serviceInfra.NewPostgresOrderRepository(database.Pool)
+	unitOfWork := serviceInfra.NewPostgresUnitOfWork(database.Pool)
+	budgetApprovalRepo := serviceInfra.NewPostgresBudgetApprovalRepository(database.Pool)
+	odometerRepo := serviceInfra.NewPostgresOdometerRepository(database.Pool)
+	maintenancePlanRepo := serviceInfra.NewPostgresMaintenancePlanRepository(database.Pool)
+	maintenanceReminderRepo := serviceInfra.NewPostgresMaintenanceReminderRepository(database.Pool)
+	vehicleOwnershipRepo := serviceInfra.NewPostgresVehicleOwnershipRepository(database.Pool)
 
 	emailService := notificationInfra.NewConsoleEmailService()
 	// ... other repos
 
 	// 4. Setup Services
-	orderService := serviceApp.NewOrderService(orderRepo, partRepo, clientRepo, emailService)
+	tokenService := identityApp.NewTokenService(userRepo, refreshTokenRepo)
+	userService := identityApp.NewUserService(userRepo, refreshTokenRepo)
+	loginGuard := identityApp.NewLoginGuard(loginAttemptRepo, auditLog)
+	accountService := identityApp.NewAccountService(userRepo, userTokenRepo, refreshTokenRepo, emailService)
+	mfaService := identityApp.NewMFAService(userRepo, recoveryCodeRepo)
+	stockAlerter := inventoryApp.NewLowStockAlerter(identityInfra.NewManagerDirectory(userRepo), emailService)
+	inventoryUnitOfWork := inventoryInfra.NewPostgresUnitOfWork(database.Pool, func(conn db.Connection) inventoryApp.OpenOrderChecker {
+		return serviceInfra.NewPostgresOrderRepository(conn)
+	})
+	partService := inventoryApp.NewPartService(inventoryUnitOfWork, stockAlerter)
+	purchaseOrderService := inventoryApp.NewPurchaseOrderService(purchaseOrderRepo, supplierRepo, partRepo, inventoryUnitOfWork)
+	budgetLinks := serviceApp.NewBudgetLinks(budgetApprovalRepo, linkSigner)
+	orderService := serviceApp.NewOrderService(orderRepo, partRepo, clientRepo, emailService, unitOfWork, stockAlerter, budgetLinks)
+	maintenanceService := serviceApp.NewMaintenanceService(maintenancePlanRepo, maintenanceReminderRepo, serviceRepo, clientRepo, emailService)
+	vehicleSpecProvider := serviceInfra.NewTableVehicleSpecProvider(serviceInfra.DefaultVehicleSpecs)
+	vehicleService := serviceApp.NewVehicleService(vehicleOwnershipRepo, clientRepo, vehicleSpecProvider)
 
 	// 5. Setup Handlers
-	authHandler := identityHttp.NewAuthHandler(userRepo)
+	authHandler := identityHttp.NewAuthHandler(userRepo, tokenService, loginGuard, accountService, mfaService)
 	clientHandler := serviceHttp.NewClientHandler(clientRepo)
-	vehicleHandler := serviceHttp.NewVehicleHandler(vehicleRepo)
-	partHandler := serviceHttp.NewPartHandler(partRepo)
+	vehicleHandler := serviceHttp.NewVehicleHandler(vehicleRepo, orderRepo, odometerRepo, vehicleService)
+	partHandler := serviceHttp.NewPartHandler(partRepo, partService)
+	supplierHandler := serviceHttp.NewSupplierHandler(supplierRepo)
+	purchaseOrderHandler := serviceHttp.NewPurchaseOrderHandler(purchaseOrderRepo, purchaseOrderService)
 	serviceHandler := serviceHttp.NewServiceHandler(serviceRepo)
+	maintenancePlanHandler := serviceHttp.NewMaintenancePlanHandler(maintenanceService)
+	userHandler := serviceHttp.NewUserHandler(userRepo, userService, loginGuard)
 	orderHandler := serviceHttp.NewOrderHandler(orderRepo, partRepo, serviceRepo, orderService)
+	portalHandler := serviceHttp.NewPortalHandler(userRepo, userService, accountService, clientRepo, vehicleRepo, orderRepo, orderService)
 	// ... other handlers
 
 	// 6. Setup Router
 	r := chi.NewRouter()
+	if cfg.TrustProxyHeaders {
+		// Login throttling counts failures per client IP, which behind a
+		// load balancer is only known from the forwarding headers.
+		r.Use(middleware.RealIP)
+	}
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
+	if cfg.QueryTimeout > 0 {
+		// Cancels the request context, and with it any running query, once
+		// the deadline passes.
+		r.Use(middleware.Timeout(cfg.QueryTimeout))
+	}
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		ExposedHeaders:   []string{"Link", "X-Next-Cursor"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
@@ -100,6 +176,8 @@ func main() {
 		httpSwagger.URL(fmt.Sprintf("http://localhost:%s/swagger/doc.json", cfg.Port)),
 	))
 
+	r.Get("/.well-known/jwks.json", authHandler.JWKS)
+
 	// Routes
 	r.Group(func(r chi.Router) {
 		// Public Routes
@@ -114,44 +192,117 @@ func main() {
 
 		r.Group(func(r chi.Router) {
 			r.Use(authMiddleware.AuthMiddleware)
+			// Every admin route names the permission it needs; the role to
+			// permission matrix lives in identity/domain. Sensitive routes
+			// also need admins and managers to have passed a second factor.
+			can := identityHttp.RequirePermission
+			mfa := identityHttp.RequireMFA
 			r.Mount("/admin", func() http.Handler {
 				sr := chi.NewRouter()
-				sr.Post("/clients", clientHandler.Create)
-				sr.Get("/clients", clientHandler.List)
-				sr.Put("/clients/{id}", clientHandler.Update)
-				sr.Delete("/clients/{id}", clientHandler.Delete)
-
-				sr.Post("/vehicles", vehicleHandler.Create)
-				sr.Get("/vehicles", vehicleHandler.ListByClient)
-				sr.Put("/vehicles/{id}", vehicleHandler.Update)
-				sr.Delete("/vehicles/{id}", vehicleHandler.Delete)
-
-				sr.Post("/parts", partHandler.Create)
-				sr.Get("/parts", partHandler.List)
-
-				sr.Post("/services", serviceHandler.Create)
-				sr.Get("/services", serviceHandler.List)
-				sr.Put("/services/{id}", serviceHandler.Update)
-				sr.Delete("/services/{id}", serviceHandler.Delete)
-
-				sr.Post("/orders", orderHandler.Create)
-				sr.Get("/orders", orderHandler.ListActive)
-				sr.Get("/orders/{id}", orderHandler.Get)
-				sr.Patch("/orders/{id}/approve", orderHandler.Approve)
-				sr.Post("/orders/{id}/diagnosis:start", orderHandler.StartDiagnosis)
-				sr.Post("/orders/{id}/budget:send", orderHandler.SendBudget)
-				sr.Post("/orders/{id}/finish", orderHandler.FinishOrder)
-				sr.Post("/orders/{id}/deliver", orderHandler.DeliverOrder)
-				sr.Patch("/orders/{id}/status", orderHandler.UpdateStatus)
-
-				sr.Get("/reports/revenue", orderHandler.ReportRevenue)
-				sr.Get("/reports/avg-execution-time", orderHandler.ReportAvgExecutionTime)
+				sr.With(can(identityDomain.PermClientsWrite)).Post("/clients", clientHandler.Create)
+				sr.With(can(identityDomain.PermClientsRead)).Get("/clients", clientHandler.List)
+				sr.With(can(identityDomain.PermClientsWrite)).Put("/clients/{id}", clientHandler.Update)
+				sr.With(can(identityDomain.PermClientsDelete), mfa).Delete("/clients/{id}", clientHandler.Delete)
+				sr.With(can(identityDomain.PermClientsWrite)).Post("/clients/{id}/portal-account", portalHandler.Invite)
+
+				sr.With(can(identityDomain.PermVehiclesWrite)).Post("/vehicles", vehicleHandler.Create)
+				sr.With(can(identityDomain.PermVehiclesRead)).Get("/vehicles", vehicleHandler.ListByClient)
+				sr.With(can(identityDomain.PermVehiclesRead)).Get("/vehicles/by-plate/{plate}", vehicleHandler.GetByPlate)
+				sr.With(can(identityDomain.PermVehiclesRead)).Get("/vehicles/{id}/history", vehicleHandler.History)
+				sr.With(can(identityDomain.PermVehiclesWrite)).Put("/vehicles/{id}", vehicleHandler.Update)
+				sr.With(can(identityDomain.PermVehiclesWrite)).Post("/vehicles/{id}/transfer", vehicleHandler.Transfer)
+				sr.With(can(identityDomain.PermVehiclesDelete), mfa).Delete("/vehicles/{id}", vehicleHandler.Delete)
+
+				sr.With(can(identityDomain.PermPartsWrite)).Post("/parts", partHandler.Create)
+				sr.With(can(identityDomain.PermPartsRead)).Get("/parts", partHandler.List)
+				sr.With(can(identityDomain.PermPartsRead)).Get("/parts/low-stock", partHandler.LowStock)
+				sr.With(can(identityDomain.PermPartsRead)).Get("/parts/reconciliation", partHandler.Reconcile)
+				sr.With(can(identityDomain.PermPartsRead)).Get("/parts/{id}", partHandler.Get)
+				sr.With(can(identityDomain.PermPartsWrite)).Put("/parts/{id}", partHandler.Update)
+				sr.With(can(identityDomain.PermPartsDelete), mfa).Delete("/parts/{id}", partHandler.Delete)
+				sr.With(can(identityDomain.PermStockAdjust), mfa).Post("/parts/{id}/stock", partHandler.AdjustStock)
+				sr.With(can(identityDomain.PermPartsRead)).Get("/parts/{id}/movements", partHandler.Movements)
+
+				sr.With(can(identityDomain.PermSuppliersWrite)).Post("/suppliers", supplierHandler.Create)
+				sr.With(can(identityDomain.PermSuppliersRead)).Get("/suppliers", supplierHandler.List)
+				sr.With(can(identityDomain.PermSuppliersRead)).Get("/suppliers/{id}", supplierHandler.Get)
+				sr.With(can(identityDomain.PermSuppliersWrite)).Put("/suppliers/{id}", supplierHandler.Update)
+				sr.With(can(identityDomain.PermSuppliersDelete), mfa).Delete("/suppliers/{id}", supplierHandler.Delete)
+
+				sr.With(can(identityDomain.PermPurchaseOrdersWrite)).Post("/purchase-orders", purchaseOrderHandler.Create)
+				sr.With(can(identityDomain.PermPurchaseOrdersRead)).Get("/purchase-orders", purchaseOrderHandler.List)
+				sr.With(can(identityDomain.PermPurchaseOrdersRead)).Get("/purchase-orders/{id}", purchaseOrderHandler.Get)
+				sr.With(can(identityDomain.PermPurchaseOrdersWrite)).Post("/purchase-orders/{id}/send", purchaseOrderHandler.Send)
+				sr.With(can(identityDomain.PermPurchaseOrdersReceive)).Post("/purchase-orders/{id}/lines/{lineID}/receive", purchaseOrderHandler.ReceiveLine)
+				sr.With(can(identityDomain.PermPurchaseOrdersRead)).Get("/purchase-orders/{id}/receipts", purchaseOrderHandler.Receipts)
+
+				sr.With(can(identityDomain.PermServicesWrite)).Post("/services", serviceHandler.Create)
+				sr.With(can(identityDomain.PermServicesRead)).Get("/services", serviceHandler.List)
+				sr.With(can(identityDomain.PermServicesWrite)).Put("/services/{id}", serviceHandler.Update)
+				sr.With(can(identityDomain.PermServicesDelete), mfa).Delete("/services/{id}", serviceHandler.Delete)
+				sr.With(can(identityDomain.PermServicesWrite)).Post("/maintenance-plans", maintenancePlanHandler.Create)
+				sr.With(can(identityDomain.PermServicesRead)).Get("/maintenance-plans", maintenancePlanHandler.List)
+				sr.With(can(identityDomain.PermServicesDelete), mfa).Delete("/maintenance-plans/{id}", maintenancePlanHandler.Delete)
+
+				sr.With(can(identityDomain.PermOrdersWrite)).Post("/orders", orderHandler.Create)
+				sr.With(can(identityDomain.PermOrdersRead)).Get("/orders", orderHandler.List)
+				sr.With(can(identityDomain.PermOrdersRead)).Get("/orders/{id}", orderHandler.Get)
+				sr.With(can(identityDomain.PermOrdersApprove)).Patch("/orders/{id}/approve", orderHandler.Approve)
+				sr.With(can(identityDomain.PermOrdersWrite)).Post("/orders/{id}/diagnosis:start", orderHandler.StartDiagnosis)
+				sr.With(can(identityDomain.PermOrdersWrite)).Post("/orders/{id}/budget:send", orderHandler.SendBudget)
+				sr.With(can(identityDomain.PermOrdersWrite)).Post("/orders/{id}/finish", orderHandler.FinishOrder)
+				sr.With(can(identityDomain.PermOrdersWrite)).Post("/orders/{id}/deliver", orderHandler.DeliverOrder)
+				sr.With(can(identityDomain.PermOrdersCancel)).Post("/orders/{id}/cancel", orderHandler.CancelOrder)
+				sr.With(can(identityDomain.PermOrdersWrite)).Delete("/orders/{id}/items/{itemID}", orderHandler.RemoveItem)
+				sr.With(can(identityDomain.PermOrdersWrite), mfa).Patch("/orders/{id}/status", orderHandler.UpdateStatus)
+				sr.With(can(identityDomain.PermOrdersRead)).Get("/orders/{id}/history", orderHandler.History)
+				sr.With(can(identityDomain.PermOrdersRead)).Get("/orders/{id}/budget-approvals", orderHandler.BudgetApprovals)
+
+				sr.With(can(identityDomain.PermReportsRead)).Get("/reports/revenue", orderHandler.ReportRevenue)
+				sr.With(can(identityDomain.PermReportsRead)).Get("/reports/margin", orderHandler.ReportMargin)
+				sr.With(can(identityDomain.PermReportsRead)).Get("/reports/avg-execution-time", orderHandler.ReportAvgExecutionTime)
+
+				sr.With(can(identityDomain.PermUsersManage)).Get("/users", userHandler.List)
+				sr.With(can(identityDomain.PermUsersManage)).Get("/users/{id}", userHandler.Get)
+				sr.With(can(identityDomain.PermUsersManage), mfa).Post("/users/{id}/deactivate", userHandler.Deactivate)
+				sr.With(can(identityDomain.PermUsersManage), mfa).Post("/users/{id}/reactivate", userHandler.Reactivate)
+				sr.With(can(identityDomain.PermUsersManage), mfa).Put("/users/{id}/role", userHandler.ChangeRole)
+				sr.With(can(identityDomain.PermUsersManage), mfa).Post("/users/{id}/password", userHandler.ResetPassword)
+				sr.With(can(identityDomain.PermUsersManage), mfa).Post("/users/{id}/unlock", userHandler.Unlock)
+				sr.With(can(identityDomain.PermUsersManage), mfa).Post("/users/{id}/mfa/reset", userHandler.ResetMFA)
 
 				return sr
 			}())
+
+			// The customer portal. Each route is scoped to the client of
+			// the signed-in customer account.
+			r.Mount("/portal", func() http.Handler {
+				sr := chi.NewRouter()
+				sr.Use(portalHandler.RequireCustomer)
+				sr.Get("/me", portalHandler.Me)
+				sr.Get("/vehicles", portalHandler.Vehicles)
+				sr.Get("/orders", portalHandler.Orders)
+				sr.Get("/orders/{id}", portalHandler.Order)
+				sr.Get("/budgets", portalHandler.Budgets)
+				sr.Post("/budgets/{id}/response", portalHandler.RespondBudget)
+				sr.Get("/invoices", portalHandler.Invoices)
+				return sr
+			}())
 		})
 	})
 
+	// Clients are emailed when a planned service falls due on their vehicle.
+	if cfg.MaintenanceReminderInterval > 0 {
+		go jobs.Every(context.Background(), cfg.MaintenanceReminderInterval, func(ctx context.Context) {
+			sent, err := maintenanceService.SendReminders(ctx, time.Now())
+			if err != nil {
+				log.Printf("Failed to send maintenance reminders: %v", err)
+				return
+			}
+			log.Printf("Sent %d maintenance reminders", sent)
+		})
+	}
+
 	log.Printf("Starting server on port %s", cfg.Port)
 	if err := http.ListenAndServe(":"+cfg.Port, r); err != nil {
 		log.Fatalf("Server failed: %v", err)
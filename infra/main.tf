@@ -208,7 +208,26 @@ resource "kubernetes_secret" "autorepair" {
 
   data = {
     DB_PASSWORD = var.db_password
-    JWT_SECRET  = var.jwt_secret
+  }
+
+  depends_on = [kubernetes_namespace.autorepair]
+}
+
+# Key pair the API signs JWTs with. Mounted as <kid>.pem; to rotate, add the
+# new key to this secret, point JWT_SIGNING_KEY at it and drop the old one
+# once the refresh tokens it signed have expired.
+resource "tls_private_key" "jwt" {
+  algorithm = "ED25519"
+}
+
+resource "kubernetes_secret" "jwt_keys" {
+  metadata {
+    name      = "autorepair-jwt-keys"
+    namespace = kubernetes_namespace.autorepair.metadata[0].name
+  }
+
+  data = {
+    "${var.jwt_signing_key_id}.pem" = tls_private_key.jwt.private_key_pem_pkcs8
   }
 
   depends_on = [kubernetes_namespace.autorepair]
@@ -221,12 +240,14 @@ resource "kubernetes_config_map" "autorepair" {
   }
 
   data = {
-    PORT       = "8080"
-    DB_HOST    = aws_db_instance.autorepair.address
-    DB_PORT    = "5432"
-    DB_NAME    = var.db_name
-    DB_USER    = var.db_username
-    DB_SSLMODE = "require"
+    PORT            = "8080"
+    DB_HOST         = aws_db_instance.autorepair.address
+    DB_PORT         = "5432"
+    DB_NAME         = var.db_name
+    DB_USER         = var.db_username
+    DB_SSLMODE      = "require"
+    APP_ENV         = "production"
+    JWT_SIGNING_KEY = var.jwt_signing_key_id
   }
 
   depends_on = [kubernetes_namespace.autorepair]
@@ -52,10 +52,10 @@ variable "db_instance_class" {
   default     = "db.t3.micro"
 }
 
-variable "jwt_secret" {
-  description = "JWT secret key for the application"
+variable "jwt_signing_key_id" {
+  description = "Key ID (kid) of the generated JWT signing key"
   type        = string
-  sensitive   = true
+  default     = "primary"
 }
 
 variable "node_instance_types" {
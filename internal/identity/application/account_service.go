@@ -0,0 +1,209 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/noggrj/autorepair/internal/identity/domain"
+	notificationDomain "github.com/noggrj/autorepair/internal/notification/domain"
+)
+
+// AccountService runs the flows a user completes through their mailbox:
+// resetting a forgotten password, verifying their email address and, for
+// customers, signing in to the portal. The tokens mailed out are
+// single-use and expire.
+type AccountService struct {
+	users    domain.UserRepository
+	tokens   domain.UserTokenRepository
+	sessions domain.RefreshTokenRepository
+	notifier notificationDomain.EmailService
+}
+
+func NewAccountService(users domain.UserRepository, tokens domain.UserTokenRepository, sessions domain.RefreshTokenRepository, notifier notificationDomain.EmailService) *AccountService {
+	return &AccountService{
+		users:    users,
+		tokens:   tokens,
+		sessions: sessions,
+		notifier: notifier,
+	}
+}
+
+// ForgotPassword mails a reset token to the user with the given email. It
+// succeeds without sending anything when no active user has that address,
+// so callers cannot use it to find out which accounts exist.
+func (s *AccountService) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.users.GetByEmail(ctx, email)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !user.IsActive() {
+		return nil
+	}
+
+	token, err := s.issue(ctx, user, domain.TokenPurposePasswordReset, domain.PasswordResetTokenTTL)
+	if err != nil {
+		return err
+	}
+	body := fmt.Sprintf("Hello %s, use this code to reset your password: %s\nIt expires in 1 hour. If you did not ask for it, ignore this email.", user.Name, token)
+	s.send(user, "Reset your password", body)
+	return nil
+}
+
+// ResetPassword redeems a reset token, sets the new password and ends all
+// the user's sessions. Receiving the token also proves the user controls
+// their address, so it verifies the email as well. A password that fails
+// the policy leaves the token unspent.
+func (s *AccountService) ResetPassword(ctx context.Context, token, password string) error {
+	stored, user, err := s.redeemable(ctx, token, domain.TokenPurposePasswordReset)
+	if err != nil {
+		return err
+	}
+	if err := user.SetPassword(password); err != nil {
+		return err
+	}
+	user.VerifyEmail()
+
+	if err := s.spend(ctx, stored); err != nil {
+		return err
+	}
+	if err := s.users.Update(ctx, user); err != nil {
+		return err
+	}
+	return s.sessions.RevokeUser(ctx, user.ID, time.Now())
+}
+
+// SendVerification mails an email verification token to the user. Users
+// whose address is already verified get nothing.
+func (s *AccountService) SendVerification(ctx context.Context, user *domain.User) error {
+	if user.IsEmailVerified() {
+		return nil
+	}
+
+	token, err := s.issue(ctx, user, domain.TokenPurposeEmailVerification, domain.EmailVerificationTokenTTL)
+	if err != nil {
+		return err
+	}
+	body := fmt.Sprintf("Hello %s, use this code to verify your email address: %s\nIt expires in 48 hours.", user.Name, token)
+	s.send(user, "Verify your email address", body)
+	return nil
+}
+
+// VerifyEmail redeems a verification token.
+func (s *AccountService) VerifyEmail(ctx context.Context, token string) error {
+	stored, user, err := s.redeemable(ctx, token, domain.TokenPurposeEmailVerification)
+	if err != nil {
+		return err
+	}
+	if err := s.spend(ctx, stored); err != nil {
+		return err
+	}
+	user.VerifyEmail()
+	return s.users.Update(ctx, user)
+}
+
+// SendMagicLink mails a sign-in token to the customer with the given email.
+// Like ForgotPassword it succeeds silently when there is no such active
+// customer; staff sign in with their password.
+func (s *AccountService) SendMagicLink(ctx context.Context, email string) error {
+	user, err := s.users.GetByEmail(ctx, email)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !user.IsActive() || !user.IsCustomer() {
+		return nil
+	}
+
+	token, err := s.issue(ctx, user, domain.TokenPurposeMagicLink, domain.MagicLinkTokenTTL)
+	if err != nil {
+		return err
+	}
+	body := fmt.Sprintf("Hello %s, use this code to sign in to the customer portal: %s\nIt expires in 15 minutes. If you did not ask for it, ignore this email.", user.Name, token)
+	s.send(user, "Sign in to the customer portal", body)
+	return nil
+}
+
+// RedeemMagicLink spends a sign-in token and returns the customer it was
+// mailed to, for the caller to start a session. Receiving it proves the
+// customer controls their address, so it verifies the email as well.
+func (s *AccountService) RedeemMagicLink(ctx context.Context, token string) (*domain.User, error) {
+	stored, user, err := s.redeemable(ctx, token, domain.TokenPurposeMagicLink)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsCustomer() {
+		return nil, domain.ErrInvalidUserToken
+	}
+	if err := s.spend(ctx, stored); err != nil {
+		return nil, err
+	}
+	if !user.IsEmailVerified() {
+		user.VerifyEmail()
+		if err := s.users.Update(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+func (s *AccountService) issue(ctx context.Context, user *domain.User, purpose domain.TokenPurpose, ttl time.Duration) (string, error) {
+	stored, token, err := domain.NewUserToken(user.ID, purpose, ttl)
+	if err != nil {
+		return "", err
+	}
+	if err := s.tokens.Save(ctx, stored); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// redeemable loads a token and its user, reporting every reason the token
+// cannot be used as ErrInvalidUserToken.
+func (s *AccountService) redeemable(ctx context.Context, token string, purpose domain.TokenPurpose) (*domain.UserToken, *domain.User, error) {
+	stored, err := s.tokens.GetByHash(ctx, domain.HashToken(token))
+	if errors.Is(err, domain.ErrUserTokenNotFound) {
+		return nil, nil, domain.ErrInvalidUserToken
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := s.users.GetByID(ctx, stored.UserID)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil, nil, domain.ErrInvalidUserToken
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if !user.IsActive() || !stored.Redeemable(purpose, user, time.Now()) {
+		return nil, nil, domain.ErrInvalidUserToken
+	}
+	return stored, user, nil
+}
+
+// spend marks the token used. Two requests racing with the same token: only
+// one may redeem it.
+func (s *AccountService) spend(ctx context.Context, stored *domain.UserToken) error {
+	spent, err := s.tokens.MarkUsed(ctx, stored.ID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !spent {
+		return domain.ErrInvalidUserToken
+	}
+	return nil
+}
+
+func (s *AccountService) send(user *domain.User, subject, body string) {
+	if err := s.notifier.SendEmail(user.Email, subject, body); err != nil {
+		// The token is stored; the user can ask for another email.
+		_ = err // ignore error
+	}
+}
@@ -0,0 +1,106 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/identity/domain"
+)
+
+// LoginGuard throttles password logins. Failures are counted per email and
+// per client IP, each under its own policy; a key that keeps failing has to
+// wait longer and longer between attempts and is eventually locked out.
+type LoginGuard struct {
+	attempts    domain.LoginAttemptRepository
+	audit       domain.AuditLog
+	emailPolicy domain.LoginPolicy
+	ipPolicy    domain.LoginPolicy
+}
+
+func NewLoginGuard(attempts domain.LoginAttemptRepository, audit domain.AuditLog) *LoginGuard {
+	return &LoginGuard{
+		attempts:    attempts,
+		audit:       audit,
+		emailPolicy: domain.EmailLoginPolicy,
+		ipPolicy:    domain.IPLoginPolicy,
+	}
+}
+
+// Allow reports how long a login for email from ip must wait, zero when it
+// may go ahead.
+func (g *LoginGuard) Allow(ctx context.Context, email, ip string) (time.Duration, error) {
+	now := time.Now()
+	var wait time.Duration
+	for _, c := range g.counters(email, ip) {
+		a, err := g.attempts.Get(ctx, c.scope, c.key)
+		if err != nil {
+			return 0, err
+		}
+		wait = max(wait, c.policy.RetryAfter(a, now))
+	}
+	return wait, nil
+}
+
+// Fail records a failed login, locking the email or IP once its policy says
+// so. Emails that match no user are counted too, so a lockout does not tell
+// which accounts exist.
+func (g *LoginGuard) Fail(ctx context.Context, email, ip string) error {
+	now := time.Now()
+	for _, c := range g.counters(email, ip) {
+		a, err := g.attempts.RecordFailure(ctx, c.scope, c.key, now, c.policy.Window)
+		if err != nil {
+			return err
+		}
+		// Lock only on the failure that crosses the threshold, so the
+		// lockout is audited once.
+		if a.Failures != c.policy.LockAfter {
+			continue
+		}
+		if err := g.attempts.Lock(ctx, c.scope, c.key, now.Add(c.policy.LockFor)); err != nil {
+			return err
+		}
+		detail := fmt.Sprintf("%s locked for %s after %d failed logins", c.scope, c.policy.LockFor, a.Failures)
+		if err := g.audit.Record(ctx, domain.NewAuditEntry(domain.AuditLoginLocked, nil, c.key, ip, detail)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Succeed clears the failures of the email that just logged in. The IP's
+// count is left alone: one valid account must not reset a spraying run.
+func (g *LoginGuard) Succeed(ctx context.Context, email string) error {
+	return g.attempts.Reset(ctx, domain.LoginScopeEmail, normalizeLoginEmail(email))
+}
+
+// Unlock lifts an admin-requested lockout of email and audits it.
+func (g *LoginGuard) Unlock(ctx context.Context, actorID uuid.UUID, email, ip string) error {
+	email = normalizeLoginEmail(email)
+	if err := g.attempts.Reset(ctx, domain.LoginScopeEmail, email); err != nil {
+		return err
+	}
+	return g.audit.Record(ctx, domain.NewAuditEntry(domain.AuditLoginUnlocked, &actorID, email, ip, "unlocked by admin"))
+}
+
+type loginCounter struct {
+	scope  domain.LoginScope
+	key    string
+	policy domain.LoginPolicy
+}
+
+func (g *LoginGuard) counters(email, ip string) []loginCounter {
+	counters := []loginCounter{{domain.LoginScopeEmail, normalizeLoginEmail(email), g.emailPolicy}}
+	if ip != "" {
+		counters = append(counters, loginCounter{domain.LoginScopeIP, ip, g.ipPolicy})
+	}
+	return counters
+}
+
+// normalizeLoginEmail matches the case-insensitive lookup of GetByEmail, so
+// changing the case of an address does not start a fresh count.
+func normalizeLoginEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,136 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/noggrj/autorepair/internal/identity/domain"
+	"github.com/noggrj/autorepair/internal/platform/auth"
+)
+
+// MFAIssuer names the account in the user's authenticator app.
+const MFAIssuer = "AutoRepair"
+
+// MFAEnrolment is what a user needs to add their account to an
+// authenticator app: the secret, and the URI to show as a QR code.
+type MFAEnrolment struct {
+	Secret          string
+	ProvisioningURI string
+}
+
+// MFAService runs TOTP two-factor authentication: enrolling an
+// authenticator app, checking its codes and the recovery codes handed out
+// when it is enabled.
+type MFAService struct {
+	users domain.UserRepository
+	codes domain.RecoveryCodeRepository
+}
+
+func NewMFAService(users domain.UserRepository, codes domain.RecoveryCodeRepository) *MFAService {
+	return &MFAService{
+		users: users,
+		codes: codes,
+	}
+}
+
+// Enrol gives the user a new secret. Two-factor authentication stays off
+// until Confirm sees a code generated from it.
+func (s *MFAService) Enrol(ctx context.Context, user *domain.User) (*MFAEnrolment, error) {
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := user.StartMFAEnrolment(secret); err != nil {
+		return nil, err
+	}
+	if err := s.users.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	return &MFAEnrolment{
+		Secret:          secret,
+		ProvisioningURI: auth.TOTPProvisioningURI(secret, MFAIssuer, user.Email),
+	}, nil
+}
+
+// Confirm turns two-factor authentication on when code matches the secret
+// from Enrol, and returns the recovery codes. They are shown only here.
+func (s *MFAService) Confirm(ctx context.Context, user *domain.User, code string) ([]string, error) {
+	if user.MFAEnabled() {
+		return nil, domain.ErrMFAAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, domain.ErrMFANotEnrolled
+	}
+	ok, err := s.checkTOTP(ctx, user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, domain.ErrInvalidMFACode
+	}
+	if err := user.EnableMFA(); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := domain.NewRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.codes.Replace(ctx, user.ID, hashes); err != nil {
+		return nil, err
+	}
+	if err := s.users.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Verify checks the second factor of a login: a code from the
+// authenticator app or an unused recovery code, which is then spent.
+func (s *MFAService) Verify(ctx context.Context, user *domain.User, code string) error {
+	if !user.MFAEnabled() {
+		return domain.ErrMFANotEnrolled
+	}
+	ok, err := s.checkTOTP(ctx, user, code)
+	if err != nil || ok {
+		return err
+	}
+
+	used, err := s.codes.Use(ctx, user.ID, domain.HashRecoveryCode(code), time.Now())
+	if err != nil {
+		return err
+	}
+	if !used {
+		return domain.ErrInvalidMFACode
+	}
+	return nil
+}
+
+// Disable turns two-factor authentication off after checking a current
+// code. Roles that require it cannot turn it off; an admin can reset it
+// through UserService.ResetMFA instead.
+func (s *MFAService) Disable(ctx context.Context, user *domain.User, code string) error {
+	if user.Role.RequiresMFA() {
+		return domain.ErrMFARequired
+	}
+	if err := s.Verify(ctx, user, code); err != nil {
+		return err
+	}
+	user.DisableMFA()
+	if err := s.codes.Replace(ctx, user.ID, nil); err != nil {
+		return err
+	}
+	return s.users.Update(ctx, user)
+}
+
+// checkTOTP reports whether code is a current TOTP code the user has not
+// used yet, recording its time step on the user and in the repository. The
+// repository has the last word, so a code replayed by a concurrent login is
+// refused.
+func (s *MFAService) checkTOTP(ctx context.Context, user *domain.User, code string) (bool, error) {
+	step, ok := auth.ValidateTOTP(user.TOTPSecret, code, time.Now())
+	if !ok || !user.AcceptTOTPStep(step) {
+		return false, nil
+	}
+	return s.users.UseTOTPStep(ctx, user.ID, step)
+}
@@ -0,0 +1,147 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/identity/domain"
+	"github.com/noggrj/autorepair/internal/platform/auth"
+)
+
+// TokenPair is what a login or a refresh hands back to the client.
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    int64
+}
+
+// TokenService issues access and refresh tokens and keeps the server-side
+// record of refresh tokens that makes rotation and revocation possible.
+type TokenService struct {
+	users  domain.UserRepository
+	tokens domain.RefreshTokenRepository
+}
+
+func NewTokenService(users domain.UserRepository, tokens domain.RefreshTokenRepository) *TokenService {
+	return &TokenService{
+		users:  users,
+		tokens: tokens,
+	}
+}
+
+// Issue starts a new session for an authenticated user on a device. amr
+// lists the methods the user authenticated with, see auth.AMRPassword.
+// Deactivated users get ErrUserDeactivated, here and on Refresh.
+func (s *TokenService) Issue(ctx context.Context, user *domain.User, device string, amr []string) (*TokenPair, error) {
+	return s.issue(ctx, user, uuid.New(), device, amr)
+}
+
+// Refresh exchanges a refresh token for a new pair. The presented token is
+// spent: presenting it again is taken as theft and revokes every token of
+// its family, including the one issued here. Tokens issued before the
+// user's last password change are refused.
+func (s *TokenService) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	stored, err := s.lookup(ctx, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	if stored.RevokedAt != nil {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+	if stored.UsedAt != nil {
+		return nil, s.reused(ctx, stored)
+	}
+
+	// Two requests racing with the same token: only one may rotate it.
+	rotated, err := s.tokens.MarkUsed(ctx, stored.ID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		return nil, s.reused(ctx, stored)
+	}
+
+	user, err := s.users.GetByID(ctx, stored.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IssuedBeforePasswordChange(stored.CreatedAt) {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+	return s.issue(ctx, user, stored.FamilyID, stored.Device, stored.AMR)
+}
+
+// Logout ends the session the refresh token belongs to.
+func (s *TokenService) Logout(ctx context.Context, refreshToken string) error {
+	stored, err := s.lookup(ctx, refreshToken)
+	if err != nil {
+		return err
+	}
+	return s.tokens.RevokeFamily(ctx, stored.FamilyID, time.Now())
+}
+
+// LogoutAll ends every session of a user. Access tokens already handed out
+// stay valid until they expire.
+func (s *TokenService) LogoutAll(ctx context.Context, userID uuid.UUID) error {
+	return s.tokens.RevokeUser(ctx, userID, time.Now())
+}
+
+func (s *TokenService) issue(ctx context.Context, user *domain.User, familyID uuid.UUID, device string, amr []string) (*TokenPair, error) {
+	if !user.IsActive() {
+		return nil, domain.ErrUserDeactivated
+	}
+
+	accessToken, expiresIn, err := auth.GenerateAccessToken(user.ID, string(user.Role), amr)
+	if err != nil {
+		return nil, err
+	}
+
+	jti := uuid.New()
+	refreshToken, expiresAt, err := auth.GenerateRefreshToken(user.ID, jti)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tokens.Save(ctx, domain.NewRefreshToken(jti, user.ID, familyID, refreshToken, device, amr, expiresAt)); err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    expiresIn,
+	}, nil
+}
+
+// lookup validates a refresh token and loads its record. Every way a token
+// can fail to match a record is reported as ErrInvalidRefreshToken.
+func (s *TokenService) lookup(ctx context.Context, refreshToken string) (*domain.RefreshToken, error) {
+	claims, err := auth.ValidateRefreshToken(refreshToken)
+	if err != nil {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+	jti, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	stored, err := s.tokens.GetByID(ctx, jti)
+	if err != nil {
+		if errors.Is(err, domain.ErrRefreshTokenNotFound) {
+			return nil, domain.ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	if stored.UserID != claims.UserID || !stored.Matches(refreshToken) {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+	return stored, nil
+}
+
+func (s *TokenService) reused(ctx context.Context, stored *domain.RefreshToken) error {
+	if err := s.tokens.RevokeFamily(ctx, stored.FamilyID, time.Now()); err != nil {
+		return err
+	}
+	return domain.ErrRefreshTokenReused
+}
@@ -0,0 +1,108 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/identity/domain"
+)
+
+// ErrSelfModification is returned when an admin tries to deactivate or change
+// the role of their own account, which could leave the shop without an admin.
+var ErrSelfModification = errors.New("admins cannot deactivate or change the role of their own account")
+
+// UserService is the admin side of the user lifecycle. Changes that should
+// end a user's sessions revoke their refresh tokens; access tokens already
+// handed out stay valid until they expire.
+type UserService struct {
+	users  domain.UserRepository
+	tokens domain.RefreshTokenRepository
+}
+
+func NewUserService(users domain.UserRepository, tokens domain.RefreshTokenRepository) *UserService {
+	return &UserService{
+		users:  users,
+		tokens: tokens,
+	}
+}
+
+// CreateCustomer opens a portal account for a client. Each client has at
+// most one; a second gets ErrClientAccountExists.
+func (s *UserService) CreateCustomer(ctx context.Context, clientID uuid.UUID, name, email string) (*domain.User, error) {
+	user, err := domain.NewCustomer(clientID, name, email)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.users.Save(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Deactivate switches the account off and ends all its sessions.
+func (s *UserService) Deactivate(ctx context.Context, actorID, id uuid.UUID) (*domain.User, error) {
+	if actorID == id {
+		return nil, ErrSelfModification
+	}
+	return s.update(ctx, id, true, func(u *domain.User) error {
+		u.Deactivate()
+		return nil
+	})
+}
+
+func (s *UserService) Reactivate(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	return s.update(ctx, id, false, func(u *domain.User) error {
+		u.Reactivate()
+		return nil
+	})
+}
+
+// ChangeRole takes effect on the user's next login or refresh.
+func (s *UserService) ChangeRole(ctx context.Context, actorID, id uuid.UUID, role domain.Role) (*domain.User, error) {
+	if actorID == id {
+		return nil, ErrSelfModification
+	}
+	return s.update(ctx, id, false, func(u *domain.User) error {
+		return u.ChangeRole(role)
+	})
+}
+
+// ResetPassword sets a new password and ends all the user's sessions.
+func (s *UserService) ResetPassword(ctx context.Context, id uuid.UUID, password string) (*domain.User, error) {
+	return s.update(ctx, id, true, func(u *domain.User) error {
+		return u.SetPassword(password)
+	})
+}
+
+// ResetMFA turns two-factor authentication off for a user who lost their
+// authenticator app and recovery codes, and ends all their sessions. They
+// enrol again on their next login. Leftover recovery codes are dead: they
+// are only checked while two-factor authentication is on, and replaced
+// when it is turned back on.
+func (s *UserService) ResetMFA(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	return s.update(ctx, id, true, func(u *domain.User) error {
+		u.DisableMFA()
+		return nil
+	})
+}
+
+func (s *UserService) update(ctx context.Context, id uuid.UUID, revokeSessions bool, change func(*domain.User) error) (*domain.User, error) {
+	user, err := s.users.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := change(user); err != nil {
+		return nil, err
+	}
+	if err := s.users.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	if revokeSessions {
+		if err := s.tokens.RevokeUser(ctx, user.ID, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
@@ -0,0 +1,199 @@
+package http
+
+import (
+	"encoding/json"
+	stdErrors "errors"
+	"net/http"
+
+	"github.com/noggrj/autorepair/internal/identity/domain"
+	"github.com/noggrj/autorepair/internal/platform/auth"
+	"github.com/noggrj/autorepair/internal/platform/errors"
+)
+
+type forgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+type magicLinkRequest struct {
+	Email string `json:"email"`
+}
+
+type magicLinkLoginRequest struct {
+	Token  string `json:"token"`
+	Device string `json:"device,omitempty"`
+}
+
+// ForgotPassword godoc
+// @Summary Forgot password
+// @Description Mail a password reset code, valid for 1 hour, to the user with this email. The answer is the same whether or not the email belongs to a user.
+// @Tags auth
+// @Accept json
+// @Param request body forgotPasswordRequest true "Forgot Password Request"
+// @Success 202
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /auth/password/forgot [post]
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req forgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.BadRequest(w, "invalid request body")
+		return
+	}
+
+	if err := h.accounts.ForgotPassword(r.Context(), req.Email); err != nil {
+		errors.InternalServerError(w, "failed to start password reset")
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description Set a new password with a code from a password reset email. The code works once, and all the user's sessions are revoked.
+// @Tags auth
+// @Accept json
+// @Param request body resetPasswordRequest true "Reset Password Request"
+// @Success 204
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /auth/password/reset [post]
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req resetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.BadRequest(w, "invalid request body")
+		return
+	}
+
+	if err := h.accounts.ResetPassword(r.Context(), req.Token, req.Password); err != nil {
+		writeAccountError(w, err, "failed to reset password")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// VerifyEmail godoc
+// @Summary Verify email
+// @Description Confirm the user's email address with a code from a verification email
+// @Tags auth
+// @Accept json
+// @Param request body verifyEmailRequest true "Verify Email Request"
+// @Success 204
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /auth/email/verify [post]
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req verifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.BadRequest(w, "invalid request body")
+		return
+	}
+
+	if err := h.accounts.VerifyEmail(r.Context(), req.Token); err != nil {
+		writeAccountError(w, err, "failed to verify email")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SendVerification godoc
+// @Summary Resend verification email
+// @Description Mail a new email verification code, valid for 48 hours, to the authenticated user. Nothing is sent when the address is already verified.
+// @Tags auth
+// @Security BearerAuth
+// @Success 202
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /auth/email/verification [post]
+func (h *AuthHandler) SendVerification(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.accounts.SendVerification(r.Context(), user); err != nil {
+		errors.InternalServerError(w, "failed to send verification email")
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// SendMagicLink godoc
+// @Summary Request a customer portal sign-in code
+// @Description Mail a sign-in code, valid for 15 minutes, to the customer with this email. The answer is the same whether or not the email belongs to a customer; staff sign in with their password.
+// @Tags auth
+// @Accept json
+// @Param request body magicLinkRequest true "Magic Link Request"
+// @Success 202
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /auth/magic-link [post]
+func (h *AuthHandler) SendMagicLink(w http.ResponseWriter, r *http.Request) {
+	var req magicLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.BadRequest(w, "invalid request body")
+		return
+	}
+
+	if err := h.accounts.SendMagicLink(r.Context(), req.Email); err != nil {
+		errors.InternalServerError(w, "failed to send sign-in code")
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// LoginMagicLink godoc
+// @Summary Sign in to the customer portal
+// @Description Exchange a code from a sign-in email for a token pair. The code works once. Customers with two-factor authentication get 202 and an mfa_token to finish at /auth/login/mfa.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body magicLinkLoginRequest true "Magic Link Login Request"
+// @Success 200 {object} loginResponse
+// @Success 202 {object} mfaChallengeResponse
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /auth/magic-link/login [post]
+func (h *AuthHandler) LoginMagicLink(w http.ResponseWriter, r *http.Request) {
+	var req magicLinkLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.BadRequest(w, "invalid request body")
+		return
+	}
+
+	user, err := h.accounts.RedeemMagicLink(r.Context(), req.Token)
+	if err != nil {
+		writeAccountError(w, err, "failed to sign in")
+		return
+	}
+
+	if user.MFAEnabled() {
+		h.challengeMFA(w, user)
+		return
+	}
+	h.startSession(w, r, user, req.Device, []string{auth.AMRMagicLink})
+}
+
+// writeAccountError maps AccountService errors to HTTP responses.
+func writeAccountError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case stdErrors.Is(err, domain.ErrInvalidUserToken), stdErrors.Is(err, domain.ErrWeakPassword):
+		errors.BadRequest(w, err.Error())
+	default:
+		errors.InternalServerError(w, fallback)
+	}
+}
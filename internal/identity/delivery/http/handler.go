@@ -2,20 +2,30 @@ package http
 
 import (
 	"encoding/json"
+	stdErrors "errors"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/noggrj/autorepair/internal/identity/application"
 	"github.com/noggrj/autorepair/internal/identity/domain"
 	"github.com/noggrj/autorepair/internal/platform/auth"
 	"github.com/noggrj/autorepair/internal/platform/errors"
+	"github.com/noggrj/autorepair/internal/platform/middleware"
 )
 
 type AuthHandler struct {
-	repo domain.UserRepository
+	repo     domain.UserRepository
+	tokens   *application.TokenService
+	guard    *application.LoginGuard
+	accounts *application.AccountService
+	mfa      *application.MFAService
 }
 
-func NewAuthHandler(repo domain.UserRepository) *AuthHandler {
-	return &AuthHandler{repo: repo}
+func NewAuthHandler(repo domain.UserRepository, tokens *application.TokenService, guard *application.LoginGuard, accounts *application.AccountService, mfa *application.MFAService) *AuthHandler {
+	return &AuthHandler{repo: repo, tokens: tokens, guard: guard, accounts: accounts, mfa: mfa}
 }
 
 type registerRequest struct {
@@ -28,17 +38,23 @@ type registerRequest struct {
 type loginRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	// Device names the session; the User-Agent header is used when empty.
+	Device string `json:"device,omitempty"`
 }
 
 // Register godoc
 // @Summary Register a new user
-// @Description Register a new user with name, email, password and role
+// @Description Create a user with name, email, password and role. Only admins can register users. The new user is mailed a code to verify their email address.
 // @Tags auth
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param request body registerRequest true "Register Request"
 // @Success 201 {object} domain.User
 // @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Failure 409 {object} errors.ErrorResponse
 // @Failure 500 {object} errors.ErrorResponse
 // @Router /auth/register [post]
 func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
@@ -50,15 +66,24 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 
 	user, err := domain.NewUser(req.Name, req.Email, req.Password, domain.Role(req.Role))
 	if err != nil {
-		errors.InternalServerError(w, err.Error())
+		errors.BadRequest(w, err.Error())
 		return
 	}
 
-	if err := h.repo.Save(user); err != nil {
+	if err := h.repo.Save(r.Context(), user); err != nil {
+		if stdErrors.Is(err, domain.ErrEmailTaken) {
+			errors.Conflict(w, err.Error())
+			return
+		}
 		errors.InternalServerError(w, "failed to save user")
 		return
 	}
 
+	if err := h.accounts.SendVerification(r.Context(), user); err != nil {
+		// The user exists; they can ask for another verification email.
+		_ = err // ignore error
+	}
+
 	w.WriteHeader(http.StatusCreated)
 	if err := json.NewEncoder(w).Encode(user); err != nil {
 		errors.InternalServerError(w, "failed to encode response")
@@ -70,17 +95,23 @@ type loginResponse struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
 	ExpiresIn    int64  `json:"expires_in"`
+	// MFAEnrolmentRequired tells an admin or manager without two-factor
+	// authentication to enrol before using sensitive routes.
+	MFAEnrolmentRequired bool `json:"mfa_enrolment_required,omitempty"`
 }
 
 // Login godoc
 // @Summary Login
-// @Description Login with email and password to get a JWT token
+// @Description Login with email and password to get a JWT token. Users with two-factor authentication get 202 and an mfa_token instead, valid for 5 minutes, to finish at /auth/login/mfa. Repeated failures for an email or from an IP are answered with 429 and a Retry-After header, first for a few seconds and then, after too many, for 15 minutes.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param request body loginRequest true "Login Request"
 // @Success 200 {object} loginResponse
+// @Success 202 {object} mfaChallengeResponse
 // @Failure 401 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Failure 429 {object} errors.ErrorResponse
 // @Failure 500 {object} errors.ErrorResponse
 // @Router /auth/login [post]
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
@@ -90,46 +121,103 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.repo.GetByEmail(req.Email)
+	ip := middleware.ClientIP(r)
+	wait, err := h.guard.Allow(r.Context(), req.Email, ip)
 	if err != nil {
-		errors.Unauthorized(w, "invalid credentials")
+		errors.InternalServerError(w, "failed to check login attempts")
+		return
+	}
+	if wait > 0 {
+		tooManyAttempts(w, wait)
 		return
 	}
 
-	if !user.CheckPassword(req.Password) {
+	user, err := h.repo.GetByEmail(r.Context(), req.Email)
+	if err != nil || !user.CheckPassword(req.Password) {
+		if err := h.guard.Fail(r.Context(), req.Email, ip); err != nil {
+			errors.InternalServerError(w, "failed to record login attempt")
+			return
+		}
 		errors.Unauthorized(w, "invalid credentials")
 		return
 	}
 
-	accessToken, refreshToken, expiresIn, err := auth.GenerateToken(user.ID, string(user.Role))
+	if user.MFAEnabled() {
+		// The failures are only cleared once the second factor is passed,
+		// so a leaked password does not buy unlimited tries at the code.
+		h.challengeMFA(w, user)
+		return
+	}
+
+	if err := h.guard.Succeed(r.Context(), req.Email); err != nil {
+		errors.InternalServerError(w, "failed to record login attempt")
+		return
+	}
+
+	h.startSession(w, r, user, req.Device, []string{auth.AMRPassword})
+}
+
+// challengeMFA answers a correct password for a user with two-factor
+// authentication with the token that lets them finish at LoginMFA.
+func (h *AuthHandler) challengeMFA(w http.ResponseWriter, user *domain.User) {
+	if !user.IsActive() {
+		errors.Forbidden(w, domain.ErrUserDeactivated.Error())
+		return
+	}
+
+	token, expiresIn, err := auth.GenerateMFAChallengeToken(user.ID)
 	if err != nil {
 		errors.InternalServerError(w, "failed to generate token")
 		return
 	}
 
-	if err := json.NewEncoder(w).Encode(loginResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresIn:    expiresIn,
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(mfaChallengeResponse{
+		MFARequired: true,
+		MFAToken:    token,
+		ExpiresIn:   expiresIn,
 	}); err != nil {
 		errors.InternalServerError(w, "failed to encode response")
 		return
 	}
 }
 
+// startSession issues the token pair of a completed login. The device
+// defaults to the User-Agent header.
+func (h *AuthHandler) startSession(w http.ResponseWriter, r *http.Request, user *domain.User, device string, amr []string) {
+	if device == "" {
+		device = r.UserAgent()
+	}
+
+	pair, err := h.tokens.Issue(r.Context(), user, device, amr)
+	if err != nil {
+		if stdErrors.Is(err, domain.ErrUserDeactivated) {
+			errors.Forbidden(w, err.Error())
+			return
+		}
+		errors.InternalServerError(w, "failed to generate token")
+		return
+	}
+
+	resp := newLoginResponse(pair)
+	resp.MFAEnrolmentRequired = user.Role.RequiresMFA() && !user.MFAEnabled()
+	writeLoginResponse(w, resp)
+}
+
 type refreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
 // Refresh godoc
 // @Summary Refresh Access Token
-// @Description Use a refresh token to get a new access token
+// @Description Exchange a refresh token for a new access and refresh token. Each refresh token can be used once; reusing one revokes the whole session.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param request body refreshTokenRequest true "Refresh Token Request"
 // @Success 200 {object} loginResponse
 // @Failure 401 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
 // @Failure 500 {object} errors.ErrorResponse
 // @Router /auth/refresh [post]
 func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
@@ -139,32 +227,135 @@ func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	claims, err := auth.ValidateToken(req.RefreshToken)
+	pair, err := h.tokens.Refresh(r.Context(), req.RefreshToken)
 	if err != nil {
-		errors.Unauthorized(w, "invalid refresh token")
+		writeRefreshError(w, err)
 		return
 	}
 
-	// In a real app, we might check if the user is still active or if token is revoked here.
+	writeTokenPair(w, pair)
+}
 
-	accessToken, refreshToken, expiresIn, err := auth.GenerateToken(claims.UserID, claims.Role)
-	if err != nil {
-		errors.InternalServerError(w, "failed to generate token")
+// Logout godoc
+// @Summary Logout
+// @Description Revoke the session the refresh token belongs to
+// @Tags auth
+// @Accept json
+// @Param request body refreshTokenRequest true "Refresh Token Request"
+// @Success 204
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /auth/logout [post]
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	var req refreshTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.BadRequest(w, "invalid request body")
 		return
 	}
 
-	if err := json.NewEncoder(w).Encode(loginResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresIn:    expiresIn,
-	}); err != nil {
+	if err := h.tokens.Logout(r.Context(), req.RefreshToken); err != nil {
+		writeRefreshError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LogoutAll godoc
+// @Summary Logout from all devices
+// @Description Revoke every session of the authenticated user. Access tokens already issued remain valid until they expire.
+// @Tags auth
+// @Security BearerAuth
+// @Success 204
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /auth/logout-all [post]
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(middleware.UserContextKey).(*auth.Claims)
+	if !ok {
+		errors.Unauthorized(w, "user not authenticated")
+		return
+	}
+
+	if err := h.tokens.LogoutAll(r.Context(), claims.UserID); err != nil {
+		errors.InternalServerError(w, "failed to revoke sessions")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// JWKS godoc
+// @Summary JSON Web Key Set
+// @Description Public keys that verify the tokens this API issues, identified by the kid header. Keys being rotated out stay listed until their tokens expire.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} auth.JWKS
+// @Router /.well-known/jwks.json [get]
+func (h *AuthHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	if err := json.NewEncoder(w).Encode(auth.PublicKeys()); err != nil {
+		errors.InternalServerError(w, "failed to encode response")
+		return
+	}
+}
+
+func newLoginResponse(pair *application.TokenPair) loginResponse {
+	return loginResponse{
+		AccessToken:  pair.AccessToken,
+		RefreshToken: pair.RefreshToken,
+		ExpiresIn:    pair.ExpiresIn,
+	}
+}
+
+func writeTokenPair(w http.ResponseWriter, pair *application.TokenPair) {
+	writeLoginResponse(w, newLoginResponse(pair))
+}
+
+func writeLoginResponse(w http.ResponseWriter, resp loginResponse) {
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
 		errors.InternalServerError(w, "failed to encode response")
 		return
 	}
 }
 
+// tooManyAttempts tells a throttled client how many whole seconds to wait.
+func tooManyAttempts(w http.ResponseWriter, wait time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	errors.TooManyRequests(w, "too many failed login attempts, try again later")
+}
+
+// writeRefreshError maps TokenService errors to HTTP responses.
+func writeRefreshError(w http.ResponseWriter, err error) {
+	switch {
+	case stdErrors.Is(err, domain.ErrRefreshTokenReused):
+		errors.Unauthorized(w, "refresh token reuse detected, session revoked")
+	case stdErrors.Is(err, domain.ErrInvalidRefreshToken):
+		errors.Unauthorized(w, "invalid refresh token")
+	case stdErrors.Is(err, domain.ErrUserDeactivated):
+		errors.Forbidden(w, err.Error())
+	default:
+		errors.InternalServerError(w, "failed to refresh token")
+	}
+}
+
 func (h *AuthHandler) RegisterRoutes(r chi.Router) {
-	r.Post("/register", h.Register)
+	r.With(middleware.AuthMiddleware, RequirePermission(domain.PermUsersManage), RequireMFA).Post("/register", h.Register)
 	r.Post("/login", h.Login)
+	r.Post("/login/mfa", h.LoginMFA)
 	r.Post("/refresh", h.Refresh)
+	r.Post("/logout", h.Logout)
+	r.With(middleware.AuthMiddleware).Post("/logout-all", h.LogoutAll)
+	r.With(middleware.AuthMiddleware).Get("/me/permissions", h.Permissions)
+	r.Post("/password/forgot", h.ForgotPassword)
+	r.Post("/password/reset", h.ResetPassword)
+	r.Post("/email/verify", h.VerifyEmail)
+	r.Post("/magic-link", h.SendMagicLink)
+	r.Post("/magic-link/login", h.LoginMagicLink)
+	r.With(middleware.AuthMiddleware).Post("/email/verification", h.SendVerification)
+	r.With(middleware.AuthMiddleware).Post("/mfa/enrol", h.EnrolMFA)
+	r.With(middleware.AuthMiddleware).Post("/mfa/confirm", h.ConfirmMFA)
+	r.With(middleware.AuthMiddleware).Post("/mfa/disable", h.DisableMFA)
 }
@@ -2,16 +2,23 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/identity/application"
 	"github.com/noggrj/autorepair/internal/identity/domain"
+	"github.com/noggrj/autorepair/internal/identity/infrastructure"
+	notificationInfra "github.com/noggrj/autorepair/internal/notification/infrastructure"
 	"github.com/noggrj/autorepair/internal/platform/auth"
+	"github.com/noggrj/autorepair/internal/platform/middleware"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -34,31 +41,143 @@ type MockUserRepository struct {
 	mock.Mock
 }
 
-func (m *MockUserRepository) Save(user *domain.User) error {
-	args := m.Called(user)
+func (m *MockUserRepository) Save(ctx context.Context, user *domain.User) error {
+	args := m.Called(ctx, user)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) GetByEmail(email string) (*domain.User, error) {
-	args := m.Called(email)
+func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	args := m.Called(ctx, email)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*domain.User), args.Error(1)
 }
 
-func (m *MockUserRepository) GetByID(id uuid.UUID) (*domain.User, error) {
-	args := m.Called(id)
+func (m *MockUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*domain.User), args.Error(1)
 }
 
+func (m *MockUserRepository) Update(ctx context.Context, user *domain.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*domain.User], error) {
+	args := m.Called(ctx, q)
+	return args.Get(0).(sharedkernel.Page[*domain.User]), args.Error(1)
+}
+
+func (m *MockUserRepository) ListByRole(ctx context.Context, role domain.Role) ([]*domain.User, error) {
+	args := m.Called(ctx, role)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) UseTOTPStep(ctx context.Context, id uuid.UUID, step int64) (bool, error) {
+	args := m.Called(ctx, id, step)
+	return args.Bool(0), args.Error(1)
+}
+
+type MockRefreshTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenRepository) Save(ctx context.Context, token *domain.RefreshToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.RefreshToken, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) MarkUsed(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	args := m.Called(ctx, id, at)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID, at time.Time) error {
+	args := m.Called(ctx, familyID, at)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) RevokeUser(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	args := m.Called(ctx, userID, at)
+	return args.Error(0)
+}
+
+type MockUserTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserTokenRepository) Save(ctx context.Context, token *domain.UserToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockUserTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*domain.UserToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.UserToken), args.Error(1)
+}
+
+func (m *MockUserTokenRepository) MarkUsed(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	args := m.Called(ctx, id, at)
+	return args.Bool(0), args.Error(1)
+}
+
+type MockRecoveryCodeRepository struct {
+	mock.Mock
+}
+
+func (m *MockRecoveryCodeRepository) Replace(ctx context.Context, userID uuid.UUID, hashes []string) error {
+	args := m.Called(ctx, userID, hashes)
+	return args.Error(0)
+}
+
+func (m *MockRecoveryCodeRepository) Use(ctx context.Context, userID uuid.UUID, hash string, at time.Time) (bool, error) {
+	args := m.Called(ctx, userID, hash, at)
+	return args.Bool(0), args.Error(1)
+}
+
+func newAuthHandler(users *MockUserRepository, tokens *MockRefreshTokenRepository) *AuthHandler {
+	guard := application.NewLoginGuard(infrastructure.NewMemoryLoginAttemptRepository(), infrastructure.NewMemoryAuditLog())
+	// Registration mails a verification token; tests that care about it
+	// build their own AccountService.
+	userTokens := new(MockUserTokenRepository)
+	userTokens.On("Save", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mailer := new(notificationInfra.MockEmailService)
+	mailer.On("SendEmail", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	accounts := application.NewAccountService(users, userTokens, tokens, mailer)
+	mfa := application.NewMFAService(users, new(MockRecoveryCodeRepository))
+	return NewAuthHandler(users, application.NewTokenService(users, tokens), guard, accounts, mfa)
+}
+
+// issueRefreshToken signs a refresh token and returns the record the server
+// would have stored for it.
+func issueRefreshToken(userID uuid.UUID) (string, *domain.RefreshToken) {
+	jti := uuid.New()
+	token, expiresAt, _ := auth.GenerateRefreshToken(userID, jti)
+	return token, domain.NewRefreshToken(jti, userID, uuid.New(), token, "test", nil, expiresAt)
+}
+
 func TestRegister(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		mockRepo := new(MockUserRepository)
-		handler := NewAuthHandler(mockRepo)
+		handler := newAuthHandler(mockRepo, new(MockRefreshTokenRepository))
 		reqBody := registerRequest{
 			Name:     "Test User",
 			Email:    "test@example.com",
@@ -69,17 +188,18 @@ func TestRegister(t *testing.T) {
 		req := httptest.NewRequest(http.MethodPost, "/auth/register", bytes.NewBuffer(body))
 		w := httptest.NewRecorder()
 
-		mockRepo.On("Save", mock.AnythingOfType("*domain.User")).Return(nil)
+		mockRepo.On("Save", mock.Anything, mock.AnythingOfType("*domain.User")).Return(nil)
 
 		handler.Register(w, req)
 
 		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.NotContains(t, w.Body.String(), `"Password"`, "the password hash must not be serialized")
 		mockRepo.AssertExpectations(t)
 	})
 
 	t.Run("Invalid Body", func(t *testing.T) {
 		mockRepo := new(MockUserRepository)
-		handler := NewAuthHandler(mockRepo)
+		handler := newAuthHandler(mockRepo, new(MockRefreshTokenRepository))
 		req := httptest.NewRequest(http.MethodPost, "/auth/register", bytes.NewBufferString("invalid json"))
 		w := httptest.NewRecorder()
 
@@ -91,7 +211,7 @@ func TestRegister(t *testing.T) {
 
 	t.Run("Empty Body", func(t *testing.T) {
 		mockRepo := new(MockUserRepository)
-		handler := NewAuthHandler(mockRepo)
+		handler := newAuthHandler(mockRepo, new(MockRefreshTokenRepository))
 		req := httptest.NewRequest(http.MethodPost, "/auth/register", bytes.NewBuffer([]byte{}))
 		w := httptest.NewRecorder()
 
@@ -103,7 +223,7 @@ func TestRegister(t *testing.T) {
 
 	t.Run("Invalid Field Type", func(t *testing.T) {
 		mockRepo := new(MockUserRepository)
-		handler := NewAuthHandler(mockRepo)
+		handler := newAuthHandler(mockRepo, new(MockRefreshTokenRepository))
 		reqBody := map[string]interface{}{
 			"name":  12345, // Should be string
 			"email": "test@example.com",
@@ -120,7 +240,7 @@ func TestRegister(t *testing.T) {
 
 	t.Run("Save Error", func(t *testing.T) {
 		mockRepo := new(MockUserRepository)
-		handler := NewAuthHandler(mockRepo)
+		handler := newAuthHandler(mockRepo, new(MockRefreshTokenRepository))
 		reqBody := registerRequest{
 			Name:     "Test User",
 			Email:    "test@example.com",
@@ -131,7 +251,7 @@ func TestRegister(t *testing.T) {
 		req := httptest.NewRequest(http.MethodPost, "/auth/register", bytes.NewBuffer(body))
 		w := httptest.NewRecorder()
 
-		mockRepo.On("Save", mock.AnythingOfType("*domain.User")).Return(errors.New("db error"))
+		mockRepo.On("Save", mock.Anything, mock.AnythingOfType("*domain.User")).Return(errors.New("db error"))
 
 		handler.Register(w, req)
 
@@ -139,9 +259,9 @@ func TestRegister(t *testing.T) {
 		assert.Contains(t, w.Body.String(), "failed to save user")
 	})
 
-	t.Run("NewUser Error", func(t *testing.T) {
+	t.Run("Password Too Long", func(t *testing.T) {
 		mockRepo := new(MockUserRepository)
-		handler := NewAuthHandler(mockRepo)
+		handler := newAuthHandler(mockRepo, new(MockRefreshTokenRepository))
 		// Password longer than 72 bytes causes bcrypt error
 		longPassword := "verylongpasswordverylongpasswordverylongpasswordverylongpasswordverylongpasswordverylongpassword"
 		reqBody := registerRequest{
@@ -156,12 +276,52 @@ func TestRegister(t *testing.T) {
 
 		handler.Register(w, req)
 
-		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Validation Error", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		handler := newAuthHandler(mockRepo, new(MockRefreshTokenRepository))
+		reqBody := registerRequest{
+			Name:     "Test User",
+			Email:    "test@example.com",
+			Password: "password123",
+			Role:     "superuser",
+		}
+		body, _ := json.Marshal(reqBody)
+		req := httptest.NewRequest(http.MethodPost, "/auth/register", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+
+		handler.Register(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "invalid role")
+		mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Email Taken", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		handler := newAuthHandler(mockRepo, new(MockRefreshTokenRepository))
+		reqBody := registerRequest{
+			Name:     "Test User",
+			Email:    "test@example.com",
+			Password: "password123",
+			Role:     "employee",
+		}
+		body, _ := json.Marshal(reqBody)
+		req := httptest.NewRequest(http.MethodPost, "/auth/register", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+
+		mockRepo.On("Save", mock.Anything, mock.AnythingOfType("*domain.User")).Return(domain.ErrEmailTaken)
+
+		handler.Register(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
 	})
 
 	t.Run("Encode Error", func(t *testing.T) {
 		mockRepo := new(MockUserRepository)
-		handler := NewAuthHandler(mockRepo)
+		handler := newAuthHandler(mockRepo, new(MockRefreshTokenRepository))
 		reqBody := registerRequest{
 			Name:     "Test User",
 			Email:    "test@example.com",
@@ -172,7 +332,7 @@ func TestRegister(t *testing.T) {
 		req := httptest.NewRequest(http.MethodPost, "/auth/register", bytes.NewBuffer(body))
 		w := &FailWriter{}
 
-		mockRepo.On("Save", mock.AnythingOfType("*domain.User")).Return(nil)
+		mockRepo.On("Save", mock.Anything, mock.AnythingOfType("*domain.User")).Return(nil)
 
 		handler.Register(w, req)
 
@@ -183,11 +343,18 @@ func TestRegister(t *testing.T) {
 func TestRefresh(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		mockRepo := new(MockUserRepository)
-		handler := NewAuthHandler(mockRepo)
+		tokenRepo := new(MockRefreshTokenRepository)
+		handler := newAuthHandler(mockRepo, tokenRepo)
 
-		// Generate a valid token first
-		userID := uuid.New()
-		_, refreshToken, _, _ := auth.GenerateToken(userID, "admin")
+		user, _ := domain.NewUser("Test", "test@example.com", "password123", domain.RoleAdmin)
+		refreshToken, stored := issueRefreshToken(user.ID)
+
+		tokenRepo.On("GetByID", mock.Anything, stored.ID).Return(stored, nil)
+		tokenRepo.On("MarkUsed", mock.Anything, stored.ID, mock.Anything).Return(true, nil)
+		tokenRepo.On("Save", mock.Anything, mock.MatchedBy(func(t *domain.RefreshToken) bool {
+			return t.FamilyID == stored.FamilyID && t.ID != stored.ID
+		})).Return(nil)
+		mockRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil)
 
 		reqBody := map[string]string{
 			"refresh_token": refreshToken,
@@ -203,11 +370,36 @@ func TestRefresh(t *testing.T) {
 		err := json.NewDecoder(w.Body).Decode(&resp)
 		assert.NoError(t, err)
 		assert.NotEmpty(t, resp.AccessToken)
+		assert.NotEqual(t, refreshToken, resp.RefreshToken)
+		tokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("Deactivated User", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		tokenRepo := new(MockRefreshTokenRepository)
+		handler := newAuthHandler(mockRepo, tokenRepo)
+
+		user, _ := domain.NewUser("Test", "test@example.com", "password123", domain.RoleAdmin)
+		user.Deactivate()
+		refreshToken, stored := issueRefreshToken(user.ID)
+
+		tokenRepo.On("GetByID", mock.Anything, stored.ID).Return(stored, nil)
+		tokenRepo.On("MarkUsed", mock.Anything, stored.ID, mock.Anything).Return(true, nil)
+		mockRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+
+		body, _ := json.Marshal(map[string]string{"refresh_token": refreshToken})
+		req := httptest.NewRequest(http.MethodPost, "/auth/refresh", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+
+		handler.Refresh(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		tokenRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
 	})
 
 	t.Run("Invalid Body", func(t *testing.T) {
 		mockRepo := new(MockUserRepository)
-		handler := NewAuthHandler(mockRepo)
+		handler := newAuthHandler(mockRepo, new(MockRefreshTokenRepository))
 		req := httptest.NewRequest(http.MethodPost, "/auth/refresh", bytes.NewBufferString("invalid json"))
 		w := httptest.NewRecorder()
 
@@ -218,7 +410,7 @@ func TestRefresh(t *testing.T) {
 
 	t.Run("Invalid Token", func(t *testing.T) {
 		mockRepo := new(MockUserRepository)
-		handler := NewAuthHandler(mockRepo)
+		handler := newAuthHandler(mockRepo, new(MockRefreshTokenRepository))
 		reqBody := map[string]string{
 			"refresh_token": "invalid.token.here",
 		}
@@ -231,27 +423,144 @@ func TestRefresh(t *testing.T) {
 		assert.Equal(t, http.StatusUnauthorized, w.Code)
 	})
 
-	t.Run("Encode Error", func(t *testing.T) {
+	t.Run("Access Token", func(t *testing.T) {
 		mockRepo := new(MockUserRepository)
-		handler := NewAuthHandler(mockRepo)
-		userID := uuid.New()
-		_, refreshToken, _, _ := auth.GenerateToken(userID, "admin")
+		handler := newAuthHandler(mockRepo, new(MockRefreshTokenRepository))
+		accessToken, _, _ := auth.GenerateAccessToken(uuid.New(), "admin", nil)
+
+		reqBody := map[string]string{
+			"refresh_token": accessToken,
+		}
+		body, _ := json.Marshal(reqBody)
+		req := httptest.NewRequest(http.MethodPost, "/auth/refresh", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+
+		handler.Refresh(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Reused Token", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		tokenRepo := new(MockRefreshTokenRepository)
+		handler := newAuthHandler(mockRepo, tokenRepo)
+
+		refreshToken, stored := issueRefreshToken(uuid.New())
+		usedAt := time.Now().Add(-time.Minute)
+		stored.UsedAt = &usedAt
+
+		tokenRepo.On("GetByID", mock.Anything, stored.ID).Return(stored, nil)
+		tokenRepo.On("RevokeFamily", mock.Anything, stored.FamilyID, mock.Anything).Return(nil)
 
 		reqBody := map[string]string{
 			"refresh_token": refreshToken,
 		}
 		body, _ := json.Marshal(reqBody)
 		req := httptest.NewRequest(http.MethodPost, "/auth/refresh", bytes.NewBuffer(body))
-		w := &FailWriter{}
+		w := httptest.NewRecorder()
+
+		handler.Refresh(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "reuse")
+		tokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("Store Error", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		tokenRepo := new(MockRefreshTokenRepository)
+		handler := newAuthHandler(mockRepo, tokenRepo)
+
+		refreshToken, stored := issueRefreshToken(uuid.New())
+		tokenRepo.On("GetByID", mock.Anything, stored.ID).Return(nil, errors.New("db error"))
+
+		reqBody := map[string]string{
+			"refresh_token": refreshToken,
+		}
+		body, _ := json.Marshal(reqBody)
+		req := httptest.NewRequest(http.MethodPost, "/auth/refresh", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
 
 		handler.Refresh(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestLogout(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		tokenRepo := new(MockRefreshTokenRepository)
+		handler := newAuthHandler(mockRepo, tokenRepo)
+
+		refreshToken, stored := issueRefreshToken(uuid.New())
+		tokenRepo.On("GetByID", mock.Anything, stored.ID).Return(stored, nil)
+		tokenRepo.On("RevokeFamily", mock.Anything, stored.FamilyID, mock.Anything).Return(nil)
+
+		body, _ := json.Marshal(map[string]string{"refresh_token": refreshToken})
+		req := httptest.NewRequest(http.MethodPost, "/auth/logout", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+
+		handler.Logout(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		tokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("Invalid Token", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		handler := newAuthHandler(mockRepo, new(MockRefreshTokenRepository))
+
+		body, _ := json.Marshal(map[string]string{"refresh_token": "invalid.token.here"})
+		req := httptest.NewRequest(http.MethodPost, "/auth/logout", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+
+		handler.Logout(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("All Sessions", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		tokenRepo := new(MockRefreshTokenRepository)
+		handler := newAuthHandler(mockRepo, tokenRepo)
+		r := chi.NewRouter()
+		handler.RegisterRoutes(r)
+
+		userID := uuid.New()
+		accessToken, _, _ := auth.GenerateAccessToken(userID, "admin", nil)
+		tokenRepo.On("RevokeUser", mock.Anything, userID, mock.Anything).Return(nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/logout-all", nil)
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		w := httptest.NewRecorder()
+
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		tokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("All Sessions Unauthenticated", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		handler := newAuthHandler(mockRepo, new(MockRefreshTokenRepository))
+		r := chi.NewRouter()
+		handler.RegisterRoutes(r)
+
+		req := httptest.NewRequest(http.MethodPost, "/logout-all", nil)
+		w := httptest.NewRecorder()
+
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
 	})
 }
 
 func TestLogin(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		mockRepo := new(MockUserRepository)
-		handler := NewAuthHandler(mockRepo)
+		tokenRepo := new(MockRefreshTokenRepository)
+		handler := newAuthHandler(mockRepo, tokenRepo)
 		user, _ := domain.NewUser("Test", "test@example.com", "password123", domain.RoleAdmin)
 
 		reqBody := loginRequest{
@@ -262,7 +571,11 @@ func TestLogin(t *testing.T) {
 		req := httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBuffer(body))
 		w := httptest.NewRecorder()
 
-		mockRepo.On("GetByEmail", "test@example.com").Return(user, nil)
+		mockRepo.On("GetByEmail", mock.Anything, "test@example.com").Return(user, nil)
+		tokenRepo.On("Save", mock.Anything, mock.MatchedBy(func(t *domain.RefreshToken) bool {
+			return t.UserID == user.ID && t.Device == "test-agent"
+		})).Return(nil)
+		req.Header.Set("User-Agent", "test-agent")
 
 		handler.Login(w, req)
 
@@ -273,9 +586,28 @@ func TestLogin(t *testing.T) {
 		assert.NotEmpty(t, resp.AccessToken)
 	})
 
+	t.Run("Deactivated User", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		tokenRepo := new(MockRefreshTokenRepository)
+		handler := newAuthHandler(mockRepo, tokenRepo)
+		user, _ := domain.NewUser("Test", "test@example.com", "password123", domain.RoleAdmin)
+		user.Deactivate()
+		body, _ := json.Marshal(loginRequest{Email: "test@example.com", Password: "password123"})
+		req := httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+
+		mockRepo.On("GetByEmail", mock.Anything, "test@example.com").Return(user, nil)
+
+		handler.Login(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "user is deactivated")
+		tokenRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
+
 	t.Run("Invalid Body", func(t *testing.T) {
 		mockRepo := new(MockUserRepository)
-		handler := NewAuthHandler(mockRepo)
+		handler := newAuthHandler(mockRepo, new(MockRefreshTokenRepository))
 		req := httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBufferString("invalid json"))
 		w := httptest.NewRecorder()
 
@@ -286,7 +618,7 @@ func TestLogin(t *testing.T) {
 
 	t.Run("User Not Found", func(t *testing.T) {
 		mockRepo := new(MockUserRepository)
-		handler := NewAuthHandler(mockRepo)
+		handler := newAuthHandler(mockRepo, new(MockRefreshTokenRepository))
 		reqBody := loginRequest{
 			Email:    "test@example.com",
 			Password: "password123",
@@ -295,7 +627,7 @@ func TestLogin(t *testing.T) {
 		req := httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBuffer(body))
 		w := httptest.NewRecorder()
 
-		mockRepo.On("GetByEmail", "test@example.com").Return(nil, errors.New("user not found"))
+		mockRepo.On("GetByEmail", mock.Anything, "test@example.com").Return(nil, errors.New("user not found"))
 
 		handler.Login(w, req)
 
@@ -304,7 +636,7 @@ func TestLogin(t *testing.T) {
 
 	t.Run("Invalid Password", func(t *testing.T) {
 		mockRepo := new(MockUserRepository)
-		handler := NewAuthHandler(mockRepo)
+		handler := newAuthHandler(mockRepo, new(MockRefreshTokenRepository))
 		user, _ := domain.NewUser("Test", "test@example.com", "password123", domain.RoleAdmin)
 
 		reqBody := loginRequest{
@@ -315,7 +647,7 @@ func TestLogin(t *testing.T) {
 		req := httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBuffer(body))
 		w := httptest.NewRecorder()
 
-		mockRepo.On("GetByEmail", "test@example.com").Return(user, nil)
+		mockRepo.On("GetByEmail", mock.Anything, "test@example.com").Return(user, nil)
 
 		handler.Login(w, req)
 
@@ -324,7 +656,8 @@ func TestLogin(t *testing.T) {
 
 	t.Run("Encode Error", func(t *testing.T) {
 		mockRepo := new(MockUserRepository)
-		handler := NewAuthHandler(mockRepo)
+		tokenRepo := new(MockRefreshTokenRepository)
+		handler := newAuthHandler(mockRepo, tokenRepo)
 		user, _ := domain.NewUser("Test", "test@example.com", "password123", domain.RoleAdmin)
 
 		reqBody := loginRequest{
@@ -335,19 +668,537 @@ func TestLogin(t *testing.T) {
 		req := httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBuffer(body))
 		w := &FailWriter{}
 
-		mockRepo.On("GetByEmail", "test@example.com").Return(user, nil)
+		mockRepo.On("GetByEmail", mock.Anything, "test@example.com").Return(user, nil)
+		tokenRepo.On("Save", mock.Anything, mock.Anything).Return(nil)
 
 		handler.Login(w, req)
 	})
+
+	t.Run("Token Store Error", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		tokenRepo := new(MockRefreshTokenRepository)
+		handler := newAuthHandler(mockRepo, tokenRepo)
+		user, _ := domain.NewUser("Test", "test@example.com", "password123", domain.RoleAdmin)
+
+		body, _ := json.Marshal(loginRequest{Email: "test@example.com", Password: "password123"})
+		req := httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+
+		mockRepo.On("GetByEmail", mock.Anything, "test@example.com").Return(user, nil)
+		tokenRepo.On("Save", mock.Anything, mock.Anything).Return(errors.New("db error"))
+
+		handler.Login(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("Throttled", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		tokenRepo := new(MockRefreshTokenRepository)
+		handler := newAuthHandler(mockRepo, tokenRepo)
+		user, _ := domain.NewUser("Test", "test@example.com", "password123", domain.RoleAdmin)
+		mockRepo.On("GetByEmail", mock.Anything, "test@example.com").Return(user, nil)
+		tokenRepo.On("Save", mock.Anything, mock.Anything).Return(nil)
+
+		login := func(password string) *httptest.ResponseRecorder {
+			body, _ := json.Marshal(loginRequest{Email: "test@example.com", Password: password})
+			req := httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBuffer(body))
+			w := httptest.NewRecorder()
+			handler.Login(w, req)
+			return w
+		}
+
+		for i := 0; i < domain.EmailLoginPolicy.FreeFailures; i++ {
+			assert.Equal(t, http.StatusUnauthorized, login("wrongpassword1").Code)
+		}
+
+		// Even the right password has to wait out the delay.
+		w := login("password123")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "1", w.Header().Get("Retry-After"))
+		mockRepo.AssertNumberOfCalls(t, "GetByEmail", domain.EmailLoginPolicy.FreeFailures)
+	})
+
+	t.Run("Success Clears Failures", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		tokenRepo := new(MockRefreshTokenRepository)
+		attempts := infrastructure.NewMemoryLoginAttemptRepository()
+		guard := application.NewLoginGuard(attempts, infrastructure.NewMemoryAuditLog())
+		accounts := application.NewAccountService(mockRepo, new(MockUserTokenRepository), tokenRepo, new(notificationInfra.MockEmailService))
+		mfa := application.NewMFAService(mockRepo, new(MockRecoveryCodeRepository))
+		handler := NewAuthHandler(mockRepo, application.NewTokenService(mockRepo, tokenRepo), guard, accounts, mfa)
+		user, _ := domain.NewUser("Test", "test@example.com", "password123", domain.RoleAdmin)
+		mockRepo.On("GetByEmail", mock.Anything, "test@example.com").Return(user, nil)
+		tokenRepo.On("Save", mock.Anything, mock.Anything).Return(nil)
+
+		_, _ = attempts.RecordFailure(context.Background(), domain.LoginScopeEmail, "test@example.com", time.Now().Add(-time.Minute), time.Hour)
+
+		body, _ := json.Marshal(loginRequest{Email: "test@example.com", Password: "password123"})
+		req := httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		handler.Login(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		a, _ := attempts.Get(context.Background(), domain.LoginScopeEmail, "test@example.com")
+		assert.Zero(t, a.Failures)
+	})
 }
 
 func TestRegisterRoutes(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	handler := NewAuthHandler(mockRepo)
+	handler := newAuthHandler(mockRepo, new(MockRefreshTokenRepository))
 	r := chi.NewRouter()
 	handler.RegisterRoutes(r)
 
 	// Check if routes are registered
 	// This is a basic check; real routing test is implicit in integration tests usually
 	assert.NotNil(t, r)
+
+	// Registration is reserved to admins
+	register := func(role domain.Role, amr []string) int {
+		req := httptest.NewRequest(http.MethodPost, "/register", bytes.NewBufferString("{}"))
+		if role != "" {
+			token, _, _ := auth.GenerateAccessToken(uuid.New(), string(role), amr)
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusUnauthorized, register("", nil))
+	assert.Equal(t, http.StatusForbidden, register(domain.RoleManager, mfaAMR))
+	// ... and to admins who passed a second factor
+	assert.Equal(t, http.StatusForbidden, register(domain.RoleAdmin, []string{auth.AMRPassword}))
+	assert.Equal(t, http.StatusBadRequest, register(domain.RoleAdmin, mfaAMR))
+}
+
+func TestJWKS(t *testing.T) {
+	handler := newAuthHandler(new(MockUserRepository), new(MockRefreshTokenRepository))
+	req := httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	w := httptest.NewRecorder()
+
+	handler.JWKS(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var set auth.JWKS
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&set))
+	assert.Len(t, set.Keys, 1)
+	assert.Equal(t, "sig", set.Keys[0].Use)
+	assert.NotEmpty(t, set.Keys[0].Kid)
+}
+
+func TestRequirePermission(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := RequirePermission(domain.PermClientsDelete)(next)
+
+	serve := func(claims *auth.Claims) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodDelete, "/admin/clients/1", nil)
+		if claims != nil {
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserContextKey, claims))
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Allowed", func(t *testing.T) {
+		w := serve(&auth.Claims{Role: string(domain.RoleManager)})
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Forbidden", func(t *testing.T) {
+		w := serve(&auth.Claims{Role: string(domain.RoleEmployee)})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		var body forbiddenResponse
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+		assert.Equal(t, domain.PermClientsDelete, body.Permission)
+		assert.Equal(t, "employee", body.Role)
+		assert.Contains(t, body.Message, "clients:delete")
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		w := serve(nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestPermissions(t *testing.T) {
+	handler := newAuthHandler(new(MockUserRepository), new(MockRefreshTokenRepository))
+
+	t.Run("Success", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/auth/me/permissions", nil)
+		claims := &auth.Claims{UserID: uuid.New(), Role: string(domain.RoleEmployee)}
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserContextKey, claims))
+		w := httptest.NewRecorder()
+
+		handler.Permissions(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var body permissionsResponse
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+		assert.Equal(t, "employee", body.Role)
+		assert.Equal(t, domain.RoleEmployee.Permissions(), body.Permissions)
+		assert.NotContains(t, body.Permissions, domain.PermReportsRead)
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/auth/me/permissions", nil)
+		w := httptest.NewRecorder()
+
+		handler.Permissions(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestAccountFlows(t *testing.T) {
+	setup := func() (*AuthHandler, *MockUserRepository, *MockUserTokenRepository, *MockRefreshTokenRepository) {
+		users := new(MockUserRepository)
+		userTokens := new(MockUserTokenRepository)
+		sessions := new(MockRefreshTokenRepository)
+		mailer := new(notificationInfra.MockEmailService)
+		mailer.On("SendEmail", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		guard := application.NewLoginGuard(infrastructure.NewMemoryLoginAttemptRepository(), infrastructure.NewMemoryAuditLog())
+		accounts := application.NewAccountService(users, userTokens, sessions, mailer)
+		mfa := application.NewMFAService(users, new(MockRecoveryCodeRepository))
+		return NewAuthHandler(users, application.NewTokenService(users, sessions), guard, accounts, mfa), users, userTokens, sessions
+	}
+	post := func(h http.HandlerFunc, body any) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(b))
+		w := httptest.NewRecorder()
+		h(w, req)
+		return w
+	}
+
+	t.Run("Forgot Password Unknown Email", func(t *testing.T) {
+		handler, users, _, _ := setup()
+		users.On("GetByEmail", mock.Anything, "nobody@example.com").Return(nil, domain.ErrUserNotFound)
+
+		w := post(handler.ForgotPassword, forgotPasswordRequest{Email: "nobody@example.com"})
+		assert.Equal(t, http.StatusAccepted, w.Code)
+	})
+
+	t.Run("Forgot Password", func(t *testing.T) {
+		handler, users, userTokens, _ := setup()
+		user, _ := domain.NewUser("Test", "test@example.com", "password123", domain.RoleEmployee)
+		users.On("GetByEmail", mock.Anything, "test@example.com").Return(user, nil)
+		userTokens.On("Save", mock.Anything, mock.MatchedBy(func(t *domain.UserToken) bool {
+			return t.UserID == user.ID && t.Purpose == domain.TokenPurposePasswordReset
+		})).Return(nil)
+
+		w := post(handler.ForgotPassword, forgotPasswordRequest{Email: "test@example.com"})
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		userTokens.AssertExpectations(t)
+	})
+
+	t.Run("Reset Password", func(t *testing.T) {
+		handler, users, userTokens, sessions := setup()
+		user, _ := domain.NewUser("Test", "test@example.com", "password123", domain.RoleEmployee)
+		stored, token, _ := domain.NewUserToken(user.ID, domain.TokenPurposePasswordReset, time.Hour)
+		userTokens.On("GetByHash", mock.Anything, stored.TokenHash).Return(stored, nil)
+		users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+		userTokens.On("MarkUsed", mock.Anything, stored.ID, mock.Anything).Return(true, nil)
+		users.On("Update", mock.Anything, user).Return(nil)
+		sessions.On("RevokeUser", mock.Anything, user.ID, mock.Anything).Return(nil)
+
+		w := post(handler.ResetPassword, resetPasswordRequest{Token: token, Password: "newpassword1"})
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.True(t, user.CheckPassword("newpassword1"))
+	})
+
+	t.Run("Reset Password Invalid Token", func(t *testing.T) {
+		handler, _, userTokens, _ := setup()
+		userTokens.On("GetByHash", mock.Anything, mock.Anything).Return(nil, domain.ErrUserTokenNotFound)
+
+		w := post(handler.ResetPassword, resetPasswordRequest{Token: "made-up", Password: "newpassword1"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), domain.ErrInvalidUserToken.Error())
+	})
+
+	t.Run("Verify Email", func(t *testing.T) {
+		handler, users, userTokens, _ := setup()
+		user, _ := domain.NewUser("Test", "test@example.com", "password123", domain.RoleEmployee)
+		stored, token, _ := domain.NewUserToken(user.ID, domain.TokenPurposeEmailVerification, time.Hour)
+		userTokens.On("GetByHash", mock.Anything, stored.TokenHash).Return(stored, nil)
+		users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+		userTokens.On("MarkUsed", mock.Anything, stored.ID, mock.Anything).Return(true, nil)
+		users.On("Update", mock.Anything, user).Return(nil)
+
+		w := post(handler.VerifyEmail, verifyEmailRequest{Token: token})
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.True(t, user.IsEmailVerified())
+	})
+
+	t.Run("Verify Email Store Error", func(t *testing.T) {
+		handler, _, userTokens, _ := setup()
+		userTokens.On("GetByHash", mock.Anything, mock.Anything).Return(nil, errors.New("db error"))
+
+		w := post(handler.VerifyEmail, verifyEmailRequest{Token: "token"})
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("Send Verification", func(t *testing.T) {
+		handler, users, userTokens, _ := setup()
+		user, _ := domain.NewUser("Test", "test@example.com", "password123", domain.RoleEmployee)
+		users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+		userTokens.On("Save", mock.Anything, mock.MatchedBy(func(t *domain.UserToken) bool {
+			return t.Purpose == domain.TokenPurposeEmailVerification
+		})).Return(nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/auth/email/verification", nil)
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserContextKey, &auth.Claims{UserID: user.ID}))
+		w := httptest.NewRecorder()
+		handler.SendVerification(w, req)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		userTokens.AssertExpectations(t)
+	})
+
+	t.Run("Magic Link Login", func(t *testing.T) {
+		handler, users, userTokens, sessions := setup()
+		user, _ := domain.NewCustomer(uuid.New(), "Maria", "maria@example.com")
+		stored, token, _ := domain.NewUserToken(user.ID, domain.TokenPurposeMagicLink, domain.MagicLinkTokenTTL)
+		userTokens.On("GetByHash", mock.Anything, stored.TokenHash).Return(stored, nil)
+		users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+		userTokens.On("MarkUsed", mock.Anything, stored.ID, mock.Anything).Return(true, nil)
+		users.On("Update", mock.Anything, user).Return(nil)
+		sessions.On("Save", mock.Anything, mock.Anything).Return(nil)
+
+		w := post(handler.LoginMagicLink, magicLinkLoginRequest{Token: token})
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp loginResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		claims, err := auth.ValidateToken(resp.AccessToken)
+		assert.NoError(t, err)
+		assert.Equal(t, string(domain.RoleCustomer), claims.Role)
+		assert.Equal(t, []string{auth.AMRMagicLink}, claims.AMR)
+	})
+
+	t.Run("Magic Link Login Invalid Token", func(t *testing.T) {
+		handler, _, userTokens, _ := setup()
+		userTokens.On("GetByHash", mock.Anything, mock.Anything).Return(nil, domain.ErrUserTokenNotFound)
+
+		w := post(handler.LoginMagicLink, magicLinkLoginRequest{Token: "made-up"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Send Magic Link", func(t *testing.T) {
+		handler, users, userTokens, _ := setup()
+		user, _ := domain.NewCustomer(uuid.New(), "Maria", "maria@example.com")
+		users.On("GetByEmail", mock.Anything, "maria@example.com").Return(user, nil)
+		userTokens.On("Save", mock.Anything, mock.MatchedBy(func(t *domain.UserToken) bool {
+			return t.UserID == user.ID && t.Purpose == domain.TokenPurposeMagicLink
+		})).Return(nil)
+
+		w := post(handler.SendMagicLink, magicLinkRequest{Email: "maria@example.com"})
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		userTokens.AssertExpectations(t)
+	})
+
+	t.Run("Send Verification Unauthenticated", func(t *testing.T) {
+		handler, _, _, _ := setup()
+		req := httptest.NewRequest(http.MethodPost, "/auth/email/verification", nil)
+		w := httptest.NewRecorder()
+		handler.SendVerification(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestRequireMFA(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := RequireMFA(next)
+
+	serve := func(claims *auth.Claims) int {
+		req := httptest.NewRequest(http.MethodDelete, "/admin/clients/1", nil)
+		if claims != nil {
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserContextKey, claims))
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve(nil))
+	assert.Equal(t, http.StatusForbidden, serve(&auth.Claims{Role: string(domain.RoleManager), AMR: []string{auth.AMRPassword}}))
+	assert.Equal(t, http.StatusOK, serve(&auth.Claims{Role: string(domain.RoleManager), AMR: mfaAMR}))
+	// Optional for employees
+	assert.Equal(t, http.StatusOK, serve(&auth.Claims{Role: string(domain.RoleEmployee), AMR: []string{auth.AMRPassword}}))
+}
+
+func TestMFAFlows(t *testing.T) {
+	type fixture struct {
+		handler  *AuthHandler
+		users    *MockUserRepository
+		codes    *MockRecoveryCodeRepository
+		sessions *MockRefreshTokenRepository
+		attempts *infrastructure.MemoryLoginAttemptRepository
+	}
+	setup := func() fixture {
+		f := fixture{
+			users:    new(MockUserRepository),
+			codes:    new(MockRecoveryCodeRepository),
+			sessions: new(MockRefreshTokenRepository),
+			attempts: infrastructure.NewMemoryLoginAttemptRepository(),
+		}
+		guard := application.NewLoginGuard(f.attempts, infrastructure.NewMemoryAuditLog())
+		accounts := application.NewAccountService(f.users, new(MockUserTokenRepository), f.sessions, new(notificationInfra.MockEmailService))
+		mfa := application.NewMFAService(f.users, f.codes)
+		f.handler = NewAuthHandler(f.users, application.NewTokenService(f.users, f.sessions), guard, accounts, mfa)
+		return f
+	}
+	enrolled := func(role domain.Role) *domain.User {
+		user, _ := domain.NewUser("Test", "test@example.com", "password123", role)
+		secret, _ := auth.GenerateTOTPSecret()
+		_ = user.StartMFAEnrolment(secret)
+		_ = user.EnableMFA()
+		return user
+	}
+	currentCode := func(user *domain.User) string {
+		code, _ := auth.TOTPCode(user.TOTPSecret, auth.TOTPStep(time.Now()))
+		return code
+	}
+	post := func(h http.HandlerFunc, body any, claims *auth.Claims) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(b))
+		if claims != nil {
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserContextKey, claims))
+		}
+		w := httptest.NewRecorder()
+		h(w, req)
+		return w
+	}
+
+	t.Run("Login Returns Challenge", func(t *testing.T) {
+		f := setup()
+		user := enrolled(domain.RoleAdmin)
+		f.users.On("GetByEmail", mock.Anything, "test@example.com").Return(user, nil)
+		_, _ = f.attempts.RecordFailure(context.Background(), domain.LoginScopeEmail, "test@example.com", time.Now().Add(-time.Minute), time.Hour)
+
+		w := post(f.handler.Login, loginRequest{Email: "test@example.com", Password: "password123"}, nil)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		var resp mfaChallengeResponse
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		assert.True(t, resp.MFARequired)
+		claims, err := auth.ValidateMFAChallengeToken(resp.MFAToken)
+		assert.NoError(t, err)
+		assert.Equal(t, user.ID, claims.UserID)
+		f.sessions.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+
+		// The password alone does not clear earlier failures
+		a, _ := f.attempts.Get(context.Background(), domain.LoginScopeEmail, "test@example.com")
+		assert.Equal(t, 1, a.Failures)
+	})
+
+	t.Run("Login Without MFA Asks For Enrolment", func(t *testing.T) {
+		f := setup()
+		user, _ := domain.NewUser("Test", "test@example.com", "password123", domain.RoleManager)
+		f.users.On("GetByEmail", mock.Anything, "test@example.com").Return(user, nil)
+		f.sessions.On("Save", mock.Anything, mock.Anything).Return(nil)
+
+		w := post(f.handler.Login, loginRequest{Email: "test@example.com", Password: "password123"}, nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp loginResponse
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		assert.True(t, resp.MFAEnrolmentRequired)
+		claims, _ := auth.ValidateToken(resp.AccessToken)
+		assert.Equal(t, []string{auth.AMRPassword}, claims.AMR)
+	})
+
+	t.Run("Login MFA", func(t *testing.T) {
+		f := setup()
+		user := enrolled(domain.RoleAdmin)
+		f.users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+		f.users.On("UseTOTPStep", mock.Anything, user.ID, mock.Anything).Return(true, nil)
+		f.sessions.On("Save", mock.Anything, mock.MatchedBy(func(t *domain.RefreshToken) bool {
+			return t.UserID == user.ID && len(t.AMR) == len(mfaAMR)
+		})).Return(nil)
+		challenge, _, _ := auth.GenerateMFAChallengeToken(user.ID)
+
+		w := post(f.handler.LoginMFA, loginMFARequest{MFAToken: challenge, Code: currentCode(user)}, nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp loginResponse
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		assert.False(t, resp.MFAEnrolmentRequired)
+		claims, err := auth.ValidateToken(resp.AccessToken)
+		assert.NoError(t, err)
+		assert.True(t, claims.HasAMR(auth.AMRMFA))
+		f.sessions.AssertExpectations(t)
+	})
+
+	t.Run("Login MFA Wrong Code", func(t *testing.T) {
+		f := setup()
+		user := enrolled(domain.RoleAdmin)
+		f.users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+		f.codes.On("Use", mock.Anything, user.ID, mock.Anything, mock.Anything).Return(false, nil)
+		challenge, _, _ := auth.GenerateMFAChallengeToken(user.ID)
+
+		w := post(f.handler.LoginMFA, loginMFARequest{MFAToken: challenge, Code: "wrong"}, nil)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		a, _ := f.attempts.Get(context.Background(), domain.LoginScopeEmail, user.Email)
+		assert.Equal(t, 1, a.Failures)
+	})
+
+	t.Run("Login MFA Needs Challenge Token", func(t *testing.T) {
+		f := setup()
+		accessToken, _, _ := auth.GenerateAccessToken(uuid.New(), "admin", nil)
+
+		w := post(f.handler.LoginMFA, loginMFARequest{MFAToken: accessToken, Code: "123456"}, nil)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		f.users.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Enrol And Confirm", func(t *testing.T) {
+		f := setup()
+		user, _ := domain.NewUser("Test", "test@example.com", "password123", domain.RoleManager)
+		claims := &auth.Claims{UserID: user.ID, Role: string(user.Role)}
+		f.users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+		f.users.On("Update", mock.Anything, user).Return(nil)
+		f.users.On("UseTOTPStep", mock.Anything, user.ID, mock.Anything).Return(true, nil)
+		f.codes.On("Replace", mock.Anything, user.ID, mock.Anything).Return(nil)
+
+		w := post(f.handler.EnrolMFA, nil, claims)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var enrolment mfaEnrolmentResponse
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&enrolment))
+		assert.Equal(t, user.TOTPSecret, enrolment.Secret)
+		assert.Contains(t, enrolment.ProvisioningURI, "otpauth://totp/")
+
+		w = post(f.handler.ConfirmMFA, mfaCodeRequest{Code: "000000"}, claims)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = post(f.handler.ConfirmMFA, mfaCodeRequest{Code: currentCode(user)}, claims)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var recovery recoveryCodesResponse
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&recovery))
+		assert.Len(t, recovery.RecoveryCodes, domain.RecoveryCodeCount)
+		assert.True(t, user.MFAEnabled())
+
+		w = post(f.handler.EnrolMFA, nil, claims)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Disable Required By Role", func(t *testing.T) {
+		f := setup()
+		user := enrolled(domain.RoleManager)
+		f.users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+
+		w := post(f.handler.DisableMFA, mfaCodeRequest{Code: currentCode(user)}, &auth.Claims{UserID: user.ID})
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.True(t, user.MFAEnabled())
+	})
 }
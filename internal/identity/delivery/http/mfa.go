@@ -0,0 +1,269 @@
+package http
+
+import (
+	"encoding/json"
+	stdErrors "errors"
+	"net/http"
+
+	"github.com/noggrj/autorepair/internal/identity/domain"
+	"github.com/noggrj/autorepair/internal/platform/auth"
+	"github.com/noggrj/autorepair/internal/platform/errors"
+	"github.com/noggrj/autorepair/internal/platform/middleware"
+)
+
+// mfaAMR is the amr of a session that passed a second factor.
+var mfaAMR = []string{auth.AMRPassword, auth.AMROTP, auth.AMRMFA}
+
+type mfaChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+type loginMFARequest struct {
+	MFAToken string `json:"mfa_token"`
+	// Code is a code from the authenticator app or a recovery code.
+	Code   string `json:"code"`
+	Device string `json:"device,omitempty"`
+}
+
+type mfaEnrolmentResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type mfaCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// RequireMFA guards sensitive routes: callers whose role requires
+// two-factor authentication get through only when their access token comes
+// from a login that passed a second factor. Other roles are let through,
+// since for them it is optional. It must run after middleware.AuthMiddleware.
+func RequireMFA(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := r.Context().Value(middleware.UserContextKey).(*auth.Claims)
+		if !ok {
+			errors.Unauthorized(w, "user not authenticated")
+			return
+		}
+
+		if domain.Role(claims.Role).RequiresMFA() && !claims.HasAMR(auth.AMRMFA) {
+			errors.Forbidden(w, "this action requires logging in with two-factor authentication")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// LoginMFA godoc
+// @Summary Finish login with a second factor
+// @Description Exchange the mfa_token from /auth/login and a code from the authenticator app, or an unused recovery code, for a token pair. Wrong codes count as failed logins.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body loginMFARequest true "MFA Login Request"
+// @Success 200 {object} loginResponse
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Failure 429 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /auth/login/mfa [post]
+func (h *AuthHandler) LoginMFA(w http.ResponseWriter, r *http.Request) {
+	var req loginMFARequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.BadRequest(w, "invalid request body")
+		return
+	}
+
+	claims, err := auth.ValidateMFAChallengeToken(req.MFAToken)
+	if err != nil {
+		errors.Unauthorized(w, "invalid or expired mfa token")
+		return
+	}
+	user, err := h.repo.GetByID(r.Context(), claims.UserID)
+	if err != nil {
+		if stdErrors.Is(err, domain.ErrUserNotFound) {
+			errors.Unauthorized(w, "invalid or expired mfa token")
+			return
+		}
+		errors.InternalServerError(w, "failed to load user")
+		return
+	}
+
+	ip := middleware.ClientIP(r)
+	wait, err := h.guard.Allow(r.Context(), user.Email, ip)
+	if err != nil {
+		errors.InternalServerError(w, "failed to check login attempts")
+		return
+	}
+	if wait > 0 {
+		tooManyAttempts(w, wait)
+		return
+	}
+
+	if err := h.mfa.Verify(r.Context(), user, req.Code); err != nil {
+		if stdErrors.Is(err, domain.ErrInvalidMFACode) || stdErrors.Is(err, domain.ErrMFANotEnrolled) {
+			if err := h.guard.Fail(r.Context(), user.Email, ip); err != nil {
+				errors.InternalServerError(w, "failed to record login attempt")
+				return
+			}
+			errors.Unauthorized(w, domain.ErrInvalidMFACode.Error())
+			return
+		}
+		errors.InternalServerError(w, "failed to verify code")
+		return
+	}
+
+	if err := h.guard.Succeed(r.Context(), user.Email); err != nil {
+		errors.InternalServerError(w, "failed to record login attempt")
+		return
+	}
+
+	h.startSession(w, r, user, req.Device, mfaAMR)
+}
+
+// EnrolMFA godoc
+// @Summary Start two-factor enrolment
+// @Description Create a TOTP secret for the authenticated user. Show provisioning_uri as a QR code for the authenticator app, then send a code from the app to /auth/mfa/confirm. Starting again replaces an unconfirmed secret.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} mfaEnrolmentResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 409 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /auth/mfa/enrol [post]
+func (h *AuthHandler) EnrolMFA(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	enrolment, err := h.mfa.Enrol(r.Context(), user)
+	if err != nil {
+		writeMFAError(w, err, "failed to start enrolment")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(mfaEnrolmentResponse{
+		Secret:          enrolment.Secret,
+		ProvisioningURI: enrolment.ProvisioningURI,
+	}); err != nil {
+		errors.InternalServerError(w, "failed to encode response")
+		return
+	}
+}
+
+// ConfirmMFA godoc
+// @Summary Confirm two-factor enrolment
+// @Description Turn two-factor authentication on with a code from the authenticator app. The answer lists the recovery codes, each usable once in place of a code; they are not shown again. Log in again to get a session that passed the second factor.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body mfaCodeRequest true "Code"
+// @Success 200 {object} recoveryCodesResponse
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 409 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /auth/mfa/confirm [post]
+func (h *AuthHandler) ConfirmMFA(w http.ResponseWriter, r *http.Request) {
+	var req mfaCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.BadRequest(w, "invalid request body")
+		return
+	}
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	codes, err := h.mfa.Confirm(r.Context(), user, req.Code)
+	if err != nil {
+		writeMFAError(w, err, "failed to confirm enrolment")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(recoveryCodesResponse{RecoveryCodes: codes}); err != nil {
+		errors.InternalServerError(w, "failed to encode response")
+		return
+	}
+}
+
+// DisableMFA godoc
+// @Summary Turn two-factor authentication off
+// @Description Turn two-factor authentication off with a current code or a recovery code. Admins and managers must keep it on.
+// @Tags auth
+// @Accept json
+// @Security BearerAuth
+// @Param request body mfaCodeRequest true "Code"
+// @Success 204
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Failure 409 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /auth/mfa/disable [post]
+func (h *AuthHandler) DisableMFA(w http.ResponseWriter, r *http.Request) {
+	var req mfaCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.BadRequest(w, "invalid request body")
+		return
+	}
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.mfa.Disable(r.Context(), user, req.Code); err != nil {
+		writeMFAError(w, err, "failed to disable two-factor authentication")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// currentUser loads the authenticated user, answering the request itself
+// when it cannot.
+func (h *AuthHandler) currentUser(w http.ResponseWriter, r *http.Request) (*domain.User, bool) {
+	claims, ok := r.Context().Value(middleware.UserContextKey).(*auth.Claims)
+	if !ok {
+		errors.Unauthorized(w, "user not authenticated")
+		return nil, false
+	}
+
+	user, err := h.repo.GetByID(r.Context(), claims.UserID)
+	if err != nil {
+		if stdErrors.Is(err, domain.ErrUserNotFound) {
+			errors.Unauthorized(w, "user not authenticated")
+			return nil, false
+		}
+		errors.InternalServerError(w, "failed to load user")
+		return nil, false
+	}
+	return user, true
+}
+
+// writeMFAError maps MFAService errors to HTTP responses.
+func writeMFAError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case stdErrors.Is(err, domain.ErrInvalidMFACode):
+		errors.BadRequest(w, err.Error())
+	case stdErrors.Is(err, domain.ErrMFAAlreadyEnabled), stdErrors.Is(err, domain.ErrMFANotEnrolled):
+		errors.Conflict(w, err.Error())
+	case stdErrors.Is(err, domain.ErrMFARequired):
+		errors.Forbidden(w, err.Error())
+	default:
+		errors.InternalServerError(w, fallback)
+	}
+}
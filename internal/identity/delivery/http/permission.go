@@ -0,0 +1,76 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/noggrj/autorepair/internal/identity/domain"
+	"github.com/noggrj/autorepair/internal/platform/auth"
+	"github.com/noggrj/autorepair/internal/platform/errors"
+	"github.com/noggrj/autorepair/internal/platform/middleware"
+)
+
+type forbiddenResponse struct {
+	Message    string            `json:"message"`
+	Permission domain.Permission `json:"permission"`
+	Role       string            `json:"role"`
+}
+
+type permissionsResponse struct {
+	Role        string              `json:"role"`
+	Permissions []domain.Permission `json:"permissions"`
+}
+
+// RequirePermission lets a request through only when the role in its access
+// token holds the permission. It must run after middleware.AuthMiddleware.
+func RequirePermission(permission domain.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := r.Context().Value(middleware.UserContextKey).(*auth.Claims)
+			if !ok {
+				errors.Unauthorized(w, "user not authenticated")
+				return
+			}
+
+			if !domain.Role(claims.Role).Can(permission) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusForbidden)
+				_ = json.NewEncoder(w).Encode(forbiddenResponse{
+					Message:    fmt.Sprintf("role %q lacks permission %s", claims.Role, permission),
+					Permission: permission,
+					Role:       claims.Role,
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Permissions godoc
+// @Summary My permissions
+// @Description List the permissions granted to the authenticated user's role, so clients can hide what the user cannot do
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} permissionsResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Router /auth/me/permissions [get]
+func (h *AuthHandler) Permissions(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(middleware.UserContextKey).(*auth.Claims)
+	if !ok {
+		errors.Unauthorized(w, "user not authenticated")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(permissionsResponse{
+		Role:        claims.Role,
+		Permissions: domain.Role(claims.Role).Permissions(),
+	}); err != nil {
+		errors.InternalServerError(w, "failed to encode response")
+		return
+	}
+}
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditEvent names a security-relevant event worth keeping a record of.
+type AuditEvent string
+
+const (
+	AuditLoginLocked   AuditEvent = "login.locked"
+	AuditLoginUnlocked AuditEvent = "login.unlocked"
+)
+
+// AuditEntry records who did what to whom. Subject is the email or IP the
+// event concerns; ActorID is nil when no authenticated user caused it.
+type AuditEntry struct {
+	ID        uuid.UUID
+	Event     AuditEvent
+	ActorID   *uuid.UUID
+	Subject   string
+	IP        string
+	Detail    string
+	CreatedAt time.Time
+}
+
+func NewAuditEntry(event AuditEvent, actorID *uuid.UUID, subject, ip, detail string) *AuditEntry {
+	return &AuditEntry{
+		ID:        uuid.New(),
+		Event:     event,
+		ActorID:   actorID,
+		Subject:   subject,
+		IP:        ip,
+		Detail:    detail,
+		CreatedAt: time.Now(),
+	}
+}
+
+type AuditLog interface {
+	Record(ctx context.Context, entry *AuditEntry) error
+}
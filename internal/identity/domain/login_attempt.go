@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// LoginScope is what a run of failed logins is counted against.
+type LoginScope string
+
+const (
+	LoginScopeEmail LoginScope = "email"
+	LoginScopeIP    LoginScope = "ip"
+)
+
+// LoginAttempts is the current run of failed logins against one email or IP.
+type LoginAttempts struct {
+	Scope         LoginScope
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	// LockedUntil is set once the run reached the policy's lockout threshold.
+	LockedUntil *time.Time
+}
+
+// LoginPolicy decides how long a key must wait after failed logins. The
+// first FreeFailures cost nothing; each further failure doubles the wait,
+// starting at BaseDelay and capped at MaxDelay. LockAfter failures lock the
+// key for LockFor. A failure more than Window after the previous one starts
+// a new run.
+type LoginPolicy struct {
+	FreeFailures int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	LockAfter    int
+	LockFor      time.Duration
+	Window       time.Duration
+}
+
+// EmailLoginPolicy guards a single account.
+var EmailLoginPolicy = LoginPolicy{
+	FreeFailures: 3,
+	BaseDelay:    time.Second,
+	MaxDelay:     30 * time.Second,
+	LockAfter:    10,
+	LockFor:      15 * time.Minute,
+	Window:       15 * time.Minute,
+}
+
+// IPLoginPolicy guards against one address trying many accounts. It is
+// looser than EmailLoginPolicy because a whole shop may sit behind one IP.
+var IPLoginPolicy = LoginPolicy{
+	FreeFailures: 10,
+	BaseDelay:    time.Second,
+	MaxDelay:     30 * time.Second,
+	LockAfter:    50,
+	LockFor:      15 * time.Minute,
+	Window:       15 * time.Minute,
+}
+
+// RetryAfter reports how long the key must wait before its next attempt,
+// zero when it may try now.
+func (p LoginPolicy) RetryAfter(a *LoginAttempts, now time.Time) time.Duration {
+	if a.LockedUntil != nil && now.Before(*a.LockedUntil) {
+		return a.LockedUntil.Sub(now)
+	}
+	if a.Failures < p.FreeFailures || now.Sub(a.LastFailureAt) >= p.Window {
+		return 0
+	}
+	if wait := p.delay(a.Failures) - now.Sub(a.LastFailureAt); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+func (p LoginPolicy) delay(failures int) time.Duration {
+	delay := p.BaseDelay
+	for i := p.FreeFailures; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxDelay)
+}
+
+type LoginAttemptRepository interface {
+	// Get returns the attempts recorded against key. A key without any gets
+	// a record with no failures.
+	Get(ctx context.Context, scope LoginScope, key string) (*LoginAttempts, error)
+	// RecordFailure counts one more failure at the given time and returns
+	// the updated run. A run whose last failure is older than window, or
+	// whose lock has expired, starts over.
+	RecordFailure(ctx context.Context, scope LoginScope, key string, at time.Time, window time.Duration) (*LoginAttempts, error)
+	Lock(ctx context.Context, scope LoginScope, key string, until time.Time) error
+	// Reset forgets the failures recorded against key, lifting any lock.
+	Reset(ctx context.Context, scope LoginScope, key string) error
+}
@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RecoveryCodeCount is how many recovery codes a user gets when they enable
+// two-factor authentication.
+const RecoveryCodeCount = 10
+
+var (
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnrolled    = errors.New("two-factor authentication is not set up")
+	ErrInvalidMFACode    = errors.New("invalid two-factor code")
+	ErrMFARequired       = errors.New("two-factor authentication is mandatory for this role")
+)
+
+// RequiresMFA reports whether users holding r must sign in with a second
+// factor to reach sensitive routes. Other roles may enable it if they like.
+func (r Role) RequiresMFA() bool {
+	return r == RoleAdmin || r == RoleManager
+}
+
+func (u *User) MFAEnabled() bool {
+	return u.MFAEnabledAt != nil
+}
+
+// StartMFAEnrolment stores a new TOTP secret, replacing any enrolment that
+// was never confirmed.
+func (u *User) StartMFAEnrolment(secret string) error {
+	if u.MFAEnabled() {
+		return ErrMFAAlreadyEnabled
+	}
+	u.TOTPSecret = secret
+	u.TOTPLastStep = 0
+	u.UpdatedAt = time.Now()
+	return nil
+}
+
+// EnableMFA turns two-factor authentication on once the user proved their
+// app holds the secret.
+func (u *User) EnableMFA() error {
+	if u.MFAEnabled() {
+		return ErrMFAAlreadyEnabled
+	}
+	if u.TOTPSecret == "" {
+		return ErrMFANotEnrolled
+	}
+	now := time.Now()
+	u.MFAEnabledAt = &now
+	u.UpdatedAt = now
+	return nil
+}
+
+func (u *User) DisableMFA() {
+	u.TOTPSecret = ""
+	u.TOTPLastStep = 0
+	u.MFAEnabledAt = nil
+	u.UpdatedAt = time.Now()
+}
+
+// AcceptTOTPStep records that a code from step was used. It refuses a step
+// at or before the last one accepted, so each code works only once.
+func (u *User) AcceptTOTPStep(step int64) bool {
+	if step <= u.TOTPLastStep {
+		return false
+	}
+	u.TOTPLastStep = step
+	u.UpdatedAt = time.Now()
+	return true
+}
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewRecoveryCodes returns a fresh set of recovery codes to show the user
+// once, and their hashes to store.
+func NewRecoveryCodes() (codes []string, hashes []string, err error) {
+	for range RecoveryCodeCount {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))
+		codes = append(codes, raw[0:4]+"-"+raw[4:8]+"-"+raw[8:12]+"-"+raw[12:16])
+		hashes = append(hashes, HashRecoveryCode(raw))
+	}
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode hashes a recovery code the way it was typed, ignoring
+// case, spaces and dashes.
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	return HashToken(code)
+}
+
+type RecoveryCodeRepository interface {
+	// Replace swaps the user's recovery codes for the given hashes.
+	Replace(ctx context.Context, userID uuid.UUID, hashes []string) error
+	// Use spends the user's unused code with the given hash. It reports
+	// false when there is none, so each code works only once.
+	Use(ctx context.Context, userID uuid.UUID, hash string, at time.Time) (bool, error)
+}
@@ -0,0 +1,97 @@
+package domain
+
+import "sort"
+
+// Permission is an action on a resource, written resource:action. Routes
+// require permissions rather than roles so the matrix below is the only
+// place that decides who may do what.
+type Permission string
+
+const (
+	PermClientsRead   Permission = "clients:read"
+	PermClientsWrite  Permission = "clients:write"
+	PermClientsDelete Permission = "clients:delete"
+
+	PermVehiclesRead   Permission = "vehicles:read"
+	PermVehiclesWrite  Permission = "vehicles:write"
+	PermVehiclesDelete Permission = "vehicles:delete"
+
+	PermPartsRead   Permission = "parts:read"
+	PermPartsWrite  Permission = "parts:write"
+	PermPartsDelete Permission = "parts:delete"
+	PermStockAdjust Permission = "stock:adjust"
+
+	PermSuppliersRead   Permission = "suppliers:read"
+	PermSuppliersWrite  Permission = "suppliers:write"
+	PermSuppliersDelete Permission = "suppliers:delete"
+
+	PermPurchaseOrdersRead    Permission = "purchase_orders:read"
+	PermPurchaseOrdersWrite   Permission = "purchase_orders:write"
+	PermPurchaseOrdersReceive Permission = "purchase_orders:receive"
+
+	PermServicesRead   Permission = "services:read"
+	PermServicesWrite  Permission = "services:write"
+	PermServicesDelete Permission = "services:delete"
+
+	PermOrdersRead    Permission = "orders:read"
+	PermOrdersWrite   Permission = "orders:write"
+	PermOrdersApprove Permission = "orders:approve"
+	PermOrdersCancel  Permission = "orders:cancel"
+
+	PermReportsRead Permission = "reports:read"
+
+	PermUsersManage Permission = "users:manage"
+)
+
+// employeePermissions covers the day-to-day work of the shop floor: serving
+// clients and moving orders along, but nothing that deletes records, changes
+// prices or stock, or shows the shop's numbers.
+var employeePermissions = []Permission{
+	PermClientsRead, PermClientsWrite,
+	PermVehiclesRead, PermVehiclesWrite,
+	PermPartsRead,
+	PermServicesRead,
+	PermOrdersRead, PermOrdersWrite,
+}
+
+// managerPermissions adds running the shop to the employee's set. Managing
+// users stays with admins.
+var managerPermissions = append([]Permission{
+	PermClientsDelete,
+	PermVehiclesDelete,
+	PermPartsWrite, PermPartsDelete, PermStockAdjust,
+	PermSuppliersRead, PermSuppliersWrite, PermSuppliersDelete,
+	PermPurchaseOrdersRead, PermPurchaseOrdersWrite, PermPurchaseOrdersReceive,
+	PermServicesWrite, PermServicesDelete,
+	PermOrdersApprove, PermOrdersCancel,
+	PermReportsRead,
+}, employeePermissions...)
+
+var rolePermissions = map[Role]map[Permission]bool{
+	RoleAdmin:    permissionSet(append([]Permission{PermUsersManage}, managerPermissions...)),
+	RoleManager:  permissionSet(managerPermissions),
+	RoleEmployee: permissionSet(employeePermissions),
+}
+
+func permissionSet(perms []Permission) map[Permission]bool {
+	set := make(map[Permission]bool, len(perms))
+	for _, p := range perms {
+		set[p] = true
+	}
+	return set
+}
+
+// Can reports whether the role holds the permission. Unknown roles hold none.
+func (r Role) Can(p Permission) bool {
+	return rolePermissions[r][p]
+}
+
+// Permissions lists the role's permissions in alphabetical order.
+func (r Role) Permissions() []Permission {
+	perms := make([]Permission, 0, len(rolePermissions[r]))
+	for p := range rolePermissions[r] {
+		perms = append(perms, p)
+	}
+	sort.Slice(perms, func(i, j int) bool { return perms[i] < perms[j] })
+	return perms
+}
@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+	ErrInvalidRefreshToken  = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused means an already rotated refresh token was
+	// presented again, so the token has leaked and its family is revoked.
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+)
+
+// RefreshToken is the server-side record of an issued refresh token. Only a
+// hash of the token is kept. Each login starts a family; every token obtained
+// by rotating a member of the family joins it, so revoking the family ends
+// that login on that device.
+type RefreshToken struct {
+	// ID is the token's jti claim.
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	FamilyID  uuid.UUID
+	TokenHash string
+	Device    string
+	// AMR is how the user authenticated when the family started. Access
+	// tokens obtained by rotation carry it on.
+	AMR       []string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	// UsedAt is set once the token has been exchanged for a new one.
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+}
+
+func NewRefreshToken(id, userID, familyID uuid.UUID, token, device string, amr []string, expiresAt time.Time) *RefreshToken {
+	return &RefreshToken{
+		ID:        id,
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: HashToken(token),
+		Device:    device,
+		AMR:       amr,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}
+}
+
+// HashToken returns the hex SHA-256 of a token. Refresh tokens are long
+// random-looking strings, so a fast hash is enough to make a leaked table
+// useless.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Matches reports whether token is the one this record was issued for.
+func (t *RefreshToken) Matches(token string) bool {
+	return subtle.ConstantTimeCompare([]byte(t.TokenHash), []byte(HashToken(token))) == 1
+}
+
+type RefreshTokenRepository interface {
+	Save(ctx context.Context, token *RefreshToken) error
+	GetByID(ctx context.Context, id uuid.UUID) (*RefreshToken, error)
+	// MarkUsed records that the token was rotated. It reports false when the
+	// token was already used or revoked, so only one rotation can succeed.
+	MarkUsed(ctx context.Context, id uuid.UUID, at time.Time) (bool, error)
+	RevokeFamily(ctx context.Context, familyID uuid.UUID, at time.Time) error
+	RevokeUser(ctx context.Context, userID uuid.UUID, at time.Time) error
+}
@@ -1,9 +1,15 @@
 package domain
 
 import (
+	"context"
+	"errors"
+	"net/mail"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -13,20 +19,86 @@ const (
 	RoleAdmin    Role = "admin"
 	RoleManager  Role = "manager"
 	RoleEmployee Role = "employee"
+	// RoleCustomer is held by a client of the shop using the customer
+	// portal. It holds no staff permissions and is only given by
+	// NewCustomer, which links the account to its client.
+	RoleCustomer Role = "customer"
+)
+
+// Valid reports whether r is a staff role, one a user can be registered
+// with or changed to. Customer accounts are created with NewCustomer.
+func (r Role) Valid() bool {
+	switch r {
+	case RoleAdmin, RoleManager, RoleEmployee:
+		return true
+	}
+	return false
+}
+
+// Statuses a user listing can be filtered by.
+const (
+	UserStatusActive      = "active"
+	UserStatusDeactivated = "deactivated"
+)
+
+// MinPasswordLength is the shortest password NewUser and SetPassword accept.
+const MinPasswordLength = 8
+
+var (
+	ErrUserNotFound    = errors.New("user not found")
+	ErrEmailTaken      = errors.New("email already registered")
+	ErrInvalidName     = errors.New("name is required")
+	ErrInvalidEmail    = errors.New("invalid email")
+	ErrInvalidRole     = errors.New("invalid role")
+	ErrWeakPassword    = errors.New("password must have at least 8 characters, including a letter and a digit")
+	ErrUserDeactivated = errors.New("user is deactivated")
+	// ErrCustomerAccount is returned when a customer account is given a
+	// staff role; its client link would be left dangling.
+	ErrCustomerAccount     = errors.New("customer accounts cannot hold staff roles")
+	ErrClientAccountExists = errors.New("client already has a portal account")
 )
 
 type User struct {
-	ID        uuid.UUID
-	Name      string
-	Email     string
-	Password  string // Hashed
-	Role      Role
+	ID       uuid.UUID
+	Name     string
+	Email    string
+	Password string `json:"-"` // Hashed; never serialized
+	Role     Role
+	// DeactivatedAt is set while an admin has switched the account off.
+	DeactivatedAt *time.Time
+	// EmailVerifiedAt is set once the user redeemed a verification token.
+	EmailVerifiedAt *time.Time
+	// PasswordChangedAt is set whenever the password is replaced. Refresh
+	// and mailed tokens issued before it are no longer accepted.
+	PasswordChangedAt *time.Time
+	// TOTPSecret is the base32 secret shared with the user's authenticator
+	// app. It is set when enrolment starts; MFAEnabledAt once a code from
+	// the app confirmed it.
+	TOTPSecret   string `json:"-"`
+	MFAEnabledAt *time.Time
+	// TOTPLastStep is the time step of the last code accepted, so a code
+	// cannot be used twice.
+	TOTPLastStep int64 `json:"-"`
+	// ClientID links a customer account to the client it acts for. It is
+	// set for RoleCustomer only.
+	ClientID  *uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
 
 func NewUser(name, email, password string, role Role) (*User, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrInvalidName
+	}
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	if !role.Valid() {
+		return nil, ErrInvalidRole
+	}
+	hashedPassword, err := hashPassword(password)
 	if err != nil {
 		return nil, err
 	}
@@ -35,20 +107,150 @@ func NewUser(name, email, password string, role Role) (*User, error) {
 		ID:        uuid.New(),
 		Name:      name,
 		Email:     email,
-		Password:  string(hashedPassword),
+		Password:  hashedPassword,
 		Role:      role,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}, nil
 }
 
+// NewCustomer creates the portal account of a client. It starts without a
+// password: the customer signs in with a mailed link, or sets a password
+// through the password reset flow.
+func NewCustomer(clientID uuid.UUID, name, email string) (*User, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrInvalidName
+	}
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return nil, err
+	}
+
+	return &User{
+		ID:        uuid.New(),
+		Name:      name,
+		Email:     email,
+		Role:      RoleCustomer,
+		ClientID:  &clientID,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}, nil
+}
+
+// IsCustomer reports whether the user is a portal account linked to a client.
+func (u *User) IsCustomer() bool {
+	return u.Role == RoleCustomer && u.ClientID != nil
+}
+
 func (u *User) CheckPassword(password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
 	return err == nil
 }
 
+// IsActive reports whether the user may log in.
+func (u *User) IsActive() bool {
+	return u.DeactivatedAt == nil
+}
+
+func (u *User) Deactivate() {
+	if u.DeactivatedAt == nil {
+		now := time.Now()
+		u.DeactivatedAt = &now
+		u.UpdatedAt = now
+	}
+}
+
+func (u *User) Reactivate() {
+	if u.DeactivatedAt != nil {
+		u.DeactivatedAt = nil
+		u.UpdatedAt = time.Now()
+	}
+}
+
+func (u *User) ChangeRole(role Role) error {
+	if u.Role == RoleCustomer {
+		return ErrCustomerAccount
+	}
+	if !role.Valid() {
+		return ErrInvalidRole
+	}
+	u.Role = role
+	u.UpdatedAt = time.Now()
+	return nil
+}
+
+func (u *User) SetPassword(password string) error {
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	u.Password = hashedPassword
+	u.PasswordChangedAt = &now
+	u.UpdatedAt = now
+	return nil
+}
+
+// IssuedBeforePasswordChange reports whether something issued at t predates
+// the user's last password change.
+func (u *User) IssuedBeforePasswordChange(t time.Time) bool {
+	return u.PasswordChangedAt != nil && t.Before(*u.PasswordChangedAt)
+}
+
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+func (u *User) VerifyEmail() {
+	if u.EmailVerifiedAt == nil {
+		now := time.Now()
+		u.EmailVerifiedAt = &now
+		u.UpdatedAt = now
+	}
+}
+
+// normalizeEmail accepts a bare address and lower-cases it, so the unique
+// index on users.email does not depend on how the address was typed.
+func normalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return "", ErrInvalidEmail
+	}
+	return email, nil
+}
+
+func hashPassword(password string) (string, error) {
+	var letter, digit bool
+	for _, c := range password {
+		letter = letter || unicode.IsLetter(c)
+		digit = digit || unicode.IsDigit(c)
+	}
+	if len([]rune(password)) < MinPasswordLength || !letter || !digit {
+		return "", ErrWeakPassword
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
 type UserRepository interface {
-	Save(user *User) error
-	GetByEmail(email string) (*User, error)
-	GetByID(id uuid.UUID) (*User, error)
+	Save(ctx context.Context, user *User) error
+	// Update writes back a user loaded from the repository.
+	Update(ctx context.Context, user *User) error
+	GetByEmail(ctx context.Context, email string) (*User, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*User, error)
+	// List pages through users. Search matches name or email and Status
+	// holds UserStatusActive or UserStatusDeactivated.
+	List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*User], error)
+	// ListByRole returns the active users holding role.
+	ListByRole(ctx context.Context, role Role) ([]*User, error)
+	// UseTOTPStep records step as the last TOTP step the user used. It
+	// reports false when that step or a later one was already used, so each
+	// code works only once, even across concurrent logins.
+	UseTOTPStep(ctx context.Context, id uuid.UUID, step int64) (bool, error)
 }
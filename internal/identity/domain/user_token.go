@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TokenPurpose says what a UserToken may be redeemed for.
+type TokenPurpose string
+
+const (
+	TokenPurposePasswordReset     TokenPurpose = "password_reset"
+	TokenPurposeEmailVerification TokenPurpose = "email_verification"
+	// TokenPurposeMagicLink signs a customer in to the portal.
+	TokenPurposeMagicLink TokenPurpose = "magic_link"
+)
+
+const (
+	PasswordResetTokenTTL     = time.Hour
+	EmailVerificationTokenTTL = 48 * time.Hour
+	MagicLinkTokenTTL         = 15 * time.Minute
+)
+
+var (
+	ErrUserTokenNotFound = errors.New("user token not found")
+	// ErrInvalidUserToken covers every way a token can fail to redeem:
+	// unknown, expired, already used, meant for something else or
+	// outdated by a password change.
+	ErrInvalidUserToken = errors.New("invalid or expired token")
+)
+
+// UserToken is a single-use token mailed to a user to prove they control
+// their address. Like refresh tokens, only a hash of it is stored.
+type UserToken struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Purpose   TokenPurpose
+	TokenHash string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UsedAt    *time.Time
+}
+
+// NewUserToken creates a token for purpose that lives for ttl. It returns
+// the record to store and the token to send to the user.
+func NewUserToken(userID uuid.UUID, purpose TokenPurpose, ttl time.Duration) (*UserToken, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	now := time.Now()
+	return &UserToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: HashToken(token),
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}, token, nil
+}
+
+// Redeemable reports whether the token can still be used for purpose by
+// user. Tokens issued before the user's last password change are dead, so
+// a reset cannot be replayed with an older link.
+func (t *UserToken) Redeemable(purpose TokenPurpose, user *User, now time.Time) bool {
+	if t.Purpose != purpose || t.UsedAt != nil || !now.Before(t.ExpiresAt) {
+		return false
+	}
+	return !user.IssuedBeforePasswordChange(t.CreatedAt)
+}
+
+type UserTokenRepository interface {
+	Save(ctx context.Context, token *UserToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*UserToken, error)
+	// MarkUsed spends the token. It reports false when the token was already
+	// used, so only one redemption can succeed.
+	MarkUsed(ctx context.Context, id uuid.UUID, at time.Time) (bool, error)
+}
@@ -0,0 +1,22 @@
+package infrastructure
+
+import (
+	"context"
+
+	"github.com/noggrj/autorepair/internal/identity/domain"
+	"github.com/noggrj/autorepair/internal/platform/db"
+)
+
+type PostgresAuditLog struct {
+	db db.Connection
+}
+
+func NewPostgresAuditLog(db db.Connection) *PostgresAuditLog {
+	return &PostgresAuditLog{db: db}
+}
+
+func (l *PostgresAuditLog) Record(ctx context.Context, entry *domain.AuditEntry) error {
+	query := `INSERT INTO audit_log (id, event, actor_id, subject, ip, detail, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err := l.db.Exec(ctx, query, entry.ID, entry.Event, entry.ActorID, entry.Subject, entry.IP, entry.Detail, entry.CreatedAt)
+	return err
+}
@@ -0,0 +1,66 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/noggrj/autorepair/internal/identity/domain"
+	"github.com/noggrj/autorepair/internal/platform/db"
+)
+
+type PostgresLoginAttemptRepository struct {
+	db db.Connection
+}
+
+func NewPostgresLoginAttemptRepository(db db.Connection) *PostgresLoginAttemptRepository {
+	return &PostgresLoginAttemptRepository{db: db}
+}
+
+func (r *PostgresLoginAttemptRepository) Get(ctx context.Context, scope domain.LoginScope, key string) (*domain.LoginAttempts, error) {
+	query := `SELECT failures, last_failure_at, locked_until FROM login_attempts WHERE scope = $1 AND key = $2`
+	a := domain.LoginAttempts{Scope: scope, Key: key}
+	err := r.db.QueryRow(ctx, query, scope, key).Scan(&a.Failures, &a.LastFailureAt, &a.LockedUntil)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// RecordFailure counts the failure in a single upsert, so concurrent logins
+// against the same key each see a distinct count.
+func (r *PostgresLoginAttemptRepository) RecordFailure(ctx context.Context, scope domain.LoginScope, key string, at time.Time, window time.Duration) (*domain.LoginAttempts, error) {
+	query := `
+		INSERT INTO login_attempts (scope, key, failures, last_failure_at)
+		VALUES ($1, $2, 1, $3)
+		ON CONFLICT (scope, key) DO UPDATE SET
+			failures = CASE
+				WHEN login_attempts.last_failure_at < $4 OR login_attempts.locked_until <= $3 THEN 1
+				ELSE login_attempts.failures + 1
+			END,
+			locked_until = CASE
+				WHEN login_attempts.locked_until <= $3 THEN NULL
+				ELSE login_attempts.locked_until
+			END,
+			last_failure_at = $3
+		RETURNING failures, last_failure_at, locked_until`
+	a := domain.LoginAttempts{Scope: scope, Key: key}
+	err := r.db.QueryRow(ctx, query, scope, key, at, at.Add(-window)).Scan(&a.Failures, &a.LastFailureAt, &a.LockedUntil)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *PostgresLoginAttemptRepository) Lock(ctx context.Context, scope domain.LoginScope, key string, until time.Time) error {
+	query := `UPDATE login_attempts SET locked_until = $3 WHERE scope = $1 AND key = $2`
+	_, err := r.db.Exec(ctx, query, scope, key, until)
+	return err
+}
+
+func (r *PostgresLoginAttemptRepository) Reset(ctx context.Context, scope domain.LoginScope, key string) error {
+	query := `DELETE FROM login_attempts WHERE scope = $1 AND key = $2`
+	_, err := r.db.Exec(ctx, query, scope, key)
+	return err
+}
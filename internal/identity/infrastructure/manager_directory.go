@@ -0,0 +1,29 @@
+package infrastructure
+
+import (
+	"context"
+
+	"github.com/noggrj/autorepair/internal/identity/domain"
+)
+
+// ManagerDirectory resolves the email addresses of users with the manager role.
+type ManagerDirectory struct {
+	users domain.UserRepository
+}
+
+func NewManagerDirectory(users domain.UserRepository) *ManagerDirectory {
+	return &ManagerDirectory{users: users}
+}
+
+func (d *ManagerDirectory) ManagerEmails(ctx context.Context) ([]string, error) {
+	managers, err := d.users.ListByRole(ctx, domain.RoleManager)
+	if err != nil {
+		return nil, err
+	}
+
+	emails := make([]string, 0, len(managers))
+	for _, u := range managers {
+		emails = append(emails, u.Email)
+	}
+	return emails, nil
+}
@@ -0,0 +1,34 @@
+package infrastructure
+
+import (
+	"context"
+	"sync"
+
+	"github.com/noggrj/autorepair/internal/identity/domain"
+)
+
+// MemoryAuditLog keeps audit entries in process memory, for tests.
+type MemoryAuditLog struct {
+	mu      sync.Mutex
+	entries []*domain.AuditEntry
+}
+
+func NewMemoryAuditLog() *MemoryAuditLog {
+	return &MemoryAuditLog{}
+}
+
+func (l *MemoryAuditLog) Record(ctx context.Context, entry *domain.AuditEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries = append(l.entries, entry)
+	return nil
+}
+
+// Entries returns the recorded entries, oldest first.
+func (l *MemoryAuditLog) Entries() []*domain.AuditEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]*domain.AuditEntry(nil), l.entries...)
+}
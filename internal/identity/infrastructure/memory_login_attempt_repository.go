@@ -0,0 +1,73 @@
+package infrastructure
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/noggrj/autorepair/internal/identity/domain"
+)
+
+type loginAttemptKey struct {
+	scope domain.LoginScope
+	key   string
+}
+
+// MemoryLoginAttemptRepository keeps login attempts in process memory. It
+// follows the same rules as the Postgres repository and is meant for tests
+// and single-instance development runs.
+type MemoryLoginAttemptRepository struct {
+	mu       sync.Mutex
+	attempts map[loginAttemptKey]domain.LoginAttempts
+}
+
+func NewMemoryLoginAttemptRepository() *MemoryLoginAttemptRepository {
+	return &MemoryLoginAttemptRepository{attempts: make(map[loginAttemptKey]domain.LoginAttempts)}
+}
+
+func (r *MemoryLoginAttemptRepository) Get(ctx context.Context, scope domain.LoginScope, key string) (*domain.LoginAttempts, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	a, ok := r.attempts[loginAttemptKey{scope, key}]
+	if !ok {
+		a = domain.LoginAttempts{Scope: scope, Key: key}
+	}
+	return &a, nil
+}
+
+func (r *MemoryLoginAttemptRepository) RecordFailure(ctx context.Context, scope domain.LoginScope, key string, at time.Time, window time.Duration) (*domain.LoginAttempts, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k := loginAttemptKey{scope, key}
+	a, ok := r.attempts[k]
+	lockExpired := a.LockedUntil != nil && !at.Before(*a.LockedUntil)
+	if !ok || a.LastFailureAt.Before(at.Add(-window)) || lockExpired {
+		a = domain.LoginAttempts{Scope: scope, Key: key}
+	}
+	a.Failures++
+	a.LastFailureAt = at
+	r.attempts[k] = a
+	return &a, nil
+}
+
+func (r *MemoryLoginAttemptRepository) Lock(ctx context.Context, scope domain.LoginScope, key string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k := loginAttemptKey{scope, key}
+	if a, ok := r.attempts[k]; ok {
+		a.LockedUntil = &until
+		r.attempts[k] = a
+	}
+	return nil
+}
+
+func (r *MemoryLoginAttemptRepository) Reset(ctx context.Context, scope domain.LoginScope, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.attempts, loginAttemptKey{scope, key})
+	return nil
+}
@@ -0,0 +1,38 @@
+package infrastructure
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/noggrj/autorepair/internal/platform/db"
+)
+
+type PostgresRecoveryCodeRepository struct {
+	db db.Connection
+}
+
+func NewPostgresRecoveryCodeRepository(db db.Connection) *PostgresRecoveryCodeRepository {
+	return &PostgresRecoveryCodeRepository{db: db}
+}
+
+func (r *PostgresRecoveryCodeRepository) Replace(ctx context.Context, userID uuid.UUID, hashes []string) error {
+	return db.RunInTx(ctx, r.db, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+			return err
+		}
+		query := `INSERT INTO recovery_codes (user_id, code_hash) SELECT $1, unnest($2::text[])`
+		_, err := tx.Exec(ctx, query, userID, hashes)
+		return err
+	})
+}
+
+func (r *PostgresRecoveryCodeRepository) Use(ctx context.Context, userID uuid.UUID, hash string, at time.Time) (bool, error) {
+	query := `UPDATE recovery_codes SET used_at = $3 WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+	result, err := r.db.Exec(ctx, query, userID, hash, at)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() == 1, nil
+}
@@ -0,0 +1,70 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/noggrj/autorepair/internal/identity/domain"
+	"github.com/noggrj/autorepair/internal/platform/db"
+)
+
+type PostgresRefreshTokenRepository struct {
+	db db.Connection
+}
+
+func NewPostgresRefreshTokenRepository(db db.Connection) *PostgresRefreshTokenRepository {
+	return &PostgresRefreshTokenRepository{db: db}
+}
+
+func (r *PostgresRefreshTokenRepository) Save(ctx context.Context, token *domain.RefreshToken) error {
+	query := `INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, device, amr, expires_at, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	_, err := r.db.Exec(ctx, query, token.ID, token.UserID, token.FamilyID, token.TokenHash, token.Device, amrOrEmpty(token.AMR), token.ExpiresAt, token.CreatedAt)
+	return err
+}
+
+func (r *PostgresRefreshTokenRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.RefreshToken, error) {
+	query := `SELECT id, user_id, family_id, token_hash, device, amr, expires_at, created_at, used_at, revoked_at FROM refresh_tokens WHERE id = $1`
+	row := r.db.QueryRow(ctx, query, id)
+
+	var t domain.RefreshToken
+	err := row.Scan(&t.ID, &t.UserID, &t.FamilyID, &t.TokenHash, &t.Device, &t.AMR, &t.ExpiresAt, &t.CreatedAt, &t.UsedAt, &t.RevokedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrRefreshTokenNotFound
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *PostgresRefreshTokenRepository) MarkUsed(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	query := `UPDATE refresh_tokens SET used_at = $2 WHERE id = $1 AND used_at IS NULL AND revoked_at IS NULL`
+	result, err := r.db.Exec(ctx, query, id, at)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+func (r *PostgresRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID, at time.Time) error {
+	query := `UPDATE refresh_tokens SET revoked_at = $2 WHERE family_id = $1 AND revoked_at IS NULL`
+	_, err := r.db.Exec(ctx, query, familyID, at)
+	return err
+}
+
+func (r *PostgresRefreshTokenRepository) RevokeUser(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	query := `UPDATE refresh_tokens SET revoked_at = $2 WHERE user_id = $1 AND revoked_at IS NULL`
+	_, err := r.db.Exec(ctx, query, userID, at)
+	return err
+}
+
+// amrOrEmpty stores a missing list as an empty array; the column is NOT NULL.
+func amrOrEmpty(amr []string) []string {
+	if amr == nil {
+		return []string{}
+	}
+	return amr
+}
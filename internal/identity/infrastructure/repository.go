@@ -3,13 +3,26 @@ package infrastructure
 import (
 	"context"
 	"errors"
+	"slices"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/noggrj/autorepair/internal/identity/domain"
 	"github.com/noggrj/autorepair/internal/platform/db"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
 )
 
+// userColumns is the column list every user query selects, in scanUser order.
+const userColumns = `id, name, email, password_hash, role, deactivated_at, email_verified_at, password_changed_at, totp_secret, mfa_enabled_at, totp_last_step, client_id, created_at, updated_at`
+
+// uniqueViolation is the Postgres error code raised when an insert or update
+// collides with a unique index.
+const uniqueViolation = "23505"
+
+// clientAccountIndex is the unique index allowing one account per client.
+const clientAccountIndex = "idx_users_client_id"
+
 type PostgresUserRepository struct {
 	db db.Connection
 }
@@ -18,42 +31,127 @@ func NewPostgresUserRepository(db db.Connection) *PostgresUserRepository {
 	return &PostgresUserRepository{db: db}
 }
 
-func (r *PostgresUserRepository) Save(user *domain.User) error {
-	query := `INSERT INTO users (id, name, email, password_hash, role, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
-	_, err := r.db.Exec(context.Background(), query, user.ID, user.Name, user.Email, user.Password, user.Role, user.CreatedAt, user.UpdatedAt)
-	return err
+func (r *PostgresUserRepository) Save(ctx context.Context, user *domain.User) error {
+	query := `INSERT INTO users (` + userColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`
+	_, err := r.db.Exec(ctx, query, user.ID, user.Name, user.Email, user.Password, user.Role, user.DeactivatedAt, user.EmailVerifiedAt, user.PasswordChangedAt, user.TOTPSecret, user.MFAEnabledAt, user.TOTPLastStep, user.ClientID, user.CreatedAt, user.UpdatedAt)
+	return translateUniqueViolation(err)
 }
 
-func (r *PostgresUserRepository) GetByEmail(email string) (*domain.User, error) {
-	query := `SELECT id, name, email, password_hash, role, created_at, updated_at FROM users WHERE email = $1`
-	row := r.db.QueryRow(context.Background(), query, email)
+func (r *PostgresUserRepository) Update(ctx context.Context, user *domain.User) error {
+	query := `UPDATE users SET name = $2, email = $3, password_hash = $4, role = $5, deactivated_at = $6, email_verified_at = $7, password_changed_at = $8, totp_secret = $9, mfa_enabled_at = $10, totp_last_step = $11, client_id = $12, updated_at = $13 WHERE id = $1`
+	result, err := r.db.Exec(ctx, query, user.ID, user.Name, user.Email, user.Password, user.Role, user.DeactivatedAt, user.EmailVerifiedAt, user.PasswordChangedAt, user.TOTPSecret, user.MFAEnabledAt, user.TOTPLastStep, user.ClientID, user.UpdatedAt)
+	if err != nil {
+		return translateUniqueViolation(err)
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
 
-	var user domain.User
-	var role string
-	err := row.Scan(&user.ID, &user.Name, &user.Email, &user.Password, &role, &user.CreatedAt, &user.UpdatedAt)
+func (r *PostgresUserRepository) UseTOTPStep(ctx context.Context, id uuid.UUID, step int64) (bool, error) {
+	query := `UPDATE users SET totp_last_step = $2 WHERE id = $1 AND (totp_last_step IS NULL OR totp_last_step < $2)`
+	result, err := r.db.Exec(ctx, query, id, step)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, errors.New("user not found")
-		}
-		return nil, err
+		return false, err
 	}
-	user.Role = domain.Role(role)
-	return &user, nil
+	return result.RowsAffected() == 1, nil
 }
 
-func (r *PostgresUserRepository) GetByID(id uuid.UUID) (*domain.User, error) {
-	query := `SELECT id, name, email, password_hash, role, created_at, updated_at FROM users WHERE id = $1`
-	row := r.db.QueryRow(context.Background(), query, id)
+// GetByEmail matches the address case-insensitively.
+func (r *PostgresUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE lower(email) = lower($1)`
+	return scanUser(r.db.QueryRow(ctx, query, email))
+}
+
+func (r *PostgresUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
+	return scanUser(r.db.QueryRow(ctx, query, id))
+}
 
+var userListing = db.Listing[*domain.User]{
+	Sorts: map[string]db.SortField[*domain.User]{
+		"name":       {{Expr: "name", Cast: "text", Value: func(u *domain.User) string { return u.Name }}},
+		"email":      {{Expr: "email", Cast: "text", Value: func(u *domain.User) string { return u.Email }}},
+		"created_at": {{Expr: "created_at", Cast: "timestamptz", Value: func(u *domain.User) string { return db.FormatTime(u.CreatedAt) }}},
+	},
+	DefaultSort: "name",
+	ID:          func(u *domain.User) uuid.UUID { return u.ID },
+}
+
+func (r *PostgresUserRepository) List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*domain.User], error) {
+	var f db.Filter
+	if q.Search != "" {
+		f.Contains(q.Search, "name", "email")
+	}
+	// Asking for both statuses is the same as asking for neither.
+	active := slices.Contains(q.Status, domain.UserStatusActive)
+	deactivated := slices.Contains(q.Status, domain.UserStatusDeactivated)
+	if active && !deactivated {
+		f.Where("deactivated_at IS NULL")
+	}
+	if deactivated && !active {
+		f.Where("deactivated_at IS NOT NULL")
+	}
+	f.CreatedBetween("created_at", q)
+
+	query, args, err := userListing.Query(`SELECT `+userColumns+` FROM users`, &f, q)
+	if err != nil {
+		return sharedkernel.Page[*domain.User]{}, err
+	}
+	users, err := r.query(ctx, query, args...)
+	if err != nil {
+		return sharedkernel.Page[*domain.User]{}, err
+	}
+	return userListing.Page(users, q), nil
+}
+
+func (r *PostgresUserRepository) ListByRole(ctx context.Context, role domain.Role) ([]*domain.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE role = $1 AND deactivated_at IS NULL ORDER BY name`
+	return r.query(ctx, query, role)
+}
+
+func (r *PostgresUserRepository) query(ctx context.Context, query string, args ...any) ([]*domain.User, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*domain.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func scanUser(row pgx.Row) (*domain.User, error) {
 	var user domain.User
 	var role string
-	err := row.Scan(&user.ID, &user.Name, &user.Email, &user.Password, &role, &user.CreatedAt, &user.UpdatedAt)
+	err := row.Scan(&user.ID, &user.Name, &user.Email, &user.Password, &role, &user.DeactivatedAt, &user.EmailVerifiedAt, &user.PasswordChangedAt, &user.TOTPSecret, &user.MFAEnabledAt, &user.TOTPLastStep, &user.ClientID, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, errors.New("user not found")
+			return nil, domain.ErrUserNotFound
 		}
 		return nil, err
 	}
 	user.Role = domain.Role(role)
 	return &user, nil
 }
+
+// translateUniqueViolation reports a clash on the client index as
+// ErrClientAccountExists and one on the email index as ErrEmailTaken.
+func translateUniqueViolation(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		if pgErr.ConstraintName == clientAccountIndex {
+			return domain.ErrClientAccountExists
+		}
+		return domain.ErrEmailTaken
+	}
+	return err
+}
@@ -0,0 +1,52 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/noggrj/autorepair/internal/identity/domain"
+	"github.com/noggrj/autorepair/internal/platform/db"
+)
+
+type PostgresUserTokenRepository struct {
+	db db.Connection
+}
+
+func NewPostgresUserTokenRepository(db db.Connection) *PostgresUserTokenRepository {
+	return &PostgresUserTokenRepository{db: db}
+}
+
+func (r *PostgresUserTokenRepository) Save(ctx context.Context, token *domain.UserToken) error {
+	query := `INSERT INTO user_tokens (id, user_id, purpose, token_hash, expires_at, created_at) VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err := r.db.Exec(ctx, query, token.ID, token.UserID, token.Purpose, token.TokenHash, token.ExpiresAt, token.CreatedAt)
+	return err
+}
+
+func (r *PostgresUserTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*domain.UserToken, error) {
+	query := `SELECT id, user_id, purpose, token_hash, expires_at, created_at, used_at FROM user_tokens WHERE token_hash = $1`
+	row := r.db.QueryRow(ctx, query, tokenHash)
+
+	var t domain.UserToken
+	var purpose string
+	err := row.Scan(&t.ID, &t.UserID, &purpose, &t.TokenHash, &t.ExpiresAt, &t.CreatedAt, &t.UsedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrUserTokenNotFound
+		}
+		return nil, err
+	}
+	t.Purpose = domain.TokenPurpose(purpose)
+	return &t, nil
+}
+
+func (r *PostgresUserTokenRepository) MarkUsed(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	query := `UPDATE user_tokens SET used_at = $2 WHERE id = $1 AND used_at IS NULL`
+	result, err := r.db.Exec(ctx, query, id, at)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() == 1, nil
+}
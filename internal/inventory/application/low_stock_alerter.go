@@ -0,0 +1,61 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/noggrj/autorepair/internal/inventory/domain"
+	notificationDomain "github.com/noggrj/autorepair/internal/notification/domain"
+)
+
+// ManagerDirectory provides the addresses that receive stock alerts.
+type ManagerDirectory interface {
+	ManagerEmails(ctx context.Context) ([]string, error)
+}
+
+// LowStockAlerter emails managers when a part drops below its minimum quantity.
+type LowStockAlerter struct {
+	managers ManagerDirectory
+	notifier notificationDomain.EmailService
+}
+
+func NewLowStockAlerter(managers ManagerDirectory, notifier notificationDomain.EmailService) *LowStockAlerter {
+	return &LowStockAlerter{
+		managers: managers,
+		notifier: notifier,
+	}
+}
+
+// NotifyLowStock alerts every manager about the given parts that crossed below
+// their minimum. It must be called after the stock change is committed;
+// failures are ignored so an alert never undoes a stock change.
+func (a *LowStockAlerter) NotifyLowStock(ctx context.Context, parts ...*domain.Part) {
+	var crossed []*domain.Part
+	for _, part := range parts {
+		if part.CrossedMinimum() {
+			crossed = append(crossed, part)
+		}
+	}
+	if len(crossed) == 0 {
+		return
+	}
+
+	emails, err := a.managers.ManagerEmails(ctx)
+	if err != nil {
+		// Log error but don't fail flow
+		return
+	}
+
+	for _, part := range crossed {
+		subject := fmt.Sprintf("Low stock: %s", part.Name)
+		body := fmt.Sprintf("Part %s (%s) is below its minimum: %d in stock, minimum %d. Suggested reorder: %d units.",
+			part.Name, part.ID, part.Quantity, part.MinQuantity, part.ReorderQuantity)
+
+		for _, to := range emails {
+			if err := a.notifier.SendEmail(to, subject, body); err != nil {
+				// Log error but continue
+				_ = err // ignore error
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package application
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/inventory/domain"
+)
+
+// OpenOrderChecker tells whether a part is still referenced by orders that
+// are not finished yet. It is implemented by the service context.
+type OpenOrderChecker interface {
+	HasOpenOrdersWithPart(ctx context.Context, partID uuid.UUID) (bool, error)
+}
+
+// StockAlerter is told about parts whose stock was removed, once the change
+// is committed.
+type StockAlerter interface {
+	NotifyLowStock(ctx context.Context, parts ...*domain.Part)
+}
+
+type PartService struct {
+	uow    UnitOfWork
+	alerts StockAlerter
+}
+
+func NewPartService(uow UnitOfWork, alerts StockAlerter) *PartService {
+	return &PartService{
+		uow:    uow,
+		alerts: alerts,
+	}
+}
+
+// AdjustStock applies a signed quantity change to a part: positive values go
+// through AddStock, negative ones through RemoveStock. The part row is locked
+// for the duration of the change.
+func (s *PartService) AdjustStock(ctx context.Context, partID uuid.UUID, delta int, movementType domain.MovementType, reference string) (*domain.Part, error) {
+	if delta == 0 {
+		return nil, domain.ErrInvalidQuantity
+	}
+
+	var part *domain.Part
+	err := s.uow.Do(ctx, func(repos TxRepositories) error {
+		var err error
+		part, err = repos.Parts.GetByIDForUpdate(ctx, partID)
+		if err != nil {
+			return err
+		}
+
+		if delta > 0 {
+			err = part.AddStock(delta, movementType, reference)
+		} else {
+			err = part.RemoveStock(-delta, movementType, reference)
+		}
+		if err != nil {
+			return err
+		}
+
+		return repos.Parts.Update(ctx, part)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.alerts.NotifyLowStock(ctx, part)
+	return part, nil
+}
+
+// Archive takes a part out of the catalogue unless an open order or a
+// purchase order still waiting for it references it. Parts are never
+// deleted, so their stock ledger is kept. The part row is locked and the
+// checks and the archiving run in one transaction.
+func (s *PartService) Archive(ctx context.Context, partID uuid.UUID) error {
+	return s.uow.Do(ctx, func(repos TxRepositories) error {
+		if _, err := repos.Parts.GetByIDForUpdate(ctx, partID); err != nil {
+			return err
+		}
+
+		inUse, err := repos.OpenOrders.HasOpenOrdersWithPart(ctx, partID)
+		if err != nil {
+			return err
+		}
+		if !inUse {
+			inUse, err = repos.PurchaseOrders.HasOpenWithPart(ctx, partID)
+			if err != nil {
+				return err
+			}
+		}
+		if inUse {
+			return domain.ErrPartInUse
+		}
+
+		return repos.Parts.Archive(ctx, partID)
+	})
+}
@@ -0,0 +1,127 @@
+package application
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/inventory/domain"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
+)
+
+// PurchaseOrderLineInput is one part to order when creating a purchase order.
+type PurchaseOrderLineInput struct {
+	PartID   uuid.UUID
+	Quantity int
+	UnitCost sharedkernel.Money
+}
+
+type PurchaseOrderService struct {
+	repo      domain.PurchaseOrderRepository
+	suppliers domain.SupplierRepository
+	parts     domain.PartRepository
+	uow       UnitOfWork
+}
+
+func NewPurchaseOrderService(
+	repo domain.PurchaseOrderRepository,
+	suppliers domain.SupplierRepository,
+	parts domain.PartRepository,
+	uow UnitOfWork,
+) *PurchaseOrderService {
+	return &PurchaseOrderService{
+		repo:      repo,
+		suppliers: suppliers,
+		parts:     parts,
+		uow:       uow,
+	}
+}
+
+// Create drafts a purchase order for an existing supplier and parts.
+func (s *PurchaseOrderService) Create(ctx context.Context, supplierID uuid.UUID, lines []PurchaseOrderLineInput) (*domain.PurchaseOrder, error) {
+	if _, err := s.suppliers.GetByID(ctx, supplierID); err != nil {
+		return nil, err
+	}
+
+	po := domain.NewPurchaseOrder(supplierID)
+	for _, in := range lines {
+		if _, err := s.parts.GetByID(ctx, in.PartID); err != nil {
+			return nil, err
+		}
+		if _, err := po.AddLine(in.PartID, in.Quantity, in.UnitCost); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.Save(ctx, po); err != nil {
+		return nil, err
+	}
+	return po, nil
+}
+
+// Send marks a draft purchase order as placed with the supplier.
+func (s *PurchaseOrderService) Send(ctx context.Context, id uuid.UUID) (*domain.PurchaseOrder, error) {
+	var po *domain.PurchaseOrder
+	err := s.uow.Do(ctx, func(repos TxRepositories) error {
+		var err error
+		po, err = repos.PurchaseOrders.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := po.Send(); err != nil {
+			return err
+		}
+		return repos.PurchaseOrders.Save(ctx, po)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return po, nil
+}
+
+// ReceiveLine brings qty units of a purchase order line into stock and
+// updates the part's cost price. The receipt, the line progress and the
+// part's stock movement are committed together. A nil unitCost means the
+// goods arrived at the agreed cost.
+func (s *PurchaseOrderService) ReceiveLine(ctx context.Context, id, lineID uuid.UUID, qty int, unitCost *sharedkernel.Money) (*domain.PurchaseOrder, error) {
+	var po *domain.PurchaseOrder
+	err := s.uow.Do(ctx, func(repos TxRepositories) error {
+		var err error
+		po, err = repos.PurchaseOrders.GetByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		line := po.Line(lineID)
+		if line == nil {
+			return domain.ErrPurchaseOrderLineNotFound
+		}
+		cost := line.UnitCost
+		if unitCost != nil {
+			cost = *unitCost
+		}
+
+		receipt, err := po.Receive(lineID, qty, cost)
+		if err != nil {
+			return err
+		}
+
+		part, err := repos.Parts.GetByIDForUpdate(ctx, receipt.PartID)
+		if err != nil {
+			return err
+		}
+		if err := part.AddStock(receipt.Quantity, domain.MovementPurchase, "purchase_order:"+po.ID.String()); err != nil {
+			return err
+		}
+		// The part is costed at its last purchase price.
+		part.CostPrice = receipt.UnitCost
+		if err := repos.Parts.Update(ctx, part); err != nil {
+			return err
+		}
+
+		return repos.PurchaseOrders.Save(ctx, po)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return po, nil
+}
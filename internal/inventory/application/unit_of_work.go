@@ -0,0 +1,20 @@
+package application
+
+import (
+	"context"
+
+	"github.com/noggrj/autorepair/internal/inventory/domain"
+)
+
+// TxRepositories are repositories bound to the same database transaction.
+type TxRepositories struct {
+	Parts          domain.PartRepository
+	PurchaseOrders domain.PurchaseOrderRepository
+	OpenOrders     OpenOrderChecker
+}
+
+// UnitOfWork runs fn atomically: either every change made through the
+// provided repositories is committed, or none is.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(repos TxRepositories) error) error
+}
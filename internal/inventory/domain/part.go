@@ -2,45 +2,155 @@ package domain
 
 import (
 	"errors"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
 )
 
-var ErrInsufficientStock = errors.New("insufficient stock")
+var (
+	ErrPartNotFound      = errors.New("part not found")
+	ErrPartInUse         = errors.New("part is referenced by open orders or purchase orders")
+	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrInvalidThreshold  = errors.New("stock thresholds cannot be negative")
+	ErrSKURequired       = errors.New("sku is required")
+	ErrDuplicateSKU      = errors.New("sku is already in use")
+)
 
 type Part struct {
-	ID          uuid.UUID
-	Name        string
-	Description string
-	Quantity    int
-	Price       float64
+	ID uuid.UUID
+	// SKU is the shop's own code for the part, unique across the catalogue.
+	SKU string
+	// ManufacturerCode is the maker's part number, if known.
+	ManufacturerCode string
+	Name             string
+	Description      string
+	Quantity         int
+	// CostPrice is what the shop pays for one unit (the last purchase cost);
+	// SalePrice is what it charges on an order.
+	CostPrice sharedkernel.Money
+	SalePrice sharedkernel.Money
+	// MinQuantity is the level below which the part is considered low on
+	// stock; zero disables the alert. ReorderQuantity is the suggested
+	// amount to buy when that happens.
+	MinQuantity     int
+	ReorderQuantity int
+
+	pendingMovements []*StockMovement
+	crossedMinimum   bool
 }
 
-func NewPart(name, description string, quantity int, price float64) (*Part, error) {
+func NewPart(sku, name, description string, quantity int, costPrice, salePrice sharedkernel.Money) (*Part, error) {
+	sku = NormalizeSKU(sku)
+	if sku == "" {
+		return nil, ErrSKURequired
+	}
 	if quantity < 0 {
 		return nil, errors.New("quantity cannot be negative")
 	}
-	if price < 0 {
+	if costPrice.IsNegative() || salePrice.IsNegative() {
 		return nil, errors.New("price cannot be negative")
 	}
 
-	return &Part{
+	part := &Part{
 		ID:          uuid.New(),
+		SKU:         sku,
 		Name:        name,
 		Description: description,
-		Quantity:    quantity,
-		Price:       price,
-	}, nil
+		CostPrice:   costPrice,
+		SalePrice:   salePrice,
+	}
+	if quantity > 0 {
+		// Opening balance, so the ledger always accounts for the whole stock.
+		if err := part.AddStock(quantity, MovementAdjustment, "initial stock"); err != nil {
+			return nil, err
+		}
+	}
+	return part, nil
+}
+
+// NormalizeSKU trims and upper-cases a SKU so lookups are case-insensitive.
+func NormalizeSKU(sku string) string {
+	return strings.ToUpper(strings.TrimSpace(sku))
 }
 
-func (p *Part) RemoveStock(qty int) error {
+// Margin is the gross margin of selling one unit at the current prices.
+func (p *Part) Margin() sharedkernel.Money {
+	margin, _ := p.SalePrice.Sub(p.CostPrice)
+	return margin
+}
+
+// RemoveStock takes qty units out of stock and records the movement in the ledger.
+func (p *Part) RemoveStock(qty int, movementType MovementType, reference string) error {
+	if qty <= 0 {
+		return ErrInvalidQuantity
+	}
+	if !movementType.IsOutbound() {
+		return ErrInvalidMovementType
+	}
 	if p.Quantity < qty {
 		return ErrInsufficientStock
 	}
+	wasLow := p.IsLowStock()
 	p.Quantity -= qty
+	p.recordMovement(movementType, -qty, reference)
+	if !wasLow && p.IsLowStock() {
+		p.crossedMinimum = true
+	}
 	return nil
 }
 
-func (p *Part) AddStock(qty int) {
+// AddStock puts qty units into stock and records the movement in the ledger.
+func (p *Part) AddStock(qty int, movementType MovementType, reference string) error {
+	if qty <= 0 {
+		return ErrInvalidQuantity
+	}
+	if !movementType.IsInbound() {
+		return ErrInvalidMovementType
+	}
 	p.Quantity += qty
+	p.recordMovement(movementType, qty, reference)
+	return nil
+}
+
+func (p *Part) recordMovement(movementType MovementType, delta int, reference string) {
+	p.pendingMovements = append(p.pendingMovements, &StockMovement{
+		ID:         uuid.New(),
+		PartID:     p.ID,
+		Type:       movementType,
+		Quantity:   delta,
+		Reference:  reference,
+		OccurredAt: time.Now(),
+	})
+}
+
+// SetThresholds configures the low-stock alert for the part.
+func (p *Part) SetThresholds(minQuantity, reorderQuantity int) error {
+	if minQuantity < 0 || reorderQuantity < 0 {
+		return ErrInvalidThreshold
+	}
+	p.MinQuantity = minQuantity
+	p.ReorderQuantity = reorderQuantity
+	return nil
+}
+
+// IsLowStock reports whether the quantity is below the configured minimum.
+func (p *Part) IsLowStock() bool {
+	return p.Quantity < p.MinQuantity
+}
+
+// CrossedMinimum reports whether a RemoveStock call since the part was
+// loaded took it from a healthy level to below its minimum.
+func (p *Part) CrossedMinimum() bool {
+	return p.crossedMinimum
+}
+
+// PendingMovements returns the stock movements not yet persisted.
+func (p *Part) PendingMovements() []*StockMovement {
+	return p.pendingMovements
+}
+
+func (p *Part) ClearPendingMovements() {
+	p.pendingMovements = nil
 }
@@ -0,0 +1,200 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
+)
+
+var (
+	ErrPurchaseOrderNotFound      = errors.New("purchase order not found")
+	ErrPurchaseOrderLineNotFound  = errors.New("purchase order line not found")
+	ErrPurchaseOrderNotDraft      = errors.New("purchase order can only be changed while in draft")
+	ErrPurchaseOrderEmpty         = errors.New("purchase order has no lines")
+	ErrPurchaseOrderNotReceivable = errors.New("purchase order must be sent before it is received")
+	ErrReceivedQuantityExceeded   = errors.New("received quantity exceeds the quantity ordered")
+	ErrInvalidUnitCost            = errors.New("unit cost cannot be negative")
+	ErrDuplicatePurchaseOrderLine = errors.New("part is already on the purchase order")
+)
+
+type PurchaseOrderStatus string
+
+const (
+	PurchaseOrderDraft             PurchaseOrderStatus = "draft"
+	PurchaseOrderSent              PurchaseOrderStatus = "sent"
+	PurchaseOrderPartiallyReceived PurchaseOrderStatus = "partially_received"
+	PurchaseOrderReceived          PurchaseOrderStatus = "received"
+)
+
+// PurchaseOrder is an order placed with a supplier. It is built in draft,
+// sent to the supplier and then received line by line; each receipt brings
+// the goods into stock at the cost actually invoiced.
+type PurchaseOrder struct {
+	ID         uuid.UUID
+	SupplierID uuid.UUID
+	Status     PurchaseOrderStatus
+	Lines      []*PurchaseOrderLine
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	SentAt     *time.Time
+	ReceivedAt *time.Time
+
+	pendingReceipts []*PurchaseReceipt
+}
+
+type PurchaseOrderLine struct {
+	ID               uuid.UUID
+	PurchaseOrderID  uuid.UUID
+	PartID           uuid.UUID
+	Quantity         int
+	ReceivedQuantity int
+	UnitCost         sharedkernel.Money
+}
+
+// Remaining is the quantity still expected from the supplier.
+func (l *PurchaseOrderLine) Remaining() int {
+	return l.Quantity - l.ReceivedQuantity
+}
+
+// PurchaseReceipt records goods received against a purchase order line.
+type PurchaseReceipt struct {
+	ID              uuid.UUID
+	PurchaseOrderID uuid.UUID
+	LineID          uuid.UUID
+	PartID          uuid.UUID
+	Quantity        int
+	UnitCost        sharedkernel.Money
+	ReceivedAt      time.Time
+}
+
+func NewPurchaseOrder(supplierID uuid.UUID) *PurchaseOrder {
+	now := time.Now()
+	return &PurchaseOrder{
+		ID:         uuid.New(),
+		SupplierID: supplierID,
+		Status:     PurchaseOrderDraft,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+}
+
+// AddLine orders qty units of a part at the agreed unit cost.
+func (po *PurchaseOrder) AddLine(partID uuid.UUID, qty int, unitCost sharedkernel.Money) (*PurchaseOrderLine, error) {
+	if po.Status != PurchaseOrderDraft {
+		return nil, ErrPurchaseOrderNotDraft
+	}
+	if qty <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+	if unitCost.IsNegative() {
+		return nil, ErrInvalidUnitCost
+	}
+	for _, l := range po.Lines {
+		if l.PartID == partID {
+			return nil, ErrDuplicatePurchaseOrderLine
+		}
+	}
+
+	line := &PurchaseOrderLine{
+		ID:              uuid.New(),
+		PurchaseOrderID: po.ID,
+		PartID:          partID,
+		Quantity:        qty,
+		UnitCost:        unitCost,
+	}
+	po.Lines = append(po.Lines, line)
+	po.UpdatedAt = time.Now()
+	return line, nil
+}
+
+// Send marks the order as placed with the supplier; lines can no longer change.
+func (po *PurchaseOrder) Send() error {
+	if po.Status != PurchaseOrderDraft {
+		return ErrPurchaseOrderNotDraft
+	}
+	if len(po.Lines) == 0 {
+		return ErrPurchaseOrderEmpty
+	}
+	now := time.Now()
+	po.Status = PurchaseOrderSent
+	po.SentAt = &now
+	po.UpdatedAt = now
+	return nil
+}
+
+// Receive registers qty units of a line as delivered at unitCost and moves
+// the order to partially received or received. The caller is responsible
+// for adding the returned receipt's quantity to the part's stock.
+func (po *PurchaseOrder) Receive(lineID uuid.UUID, qty int, unitCost sharedkernel.Money) (*PurchaseReceipt, error) {
+	if po.Status != PurchaseOrderSent && po.Status != PurchaseOrderPartiallyReceived {
+		return nil, ErrPurchaseOrderNotReceivable
+	}
+	line := po.Line(lineID)
+	if line == nil {
+		return nil, ErrPurchaseOrderLineNotFound
+	}
+	if qty <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+	if qty > line.Remaining() {
+		return nil, ErrReceivedQuantityExceeded
+	}
+	if unitCost.IsNegative() {
+		return nil, ErrInvalidUnitCost
+	}
+
+	now := time.Now()
+	line.ReceivedQuantity += qty
+	receipt := &PurchaseReceipt{
+		ID:              uuid.New(),
+		PurchaseOrderID: po.ID,
+		LineID:          line.ID,
+		PartID:          line.PartID,
+		Quantity:        qty,
+		UnitCost:        unitCost,
+		ReceivedAt:      now,
+	}
+	po.pendingReceipts = append(po.pendingReceipts, receipt)
+
+	po.Status = PurchaseOrderReceived
+	for _, l := range po.Lines {
+		if l.Remaining() > 0 {
+			po.Status = PurchaseOrderPartiallyReceived
+			break
+		}
+	}
+	if po.Status == PurchaseOrderReceived {
+		po.ReceivedAt = &now
+	}
+	po.UpdatedAt = now
+	return receipt, nil
+}
+
+func (po *PurchaseOrder) Line(lineID uuid.UUID) *PurchaseOrderLine {
+	for _, l := range po.Lines {
+		if l.ID == lineID {
+			return l
+		}
+	}
+	return nil
+}
+
+// Total is the ordered value at the agreed unit costs.
+func (po *PurchaseOrder) Total() sharedkernel.Money {
+	var total sharedkernel.Money
+	for _, l := range po.Lines {
+		total, _ = total.Add(l.UnitCost.Mul(int64(l.Quantity)))
+	}
+	return total
+}
+
+// PendingReceipts returns the receipts not yet persisted.
+func (po *PurchaseOrder) PendingReceipts() []*PurchaseReceipt {
+	return po.pendingReceipts
+}
+
+func (po *PurchaseOrder) ClearPendingReceipts() {
+	po.pendingReceipts = nil
+}
@@ -4,12 +4,54 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
 )
 
 type PartRepository interface {
 	Save(ctx context.Context, part *Part) error
 	GetByID(ctx context.Context, id uuid.UUID) (*Part, error)
+	// GetByIDForUpdate is GetByID with a row lock held until the transaction ends.
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*Part, error)
+	// GetBySKU finds a part by SKU, ignoring case; ErrPartNotFound if none matches.
+	GetBySKU(ctx context.Context, sku string) (*Part, error)
 	Update(ctx context.Context, part *Part) error
+	// Archive takes a part out of the catalogue. Archived parts are not found
+	// by the lookups and listings, but their stock ledger is kept.
+	Archive(ctx context.Context, id uuid.UUID) error
+	// List pages through parts, by name unless q says otherwise. Search
+	// matches SKU, manufacturer code, name and description.
+	List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*Part], error)
+	// ListLowStock returns the parts whose quantity is below their minimum,
+	// the largest shortfall first.
+	ListLowStock(ctx context.Context) ([]*Part, error)
+	// ListMovements returns the stock ledger of a part, oldest first.
+	ListMovements(ctx context.Context, partID uuid.UUID) ([]*StockMovement, error)
+	// LedgerQuantities returns, per part, the quantity recomputed from the ledger.
+	LedgerQuantities(ctx context.Context) (map[uuid.UUID]int, error)
+}
+
+type SupplierRepository interface {
+	Save(ctx context.Context, supplier *Supplier) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Supplier, error)
+	// List pages through suppliers, by name unless q says otherwise. Search
+	// matches name, email and document.
+	List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*Supplier], error)
 	Delete(ctx context.Context, id uuid.UUID) error
-	List(ctx context.Context) ([]*Part, error)
+}
+
+type PurchaseOrderRepository interface {
+	// Save inserts or updates the order and its lines, together with any
+	// pending receipts.
+	Save(ctx context.Context, po *PurchaseOrder) error
+	GetByID(ctx context.Context, id uuid.UUID) (*PurchaseOrder, error)
+	// GetByIDForUpdate is GetByID with a row lock held until the transaction ends.
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*PurchaseOrder, error)
+	// List pages through purchase orders, newest first unless q says
+	// otherwise. It filters by status and creation date.
+	List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*PurchaseOrder], error)
+	// ListReceipts returns what was received against an order, oldest first.
+	ListReceipts(ctx context.Context, purchaseOrderID uuid.UUID) ([]*PurchaseReceipt, error)
+	// HasOpenWithPart reports whether a part is on a purchase order that is
+	// not fully received yet.
+	HasOpenWithPart(ctx context.Context, partID uuid.UUID) (bool, error)
 }
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidMovementType = errors.New("invalid stock movement type")
+	ErrInvalidQuantity     = errors.New("quantity must be positive")
+)
+
+type MovementType string
+
+const (
+	MovementPurchase         MovementType = "purchase"
+	MovementOrderConsumption MovementType = "order_consumption"
+	MovementAdjustment       MovementType = "adjustment"
+	MovementReturn           MovementType = "return"
+	MovementLoss             MovementType = "loss"
+)
+
+// IsInbound reports whether the movement type may add stock.
+func (t MovementType) IsInbound() bool {
+	return t == MovementPurchase || t == MovementReturn || t == MovementAdjustment
+}
+
+// IsOutbound reports whether the movement type may remove stock.
+func (t MovementType) IsOutbound() bool {
+	return t == MovementOrderConsumption || t == MovementLoss || t == MovementAdjustment
+}
+
+// StockMovement is an entry in a part's stock ledger. Quantity is signed:
+// positive when stock comes in, negative when it goes out.
+type StockMovement struct {
+	ID         uuid.UUID
+	PartID     uuid.UUID
+	Type       MovementType
+	Quantity   int
+	Reference  string
+	OccurredAt time.Time
+}
+
+// Reconciliation compares the stored quantity of a part with the quantity
+// recomputed from its ledger.
+type Reconciliation struct {
+	PartID         uuid.UUID
+	Quantity       int
+	LedgerQuantity int
+	Drift          int
+}
+
+func Reconcile(part *Part, ledgerQuantity int) Reconciliation {
+	return Reconciliation{
+		PartID:         part.ID,
+		Quantity:       part.Quantity,
+		LedgerQuantity: ledgerQuantity,
+		Drift:          part.Quantity - ledgerQuantity,
+	}
+}
+
+func (r Reconciliation) HasDrift() bool {
+	return r.Drift != 0
+}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
+)
+
+var (
+	ErrSupplierNotFound = errors.New("supplier not found")
+	ErrSupplierInUse    = errors.New("supplier has purchase orders")
+)
+
+type Supplier struct {
+	ID        uuid.UUID
+	Name      string
+	Document  sharedkernel.DocumentoBR
+	Email     string
+	Phone     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func NewSupplier(name, doc, email, phone string) (*Supplier, error) {
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+
+	d, err := sharedkernel.NewDocumentoBR(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Supplier{
+		ID:        uuid.New(),
+		Name:      name,
+		Document:  d,
+		Email:     email,
+		Phone:     phone,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}, nil
+}
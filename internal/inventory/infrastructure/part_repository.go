@@ -3,11 +3,14 @@ package infrastructure
 import (
 	"context"
 	"errors"
+	"strconv"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/noggrj/autorepair/internal/inventory/domain"
 	"github.com/noggrj/autorepair/internal/platform/db"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
 )
 
 type PostgresPartRepository struct {
@@ -18,36 +21,145 @@ func NewPostgresPartRepository(db db.Connection) *PostgresPartRepository {
 	return &PostgresPartRepository{db: db}
 }
 
+// partColumns is the column list every part query selects, in scanPart order.
+const partColumns = `id, sku, manufacturer_code, name, description, stock_qty, cost_price, sale_price, min_quantity, reorder_quantity`
+
+// uniqueViolation is the Postgres error code raised when an insert or update
+// collides with a unique index.
+const uniqueViolation = "23505"
+
 func (r *PostgresPartRepository) Save(ctx context.Context, part *domain.Part) error {
-	query := `INSERT INTO parts (id, name, description, stock_qty, price) VALUES ($1, $2, $3, $4, $5)`
-	_, err := r.db.Exec(ctx, query, part.ID, part.Name, part.Description, part.Quantity, part.Price)
+	query := `INSERT INTO parts (` + partColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	return r.writeWithMovements(ctx, part, func(conn db.Connection) error {
+		_, err := conn.Exec(ctx, query,
+			part.ID, part.SKU, part.ManufacturerCode, part.Name, part.Description, part.Quantity,
+			part.CostPrice, part.SalePrice, part.MinQuantity, part.ReorderQuantity)
+		return translateUniqueViolation(err)
+	})
+}
+
+// translateUniqueViolation reports a clash on the SKU index as ErrDuplicateSKU.
+func translateUniqueViolation(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return domain.ErrDuplicateSKU
+	}
 	return err
 }
 
+// writeWithMovements runs write and inserts the pending stock movements of the
+// part in the same transaction, so the quantity and the ledger never diverge.
+func (r *PostgresPartRepository) writeWithMovements(ctx context.Context, part *domain.Part, write func(conn db.Connection) error) error {
+	movements := part.PendingMovements()
+	if len(movements) == 0 {
+		return write(r.db)
+	}
+
+	err := db.RunInTx(ctx, r.db, func(tx pgx.Tx) error {
+		if err := write(tx); err != nil {
+			return err
+		}
+
+		query := `INSERT INTO stock_movements (id, part_id, type, quantity, reference, occurred_at) VALUES ($1, $2, $3, $4, $5, $6)`
+		for _, m := range movements {
+			if _, err := tx.Exec(ctx, query, m.ID, m.PartID, m.Type, m.Quantity, m.Reference, m.OccurredAt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	part.ClearPendingMovements()
+	return nil
+}
+
 func (r *PostgresPartRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Part, error) {
-	query := `SELECT id, name, description, stock_qty, price FROM parts WHERE id = $1`
-	row := r.db.QueryRow(ctx, query, id)
+	return r.getByID(ctx, id, "")
+}
+
+// GetByIDForUpdate loads the part and locks its row until the surrounding
+// transaction ends, so concurrent stock changes are serialized.
+func (r *PostgresPartRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*domain.Part, error) {
+	return r.getByID(ctx, id, " FOR UPDATE")
+}
+
+func (r *PostgresPartRepository) getByID(ctx context.Context, id uuid.UUID, lock string) (*domain.Part, error) {
+	query := `SELECT ` + partColumns + ` FROM parts WHERE id = $1 AND archived_at IS NULL` + lock
+	return scanPart(r.db.QueryRow(ctx, query, id))
+}
+
+// GetBySKU finds a part by its SKU, ignoring case and surrounding spaces.
+func (r *PostgresPartRepository) GetBySKU(ctx context.Context, sku string) (*domain.Part, error) {
+	query := `SELECT ` + partColumns + ` FROM parts WHERE sku = $1 AND archived_at IS NULL`
+	return scanPart(r.db.QueryRow(ctx, query, domain.NormalizeSKU(sku)))
+}
 
-	var part domain.Part
-	err := row.Scan(&part.ID, &part.Name, &part.Description, &part.Quantity, &part.Price)
+func scanPart(row pgx.Row) (*domain.Part, error) {
+	var p domain.Part
+	err := row.Scan(&p.ID, &p.SKU, &p.ManufacturerCode, &p.Name, &p.Description, &p.Quantity,
+		&p.CostPrice, &p.SalePrice, &p.MinQuantity, &p.ReorderQuantity)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, errors.New("part not found")
+			return nil, domain.ErrPartNotFound
 		}
 		return nil, err
 	}
-	return &part, nil
+	return &p, nil
 }
 
 func (r *PostgresPartRepository) Update(ctx context.Context, part *domain.Part) error {
-	query := `UPDATE parts SET name = $1, description = $2, stock_qty = $3, price = $4 WHERE id = $5`
-	_, err := r.db.Exec(ctx, query, part.Name, part.Description, part.Quantity, part.Price, part.ID)
-	return err
+	query := `UPDATE parts SET sku = $1, manufacturer_code = $2, name = $3, description = $4, stock_qty = $5,
+	          cost_price = $6, sale_price = $7, min_quantity = $8, reorder_quantity = $9 WHERE id = $10`
+	return r.writeWithMovements(ctx, part, func(conn db.Connection) error {
+		_, err := conn.Exec(ctx, query,
+			part.SKU, part.ManufacturerCode, part.Name, part.Description, part.Quantity,
+			part.CostPrice, part.SalePrice, part.MinQuantity, part.ReorderQuantity, part.ID)
+		return translateUniqueViolation(err)
+	})
 }
 
-func (r *PostgresPartRepository) List(ctx context.Context) ([]*domain.Part, error) {
-	query := `SELECT id, name, description, stock_qty, price FROM parts`
-	rows, err := r.db.Query(ctx, query)
+var partListing = db.Listing[*domain.Part]{
+	Sorts: map[string]db.SortField[*domain.Part]{
+		"name":       {{Expr: "name", Cast: "text", Value: func(p *domain.Part) string { return p.Name }}},
+		"sku":        {{Expr: "sku", Cast: "text", Value: func(p *domain.Part) string { return p.SKU }}},
+		"stock_qty":  {{Expr: "stock_qty", Cast: "int", Value: func(p *domain.Part) string { return strconv.Itoa(p.Quantity) }}},
+		"sale_price": {{Expr: "sale_price", Cast: "numeric", Value: func(p *domain.Part) string { return p.SalePrice.String() }}},
+	},
+	DefaultSort: "name",
+	ID:          func(p *domain.Part) uuid.UUID { return p.ID },
+}
+
+func (r *PostgresPartRepository) List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*domain.Part], error) {
+	var f db.Filter
+	f.Where("archived_at IS NULL")
+	if q.Search != "" {
+		f.Contains(q.Search, "sku", "manufacturer_code", "name", "description")
+	}
+	f.CreatedBetween("created_at", q)
+
+	query, args, err := partListing.Query(`SELECT `+partColumns+` FROM parts`, &f, q)
+	if err != nil {
+		return sharedkernel.Page[*domain.Part]{}, err
+	}
+	parts, err := r.queryParts(ctx, query, args...)
+	if err != nil {
+		return sharedkernel.Page[*domain.Part]{}, err
+	}
+	return partListing.Page(parts, q), nil
+}
+
+func (r *PostgresPartRepository) ListLowStock(ctx context.Context) ([]*domain.Part, error) {
+	query := `SELECT ` + partColumns + ` FROM parts
+	          WHERE stock_qty < min_quantity AND archived_at IS NULL
+	          ORDER BY min_quantity - stock_qty DESC, name ASC`
+	return r.queryParts(ctx, query)
+}
+
+func (r *PostgresPartRepository) queryParts(ctx context.Context, query string, args ...interface{}) ([]*domain.Part, error) {
+	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -55,23 +167,66 @@ func (r *PostgresPartRepository) List(ctx context.Context) ([]*domain.Part, erro
 
 	var parts []*domain.Part
 	for rows.Next() {
-		var p domain.Part
-		if err := rows.Scan(&p.ID, &p.Name, &p.Description, &p.Quantity, &p.Price); err != nil {
+		p, err := scanPart(rows)
+		if err != nil {
 			return nil, err
 		}
-		parts = append(parts, &p)
+		parts = append(parts, p)
 	}
 	return parts, nil
 }
 
-func (r *PostgresPartRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	query := `DELETE FROM parts WHERE id = $1`
+// Archive hides the part from lookups and listings. The row stays, so its
+// stock ledger and the orders that used it are kept.
+func (r *PostgresPartRepository) Archive(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE parts SET archived_at = NOW() WHERE id = $1 AND archived_at IS NULL`
 	result, err := r.db.Exec(ctx, query, id)
 	if err != nil {
 		return err
 	}
 	if result.RowsAffected() == 0 {
-		return errors.New("part not found")
+		return domain.ErrPartNotFound
 	}
 	return nil
 }
+
+func (r *PostgresPartRepository) ListMovements(ctx context.Context, partID uuid.UUID) ([]*domain.StockMovement, error) {
+	query := `SELECT id, part_id, type, quantity, reference, occurred_at FROM stock_movements WHERE part_id = $1 ORDER BY occurred_at ASC`
+	rows, err := r.db.Query(ctx, query, partID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var movements []*domain.StockMovement
+	for rows.Next() {
+		var m domain.StockMovement
+		var typeStr string
+		if err := rows.Scan(&m.ID, &m.PartID, &typeStr, &m.Quantity, &m.Reference, &m.OccurredAt); err != nil {
+			return nil, err
+		}
+		m.Type = domain.MovementType(typeStr)
+		movements = append(movements, &m)
+	}
+	return movements, rows.Err()
+}
+
+func (r *PostgresPartRepository) LedgerQuantities(ctx context.Context) (map[uuid.UUID]int, error) {
+	query := `SELECT part_id, COALESCE(SUM(quantity), 0) FROM stock_movements GROUP BY part_id`
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	quantities := make(map[uuid.UUID]int)
+	for rows.Next() {
+		var partID uuid.UUID
+		var qty int
+		if err := rows.Scan(&partID, &qty); err != nil {
+			return nil, err
+		}
+		quantities[partID] = qty
+	}
+	return quantities, rows.Err()
+}
@@ -0,0 +1,204 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/noggrj/autorepair/internal/inventory/domain"
+	"github.com/noggrj/autorepair/internal/platform/db"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
+)
+
+type PostgresPurchaseOrderRepository struct {
+	db db.Connection
+}
+
+func NewPostgresPurchaseOrderRepository(db db.Connection) *PostgresPurchaseOrderRepository {
+	return &PostgresPurchaseOrderRepository{db: db}
+}
+
+func (r *PostgresPurchaseOrderRepository) Save(ctx context.Context, po *domain.PurchaseOrder) error {
+	err := db.RunInTx(ctx, r.db, func(tx pgx.Tx) error {
+		query := `INSERT INTO purchase_orders (id, supplier_id, status, created_at, updated_at, sent_at, received_at)
+		          VALUES ($1, $2, $3, $4, $5, $6, $7)
+		          ON CONFLICT (id) DO UPDATE SET
+		          status = EXCLUDED.status,
+		          updated_at = EXCLUDED.updated_at,
+		          sent_at = EXCLUDED.sent_at,
+		          received_at = EXCLUDED.received_at`
+		_, err := tx.Exec(ctx, query,
+			po.ID, po.SupplierID, string(po.Status), po.CreatedAt, po.UpdatedAt, po.SentAt, po.ReceivedAt)
+		if err != nil {
+			return err
+		}
+
+		lineQuery := `INSERT INTO purchase_order_lines (id, purchase_order_id, part_id, quantity, received_quantity, unit_cost)
+		              VALUES ($1, $2, $3, $4, $5, $6)
+		              ON CONFLICT (id) DO UPDATE SET
+		              quantity = EXCLUDED.quantity,
+		              received_quantity = EXCLUDED.received_quantity,
+		              unit_cost = EXCLUDED.unit_cost`
+		for _, l := range po.Lines {
+			_, err := tx.Exec(ctx, lineQuery,
+				l.ID, l.PurchaseOrderID, l.PartID, l.Quantity, l.ReceivedQuantity, l.UnitCost)
+			if err != nil {
+				return err
+			}
+		}
+
+		receiptQuery := `INSERT INTO purchase_receipts (id, purchase_order_id, line_id, part_id, quantity, unit_cost, received_at)
+		                 VALUES ($1, $2, $3, $4, $5, $6, $7)`
+		for _, rc := range po.PendingReceipts() {
+			_, err := tx.Exec(ctx, receiptQuery,
+				rc.ID, rc.PurchaseOrderID, rc.LineID, rc.PartID, rc.Quantity, rc.UnitCost, rc.ReceivedAt)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	po.ClearPendingReceipts()
+	return nil
+}
+
+func (r *PostgresPurchaseOrderRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.PurchaseOrder, error) {
+	return r.getByID(ctx, id, "")
+}
+
+// GetByIDForUpdate loads the purchase order and locks its row until the
+// surrounding transaction ends, so concurrent receipts are serialized.
+func (r *PostgresPurchaseOrderRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*domain.PurchaseOrder, error) {
+	return r.getByID(ctx, id, " FOR UPDATE")
+}
+
+func (r *PostgresPurchaseOrderRepository) getByID(ctx context.Context, id uuid.UUID, lock string) (*domain.PurchaseOrder, error) {
+	query := `SELECT id, supplier_id, status, created_at, updated_at, sent_at, received_at FROM purchase_orders WHERE id = $1` + lock
+	po, err := scanPurchaseOrder(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrPurchaseOrderNotFound
+		}
+		return nil, err
+	}
+
+	if err := r.loadLines(ctx, po); err != nil {
+		return nil, err
+	}
+	return po, nil
+}
+
+var purchaseOrderListing = db.Listing[*domain.PurchaseOrder]{
+	Sorts: map[string]db.SortField[*domain.PurchaseOrder]{
+		"created_at": {{Expr: "created_at", Cast: "timestamptz", Value: func(po *domain.PurchaseOrder) string { return db.FormatTime(po.CreatedAt) }}},
+		"updated_at": {{Expr: "updated_at", Cast: "timestamptz", Value: func(po *domain.PurchaseOrder) string { return db.FormatTime(po.UpdatedAt) }}},
+	},
+	DefaultSort: "created_at",
+	DefaultDesc: true,
+	ID:          func(po *domain.PurchaseOrder) uuid.UUID { return po.ID },
+}
+
+func (r *PostgresPurchaseOrderRepository) List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*domain.PurchaseOrder], error) {
+	var f db.Filter
+	if len(q.Status) > 0 {
+		f.Where("status = ANY(" + f.Arg(q.Status) + ")")
+	}
+	f.CreatedBetween("created_at", q)
+
+	query, args, err := purchaseOrderListing.Query(`SELECT id, supplier_id, status, created_at, updated_at, sent_at, received_at FROM purchase_orders`, &f, q)
+	if err != nil {
+		return sharedkernel.Page[*domain.PurchaseOrder]{}, err
+	}
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return sharedkernel.Page[*domain.PurchaseOrder]{}, err
+	}
+	defer rows.Close()
+
+	var orders []*domain.PurchaseOrder
+	for rows.Next() {
+		po, err := scanPurchaseOrder(rows)
+		if err != nil {
+			return sharedkernel.Page[*domain.PurchaseOrder]{}, err
+		}
+		orders = append(orders, po)
+	}
+	if err := rows.Err(); err != nil {
+		return sharedkernel.Page[*domain.PurchaseOrder]{}, err
+	}
+	rows.Close()
+
+	page := purchaseOrderListing.Page(orders, q)
+	for _, po := range page.Items {
+		if err := r.loadLines(ctx, po); err != nil {
+			return sharedkernel.Page[*domain.PurchaseOrder]{}, err
+		}
+	}
+	return page, nil
+}
+
+func (r *PostgresPurchaseOrderRepository) loadLines(ctx context.Context, po *domain.PurchaseOrder) error {
+	query := `SELECT id, purchase_order_id, part_id, quantity, received_quantity, unit_cost FROM purchase_order_lines WHERE purchase_order_id = $1`
+	rows, err := r.db.Query(ctx, query, po.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var l domain.PurchaseOrderLine
+		if err := rows.Scan(&l.ID, &l.PurchaseOrderID, &l.PartID, &l.Quantity, &l.ReceivedQuantity, &l.UnitCost); err != nil {
+			return err
+		}
+		po.Lines = append(po.Lines, &l)
+	}
+	return rows.Err()
+}
+
+func (r *PostgresPurchaseOrderRepository) ListReceipts(ctx context.Context, purchaseOrderID uuid.UUID) ([]*domain.PurchaseReceipt, error) {
+	query := `SELECT id, purchase_order_id, line_id, part_id, quantity, unit_cost, received_at FROM purchase_receipts WHERE purchase_order_id = $1 ORDER BY received_at ASC`
+	rows, err := r.db.Query(ctx, query, purchaseOrderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var receipts []*domain.PurchaseReceipt
+	for rows.Next() {
+		var rc domain.PurchaseReceipt
+		if err := rows.Scan(&rc.ID, &rc.PurchaseOrderID, &rc.LineID, &rc.PartID, &rc.Quantity, &rc.UnitCost, &rc.ReceivedAt); err != nil {
+			return nil, err
+		}
+		receipts = append(receipts, &rc)
+	}
+	return receipts, rows.Err()
+}
+
+// HasOpenWithPart reports whether a part is a line of any purchase order
+// that is not fully received.
+func (r *PostgresPurchaseOrderRepository) HasOpenWithPart(ctx context.Context, partID uuid.UUID) (bool, error) {
+	query := `SELECT EXISTS (
+	            SELECT 1 FROM purchase_order_lines l
+	            JOIN purchase_orders po ON po.id = l.purchase_order_id
+	            WHERE l.part_id = $1 AND po.status <> $2
+	          )`
+
+	var exists bool
+	err := r.db.QueryRow(ctx, query, partID, string(domain.PurchaseOrderReceived)).Scan(&exists)
+	return exists, err
+}
+
+func scanPurchaseOrder(row pgx.Row) (*domain.PurchaseOrder, error) {
+	var po domain.PurchaseOrder
+	var statusStr string
+	if err := row.Scan(&po.ID, &po.SupplierID, &statusStr, &po.CreatedAt, &po.UpdatedAt, &po.SentAt, &po.ReceivedAt); err != nil {
+		return nil, err
+	}
+	po.Status = domain.PurchaseOrderStatus(statusStr)
+	return &po, nil
+}
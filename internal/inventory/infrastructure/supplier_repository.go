@@ -0,0 +1,119 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/noggrj/autorepair/internal/inventory/domain"
+	"github.com/noggrj/autorepair/internal/platform/db"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
+)
+
+// foreignKeyViolation is the Postgres error code raised when a delete would
+// leave rows pointing at a missing parent.
+const foreignKeyViolation = "23503"
+
+type PostgresSupplierRepository struct {
+	db db.Connection
+}
+
+func NewPostgresSupplierRepository(db db.Connection) *PostgresSupplierRepository {
+	return &PostgresSupplierRepository{db: db}
+}
+
+func (r *PostgresSupplierRepository) Save(ctx context.Context, supplier *domain.Supplier) error {
+	query := `INSERT INTO suppliers (id, name, document, email, phone, created_at, updated_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7)
+	          ON CONFLICT (id) DO UPDATE SET
+	          name = EXCLUDED.name,
+	          document = EXCLUDED.document,
+	          email = EXCLUDED.email,
+	          phone = EXCLUDED.phone,
+	          updated_at = EXCLUDED.updated_at`
+	_, err := r.db.Exec(ctx, query,
+		supplier.ID, supplier.Name, supplier.Document.String(), supplier.Email, supplier.Phone, supplier.CreatedAt, supplier.UpdatedAt)
+	return err
+}
+
+func (r *PostgresSupplierRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Supplier, error) {
+	query := `SELECT id, name, document, email, phone, created_at, updated_at FROM suppliers WHERE id = $1`
+	row := r.db.QueryRow(ctx, query, id)
+	return scanSupplier(row)
+}
+
+var supplierListing = db.Listing[*domain.Supplier]{
+	Sorts: map[string]db.SortField[*domain.Supplier]{
+		"name":       {{Expr: "name", Cast: "text", Value: func(s *domain.Supplier) string { return s.Name }}},
+		"created_at": {{Expr: "created_at", Cast: "timestamptz", Value: func(s *domain.Supplier) string { return db.FormatTime(s.CreatedAt) }}},
+	},
+	DefaultSort: "name",
+	ID:          func(s *domain.Supplier) uuid.UUID { return s.ID },
+}
+
+func (r *PostgresSupplierRepository) List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*domain.Supplier], error) {
+	var f db.Filter
+	if q.Search != "" {
+		f.Contains(q.Search, "name", "email", "document")
+	}
+	f.CreatedBetween("created_at", q)
+
+	query, args, err := supplierListing.Query(`SELECT id, name, document, email, phone, created_at, updated_at FROM suppliers`, &f, q)
+	if err != nil {
+		return sharedkernel.Page[*domain.Supplier]{}, err
+	}
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return sharedkernel.Page[*domain.Supplier]{}, err
+	}
+	defer rows.Close()
+
+	var suppliers []*domain.Supplier
+	for rows.Next() {
+		supplier, err := scanSupplier(rows)
+		if err != nil {
+			return sharedkernel.Page[*domain.Supplier]{}, err
+		}
+		suppliers = append(suppliers, supplier)
+	}
+	if err := rows.Err(); err != nil {
+		return sharedkernel.Page[*domain.Supplier]{}, err
+	}
+	return supplierListing.Page(suppliers, q), nil
+}
+
+func (r *PostgresSupplierRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM suppliers WHERE id = $1`
+	result, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+			return domain.ErrSupplierInUse
+		}
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrSupplierNotFound
+	}
+	return nil
+}
+
+func scanSupplier(row pgx.Row) (*domain.Supplier, error) {
+	var supplier domain.Supplier
+	var docStr string
+	err := row.Scan(&supplier.ID, &supplier.Name, &docStr, &supplier.Email, &supplier.Phone, &supplier.CreatedAt, &supplier.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrSupplierNotFound
+		}
+		return nil, err
+	}
+	doc, err := sharedkernel.NewDocumentoBR(docStr)
+	if err != nil {
+		return nil, err
+	}
+	supplier.Document = doc
+	return &supplier, nil
+}
@@ -0,0 +1,33 @@
+package infrastructure
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/noggrj/autorepair/internal/inventory/application"
+	"github.com/noggrj/autorepair/internal/platform/db"
+)
+
+// OpenOrdersFunc binds an OpenOrderChecker to a connection. The checker is
+// implemented by the service context, which already depends on this package,
+// so it is handed in rather than built here.
+type OpenOrdersFunc func(conn db.Connection) application.OpenOrderChecker
+
+type PostgresUnitOfWork struct {
+	db         db.Connection
+	openOrders OpenOrdersFunc
+}
+
+func NewPostgresUnitOfWork(db db.Connection, openOrders OpenOrdersFunc) *PostgresUnitOfWork {
+	return &PostgresUnitOfWork{db: db, openOrders: openOrders}
+}
+
+func (u *PostgresUnitOfWork) Do(ctx context.Context, fn func(repos application.TxRepositories) error) error {
+	return db.RunInTx(ctx, u.db, func(tx pgx.Tx) error {
+		return fn(application.TxRepositories{
+			Parts:          NewPostgresPartRepository(tx),
+			PurchaseOrders: NewPostgresPurchaseOrderRepository(tx),
+			OpenOrders:     u.openOrders(tx),
+		})
+	})
+}
@@ -2,74 +2,184 @@ package auth
 
 import (
 	"errors"
-	"os"
+	"slices"
+	"sync/atomic"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
-var secretKey = []byte(getSecretKey())
+// Token types, carried in the token_type claim so a refresh token is never
+// accepted where an access token is expected and vice versa.
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+	// TokenTypeMFAChallenge is handed out when a password was right but the
+	// user still owes a second factor. It only buys a call to finish login.
+	TokenTypeMFAChallenge = "mfa_challenge"
+)
+
+// Authentication methods carried in the amr claim (RFC 8176).
+const (
+	AMRPassword = "pwd"
+	AMROTP      = "otp"
+	AMRMFA      = "mfa"
+	// AMRMagicLink marks a login with a single-use link mailed to the
+	// user. RFC 8176 has no value for it.
+	AMRMagicLink = "email"
+)
+
+const (
+	AccessTokenTTL       = 15 * time.Minute
+	RefreshTokenTTL      = 7 * 24 * time.Hour
+	MFAChallengeTokenTTL = 5 * time.Minute
+)
+
+var ErrWrongTokenType = errors.New("wrong token type")
+
+// Default iss and aud claims, used until UseIssuer is called.
+const (
+	DefaultIssuer   = "autorepair"
+	DefaultAudience = "autorepair-api"
+)
+
+// tokenScope is who issues tokens and who they are meant for.
+type tokenScope struct {
+	issuer   string
+	audience string
+}
+
+var currentScope atomic.Pointer[tokenScope]
 
-func getSecretKey() string {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		// Fallback for development only, or panic in production
-		return "secret" 
+// UseIssuer sets the iss and aud claims new tokens carry. Tokens naming
+// another issuer or audience are refused.
+func UseIssuer(issuer, audience string) {
+	currentScope.Store(&tokenScope{issuer: issuer, audience: audience})
+}
+
+func scope() *tokenScope {
+	if s := currentScope.Load(); s != nil {
+		return s
+	}
+	return &tokenScope{issuer: DefaultIssuer, audience: DefaultAudience}
+}
+
+// registeredClaims returns the iss, aud and exp claims of a token that
+// expires at expiresAt.
+func registeredClaims(expiresAt time.Time) jwt.RegisteredClaims {
+	s := scope()
+	return jwt.RegisteredClaims{
+		Issuer:    s.issuer,
+		Audience:  jwt.ClaimStrings{s.audience},
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
 	}
-	return secret
 }
 
 type Claims struct {
-	UserID uuid.UUID `json:"user_id"`
-	Role   string    `json:"role"`
+	UserID    uuid.UUID `json:"user_id"`
+	Role      string    `json:"role,omitempty"`
+	TokenType string    `json:"token_type"`
+	// AMR lists how the user authenticated for this session.
+	AMR []string `json:"amr,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID uuid.UUID, role string) (string, string, int64, error) {
-	expirationTime := time.Now().Add(15 * time.Minute)
+// HasAMR reports whether the session was authenticated with method.
+func (c *Claims) HasAMR(method string) bool {
+	return slices.Contains(c.AMR, method)
+}
+
+// GenerateAccessToken signs a short-lived access token and returns it with
+// its lifetime in seconds. amr records how the user authenticated.
+func GenerateAccessToken(userID uuid.UUID, role string, amr []string) (string, int64, error) {
+	expirationTime := time.Now().Add(AccessTokenTTL)
 	claims := &Claims{
-		UserID: userID,
-		Role:   role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-		},
+		UserID:           userID,
+		Role:             role,
+		TokenType:        TokenTypeAccess,
+		AMR:              amr,
+		RegisteredClaims: registeredClaims(expirationTime),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	accessToken, err := token.SignedString(secretKey)
+	accessToken, err := keyring().sign(claims)
 	if err != nil {
-		return "", "", 0, err
+		return "", 0, err
 	}
+	return accessToken, int64(time.Until(expirationTime).Seconds()), nil
+}
 
-	refreshClaims := &Claims{
-		UserID: userID,
-		Role:   role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(7 * 24 * time.Hour)),
-		},
+// GenerateRefreshToken signs a refresh token identified by jti and returns it
+// with its expiry. The token is only usable while the server still holds a
+// matching record for jti.
+func GenerateRefreshToken(userID, jti uuid.UUID) (string, time.Time, error) {
+	expiresAt := time.Now().Add(RefreshTokenTTL)
+	claims := &Claims{
+		UserID:           userID,
+		TokenType:        TokenTypeRefresh,
+		RegisteredClaims: registeredClaims(expiresAt),
 	}
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	refreshTokenString, err := refreshToken.SignedString(secretKey)
+	claims.ID = jti.String()
+
+	refreshToken, err := keyring().sign(claims)
 	if err != nil {
-		return "", "", 0, err
+		return "", time.Time{}, err
 	}
+	return refreshToken, expiresAt, nil
+}
 
-	return accessToken, refreshTokenString, int64(time.Until(expirationTime).Seconds()), nil
+// GenerateMFAChallengeToken signs the token that lets a user who passed the
+// password check finish logging in with a second factor, and returns it with
+// its lifetime in seconds.
+func GenerateMFAChallengeToken(userID uuid.UUID) (string, int64, error) {
+	expirationTime := time.Now().Add(MFAChallengeTokenTTL)
+	claims := &Claims{
+		UserID:           userID,
+		TokenType:        TokenTypeMFAChallenge,
+		AMR:              []string{AMRPassword},
+		RegisteredClaims: registeredClaims(expirationTime),
+	}
+
+	challengeToken, err := keyring().sign(claims)
+	if err != nil {
+		return "", 0, err
+	}
+	return challengeToken, int64(time.Until(expirationTime).Seconds()), nil
 }
 
+// ValidateToken parses an access token.
 func ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return secretKey, nil
-	})
+	return validate(tokenString, TokenTypeAccess)
+}
+
+// ValidateRefreshToken parses a refresh token. It only checks the signature,
+// expiry and type; whether the token was revoked is up to the caller.
+func ValidateRefreshToken(tokenString string) (*Claims, error) {
+	return validate(tokenString, TokenTypeRefresh)
+}
+
+// ValidateMFAChallengeToken parses an MFA challenge token.
+func ValidateMFAChallengeToken(tokenString string) (*Claims, error) {
+	return validate(tokenString, TokenTypeMFAChallenge)
+}
+
+func validate(tokenString, tokenType string) (*Claims, error) {
+	s := scope()
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, keyring().verificationKey,
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(s.issuer),
+		jwt.WithAudience(s.audience))
 
 	if err != nil {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
 	}
-
-	return nil, errors.New("invalid token")
+	if claims.TokenType != tokenType {
+		return nil, ErrWrongTokenType
+	}
+	return claims, nil
 }
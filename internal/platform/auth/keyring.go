@@ -0,0 +1,258 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrNoSigningKey is returned by LoadKeyring when there is no private key to
+// sign tokens with.
+var ErrNoSigningKey = errors.New("no JWT signing key configured")
+
+// minRSABits is the smallest RSA modulus accepted for RS256.
+const minRSABits = 2048
+
+// Key is one key of a keyring. Private is nil for keys kept only to verify
+// tokens signed before a rotation.
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.PrivateKey
+	Public  crypto.PublicKey
+}
+
+// Keyring holds the key new tokens are signed with and every key tokens are
+// still accepted from, looked up by the kid header.
+type Keyring struct {
+	signing *Key
+	keys    map[string]*Key
+	ids     []string
+}
+
+// LoadKeyring reads every *.pem file in dir. The file name without its
+// extension is the key ID. A file may hold a PKCS#8 or PKCS#1 private key
+// (RSA or Ed25519) or a PKIX public key. signingID picks the private key new
+// tokens are signed with; it may be empty when there is exactly one.
+//
+// To rotate, add the new key, point signingID at it and keep the old file
+// until the tokens it signed have expired.
+func LoadKeyring(dir, signingID string) (*Keyring, error) {
+	if dir == "" {
+		return nil, ErrNoSigningKey
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var keys []*Key
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		key, err := ParseKey(strings.TrimSuffix(filepath.Base(path), ".pem"), data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		keys = append(keys, key)
+	}
+	return NewKeyring(signingID, keys...)
+}
+
+// NewKeyring builds a keyring from already parsed keys. See LoadKeyring for
+// how the signing key is chosen.
+func NewKeyring(signingID string, keys ...*Key) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string]*Key, len(keys))}
+	var private []*Key
+	for _, key := range keys {
+		if _, dup := k.keys[key.ID]; dup {
+			return nil, fmt.Errorf("duplicate JWT key id %q", key.ID)
+		}
+		k.keys[key.ID] = key
+		k.ids = append(k.ids, key.ID)
+		if key.Private != nil {
+			private = append(private, key)
+		}
+	}
+
+	switch {
+	case signingID != "":
+		key, ok := k.keys[signingID]
+		if !ok || key.Private == nil {
+			return nil, fmt.Errorf("JWT signing key %q not found among the private keys", signingID)
+		}
+		k.signing = key
+	case len(private) == 1:
+		k.signing = private[0]
+	case len(private) == 0:
+		return nil, ErrNoSigningKey
+	default:
+		return nil, errors.New("several JWT private keys configured; set the signing key id")
+	}
+	return k, nil
+}
+
+// NewEphemeralKeyring generates a throwaway Ed25519 key. Tokens it signs do
+// not survive a restart, so it is only meant for development and tests.
+func NewEphemeralKeyring() (*Keyring, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeyring("", &Key{ID: "ephemeral", Method: jwt.SigningMethodEdDSA, Private: private, Public: public})
+}
+
+// ParseKey decodes a PEM encoded RSA or Ed25519 key.
+func ParseKey(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &Key{ID: id}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T; use RSA or Ed25519", parsed)
+	}
+	if rsaKey, ok := key.Public.(*rsa.PublicKey); ok && rsaKey.N.BitLen() < minRSABits {
+		return nil, fmt.Errorf("RSA key is %d bits, at least %d are required", rsaKey.N.BitLen(), minRSABits)
+	}
+	return key, nil
+}
+
+// SigningKeyID returns the kid new tokens carry.
+func (k *Keyring) SigningKeyID() string {
+	return k.signing.ID
+}
+
+func (k *Keyring) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.signing.Method, claims)
+	token.Header["kid"] = k.signing.ID
+	return token.SignedString(k.signing.Private)
+}
+
+// verificationKey resolves the key a token claims to be signed with.
+func (k *Keyring) verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("key %q does not sign with %s", kid, token.Method.Alg())
+	}
+	return key.Public, nil
+}
+
+// JWK is a public key in JSON Web Key form (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is the document served at /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS lists the public half of every key tokens are accepted from.
+func (k *Keyring) JWKS() JWKS {
+	set := JWKS{Keys: make([]JWK, 0, len(k.ids))}
+	for _, id := range k.ids {
+		key := k.keys[id]
+		jwk := JWK{Kid: id, Use: "sig", Alg: key.Method.Alg()}
+		switch pub := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+var (
+	current atomic.Pointer[Keyring]
+	// Until Use is called, tokens are signed with a key generated on first
+	// use. The API refuses to start that way outside development.
+	fallback = sync.OnceValue(func() *Keyring {
+		k, err := NewEphemeralKeyring()
+		if err != nil {
+			panic(err)
+		}
+		return k
+	})
+)
+
+// Use installs the keyring tokens are signed and verified with. Passing nil
+// goes back to the ephemeral fallback.
+func Use(k *Keyring) {
+	current.Store(k)
+}
+
+func keyring() *Keyring {
+	if k := current.Load(); k != nil {
+		return k
+	}
+	return fallback()
+}
+
+// PublicKeys returns the JWKS of the installed keyring.
+func PublicKeys() JWKS {
+	return keyring().JWKS()
+}
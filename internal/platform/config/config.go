@@ -3,11 +3,59 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 )
 
+// defaultQueryTimeout bounds how long a request may spend talking to the
+// database when DB_QUERY_TIMEOUT is not set.
+const defaultQueryTimeout = 5 * time.Second
+
+// defaultMaintenanceReminderInterval is how often due maintenance reminders
+// are sent when MAINTENANCE_REMINDER_INTERVAL is not set.
+const defaultMaintenanceReminderInterval = 24 * time.Hour
+
+// defaultJWTIssuer and defaultJWTAudience are the iss and aud claims of
+// tokens when JWT_ISSUER and JWT_AUDIENCE are not set.
+const (
+	defaultJWTIssuer   = "autorepair"
+	defaultJWTAudience = "autorepair-api"
+)
+
+// EnvDevelopment is the APP_ENV value that allows running without JWT keys.
+const EnvDevelopment = "development"
+
 type Config struct {
 	DBURL string
 	Port  string
+	// QueryTimeout is the deadline put on each request's context, and so on
+	// every query it runs. Zero disables it.
+	QueryTimeout time.Duration
+	// Env is the deployment profile. Anything but EnvDevelopment is treated
+	// as production.
+	Env string
+	// JWTKeysDir holds the PEM keys tokens are signed and verified with, and
+	// JWTSigningKey names the one new tokens are signed with.
+	JWTKeysDir    string
+	JWTSigningKey string
+	// JWTIssuer and JWTAudience are the iss and aud claims tokens are issued
+	// with and must carry to be accepted.
+	JWTIssuer   string
+	JWTAudience string
+	// BudgetLinkSecret is the HMAC key budget approval links are signed
+	// with. It must be at least 32 bytes long.
+	BudgetLinkSecret string
+	// TrustProxyHeaders takes the client IP from X-Forwarded-For and
+	// X-Real-IP. Enable it only behind a proxy that sets them.
+	TrustProxyHeaders bool
+	// MaintenanceReminderInterval is how often the job emailing due
+	// maintenance reminders runs. Zero disables it.
+	MaintenanceReminderInterval time.Duration
+}
+
+// IsDevelopment reports whether the API runs under the development profile.
+func (c *Config) IsDevelopment() bool {
+	return c.Env == EnvDevelopment
 }
 
 func Load() (*Config, error) {
@@ -21,8 +69,61 @@ func Load() (*Config, error) {
 		port = "8080"
 	}
 
+	queryTimeout := defaultQueryTimeout
+	if s := os.Getenv("DB_QUERY_TIMEOUT"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("DB_QUERY_TIMEOUT must be a non-negative duration such as 5s")
+		}
+		queryTimeout = d
+	}
+
+	env := os.Getenv("APP_ENV")
+	if env == "" {
+		env = "production"
+	}
+
+	jwtIssuer := os.Getenv("JWT_ISSUER")
+	if jwtIssuer == "" {
+		jwtIssuer = defaultJWTIssuer
+	}
+	jwtAudience := os.Getenv("JWT_AUDIENCE")
+	if jwtAudience == "" {
+		jwtAudience = defaultJWTAudience
+	}
+
+	trustProxyHeaders := false
+	if s := os.Getenv("TRUST_PROXY_HEADERS"); s != "" {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("TRUST_PROXY_HEADERS must be true or false")
+		}
+		trustProxyHeaders = b
+	}
+
+	maintenanceReminderInterval := defaultMaintenanceReminderInterval
+	if s := os.Getenv("MAINTENANCE_REMINDER_INTERVAL"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("MAINTENANCE_REMINDER_INTERVAL must be a non-negative duration such as 24h")
+		}
+		maintenanceReminderInterval = d
+	}
+
 	return &Config{
-		DBURL: dbURL,
-		Port:  port,
+		DBURL:         dbURL,
+		Port:          port,
+		QueryTimeout:  queryTimeout,
+		Env:           env,
+		JWTKeysDir:    os.Getenv("JWT_KEYS_DIR"),
+		JWTSigningKey: os.Getenv("JWT_SIGNING_KEY"),
+		JWTIssuer:     jwtIssuer,
+		JWTAudience:   jwtAudience,
+
+		BudgetLinkSecret: os.Getenv("BUDGET_LINK_SECRET"),
+
+		TrustProxyHeaders: trustProxyHeaders,
+
+		MaintenanceReminderInterval: maintenanceReminderInterval,
 	}, nil
 }
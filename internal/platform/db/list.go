@@ -0,0 +1,184 @@
+package db
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
+)
+
+// SortKey is one expression a listing is ordered by. Value reads the
+// expression's value back from a row so it can be carried in the cursor,
+// and Cast is the SQL type that value is converted to when compared.
+type SortKey[T any] struct {
+	Expr  string
+	Cast  string
+	Value func(T) string
+}
+
+// SortField is a named sort order made of one or more keys. The row ID is
+// always appended as the last key so the order is total.
+type SortField[T any] []SortKey[T]
+
+// Listing describes how a repository pages through an entity with keyset
+// pagination: the sort orders it offers and how to read a row's ID.
+type Listing[T any] struct {
+	Sorts       map[string]SortField[T]
+	DefaultSort string
+	DefaultDesc bool
+	// IDColumn defaults to "id".
+	IDColumn string
+	ID       func(T) uuid.UUID
+}
+
+// Filter accumulates WHERE conditions and their positional arguments.
+type Filter struct {
+	conds []string
+	args  []any
+}
+
+// Arg registers a query argument and returns its placeholder.
+func (f *Filter) Arg(v any) string {
+	f.args = append(f.args, v)
+	return "$" + strconv.Itoa(len(f.args))
+}
+
+// Where adds a condition. Arguments are referenced through Arg.
+func (f *Filter) Where(cond string) {
+	f.conds = append(f.conds, cond)
+}
+
+// Contains adds a case-insensitive substring match of term against any of
+// the given columns.
+func (f *Filter) Contains(term string, columns ...string) {
+	pattern := f.Arg("%" + likeEscaper.Replace(term) + "%")
+	matches := make([]string, len(columns))
+	for i, c := range columns {
+		matches[i] = c + " ILIKE " + pattern
+	}
+	f.Where("(" + strings.Join(matches, " OR ") + ")")
+}
+
+// CreatedBetween applies the From/To bounds of q to column.
+func (f *Filter) CreatedBetween(column string, q sharedkernel.ListQuery) {
+	if q.From != nil {
+		f.Where(column + " >= " + f.Arg(*q.From))
+	}
+	if q.To != nil {
+		f.Where(column + " < " + f.Arg(*q.To))
+	}
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+type cursor struct {
+	Sort   string    `json:"s"`
+	Desc   bool      `json:"d,omitempty"`
+	Values []string  `json:"v"`
+	ID     uuid.UUID `json:"id"`
+}
+
+// Query completes base, a SELECT without WHERE or ORDER BY, with the
+// conditions in f, the keyset condition for q.Cursor, the sort order and
+// the limit. One row more than the limit is fetched so Page can tell
+// whether there is a next page.
+func (l Listing[T]) Query(base string, f *Filter, q sharedkernel.ListQuery) (string, []any, error) {
+	name, desc := l.order(q)
+	field, ok := l.Sorts[name]
+	if !ok {
+		return "", nil, sharedkernel.ErrInvalidSort
+	}
+
+	exprs := make([]string, 0, len(field)+1)
+	for _, k := range field {
+		exprs = append(exprs, k.Expr)
+	}
+	idColumn := l.IDColumn
+	if idColumn == "" {
+		idColumn = "id"
+	}
+	exprs = append(exprs, idColumn)
+
+	if q.Cursor != "" {
+		c, err := decodeCursor(q.Cursor)
+		if err != nil || c.Sort != name || c.Desc != desc || len(c.Values) != len(field) {
+			return "", nil, sharedkernel.ErrInvalidCursor
+		}
+		values := make([]string, 0, len(field)+1)
+		for i, k := range field {
+			values = append(values, f.Arg(c.Values[i])+"::"+k.Cast)
+		}
+		values = append(values, f.Arg(c.ID))
+
+		cmp := ">"
+		if desc {
+			cmp = "<"
+		}
+		f.Where(fmt.Sprintf("(%s) %s (%s)", strings.Join(exprs, ", "), cmp, strings.Join(values, ", ")))
+	}
+
+	query := base
+	if len(f.conds) > 0 {
+		query += " WHERE " + strings.Join(f.conds, " AND ")
+	}
+
+	dir := " ASC"
+	if desc {
+		dir = " DESC"
+	}
+	query += " ORDER BY " + strings.Join(exprs, dir+", ") + dir
+
+	if q.Limit > 0 {
+		query += " LIMIT " + f.Arg(q.Limit+1)
+	}
+	return query, f.args, nil
+}
+
+// Page trims the extra row fetched by Query and, if there was one, builds
+// the cursor of the next page from the last row kept.
+func (l Listing[T]) Page(items []T, q sharedkernel.ListQuery) sharedkernel.Page[T] {
+	if q.Limit <= 0 || len(items) <= q.Limit {
+		return sharedkernel.Page[T]{Items: items}
+	}
+	items = items[:q.Limit]
+	last := items[len(items)-1]
+
+	name, desc := l.order(q)
+	c := cursor{Sort: name, Desc: desc, ID: l.ID(last)}
+	for _, k := range l.Sorts[name] {
+		c.Values = append(c.Values, k.Value(last))
+	}
+	return sharedkernel.Page[T]{Items: items, NextCursor: encodeCursor(c)}
+}
+
+func (l Listing[T]) order(q sharedkernel.ListQuery) (string, bool) {
+	if q.Sort == "" {
+		return l.DefaultSort, l.DefaultDesc
+	}
+	return q.Sort, q.Desc
+}
+
+// FormatTime renders a timestamp for a cursor without losing precision.
+func FormatTime(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
+func encodeCursor(c cursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(raw, &c)
+	return c, err
+}
@@ -0,0 +1,28 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// RunInTx begins a transaction on conn and runs fn with it. The transaction is
+// committed only if fn returns nil; otherwise every change is rolled back.
+func RunInTx(ctx context.Context, conn Connection, fn func(tx pgx.Tx) error) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil {
+			// Rollback after a successful commit is a no-op (ErrTxClosed)
+			_ = err // ignore error
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
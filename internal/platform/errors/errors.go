@@ -34,6 +34,14 @@ func NotFound(w http.ResponseWriter, message string) {
 	JSON(w, http.StatusNotFound, message)
 }
 
+func Conflict(w http.ResponseWriter, message string) {
+	JSON(w, http.StatusConflict, message)
+}
+
+func TooManyRequests(w http.ResponseWriter, message string) {
+	JSON(w, http.StatusTooManyRequests, message)
+}
+
 func InternalServerError(w http.ResponseWriter, message string) {
 	JSON(w, http.StatusInternalServerError, message)
 }
@@ -0,0 +1,24 @@
+// Package jobs runs background work on a schedule.
+package jobs
+
+import (
+	"context"
+	"time"
+)
+
+// Every calls fn once every interval until ctx is done. The first call is
+// one interval after Every starts. Runs never overlap: a run that takes
+// longer than interval delays the next one.
+func Every(ctx context.Context, interval time.Duration, fn func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fn(ctx)
+		}
+	}
+}
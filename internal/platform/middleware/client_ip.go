@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+)
+
+// ClientIP is the address a request came from, without the port. Behind a
+// proxy it is the client's only when chi's RealIP middleware ran first.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
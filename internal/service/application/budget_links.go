@@ -0,0 +1,64 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/platform/auth"
+	serviceDomain "github.com/noggrj/autorepair/internal/service/domain"
+)
+
+// budgetLinkPurpose keeps budget approval tokens from being accepted by any
+// other kind of signed link.
+const budgetLinkPurpose = "budget_approval"
+
+// BudgetLinks mints and checks the signed links clients answer budgets
+// with. The token only names a BudgetApproval; the record decides whether
+// it is still good, which makes it single-use and revocable.
+type BudgetLinks struct {
+	approvals serviceDomain.BudgetApprovalRepository
+	signer    *auth.LinkSigner
+}
+
+func NewBudgetLinks(approvals serviceDomain.BudgetApprovalRepository, signer *auth.LinkSigner) *BudgetLinks {
+	return &BudgetLinks{
+		approvals: approvals,
+		signer:    signer,
+	}
+}
+
+// issue revokes the links already sent for the order and returns a new one.
+// The changes go through approvals, so callers can make them part of the
+// transaction that moves the order.
+func (l *BudgetLinks) issue(ctx context.Context, approvals serviceDomain.BudgetApprovalRepository, orderID uuid.UUID) (string, *serviceDomain.BudgetApproval, error) {
+	approval := serviceDomain.NewBudgetApproval(orderID)
+	if err := approvals.RevokeOrder(ctx, orderID, approval.CreatedAt); err != nil {
+		return "", nil, err
+	}
+	if err := approvals.Save(ctx, approval); err != nil {
+		return "", nil, err
+	}
+	return l.signer.Sign(budgetLinkPurpose, approval.ID, approval.ExpiresAt), approval, nil
+}
+
+// check returns the approval token names when it was issued for orderID
+// and can still be used.
+func (l *BudgetLinks) check(ctx context.Context, orderID uuid.UUID, token string, now time.Time) (*serviceDomain.BudgetApproval, error) {
+	id, err := l.signer.Verify(budgetLinkPurpose, token, now)
+	if err != nil {
+		return nil, serviceDomain.ErrInvalidBudgetApproval
+	}
+	approval, err := l.approvals.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, serviceDomain.ErrBudgetApprovalNotFound) {
+			return nil, serviceDomain.ErrInvalidBudgetApproval
+		}
+		return nil, err
+	}
+	if approval.OrderID != orderID || !approval.Usable(now) {
+		return nil, serviceDomain.ErrInvalidBudgetApproval
+	}
+	return approval, nil
+}
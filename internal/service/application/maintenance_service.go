@@ -0,0 +1,116 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	notificationDomain "github.com/noggrj/autorepair/internal/notification/domain"
+	serviceDomain "github.com/noggrj/autorepair/internal/service/domain"
+)
+
+// MaintenanceService manages maintenance plans and emails clients when a
+// planned service is due again on one of their vehicles.
+type MaintenanceService struct {
+	plans       serviceDomain.MaintenancePlanRepository
+	reminders   serviceDomain.MaintenanceReminderRepository
+	serviceRepo serviceDomain.ServiceRepository
+	clientRepo  serviceDomain.ClientRepository
+	notifier    notificationDomain.EmailService
+}
+
+func NewMaintenanceService(
+	plans serviceDomain.MaintenancePlanRepository,
+	reminders serviceDomain.MaintenanceReminderRepository,
+	serviceRepo serviceDomain.ServiceRepository,
+	clientRepo serviceDomain.ClientRepository,
+	notifier notificationDomain.EmailService,
+) *MaintenanceService {
+	return &MaintenanceService{
+		plans:       plans,
+		reminders:   reminders,
+		serviceRepo: serviceRepo,
+		clientRepo:  clientRepo,
+		notifier:    notifier,
+	}
+}
+
+// CreatePlan puts a service on a maintenance plan. Each service has at most
+// one plan.
+func (s *MaintenanceService) CreatePlan(ctx context.Context, serviceID uuid.UUID, intervalKm, intervalMonths int) (*serviceDomain.MaintenancePlan, error) {
+	plan, err := serviceDomain.NewMaintenancePlan(serviceID, intervalKm, intervalMonths)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.serviceRepo.GetByID(ctx, serviceID); err != nil {
+		return nil, err
+	}
+	if err := s.plans.Save(ctx, plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (s *MaintenanceService) ListPlans(ctx context.Context) ([]*serviceDomain.MaintenancePlan, error) {
+	return s.plans.List(ctx)
+}
+
+func (s *MaintenanceService) DeletePlan(ctx context.Context, id uuid.UUID) error {
+	return s.plans.Delete(ctx, id)
+}
+
+// SendReminders emails the owner of every vehicle on which a planned service
+// is due at now, and returns how many reminders were sent. Each service
+// order is reminded of once; doing the service again starts a new cycle.
+func (s *MaintenanceService) SendReminders(ctx context.Context, now time.Time) (int, error) {
+	candidates, err := s.reminders.ListCandidates(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, c := range candidates {
+		reminder := c.Reminder(now)
+		if reminder == nil {
+			continue
+		}
+
+		client, err := s.clientRepo.GetByID(ctx, c.ClientID)
+		if err != nil {
+			// Try again on the next run
+			continue
+		}
+
+		claimed, err := s.reminders.Claim(ctx, reminder)
+		if err != nil {
+			return sent, err
+		}
+		if !claimed {
+			// Another run got to it first
+			continue
+		}
+
+		subject := fmt.Sprintf("Maintenance Due: %s", c.ServiceName)
+		body := fmt.Sprintf("Hello %s, the %s of your vehicle %s is due %s. Get in touch to book a visit.",
+			client.Name, c.ServiceName, c.Plate, describeDue(reminder))
+		if err := s.notifier.SendEmail(client.Email, subject, body); err != nil {
+			// Log error but continue
+			_ = err // ignore error
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+func describeDue(r *serviceDomain.MaintenanceReminder) string {
+	var due []string
+	if r.DueMileage != nil {
+		due = append(due, fmt.Sprintf("at %d km", *r.DueMileage))
+	}
+	if r.DueAt != nil {
+		due = append(due, "on "+r.DueAt.Format("2006-01-02"))
+	}
+	return strings.Join(due, " or ")
+}
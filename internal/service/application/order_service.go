@@ -1,23 +1,33 @@
 package application
 
 import (
-	"errors"
+	"bytes"
+	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
-	"context"
-
 	"github.com/google/uuid"
 	inventoryDomain "github.com/noggrj/autorepair/internal/inventory/domain"
 	notificationDomain "github.com/noggrj/autorepair/internal/notification/domain"
 	serviceDomain "github.com/noggrj/autorepair/internal/service/domain"
 )
 
+// StockAlerter is told about parts whose stock was removed, once the change
+// is committed, so it can warn about parts that fell below their minimum.
+type StockAlerter interface {
+	NotifyLowStock(ctx context.Context, parts ...*inventoryDomain.Part)
+}
+
 type OrderService struct {
 	orderRepo  serviceDomain.OrderRepository
 	partRepo   inventoryDomain.PartRepository
 	clientRepo serviceDomain.ClientRepository
 	notifier   notificationDomain.EmailService
+	uow        UnitOfWork
+	alerts     StockAlerter
+	links      *BudgetLinks
 }
 
 func NewOrderService(
@@ -25,55 +35,98 @@ func NewOrderService(
 	partRepo inventoryDomain.PartRepository,
 	clientRepo serviceDomain.ClientRepository,
 	notifier notificationDomain.EmailService,
+	uow UnitOfWork,
+	alerts StockAlerter,
+	links *BudgetLinks,
 ) *OrderService {
 	return &OrderService{
 		orderRepo:  orderRepo,
 		partRepo:   partRepo,
 		clientRepo: clientRepo,
 		notifier:   notifier,
+		uow:        uow,
+		alerts:     alerts,
+		links:      links,
 	}
 }
 
-func (s *OrderService) StartDiagnosis(orderID uuid.UUID) error {
-	order, err := s.orderRepo.GetByID(orderID)
-	if err != nil {
-		return err
-	}
+// transitionError keeps the command-specific message while still matching
+// the domain sentinel returned by Order.Transition.
+type transitionError struct {
+	msg string
+	err error
+}
 
-	if order.Status != serviceDomain.OrderStatusReceived {
-		return errors.New("diagnosis can only be started from 'Received' status")
-	}
+func (e *transitionError) Error() string { return e.msg }
+func (e *transitionError) Unwrap() error { return e.err }
+
+func (s *OrderService) StartDiagnosis(ctx context.Context, orderID uuid.UUID, actor, reason string) error {
+	var order *serviceDomain.Order
 
-	order.Status = serviceDomain.OrderStatusInDiagnosis
-	if err := s.orderRepo.Save(order); err != nil {
+	err := s.uow.Do(ctx, func(repos TxRepositories) error {
+		var err error
+		order, err = repos.Orders.GetByIDForUpdate(ctx, orderID)
+		if err != nil {
+			return err
+		}
+
+		if err := order.Transition(serviceDomain.OrderStatusInDiagnosis, actor, reason); err != nil {
+			return &transitionError{msg: "diagnosis can only be started from 'Received' status", err: err}
+		}
+
+		return repos.Orders.Save(ctx, order)
+	})
+	if err != nil {
 		return err
 	}
-	s.notifyStatusChange(order)
+
+	s.notifyStatusChange(ctx, order)
 	return nil
 }
 
-func (s *OrderService) SendBudget(orderID uuid.UUID) error {
-	order, err := s.orderRepo.GetByID(orderID)
-	if err != nil {
-		return err
-	}
+// SendBudget moves the order to Awaiting approval and mails the budget to
+// the client. With budget links configured, the mail carries a signed
+// approval code for AnswerBudget, and codes mailed before stop working. The
+// status change and the new link commit together.
+func (s *OrderService) SendBudget(ctx context.Context, orderID uuid.UUID, actor, reason string) error {
+	var order *serviceDomain.Order
+	var token string
+	var approval *serviceDomain.BudgetApproval
+
+	err := s.uow.Do(ctx, func(repos TxRepositories) error {
+		var err error
+		order, err = repos.Orders.GetByIDForUpdate(ctx, orderID)
+		if err != nil {
+			return err
+		}
 
-	if order.Status != serviceDomain.OrderStatusInDiagnosis {
-		return errors.New("budget can only be sent from 'In diagnosis' status")
-	}
+		if err := order.Transition(serviceDomain.OrderStatusAwaitingApproval, actor, reason); err != nil {
+			return &transitionError{msg: "budget can only be sent from 'In diagnosis' status", err: err}
+		}
+
+		if s.links != nil {
+			if token, approval, err = s.links.issue(ctx, repos.BudgetApprovals, order.ID); err != nil {
+				return err
+			}
+		}
 
-	order.Status = serviceDomain.OrderStatusAwaitingApproval
-	if err := s.orderRepo.Save(order); err != nil {
+		return repos.Orders.Save(ctx, order)
+	})
+	if err != nil {
 		return err
 	}
 
 	// Notify specifically about budget
-	client, err := s.clientRepo.GetByID(order.ClientID)
+	client, err := s.clientRepo.GetByID(ctx, order.ClientID)
 	if err != nil {
 		// Log error but continue with order status update
 		return nil
 	}
-	if err := s.notifier.SendEmail(client.Email, "Order Budget Ready", fmt.Sprintf("Your budget for order %s is ready. Total: %.2f", order.ID, order.Total)); err != nil {
+	body := fmt.Sprintf("Your budget for order %s is ready. Total: R$ %s", order.ID, order.Total)
+	if approval != nil {
+		body += fmt.Sprintf(". To approve or reject it, use this approval code before %s: %s", approval.ExpiresAt.Format(time.RFC1123), token)
+	}
+	if err := s.notifier.SendEmail(client.Email, "Order Budget Ready", body); err != nil {
 		// Log error but continue with order status update
 		_ = err // ignore error
 	}
@@ -81,128 +134,444 @@ func (s *OrderService) SendBudget(orderID uuid.UUID) error {
 	return nil
 }
 
-func (s *OrderService) ApproveOrder(orderID uuid.UUID) error {
-	// 1. Get Order
-	order, err := s.orderRepo.GetByID(orderID)
+// AnswerBudget records a client's answer to a budget given through the
+// approval code mailed by SendBudget. The code must be the latest one sent
+// for the order; it is spent in the same transaction that approves the
+// order, deducting its parts, or returns it to Received. The decision keeps
+// the evidence of who gave it.
+func (s *OrderService) AnswerBudget(ctx context.Context, orderID uuid.UUID, token string, decision serviceDomain.BudgetDecision) error {
+	if s.links == nil {
+		return serviceDomain.ErrInvalidBudgetApproval
+	}
+	approval, err := s.links.check(ctx, orderID, token, decision.DecidedAt)
 	if err != nil {
 		return err
 	}
 
-	// 2. Validate Status
-	// Allow approval from Received (direct) or Awaiting Approval (flow)
-	if order.Status != serviceDomain.OrderStatusReceived && order.Status != serviceDomain.OrderStatusAwaitingApproval {
-		return errors.New("order can only be approved from 'Received' or 'Awaiting approval' status")
+	var order *serviceDomain.Order
+	var reserved []*inventoryDomain.Part
+
+	err = s.uow.Do(ctx, func(repos TxRepositories) error {
+		var err error
+		order, err = repos.Orders.GetByIDForUpdate(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		if reserved, err = answerBudget(ctx, repos, order, "client", decision.Approved); err != nil {
+			return err
+		}
+
+		// Only one answer per link counts, even when two race past check.
+		decided, err := repos.BudgetApprovals.Decide(ctx, approval.ID, decision)
+		if err != nil {
+			return err
+		}
+		if !decided {
+			return serviceDomain.ErrInvalidBudgetApproval
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	// 3. Process Parts (Decrease Stock)
-	for _, item := range order.Items {
-		if item.Type == serviceDomain.ItemTypePart {
-			// Get Part to check stock and decrease it
-			part, err := s.partRepo.GetByID(context.Background(), item.RefID)
-			if err != nil {
-				return err
-			}
+	s.notifyStatusChange(ctx, order)
+	if s.alerts != nil {
+		s.alerts.NotifyLowStock(ctx, reserved...)
+	}
+	return nil
+}
 
-			if err := part.RemoveStock(item.Quantity); err != nil {
-				return err
-			}
+// RespondBudget records the answer a signed-in client gives to a budget in
+// the portal. Like AnswerBudget it only answers a budget awaiting approval,
+// checked under the order lock, and spends the link mailed with the budget,
+// which keeps the evidence of who answered it.
+func (s *OrderService) RespondBudget(ctx context.Context, orderID uuid.UUID, actor string, decision serviceDomain.BudgetDecision) error {
+	var order *serviceDomain.Order
+	var reserved []*inventoryDomain.Part
+
+	err := s.uow.Do(ctx, func(repos TxRepositories) error {
+		var err error
+		order, err = repos.Orders.GetByIDForUpdate(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		if reserved, err = answerBudget(ctx, repos, order, actor, decision.Approved); err != nil {
+			return err
+		}
 
-			if err := s.partRepo.Update(context.Background(), part); err != nil {
-				return err
-			}
+		decided, err := repos.BudgetApprovals.DecideOrder(ctx, order.ID, decision)
+		if err != nil {
+			return err
 		}
+		if decided {
+			return nil
+		}
+		// No link was mailed with the budget; keep the evidence anyway.
+		approval := serviceDomain.NewBudgetApproval(order.ID)
+		if err := repos.BudgetApprovals.Save(ctx, approval); err != nil {
+			return err
+		}
+		_, err = repos.BudgetApprovals.Decide(ctx, approval.ID, decision)
+		return err
+	})
+	if err != nil {
+		return err
 	}
 
-	// 4. Update Status
-	order.Status = serviceDomain.OrderStatusInExecution
-	now := time.Now()
-	order.StartedAt = &now
+	s.notifyStatusChange(ctx, order)
+	if s.alerts != nil {
+		s.alerts.NotifyLowStock(ctx, reserved...)
+	}
+	return nil
+}
 
-	// 5. Save
-	if err := s.orderRepo.Save(order); err != nil {
-		return err
+// answerBudget applies a client's answer to the locked order and saves it:
+// approving starts the work and deducts its parts, rejecting returns the
+// order to Received.
+func answerBudget(ctx context.Context, repos TxRepositories, order *serviceDomain.Order, actor string, approved bool) ([]*inventoryDomain.Part, error) {
+	// Approving also works from Received; a client only answers a budget.
+	if order.Status != serviceDomain.OrderStatusAwaitingApproval {
+		return nil, &transitionError{msg: "budget can only be answered in 'Awaiting approval' status", err: serviceDomain.ErrInvalidTransition}
 	}
 
-	// 6. Notify
-	s.notifyStatusChange(order)
+	var reserved []*inventoryDomain.Part
+	if approved {
+		if err := order.Transition(serviceDomain.OrderStatusInExecution, actor, "budget approved by client"); err != nil {
+			return nil, err
+		}
+		var err error
+		if reserved, err = reserveParts(ctx, repos, order); err != nil {
+			return nil, err
+		}
+	} else if err := order.Transition(serviceDomain.OrderStatusReceived, actor, "budget rejected by client"); err != nil {
+		return nil, err
+	}
 
-	return nil
+	return reserved, repos.Orders.Save(ctx, order)
+}
+
+// BudgetApprovals returns the approval links sent for an order, with the
+// answers given through them.
+func (s *OrderService) BudgetApprovals(ctx context.Context, orderID uuid.UUID) ([]*serviceDomain.BudgetApproval, error) {
+	if _, err := s.orderRepo.GetByID(ctx, orderID); err != nil {
+		return nil, err
+	}
+	if s.links == nil {
+		return []*serviceDomain.BudgetApproval{}, nil
+	}
+	return s.links.approvals.ListByOrderID(ctx, orderID)
 }
 
-func (s *OrderService) FinishOrder(orderID uuid.UUID) error {
-	order, err := s.orderRepo.GetByID(orderID)
+func (s *OrderService) ApproveOrder(ctx context.Context, orderID uuid.UUID, actor, reason string) error {
+	var order *serviceDomain.Order
+	var reserved []*inventoryDomain.Part
+
+	// The status change and the stock deduction commit together or not at all.
+	err := s.uow.Do(ctx, func(repos TxRepositories) error {
+		// 1. Get Order (locked, so concurrent approvals of the same order serialize)
+		var err error
+		order, err = repos.Orders.GetByIDForUpdate(ctx, orderID)
+		if err != nil {
+			return err
+		}
+
+		// 2. Transition Status (sets StartedAt)
+		// Allow approval from Received (direct) or Awaiting Approval (flow)
+		if err := order.Transition(serviceDomain.OrderStatusInExecution, actor, reason); err != nil {
+			return &transitionError{msg: "order can only be approved from 'Received' or 'Awaiting approval' status", err: err}
+		}
+
+		// 3. Reserve Parts (Decrease Stock)
+		reserved, err = reserveParts(ctx, repos, order)
+		if err != nil {
+			return err
+		}
+
+		// 4. Save
+		return repos.Orders.Save(ctx, order)
+	})
 	if err != nil {
 		return err
 	}
 
-	if order.Status != serviceDomain.OrderStatusInExecution {
-		return errors.New("order can only be finished from 'In execution' status")
+	// 5. Notify (only after commit)
+	s.notifyStatusChange(ctx, order)
+	if s.alerts != nil {
+		s.alerts.NotifyLowStock(ctx, reserved...)
+	}
+
+	return nil
+}
+
+// CancelOrder moves the order to the terminal Cancelled status. If its parts
+// were already deducted on approval, they are returned to inventory in the
+// same transaction. The client is notified with the given reason.
+func (s *OrderService) CancelOrder(ctx context.Context, orderID uuid.UUID, actor, reason string) error {
+	if strings.TrimSpace(reason) == "" {
+		return serviceDomain.ErrReasonRequired
 	}
 
-	order.Status = serviceDomain.OrderStatusCompleted // "Finished" in requirements
-	now := time.Now()
-	order.FinishedAt = &now
+	var order *serviceDomain.Order
 
-	if err := s.orderRepo.Save(order); err != nil {
+	err := s.uow.Do(ctx, func(repos TxRepositories) error {
+		var err error
+		order, err = repos.Orders.GetByIDForUpdate(ctx, orderID)
+		if err != nil {
+			return err
+		}
+
+		restoreStock := order.Status.HoldsStock()
+		if err := order.Transition(serviceDomain.OrderStatusCancelled, actor, reason); err != nil {
+			return &transitionError{msg: "order can only be cancelled before it is completed", err: err}
+		}
+
+		if restoreStock {
+			partIDs, quantities := partQuantities(order.Items)
+			for _, partID := range partIDs {
+				part, err := repos.Parts.GetByIDForUpdate(ctx, partID)
+				if err != nil {
+					return err
+				}
+
+				if err := part.AddStock(quantities[partID], inventoryDomain.MovementReturn, "order:"+order.ID.String()); err != nil {
+					return err
+				}
+
+				if err := repos.Parts.Update(ctx, part); err != nil {
+					return err
+				}
+			}
+		}
+
+		return repos.Orders.Save(ctx, order)
+	})
+	if err != nil {
 		return err
 	}
-	s.notifyStatusChange(order)
+
+	s.notifyCancellation(ctx, order, reason)
 	return nil
 }
 
-func (s *OrderService) DeliverOrder(orderID uuid.UUID) error {
-	order, err := s.orderRepo.GetByID(orderID)
+// RemoveItem takes a line off an open order. If the order's parts were
+// already deducted on approval, the removed part goes back to inventory in
+// the same transaction that saves the new totals.
+func (s *OrderService) RemoveItem(ctx context.Context, orderID, itemID uuid.UUID) (*serviceDomain.Order, error) {
+	var order *serviceDomain.Order
+
+	err := s.uow.Do(ctx, func(repos TxRepositories) error {
+		var err error
+		order, err = repos.Orders.GetByIDForUpdate(ctx, orderID)
+		if err != nil {
+			return err
+		}
+
+		item, err := order.RemoveItem(itemID)
+		if err != nil {
+			return err
+		}
+
+		if item.Type == serviceDomain.ItemTypePart && order.Status.HoldsStock() {
+			part, err := repos.Parts.GetByIDForUpdate(ctx, item.RefID)
+			if err != nil {
+				return err
+			}
+
+			if err := part.AddStock(item.Quantity, inventoryDomain.MovementReturn, "order:"+order.ID.String()); err != nil {
+				return err
+			}
+
+			if err := repos.Parts.Update(ctx, part); err != nil {
+				return err
+			}
+		}
+
+		return repos.Orders.Save(ctx, order)
+	})
 	if err != nil {
-		return err
+		return nil, err
+	}
+	return order, nil
+}
+
+// reserveParts deducts the order's parts from stock and returns them.
+// Parts are locked in a stable order to avoid deadlocks between approvals.
+func reserveParts(ctx context.Context, repos TxRepositories, order *serviceDomain.Order) ([]*inventoryDomain.Part, error) {
+	var reserved []*inventoryDomain.Part
+	partIDs, quantities := partQuantities(order.Items)
+	for _, partID := range partIDs {
+		part, err := repos.Parts.GetByIDForUpdate(ctx, partID)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := part.RemoveStock(quantities[partID], inventoryDomain.MovementOrderConsumption, "order:"+order.ID.String()); err != nil {
+			return nil, err
+		}
+
+		if err := repos.Parts.Update(ctx, part); err != nil {
+			return nil, err
+		}
+		reserved = append(reserved, part)
 	}
+	return reserved, nil
+}
 
-	if order.Status != serviceDomain.OrderStatusCompleted {
-		return errors.New("order can only be delivered from 'Completed' status")
+// partQuantities sums the requested quantity per part and returns the part
+// IDs sorted, so every transaction acquires row locks in the same order.
+func partQuantities(items []*serviceDomain.OrderItem) ([]uuid.UUID, map[uuid.UUID]int) {
+	quantities := make(map[uuid.UUID]int)
+	var ids []uuid.UUID
+	for _, item := range items {
+		if item.Type != serviceDomain.ItemTypePart {
+			continue
+		}
+		if _, ok := quantities[item.RefID]; !ok {
+			ids = append(ids, item.RefID)
+		}
+		quantities[item.RefID] += item.Quantity
 	}
+	sort.Slice(ids, func(i, j int) bool {
+		return bytes.Compare(ids[i][:], ids[j][:]) < 0
+	})
+	return ids, quantities
+}
+
+// FinishOrder locks the order, so it cannot be completed while a concurrent
+// cancellation returns its parts to stock.
+func (s *OrderService) FinishOrder(ctx context.Context, orderID uuid.UUID, actor, reason string) error {
+	var order *serviceDomain.Order
+
+	err := s.uow.Do(ctx, func(repos TxRepositories) error {
+		var err error
+		order, err = repos.Orders.GetByIDForUpdate(ctx, orderID)
+		if err != nil {
+			return err
+		}
+
+		// "Finished" in requirements; Transition sets FinishedAt
+		if err := order.Transition(serviceDomain.OrderStatusCompleted, actor, reason); err != nil {
+			return &transitionError{msg: "order can only be finished from 'In execution' status", err: err}
+		}
 
-	order.Status = serviceDomain.OrderStatusDelivered
-	if err := s.orderRepo.Save(order); err != nil {
+		return repos.Orders.Save(ctx, order)
+	})
+	if err != nil {
 		return err
 	}
-	s.notifyStatusChange(order)
+
+	s.notifyStatusChange(ctx, order)
 	return nil
 }
 
-func (s *OrderService) UpdateStatus(orderID uuid.UUID, status serviceDomain.OrderStatus) error {
-	order, err := s.orderRepo.GetByID(orderID)
+// Receive stores a newly opened order together with the odometer reading
+// taken at reception, which must not be below the vehicle's last one.
+func (s *OrderService) Receive(ctx context.Context, order *serviceDomain.Order) error {
+	reading, err := order.ReceptionReading()
 	if err != nil {
 		return err
 	}
+	return s.uow.Do(ctx, func(repos TxRepositories) error {
+		if err := repos.Orders.Save(ctx, order); err != nil {
+			return err
+		}
+		return repos.Odometer.Record(ctx, reading)
+	})
+}
+
+// DeliverOrder hands the vehicle back to the client. When mileage is given it
+// is recorded as the delivery odometer reading, in the same transaction as
+// the status change.
+func (s *OrderService) DeliverOrder(ctx context.Context, orderID uuid.UUID, mileage *int, actor, reason string) error {
+	var order *serviceDomain.Order
+
+	err := s.uow.Do(ctx, func(repos TxRepositories) error {
+		var err error
+		order, err = repos.Orders.GetByIDForUpdate(ctx, orderID)
+		if err != nil {
+			return err
+		}
+
+		if err := order.Transition(serviceDomain.OrderStatusDelivered, actor, reason); err != nil {
+			return &transitionError{msg: "order can only be delivered from 'Completed' status", err: err}
+		}
+
+		if mileage != nil {
+			reading, err := order.RecordDeliveryMileage(*mileage)
+			if err != nil {
+				return err
+			}
+			if err := repos.Odometer.Record(ctx, reading); err != nil {
+				return err
+			}
+		}
 
-	order.Status = status
-	if err := s.orderRepo.Save(order); err != nil {
+		return repos.Orders.Save(ctx, order)
+	})
+	if err != nil {
 		return err
 	}
 
-	s.notifyStatusChange(order)
-
+	s.notifyStatusChange(ctx, order)
 	return nil
 }
 
-func (s *OrderService) RejectBudget(orderID uuid.UUID) error {
-	order, err := s.orderRepo.GetByID(orderID)
-	if err != nil {
-		return err
+// UpdateStatus moves an order to the requested status through the matching command,
+// so side effects such as stock deduction and notifications are never skipped.
+func (s *OrderService) UpdateStatus(ctx context.Context, orderID uuid.UUID, status serviceDomain.OrderStatus, actor, reason string) error {
+	switch status {
+	case serviceDomain.OrderStatusInDiagnosis:
+		return s.StartDiagnosis(ctx, orderID, actor, reason)
+	case serviceDomain.OrderStatusAwaitingApproval:
+		return s.SendBudget(ctx, orderID, actor, reason)
+	case serviceDomain.OrderStatusInExecution:
+		return s.ApproveOrder(ctx, orderID, actor, reason)
+	case serviceDomain.OrderStatusCompleted:
+		return s.FinishOrder(ctx, orderID, actor, reason)
+	case serviceDomain.OrderStatusDelivered:
+		return s.DeliverOrder(ctx, orderID, nil, actor, reason)
+	case serviceDomain.OrderStatusReceived:
+		return s.RejectBudget(ctx, orderID, actor, reason)
+	case serviceDomain.OrderStatusCancelled:
+		return s.CancelOrder(ctx, orderID, actor, reason)
+	default:
+		return serviceDomain.ErrInvalidOrderStatus
 	}
+}
 
-	if order.Status != serviceDomain.OrderStatusAwaitingApproval {
-		return errors.New("budget can only be rejected from 'Awaiting approval' status")
+// History returns the recorded status transitions of an order.
+func (s *OrderService) History(ctx context.Context, orderID uuid.UUID) ([]*serviceDomain.StatusTransition, error) {
+	if _, err := s.orderRepo.GetByID(ctx, orderID); err != nil {
+		return nil, err
 	}
+	return s.orderRepo.ListStatusHistory(ctx, orderID)
+}
+
+// RejectBudget returns the order to Received. The order is locked, so a
+// rejection cannot undo an approval that deducted its parts meanwhile.
+func (s *OrderService) RejectBudget(ctx context.Context, orderID uuid.UUID, actor, reason string) error {
+	var order *serviceDomain.Order
+
+	err := s.uow.Do(ctx, func(repos TxRepositories) error {
+		var err error
+		order, err = repos.Orders.GetByIDForUpdate(ctx, orderID)
+		if err != nil {
+			return err
+		}
 
-	order.Status = serviceDomain.OrderStatusReceived
-	order.UpdatedAt = time.Now()
+		if err := order.Transition(serviceDomain.OrderStatusReceived, actor, reason); err != nil {
+			return &transitionError{msg: "budget can only be rejected from 'Awaiting approval' status", err: err}
+		}
 
-	if err := s.orderRepo.Save(order); err != nil {
+		return repos.Orders.Save(ctx, order)
+	})
+	if err != nil {
 		return err
 	}
 
 	// Notify client about rejection
-	client, err := s.clientRepo.GetByID(order.ClientID)
+	client, err := s.clientRepo.GetByID(ctx, order.ClientID)
 	if err != nil {
 		return nil
 	}
@@ -215,8 +584,8 @@ func (s *OrderService) RejectBudget(orderID uuid.UUID) error {
 	return nil
 }
 
-func (s *OrderService) notifyStatusChange(order *serviceDomain.Order) {
-	client, err := s.clientRepo.GetByID(order.ClientID)
+func (s *OrderService) notifyStatusChange(ctx context.Context, order *serviceDomain.Order) {
+	client, err := s.clientRepo.GetByID(ctx, order.ClientID)
 	if err != nil {
 		// Log error but don't fail flow
 		return
@@ -230,3 +599,19 @@ func (s *OrderService) notifyStatusChange(order *serviceDomain.Order) {
 		_ = err // ignore error
 	}
 }
+
+func (s *OrderService) notifyCancellation(ctx context.Context, order *serviceDomain.Order, reason string) {
+	client, err := s.clientRepo.GetByID(ctx, order.ClientID)
+	if err != nil {
+		// Log error but don't fail flow
+		return
+	}
+
+	subject := fmt.Sprintf("Order Cancelled: %s", order.ID)
+	body := fmt.Sprintf("Hello %s, your order %s has been cancelled. Reason: %s", client.Name, order.ID, reason)
+
+	if err := s.notifier.SendEmail(client.Email, subject, body); err != nil {
+		// Log error but continue
+		_ = err // ignore error
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/google/uuid"
 	inventoryDomain "github.com/noggrj/autorepair/internal/inventory/domain"
 	serviceDomain "github.com/noggrj/autorepair/internal/service/domain"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -16,32 +17,59 @@ type MockOrderRepository struct {
 	mock.Mock
 }
 
-func (m *MockOrderRepository) Save(order *serviceDomain.Order) error {
-	args := m.Called(order)
+func (m *MockOrderRepository) Save(ctx context.Context, order *serviceDomain.Order) error {
+	args := m.Called(ctx, order)
 	return args.Error(0)
 }
 
-func (m *MockOrderRepository) GetByID(id uuid.UUID) (*serviceDomain.Order, error) {
-	args := m.Called(id)
+func (m *MockOrderRepository) GetByID(ctx context.Context, id uuid.UUID) (*serviceDomain.Order, error) {
+	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*serviceDomain.Order), args.Error(1)
 }
 
-func (m *MockOrderRepository) List() ([]*serviceDomain.Order, error) {
-	args := m.Called()
+func (m *MockOrderRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*serviceDomain.Order, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*serviceDomain.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*serviceDomain.Order], error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return sharedkernel.Page[*serviceDomain.Order]{}, args.Error(1)
+	}
+	return args.Get(0).(sharedkernel.Page[*serviceDomain.Order]), args.Error(1)
+}
+
+func (m *MockOrderRepository) ListActive(ctx context.Context) ([]*serviceDomain.Order, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
 	return args.Get(0).([]*serviceDomain.Order), args.Error(1)
 }
 
-func (m *MockOrderRepository) ListActive() ([]*serviceDomain.Order, error) {
-	args := m.Called()
+func (m *MockOrderRepository) ListByVehicleID(ctx context.Context, vehicleID uuid.UUID) ([]*serviceDomain.Order, error) {
+	args := m.Called(ctx, vehicleID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*serviceDomain.Order), args.Error(1)
 }
 
+func (m *MockOrderRepository) ListStatusHistory(ctx context.Context, orderID uuid.UUID) ([]*serviceDomain.StatusTransition, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*serviceDomain.StatusTransition), args.Error(1)
+}
+
 type MockPartRepository struct {
 	mock.Mock
 }
@@ -59,9 +87,28 @@ func (m *MockPartRepository) GetByID(ctx context.Context, id uuid.UUID) (*invent
 	return args.Get(0).(*inventoryDomain.Part), args.Error(1)
 }
 
-func (m *MockPartRepository) List(ctx context.Context) ([]*inventoryDomain.Part, error) {
-	args := m.Called(ctx)
-	return args.Get(0).([]*inventoryDomain.Part), args.Error(1)
+func (m *MockPartRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*inventoryDomain.Part, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*inventoryDomain.Part), args.Error(1)
+}
+
+func (m *MockPartRepository) GetBySKU(ctx context.Context, sku string) (*inventoryDomain.Part, error) {
+	args := m.Called(ctx, sku)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*inventoryDomain.Part), args.Error(1)
+}
+
+func (m *MockPartRepository) List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*inventoryDomain.Part], error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return sharedkernel.Page[*inventoryDomain.Part]{}, args.Error(1)
+	}
+	return args.Get(0).(sharedkernel.Page[*inventoryDomain.Part]), args.Error(1)
 }
 
 func (m *MockPartRepository) Update(ctx context.Context, part *inventoryDomain.Part) error {
@@ -69,22 +116,59 @@ func (m *MockPartRepository) Update(ctx context.Context, part *inventoryDomain.P
 	return args.Error(0)
 }
 
-func (m *MockPartRepository) Delete(ctx context.Context, id uuid.UUID) error {
+func (m *MockPartRepository) Archive(ctx context.Context, id uuid.UUID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
+func (m *MockPartRepository) ListLowStock(ctx context.Context) ([]*inventoryDomain.Part, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*inventoryDomain.Part), args.Error(1)
+}
+
+func (m *MockPartRepository) ListMovements(ctx context.Context, partID uuid.UUID) ([]*inventoryDomain.StockMovement, error) {
+	args := m.Called(ctx, partID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*inventoryDomain.StockMovement), args.Error(1)
+}
+
+func (m *MockPartRepository) LedgerQuantities(ctx context.Context) (map[uuid.UUID]int, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uuid.UUID]int), args.Error(1)
+}
+
+// fakeUnitOfWork hands the mocks to the callback without a real transaction.
+type fakeUnitOfWork struct {
+	repos TxRepositories
+}
+
+func newFakeUnitOfWork(orders serviceDomain.OrderRepository, parts inventoryDomain.PartRepository) *fakeUnitOfWork {
+	return &fakeUnitOfWork{repos: TxRepositories{Orders: orders, Parts: parts}}
+}
+
+func (u *fakeUnitOfWork) Do(ctx context.Context, fn func(repos TxRepositories) error) error {
+	return fn(u.repos)
+}
+
 type MockClientRepository struct {
 	mock.Mock
 }
 
-func (m *MockClientRepository) Save(client *serviceDomain.Client) error {
-	args := m.Called(client)
+func (m *MockClientRepository) Save(ctx context.Context, client *serviceDomain.Client) error {
+	args := m.Called(ctx, client)
 	return args.Error(0)
 }
 
-func (m *MockClientRepository) GetByID(id uuid.UUID) (*serviceDomain.Client, error) {
-	args := m.Called(id)
+func (m *MockClientRepository) GetByID(ctx context.Context, id uuid.UUID) (*serviceDomain.Client, error) {
+	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -99,13 +183,16 @@ func (m *MockClientRepository) GetByCPF(cpf string) (*serviceDomain.Client, erro
 	return args.Get(0).(*serviceDomain.Client), args.Error(1)
 }
 
-func (m *MockClientRepository) List() ([]*serviceDomain.Client, error) {
-	args := m.Called()
-	return args.Get(0).([]*serviceDomain.Client), args.Error(1)
+func (m *MockClientRepository) List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*serviceDomain.Client], error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return sharedkernel.Page[*serviceDomain.Client]{}, args.Error(1)
+	}
+	return args.Get(0).(sharedkernel.Page[*serviceDomain.Client]), args.Error(1)
 }
 
-func (m *MockClientRepository) Delete(id uuid.UUID) error {
-	args := m.Called(id)
+func (m *MockClientRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
@@ -124,7 +211,7 @@ func TestStartDiagnosis(t *testing.T) {
 	mockClientRepo := new(MockClientRepository)
 	mockNotifier := new(MockEmailService)
 
-	service := NewOrderService(mockOrderRepo, mockPartRepo, mockClientRepo, mockNotifier)
+	service := NewOrderService(mockOrderRepo, mockPartRepo, mockClientRepo, mockNotifier, newFakeUnitOfWork(mockOrderRepo, mockPartRepo), nil, nil)
 
 	orderID := uuid.New()
 	clientID := uuid.New()
@@ -141,12 +228,12 @@ func TestStartDiagnosis(t *testing.T) {
 	}
 
 	t.Run("Success", func(t *testing.T) {
-		mockOrderRepo.On("GetByID", orderID).Return(order, nil)
-		mockOrderRepo.On("Save", mock.Anything).Return(nil)
-		mockClientRepo.On("GetByID", clientID).Return(client, nil)
+		mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+		mockOrderRepo.On("Save", mock.Anything, mock.Anything).Return(nil)
+		mockClientRepo.On("GetByID", mock.Anything, clientID).Return(client, nil)
 		mockNotifier.On("SendEmail", client.Email, mock.Anything, mock.Anything).Return(nil)
 
-		err := service.StartDiagnosis(orderID)
+		err := service.StartDiagnosis(context.Background(), orderID, "tester", "")
 
 		assert.NoError(t, err)
 		assert.Equal(t, serviceDomain.OrderStatusInDiagnosis, order.Status)
@@ -159,7 +246,7 @@ func TestApproveOrder(t *testing.T) {
 	mockClientRepo := new(MockClientRepository)
 	mockNotifier := new(MockEmailService)
 
-	service := NewOrderService(mockOrderRepo, mockPartRepo, mockClientRepo, mockNotifier)
+	service := NewOrderService(mockOrderRepo, mockPartRepo, mockClientRepo, mockNotifier, newFakeUnitOfWork(mockOrderRepo, mockPartRepo), nil, nil)
 
 	orderID := uuid.New()
 	clientID := uuid.New()
@@ -190,14 +277,14 @@ func TestApproveOrder(t *testing.T) {
 	}
 
 	t.Run("Success", func(t *testing.T) {
-		mockOrderRepo.On("GetByID", orderID).Return(order, nil)
-		mockPartRepo.On("GetByID", context.Background(), partID).Return(part, nil)
+		mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+		mockPartRepo.On("GetByIDForUpdate", context.Background(), partID).Return(part, nil)
 		mockPartRepo.On("Update", context.Background(), part).Return(nil)
-		mockOrderRepo.On("Save", mock.Anything).Return(nil)
-		mockClientRepo.On("GetByID", clientID).Return(client, nil)
+		mockOrderRepo.On("Save", mock.Anything, mock.Anything).Return(nil)
+		mockClientRepo.On("GetByID", mock.Anything, clientID).Return(client, nil)
 		mockNotifier.On("SendEmail", client.Email, mock.Anything, mock.Anything).Return(nil)
 
-		err := service.ApproveOrder(orderID)
+		err := service.ApproveOrder(context.Background(), orderID, "tester", "")
 
 		assert.NoError(t, err)
 		assert.Equal(t, serviceDomain.OrderStatusInExecution, order.Status)
@@ -211,7 +298,7 @@ func TestSendBudget(t *testing.T) {
 	mockClientRepo := new(MockClientRepository)
 	mockNotifier := new(MockEmailService)
 
-	service := NewOrderService(mockOrderRepo, mockPartRepo, mockClientRepo, mockNotifier)
+	service := NewOrderService(mockOrderRepo, mockPartRepo, mockClientRepo, mockNotifier, newFakeUnitOfWork(mockOrderRepo, mockPartRepo), nil, nil)
 
 	orderID := uuid.New()
 	clientID := uuid.New()
@@ -219,7 +306,7 @@ func TestSendBudget(t *testing.T) {
 		ID:       orderID,
 		ClientID: clientID,
 		Status:   serviceDomain.OrderStatusInDiagnosis,
-		Total:    100.0,
+		Total:    sharedkernel.NewMoneyFromFloat(100.0),
 	}
 
 	client := &serviceDomain.Client{
@@ -229,12 +316,12 @@ func TestSendBudget(t *testing.T) {
 	}
 
 	t.Run("Success", func(t *testing.T) {
-		mockOrderRepo.On("GetByID", orderID).Return(order, nil)
-		mockOrderRepo.On("Save", mock.Anything).Return(nil)
-		mockClientRepo.On("GetByID", clientID).Return(client, nil)
+		mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+		mockOrderRepo.On("Save", mock.Anything, mock.Anything).Return(nil)
+		mockClientRepo.On("GetByID", mock.Anything, clientID).Return(client, nil)
 		mockNotifier.On("SendEmail", client.Email, mock.Anything, mock.Anything).Return(nil)
 
-		err := service.SendBudget(orderID)
+		err := service.SendBudget(context.Background(), orderID, "tester", "")
 
 		assert.NoError(t, err)
 		assert.Equal(t, serviceDomain.OrderStatusAwaitingApproval, order.Status)
@@ -247,7 +334,7 @@ func TestFinishOrder(t *testing.T) {
 	mockClientRepo := new(MockClientRepository)
 	mockNotifier := new(MockEmailService)
 
-	service := NewOrderService(mockOrderRepo, mockPartRepo, mockClientRepo, mockNotifier)
+	service := NewOrderService(mockOrderRepo, mockPartRepo, mockClientRepo, mockNotifier, newFakeUnitOfWork(mockOrderRepo, mockPartRepo), nil, nil)
 
 	orderID := uuid.New()
 	clientID := uuid.New()
@@ -264,12 +351,12 @@ func TestFinishOrder(t *testing.T) {
 	}
 
 	t.Run("Success", func(t *testing.T) {
-		mockOrderRepo.On("GetByID", orderID).Return(order, nil)
-		mockOrderRepo.On("Save", mock.Anything).Return(nil)
-		mockClientRepo.On("GetByID", clientID).Return(client, nil)
+		mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+		mockOrderRepo.On("Save", mock.Anything, mock.Anything).Return(nil)
+		mockClientRepo.On("GetByID", mock.Anything, clientID).Return(client, nil)
 		mockNotifier.On("SendEmail", client.Email, mock.Anything, mock.Anything).Return(nil)
 
-		err := service.FinishOrder(orderID)
+		err := service.FinishOrder(context.Background(), orderID, "tester", "")
 
 		assert.NoError(t, err)
 		assert.Equal(t, serviceDomain.OrderStatusCompleted, order.Status)
@@ -283,7 +370,7 @@ func TestDeliverOrder(t *testing.T) {
 	mockClientRepo := new(MockClientRepository)
 	mockNotifier := new(MockEmailService)
 
-	service := NewOrderService(mockOrderRepo, mockPartRepo, mockClientRepo, mockNotifier)
+	service := NewOrderService(mockOrderRepo, mockPartRepo, mockClientRepo, mockNotifier, newFakeUnitOfWork(mockOrderRepo, mockPartRepo), nil, nil)
 
 	orderID := uuid.New()
 	clientID := uuid.New()
@@ -300,12 +387,12 @@ func TestDeliverOrder(t *testing.T) {
 	}
 
 	t.Run("Success", func(t *testing.T) {
-		mockOrderRepo.On("GetByID", orderID).Return(order, nil)
-		mockOrderRepo.On("Save", mock.Anything).Return(nil)
-		mockClientRepo.On("GetByID", clientID).Return(client, nil)
+		mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+		mockOrderRepo.On("Save", mock.Anything, mock.Anything).Return(nil)
+		mockClientRepo.On("GetByID", mock.Anything, clientID).Return(client, nil)
 		mockNotifier.On("SendEmail", client.Email, mock.Anything, mock.Anything).Return(nil)
 
-		err := service.DeliverOrder(orderID)
+		err := service.DeliverOrder(context.Background(), orderID, nil, "tester", "")
 
 		assert.NoError(t, err)
 		assert.Equal(t, serviceDomain.OrderStatusDelivered, order.Status)
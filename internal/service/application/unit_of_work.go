@@ -0,0 +1,22 @@
+package application
+
+import (
+	"context"
+
+	inventoryDomain "github.com/noggrj/autorepair/internal/inventory/domain"
+	serviceDomain "github.com/noggrj/autorepair/internal/service/domain"
+)
+
+// TxRepositories are repositories bound to the same database transaction.
+type TxRepositories struct {
+	Orders          serviceDomain.OrderRepository
+	Parts           inventoryDomain.PartRepository
+	BudgetApprovals serviceDomain.BudgetApprovalRepository
+	Odometer        serviceDomain.OdometerRepository
+}
+
+// UnitOfWork runs fn atomically: either every change made through the
+// provided repositories is committed, or none is.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(repos TxRepositories) error) error
+}
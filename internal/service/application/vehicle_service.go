@@ -0,0 +1,82 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	serviceDomain "github.com/noggrj/autorepair/internal/service/domain"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
+)
+
+// VehicleService moves vehicles between clients, keeps who owned them
+// before, and decodes what it can of a vehicle from its VIN.
+type VehicleService struct {
+	ownerships serviceDomain.VehicleOwnershipRepository
+	clientRepo serviceDomain.ClientRepository
+	specs      serviceDomain.VehicleSpecProvider
+}
+
+func NewVehicleService(
+	ownerships serviceDomain.VehicleOwnershipRepository,
+	clientRepo serviceDomain.ClientRepository,
+	specs serviceDomain.VehicleSpecProvider,
+) *VehicleService {
+	return &VehicleService{
+		ownerships: ownerships,
+		clientRepo: clientRepo,
+		specs:      specs,
+	}
+}
+
+// VehicleDetails are the free-text details of a vehicle that a VIN can
+// fill in.
+type VehicleDetails struct {
+	Brand string
+	Model string
+	Year  int
+}
+
+// Prefill fills in the empty fields of details from the VIN: brand and model
+// from the spec provider, year from the VIN's model year. Fields already set
+// are kept, and a VIN the provider does not know leaves brand and model
+// empty.
+func (s *VehicleService) Prefill(ctx context.Context, vin sharedkernel.VIN, details VehicleDetails) (VehicleDetails, error) {
+	if (details.Brand == "" || details.Model == "") && s.specs != nil {
+		spec, err := s.specs.Lookup(ctx, vin)
+		switch {
+		case errors.Is(err, serviceDomain.ErrVehicleSpecNotFound):
+			// Left for the caller to fill in
+		case err != nil:
+			return details, err
+		default:
+			if details.Brand == "" {
+				details.Brand = spec.Brand
+			}
+			if details.Model == "" {
+				details.Model = spec.Model
+			}
+		}
+	}
+	if details.Year == 0 {
+		if year, ok := vin.ModelYear(time.Now().Year()); ok {
+			details.Year = year
+		}
+	}
+	return details, nil
+}
+
+// TransferOwnership hands a vehicle over to another client, who must exist.
+// The orders opened so far stay with the previous owner.
+func (s *VehicleService) TransferOwnership(ctx context.Context, vehicleID, clientID uuid.UUID) (*serviceDomain.Vehicle, error) {
+	if _, err := s.clientRepo.GetByID(ctx, clientID); err != nil {
+		return nil, err
+	}
+	return s.ownerships.Transfer(ctx, vehicleID, clientID)
+}
+
+// PastOwners returns the earlier ownership periods of a vehicle, oldest first.
+func (s *VehicleService) PastOwners(ctx context.Context, vehicleID uuid.UUID) ([]*serviceDomain.VehicleOwnership, error) {
+	return s.ownerships.ListByVehicleID(ctx, vehicleID)
+}
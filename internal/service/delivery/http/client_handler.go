@@ -50,7 +50,7 @@ func (h *ClientHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.repo.Save(client); err != nil {
+	if err := h.repo.Save(r.Context(), client); err != nil {
 		http.Error(w, "Failed to save client", http.StatusInternalServerError)
 		return
 	}
@@ -63,24 +63,34 @@ func (h *ClientHandler) Create(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary List Clients
-// @Description List all clients
+// @Description List clients a page at a time. The next page is linked in the Link header.
 // @Tags clients
 // @Accept json
 // @Produce json
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param cursor query string false "Cursor from X-Next-Cursor"
+// @Param sort query string false "name or created_at, prefix with - for descending"
+// @Param q query string false "Search name, email or document"
+// @Param from query string false "Created from (RFC 3339 or YYYY-MM-DD)"
+// @Param to query string false "Created until (RFC 3339 or YYYY-MM-DD)"
 // @Success 200 {array} map[string]interface{}
+// @Header 200 {string} Link "Next page"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page"
+// @Failure 400 {object} string "Invalid query"
 // @Failure 500 {object} string "Internal Server Error"
 // @Router /admin/clients [get]
 func (h *ClientHandler) List(w http.ResponseWriter, r *http.Request) {
-	clients, err := h.repo.List()
+	q, err := parseListQuery(r)
 	if err != nil {
-		http.Error(w, "Failed to list clients", http.StatusInternalServerError)
+		writeListError(w, err, "Failed to list clients")
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(clients); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	page, err := h.repo.List(r.Context(), q)
+	if err != nil {
+		writeListError(w, err, "Failed to list clients")
 		return
 	}
+	writePage(w, r, page.Items, page.NextCursor)
 }
 
 // @Summary Update Client
@@ -109,7 +119,7 @@ func (h *ClientHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	client, err := h.repo.GetByID(id)
+	client, err := h.repo.GetByID(r.Context(), id)
 	if err != nil {
 		http.Error(w, "Client not found", http.StatusNotFound)
 		return
@@ -135,7 +145,7 @@ func (h *ClientHandler) Update(w http.ResponseWriter, r *http.Request) {
 	}
 	client.UpdatedAt = time.Now()
 
-	if err := h.repo.Save(client); err != nil {
+	if err := h.repo.Save(r.Context(), client); err != nil {
 		http.Error(w, "Failed to update client", http.StatusInternalServerError)
 		return
 	}
@@ -166,7 +176,7 @@ func (h *ClientHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.repo.Delete(id); err != nil {
+	if err := h.repo.Delete(r.Context(), id); err != nil {
 		if errors.Is(err, domain.ErrClientNotFound) {
 			http.Error(w, "Client not found", http.StatusNotFound)
 			return
@@ -0,0 +1,123 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	serviceApplication "github.com/noggrj/autorepair/internal/service/application"
+	serviceDomain "github.com/noggrj/autorepair/internal/service/domain"
+)
+
+type MaintenancePlanHandler struct {
+	service *serviceApplication.MaintenanceService
+}
+
+func NewMaintenancePlanHandler(service *serviceApplication.MaintenanceService) *MaintenancePlanHandler {
+	return &MaintenancePlanHandler{service: service}
+}
+
+// CreateMaintenancePlanRequest puts a service on a plan. Either interval may
+// be zero, but not both.
+type CreateMaintenancePlanRequest struct {
+	ServiceID      string `json:"service_id"`
+	IntervalKm     int    `json:"interval_km"`
+	IntervalMonths int    `json:"interval_months"`
+}
+
+// @Summary Create Maintenance Plan
+// @Description Make a service due again every so many km or months after it is done on a vehicle, whichever comes first. Owners are emailed when it is due.
+// @Tags maintenance
+// @Accept json
+// @Produce json
+// @Param plan body CreateMaintenancePlanRequest true "Plan Details"
+// @Success 201 {object} domain.MaintenancePlan
+// @Failure 400 {object} string "Invalid input or service not found"
+// @Failure 409 {object} string "Service already has a plan"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/maintenance-plans [post]
+func (h *MaintenancePlanHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req CreateMaintenancePlanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	serviceID, err := uuid.Parse(req.ServiceID)
+	if err != nil {
+		http.Error(w, "Invalid service ID", http.StatusBadRequest)
+		return
+	}
+
+	plan, err := h.service.CreatePlan(r.Context(), serviceID, req.IntervalKm, req.IntervalMonths)
+	if err != nil {
+		switch {
+		case errors.Is(err, serviceDomain.ErrInvalidMaintenancePlan):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, serviceDomain.ErrServiceNotFound):
+			http.Error(w, "Service not found", http.StatusBadRequest)
+		case errors.Is(err, serviceDomain.ErrMaintenancePlanExists):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to save maintenance plan", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(plan); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary List Maintenance Plans
+// @Description List the maintenance plans
+// @Tags maintenance
+// @Produce json
+// @Success 200 {array} domain.MaintenancePlan
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/maintenance-plans [get]
+func (h *MaintenancePlanHandler) List(w http.ResponseWriter, r *http.Request) {
+	plans, err := h.service.ListPlans(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to list maintenance plans", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(plans); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Delete Maintenance Plan
+// @Description Take a service off its maintenance plan. No more reminders are sent for it.
+// @Tags maintenance
+// @Param id path string true "Plan ID"
+// @Success 204 {object} nil
+// @Failure 400 {object} string "Invalid ID"
+// @Failure 404 {object} string "Maintenance plan not found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/maintenance-plans/{id} [delete]
+func (h *MaintenancePlanHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeletePlan(r.Context(), id); err != nil {
+		if errors.Is(err, serviceDomain.ErrMaintenancePlanNotFound) {
+			http.Error(w, "Maintenance plan not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to delete maintenance plan", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -2,30 +2,68 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	identityDomain "github.com/noggrj/autorepair/internal/identity/domain"
+	inventoryApplication "github.com/noggrj/autorepair/internal/inventory/application"
 	inventoryDomain "github.com/noggrj/autorepair/internal/inventory/domain"
+	"github.com/noggrj/autorepair/internal/platform/auth"
+	authMiddleware "github.com/noggrj/autorepair/internal/platform/middleware"
 	serviceApplication "github.com/noggrj/autorepair/internal/service/application"
 	serviceDomain "github.com/noggrj/autorepair/internal/service/domain"
 	"github.com/noggrj/autorepair/internal/sharedkernel"
 )
 
 type PartHandler struct {
-	repo inventoryDomain.PartRepository
+	repo    inventoryDomain.PartRepository
+	service *inventoryApplication.PartService
 }
 
-func NewPartHandler(repo inventoryDomain.PartRepository) *PartHandler {
-	return &PartHandler{repo: repo}
+func NewPartHandler(repo inventoryDomain.PartRepository, service *inventoryApplication.PartService) *PartHandler {
+	return &PartHandler{repo: repo, service: service}
 }
 
 type CreatePartRequest struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
-	StockQty    int     `json:"stock_qty"`
+	SKU              string             `json:"sku"`
+	ManufacturerCode string             `json:"manufacturer_code"`
+	Name             string             `json:"name"`
+	Description      string             `json:"description"`
+	CostPrice        sharedkernel.Money `json:"cost_price" swaggertype:"number"`
+	SalePrice        sharedkernel.Money `json:"sale_price" swaggertype:"number"`
+	StockQty         int                `json:"stock_qty"`
+	MinQuantity      *int               `json:"min_quantity,omitempty"`
+	ReorderQuantity  *int               `json:"reorder_quantity,omitempty"`
+}
+
+// UpdatePartRequest changes part details. Stock is not part of it: quantity
+// changes go through POST /admin/parts/{id}/stock.
+type UpdatePartRequest struct {
+	SKU              string             `json:"sku"`
+	ManufacturerCode string             `json:"manufacturer_code"`
+	Name             string             `json:"name"`
+	Description      string             `json:"description"`
+	CostPrice        sharedkernel.Money `json:"cost_price" swaggertype:"number"`
+	SalePrice        sharedkernel.Money `json:"sale_price" swaggertype:"number"`
+	MinQuantity      *int               `json:"min_quantity,omitempty"`
+	ReorderQuantity  *int               `json:"reorder_quantity,omitempty"`
+}
+
+// applyThresholds sets the low-stock thresholds present in a request,
+// keeping the current value of any that was omitted.
+func applyThresholds(part *inventoryDomain.Part, minQuantity, reorderQuantity *int) error {
+	minQty, reorderQty := part.MinQuantity, part.ReorderQuantity
+	if minQuantity != nil {
+		minQty = *minQuantity
+	}
+	if reorderQuantity != nil {
+		reorderQty = *reorderQuantity
+	}
+	return part.SetThresholds(minQty, reorderQty)
 }
 
 // @Summary Create Part
@@ -45,13 +83,22 @@ func (h *PartHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	part, err := inventoryDomain.NewPart(req.Name, req.Description, req.StockQty, req.Price)
+	part, err := inventoryDomain.NewPart(req.SKU, req.Name, req.Description, req.StockQty, req.CostPrice, req.SalePrice)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	part.ManufacturerCode = req.ManufacturerCode
+	if err := applyThresholds(part, req.MinQuantity, req.ReorderQuantity); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	if err := h.repo.Save(r.Context(), part); err != nil {
+		if errors.Is(err, inventoryDomain.ErrDuplicateSKU) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, "Failed to save part", http.StatusInternalServerError)
 		return
 	}
@@ -64,24 +111,46 @@ func (h *PartHandler) Create(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary List Parts
-// @Description List all parts
+// @Description List parts a page at a time, or the part with a given SKU. The next page is linked in the Link header.
 // @Tags parts
 // @Accept json
 // @Produce json
+// @Param sku query string false "Exact SKU (case-insensitive)"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param cursor query string false "Cursor from X-Next-Cursor"
+// @Param sort query string false "name, sku, stock_qty or sale_price, prefix with - for descending"
+// @Param q query string false "Search SKU, manufacturer code, name or description"
 // @Success 200 {array} map[string]interface{}
+// @Header 200 {string} Link "Next page"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page"
+// @Failure 400 {object} string "Invalid query"
 // @Failure 500 {object} string "Internal Server Error"
 // @Router /admin/parts [get]
 func (h *PartHandler) List(w http.ResponseWriter, r *http.Request) {
-	parts, err := h.repo.List(r.Context())
+	if sku := r.URL.Query().Get("sku"); sku != "" {
+		part, err := h.repo.GetBySKU(r.Context(), sku)
+		switch {
+		case err == nil:
+			writePage(w, r, []*inventoryDomain.Part{part}, "")
+		case errors.Is(err, inventoryDomain.ErrPartNotFound):
+			writePage(w, r, []*inventoryDomain.Part{}, "")
+		default:
+			http.Error(w, "Failed to list parts", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	q, err := parseListQuery(r)
 	if err != nil {
-		http.Error(w, "Failed to list parts", http.StatusInternalServerError)
+		writeListError(w, err, "Failed to list parts")
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(parts); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	page, err := h.repo.List(r.Context(), q)
+	if err != nil {
+		writeListError(w, err, "Failed to list parts")
 		return
 	}
+	writePage(w, r, page.Items, page.NextCursor)
 }
 
 // @Summary Update Part
@@ -90,7 +159,7 @@ func (h *PartHandler) List(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param id path string true "Part ID"
-// @Param part body CreatePartRequest true "Part Details"
+// @Param part body UpdatePartRequest true "Part Details"
 // @Success 200 {object} domain.Part
 // @Failure 400 {object} string "Invalid input"
 // @Failure 404 {object} string "Part not found"
@@ -104,7 +173,7 @@ func (h *PartHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req CreatePartRequest
+	var req UpdatePartRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid input", http.StatusBadRequest)
 		return
@@ -117,20 +186,34 @@ func (h *PartHandler) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Update fields
+	if sku := inventoryDomain.NormalizeSKU(req.SKU); sku != "" {
+		part.SKU = sku
+	}
+	if req.ManufacturerCode != "" {
+		part.ManufacturerCode = req.ManufacturerCode
+	}
 	if req.Name != "" {
 		part.Name = req.Name
 	}
 	if req.Description != "" {
 		part.Description = req.Description
 	}
-	if req.Price > 0 {
-		part.Price = req.Price
+	if req.CostPrice.Cents() > 0 {
+		part.CostPrice = req.CostPrice
+	}
+	if req.SalePrice.Cents() > 0 {
+		part.SalePrice = req.SalePrice
 	}
-	if req.StockQty >= 0 {
-		part.Quantity = req.StockQty
+	if err := applyThresholds(part, req.MinQuantity, req.ReorderQuantity); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
 	if err := h.repo.Update(r.Context(), part); err != nil {
+		if errors.Is(err, inventoryDomain.ErrDuplicateSKU) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, "Failed to update part", http.StatusInternalServerError)
 		return
 	}
@@ -143,7 +226,7 @@ func (h *PartHandler) Update(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Delete Part
-// @Description Delete a part by ID
+// @Description Archive a part by ID. It leaves listings and can no longer be added to orders, but its stock ledger is kept.
 // @Tags parts
 // @Accept json
 // @Produce json
@@ -151,6 +234,7 @@ func (h *PartHandler) Update(w http.ResponseWriter, r *http.Request) {
 // @Success 204 {object} nil
 // @Failure 400 {object} string "Invalid ID"
 // @Failure 404 {object} string "Part not found"
+// @Failure 409 {object} string "Part is referenced by open orders or purchase orders"
 // @Failure 500 {object} string "Internal Server Error"
 // @Router /admin/parts/{id} [delete]
 func (h *PartHandler) Delete(w http.ResponseWriter, r *http.Request) {
@@ -161,14 +245,237 @@ func (h *PartHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.repo.Delete(r.Context(), id); err != nil {
-		http.Error(w, "Failed to delete part", http.StatusInternalServerError)
+	if err := h.service.Archive(r.Context(), id); err != nil {
+		switch {
+		case errors.Is(err, inventoryDomain.ErrPartNotFound):
+			http.Error(w, "Part not found", http.StatusNotFound)
+		case errors.Is(err, inventoryDomain.ErrPartInUse):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to delete part", http.StatusInternalServerError)
+		}
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// @Summary Get Part
+// @Description Get a part by ID
+// @Tags parts
+// @Accept json
+// @Produce json
+// @Param id path string true "Part ID"
+// @Success 200 {object} domain.Part
+// @Failure 400 {object} string "Invalid ID"
+// @Failure 404 {object} string "Part not found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/parts/{id} [get]
+func (h *PartHandler) Get(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	part, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, inventoryDomain.ErrPartNotFound) {
+			http.Error(w, "Part not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to get part", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(part); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// StockAdjustmentRequest moves stock in (positive quantity) or out (negative
+// quantity). The type must match the direction: purchase and return add
+// stock, order_consumption and loss remove it, adjustment does both.
+type StockAdjustmentRequest struct {
+	Quantity  int    `json:"quantity"`
+	Type      string `json:"type"`
+	Reference string `json:"reference"`
+}
+
+// @Summary Adjust Part Stock
+// @Description Receive or remove stock through the part's ledger
+// @Tags parts
+// @Accept json
+// @Produce json
+// @Param id path string true "Part ID"
+// @Param request body StockAdjustmentRequest true "Stock adjustment"
+// @Success 200 {object} domain.Part
+// @Failure 400 {object} string "Invalid input"
+// @Failure 404 {object} string "Part not found"
+// @Failure 409 {object} string "Insufficient stock"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/parts/{id}/stock [post]
+func (h *PartHandler) AdjustStock(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	var req StockAdjustmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	reference := req.Reference
+	if reference == "" {
+		reference = "manual:" + actorFromRequest(r)
+	}
+
+	part, err := h.service.AdjustStock(r.Context(), id, req.Quantity, inventoryDomain.MovementType(req.Type), reference)
+	if err != nil {
+		switch {
+		case errors.Is(err, inventoryDomain.ErrPartNotFound):
+			http.Error(w, "Part not found", http.StatusNotFound)
+		case errors.Is(err, inventoryDomain.ErrInvalidQuantity), errors.Is(err, inventoryDomain.ErrInvalidMovementType):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, inventoryDomain.ErrInsufficientStock):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to adjust stock", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(part); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary List Low-Stock Parts
+// @Description List parts whose quantity is below their minimum, largest shortfall first
+// @Tags parts
+// @Accept json
+// @Produce json
+// @Success 200 {array} domain.Part
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/parts/low-stock [get]
+func (h *PartHandler) LowStock(w http.ResponseWriter, r *http.Request) {
+	parts, err := h.repo.ListLowStock(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to list low-stock parts", http.StatusInternalServerError)
+		return
+	}
+	if parts == nil {
+		parts = []*inventoryDomain.Part{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(parts); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary List Part Movements
+// @Description List the stock ledger of a part, oldest first
+// @Tags parts
+// @Accept json
+// @Produce json
+// @Param id path string true "Part ID"
+// @Success 200 {array} domain.StockMovement
+// @Failure 400 {object} string "Invalid ID"
+// @Failure 404 {object} string "Part not found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/parts/{id}/movements [get]
+func (h *PartHandler) Movements(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.repo.GetByID(r.Context(), id); err != nil {
+		http.Error(w, "Part not found", http.StatusNotFound)
+		return
+	}
+
+	movements, err := h.repo.ListMovements(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Failed to list part movements", http.StatusInternalServerError)
+		return
+	}
+	if movements == nil {
+		movements = []*inventoryDomain.StockMovement{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(movements); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+type StockDriftResponse struct {
+	PartID         uuid.UUID `json:"part_id"`
+	Name           string    `json:"name"`
+	Quantity       int       `json:"quantity"`
+	LedgerQuantity int       `json:"ledger_quantity"`
+	Drift          int       `json:"drift"`
+}
+
+// @Summary Reconcile Stock
+// @Description Recompute each part's quantity from its ledger and list the parts whose stored quantity drifted
+// @Tags parts
+// @Accept json
+// @Produce json
+// @Success 200 {array} StockDriftResponse
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/parts/reconciliation [get]
+func (h *PartHandler) Reconcile(w http.ResponseWriter, r *http.Request) {
+	page, err := h.repo.List(r.Context(), sharedkernel.ListQuery{})
+	if err != nil {
+		http.Error(w, "Failed to list parts", http.StatusInternalServerError)
+		return
+	}
+	parts := page.Items
+
+	ledger, err := h.repo.LedgerQuantities(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load stock ledger", http.StatusInternalServerError)
+		return
+	}
+
+	drifts := []StockDriftResponse{}
+	for _, part := range parts {
+		rec := inventoryDomain.Reconcile(part, ledger[part.ID])
+		if !rec.HasDrift() {
+			continue
+		}
+		drifts = append(drifts, StockDriftResponse{
+			PartID:         part.ID,
+			Name:           part.Name,
+			Quantity:       rec.Quantity,
+			LedgerQuantity: rec.LedgerQuantity,
+			Drift:          rec.Drift,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(drifts); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
 // ---------------------------------------------------------
 
 type ServiceHandler struct {
@@ -180,9 +487,9 @@ func NewServiceHandler(repo serviceDomain.ServiceRepository) *ServiceHandler {
 }
 
 type CreateServiceRequest struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Price       sharedkernel.Money `json:"price" swaggertype:"number"`
 }
 
 // @Summary Create Service
@@ -208,7 +515,7 @@ func (h *ServiceHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.repo.Save(service); err != nil {
+	if err := h.repo.Save(r.Context(), service); err != nil {
 		http.Error(w, "Failed to save service", http.StatusInternalServerError)
 		return
 	}
@@ -221,24 +528,32 @@ func (h *ServiceHandler) Create(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary List Services
-// @Description List all services
+// @Description List services a page at a time. The next page is linked in the Link header.
 // @Tags services
 // @Accept json
 // @Produce json
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param cursor query string false "Cursor from X-Next-Cursor"
+// @Param sort query string false "name, price or created_at, prefix with - for descending"
+// @Param q query string false "Search name or description"
 // @Success 200 {array} map[string]interface{}
+// @Header 200 {string} Link "Next page"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page"
+// @Failure 400 {object} string "Invalid query"
 // @Failure 500 {object} string "Internal Server Error"
 // @Router /admin/services [get]
 func (h *ServiceHandler) List(w http.ResponseWriter, r *http.Request) {
-	services, err := h.repo.List()
+	q, err := parseListQuery(r)
 	if err != nil {
-		http.Error(w, "Failed to list services", http.StatusInternalServerError)
+		writeListError(w, err, "Failed to list services")
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(services); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	page, err := h.repo.List(r.Context(), q)
+	if err != nil {
+		writeListError(w, err, "Failed to list services")
 		return
 	}
+	writePage(w, r, page.Items, page.NextCursor)
 }
 
 // @Summary Update Service
@@ -267,7 +582,7 @@ func (h *ServiceHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	service, err := h.repo.GetByID(id)
+	service, err := h.repo.GetByID(r.Context(), id)
 	if err != nil {
 		http.Error(w, "Service not found", http.StatusNotFound)
 		return
@@ -280,12 +595,12 @@ func (h *ServiceHandler) Update(w http.ResponseWriter, r *http.Request) {
 	if req.Description != "" {
 		service.Description = req.Description
 	}
-	if req.Price >= 0 {
-		service.Price = sharedkernel.Money(req.Price)
+	if !req.Price.IsNegative() {
+		service.Price = req.Price
 	}
 	service.UpdatedAt = time.Now()
 
-	if err := h.repo.Save(service); err != nil {
+	if err := h.repo.Save(r.Context(), service); err != nil {
 		http.Error(w, "Failed to update service", http.StatusInternalServerError)
 		return
 	}
@@ -316,7 +631,7 @@ func (h *ServiceHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.repo.Delete(id); err != nil {
+	if err := h.repo.Delete(r.Context(), id); err != nil {
 		http.Error(w, "Failed to delete service", http.StatusInternalServerError)
 		return
 	}
@@ -347,6 +662,34 @@ func NewOrderHandler(
 	}
 }
 
+// actorFromRequest identifies who is performing an order command, for the status history.
+func actorFromRequest(r *http.Request) string {
+	if claims, ok := r.Context().Value(authMiddleware.UserContextKey).(*auth.Claims); ok {
+		return claims.UserID.String()
+	}
+	return "system"
+}
+
+// writeOrderCommandError maps errors returned by OrderService commands to HTTP responses.
+func writeOrderCommandError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, serviceDomain.ErrOrderNotFound):
+		http.Error(w, "Order not found", http.StatusNotFound)
+	case errors.Is(err, serviceDomain.ErrOrderItemNotFound):
+		http.Error(w, "Order item not found", http.StatusNotFound)
+	case errors.Is(err, serviceDomain.ErrInvalidOrderStatus), errors.Is(err, serviceDomain.ErrReasonRequired),
+		errors.Is(err, serviceDomain.ErrInvalidMileage):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, serviceDomain.ErrInvalidTransition), errors.Is(err, inventoryDomain.ErrInsufficientStock),
+		errors.Is(err, serviceDomain.ErrMileageDecreased), errors.Is(err, serviceDomain.ErrOrderClosed):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, serviceDomain.ErrInvalidBudgetApproval):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
 // ... (Create method remains unchanged)
 
 // @Summary Approve Order
@@ -357,7 +700,8 @@ func NewOrderHandler(
 // @Param id path string true "Order ID"
 // @Success 200
 // @Failure 400 {object} string "Invalid order ID"
-// @Failure 409 {object} string "Insufficient stock"
+// @Failure 404 {object} string "Order not found"
+// @Failure 409 {object} string "Insufficient stock or invalid status transition"
 // @Failure 500 {object} string "Internal Server Error"
 // @Router /admin/orders/{id}/approve [patch]
 func (h *OrderHandler) Approve(w http.ResponseWriter, r *http.Request) {
@@ -368,12 +712,8 @@ func (h *OrderHandler) Approve(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.orderService.ApproveOrder(id); err != nil {
-		if err == inventoryDomain.ErrInsufficientStock {
-			http.Error(w, err.Error(), http.StatusConflict)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.orderService.ApproveOrder(r.Context(), id, actorFromRequest(r), ""); err != nil {
+		writeOrderCommandError(w, err)
 		return
 	}
 
@@ -388,6 +728,8 @@ func (h *OrderHandler) Approve(w http.ResponseWriter, r *http.Request) {
 // @Param id path string true "Order ID"
 // @Success 200
 // @Failure 400 {object} string "Invalid order ID"
+// @Failure 404 {object} string "Order not found"
+// @Failure 409 {object} string "Invalid status transition"
 // @Failure 500 {object} string "Internal Server Error"
 // @Router /admin/orders/{id}/diagnosis:start [post]
 func (h *OrderHandler) StartDiagnosis(w http.ResponseWriter, r *http.Request) {
@@ -398,8 +740,8 @@ func (h *OrderHandler) StartDiagnosis(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.orderService.StartDiagnosis(id); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.orderService.StartDiagnosis(r.Context(), id, actorFromRequest(r), ""); err != nil {
+		writeOrderCommandError(w, err)
 		return
 	}
 
@@ -414,6 +756,8 @@ func (h *OrderHandler) StartDiagnosis(w http.ResponseWriter, r *http.Request) {
 // @Param id path string true "Order ID"
 // @Success 200
 // @Failure 400 {object} string "Invalid order ID"
+// @Failure 404 {object} string "Order not found"
+// @Failure 409 {object} string "Invalid status transition"
 // @Failure 500 {object} string "Internal Server Error"
 // @Router /admin/orders/{id}/budget:send [post]
 func (h *OrderHandler) SendBudget(w http.ResponseWriter, r *http.Request) {
@@ -424,8 +768,8 @@ func (h *OrderHandler) SendBudget(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.orderService.SendBudget(id); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.orderService.SendBudget(r.Context(), id, actorFromRequest(r), ""); err != nil {
+		writeOrderCommandError(w, err)
 		return
 	}
 
@@ -440,6 +784,8 @@ func (h *OrderHandler) SendBudget(w http.ResponseWriter, r *http.Request) {
 // @Param id path string true "Order ID"
 // @Success 200
 // @Failure 400 {object} string "Invalid order ID"
+// @Failure 404 {object} string "Order not found"
+// @Failure 409 {object} string "Invalid status transition"
 // @Failure 500 {object} string "Internal Server Error"
 // @Router /admin/orders/{id}/finish [post]
 func (h *OrderHandler) FinishOrder(w http.ResponseWriter, r *http.Request) {
@@ -450,22 +796,30 @@ func (h *OrderHandler) FinishOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.orderService.FinishOrder(id); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.orderService.FinishOrder(r.Context(), id, actorFromRequest(r), ""); err != nil {
+		writeOrderCommandError(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 }
 
+// DeliverOrderRequest optionally carries the odometer at delivery.
+type DeliverOrderRequest struct {
+	Mileage *int `json:"mileage"`
+}
+
 // @Summary Deliver Order
-// @Description Mark order as delivered to client
+// @Description Mark order as delivered to client, recording the odometer when a mileage is given
 // @Tags orders
 // @Accept json
 // @Produce json
 // @Param id path string true "Order ID"
+// @Param request body DeliverOrderRequest false "Mileage at delivery"
 // @Success 200
-// @Failure 400 {object} string "Invalid order ID"
+// @Failure 400 {object} string "Invalid order ID or mileage"
+// @Failure 404 {object} string "Order not found"
+// @Failure 409 {object} string "Invalid status transition or mileage below the last reading"
 // @Failure 500 {object} string "Internal Server Error"
 // @Router /admin/orders/{id}/deliver [post]
 func (h *OrderHandler) DeliverOrder(w http.ResponseWriter, r *http.Request) {
@@ -476,27 +830,124 @@ func (h *OrderHandler) DeliverOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.orderService.DeliverOrder(id); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	var req DeliverOrderRequest
+	if r.Body != nil {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := h.orderService.DeliverOrder(r.Context(), id, req.Mileage, actorFromRequest(r), ""); err != nil {
+		writeOrderCommandError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+type CancelOrderRequest struct {
+	Reason string `json:"reason"`
+}
+
+// @Summary Cancel Order
+// @Description Cancel an order before completion. Parts already deducted from inventory are returned and the client is notified.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param request body CancelOrderRequest true "Cancellation reason"
+// @Success 200
+// @Failure 400 {object} string "Invalid input or missing reason"
+// @Failure 404 {object} string "Order not found"
+// @Failure 409 {object} string "Invalid status transition"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/orders/{id}/cancel [post]
+func (h *OrderHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		http.Error(w, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	var req CancelOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.orderService.CancelOrder(r.Context(), id, actorFromRequest(r), req.Reason); err != nil {
+		writeOrderCommandError(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 }
 
+// @Summary Remove Order Item
+// @Description Remove a line from an order that is not yet completed and recompute its totals. A part already deducted from inventory is returned to stock.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param itemID path string true "Order item ID"
+// @Success 200 {object} domain.Order
+// @Failure 400 {object} string "Invalid order or item ID"
+// @Failure 404 {object} string "Order or item not found"
+// @Failure 409 {object} string "Order is closed"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/orders/{id}/items/{itemID} [delete]
+func (h *OrderHandler) RemoveItem(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+	itemID, err := uuid.Parse(chi.URLParam(r, "itemID"))
+	if err != nil {
+		http.Error(w, "Invalid item ID", http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.orderService.RemoveItem(r.Context(), id, itemID)
+	if err != nil {
+		writeOrderCommandError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(order); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
 type UpdateStatusRequest struct {
 	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+// statusPermissions are the permissions, beyond the route's orders:write,
+// needed to move an order to a status through UpdateStatus. They are the
+// ones guarding the dedicated approve and cancel routes.
+var statusPermissions = map[serviceDomain.OrderStatus]identityDomain.Permission{
+	serviceDomain.OrderStatusInExecution: identityDomain.PermOrdersApprove,
+	serviceDomain.OrderStatusCancelled:   identityDomain.PermOrdersCancel,
 }
 
 // @Summary Update Order Status
-// @Description Update status of an order
+// @Description Move an order to a new status. Only transitions allowed by the order state machine are accepted. Approving (In execution) and cancelling also need orders:approve and orders:cancel.
 // @Tags orders
 // @Accept json
 // @Produce json
 // @Param id path string true "Order ID"
 // @Param status body UpdateStatusRequest true "New Status"
 // @Success 200
-// @Failure 400 {object} string "Invalid input"
+// @Failure 400 {object} string "Invalid input or unknown status"
+// @Failure 403 {object} string "Missing permission for the target status"
+// @Failure 404 {object} string "Order not found"
+// @Failure 409 {object} string "Invalid status transition"
 // @Failure 500 {object} string "Internal Server Error"
 // @Router /admin/orders/{id}/status [patch]
 func (h *OrderHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
@@ -513,17 +964,67 @@ func (h *OrderHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	status := serviceDomain.OrderStatus(req.Status)
-	// Ideally validate status enum here
+	status, err := serviceDomain.ParseOrderStatus(req.Status)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	if err := h.orderService.UpdateStatus(id, status); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if permission, ok := statusPermissions[status]; ok {
+		claims, _ := r.Context().Value(authMiddleware.UserContextKey).(*auth.Claims)
+		if claims == nil || !identityDomain.Role(claims.Role).Can(permission) {
+			http.Error(w, "Missing permission "+string(permission), http.StatusForbidden)
+			return
+		}
+	}
+
+	if err := h.orderService.UpdateStatus(r.Context(), id, status, actorFromRequest(r), req.Reason); err != nil {
+		writeOrderCommandError(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 }
 
+// @Summary Order Status History
+// @Description List every status transition of an order (from, to, actor, timestamp, reason), oldest first
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {array} domain.StatusTransition
+// @Failure 400 {object} string "Invalid order ID"
+// @Failure 404 {object} string "Order not found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/orders/{id}/history [get]
+func (h *OrderHandler) History(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		http.Error(w, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	history, err := h.orderService.History(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, serviceDomain.ErrOrderNotFound) {
+			http.Error(w, "Order not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to list order history", http.StatusInternalServerError)
+		return
+	}
+	if history == nil {
+		history = []*serviceDomain.StatusTransition{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(history); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
 type CreateOrderItemRequest struct {
 	Type     string `json:"type"` // "service" or "part"
 	RefID    string `json:"ref_id"`
@@ -533,17 +1034,19 @@ type CreateOrderItemRequest struct {
 type CreateOrderRequest struct {
 	ClientID  string                   `json:"client_id"`
 	VehicleID string                   `json:"vehicle_id"`
+	Mileage   *int                     `json:"mileage"`
 	Items     []CreateOrderItemRequest `json:"items"`
 }
 
 // @Summary Create Order
-// @Description Create a new service order
+// @Description Create a new service order, recording the mileage on the odometer when the vehicle is received
 // @Tags orders
 // @Accept json
 // @Produce json
 // @Param order body CreateOrderRequest true "Order Request"
 // @Success 201 {object} map[string]interface{}
-// @Failure 400 {object} string "Invalid input"
+// @Failure 400 {object} string "Invalid input, missing mileage, or unknown client or vehicle"
+// @Failure 409 {object} string "Mileage below the vehicle's last reading"
 // @Failure 500 {object} string "Internal Server Error"
 // @Router /admin/orders [post]
 func (h *OrderHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -563,8 +1066,12 @@ func (h *OrderHandler) Create(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid vehicle ID", http.StatusBadRequest)
 		return
 	}
+	if req.Mileage == nil {
+		http.Error(w, "mileage is required", http.StatusBadRequest)
+		return
+	}
 
-	order, err := serviceDomain.NewOrder(clientID, vehicleID)
+	order, err := serviceDomain.NewOrder(clientID, vehicleID, *req.Mileage)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -579,12 +1086,12 @@ func (h *OrderHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 		switch serviceDomain.OrderItemType(itemReq.Type) {
 		case serviceDomain.ItemTypeService:
-			svc, err := h.serviceRepo.GetByID(refID)
+			svc, err := h.serviceRepo.GetByID(r.Context(), refID)
 			if err != nil {
 				http.Error(w, "Service not found: "+refID.String(), http.StatusBadRequest)
 				return
 			}
-			err = order.AddItem(refID, serviceDomain.ItemTypeService, svc.Name, itemReq.Quantity, float64(svc.Price))
+			err = order.AddItem(refID, serviceDomain.ItemTypeService, svc.Name, itemReq.Quantity, svc.Price, sharedkernel.Money{})
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
@@ -597,7 +1104,7 @@ func (h *OrderHandler) Create(w http.ResponseWriter, r *http.Request) {
 			}
 			// Note: We check stock here but decrement only on approval (Sprint 3)
 			// Requirements: "Automatically generate estimate/budget"
-			err = order.AddItem(refID, serviceDomain.ItemTypePart, part.Name, itemReq.Quantity, float64(part.Price))
+			err = order.AddItem(refID, serviceDomain.ItemTypePart, part.Name, itemReq.Quantity, part.SalePrice, part.CostPrice)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
@@ -608,8 +1115,17 @@ func (h *OrderHandler) Create(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if err := h.orderRepo.Save(order); err != nil {
-		http.Error(w, "Failed to save order", http.StatusInternalServerError)
+	if err := h.orderService.Receive(r.Context(), order); err != nil {
+		switch {
+		case errors.Is(err, serviceDomain.ErrVehicleNotFound):
+			http.Error(w, "Vehicle not found", http.StatusBadRequest)
+		case errors.Is(err, serviceDomain.ErrClientNotFound):
+			http.Error(w, "Client not found", http.StatusBadRequest)
+		case errors.Is(err, serviceDomain.ErrMileageDecreased):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to save order", http.StatusInternalServerError)
+		}
 		return
 	}
 
@@ -639,7 +1155,7 @@ func (h *OrderHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	order, err := h.orderRepo.GetByID(id)
+	order, err := h.orderRepo.GetByID(r.Context(), id)
 	if err != nil {
 		if err == serviceDomain.ErrOrderNotFound {
 			http.Error(w, "Order not found", http.StatusNotFound)
@@ -665,21 +1181,31 @@ func (h *OrderHandler) Get(w http.ResponseWriter, r *http.Request) {
 // @Failure 500 {object} string "Internal Server Error"
 // @Router /admin/reports/revenue [get]
 func (h *OrderHandler) ReportRevenue(w http.ResponseWriter, r *http.Request) {
-	orders, err := h.orderRepo.List()
+	page, err := h.orderRepo.List(r.Context(), sharedkernel.ListQuery{})
 	if err != nil {
 		http.Error(w, "Failed to list orders", http.StatusInternalServerError)
 		return
 	}
+	orders := page.Items
 
-	var totalRevenue float64
+	var totalRevenue sharedkernel.Money
+	orderCount := 0
 	for _, o := range orders {
-		totalRevenue += float64(o.Total)
+		// Cancelled orders never generate revenue
+		if o.Status == serviceDomain.OrderStatusCancelled {
+			continue
+		}
+		orderCount++
+		if totalRevenue, err = totalRevenue.Add(o.Total); err != nil {
+			http.Error(w, "Failed to compute revenue", http.StatusInternalServerError)
+			return
+		}
 	}
 
 	response := map[string]interface{}{
 		"period":        "all_time",
 		"total_revenue": totalRevenue,
-		"order_count":   len(orders),
+		"order_count":   orderCount,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -689,6 +1215,72 @@ func (h *OrderHandler) ReportRevenue(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+type OrderMarginResponse struct {
+	OrderID     uuid.UUID          `json:"order_id"`
+	Status      string             `json:"status"`
+	Revenue     sharedkernel.Money `json:"revenue" swaggertype:"number"`
+	Cost        sharedkernel.Money `json:"cost" swaggertype:"number"`
+	GrossMargin sharedkernel.Money `json:"gross_margin" swaggertype:"number"`
+}
+
+type MarginReportResponse struct {
+	Orders      []OrderMarginResponse `json:"orders"`
+	Revenue     sharedkernel.Money    `json:"revenue" swaggertype:"number"`
+	Cost        sharedkernel.Money    `json:"cost" swaggertype:"number"`
+	GrossMargin sharedkernel.Money    `json:"gross_margin" swaggertype:"number"`
+}
+
+// @Summary Report Gross Margin
+// @Description Get revenue, cost and gross margin per order, using the costs snapshotted when items were added
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Success 200 {object} MarginReportResponse
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/reports/margin [get]
+func (h *OrderHandler) ReportMargin(w http.ResponseWriter, r *http.Request) {
+	page, err := h.orderRepo.List(r.Context(), sharedkernel.ListQuery{})
+	if err != nil {
+		http.Error(w, "Failed to list orders", http.StatusInternalServerError)
+		return
+	}
+	orders := page.Items
+
+	report := MarginReportResponse{Orders: []OrderMarginResponse{}}
+	for _, o := range orders {
+		if o.Status == serviceDomain.OrderStatusCancelled {
+			continue
+		}
+		margin, err := o.GrossMargin()
+		if err != nil {
+			http.Error(w, "Failed to compute margin", http.StatusInternalServerError)
+			return
+		}
+		report.Orders = append(report.Orders, OrderMarginResponse{
+			OrderID:     o.ID,
+			Status:      string(o.Status),
+			Revenue:     o.Total,
+			Cost:        o.TotalCost,
+			GrossMargin: margin,
+		})
+		if report.Revenue, err = report.Revenue.Add(o.Total); err != nil {
+			http.Error(w, "Failed to compute margin", http.StatusInternalServerError)
+			return
+		}
+		if report.Cost, err = report.Cost.Add(o.TotalCost); err != nil {
+			http.Error(w, "Failed to compute margin", http.StatusInternalServerError)
+			return
+		}
+	}
+	report.GrossMargin, _ = report.Revenue.Sub(report.Cost)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
 // @Summary Report Avg Execution Time
 // @Description Get average execution time of orders (In Execution -> Completed)
 // @Tags reports
@@ -698,11 +1290,12 @@ func (h *OrderHandler) ReportRevenue(w http.ResponseWriter, r *http.Request) {
 // @Failure 500 {object} string "Internal Server Error"
 // @Router /admin/reports/avg-execution-time [get]
 func (h *OrderHandler) ReportAvgExecutionTime(w http.ResponseWriter, r *http.Request) {
-	orders, err := h.orderRepo.List()
+	page, err := h.orderRepo.List(r.Context(), sharedkernel.ListQuery{})
 	if err != nil {
 		http.Error(w, "Failed to list orders", http.StatusInternalServerError)
 		return
 	}
+	orders := page.Items
 
 	var totalDuration time.Duration
 	var count int
@@ -734,15 +1327,15 @@ func (h *OrderHandler) ReportAvgExecutionTime(w http.ResponseWriter, r *http.Req
 }
 
 type OrderTrackingItem struct {
-	Name     string  `json:"name"`
-	Quantity int     `json:"quantity"`
-	Total    float64 `json:"total"`
+	Name     string             `json:"name"`
+	Quantity int                `json:"quantity"`
+	Total    sharedkernel.Money `json:"total" swaggertype:"number"`
 }
 
 type OrderTrackingResponse struct {
 	ID        string              `json:"id"`
 	Status    string              `json:"status"`
-	Total     float64             `json:"total"`
+	Total     sharedkernel.Money  `json:"total" swaggertype:"number"`
 	CreatedAt time.Time           `json:"created_at"`
 	Items     []OrderTrackingItem `json:"items"`
 }
@@ -766,7 +1359,7 @@ func (h *OrderHandler) TrackOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	order, err := h.orderRepo.GetByID(id)
+	order, err := h.orderRepo.GetByID(r.Context(), id)
 	if err != nil {
 		if err == serviceDomain.ErrOrderNotFound {
 			http.Error(w, "Order not found", http.StatusNotFound)
@@ -781,14 +1374,14 @@ func (h *OrderHandler) TrackOrder(w http.ResponseWriter, r *http.Request) {
 		items = append(items, OrderTrackingItem{
 			Name:     item.Name,
 			Quantity: item.Quantity,
-			Total:    float64(item.Total),
+			Total:    item.Total,
 		})
 	}
 
 	response := OrderTrackingResponse{
 		ID:        order.ID.String(),
 		Status:    string(order.Status),
-		Total:     float64(order.Total),
+		Total:     order.Total,
 		CreatedAt: order.CreatedAt,
 		Items:     items,
 	}
@@ -800,41 +1393,87 @@ func (h *OrderHandler) TrackOrder(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// @Summary List Active Orders
-// @Description List active service orders sorted by status priority (In Execution > Awaiting Approval > In Diagnosis > Received), oldest first. Excludes Completed and Delivered orders.
+// @Summary List Orders
+// @Description List service orders a page at a time. By default only active orders are listed, sorted by status priority (In Execution > Awaiting Approval > In Diagnosis > Received), oldest first; status=all lists every order. The next page is linked in the Link header.
 // @Tags orders
 // @Accept json
 // @Produce json
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param cursor query string false "Cursor from X-Next-Cursor"
+// @Param sort query string false "priority, created_at, updated_at or total, prefix with - for descending"
+// @Param status query string false "Statuses, comma-separated, or all"
+// @Param client_id query string false "Client ID"
+// @Param from query string false "Created from (RFC 3339 or YYYY-MM-DD)"
+// @Param to query string false "Created until (RFC 3339 or YYYY-MM-DD)"
 // @Success 200 {array} map[string]interface{}
+// @Header 200 {string} Link "Next page"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page"
+// @Failure 400 {object} string "Invalid query"
 // @Failure 500 {object} string "Internal Server Error"
 // @Router /admin/orders [get]
-func (h *OrderHandler) ListActive(w http.ResponseWriter, r *http.Request) {
-	orders, err := h.orderRepo.ListActive()
+func (h *OrderHandler) List(w http.ResponseWriter, r *http.Request) {
+	q, err := parseListQuery(r)
 	if err != nil {
-		http.Error(w, "Failed to list orders", http.StatusInternalServerError)
+		writeListError(w, err, "Failed to list orders")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(orders); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	switch {
+	case len(q.Status) == 0:
+		q.Status = activeOrderStatuses
+	case len(q.Status) == 1 && q.Status[0] == "all":
+		q.Status = nil
+	default:
+		for _, s := range q.Status {
+			if _, err := serviceDomain.ParseOrderStatus(s); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+	}
+	if q.Sort == "" {
+		q.Sort = "priority"
+	}
+
+	page, err := h.orderRepo.List(r.Context(), q)
+	if err != nil {
+		writeListError(w, err, "Failed to list orders")
 		return
 	}
+	writePage(w, r, page.Items, page.NextCursor)
+}
+
+// activeOrderStatuses are the statuses GET /admin/orders lists by default.
+var activeOrderStatuses = []string{
+	string(serviceDomain.OrderStatusReceived),
+	string(serviceDomain.OrderStatusInDiagnosis),
+	string(serviceDomain.OrderStatusAwaitingApproval),
+	string(serviceDomain.OrderStatusInExecution),
 }
 
 type BudgetResponseRequest struct {
 	Approved bool `json:"approved"`
 }
 
+// BudgetAnswerRequest answers a budget through the public endpoint. Token is
+// the approval code mailed with the budget.
+type BudgetAnswerRequest struct {
+	Token    string `json:"token"`
+	Approved bool   `json:"approved"`
+}
+
 // @Summary Respond to Budget
-// @Description External endpoint for approving or rejecting an order budget (webhook-style)
+// @Description External endpoint for approving or rejecting an order budget with the approval code mailed to the client. Each code answers once, expires after 7 days and stops working when the budget is sent again. The client's IP, user agent and the time of the answer are kept as evidence.
 // @Tags public
 // @Accept json
 // @Produce json
 // @Param id path string true "Order ID"
-// @Param response body BudgetResponseRequest true "Approval/Rejection"
+// @Param response body BudgetAnswerRequest true "Approval/Rejection"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} string "Invalid input"
+// @Failure 403 {object} string "Invalid or expired approval code"
+// @Failure 404 {object} string "Order not found"
+// @Failure 409 {object} string "No budget awaiting approval or insufficient stock"
 // @Failure 500 {object} string "Internal Server Error"
 // @Router /orders/{id}/budget-response [post]
 func (h *OrderHandler) ApproveBudget(w http.ResponseWriter, r *http.Request) {
@@ -845,22 +1484,25 @@ func (h *OrderHandler) ApproveBudget(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req BudgetResponseRequest
+	var req BudgetAnswerRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid input", http.StatusBadRequest)
 		return
 	}
+	if req.Token == "" {
+		http.Error(w, "token is required", http.StatusBadRequest)
+		return
+	}
 
-	if req.Approved {
-		if err := h.orderService.ApproveOrder(id); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-	} else {
-		if err := h.orderService.RejectBudget(id); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
+	decision := serviceDomain.BudgetDecision{
+		Approved:  req.Approved,
+		IP:        authMiddleware.ClientIP(r),
+		UserAgent: r.UserAgent(),
+		DecidedAt: time.Now(),
+	}
+	if err := h.orderService.AnswerBudget(r.Context(), id, req.Token, decision); err != nil {
+		writeOrderCommandError(w, err)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -874,3 +1516,72 @@ func (h *OrderHandler) ApproveBudget(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// BudgetApprovalResponse is an approval link sent for an order and, once
+// the client answered through it, the evidence of the answer.
+type BudgetApprovalResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	Approved  *bool      `json:"approved,omitempty"`
+	DecidedAt *time.Time `json:"decided_at,omitempty"`
+	IP        string     `json:"ip,omitempty"`
+	UserAgent string     `json:"user_agent,omitempty"`
+}
+
+func newBudgetApprovalResponse(a *serviceDomain.BudgetApproval) BudgetApprovalResponse {
+	resp := BudgetApprovalResponse{
+		ID:        a.ID,
+		ExpiresAt: a.ExpiresAt,
+		CreatedAt: a.CreatedAt,
+		RevokedAt: a.RevokedAt,
+	}
+	if d := a.Decision; d != nil {
+		resp.Approved = &d.Approved
+		resp.DecidedAt = &d.DecidedAt
+		resp.IP = d.IP
+		resp.UserAgent = d.UserAgent
+	}
+	return resp
+}
+
+// @Summary Budget Approval Links
+// @Description List the approval links mailed for an order, oldest first, with the client's answer and its evidence (IP, user agent, timestamp)
+// @Tags orders
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {array} BudgetApprovalResponse
+// @Failure 400 {object} string "Invalid order ID"
+// @Failure 404 {object} string "Order not found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/orders/{id}/budget-approvals [get]
+func (h *OrderHandler) BudgetApprovals(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		http.Error(w, "Invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	approvals, err := h.orderService.BudgetApprovals(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, serviceDomain.ErrOrderNotFound) {
+			http.Error(w, "Order not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to list budget approvals", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]BudgetApprovalResponse, 0, len(approvals))
+	for _, a := range approvals {
+		resp = append(resp, newBudgetApprovalResponse(a))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
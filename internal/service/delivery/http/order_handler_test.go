@@ -13,6 +13,7 @@ import (
 	inventoryDomain "github.com/noggrj/autorepair/internal/inventory/domain"
 	serviceApplication "github.com/noggrj/autorepair/internal/service/application"
 	serviceDomain "github.com/noggrj/autorepair/internal/service/domain"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -22,32 +23,59 @@ type MockOrderRepository struct {
 	mock.Mock
 }
 
-func (m *MockOrderRepository) Save(order *serviceDomain.Order) error {
-	args := m.Called(order)
+func (m *MockOrderRepository) Save(ctx context.Context, order *serviceDomain.Order) error {
+	args := m.Called(ctx, order)
 	return args.Error(0)
 }
 
-func (m *MockOrderRepository) GetByID(id uuid.UUID) (*serviceDomain.Order, error) {
-	args := m.Called(id)
+func (m *MockOrderRepository) GetByID(ctx context.Context, id uuid.UUID) (*serviceDomain.Order, error) {
+	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*serviceDomain.Order), args.Error(1)
 }
 
-func (m *MockOrderRepository) List() ([]*serviceDomain.Order, error) {
-	args := m.Called()
+func (m *MockOrderRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*serviceDomain.Order, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*serviceDomain.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*serviceDomain.Order], error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return sharedkernel.Page[*serviceDomain.Order]{}, args.Error(1)
+	}
+	return args.Get(0).(sharedkernel.Page[*serviceDomain.Order]), args.Error(1)
+}
+
+func (m *MockOrderRepository) ListActive(ctx context.Context) ([]*serviceDomain.Order, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
 	return args.Get(0).([]*serviceDomain.Order), args.Error(1)
 }
 
-func (m *MockOrderRepository) ListActive() ([]*serviceDomain.Order, error) {
-	args := m.Called()
+func (m *MockOrderRepository) ListByVehicleID(ctx context.Context, vehicleID uuid.UUID) ([]*serviceDomain.Order, error) {
+	args := m.Called(ctx, vehicleID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*serviceDomain.Order), args.Error(1)
 }
 
+func (m *MockOrderRepository) ListStatusHistory(ctx context.Context, orderID uuid.UUID) ([]*serviceDomain.StatusTransition, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*serviceDomain.StatusTransition), args.Error(1)
+}
+
 type MockPartRepository struct {
 	mock.Mock
 }
@@ -65,9 +93,28 @@ func (m *MockPartRepository) GetByID(ctx context.Context, id uuid.UUID) (*invent
 	return args.Get(0).(*inventoryDomain.Part), args.Error(1)
 }
 
-func (m *MockPartRepository) List(ctx context.Context) ([]*inventoryDomain.Part, error) {
-	args := m.Called(ctx)
-	return args.Get(0).([]*inventoryDomain.Part), args.Error(1)
+func (m *MockPartRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*inventoryDomain.Part, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*inventoryDomain.Part), args.Error(1)
+}
+
+func (m *MockPartRepository) GetBySKU(ctx context.Context, sku string) (*inventoryDomain.Part, error) {
+	args := m.Called(ctx, sku)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*inventoryDomain.Part), args.Error(1)
+}
+
+func (m *MockPartRepository) List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*inventoryDomain.Part], error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return sharedkernel.Page[*inventoryDomain.Part]{}, args.Error(1)
+	}
+	return args.Get(0).(sharedkernel.Page[*inventoryDomain.Part]), args.Error(1)
 }
 
 func (m *MockPartRepository) Update(ctx context.Context, part *inventoryDomain.Part) error {
@@ -75,49 +122,89 @@ func (m *MockPartRepository) Update(ctx context.Context, part *inventoryDomain.P
 	return args.Error(0)
 }
 
-func (m *MockPartRepository) Delete(ctx context.Context, id uuid.UUID) error {
+func (m *MockPartRepository) Archive(ctx context.Context, id uuid.UUID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
+func (m *MockPartRepository) ListLowStock(ctx context.Context) ([]*inventoryDomain.Part, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*inventoryDomain.Part), args.Error(1)
+}
+
+func (m *MockPartRepository) ListMovements(ctx context.Context, partID uuid.UUID) ([]*inventoryDomain.StockMovement, error) {
+	args := m.Called(ctx, partID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*inventoryDomain.StockMovement), args.Error(1)
+}
+
+func (m *MockPartRepository) LedgerQuantities(ctx context.Context) (map[uuid.UUID]int, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uuid.UUID]int), args.Error(1)
+}
+
 type MockServiceRepository struct {
 	mock.Mock
 }
 
-func (m *MockServiceRepository) Save(service *serviceDomain.Service) error {
-	args := m.Called(service)
+func (m *MockServiceRepository) Save(ctx context.Context, service *serviceDomain.Service) error {
+	args := m.Called(ctx, service)
 	return args.Error(0)
 }
 
-func (m *MockServiceRepository) GetByID(id uuid.UUID) (*serviceDomain.Service, error) {
-	args := m.Called(id)
+func (m *MockServiceRepository) GetByID(ctx context.Context, id uuid.UUID) (*serviceDomain.Service, error) {
+	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*serviceDomain.Service), args.Error(1)
 }
 
-func (m *MockServiceRepository) List() ([]*serviceDomain.Service, error) {
-	args := m.Called()
-	return args.Get(0).([]*serviceDomain.Service), args.Error(1)
+func (m *MockServiceRepository) List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*serviceDomain.Service], error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return sharedkernel.Page[*serviceDomain.Service]{}, args.Error(1)
+	}
+	return args.Get(0).(sharedkernel.Page[*serviceDomain.Service]), args.Error(1)
 }
 
-func (m *MockServiceRepository) Delete(id uuid.UUID) error {
-	args := m.Called(id)
+func (m *MockServiceRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
+// fakeUnitOfWork hands the mocks to the callback without a real transaction.
+type fakeUnitOfWork struct {
+	repos serviceApplication.TxRepositories
+}
+
+func newFakeUnitOfWork(orders serviceDomain.OrderRepository, parts inventoryDomain.PartRepository) *fakeUnitOfWork {
+	return &fakeUnitOfWork{repos: serviceApplication.TxRepositories{Orders: orders, Parts: parts}}
+}
+
+func (u *fakeUnitOfWork) Do(ctx context.Context, fn func(repos serviceApplication.TxRepositories) error) error {
+	return fn(u.repos)
+}
+
 type MockClientRepository struct {
 	mock.Mock
 }
 
-func (m *MockClientRepository) Save(client *serviceDomain.Client) error {
-	args := m.Called(client)
+func (m *MockClientRepository) Save(ctx context.Context, client *serviceDomain.Client) error {
+	args := m.Called(ctx, client)
 	return args.Error(0)
 }
 
-func (m *MockClientRepository) GetByID(id uuid.UUID) (*serviceDomain.Client, error) {
-	args := m.Called(id)
+func (m *MockClientRepository) GetByID(ctx context.Context, id uuid.UUID) (*serviceDomain.Client, error) {
+	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -132,13 +219,16 @@ func (m *MockClientRepository) GetByCPF(cpf string) (*serviceDomain.Client, erro
 	return args.Get(0).(*serviceDomain.Client), args.Error(1)
 }
 
-func (m *MockClientRepository) List() ([]*serviceDomain.Client, error) {
-	args := m.Called()
-	return args.Get(0).([]*serviceDomain.Client), args.Error(1)
+func (m *MockClientRepository) List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*serviceDomain.Client], error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return sharedkernel.Page[*serviceDomain.Client]{}, args.Error(1)
+	}
+	return args.Get(0).(sharedkernel.Page[*serviceDomain.Client]), args.Error(1)
 }
 
-func (m *MockClientRepository) Delete(id uuid.UUID) error {
-	args := m.Called(id)
+func (m *MockClientRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
@@ -158,7 +248,7 @@ func TestOrderHandler_StartDiagnosis(t *testing.T) {
 	mockClientRepo := new(MockClientRepository)
 	mockNotifier := new(MockEmailService)
 
-	orderService := serviceApplication.NewOrderService(mockOrderRepo, mockPartRepo, mockClientRepo, mockNotifier)
+	orderService := serviceApplication.NewOrderService(mockOrderRepo, mockPartRepo, mockClientRepo, mockNotifier, newFakeUnitOfWork(mockOrderRepo, mockPartRepo), nil, nil)
 	handler := NewOrderHandler(mockOrderRepo, mockPartRepo, mockServiceRepo, orderService)
 
 	t.Run("Success", func(t *testing.T) {
@@ -167,9 +257,9 @@ func TestOrderHandler_StartDiagnosis(t *testing.T) {
 		order := &serviceDomain.Order{ID: orderID, ClientID: clientID, Status: serviceDomain.OrderStatusReceived}
 		client := &serviceDomain.Client{ID: clientID, Email: "test@example.com"}
 
-		mockOrderRepo.On("GetByID", orderID).Return(order, nil)
-		mockOrderRepo.On("Save", mock.Anything).Return(nil)
-		mockClientRepo.On("GetByID", clientID).Return(client, nil)
+		mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+		mockOrderRepo.On("Save", mock.Anything, mock.Anything).Return(nil)
+		mockClientRepo.On("GetByID", mock.Anything, clientID).Return(client, nil)
 		mockNotifier.On("SendEmail", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
 		r := httptest.NewRequest(http.MethodPost, "/admin/orders/{id}/diagnosis:start", nil)
@@ -186,13 +276,15 @@ func TestOrderHandler_StartDiagnosis(t *testing.T) {
 
 func TestPartHandler_Create(t *testing.T) {
 	mockRepo := new(MockPartRepository)
-	handler := NewPartHandler(mockRepo)
+	handler := NewPartHandler(mockRepo, nil)
 
 	t.Run("Success", func(t *testing.T) {
 		reqBody := CreatePartRequest{
+			SKU:         "TP-001",
 			Name:        "Test Part",
 			Description: "Test Description",
-			Price:       100.0,
+			CostPrice:   sharedkernel.NewMoneyFromFloat(60.0),
+			SalePrice:   sharedkernel.NewMoneyFromFloat(100.0),
 			StockQty:    10,
 		}
 		body, _ := json.Marshal(reqBody)
@@ -215,13 +307,13 @@ func TestServiceHandler_Create(t *testing.T) {
 		reqBody := CreateServiceRequest{
 			Name:        "Test Service",
 			Description: "Test Description",
-			Price:       50.0,
+			Price:       sharedkernel.NewMoneyFromFloat(50.0),
 		}
 		body, _ := json.Marshal(reqBody)
 		req := httptest.NewRequest(http.MethodPost, "/admin/services", bytes.NewBuffer(body))
 		w := httptest.NewRecorder()
 
-		mockRepo.On("Save", mock.AnythingOfType("*domain.Service")).Return(nil)
+		mockRepo.On("Save", mock.Anything, mock.AnythingOfType("*domain.Service")).Return(nil)
 
 		handler.Create(w, req)
 
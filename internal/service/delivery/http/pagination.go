@@ -0,0 +1,132 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+var errInvalidListQuery = errors.New("invalid list query")
+
+// parseListQuery reads the listing parameters shared by the admin GET
+// routes:
+//
+//	limit      page size (default 50, at most 200)
+//	cursor     value of X-Next-Cursor from the previous page
+//	sort       field to order by, prefixed with "-" for descending
+//	q          free-text search
+//	status     one or more statuses, repeated or comma-separated
+//	client_id  owning client
+//	from, to   creation date range, RFC 3339 or YYYY-MM-DD (a bare "to" date is inclusive)
+func parseListQuery(r *http.Request) (sharedkernel.ListQuery, error) {
+	values := r.URL.Query()
+	q := sharedkernel.ListQuery{
+		Limit:  defaultPageSize,
+		Cursor: values.Get("cursor"),
+		Search: strings.TrimSpace(values.Get("q")),
+	}
+
+	if s := values.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			return q, errInvalidListQuery
+		}
+		q.Limit = limit
+	}
+
+	if s := values.Get("sort"); s != "" {
+		q.Sort = strings.TrimPrefix(s, "-")
+		q.Desc = strings.HasPrefix(s, "-")
+	}
+
+	for _, s := range values["status"] {
+		for _, status := range strings.Split(s, ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				q.Status = append(q.Status, status)
+			}
+		}
+	}
+
+	if s := values.Get("client_id"); s != "" {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			return q, errInvalidListQuery
+		}
+		q.ClientID = &id
+	}
+
+	var err error
+	if q.From, err = parseDateParam(values.Get("from"), false); err != nil {
+		return q, errInvalidListQuery
+	}
+	if q.To, err = parseDateParam(values.Get("to"), true); err != nil {
+		return q, errInvalidListQuery
+	}
+	return q, nil
+}
+
+// parseDateParam accepts a timestamp or a calendar date. As an upper bound,
+// a date stands for the end of that day.
+func parseDateParam(s string, upper bool) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return nil, err
+	}
+	if upper {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+// writeListError answers a failed listing: 400 for a bad query, cursor or
+// sort field, 500 with fallback otherwise.
+func writeListError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, errInvalidListQuery),
+		errors.Is(err, sharedkernel.ErrInvalidCursor),
+		errors.Is(err, sharedkernel.ErrInvalidSort):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+// writePage encodes items as a JSON array. When there is another page its
+// cursor is sent in X-Next-Cursor and as a rel="next" Link to the same
+// request with the cursor replaced.
+func writePage[T any](w http.ResponseWriter, r *http.Request, items []T, nextCursor string) {
+	if items == nil {
+		items = []T{}
+	}
+	if nextCursor != "" {
+		next := *r.URL
+		values := next.Query()
+		values.Set("cursor", nextCursor)
+		next.RawQuery = values.Encode()
+		w.Header().Set("Link", `<`+next.RequestURI()+`>; rel="next"`)
+		w.Header().Set("X-Next-Cursor", nextCursor)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(items); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,466 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	identityApplication "github.com/noggrj/autorepair/internal/identity/application"
+	identityDomain "github.com/noggrj/autorepair/internal/identity/domain"
+	"github.com/noggrj/autorepair/internal/platform/auth"
+	authMiddleware "github.com/noggrj/autorepair/internal/platform/middleware"
+	serviceApplication "github.com/noggrj/autorepair/internal/service/application"
+	serviceDomain "github.com/noggrj/autorepair/internal/service/domain"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
+)
+
+// PortalHandler serves the customer portal, where a client's portal account
+// sees its own vehicles, orders, budgets and invoices, and staff open those
+// accounts. Every portal route runs behind RequireCustomer and reads the
+// client from the account, never from the request, so a customer cannot
+// reach another client's data.
+type PortalHandler struct {
+	users        identityDomain.UserRepository
+	userService  *identityApplication.UserService
+	accounts     *identityApplication.AccountService
+	clientRepo   serviceDomain.ClientRepository
+	vehicleRepo  serviceDomain.VehicleRepository
+	orderRepo    serviceDomain.OrderRepository
+	orderService *serviceApplication.OrderService
+}
+
+func NewPortalHandler(
+	users identityDomain.UserRepository,
+	userService *identityApplication.UserService,
+	accounts *identityApplication.AccountService,
+	clientRepo serviceDomain.ClientRepository,
+	vehicleRepo serviceDomain.VehicleRepository,
+	orderRepo serviceDomain.OrderRepository,
+	orderService *serviceApplication.OrderService,
+) *PortalHandler {
+	return &PortalHandler{
+		users:        users,
+		userService:  userService,
+		accounts:     accounts,
+		clientRepo:   clientRepo,
+		vehicleRepo:  vehicleRepo,
+		orderRepo:    orderRepo,
+		orderService: orderService,
+	}
+}
+
+type portalClientKey struct{}
+
+// budgetStatuses are the statuses of an order whose budget waits for the client.
+var budgetStatuses = []string{string(serviceDomain.OrderStatusAwaitingApproval)}
+
+// invoiceStatuses are the statuses of a finished order. There is no separate
+// billing record yet: the invoice of an order is the order once finished.
+var invoiceStatuses = []string{
+	string(serviceDomain.OrderStatusCompleted),
+	string(serviceDomain.OrderStatusDelivered),
+}
+
+// RequireCustomer lets through active customer accounts and records the
+// client they act for. The account is loaded on every request, so
+// deactivating it takes effect at once. It must run after
+// authMiddleware.AuthMiddleware.
+func (h *PortalHandler) RequireCustomer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := r.Context().Value(authMiddleware.UserContextKey).(*auth.Claims)
+		if !ok {
+			http.Error(w, "User not authenticated", http.StatusUnauthorized)
+			return
+		}
+
+		user, err := h.users.GetByID(r.Context(), claims.UserID)
+		if err != nil {
+			if errors.Is(err, identityDomain.ErrUserNotFound) {
+				http.Error(w, "User not authenticated", http.StatusUnauthorized)
+				return
+			}
+			http.Error(w, "Failed to load user", http.StatusInternalServerError)
+			return
+		}
+		if !user.IsCustomer() {
+			http.Error(w, "The portal is for customer accounts", http.StatusForbidden)
+			return
+		}
+		if !user.IsActive() {
+			http.Error(w, identityDomain.ErrUserDeactivated.Error(), http.StatusForbidden)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), portalClientKey{}, *user.ClientID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// portalClientID is the client of the customer making the request.
+func portalClientID(r *http.Request) uuid.UUID {
+	id, _ := r.Context().Value(portalClientKey{}).(uuid.UUID)
+	return id
+}
+
+type PortalInviteRequest struct {
+	// Email defaults to the client's email.
+	Email string `json:"email,omitempty"`
+}
+
+// @Summary Open Customer Portal Account
+// @Description Open the portal account of a client and mail them a sign-in code. Each client has at most one account.
+// @Tags clients
+// @Accept json
+// @Produce json
+// @Param id path string true "Client ID"
+// @Param request body PortalInviteRequest false "Address to send the account to"
+// @Success 201 {object} identityDomain.User
+// @Failure 400 {object} string "Invalid input"
+// @Failure 404 {object} string "Client not found"
+// @Failure 409 {object} string "Client already has an account or email taken"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/clients/{id}/portal-account [post]
+func (h *PortalHandler) Invite(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	var req PortalInviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	client, err := h.clientRepo.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, serviceDomain.ErrClientNotFound) {
+			http.Error(w, "Client not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to load client", http.StatusInternalServerError)
+		return
+	}
+	email := req.Email
+	if email == "" {
+		email = client.Email
+	}
+
+	user, err := h.userService.CreateCustomer(r.Context(), client.ID, client.Name, email)
+	if err != nil {
+		writeUserError(w, err, "Failed to open portal account")
+		return
+	}
+
+	if err := h.accounts.SendMagicLink(r.Context(), user.Email); err != nil {
+		// The account exists; the customer can ask for another code.
+		_ = err // ignore error
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(user); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Portal: My Details
+// @Description The client record of the signed-in customer
+// @Tags portal
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} string "Not authenticated"
+// @Failure 403 {object} string "Not a customer account"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /portal/me [get]
+func (h *PortalHandler) Me(w http.ResponseWriter, r *http.Request) {
+	client, err := h.clientRepo.GetByID(r.Context(), portalClientID(r))
+	if err != nil {
+		http.Error(w, "Failed to load client", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(client); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Portal: My Vehicles
+// @Description The vehicles of the signed-in customer
+// @Tags portal
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} map[string]interface{}
+// @Failure 401 {object} string "Not authenticated"
+// @Failure 403 {object} string "Not a customer account"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /portal/vehicles [get]
+func (h *PortalHandler) Vehicles(w http.ResponseWriter, r *http.Request) {
+	vehicles, err := h.vehicleRepo.ListByClientID(r.Context(), portalClientID(r))
+	if err != nil {
+		http.Error(w, "Failed to list vehicles", http.StatusInternalServerError)
+		return
+	}
+	if vehicles == nil {
+		vehicles = []*serviceDomain.Vehicle{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(vehicles); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+type PortalOrderItem struct {
+	Type      string             `json:"type"`
+	Name      string             `json:"name"`
+	Quantity  int                `json:"quantity"`
+	UnitPrice sharedkernel.Money `json:"unit_price" swaggertype:"number"`
+	Total     sharedkernel.Money `json:"total" swaggertype:"number"`
+}
+
+// PortalOrder is an order as its client sees it: prices, but none of the
+// shop's costs.
+type PortalOrder struct {
+	ID           string             `json:"id"`
+	VehicleID    string             `json:"vehicle_id"`
+	Status       string             `json:"status"`
+	Items        []PortalOrderItem  `json:"items"`
+	TotalService sharedkernel.Money `json:"total_service" swaggertype:"number"`
+	TotalParts   sharedkernel.Money `json:"total_parts" swaggertype:"number"`
+	Total        sharedkernel.Money `json:"total" swaggertype:"number"`
+	CreatedAt    time.Time          `json:"created_at"`
+	StartedAt    *time.Time         `json:"started_at,omitempty"`
+	FinishedAt   *time.Time         `json:"finished_at,omitempty"`
+}
+
+func newPortalOrder(order *serviceDomain.Order) PortalOrder {
+	items := make([]PortalOrderItem, 0, len(order.Items))
+	for _, item := range order.Items {
+		items = append(items, PortalOrderItem{
+			Type:      string(item.Type),
+			Name:      item.Name,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+			Total:     item.Total,
+		})
+	}
+	return PortalOrder{
+		ID:           order.ID.String(),
+		VehicleID:    order.VehicleID.String(),
+		Status:       string(order.Status),
+		Items:        items,
+		TotalService: order.TotalService,
+		TotalParts:   order.TotalParts,
+		Total:        order.Total,
+		CreatedAt:    order.CreatedAt,
+		StartedAt:    order.StartedAt,
+		FinishedAt:   order.FinishedAt,
+	}
+}
+
+// @Summary Portal: My Orders
+// @Description The orders of the signed-in customer, newest first, a page at a time
+// @Tags portal
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param cursor query string false "Cursor from X-Next-Cursor"
+// @Param status query string false "Statuses, comma-separated"
+// @Success 200 {array} PortalOrder
+// @Header 200 {string} Link "Next page"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page"
+// @Failure 400 {object} string "Invalid query"
+// @Failure 401 {object} string "Not authenticated"
+// @Failure 403 {object} string "Not a customer account"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /portal/orders [get]
+func (h *PortalHandler) Orders(w http.ResponseWriter, r *http.Request) {
+	h.listOrders(w, r, nil)
+}
+
+// @Summary Portal: My Budgets
+// @Description The orders of the signed-in customer whose budget waits for their answer
+// @Tags portal
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param cursor query string false "Cursor from X-Next-Cursor"
+// @Success 200 {array} PortalOrder
+// @Failure 400 {object} string "Invalid query"
+// @Failure 401 {object} string "Not authenticated"
+// @Failure 403 {object} string "Not a customer account"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /portal/budgets [get]
+func (h *PortalHandler) Budgets(w http.ResponseWriter, r *http.Request) {
+	h.listOrders(w, r, budgetStatuses)
+}
+
+// @Summary Portal: My Invoices
+// @Description The finished (completed or delivered) orders of the signed-in customer, with what they were charged
+// @Tags portal
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param cursor query string false "Cursor from X-Next-Cursor"
+// @Success 200 {array} PortalOrder
+// @Failure 400 {object} string "Invalid query"
+// @Failure 401 {object} string "Not authenticated"
+// @Failure 403 {object} string "Not a customer account"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /portal/invoices [get]
+func (h *PortalHandler) Invoices(w http.ResponseWriter, r *http.Request) {
+	h.listOrders(w, r, invoiceStatuses)
+}
+
+// listOrders pages through the customer's orders. statuses, when set,
+// replaces any status filter in the request.
+func (h *PortalHandler) listOrders(w http.ResponseWriter, r *http.Request, statuses []string) {
+	q, err := parseListQuery(r)
+	if err != nil {
+		writeListError(w, err, "Failed to list orders")
+		return
+	}
+
+	clientID := portalClientID(r)
+	q.ClientID = &clientID
+	if statuses != nil {
+		q.Status = statuses
+	}
+	for _, s := range q.Status {
+		if _, err := serviceDomain.ParseOrderStatus(s); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if q.Sort == "" {
+		q.Sort = "created_at"
+		q.Desc = true
+	}
+
+	page, err := h.orderRepo.List(r.Context(), q)
+	if err != nil {
+		writeListError(w, err, "Failed to list orders")
+		return
+	}
+	orders := make([]PortalOrder, 0, len(page.Items))
+	for _, order := range page.Items {
+		orders = append(orders, newPortalOrder(order))
+	}
+	writePage(w, r, orders, page.NextCursor)
+}
+
+// @Summary Portal: Order
+// @Description One order of the signed-in customer
+// @Tags portal
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Order ID"
+// @Success 200 {object} PortalOrder
+// @Failure 400 {object} string "Invalid order ID"
+// @Failure 401 {object} string "Not authenticated"
+// @Failure 403 {object} string "Not a customer account"
+// @Failure 404 {object} string "Order not found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /portal/orders/{id} [get]
+func (h *PortalHandler) Order(w http.ResponseWriter, r *http.Request) {
+	order, ok := h.loadOrder(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(newPortalOrder(order)); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Portal: Respond to Budget
+// @Description Approve or reject the budget of one of the signed-in customer's orders
+// @Tags portal
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Order ID"
+// @Param response body BudgetResponseRequest true "Approval/Rejection"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} string "Invalid input"
+// @Failure 401 {object} string "Not authenticated"
+// @Failure 403 {object} string "Not a customer account"
+// @Failure 404 {object} string "Order not found"
+// @Failure 409 {object} string "No budget awaiting approval or insufficient stock"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /portal/budgets/{id}/response [post]
+func (h *PortalHandler) RespondBudget(w http.ResponseWriter, r *http.Request) {
+	var req BudgetResponseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	order, ok := h.loadOrder(w, r)
+	if !ok {
+		return
+	}
+	// The order was read unlocked to check it is the client's; the service
+	// checks the status again under the lock.
+	decision := serviceDomain.BudgetDecision{
+		Approved:  req.Approved,
+		IP:        authMiddleware.ClientIP(r),
+		UserAgent: r.UserAgent(),
+		DecidedAt: time.Now(),
+	}
+	if err := h.orderService.RespondBudget(r.Context(), order.ID, actorFromRequest(r), decision); err != nil {
+		writeOrderCommandError(w, err)
+		return
+	}
+
+	status := "rejected"
+	if req.Approved {
+		status = "approved"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	response := map[string]string{"status": status, "order_id": order.ID.String()}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// loadOrder loads the order named in the path, answering 404 when it belongs
+// to another client, so customers cannot tell it exists.
+func (h *PortalHandler) loadOrder(w http.ResponseWriter, r *http.Request) (*serviceDomain.Order, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid order ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	order, err := h.orderRepo.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, serviceDomain.ErrOrderNotFound) {
+			http.Error(w, "Order not found", http.StatusNotFound)
+			return nil, false
+		}
+		http.Error(w, "Failed to load order", http.StatusInternalServerError)
+		return nil, false
+	}
+	if order.ClientID != portalClientID(r) {
+		http.Error(w, "Order not found", http.StatusNotFound)
+		return nil, false
+	}
+	return order, true
+}
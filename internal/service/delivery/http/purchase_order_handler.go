@@ -0,0 +1,318 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	inventoryApplication "github.com/noggrj/autorepair/internal/inventory/application"
+	inventoryDomain "github.com/noggrj/autorepair/internal/inventory/domain"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
+)
+
+type PurchaseOrderHandler struct {
+	repo    inventoryDomain.PurchaseOrderRepository
+	service *inventoryApplication.PurchaseOrderService
+}
+
+func NewPurchaseOrderHandler(repo inventoryDomain.PurchaseOrderRepository, service *inventoryApplication.PurchaseOrderService) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{repo: repo, service: service}
+}
+
+type PurchaseOrderLineRequest struct {
+	PartID   uuid.UUID          `json:"part_id"`
+	Quantity int                `json:"quantity"`
+	UnitCost sharedkernel.Money `json:"unit_cost" swaggertype:"number"`
+}
+
+type CreatePurchaseOrderRequest struct {
+	SupplierID uuid.UUID                  `json:"supplier_id"`
+	Lines      []PurchaseOrderLineRequest `json:"lines"`
+}
+
+// ReceivePurchaseOrderLineRequest registers a delivery. UnitCost is the
+// invoiced cost; when omitted the line's agreed cost is used.
+type ReceivePurchaseOrderLineRequest struct {
+	Quantity int                 `json:"quantity"`
+	UnitCost *sharedkernel.Money `json:"unit_cost,omitempty" swaggertype:"number"`
+}
+
+type PurchaseOrderLineResponse struct {
+	ID               uuid.UUID          `json:"id"`
+	PartID           uuid.UUID          `json:"part_id"`
+	Quantity         int                `json:"quantity"`
+	ReceivedQuantity int                `json:"received_quantity"`
+	UnitCost         sharedkernel.Money `json:"unit_cost" swaggertype:"number"`
+}
+
+type PurchaseOrderResponse struct {
+	ID         uuid.UUID                   `json:"id"`
+	SupplierID uuid.UUID                   `json:"supplier_id"`
+	Status     string                      `json:"status"`
+	Total      sharedkernel.Money          `json:"total" swaggertype:"number"`
+	Lines      []PurchaseOrderLineResponse `json:"lines"`
+	CreatedAt  time.Time                   `json:"created_at"`
+	SentAt     *time.Time                  `json:"sent_at,omitempty"`
+	ReceivedAt *time.Time                  `json:"received_at,omitempty"`
+}
+
+func toPurchaseOrderResponse(po *inventoryDomain.PurchaseOrder) PurchaseOrderResponse {
+	resp := PurchaseOrderResponse{
+		ID:         po.ID,
+		SupplierID: po.SupplierID,
+		Status:     string(po.Status),
+		Total:      po.Total(),
+		Lines:      make([]PurchaseOrderLineResponse, 0, len(po.Lines)),
+		CreatedAt:  po.CreatedAt,
+		SentAt:     po.SentAt,
+		ReceivedAt: po.ReceivedAt,
+	}
+	for _, l := range po.Lines {
+		resp.Lines = append(resp.Lines, PurchaseOrderLineResponse{
+			ID:               l.ID,
+			PartID:           l.PartID,
+			Quantity:         l.Quantity,
+			ReceivedQuantity: l.ReceivedQuantity,
+			UnitCost:         l.UnitCost,
+		})
+	}
+	return resp
+}
+
+// writePurchaseOrderError maps errors returned by PurchaseOrderService to HTTP responses.
+func writePurchaseOrderError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, inventoryDomain.ErrPurchaseOrderNotFound):
+		http.Error(w, "Purchase order not found", http.StatusNotFound)
+	case errors.Is(err, inventoryDomain.ErrPurchaseOrderLineNotFound):
+		http.Error(w, "Purchase order line not found", http.StatusNotFound)
+	case errors.Is(err, inventoryDomain.ErrSupplierNotFound),
+		errors.Is(err, inventoryDomain.ErrPartNotFound),
+		errors.Is(err, inventoryDomain.ErrInvalidQuantity),
+		errors.Is(err, inventoryDomain.ErrInvalidUnitCost),
+		errors.Is(err, inventoryDomain.ErrDuplicatePurchaseOrderLine),
+		errors.Is(err, inventoryDomain.ErrPurchaseOrderEmpty):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, inventoryDomain.ErrPurchaseOrderNotDraft),
+		errors.Is(err, inventoryDomain.ErrPurchaseOrderNotReceivable),
+		errors.Is(err, inventoryDomain.ErrReceivedQuantityExceeded):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writePurchaseOrder(w http.ResponseWriter, status int, po *inventoryDomain.PurchaseOrder) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(toPurchaseOrderResponse(po)); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Create Purchase Order
+// @Description Draft a purchase order with a supplier
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Param request body CreatePurchaseOrderRequest true "Purchase order"
+// @Success 201 {object} PurchaseOrderResponse
+// @Failure 400 {object} string "Invalid input"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/purchase-orders [post]
+func (h *PurchaseOrderHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req CreatePurchaseOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	lines := make([]inventoryApplication.PurchaseOrderLineInput, 0, len(req.Lines))
+	for _, l := range req.Lines {
+		lines = append(lines, inventoryApplication.PurchaseOrderLineInput{
+			PartID:   l.PartID,
+			Quantity: l.Quantity,
+			UnitCost: l.UnitCost,
+		})
+	}
+
+	po, err := h.service.Create(r.Context(), req.SupplierID, lines)
+	if err != nil {
+		writePurchaseOrderError(w, err)
+		return
+	}
+
+	writePurchaseOrder(w, http.StatusCreated, po)
+}
+
+// @Summary List Purchase Orders
+// @Description List purchase orders a page at a time, newest first. The next page is linked in the Link header.
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param cursor query string false "Cursor from X-Next-Cursor"
+// @Param sort query string false "created_at or updated_at, prefix with - for descending"
+// @Param status query string false "Statuses, comma-separated"
+// @Param from query string false "Created from (RFC 3339 or YYYY-MM-DD)"
+// @Param to query string false "Created until (RFC 3339 or YYYY-MM-DD)"
+// @Success 200 {array} PurchaseOrderResponse
+// @Header 200 {string} Link "Next page"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page"
+// @Failure 400 {object} string "Invalid query"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/purchase-orders [get]
+func (h *PurchaseOrderHandler) List(w http.ResponseWriter, r *http.Request) {
+	q, err := parseListQuery(r)
+	if err != nil {
+		writeListError(w, err, "Failed to list purchase orders")
+		return
+	}
+	page, err := h.repo.List(r.Context(), q)
+	if err != nil {
+		writeListError(w, err, "Failed to list purchase orders")
+		return
+	}
+
+	resp := make([]PurchaseOrderResponse, 0, len(page.Items))
+	for _, po := range page.Items {
+		resp = append(resp, toPurchaseOrderResponse(po))
+	}
+	writePage(w, r, resp, page.NextCursor)
+}
+
+// @Summary Get Purchase Order
+// @Description Get a purchase order by ID
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Purchase Order ID"
+// @Success 200 {object} PurchaseOrderResponse
+// @Failure 400 {object} string "Invalid ID"
+// @Failure 404 {object} string "Purchase order not found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/purchase-orders/{id} [get]
+func (h *PurchaseOrderHandler) Get(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	po, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		writePurchaseOrderError(w, err)
+		return
+	}
+
+	writePurchaseOrder(w, http.StatusOK, po)
+}
+
+// @Summary Send Purchase Order
+// @Description Mark a draft purchase order as sent to the supplier
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Purchase Order ID"
+// @Success 200 {object} PurchaseOrderResponse
+// @Failure 400 {object} string "Purchase order has no lines"
+// @Failure 404 {object} string "Purchase order not found"
+// @Failure 409 {object} string "Purchase order is not in draft"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/purchase-orders/{id}/send [post]
+func (h *PurchaseOrderHandler) Send(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	po, err := h.service.Send(r.Context(), id)
+	if err != nil {
+		writePurchaseOrderError(w, err)
+		return
+	}
+
+	writePurchaseOrder(w, http.StatusOK, po)
+}
+
+// @Summary Receive Purchase Order Line
+// @Description Receive goods for a purchase order line, adding them to the part's stock
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Purchase Order ID"
+// @Param lineID path string true "Line ID"
+// @Param request body ReceivePurchaseOrderLineRequest true "Received quantity and cost"
+// @Success 200 {object} PurchaseOrderResponse
+// @Failure 400 {object} string "Invalid input"
+// @Failure 404 {object} string "Purchase order or line not found"
+// @Failure 409 {object} string "Purchase order not sent or quantity exceeds what was ordered"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/purchase-orders/{id}/lines/{lineID}/receive [post]
+func (h *PurchaseOrderHandler) ReceiveLine(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+	lineID, err := uuid.Parse(chi.URLParam(r, "lineID"))
+	if err != nil {
+		http.Error(w, "Invalid line ID format", http.StatusBadRequest)
+		return
+	}
+
+	var req ReceivePurchaseOrderLineRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	po, err := h.service.ReceiveLine(r.Context(), id, lineID, req.Quantity, req.UnitCost)
+	if err != nil {
+		writePurchaseOrderError(w, err)
+		return
+	}
+
+	writePurchaseOrder(w, http.StatusOK, po)
+}
+
+// @Summary List Purchase Order Receipts
+// @Description List the goods received against a purchase order, oldest first
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Purchase Order ID"
+// @Success 200 {array} domain.PurchaseReceipt
+// @Failure 400 {object} string "Invalid ID"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/purchase-orders/{id}/receipts [get]
+func (h *PurchaseOrderHandler) Receipts(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	receipts, err := h.repo.ListReceipts(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Failed to list receipts", http.StatusInternalServerError)
+		return
+	}
+	if receipts == nil {
+		receipts = []*inventoryDomain.PurchaseReceipt{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(receipts); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,227 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	inventoryDomain "github.com/noggrj/autorepair/internal/inventory/domain"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
+)
+
+type SupplierHandler struct {
+	repo inventoryDomain.SupplierRepository
+}
+
+func NewSupplierHandler(repo inventoryDomain.SupplierRepository) *SupplierHandler {
+	return &SupplierHandler{repo: repo}
+}
+
+type CreateSupplierRequest struct {
+	Name     string `json:"name"`
+	Document string `json:"document"`
+	Email    string `json:"email"`
+	Phone    string `json:"phone"`
+}
+
+// @Summary Create Supplier
+// @Description Register a new parts supplier
+// @Tags suppliers
+// @Accept json
+// @Produce json
+// @Param supplier body CreateSupplierRequest true "Supplier Details"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} string "Invalid input"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/suppliers [post]
+func (h *SupplierHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req CreateSupplierRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	supplier, err := inventoryDomain.NewSupplier(req.Name, req.Document, req.Email, req.Phone)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Save(r.Context(), supplier); err != nil {
+		http.Error(w, "Failed to save supplier", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(supplier); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary List Suppliers
+// @Description List suppliers a page at a time. The next page is linked in the Link header.
+// @Tags suppliers
+// @Accept json
+// @Produce json
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param cursor query string false "Cursor from X-Next-Cursor"
+// @Param sort query string false "name or created_at, prefix with - for descending"
+// @Param q query string false "Search name, email or document"
+// @Success 200 {array} map[string]interface{}
+// @Header 200 {string} Link "Next page"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page"
+// @Failure 400 {object} string "Invalid query"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/suppliers [get]
+func (h *SupplierHandler) List(w http.ResponseWriter, r *http.Request) {
+	q, err := parseListQuery(r)
+	if err != nil {
+		writeListError(w, err, "Failed to list suppliers")
+		return
+	}
+	page, err := h.repo.List(r.Context(), q)
+	if err != nil {
+		writeListError(w, err, "Failed to list suppliers")
+		return
+	}
+	writePage(w, r, page.Items, page.NextCursor)
+}
+
+// @Summary Get Supplier
+// @Description Get a supplier by ID
+// @Tags suppliers
+// @Accept json
+// @Produce json
+// @Param id path string true "Supplier ID"
+// @Success 200 {object} domain.Supplier
+// @Failure 400 {object} string "Invalid ID"
+// @Failure 404 {object} string "Supplier not found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/suppliers/{id} [get]
+func (h *SupplierHandler) Get(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	supplier, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, inventoryDomain.ErrSupplierNotFound) {
+			http.Error(w, "Supplier not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to get supplier", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(supplier); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Update Supplier
+// @Description Update an existing supplier
+// @Tags suppliers
+// @Accept json
+// @Produce json
+// @Param id path string true "Supplier ID"
+// @Param supplier body CreateSupplierRequest true "Supplier Details"
+// @Success 200 {object} domain.Supplier
+// @Failure 400 {object} string "Invalid input"
+// @Failure 404 {object} string "Supplier not found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/suppliers/{id} [put]
+func (h *SupplierHandler) Update(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	var req CreateSupplierRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	supplier, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Supplier not found", http.StatusNotFound)
+		return
+	}
+
+	// Update fields
+	if req.Name != "" {
+		supplier.Name = req.Name
+	}
+	if req.Document != "" {
+		doc, err := sharedkernel.NewDocumentoBR(req.Document)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		supplier.Document = doc
+	}
+	if req.Email != "" {
+		supplier.Email = req.Email
+	}
+	if req.Phone != "" {
+		supplier.Phone = req.Phone
+	}
+	supplier.UpdatedAt = time.Now()
+
+	if err := h.repo.Save(r.Context(), supplier); err != nil {
+		http.Error(w, "Failed to update supplier", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(supplier); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Delete Supplier
+// @Description Delete a supplier that has no purchase orders
+// @Tags suppliers
+// @Accept json
+// @Produce json
+// @Param id path string true "Supplier ID"
+// @Success 204 {object} nil
+// @Failure 400 {object} string "Invalid ID"
+// @Failure 404 {object} string "Supplier not found"
+// @Failure 409 {object} string "Supplier has purchase orders"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/suppliers/{id} [delete]
+func (h *SupplierHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Delete(r.Context(), id); err != nil {
+		switch {
+		case errors.Is(err, inventoryDomain.ErrSupplierNotFound):
+			http.Error(w, "Supplier not found", http.StatusNotFound)
+		case errors.Is(err, inventoryDomain.ErrSupplierInUse):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to delete supplier", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,299 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	identityApplication "github.com/noggrj/autorepair/internal/identity/application"
+	identityDomain "github.com/noggrj/autorepair/internal/identity/domain"
+	"github.com/noggrj/autorepair/internal/platform/auth"
+	authMiddleware "github.com/noggrj/autorepair/internal/platform/middleware"
+)
+
+// UserHandler serves the admin user management routes. New users are
+// created through /auth/register.
+type UserHandler struct {
+	repo    identityDomain.UserRepository
+	service *identityApplication.UserService
+	guard   *identityApplication.LoginGuard
+}
+
+func NewUserHandler(repo identityDomain.UserRepository, service *identityApplication.UserService, guard *identityApplication.LoginGuard) *UserHandler {
+	return &UserHandler{repo: repo, service: service, guard: guard}
+}
+
+type ChangeRoleRequest struct {
+	Role string `json:"role"`
+}
+
+type ResetPasswordRequest struct {
+	Password string `json:"password"`
+}
+
+// @Summary List Users
+// @Description List users a page at a time. The next page is linked in the Link header.
+// @Tags users
+// @Produce json
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param cursor query string false "Cursor from X-Next-Cursor"
+// @Param sort query string false "name, email or created_at, prefix with - for descending"
+// @Param q query string false "Search name or email"
+// @Param status query string false "active or deactivated"
+// @Success 200 {array} identityDomain.User
+// @Header 200 {string} Link "Next page"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page"
+// @Failure 400 {object} string "Invalid query"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/users [get]
+func (h *UserHandler) List(w http.ResponseWriter, r *http.Request) {
+	q, err := parseListQuery(r)
+	if err != nil {
+		writeListError(w, err, "Failed to list users")
+		return
+	}
+	for _, s := range q.Status {
+		if s != identityDomain.UserStatusActive && s != identityDomain.UserStatusDeactivated {
+			http.Error(w, "status must be active or deactivated", http.StatusBadRequest)
+			return
+		}
+	}
+
+	page, err := h.repo.List(r.Context(), q)
+	if err != nil {
+		writeListError(w, err, "Failed to list users")
+		return
+	}
+	writePage(w, r, page.Items, page.NextCursor)
+}
+
+// @Summary Get User
+// @Description Get a user by ID
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} identityDomain.User
+// @Failure 400 {object} string "Invalid ID"
+// @Failure 404 {object} string "User not found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/users/{id} [get]
+func (h *UserHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		writeUserError(w, err, "Failed to get user")
+		return
+	}
+	writeUser(w, user)
+}
+
+// @Summary Deactivate User
+// @Description Switch a user's account off. The user can no longer log in or refresh tokens, and all their sessions are revoked.
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} identityDomain.User
+// @Failure 400 {object} string "Invalid ID"
+// @Failure 404 {object} string "User not found"
+// @Failure 409 {object} string "Own account or customer account"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/users/{id}/deactivate [post]
+func (h *UserHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.service.Deactivate(r.Context(), actorID(r), id)
+	if err != nil {
+		writeUserError(w, err, "Failed to deactivate user")
+		return
+	}
+	writeUser(w, user)
+}
+
+// @Summary Reactivate User
+// @Description Switch a deactivated user's account back on
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} identityDomain.User
+// @Failure 400 {object} string "Invalid ID"
+// @Failure 404 {object} string "User not found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/users/{id}/reactivate [post]
+func (h *UserHandler) Reactivate(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.service.Reactivate(r.Context(), id)
+	if err != nil {
+		writeUserError(w, err, "Failed to reactivate user")
+		return
+	}
+	writeUser(w, user)
+}
+
+// @Summary Change User Role
+// @Description Change a user's role. It applies from the user's next login or token refresh.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body ChangeRoleRequest true "New role: admin, manager or employee"
+// @Success 200 {object} identityDomain.User
+// @Failure 400 {object} string "Invalid input"
+// @Failure 404 {object} string "User not found"
+// @Failure 409 {object} string "Own account or customer account"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/users/{id}/role [put]
+func (h *UserHandler) ChangeRole(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	var req ChangeRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.service.ChangeRole(r.Context(), actorID(r), id, identityDomain.Role(req.Role))
+	if err != nil {
+		writeUserError(w, err, "Failed to change role")
+		return
+	}
+	writeUser(w, user)
+}
+
+// @Summary Reset User Password
+// @Description Set a new password for a user and revoke all their sessions
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body ResetPasswordRequest true "New password"
+// @Success 200 {object} identityDomain.User
+// @Failure 400 {object} string "Invalid input"
+// @Failure 404 {object} string "User not found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/users/{id}/password [post]
+func (h *UserHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	var req ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.service.ResetPassword(r.Context(), id, req.Password)
+	if err != nil {
+		writeUserError(w, err, "Failed to reset password")
+		return
+	}
+	writeUser(w, user)
+}
+
+// @Summary Unlock User Login
+// @Description Lift the lockout put on a user's email after too many failed logins. The unlock is recorded in the audit log.
+// @Tags users
+// @Param id path string true "User ID"
+// @Success 204
+// @Failure 400 {object} string "Invalid ID"
+// @Failure 404 {object} string "User not found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/users/{id}/unlock [post]
+func (h *UserHandler) Unlock(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		writeUserError(w, err, "Failed to unlock user")
+		return
+	}
+	if err := h.guard.Unlock(r.Context(), actorID(r), user.Email, authMiddleware.ClientIP(r)); err != nil {
+		http.Error(w, "Failed to unlock user", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Reset User Two-Factor Authentication
+// @Description Turn two-factor authentication off for a user who lost their authenticator app and recovery codes, and end all their sessions. They enrol again after logging in.
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} identityDomain.User
+// @Failure 400 {object} string "Invalid ID"
+// @Failure 404 {object} string "User not found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/users/{id}/mfa/reset [post]
+func (h *UserHandler) ResetMFA(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.service.ResetMFA(r.Context(), id)
+	if err != nil {
+		writeUserError(w, err, "Failed to reset two-factor authentication")
+		return
+	}
+	writeUser(w, user)
+}
+
+// actorID is the ID of the authenticated user making the request.
+func actorID(r *http.Request) uuid.UUID {
+	if claims, ok := r.Context().Value(authMiddleware.UserContextKey).(*auth.Claims); ok {
+		return claims.UserID
+	}
+	return uuid.Nil
+}
+
+// writeUserError maps errors returned by UserService and the user repository
+// to HTTP responses, answering 500 with fallback for anything else.
+func writeUserError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, identityDomain.ErrUserNotFound):
+		http.Error(w, "User not found", http.StatusNotFound)
+	case errors.Is(err, identityDomain.ErrInvalidRole), errors.Is(err, identityDomain.ErrWeakPassword),
+		errors.Is(err, identityDomain.ErrInvalidName), errors.Is(err, identityDomain.ErrInvalidEmail):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, identityApplication.ErrSelfModification), errors.Is(err, identityDomain.ErrCustomerAccount),
+		errors.Is(err, identityDomain.ErrEmailTaken), errors.Is(err, identityDomain.ErrClientAccountExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+func writeUser(w http.ResponseWriter, user *identityDomain.User) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(user); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
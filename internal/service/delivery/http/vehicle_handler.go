@@ -4,38 +4,69 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
-	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	serviceApplication "github.com/noggrj/autorepair/internal/service/application"
 	"github.com/noggrj/autorepair/internal/service/domain"
 	"github.com/noggrj/autorepair/internal/sharedkernel"
 )
 
 type VehicleHandler struct {
-	repo domain.VehicleRepository
+	repo         domain.VehicleRepository
+	orderRepo    domain.OrderRepository
+	odometerRepo domain.OdometerRepository
+	service      *serviceApplication.VehicleService
 }
 
-func NewVehicleHandler(repo domain.VehicleRepository) *VehicleHandler {
-	return &VehicleHandler{repo: repo}
+func NewVehicleHandler(
+	repo domain.VehicleRepository,
+	orderRepo domain.OrderRepository,
+	odometerRepo domain.OdometerRepository,
+	service *serviceApplication.VehicleService,
+) *VehicleHandler {
+	return &VehicleHandler{
+		repo:         repo,
+		orderRepo:    orderRepo,
+		odometerRepo: odometerRepo,
+		service:      service,
+	}
+}
+
+// VehicleHistoryResponse is a vehicle and everything done to it.
+type VehicleHistoryResponse struct {
+	Vehicle *domain.Vehicle `json:"vehicle"`
+	// Orders are oldest first. Each keeps the client that owned the
+	// vehicle when it was opened.
+	Orders []*domain.Order `json:"orders"`
+	// Readings are the odometer readings taken at reception and delivery,
+	// oldest first.
+	Readings []*domain.OdometerReading `json:"readings"`
+	// PastOwners are the clients that owned the vehicle before its current
+	// owner, oldest first.
+	PastOwners []*domain.VehicleOwnership `json:"past_owners"`
 }
 
+// CreateVehicleRequest registers a vehicle. VIN is optional; when given,
+// empty brand, model and year are filled in from it.
 type CreateVehicleRequest struct {
 	ClientID string `json:"client_id"`
 	Plate    string `json:"plate"`
+	VIN      string `json:"vin"`
 	Brand    string `json:"brand"`
 	Model    string `json:"model"`
 	Year     int    `json:"year"`
 }
 
 // @Summary Create Vehicle
-// @Description Register a new vehicle
+// @Description Register a new vehicle. With a VIN, brand, model and year left empty are filled in from it where known.
 // @Tags vehicles
 // @Accept json
 // @Produce json
 // @Param vehicle body CreateVehicleRequest true "Vehicle Details"
 // @Success 201 {object} map[string]interface{}
 // @Failure 400 {object} string "Invalid input"
+// @Failure 409 {object} string "Plate, in either format, or VIN already registered"
 // @Failure 500 {object} string "Internal Server Error"
 // @Router /admin/vehicles [post]
 func (h *VehicleHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -51,13 +82,35 @@ func (h *VehicleHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var vin *sharedkernel.VIN
+	if req.VIN != "" {
+		parsed, err := sharedkernel.NewVIN(req.VIN)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		vin = &parsed
+
+		details, err := h.service.Prefill(r.Context(), parsed, serviceApplication.VehicleDetails{Brand: req.Brand, Model: req.Model, Year: req.Year})
+		if err != nil {
+			http.Error(w, "Failed to decode VIN", http.StatusInternalServerError)
+			return
+		}
+		req.Brand, req.Model, req.Year = details.Brand, details.Model, details.Year
+	}
+
 	vehicle, err := domain.NewVehicle(clientID, req.Plate, req.Brand, req.Model, req.Year)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	vehicle.VIN = vin
 
-	if err := h.repo.Save(vehicle); err != nil {
+	if err := h.repo.Save(r.Context(), vehicle); err != nil {
+		if errors.Is(err, domain.ErrVehiclePlateExists) || errors.Is(err, domain.ErrVehicleVINExists) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, "Failed to save vehicle", http.StatusInternalServerError)
 		return
 	}
@@ -92,7 +145,7 @@ func (h *VehicleHandler) ListByClient(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	vehicles, err := h.repo.ListByClientID(clientID)
+	vehicles, err := h.repo.ListByClientID(r.Context(), clientID)
 	if err != nil {
 		http.Error(w, "Failed to list vehicles", http.StatusInternalServerError)
 		return
@@ -104,16 +157,125 @@ func (h *VehicleHandler) ListByClient(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// @Summary Get Vehicle by Plate
+// @Description Find a vehicle by plate, in legacy (ABC-1234) or Mercosul (ABC1D23) format, with or without hyphen and in any case. A legacy plate also finds the vehicle registered under its Mercosul conversion, and the other way round.
+// @Tags vehicles
+// @Produce json
+// @Param plate path string true "Plate"
+// @Success 200 {object} domain.Vehicle
+// @Failure 400 {object} string "Invalid plate"
+// @Failure 404 {object} string "Vehicle not found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/vehicles/by-plate/{plate} [get]
+func (h *VehicleHandler) GetByPlate(w http.ResponseWriter, r *http.Request) {
+	plate, err := sharedkernel.NewPlacaBR(chi.URLParam(r, "plate"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	vehicle, err := h.repo.GetByPlate(r.Context(), plate)
+	if err != nil {
+		if errors.Is(err, domain.ErrVehicleNotFound) {
+			http.Error(w, "Vehicle not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to get vehicle", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(vehicle); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Vehicle Service History
+// @Description Every order of a vehicle with its items and odometer readings, oldest first, across all the clients that owned it, and its past owners
+// @Tags vehicles
+// @Produce json
+// @Param id path string true "Vehicle ID"
+// @Success 200 {object} VehicleHistoryResponse
+// @Failure 400 {object} string "Invalid ID"
+// @Failure 404 {object} string "Vehicle not found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/vehicles/{id}/history [get]
+func (h *VehicleHandler) History(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	vehicle, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrVehicleNotFound) {
+			http.Error(w, "Vehicle not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to get vehicle", http.StatusInternalServerError)
+		return
+	}
+
+	orders, err := h.orderRepo.ListByVehicleID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Failed to list vehicle history", http.StatusInternalServerError)
+		return
+	}
+	if orders == nil {
+		orders = []*domain.Order{}
+	}
+
+	readings, err := h.odometerRepo.ListByVehicleID(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Failed to list vehicle history", http.StatusInternalServerError)
+		return
+	}
+	if readings == nil {
+		readings = []*domain.OdometerReading{}
+	}
+
+	pastOwners, err := h.service.PastOwners(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Failed to list vehicle history", http.StatusInternalServerError)
+		return
+	}
+	if pastOwners == nil {
+		pastOwners = []*domain.VehicleOwnership{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(VehicleHistoryResponse{Vehicle: vehicle, Orders: orders, Readings: readings, PastOwners: pastOwners}); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// UpdateVehicleRequest changes a vehicle's details. Empty or zero fields are
+// left as they are. ClientID, if given, must be the current owner: the owner
+// is changed with TransferVehicleRequest.
+type UpdateVehicleRequest struct {
+	ClientID string `json:"client_id"`
+	Plate    string `json:"plate"`
+	VIN      string `json:"vin"`
+	Brand    string `json:"brand"`
+	Model    string `json:"model"`
+	Year     int    `json:"year"`
+}
+
 // @Summary Update Vehicle
-// @Description Update an existing vehicle
+// @Description Update the plate, VIN, brand, model or year of a vehicle. Use the transfer endpoint to change its owner.
 // @Tags vehicles
 // @Accept json
 // @Produce json
 // @Param id path string true "Vehicle ID"
-// @Param vehicle body CreateVehicleRequest true "Vehicle Details"
+// @Param vehicle body UpdateVehicleRequest true "Vehicle Details"
 // @Success 200 {object} domain.Vehicle
 // @Failure 400 {object} string "Invalid input"
 // @Failure 404 {object} string "Vehicle not found"
+// @Failure 409 {object} string "Plate, in either format, or VIN already registered"
 // @Failure 500 {object} string "Internal Server Error"
 // @Router /admin/vehicles/{id} [put]
 func (h *VehicleHandler) Update(w http.ResponseWriter, r *http.Request) {
@@ -124,43 +286,52 @@ func (h *VehicleHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req CreateVehicleRequest
+	var req UpdateVehicleRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid input", http.StatusBadRequest)
 		return
 	}
 
-	vehicle, err := h.repo.GetByID(id)
+	vehicle, err := h.repo.GetByID(r.Context(), id)
 	if err != nil {
-		http.Error(w, "Vehicle not found", http.StatusNotFound)
+		if errors.Is(err, domain.ErrVehicleNotFound) {
+			http.Error(w, "Vehicle not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to get vehicle", http.StatusInternalServerError)
 		return
 	}
 
-	// Update fields
 	if req.ClientID != "" {
 		clientID, err := uuid.Parse(req.ClientID)
-		if err == nil {
-			vehicle.ClientID = clientID
+		if err != nil {
+			http.Error(w, "Invalid client ID", http.StatusBadRequest)
+			return
 		}
-	}
-	if req.Plate != "" {
-		plate, err := sharedkernel.NewPlacaBR(req.Plate)
-		if err == nil {
-			vehicle.Plate = plate
+		if clientID != vehicle.ClientID {
+			http.Error(w, "Vehicle owner cannot be changed here; use the transfer endpoint", http.StatusBadRequest)
+			return
 		}
 	}
-	if req.Brand != "" {
-		vehicle.Brand = req.Brand
-	}
-	if req.Model != "" {
-		vehicle.Model = req.Model
+
+	if err := vehicle.Update(req.Plate, req.Brand, req.Model, req.Year); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
-	if req.Year > 0 {
-		vehicle.Year = req.Year
+	if req.VIN != "" {
+		vin, err := sharedkernel.NewVIN(req.VIN)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		vehicle.VIN = &vin
 	}
-	vehicle.UpdatedAt = time.Now()
 
-	if err := h.repo.Save(vehicle); err != nil {
+	if err := h.repo.Save(r.Context(), vehicle); err != nil {
+		if errors.Is(err, domain.ErrVehiclePlateExists) || errors.Is(err, domain.ErrVehicleVINExists) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, "Failed to update vehicle", http.StatusInternalServerError)
 		return
 	}
@@ -172,6 +343,64 @@ func (h *VehicleHandler) Update(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// TransferVehicleRequest names the client a vehicle is handed over to.
+type TransferVehicleRequest struct {
+	ClientID string `json:"client_id"`
+}
+
+// @Summary Transfer Vehicle
+// @Description Hand a vehicle over to another client. The previous owner's period is kept in the vehicle's history and its orders stay with it.
+// @Tags vehicles
+// @Accept json
+// @Produce json
+// @Param id path string true "Vehicle ID"
+// @Param transfer body TransferVehicleRequest true "New owner"
+// @Success 200 {object} domain.Vehicle
+// @Failure 400 {object} string "Invalid input or client not found"
+// @Failure 404 {object} string "Vehicle not found"
+// @Failure 409 {object} string "Vehicle already belongs to this client"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/vehicles/{id}/transfer [post]
+func (h *VehicleHandler) Transfer(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid ID format", http.StatusBadRequest)
+		return
+	}
+
+	var req TransferVehicleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	clientID, err := uuid.Parse(req.ClientID)
+	if err != nil {
+		http.Error(w, "Invalid client ID", http.StatusBadRequest)
+		return
+	}
+
+	vehicle, err := h.service.TransferOwnership(r.Context(), id, clientID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrClientNotFound):
+			http.Error(w, "Client not found", http.StatusBadRequest)
+		case errors.Is(err, domain.ErrVehicleNotFound):
+			http.Error(w, "Vehicle not found", http.StatusNotFound)
+		case errors.Is(err, domain.ErrSameOwner):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to transfer vehicle", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(vehicle); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
 // @Summary Delete Vehicle
 // @Description Delete a vehicle by ID
 // @Tags vehicles
@@ -191,7 +420,7 @@ func (h *VehicleHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.repo.Delete(id); err != nil {
+	if err := h.repo.Delete(r.Context(), id); err != nil {
 		if errors.Is(err, domain.ErrVehicleNotFound) {
 			http.Error(w, "Vehicle not found", http.StatusNotFound)
 			return
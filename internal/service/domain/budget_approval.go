@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BudgetApprovalTTL is how long a client has to answer a budget.
+const BudgetApprovalTTL = 7 * 24 * time.Hour
+
+var (
+	ErrBudgetApprovalNotFound = errors.New("budget approval not found")
+	// ErrInvalidBudgetApproval covers every way a budget approval link can
+	// fail: a bad signature, another order's link, or one that expired, was
+	// superseded by a newer budget or was already answered.
+	ErrInvalidBudgetApproval = errors.New("invalid or expired budget approval link")
+)
+
+// BudgetDecision is a client's answer to a budget, with the evidence of who
+// gave it.
+type BudgetDecision struct {
+	Approved  bool
+	IP        string
+	UserAgent string
+	DecidedAt time.Time
+}
+
+// BudgetApproval is a single-use link mailed to the client when a budget is
+// sent. Sending the budget again revokes the links sent before.
+type BudgetApproval struct {
+	ID        uuid.UUID
+	OrderID   uuid.UUID
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	RevokedAt *time.Time
+	Decision  *BudgetDecision
+}
+
+func NewBudgetApproval(orderID uuid.UUID) *BudgetApproval {
+	now := time.Now()
+	return &BudgetApproval{
+		ID:        uuid.New(),
+		OrderID:   orderID,
+		ExpiresAt: now.Add(BudgetApprovalTTL),
+		CreatedAt: now,
+	}
+}
+
+// Usable reports whether the link can still answer the budget.
+func (a *BudgetApproval) Usable(now time.Time) bool {
+	return a.RevokedAt == nil && a.Decision == nil && now.Before(a.ExpiresAt)
+}
+
+type BudgetApprovalRepository interface {
+	Save(ctx context.Context, approval *BudgetApproval) error
+	GetByID(ctx context.Context, id uuid.UUID) (*BudgetApproval, error)
+	// ListByOrderID returns the links sent for an order, oldest first.
+	ListByOrderID(ctx context.Context, orderID uuid.UUID) ([]*BudgetApproval, error)
+	// RevokeOrder revokes the order's unanswered links.
+	RevokeOrder(ctx context.Context, orderID uuid.UUID, at time.Time) error
+	// Decide records the answer given through a link. It reports false when
+	// the link was already answered or revoked, so only one answer counts.
+	Decide(ctx context.Context, id uuid.UUID, decision BudgetDecision) (bool, error)
+	// DecideOrder records an answer given outside the links on the order's
+	// unanswered link. It reports false when the order has none.
+	DecideOrder(ctx context.Context, orderID uuid.UUID, decision BudgetDecision) (bool, error)
+}
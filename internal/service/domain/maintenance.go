@@ -0,0 +1,127 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrMaintenancePlanNotFound = errors.New("maintenance plan not found")
+	ErrMaintenancePlanExists   = errors.New("service already has a maintenance plan")
+	ErrInvalidMaintenancePlan  = errors.New("maintenance plan needs a positive interval in km or months, and none negative")
+)
+
+// MaintenancePlan says a service is due again every IntervalKm kilometres or
+// IntervalMonths months after it was last done on a vehicle, whichever comes
+// first. A zero interval is not checked.
+type MaintenancePlan struct {
+	ID             uuid.UUID
+	ServiceID      uuid.UUID
+	IntervalKm     int
+	IntervalMonths int
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func NewMaintenancePlan(serviceID uuid.UUID, intervalKm, intervalMonths int) (*MaintenancePlan, error) {
+	if serviceID == uuid.Nil {
+		return nil, errors.New("service id is required")
+	}
+	if intervalKm < 0 || intervalMonths < 0 || (intervalKm == 0 && intervalMonths == 0) {
+		return nil, ErrInvalidMaintenancePlan
+	}
+	return &MaintenancePlan{
+		ID:             uuid.New(),
+		ServiceID:      serviceID,
+		IntervalKm:     intervalKm,
+		IntervalMonths: intervalMonths,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}, nil
+}
+
+// Next returns when the service is due again after being done at doneAt with
+// doneMileage on the odometer. Either result is nil when the plan has no
+// matching interval, or when the mileage of the last service is unknown.
+func (p *MaintenancePlan) Next(doneMileage *int, doneAt time.Time) (dueMileage *int, dueAt *time.Time) {
+	if p.IntervalKm > 0 && doneMileage != nil {
+		km := *doneMileage + p.IntervalKm
+		dueMileage = &km
+	}
+	if p.IntervalMonths > 0 {
+		at := doneAt.AddDate(0, p.IntervalMonths, 0)
+		dueAt = &at
+	}
+	return dueMileage, dueAt
+}
+
+// MaintenanceCandidate is the last time a planned service was done on a
+// vehicle, for which no reminder was sent yet.
+type MaintenanceCandidate struct {
+	Plan        *MaintenancePlan
+	ServiceName string
+	VehicleID   uuid.UUID
+	Plate       string
+	// ClientID is the vehicle's current owner, who gets the reminder.
+	ClientID       uuid.UUID
+	CurrentMileage int
+	OrderID        uuid.UUID
+	DoneMileage    *int
+	DoneAt         time.Time
+}
+
+// Reminder returns the reminder to send for the candidate, or nil when the
+// service is not due yet.
+func (c *MaintenanceCandidate) Reminder(now time.Time) *MaintenanceReminder {
+	dueMileage, dueAt := c.Plan.Next(c.DoneMileage, c.DoneAt)
+	byMileage := dueMileage != nil && c.CurrentMileage >= *dueMileage
+	byDate := dueAt != nil && !now.Before(*dueAt)
+	if !byMileage && !byDate {
+		return nil
+	}
+	return &MaintenanceReminder{
+		ID:         uuid.New(),
+		PlanID:     c.Plan.ID,
+		VehicleID:  c.VehicleID,
+		OrderID:    c.OrderID,
+		ClientID:   c.ClientID,
+		DueMileage: dueMileage,
+		DueAt:      dueAt,
+		CreatedAt:  now,
+	}
+}
+
+// MaintenanceReminder records that a client was told a service is due on
+// their vehicle. OrderID is the order that last did the service.
+type MaintenanceReminder struct {
+	ID         uuid.UUID
+	PlanID     uuid.UUID
+	VehicleID  uuid.UUID
+	OrderID    uuid.UUID
+	ClientID   uuid.UUID
+	DueMileage *int
+	DueAt      *time.Time
+	CreatedAt  time.Time
+}
+
+type MaintenancePlanRepository interface {
+	// Save returns ErrMaintenancePlanExists when the service already has
+	// another plan.
+	Save(ctx context.Context, plan *MaintenancePlan) error
+	GetByID(ctx context.Context, id uuid.UUID) (*MaintenancePlan, error)
+	List(ctx context.Context) ([]*MaintenancePlan, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type MaintenanceReminderRepository interface {
+	// ListCandidates returns, for each plan and vehicle, the last completed
+	// or delivered order with the plan's service, unless a reminder was
+	// already sent for it.
+	ListCandidates(ctx context.Context) ([]*MaintenanceCandidate, error)
+	// Claim stores the reminder and reports whether it was new, so a
+	// reminder is sent once even when jobs overlap.
+	Claim(ctx context.Context, reminder *MaintenanceReminder) (bool, error)
+}
@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidMileage = errors.New("mileage must not be negative")
+	// ErrMileageDecreased is returned for a reading below the vehicle's last
+	// one. Odometers only move forward.
+	ErrMileageDecreased = errors.New("mileage is lower than the vehicle's last reading")
+)
+
+// OdometerSource says when a reading was taken.
+type OdometerSource string
+
+const (
+	OdometerSourceReception OdometerSource = "reception"
+	OdometerSourceDelivery  OdometerSource = "delivery"
+)
+
+// OdometerReading is the mileage of a vehicle read when an order received or
+// delivered it.
+type OdometerReading struct {
+	ID         uuid.UUID
+	VehicleID  uuid.UUID
+	OrderID    uuid.UUID
+	Mileage    int
+	Source     OdometerSource
+	RecordedAt time.Time
+}
+
+func NewOdometerReading(vehicleID, orderID uuid.UUID, mileage int, source OdometerSource) (*OdometerReading, error) {
+	if mileage < 0 {
+		return nil, ErrInvalidMileage
+	}
+	return &OdometerReading{
+		ID:         uuid.New(),
+		VehicleID:  vehicleID,
+		OrderID:    orderID,
+		Mileage:    mileage,
+		Source:     source,
+		RecordedAt: time.Now(),
+	}, nil
+}
+
+// Follows checks the reading against the vehicle's last known mileage.
+func (r *OdometerReading) Follows(last int) error {
+	if r.Mileage < last {
+		return ErrMileageDecreased
+	}
+	return nil
+}
+
+type OdometerRepository interface {
+	// Record stores the reading and advances the vehicle's mileage to it,
+	// refusing with ErrMileageDecreased a reading below the current one.
+	Record(ctx context.Context, reading *OdometerReading) error
+	// ListByVehicleID returns the readings of a vehicle, oldest first.
+	ListByVehicleID(ctx context.Context, vehicleID uuid.UUID) ([]*OdometerReading, error)
+}
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/noggrj/autorepair/internal/sharedkernel"
@@ -9,7 +10,11 @@ import (
 )
 
 var (
-	ErrOrderNotFound = errors.New("order not found")
+	ErrOrderNotFound     = errors.New("order not found")
+	ErrOrderItemNotFound = errors.New("order item not found")
+	// ErrOrderClosed is returned when changing the items of an order that is
+	// already completed, delivered or cancelled.
+	ErrOrderClosed = errors.New("order is closed")
 )
 
 type OrderItemType string
@@ -27,6 +32,7 @@ type OrderItem struct {
 	Name      string
 	Quantity  int
 	UnitPrice sharedkernel.Money
+	UnitCost  sharedkernel.Money // cost to the shop when added; zero for services
 	Total     sharedkernel.Money
 }
 
@@ -39,38 +45,79 @@ type Order struct {
 	TotalService sharedkernel.Money
 	TotalParts   sharedkernel.Money
 	Total        sharedkernel.Money
+	TotalCost    sharedkernel.Money
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 	StartedAt    *time.Time
 	FinishedAt   *time.Time
+	// ReceptionMileage and DeliveryMileage are the odometer when the
+	// vehicle came in and when it was handed back. Orders opened before
+	// mileage was recorded have neither.
+	ReceptionMileage *int
+	DeliveryMileage  *int
+
+	// pendingTransitions holds status changes not yet persisted.
+	pendingTransitions []*StatusTransition
 }
 
-func NewOrder(clientID, vehicleID uuid.UUID) (*Order, error) {
+// NewOrder opens an order for a vehicle received with the given mileage on
+// its odometer.
+func NewOrder(clientID, vehicleID uuid.UUID, mileage int) (*Order, error) {
 	if clientID == uuid.Nil || vehicleID == uuid.Nil {
 		return nil, errors.New("client and vehicle are required")
 	}
+	if mileage < 0 {
+		return nil, ErrInvalidMileage
+	}
 
 	return &Order{
-		ID:        uuid.New(),
-		ClientID:  clientID,
-		VehicleID: vehicleID,
-		Status:    OrderStatusReceived,
-		Items:     []*OrderItem{},
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		ID:               uuid.New(),
+		ClientID:         clientID,
+		VehicleID:        vehicleID,
+		Status:           OrderStatusReceived,
+		Items:            []*OrderItem{},
+		CreatedAt:        time.Now(),
+		UpdatedAt:        time.Now(),
+		ReceptionMileage: &mileage,
 	}, nil
 }
 
-func (o *Order) AddItem(refID uuid.UUID, itemType OrderItemType, name string, qty int, price float64) error {
+// ReceptionReading is the odometer reading taken when the order was opened.
+func (o *Order) ReceptionReading() (*OdometerReading, error) {
+	if o.ReceptionMileage == nil {
+		return nil, ErrInvalidMileage
+	}
+	return NewOdometerReading(o.VehicleID, o.ID, *o.ReceptionMileage, OdometerSourceReception)
+}
+
+// RecordDeliveryMileage notes the odometer when the vehicle is handed back
+// and returns the reading to store. It cannot be below the reception
+// mileage.
+func (o *Order) RecordDeliveryMileage(mileage int) (*OdometerReading, error) {
+	reading, err := NewOdometerReading(o.VehicleID, o.ID, mileage, OdometerSourceDelivery)
+	if err != nil {
+		return nil, err
+	}
+	if o.ReceptionMileage != nil {
+		if err := reading.Follows(*o.ReceptionMileage); err != nil {
+			return nil, err
+		}
+	}
+	o.DeliveryMileage = &mileage
+	return reading, nil
+}
+
+// AddItem adds a line to the order, snapshotting the sale price and the cost
+// at this moment so later catalogue changes do not alter the order.
+func (o *Order) AddItem(refID uuid.UUID, itemType OrderItemType, name string, qty int, unitPrice, unitCost sharedkernel.Money) error {
 	if qty <= 0 {
 		return errors.New("quantity must be positive")
 	}
-	if price < 0 {
+	if unitPrice.IsNegative() || unitCost.IsNegative() {
 		return errors.New("price cannot be negative")
 	}
 
-	unitPrice := sharedkernel.Money(price)
-	total := sharedkernel.Money(float64(qty) * price)
+	total := unitPrice.Mul(int64(qty))
 
 	item := &OrderItem{
 		ID:        uuid.New(),
@@ -80,26 +127,120 @@ func (o *Order) AddItem(refID uuid.UUID, itemType OrderItemType, name string, qt
 		Name:      name,
 		Quantity:  qty,
 		UnitPrice: unitPrice,
+		UnitCost:  unitCost,
 		Total:     total,
 	}
 
 	o.Items = append(o.Items, item)
-	o.CalculateTotal()
+	if err := o.CalculateTotal(); err != nil {
+		o.Items = o.Items[:len(o.Items)-1]
+		return err
+	}
 	return nil
 }
 
-func (o *Order) CalculateTotal() {
-	var totalService, totalParts float64
+// RemoveItem takes a line off an order that is not yet closed and returns
+// it, recomputing the totals.
+func (o *Order) RemoveItem(itemID uuid.UUID) (*OrderItem, error) {
+	switch o.Status {
+	case OrderStatusCompleted, OrderStatusDelivered, OrderStatusCancelled:
+		return nil, ErrOrderClosed
+	}
+
+	for i, item := range o.Items {
+		if item.ID != itemID {
+			continue
+		}
+		o.Items = append(o.Items[:i:i], o.Items[i+1:]...)
+		if err := o.CalculateTotal(); err != nil {
+			return nil, err
+		}
+		o.UpdatedAt = time.Now()
+		return item, nil
+	}
+	return nil, ErrOrderItemNotFound
+}
+
+func (o *Order) CalculateTotal() error {
+	var services, parts, costs []sharedkernel.Money
 
 	for _, item := range o.Items {
+		costs = append(costs, item.UnitCost.Mul(int64(item.Quantity)))
 		if item.Type == ItemTypeService {
-			totalService += float64(item.Total)
+			services = append(services, item.Total)
 		} else {
-			totalParts += float64(item.Total)
+			parts = append(parts, item.Total)
 		}
 	}
 
-	o.TotalService = sharedkernel.Money(totalService)
-	o.TotalParts = sharedkernel.Money(totalParts)
-	o.Total = sharedkernel.Money(totalService + totalParts)
+	totalService, err := sharedkernel.Sum(services...)
+	if err != nil {
+		return err
+	}
+	totalParts, err := sharedkernel.Sum(parts...)
+	if err != nil {
+		return err
+	}
+	total, err := totalService.Add(totalParts)
+	if err != nil {
+		return err
+	}
+	totalCost, err := sharedkernel.Sum(costs...)
+	if err != nil {
+		return err
+	}
+
+	o.TotalService = totalService
+	o.TotalParts = totalParts
+	o.Total = total
+	o.TotalCost = totalCost
+	return nil
+}
+
+// GrossMargin is the order total minus TotalCost, what its items cost the
+// shop at the unit costs snapshotted by AddItem.
+func (o *Order) GrossMargin() (sharedkernel.Money, error) {
+	return o.Total.Sub(o.TotalCost)
+}
+
+// Transition moves the order to the target status if the state machine allows it,
+// recording who performed the change and why.
+func (o *Order) Transition(to OrderStatus, actor, reason string) error {
+	if !to.IsValid() {
+		return ErrInvalidOrderStatus
+	}
+	if !o.Status.CanTransitionTo(to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, o.Status, to)
+	}
+
+	now := time.Now()
+	switch to {
+	case OrderStatusInExecution:
+		o.StartedAt = &now
+	case OrderStatusCompleted:
+		o.FinishedAt = &now
+	}
+
+	o.pendingTransitions = append(o.pendingTransitions, &StatusTransition{
+		ID:         uuid.New(),
+		OrderID:    o.ID,
+		From:       o.Status,
+		To:         to,
+		Actor:      actor,
+		Reason:     reason,
+		OccurredAt: now,
+	})
+	o.Status = to
+	o.UpdatedAt = now
+	return nil
+}
+
+// PendingTransitions returns the status changes recorded since the order was last persisted.
+func (o *Order) PendingTransitions() []*StatusTransition {
+	return o.pendingTransitions
+}
+
+// ClearPendingTransitions is called by the repository once transitions are persisted.
+func (o *Order) ClearPendingTransitions() {
+	o.pendingTransitions = nil
 }
@@ -0,0 +1,18 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StatusTransition records a single status change of an order.
+type StatusTransition struct {
+	ID         uuid.UUID
+	OrderID    uuid.UUID
+	From       OrderStatus
+	To         OrderStatus
+	Actor      string
+	Reason     string
+	OccurredAt time.Time
+}
@@ -1,5 +1,13 @@
 package domain
 
+import "errors"
+
+var (
+	ErrInvalidOrderStatus = errors.New("invalid order status")
+	ErrInvalidTransition  = errors.New("invalid order status transition")
+	ErrReasonRequired     = errors.New("a reason is required")
+)
+
 type OrderStatus string
 
 const (
@@ -9,4 +17,48 @@ const (
 	OrderStatusInExecution      OrderStatus = "In execution"
 	OrderStatusCompleted        OrderStatus = "Completed"
 	OrderStatusDelivered        OrderStatus = "Delivered"
+	OrderStatusCancelled        OrderStatus = "Cancelled"
 )
+
+// orderTransitions is the single source of truth for legal status moves.
+// A status without an entry (or with an empty slice) is terminal.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusReceived:         {OrderStatusInDiagnosis, OrderStatusInExecution, OrderStatusCancelled},
+	OrderStatusInDiagnosis:      {OrderStatusAwaitingApproval, OrderStatusCancelled},
+	OrderStatusAwaitingApproval: {OrderStatusInExecution, OrderStatusReceived, OrderStatusCancelled},
+	OrderStatusInExecution:      {OrderStatusCompleted, OrderStatusCancelled},
+	OrderStatusCompleted:        {OrderStatusDelivered},
+	OrderStatusDelivered:        {},
+	OrderStatusCancelled:        {},
+}
+
+// ParseOrderStatus converts a raw string into a known OrderStatus.
+func ParseOrderStatus(s string) (OrderStatus, error) {
+	status := OrderStatus(s)
+	if !status.IsValid() {
+		return "", ErrInvalidOrderStatus
+	}
+	return status, nil
+}
+
+// IsValid reports whether s is one of the declared order statuses.
+func (s OrderStatus) IsValid() bool {
+	_, ok := orderTransitions[s]
+	return ok
+}
+
+// CanTransitionTo reports whether moving from s to target is allowed.
+func (s OrderStatus) CanTransitionTo(target OrderStatus) bool {
+	for _, allowed := range orderTransitions[s] {
+		if allowed == target {
+			return true
+		}
+	}
+	return false
+}
+
+// HoldsStock reports whether parts of an order in status s have already been
+// deducted from inventory (they are deducted on approval).
+func (s OrderStatus) HoldsStock() bool {
+	return s == OrderStatusInExecution || s == OrderStatusCompleted || s == OrderStatusDelivered
+}
@@ -1,22 +1,35 @@
 package domain
 
 import (
+	"context"
 	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
 )
 
 type ClientRepository interface {
-	Save(client *Client) error
-	GetByID(id uuid.UUID) (*Client, error)
-	List() ([]*Client, error)
-	Delete(id uuid.UUID) error
+	Save(ctx context.Context, client *Client) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Client, error)
+	// List pages through clients, by name unless q says otherwise. Search
+	// matches name, email and document.
+	List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*Client], error)
+	Delete(ctx context.Context, id uuid.UUID) error
 }
 
 type OrderRepository interface {
-	Save(order *Order) error
-	GetByID(id uuid.UUID) (*Order, error)
-	List() ([]*Order, error)
-	// ListActive returns orders excluding Completed and Delivered,
+	Save(ctx context.Context, order *Order) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Order, error)
+	// GetByIDForUpdate is GetByID with a row lock held until the transaction ends.
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*Order, error)
+	// List pages through orders, oldest first unless q says otherwise. It
+	// filters by status, client and creation date.
+	List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*Order], error)
+	// ListActive returns orders excluding Completed, Delivered and Cancelled,
 	// sorted by status priority (In Execution > Awaiting Approval > In Diagnosis > Received)
 	// and then by creation date (oldest first).
-	ListActive() ([]*Order, error)
+	ListActive(ctx context.Context) ([]*Order, error)
+	// ListByVehicleID returns every order of a vehicle with its items, oldest
+	// first, whoever the client was.
+	ListByVehicleID(ctx context.Context, vehicleID uuid.UUID) ([]*Order, error)
+	// ListStatusHistory returns the status transitions of an order, oldest first.
+	ListStatusHistory(ctx context.Context, orderID uuid.UUID) ([]*StatusTransition, error)
 }
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"context"
 	"errors"
 	"time"
 
@@ -21,11 +22,11 @@ type Service struct {
 	UpdatedAt   time.Time
 }
 
-func NewService(name, description string, price float64) (*Service, error) {
+func NewService(name, description string, price sharedkernel.Money) (*Service, error) {
 	if name == "" {
 		return nil, errors.New("name is required")
 	}
-	if price < 0 {
+	if price.IsNegative() {
 		return nil, errors.New("price cannot be negative")
 	}
 
@@ -33,15 +34,17 @@ func NewService(name, description string, price float64) (*Service, error) {
 		ID:          uuid.New(),
 		Name:        name,
 		Description: description,
-		Price:       sharedkernel.Money(price),
+		Price:       price,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}, nil
 }
 
 type ServiceRepository interface {
-	Save(service *Service) error
-	GetByID(id uuid.UUID) (*Service, error)
-	List() ([]*Service, error)
-	Delete(id uuid.UUID) error
+	Save(ctx context.Context, service *Service) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Service, error)
+	// List pages through services, by name unless q says otherwise. Search
+	// matches name and description.
+	List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*Service], error)
+	Delete(ctx context.Context, id uuid.UUID) error
 }
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"context"
 	"errors"
 	"time"
 
@@ -10,15 +11,24 @@ import (
 
 var (
 	ErrVehicleNotFound = errors.New("vehicle not found")
+	ErrInvalidYear     = errors.New("invalid year")
+	// ErrVehiclePlateExists is returned when another vehicle has the plate,
+	// in either its legacy or its Mercosul form.
+	ErrVehiclePlateExists = errors.New("a vehicle with this plate already exists")
+	ErrVehicleVINExists   = errors.New("a vehicle with this VIN already exists")
 )
 
+// A Vehicle's Mileage is its latest odometer reading. It only moves forward,
+// through OdometerRepository.Record. VIN is optional and nil when unknown.
 type Vehicle struct {
 	ID        uuid.UUID
 	ClientID  uuid.UUID
 	Plate     sharedkernel.PlacaBR
+	VIN       *sharedkernel.VIN
 	Brand     string
 	Model     string
 	Year      int
+	Mileage   int
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
@@ -30,8 +40,8 @@ func NewVehicle(clientID uuid.UUID, plate, brand, model string, year int) (*Vehi
 	if brand == "" || model == "" {
 		return nil, errors.New("brand and model are required")
 	}
-	if year < 1900 || year > time.Now().Year()+1 {
-		return nil, errors.New("invalid year")
+	if !validYear(year) {
+		return nil, ErrInvalidYear
 	}
 
 	p, err := sharedkernel.NewPlacaBR(plate)
@@ -51,9 +61,45 @@ func NewVehicle(clientID uuid.UUID, plate, brand, model string, year int) (*Vehi
 	}, nil
 }
 
+// Update changes the plate, brand, model and year of the vehicle, leaving
+// empty or zero values as they are. It does not change the owner: that is
+// TransferTo.
+func (v *Vehicle) Update(plate, brand, model string, year int) error {
+	p := v.Plate
+	if plate != "" {
+		var err error
+		if p, err = sharedkernel.NewPlacaBR(plate); err != nil {
+			return err
+		}
+	}
+	if year != 0 && !validYear(year) {
+		return ErrInvalidYear
+	}
+
+	v.Plate = p
+	if brand != "" {
+		v.Brand = brand
+	}
+	if model != "" {
+		v.Model = model
+	}
+	if year != 0 {
+		v.Year = year
+	}
+	v.UpdatedAt = time.Now()
+	return nil
+}
+
+func validYear(year int) bool {
+	return year >= 1900 && year <= time.Now().Year()+1
+}
+
 type VehicleRepository interface {
-	Save(vehicle *Vehicle) error
-	GetByID(id uuid.UUID) (*Vehicle, error)
-	ListByClientID(clientID uuid.UUID) ([]*Vehicle, error)
-	Delete(id uuid.UUID) error
+	Save(ctx context.Context, vehicle *Vehicle) error
+	GetByID(ctx context.Context, id uuid.UUID) (*Vehicle, error)
+	// GetByPlate finds the vehicle registered under plate, in either its
+	// legacy or its Mercosul form.
+	GetByPlate(ctx context.Context, plate sharedkernel.PlacaBR) (*Vehicle, error)
+	ListByClientID(ctx context.Context, clientID uuid.UUID) ([]*Vehicle, error)
+	Delete(ctx context.Context, id uuid.UUID) error
 }
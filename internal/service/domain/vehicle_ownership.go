@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrSameOwner is returned when a vehicle is transferred to the client
+	// that already owns it.
+	ErrSameOwner = errors.New("vehicle already belongs to this client")
+)
+
+// VehicleOwnership is a closed period during which a client owned a vehicle.
+// The current owner's period is open and lives on the vehicle itself, as its
+// ClientID; it becomes a VehicleOwnership when the vehicle is transferred.
+type VehicleOwnership struct {
+	ID        uuid.UUID
+	VehicleID uuid.UUID
+	ClientID  uuid.UUID
+	StartedAt time.Time
+	EndedAt   time.Time
+}
+
+// TransferTo hands the vehicle to clientID and returns the period that ends
+// for its previous owner, who had it since the given time.
+func (v *Vehicle) TransferTo(clientID uuid.UUID, since time.Time) (*VehicleOwnership, error) {
+	if clientID == uuid.Nil {
+		return nil, errors.New("client id is required")
+	}
+	if clientID == v.ClientID {
+		return nil, ErrSameOwner
+	}
+
+	now := time.Now()
+	ownership := &VehicleOwnership{
+		ID:        uuid.New(),
+		VehicleID: v.ID,
+		ClientID:  v.ClientID,
+		StartedAt: since,
+		EndedAt:   now,
+	}
+	v.ClientID = clientID
+	v.UpdatedAt = now
+	return ownership, nil
+}
+
+type VehicleOwnershipRepository interface {
+	// Transfer moves the vehicle to clientID and closes the previous owner's
+	// period, which started when the vehicle was last transferred or, failing
+	// that, registered. Orders stay with the client that opened them.
+	Transfer(ctx context.Context, vehicleID, clientID uuid.UUID) (*Vehicle, error)
+	// ListByVehicleID returns the past owners of a vehicle, oldest first.
+	ListByVehicleID(ctx context.Context, vehicleID uuid.UUID) ([]*VehicleOwnership, error)
+}
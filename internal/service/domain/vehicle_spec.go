@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"context"
+	"errors"
+
+	"github.com/noggrj/autorepair/internal/sharedkernel"
+)
+
+var (
+	ErrVehicleSpecNotFound = errors.New("no spec known for this VIN")
+)
+
+// VehicleSpec is what a VIN tells about a vehicle. Model is empty when only
+// the manufacturer is known.
+type VehicleSpec struct {
+	Brand string
+	Model string
+}
+
+// VehicleSpecProvider decodes a VIN into the vehicle's brand and model. It
+// returns ErrVehicleSpecNotFound for VINs it knows nothing about.
+type VehicleSpecProvider interface {
+	Lookup(ctx context.Context, vin sharedkernel.VIN) (*VehicleSpec, error)
+}
@@ -0,0 +1,109 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/noggrj/autorepair/internal/platform/db"
+	"github.com/noggrj/autorepair/internal/service/domain"
+)
+
+const budgetApprovalColumns = `id, order_id, expires_at, created_at, revoked_at, decided_at, approved, ip, user_agent`
+
+type PostgresBudgetApprovalRepository struct {
+	db db.Connection
+}
+
+func NewPostgresBudgetApprovalRepository(db db.Connection) *PostgresBudgetApprovalRepository {
+	return &PostgresBudgetApprovalRepository{db: db}
+}
+
+func (r *PostgresBudgetApprovalRepository) Save(ctx context.Context, approval *domain.BudgetApproval) error {
+	query := `INSERT INTO budget_approvals (id, order_id, expires_at, created_at) VALUES ($1, $2, $3, $4)`
+	_, err := r.db.Exec(ctx, query, approval.ID, approval.OrderID, approval.ExpiresAt, approval.CreatedAt)
+	return err
+}
+
+func (r *PostgresBudgetApprovalRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.BudgetApproval, error) {
+	query := `SELECT ` + budgetApprovalColumns + ` FROM budget_approvals WHERE id = $1`
+	approval, err := scanBudgetApproval(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrBudgetApprovalNotFound
+		}
+		return nil, err
+	}
+	return approval, nil
+}
+
+func (r *PostgresBudgetApprovalRepository) ListByOrderID(ctx context.Context, orderID uuid.UUID) ([]*domain.BudgetApproval, error) {
+	query := `SELECT ` + budgetApprovalColumns + ` FROM budget_approvals WHERE order_id = $1 ORDER BY created_at`
+	rows, err := r.db.Query(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	approvals := []*domain.BudgetApproval{}
+	for rows.Next() {
+		approval, err := scanBudgetApproval(rows)
+		if err != nil {
+			return nil, err
+		}
+		approvals = append(approvals, approval)
+	}
+	return approvals, rows.Err()
+}
+
+func (r *PostgresBudgetApprovalRepository) RevokeOrder(ctx context.Context, orderID uuid.UUID, at time.Time) error {
+	query := `UPDATE budget_approvals SET revoked_at = $2 WHERE order_id = $1 AND revoked_at IS NULL AND decided_at IS NULL`
+	_, err := r.db.Exec(ctx, query, orderID, at)
+	return err
+}
+
+func (r *PostgresBudgetApprovalRepository) Decide(ctx context.Context, id uuid.UUID, decision domain.BudgetDecision) (bool, error) {
+	query := `UPDATE budget_approvals SET decided_at = $2, approved = $3, ip = $4, user_agent = $5
+		WHERE id = $1 AND decided_at IS NULL AND revoked_at IS NULL`
+	result, err := r.db.Exec(ctx, query, id, decision.DecidedAt, decision.Approved, decision.IP, decision.UserAgent)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+func (r *PostgresBudgetApprovalRepository) DecideOrder(ctx context.Context, orderID uuid.UUID, decision domain.BudgetDecision) (bool, error) {
+	query := `UPDATE budget_approvals SET decided_at = $2, approved = $3, ip = $4, user_agent = $5
+		WHERE order_id = $1 AND decided_at IS NULL AND revoked_at IS NULL`
+	result, err := r.db.Exec(ctx, query, orderID, decision.DecidedAt, decision.Approved, decision.IP, decision.UserAgent)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+func scanBudgetApproval(row pgx.Row) (*domain.BudgetApproval, error) {
+	var a domain.BudgetApproval
+	var decidedAt *time.Time
+	var approved *bool
+	var ip, userAgent *string
+	err := row.Scan(&a.ID, &a.OrderID, &a.ExpiresAt, &a.CreatedAt, &a.RevokedAt, &decidedAt, &approved, &ip, &userAgent)
+	if err != nil {
+		return nil, err
+	}
+	if decidedAt != nil {
+		a.Decision = &domain.BudgetDecision{DecidedAt: *decidedAt}
+		if approved != nil {
+			a.Decision.Approved = *approved
+		}
+		if ip != nil {
+			a.Decision.IP = *ip
+		}
+		if userAgent != nil {
+			a.Decision.UserAgent = *userAgent
+		}
+	}
+	return &a, nil
+}
@@ -19,7 +19,7 @@ func NewPostgresClientRepository(db db.Connection) *PostgresClientRepository {
 	return &PostgresClientRepository{db: db}
 }
 
-func (r *PostgresClientRepository) Save(client *domain.Client) error {
+func (r *PostgresClientRepository) Save(ctx context.Context, client *domain.Client) error {
 	query := `INSERT INTO clients (id, name, document, email, phone, created_at, updated_at)
 	          VALUES ($1, $2, $3, $4, $5, $6, $7)
 	          ON CONFLICT (id) DO UPDATE SET
@@ -28,22 +28,40 @@ func (r *PostgresClientRepository) Save(client *domain.Client) error {
 	          email = EXCLUDED.email,
 	          phone = EXCLUDED.phone,
 	          updated_at = EXCLUDED.updated_at`
-	_, err := r.db.Exec(context.Background(), query,
+	_, err := r.db.Exec(ctx, query,
 		client.ID, client.Name, client.Document.String(), client.Email, client.Phone, client.CreatedAt, client.UpdatedAt)
 	return err
 }
 
-func (r *PostgresClientRepository) GetByID(id uuid.UUID) (*domain.Client, error) {
+func (r *PostgresClientRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Client, error) {
 	query := `SELECT id, name, document, email, phone, created_at, updated_at FROM clients WHERE id = $1`
-	row := r.db.QueryRow(context.Background(), query, id)
+	row := r.db.QueryRow(ctx, query, id)
 	return scanClient(row)
 }
 
-func (r *PostgresClientRepository) List() ([]*domain.Client, error) {
-	query := `SELECT id, name, document, email, phone, created_at, updated_at FROM clients`
-	rows, err := r.db.Query(context.Background(), query)
+var clientListing = db.Listing[*domain.Client]{
+	Sorts: map[string]db.SortField[*domain.Client]{
+		"name":       {{Expr: "name", Cast: "text", Value: func(c *domain.Client) string { return c.Name }}},
+		"created_at": {{Expr: "created_at", Cast: "timestamptz", Value: func(c *domain.Client) string { return db.FormatTime(c.CreatedAt) }}},
+	},
+	DefaultSort: "name",
+	ID:          func(c *domain.Client) uuid.UUID { return c.ID },
+}
+
+func (r *PostgresClientRepository) List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*domain.Client], error) {
+	var f db.Filter
+	if q.Search != "" {
+		f.Contains(q.Search, "name", "email", "document")
+	}
+	f.CreatedBetween("created_at", q)
+
+	query, args, err := clientListing.Query(`SELECT id, name, document, email, phone, created_at, updated_at FROM clients`, &f, q)
 	if err != nil {
-		return nil, err
+		return sharedkernel.Page[*domain.Client]{}, err
+	}
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return sharedkernel.Page[*domain.Client]{}, err
 	}
 	defer rows.Close()
 
@@ -51,16 +69,16 @@ func (r *PostgresClientRepository) List() ([]*domain.Client, error) {
 	for rows.Next() {
 		client, err := scanClient(rows)
 		if err != nil {
-			return nil, err
+			return sharedkernel.Page[*domain.Client]{}, err
 		}
 		clients = append(clients, client)
 	}
-	return clients, nil
+	return clientListing.Page(clients, q), nil
 }
 
-func (r *PostgresClientRepository) Delete(id uuid.UUID) error {
+func (r *PostgresClientRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM clients WHERE id = $1`
-	result, err := r.db.Exec(context.Background(), query, id)
+	result, err := r.db.Exec(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,141 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/noggrj/autorepair/internal/platform/db"
+	"github.com/noggrj/autorepair/internal/service/domain"
+)
+
+// uniqueViolation is the Postgres error code raised when an insert or update
+// collides with a unique index.
+const uniqueViolation = "23505"
+
+const maintenancePlanColumns = `id, service_id, interval_km, interval_months, created_at, updated_at`
+
+type PostgresMaintenancePlanRepository struct {
+	db db.Connection
+}
+
+func NewPostgresMaintenancePlanRepository(db db.Connection) *PostgresMaintenancePlanRepository {
+	return &PostgresMaintenancePlanRepository{db: db}
+}
+
+func (r *PostgresMaintenancePlanRepository) Save(ctx context.Context, plan *domain.MaintenancePlan) error {
+	query := `INSERT INTO maintenance_plans (` + maintenancePlanColumns + `) VALUES ($1, $2, $3, $4, $5, $6)
+			  ON CONFLICT (id) DO UPDATE SET
+			  interval_km = EXCLUDED.interval_km,
+			  interval_months = EXCLUDED.interval_months,
+			  updated_at = EXCLUDED.updated_at`
+	_, err := r.db.Exec(ctx, query, plan.ID, plan.ServiceID, plan.IntervalKm, plan.IntervalMonths, plan.CreatedAt, plan.UpdatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return domain.ErrMaintenancePlanExists
+	}
+	return err
+}
+
+func (r *PostgresMaintenancePlanRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.MaintenancePlan, error) {
+	query := `SELECT ` + maintenancePlanColumns + ` FROM maintenance_plans WHERE id = $1`
+	var p domain.MaintenancePlan
+	err := r.db.QueryRow(ctx, query, id).Scan(&p.ID, &p.ServiceID, &p.IntervalKm, &p.IntervalMonths, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrMaintenancePlanNotFound
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *PostgresMaintenancePlanRepository) List(ctx context.Context) ([]*domain.MaintenancePlan, error) {
+	query := `SELECT ` + maintenancePlanColumns + ` FROM maintenance_plans ORDER BY created_at`
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	plans := []*domain.MaintenancePlan{}
+	for rows.Next() {
+		var p domain.MaintenancePlan
+		if err := rows.Scan(&p.ID, &p.ServiceID, &p.IntervalKm, &p.IntervalMonths, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		plans = append(plans, &p)
+	}
+	return plans, rows.Err()
+}
+
+func (r *PostgresMaintenancePlanRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.Exec(ctx, `DELETE FROM maintenance_plans WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrMaintenancePlanNotFound
+	}
+	return nil
+}
+
+type PostgresMaintenanceReminderRepository struct {
+	db db.Connection
+}
+
+func NewPostgresMaintenanceReminderRepository(db db.Connection) *PostgresMaintenanceReminderRepository {
+	return &PostgresMaintenanceReminderRepository{db: db}
+}
+
+// ListCandidates picks the latest service order per plan and vehicle first and
+// only then drops the ones already reminded, so an older order never stands
+// in for a newer one that was.
+func (r *PostgresMaintenanceReminderRepository) ListCandidates(ctx context.Context) ([]*domain.MaintenanceCandidate, error) {
+	query := `SELECT c.* FROM (
+	            SELECT DISTINCT ON (p.id, v.id)
+	                   p.id AS plan_id, p.service_id, p.interval_km, p.interval_months, p.created_at, p.updated_at,
+	                   s.name, v.id AS vehicle_id, v.plate, v.client_id, v.mileage,
+	                   o.id AS order_id, COALESCE(o.delivery_mileage, o.reception_mileage), COALESCE(o.finished_at, o.updated_at) AS done_at
+	            FROM maintenance_plans p
+	            JOIN services s ON s.id = p.service_id
+	            JOIN order_items oi ON oi.ref_id = p.service_id AND oi.type = $1
+	            JOIN orders o ON o.id = oi.order_id AND o.status IN ($2, $3)
+	            JOIN vehicles v ON v.id = o.vehicle_id
+	            ORDER BY p.id, v.id, done_at DESC
+	          ) c
+	          WHERE NOT EXISTS (SELECT 1 FROM maintenance_reminders mr WHERE mr.plan_id = c.plan_id AND mr.order_id = c.order_id)`
+	rows, err := r.db.Query(ctx, query, string(domain.ItemTypeService), string(domain.OrderStatusCompleted), string(domain.OrderStatusDelivered))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	candidates := []*domain.MaintenanceCandidate{}
+	for rows.Next() {
+		c := domain.MaintenanceCandidate{Plan: &domain.MaintenancePlan{}}
+		err := rows.Scan(&c.Plan.ID, &c.Plan.ServiceID, &c.Plan.IntervalKm, &c.Plan.IntervalMonths, &c.Plan.CreatedAt, &c.Plan.UpdatedAt,
+			&c.ServiceName, &c.VehicleID, &c.Plate, &c.ClientID, &c.CurrentMileage,
+			&c.OrderID, &c.DoneMileage, &c.DoneAt)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, &c)
+	}
+	return candidates, rows.Err()
+}
+
+func (r *PostgresMaintenanceReminderRepository) Claim(ctx context.Context, reminder *domain.MaintenanceReminder) (bool, error) {
+	query := `INSERT INTO maintenance_reminders (id, plan_id, vehicle_id, order_id, client_id, due_mileage, due_at, created_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	          ON CONFLICT (plan_id, order_id) DO NOTHING`
+	result, err := r.db.Exec(ctx, query,
+		reminder.ID, reminder.PlanID, reminder.VehicleID, reminder.OrderID, reminder.ClientID,
+		reminder.DueMileage, reminder.DueAt, reminder.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() == 1, nil
+}
@@ -0,0 +1,67 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/noggrj/autorepair/internal/platform/db"
+	"github.com/noggrj/autorepair/internal/service/domain"
+)
+
+type PostgresOdometerRepository struct {
+	db db.Connection
+}
+
+func NewPostgresOdometerRepository(db db.Connection) *PostgresOdometerRepository {
+	return &PostgresOdometerRepository{db: db}
+}
+
+// Record locks the vehicle row so concurrent readings of the same vehicle
+// are checked against each other, not against a stale mileage.
+func (r *PostgresOdometerRepository) Record(ctx context.Context, reading *domain.OdometerReading) error {
+	return db.RunInTx(ctx, r.db, func(tx pgx.Tx) error {
+		var current int
+		err := tx.QueryRow(ctx, `SELECT mileage FROM vehicles WHERE id = $1 FOR UPDATE`, reading.VehicleID).Scan(&current)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return domain.ErrVehicleNotFound
+			}
+			return err
+		}
+		if err := reading.Follows(current); err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `INSERT INTO odometer_readings (id, vehicle_id, order_id, mileage, source, recorded_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+			reading.ID, reading.VehicleID, reading.OrderID, reading.Mileage, string(reading.Source), reading.RecordedAt)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `UPDATE vehicles SET mileage = $2 WHERE id = $1`, reading.VehicleID, reading.Mileage)
+		return err
+	})
+}
+
+func (r *PostgresOdometerRepository) ListByVehicleID(ctx context.Context, vehicleID uuid.UUID) ([]*domain.OdometerReading, error) {
+	query := `SELECT id, vehicle_id, order_id, mileage, source, recorded_at FROM odometer_readings WHERE vehicle_id = $1 ORDER BY recorded_at`
+	rows, err := r.db.Query(ctx, query, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	readings := []*domain.OdometerReading{}
+	for rows.Next() {
+		var reading domain.OdometerReading
+		var source string
+		if err := rows.Scan(&reading.ID, &reading.VehicleID, &reading.OrderID, &reading.Mileage, &source, &reading.RecordedAt); err != nil {
+			return nil, err
+		}
+		reading.Source = domain.OdometerSource(source)
+		readings = append(readings, &reading)
+	}
+	return readings, rows.Err()
+}
@@ -3,9 +3,13 @@ package infrastructure
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/noggrj/autorepair/internal/platform/db"
 	"github.com/noggrj/autorepair/internal/service/domain"
 	"github.com/noggrj/autorepair/internal/sharedkernel"
@@ -19,8 +23,7 @@ func NewPostgresOrderRepository(db db.Connection) *PostgresOrderRepository {
 	return &PostgresOrderRepository{db: db}
 }
 
-func (r *PostgresOrderRepository) Save(order *domain.Order) error {
-	ctx := context.Background()
+func (r *PostgresOrderRepository) Save(ctx context.Context, order *domain.Order) error {
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
 		return err
@@ -33,21 +36,33 @@ func (r *PostgresOrderRepository) Save(order *domain.Order) error {
 	}()
 
 	// Save Order
-	query := `INSERT INTO orders (id, client_id, vehicle_id, status, total_service, total_parts, total, created_at, updated_at, started_at, finished_at)
-	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	query := `INSERT INTO orders (id, client_id, vehicle_id, status, total_service, total_parts, total, total_cost, created_at, updated_at, started_at, finished_at, reception_mileage, delivery_mileage)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 	          ON CONFLICT (id) DO UPDATE SET
 	          status = EXCLUDED.status,
 	          total_service = EXCLUDED.total_service,
 	          total_parts = EXCLUDED.total_parts,
 	          total = EXCLUDED.total,
+	          total_cost = EXCLUDED.total_cost,
 	          updated_at = EXCLUDED.updated_at,
 	          started_at = EXCLUDED.started_at,
-	          finished_at = EXCLUDED.finished_at`
+	          finished_at = EXCLUDED.finished_at,
+	          delivery_mileage = EXCLUDED.delivery_mileage`
 
 	_, err = tx.Exec(ctx, query,
 		order.ID, order.ClientID, order.VehicleID, order.Status,
-		float64(order.TotalService), float64(order.TotalParts), float64(order.Total),
-		order.CreatedAt, order.UpdatedAt, order.StartedAt, order.FinishedAt)
+		order.TotalService, order.TotalParts, order.Total, order.TotalCost,
+		order.CreatedAt, order.UpdatedAt, order.StartedAt, order.FinishedAt,
+		order.ReceptionMileage, order.DeliveryMileage)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+		switch pgErr.ConstraintName {
+		case "orders_vehicle_id_fkey":
+			return domain.ErrVehicleNotFound
+		case "orders_client_id_fkey":
+			return domain.ErrClientNotFound
+		}
+	}
 	if err != nil {
 		return err
 	}
@@ -59,32 +74,56 @@ func (r *PostgresOrderRepository) Save(order *domain.Order) error {
 		return err
 	}
 
-	itemQuery := `INSERT INTO order_items (id, order_id, ref_id, type, name, quantity, unit_price, total)
-	              VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	itemQuery := `INSERT INTO order_items (id, order_id, ref_id, type, name, quantity, unit_price, unit_cost, total)
+	              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
 
 	for _, item := range order.Items {
 		_, err = tx.Exec(ctx, itemQuery,
 			item.ID, item.OrderID, item.RefID, item.Type, item.Name,
-			item.Quantity, float64(item.UnitPrice), float64(item.Total))
+			item.Quantity, item.UnitPrice, item.UnitCost, item.Total)
 		if err != nil {
 			return err
 		}
 	}
 
-	return tx.Commit(ctx)
+	historyQuery := `INSERT INTO order_status_history (id, order_id, from_status, to_status, actor, reason, occurred_at)
+	                 VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	for _, t := range order.PendingTransitions() {
+		_, err = tx.Exec(ctx, historyQuery,
+			t.ID, t.OrderID, string(t.From), string(t.To), t.Actor, t.Reason, t.OccurredAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	order.ClearPendingTransitions()
+	return nil
+}
+
+func (r *PostgresOrderRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Order, error) {
+	return r.getByID(ctx, id, "")
+}
+
+// GetByIDForUpdate loads the order and locks its row until the surrounding
+// transaction ends. It only makes sense on a transaction-bound repository.
+func (r *PostgresOrderRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*domain.Order, error) {
+	return r.getByID(ctx, id, " FOR UPDATE")
 }
 
-func (r *PostgresOrderRepository) GetByID(id uuid.UUID) (*domain.Order, error) {
-	query := `SELECT id, client_id, vehicle_id, status, total_service, total_parts, total, created_at, updated_at, started_at, finished_at 
-	          FROM orders WHERE id = $1`
+func (r *PostgresOrderRepository) getByID(ctx context.Context, id uuid.UUID, lock string) (*domain.Order, error) {
+	query := `SELECT id, client_id, vehicle_id, status, total_service, total_parts, total, total_cost, created_at, updated_at, started_at, finished_at, reception_mileage, delivery_mileage 
+	          FROM orders WHERE id = $1` + lock
 
-	row := r.db.QueryRow(context.Background(), query, id)
+	row := r.db.QueryRow(ctx, query, id)
 
 	var o domain.Order
-	var ts, tp, t float64
 	var statusStr string
 
-	err := row.Scan(&o.ID, &o.ClientID, &o.VehicleID, &statusStr, &ts, &tp, &t, &o.CreatedAt, &o.UpdatedAt, &o.StartedAt, &o.FinishedAt)
+	err := row.Scan(&o.ID, &o.ClientID, &o.VehicleID, &statusStr, &o.TotalService, &o.TotalParts, &o.Total, &o.TotalCost, &o.CreatedAt, &o.UpdatedAt, &o.StartedAt, &o.FinishedAt, &o.ReceptionMileage, &o.DeliveryMileage)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, domain.ErrOrderNotFound
@@ -93,13 +132,10 @@ func (r *PostgresOrderRepository) GetByID(id uuid.UUID) (*domain.Order, error) {
 	}
 
 	o.Status = domain.OrderStatus(statusStr)
-	o.TotalService = sharedkernel.Money(ts)
-	o.TotalParts = sharedkernel.Money(tp)
-	o.Total = sharedkernel.Money(t)
 
 	// Fetch Items
-	itemsQuery := `SELECT id, order_id, ref_id, type, name, quantity, unit_price, total FROM order_items WHERE order_id = $1`
-	rows, err := r.db.Query(context.Background(), itemsQuery, id)
+	itemsQuery := `SELECT id, order_id, ref_id, type, name, quantity, unit_price, unit_cost, total FROM order_items WHERE order_id = $1`
+	rows, err := r.db.Query(ctx, itemsQuery, id)
 	if err != nil {
 		return nil, err
 	}
@@ -108,87 +144,197 @@ func (r *PostgresOrderRepository) GetByID(id uuid.UUID) (*domain.Order, error) {
 	for rows.Next() {
 		var i domain.OrderItem
 		var typeStr string
-		var up, tot float64
-		if err := rows.Scan(&i.ID, &i.OrderID, &i.RefID, &typeStr, &i.Name, &i.Quantity, &up, &tot); err != nil {
+		if err := rows.Scan(&i.ID, &i.OrderID, &i.RefID, &typeStr, &i.Name, &i.Quantity, &i.UnitPrice, &i.UnitCost, &i.Total); err != nil {
 			return nil, err
 		}
 		i.Type = domain.OrderItemType(typeStr)
-		i.UnitPrice = sharedkernel.Money(up)
-		i.Total = sharedkernel.Money(tot)
 		o.Items = append(o.Items, &i)
 	}
 
 	return &o, nil
 }
 
-func (r *PostgresOrderRepository) List() ([]*domain.Order, error) {
-	// Basic listing without pagination for MVP
-	query := `SELECT id, client_id, vehicle_id, status, total_service, total_parts, total, created_at, updated_at, started_at, finished_at FROM orders`
-	rows, err := r.db.Query(context.Background(), query)
+// queuePriority is the order in which open orders are worked on; any other
+// status sorts after them.
+var queuePriority = []domain.OrderStatus{
+	domain.OrderStatusInExecution,
+	domain.OrderStatusAwaitingApproval,
+	domain.OrderStatusInDiagnosis,
+	domain.OrderStatusReceived,
+}
+
+func queueRank(status domain.OrderStatus) int {
+	for i, s := range queuePriority {
+		if s == status {
+			return i + 1
+		}
+	}
+	return len(queuePriority) + 1
+}
+
+var queueRankExpr = func() string {
+	var b strings.Builder
+	b.WriteString("CASE status")
+	for _, s := range queuePriority {
+		fmt.Fprintf(&b, " WHEN '%s' THEN %d", s, queueRank(s))
+	}
+	fmt.Fprintf(&b, " ELSE %d END", len(queuePriority)+1)
+	return b.String()
+}()
+
+var orderListing = db.Listing[*domain.Order]{
+	Sorts: map[string]db.SortField[*domain.Order]{
+		"created_at": {{Expr: "created_at", Cast: "timestamptz", Value: func(o *domain.Order) string { return db.FormatTime(o.CreatedAt) }}},
+		"updated_at": {{Expr: "updated_at", Cast: "timestamptz", Value: func(o *domain.Order) string { return db.FormatTime(o.UpdatedAt) }}},
+		"total":      {{Expr: "total", Cast: "numeric", Value: func(o *domain.Order) string { return o.Total.String() }}},
+		"priority": {
+			{Expr: queueRankExpr, Cast: "int", Value: func(o *domain.Order) string { return strconv.Itoa(queueRank(o.Status)) }},
+			{Expr: "created_at", Cast: "timestamptz", Value: func(o *domain.Order) string { return db.FormatTime(o.CreatedAt) }},
+		},
+	},
+	DefaultSort: "created_at",
+	ID:          func(o *domain.Order) uuid.UUID { return o.ID },
+}
+
+func (r *PostgresOrderRepository) List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*domain.Order], error) {
+	var f db.Filter
+	if len(q.Status) > 0 {
+		f.Where("status = ANY(" + f.Arg(q.Status) + ")")
+	}
+	if q.ClientID != nil {
+		f.Where("client_id = " + f.Arg(*q.ClientID))
+	}
+	f.CreatedBetween("created_at", q)
+
+	query, args, err := orderListing.Query(`SELECT id, client_id, vehicle_id, status, total_service, total_parts, total, total_cost, created_at, updated_at, started_at, finished_at, reception_mileage, delivery_mileage FROM orders`, &f, q)
 	if err != nil {
-		return nil, err
+		return sharedkernel.Page[*domain.Order]{}, err
+	}
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return sharedkernel.Page[*domain.Order]{}, err
 	}
 	defer rows.Close()
 
 	var orders []*domain.Order
 	for rows.Next() {
 		var o domain.Order
-		var ts, tp, t float64
 		var statusStr string
-		err := rows.Scan(&o.ID, &o.ClientID, &o.VehicleID, &statusStr, &ts, &tp, &t, &o.CreatedAt, &o.UpdatedAt, &o.StartedAt, &o.FinishedAt)
+		err := rows.Scan(&o.ID, &o.ClientID, &o.VehicleID, &statusStr, &o.TotalService, &o.TotalParts, &o.Total, &o.TotalCost, &o.CreatedAt, &o.UpdatedAt, &o.StartedAt, &o.FinishedAt, &o.ReceptionMileage, &o.DeliveryMileage)
 		if err != nil {
-			return nil, err
+			return sharedkernel.Page[*domain.Order]{}, err
 		}
 		o.Status = domain.OrderStatus(statusStr)
-		o.TotalService = sharedkernel.Money(ts)
-		o.TotalParts = sharedkernel.Money(tp)
-		o.Total = sharedkernel.Money(t)
 		orders = append(orders, &o)
 	}
-	return orders, nil
+	return orderListing.Page(orders, q), nil
 }
 
-func (r *PostgresOrderRepository) ListActive() ([]*domain.Order, error) {
-	query := `SELECT id, client_id, vehicle_id, status, total_service, total_parts, total, created_at, updated_at, started_at, finished_at
-	          FROM orders
-	          WHERE status NOT IN ($1, $2)
-	          ORDER BY
-	            CASE status
-	              WHEN $3 THEN 1
-	              WHEN $4 THEN 2
-	              WHEN $5 THEN 3
-	              WHEN $6 THEN 4
-	              ELSE 5
-	            END,
-	            created_at ASC`
-
-	rows, err := r.db.Query(context.Background(), query,
-		string(domain.OrderStatusCompleted),
-		string(domain.OrderStatusDelivered),
-		string(domain.OrderStatusInExecution),
-		string(domain.OrderStatusAwaitingApproval),
-		string(domain.OrderStatusInDiagnosis),
-		string(domain.OrderStatusReceived),
-	)
+func (r *PostgresOrderRepository) ListActive(ctx context.Context) ([]*domain.Order, error) {
+	statuses := make([]string, len(queuePriority))
+	for i, s := range queuePriority {
+		statuses[i] = string(s)
+	}
+	page, err := r.List(ctx, sharedkernel.ListQuery{Status: statuses, Sort: "priority"})
+	return page.Items, err
+}
+
+func (r *PostgresOrderRepository) ListByVehicleID(ctx context.Context, vehicleID uuid.UUID) ([]*domain.Order, error) {
+	query := `SELECT id, client_id, vehicle_id, status, total_service, total_parts, total, total_cost, created_at, updated_at, started_at, finished_at, reception_mileage, delivery_mileage
+	          FROM orders WHERE vehicle_id = $1 ORDER BY created_at ASC, id ASC`
+	rows, err := r.db.Query(ctx, query, vehicleID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var orders []*domain.Order
+	orders := []*domain.Order{}
+	byID := make(map[uuid.UUID]*domain.Order)
 	for rows.Next() {
 		var o domain.Order
-		var ts, tp, t float64
 		var statusStr string
-		err := rows.Scan(&o.ID, &o.ClientID, &o.VehicleID, &statusStr, &ts, &tp, &t, &o.CreatedAt, &o.UpdatedAt, &o.StartedAt, &o.FinishedAt)
+		err := rows.Scan(&o.ID, &o.ClientID, &o.VehicleID, &statusStr, &o.TotalService, &o.TotalParts, &o.Total, &o.TotalCost, &o.CreatedAt, &o.UpdatedAt, &o.StartedAt, &o.FinishedAt, &o.ReceptionMileage, &o.DeliveryMileage)
 		if err != nil {
 			return nil, err
 		}
 		o.Status = domain.OrderStatus(statusStr)
-		o.TotalService = sharedkernel.Money(ts)
-		o.TotalParts = sharedkernel.Money(tp)
-		o.Total = sharedkernel.Money(t)
 		orders = append(orders, &o)
+		byID[o.ID] = &o
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return orders, nil
+	}
+
+	// Items of all the orders in one round trip
+	ids := make([]uuid.UUID, len(orders))
+	for i, o := range orders {
+		ids[i] = o.ID
+	}
+	itemsQuery := `SELECT id, order_id, ref_id, type, name, quantity, unit_price, unit_cost, total FROM order_items WHERE order_id = ANY($1)`
+	itemRows, err := r.db.Query(ctx, itemsQuery, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var i domain.OrderItem
+		var typeStr string
+		if err := itemRows.Scan(&i.ID, &i.OrderID, &i.RefID, &typeStr, &i.Name, &i.Quantity, &i.UnitPrice, &i.UnitCost, &i.Total); err != nil {
+			return nil, err
+		}
+		i.Type = domain.OrderItemType(typeStr)
+		if o, ok := byID[i.OrderID]; ok {
+			o.Items = append(o.Items, &i)
+		}
 	}
-	return orders, nil
+	return orders, itemRows.Err()
+}
+
+func (r *PostgresOrderRepository) ListStatusHistory(ctx context.Context, orderID uuid.UUID) ([]*domain.StatusTransition, error) {
+	query := `SELECT id, order_id, from_status, to_status, actor, reason, occurred_at
+	          FROM order_status_history
+	          WHERE order_id = $1
+	          ORDER BY occurred_at ASC`
+
+	rows, err := r.db.Query(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []*domain.StatusTransition
+	for rows.Next() {
+		var t domain.StatusTransition
+		var from, to string
+		if err := rows.Scan(&t.ID, &t.OrderID, &from, &to, &t.Actor, &t.Reason, &t.OccurredAt); err != nil {
+			return nil, err
+		}
+		t.From = domain.OrderStatus(from)
+		t.To = domain.OrderStatus(to)
+		history = append(history, &t)
+	}
+	return history, nil
+}
+
+// HasOpenOrdersWithPart reports whether a part is an item of any order that
+// is neither delivered nor cancelled.
+func (r *PostgresOrderRepository) HasOpenOrdersWithPart(ctx context.Context, partID uuid.UUID) (bool, error) {
+	query := `SELECT EXISTS (
+	            SELECT 1 FROM order_items oi
+	            JOIN orders o ON o.id = oi.order_id
+	            WHERE oi.ref_id = $1 AND oi.type = $2 AND o.status NOT IN ($3, $4)
+	          )`
+
+	var exists bool
+	err := r.db.QueryRow(ctx, query,
+		partID,
+		string(domain.ItemTypePart),
+		string(domain.OrderStatusDelivered),
+		string(domain.OrderStatusCancelled),
+	).Scan(&exists)
+	return exists, err
 }
@@ -21,7 +21,7 @@ func NewPostgresServiceRepository(db db.Connection) *PostgresServiceRepository {
 	return &PostgresServiceRepository{db: db}
 }
 
-func (r *PostgresServiceRepository) Save(service *domain.Service) error {
+func (r *PostgresServiceRepository) Save(ctx context.Context, service *domain.Service) error {
 	query := `INSERT INTO services (id, name, description, price, created_at, updated_at)
 	          VALUES ($1, $2, $3, $4, $5, $6)
 	          ON CONFLICT (id) DO UPDATE SET
@@ -29,22 +29,41 @@ func (r *PostgresServiceRepository) Save(service *domain.Service) error {
 	          description = EXCLUDED.description,
 	          price = EXCLUDED.price,
 	          updated_at = EXCLUDED.updated_at`
-	_, err := r.db.Exec(context.Background(), query,
-		service.ID, service.Name, service.Description, float64(service.Price), service.CreatedAt, service.UpdatedAt)
+	_, err := r.db.Exec(ctx, query,
+		service.ID, service.Name, service.Description, service.Price, service.CreatedAt, service.UpdatedAt)
 	return err
 }
 
-func (r *PostgresServiceRepository) GetByID(id uuid.UUID) (*domain.Service, error) {
+func (r *PostgresServiceRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Service, error) {
 	query := `SELECT id, name, description, price, created_at, updated_at FROM services WHERE id = $1`
-	row := r.db.QueryRow(context.Background(), query, id)
+	row := r.db.QueryRow(ctx, query, id)
 	return scanService(row)
 }
 
-func (r *PostgresServiceRepository) List() ([]*domain.Service, error) {
-	query := `SELECT id, name, description, price, created_at, updated_at FROM services`
-	rows, err := r.db.Query(context.Background(), query)
+var serviceListing = db.Listing[*domain.Service]{
+	Sorts: map[string]db.SortField[*domain.Service]{
+		"name":       {{Expr: "name", Cast: "text", Value: func(s *domain.Service) string { return s.Name }}},
+		"price":      {{Expr: "price", Cast: "numeric", Value: func(s *domain.Service) string { return s.Price.String() }}},
+		"created_at": {{Expr: "created_at", Cast: "timestamptz", Value: func(s *domain.Service) string { return db.FormatTime(s.CreatedAt) }}},
+	},
+	DefaultSort: "name",
+	ID:          func(s *domain.Service) uuid.UUID { return s.ID },
+}
+
+func (r *PostgresServiceRepository) List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*domain.Service], error) {
+	var f db.Filter
+	if q.Search != "" {
+		f.Contains(q.Search, "name", "description")
+	}
+	f.CreatedBetween("created_at", q)
+
+	query, args, err := serviceListing.Query(`SELECT id, name, description, price, created_at, updated_at FROM services`, &f, q)
 	if err != nil {
-		return nil, err
+		return sharedkernel.Page[*domain.Service]{}, err
+	}
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return sharedkernel.Page[*domain.Service]{}, err
 	}
 	defer rows.Close()
 
@@ -52,16 +71,16 @@ func (r *PostgresServiceRepository) List() ([]*domain.Service, error) {
 	for rows.Next() {
 		s, err := scanService(rows)
 		if err != nil {
-			return nil, err
+			return sharedkernel.Page[*domain.Service]{}, err
 		}
 		services = append(services, s)
 	}
-	return services, nil
+	return serviceListing.Page(services, q), nil
 }
 
-func (r *PostgresServiceRepository) Delete(id uuid.UUID) error {
+func (r *PostgresServiceRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM services WHERE id = $1`
-	result, err := r.db.Exec(context.Background(), query, id)
+	result, err := r.db.Exec(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -73,14 +92,12 @@ func (r *PostgresServiceRepository) Delete(id uuid.UUID) error {
 
 func scanService(row pgx.Row) (*domain.Service, error) {
 	var s domain.Service
-	var price float64
-	err := row.Scan(&s.ID, &s.Name, &s.Description, &price, &s.CreatedAt, &s.UpdatedAt)
+	err := row.Scan(&s.ID, &s.Name, &s.Description, &s.Price, &s.CreatedAt, &s.UpdatedAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, domain.ErrServiceNotFound
 		}
 		return nil, err
 	}
-	s.Price = sharedkernel.Money(price)
 	return &s, nil
 }
@@ -0,0 +1,29 @@
+package infrastructure
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	inventoryInfra "github.com/noggrj/autorepair/internal/inventory/infrastructure"
+	"github.com/noggrj/autorepair/internal/platform/db"
+	"github.com/noggrj/autorepair/internal/service/application"
+)
+
+type PostgresUnitOfWork struct {
+	db db.Connection
+}
+
+func NewPostgresUnitOfWork(db db.Connection) *PostgresUnitOfWork {
+	return &PostgresUnitOfWork{db: db}
+}
+
+func (u *PostgresUnitOfWork) Do(ctx context.Context, fn func(repos application.TxRepositories) error) error {
+	return db.RunInTx(ctx, u.db, func(tx pgx.Tx) error {
+		return fn(application.TxRepositories{
+			Orders:          NewPostgresOrderRepository(tx),
+			Parts:           inventoryInfra.NewPostgresPartRepository(tx),
+			BudgetApprovals: NewPostgresBudgetApprovalRepository(tx),
+			Odometer:        NewPostgresOdometerRepository(tx),
+		})
+	})
+}
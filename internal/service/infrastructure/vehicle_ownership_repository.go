@@ -0,0 +1,89 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/noggrj/autorepair/internal/platform/db"
+	"github.com/noggrj/autorepair/internal/service/domain"
+)
+
+// foreignKeyViolation is the Postgres error code raised when a row points at
+// one that does not exist.
+const foreignKeyViolation = "23503"
+
+type PostgresVehicleOwnershipRepository struct {
+	db db.Connection
+}
+
+func NewPostgresVehicleOwnershipRepository(db db.Connection) *PostgresVehicleOwnershipRepository {
+	return &PostgresVehicleOwnershipRepository{db: db}
+}
+
+// Transfer locks the vehicle row so two transfers of the same vehicle cannot
+// both close the same ownership period.
+func (r *PostgresVehicleOwnershipRepository) Transfer(ctx context.Context, vehicleID, clientID uuid.UUID) (*domain.Vehicle, error) {
+	var vehicle *domain.Vehicle
+	err := db.RunInTx(ctx, r.db, func(tx pgx.Tx) error {
+		var err error
+		vehicle, err = scanVehicle(tx.QueryRow(ctx,
+			`SELECT id, client_id, plate, vin, brand, model, year, mileage, created_at, updated_at FROM vehicles WHERE id = $1 FOR UPDATE`, vehicleID))
+		if err != nil {
+			return err
+		}
+
+		var lastEnded *time.Time
+		if err := tx.QueryRow(ctx, `SELECT MAX(ended_at) FROM vehicle_ownerships WHERE vehicle_id = $1`, vehicleID).Scan(&lastEnded); err != nil {
+			return err
+		}
+		since := vehicle.CreatedAt
+		if lastEnded != nil {
+			since = *lastEnded
+		}
+
+		ownership, err := vehicle.TransferTo(clientID, since)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `INSERT INTO vehicle_ownerships (id, vehicle_id, client_id, started_at, ended_at) VALUES ($1, $2, $3, $4, $5)`,
+			ownership.ID, ownership.VehicleID, ownership.ClientID, ownership.StartedAt, ownership.EndedAt)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `UPDATE vehicles SET client_id = $2, updated_at = $3 WHERE id = $1`, vehicle.ID, vehicle.ClientID, vehicle.UpdatedAt)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+			return domain.ErrClientNotFound
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return vehicle, nil
+}
+
+func (r *PostgresVehicleOwnershipRepository) ListByVehicleID(ctx context.Context, vehicleID uuid.UUID) ([]*domain.VehicleOwnership, error) {
+	query := `SELECT id, vehicle_id, client_id, started_at, ended_at FROM vehicle_ownerships WHERE vehicle_id = $1 ORDER BY ended_at`
+	rows, err := r.db.Query(ctx, query, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ownerships := []*domain.VehicleOwnership{}
+	for rows.Next() {
+		var o domain.VehicleOwnership
+		if err := rows.Scan(&o.ID, &o.VehicleID, &o.ClientID, &o.StartedAt, &o.EndedAt); err != nil {
+			return nil, err
+		}
+		ownerships = append(ownerships, &o)
+	}
+	return ownerships, rows.Err()
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/noggrj/autorepair/internal/platform/db"
 	"github.com/noggrj/autorepair/internal/service/domain"
 	"github.com/noggrj/autorepair/internal/sharedkernel"
@@ -21,29 +22,53 @@ func NewPostgresVehicleRepository(db db.Connection) *PostgresVehicleRepository {
 	return &PostgresVehicleRepository{db: db}
 }
 
-func (r *PostgresVehicleRepository) Save(vehicle *domain.Vehicle) error {
-	query := `INSERT INTO vehicles (id, client_id, plate, brand, model, year, created_at, updated_at)
-	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+// Save leaves mileage alone on update: it only moves through
+// PostgresOdometerRepository.Record. plate_key is the plate's canonical
+// form, so a vehicle cannot be registered again under its other format; the
+// VIN, when known, is unique too.
+func (r *PostgresVehicleRepository) Save(ctx context.Context, vehicle *domain.Vehicle) error {
+	query := `INSERT INTO vehicles (id, client_id, plate, plate_key, vin, brand, model, year, created_at, updated_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 			  ON CONFLICT (id) DO UPDATE SET
 			  plate = EXCLUDED.plate,
+			  plate_key = EXCLUDED.plate_key,
+			  vin = EXCLUDED.vin,
 			  brand = EXCLUDED.brand,
 			  model = EXCLUDED.model,
 			  year = EXCLUDED.year,
 			  updated_at = EXCLUDED.updated_at`
-	_, err := r.db.Exec(context.Background(), query,
-		vehicle.ID, vehicle.ClientID, vehicle.Plate.String(), vehicle.Brand, vehicle.Model, vehicle.Year, vehicle.CreatedAt, vehicle.UpdatedAt)
+	var vin *string
+	if vehicle.VIN != nil {
+		value := vehicle.VIN.String()
+		vin = &value
+	}
+	_, err := r.db.Exec(ctx, query,
+		vehicle.ID, vehicle.ClientID, vehicle.Plate.String(), vehicle.Plate.Canonical(), vin, vehicle.Brand, vehicle.Model, vehicle.Year, vehicle.CreatedAt, vehicle.UpdatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		if pgErr.ConstraintName == "idx_vehicles_vin" {
+			return domain.ErrVehicleVINExists
+		}
+		return domain.ErrVehiclePlateExists
+	}
 	return err
 }
 
-func (r *PostgresVehicleRepository) GetByID(id uuid.UUID) (*domain.Vehicle, error) {
-	query := `SELECT id, client_id, plate, brand, model, year, created_at, updated_at FROM vehicles WHERE id = $1`
-	row := r.db.QueryRow(context.Background(), query, id)
+func (r *PostgresVehicleRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Vehicle, error) {
+	query := `SELECT id, client_id, plate, vin, brand, model, year, mileage, created_at, updated_at FROM vehicles WHERE id = $1`
+	row := r.db.QueryRow(ctx, query, id)
+	return scanVehicle(row)
+}
+
+func (r *PostgresVehicleRepository) GetByPlate(ctx context.Context, plate sharedkernel.PlacaBR) (*domain.Vehicle, error) {
+	query := `SELECT id, client_id, plate, vin, brand, model, year, mileage, created_at, updated_at FROM vehicles WHERE plate_key = $1`
+	row := r.db.QueryRow(ctx, query, plate.Canonical())
 	return scanVehicle(row)
 }
 
-func (r *PostgresVehicleRepository) ListByClientID(clientID uuid.UUID) ([]*domain.Vehicle, error) {
-	query := `SELECT id, client_id, plate, brand, model, year, created_at, updated_at FROM vehicles WHERE client_id = $1`
-	rows, err := r.db.Query(context.Background(), query, clientID)
+func (r *PostgresVehicleRepository) ListByClientID(ctx context.Context, clientID uuid.UUID) ([]*domain.Vehicle, error) {
+	query := `SELECT id, client_id, plate, vin, brand, model, year, mileage, created_at, updated_at FROM vehicles WHERE client_id = $1`
+	rows, err := r.db.Query(ctx, query, clientID)
 	if err != nil {
 		return nil, err
 	}
@@ -60,9 +85,9 @@ func (r *PostgresVehicleRepository) ListByClientID(clientID uuid.UUID) ([]*domai
 	return vehicles, nil
 }
 
-func (r *PostgresVehicleRepository) Delete(id uuid.UUID) error {
+func (r *PostgresVehicleRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM vehicles WHERE id = $1`
-	result, err := r.db.Exec(context.Background(), query, id)
+	result, err := r.db.Exec(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -75,7 +100,8 @@ func (r *PostgresVehicleRepository) Delete(id uuid.UUID) error {
 func scanVehicle(row pgx.Row) (*domain.Vehicle, error) {
 	var v domain.Vehicle
 	var plateStr string
-	err := row.Scan(&v.ID, &v.ClientID, &plateStr, &v.Brand, &v.Model, &v.Year, &v.CreatedAt, &v.UpdatedAt)
+	var vinStr *string
+	err := row.Scan(&v.ID, &v.ClientID, &plateStr, &vinStr, &v.Brand, &v.Model, &v.Year, &v.Mileage, &v.CreatedAt, &v.UpdatedAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, domain.ErrVehicleNotFound
@@ -87,5 +113,12 @@ func scanVehicle(row pgx.Row) (*domain.Vehicle, error) {
 		return nil, err
 	}
 	v.Plate = plate
+	if vinStr != nil {
+		vin, err := sharedkernel.NewVIN(*vinStr)
+		if err != nil {
+			return nil, err
+		}
+		v.VIN = &vin
+	}
 	return &v, nil
 }
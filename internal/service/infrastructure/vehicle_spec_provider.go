@@ -0,0 +1,68 @@
+package infrastructure
+
+import (
+	"context"
+
+	"github.com/noggrj/autorepair/internal/service/domain"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
+)
+
+// DefaultVehicleSpecs names the manufacturer behind the WMIs most seen at
+// the shop. Models are manufacturer-specific and left to the table given to
+// NewTableVehicleSpecProvider.
+var DefaultVehicleSpecs = map[string]domain.VehicleSpec{
+	// Brazil
+	"9BW": {Brand: "Volkswagen"},
+	"9BD": {Brand: "Fiat"},
+	"9BG": {Brand: "Chevrolet"},
+	"9BF": {Brand: "Ford"},
+	"9BR": {Brand: "Toyota"},
+	"9BH": {Brand: "Hyundai"},
+	"9BM": {Brand: "Mercedes-Benz"},
+	"93H": {Brand: "Honda"},
+	"93Y": {Brand: "Renault"},
+	"93X": {Brand: "Mitsubishi"},
+	"935": {Brand: "Citroën"},
+	"936": {Brand: "Peugeot"},
+	"94D": {Brand: "Nissan"},
+	// Argentina
+	"8AP": {Brand: "Fiat"},
+	"8AF": {Brand: "Ford"},
+	"8AG": {Brand: "Chevrolet"},
+	"8AW": {Brand: "Volkswagen"},
+	"8AJ": {Brand: "Toyota"},
+	// Imported
+	"WVW": {Brand: "Volkswagen"},
+	"WAU": {Brand: "Audi"},
+	"WBA": {Brand: "BMW"},
+	"WDD": {Brand: "Mercedes-Benz"},
+	"ZFA": {Brand: "Fiat"},
+	"VF1": {Brand: "Renault"},
+	"VF3": {Brand: "Peugeot"},
+	"JHM": {Brand: "Honda"},
+	"JTD": {Brand: "Toyota"},
+	"KMH": {Brand: "Hyundai"},
+	"1HG": {Brand: "Honda"},
+	"1FA": {Brand: "Ford"},
+}
+
+// TableVehicleSpecProvider decodes VINs offline from a table keyed by VIN
+// prefix. The longest matching prefix wins, so a WMI entry gives the brand
+// and a longer one, such as WMI plus the model characters, the model too.
+type TableVehicleSpecProvider struct {
+	specs map[string]domain.VehicleSpec
+}
+
+func NewTableVehicleSpecProvider(specs map[string]domain.VehicleSpec) *TableVehicleSpecProvider {
+	return &TableVehicleSpecProvider{specs: specs}
+}
+
+func (p *TableVehicleSpecProvider) Lookup(ctx context.Context, vin sharedkernel.VIN) (*domain.VehicleSpec, error) {
+	value := vin.String()
+	for n := len(value); n >= 3; n-- {
+		if spec, ok := p.specs[value[:n]]; ok {
+			return &spec, nil
+		}
+	}
+	return nil, domain.ErrVehicleSpecNotFound
+}
@@ -1,3 +1,226 @@
 package sharedkernel
 
-type Money float64
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidMoney     = errors.New("invalid money amount")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+)
+
+type Currency string
+
+const (
+	CurrencyBRL     Currency = "BRL"
+	DefaultCurrency          = CurrencyBRL
+)
+
+// Money is an exact monetary amount stored as integer cents.
+// The zero value is R$ 0,00. The default currency is kept as an empty
+// field so that values built in different ways compare equal.
+type Money struct {
+	cents    int64
+	currency Currency
+}
+
+func NewMoneyFromCents(cents int64) Money {
+	return Money{cents: cents}
+}
+
+func NewMoneyInCurrency(cents int64, currency Currency) Money {
+	if currency == DefaultCurrency {
+		currency = ""
+	}
+	return Money{cents: cents, currency: currency}
+}
+
+// NewMoneyFromFloat converts a float to Money using its shortest decimal
+// representation, rounding half away from zero to the nearest cent.
+func NewMoneyFromFloat(v float64) Money {
+	m, err := ParseMoney(strconv.FormatFloat(v, 'f', -1, 64))
+	if err != nil {
+		// Only NaN and Inf fail to parse; treat them as zero.
+		return NewMoneyFromCents(0)
+	}
+	return m
+}
+
+// ParseMoney parses a decimal string such as "1234.56" or "-0.5".
+// Digits beyond the second decimal place are rounded half away from zero.
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Money{}, ErrInvalidMoney
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if intPart == "" && fracPart == "" {
+		return Money{}, ErrInvalidMoney
+	}
+	if !isDigits(intPart) || !isDigits(fracPart) {
+		return Money{}, ErrInvalidMoney
+	}
+
+	var units int64
+	if intPart != "" {
+		var err error
+		units, err = strconv.ParseInt(intPart, 10, 64)
+		if err != nil || units > math.MaxInt64/100-1 {
+			return Money{}, ErrInvalidMoney
+		}
+	}
+
+	var frac int64
+	for i := 0; i < 2; i++ {
+		frac *= 10
+		if i < len(fracPart) {
+			frac += int64(fracPart[i] - '0')
+		}
+	}
+	cents := units*100 + frac
+	if len(fracPart) > 2 && fracPart[2] >= '5' {
+		cents++
+	}
+
+	if negative {
+		cents = -cents
+	}
+	return NewMoneyFromCents(cents), nil
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (m Money) Cents() int64 {
+	return m.cents
+}
+
+func (m Money) Currency() Currency {
+	if m.currency == "" {
+		return DefaultCurrency
+	}
+	return m.currency
+}
+
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency() != other.Currency() {
+		return Money{}, ErrCurrencyMismatch
+	}
+	return Money{cents: m.cents + other.cents, currency: m.currency}, nil
+}
+
+func (m Money) Sub(other Money) (Money, error) {
+	if m.Currency() != other.Currency() {
+		return Money{}, ErrCurrencyMismatch
+	}
+	return Money{cents: m.cents - other.cents, currency: m.currency}, nil
+}
+
+// Mul multiplies the amount by an integer quantity; no rounding is involved.
+func (m Money) Mul(qty int64) Money {
+	return Money{cents: m.cents * qty, currency: m.currency}
+}
+
+// Sum adds all values, failing if they are not in the same currency.
+func Sum(values ...Money) (Money, error) {
+	var total Money
+	for i, v := range values {
+		if i == 0 {
+			total = Money{currency: v.currency}
+		}
+		var err error
+		if total, err = total.Add(v); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+func (m Money) IsZero() bool {
+	return m.cents == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.cents < 0
+}
+
+func (m Money) Equal(other Money) bool {
+	return m == other
+}
+
+// Float64 returns an approximate value, for display and metrics only.
+func (m Money) Float64() float64 {
+	return float64(m.cents) / 100
+}
+
+// String returns the amount as a plain decimal with two places, e.g. "1234.50".
+func (m Money) String() string {
+	cents := m.cents
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	frac := strconv.FormatInt(cents%100, 10)
+	if len(frac) == 1 {
+		frac = "0" + frac
+	}
+	return sign + strconv.FormatInt(cents/100, 10) + "." + frac
+}
+
+// MarshalJSON encodes the amount as an exact JSON number, e.g. 1234.50.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts either a JSON number or a decimal string.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*m = NewMoneyFromCents(0)
+		return nil
+	}
+
+	raw := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return ErrInvalidMoney
+		}
+	} else if strings.ContainsAny(raw, "eE") {
+		// Exponent notation is valid JSON but not a plain decimal.
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return ErrInvalidMoney
+		}
+		*m = NewMoneyFromFloat(f)
+		return nil
+	}
+
+	parsed, err := ParseMoney(raw)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
@@ -0,0 +1,84 @@
+package sharedkernel
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ScanNumeric implements pgtype.NumericScanner so DECIMAL columns are read
+// without going through float64. NULL is read as zero.
+func (m *Money) ScanNumeric(n pgtype.Numeric) error {
+	if !n.Valid {
+		*m = NewMoneyFromCents(0)
+		return nil
+	}
+	if n.NaN || n.InfinityModifier != pgtype.Finite {
+		return ErrInvalidMoney
+	}
+
+	cents := new(big.Int).Set(n.Int)
+	exp := n.Exp + 2
+	if exp >= 0 {
+		cents.Mul(cents, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil))
+	} else {
+		// Round half away from zero when the column has more than two decimals.
+		divisor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(-exp)), nil)
+		quo, rem := new(big.Int).QuoRem(cents, divisor, new(big.Int))
+		if new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2)).Cmp(divisor) >= 0 {
+			quo.Add(quo, big.NewInt(int64(cents.Sign())))
+		}
+		cents = quo
+	}
+	if !cents.IsInt64() {
+		return ErrInvalidMoney
+	}
+
+	*m = NewMoneyFromCents(cents.Int64())
+	return nil
+}
+
+// NumericValue implements pgtype.NumericValuer.
+func (m Money) NumericValue() (pgtype.Numeric, error) {
+	return pgtype.Numeric{Int: big.NewInt(m.cents), Exp: -2, Valid: true}, nil
+}
+
+// Scan implements sql.Scanner for drivers that hand over plain Go values.
+func (m *Money) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*m = NewMoneyFromCents(0)
+	case float64:
+		*m = NewMoneyFromFloat(v)
+	case float32:
+		*m = NewMoneyFromFloat(float64(v))
+	case int64:
+		*m = NewMoneyFromCents(v * 100)
+	case int:
+		*m = NewMoneyFromCents(int64(v) * 100)
+	case string:
+		parsed, err := ParseMoney(v)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+	case []byte:
+		parsed, err := ParseMoney(string(v))
+		if err != nil {
+			return err
+		}
+		*m = parsed
+	case pgtype.Numeric:
+		return m.ScanNumeric(v)
+	default:
+		return fmt.Errorf("%w: cannot scan %T", ErrInvalidMoney, src)
+	}
+	return nil
+}
+
+// Value implements driver.Valuer, encoding the amount as an exact decimal string.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
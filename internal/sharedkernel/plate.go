@@ -11,6 +11,21 @@ var (
 	ErrInvalidPlate = errors.New("invalid plate format")
 )
 
+// PlateFormat is the layout of a Brazilian plate.
+type PlateFormat string
+
+const (
+	// PlateFormatLegacy is AAA1234: three letters and four digits.
+	PlateFormatLegacy PlateFormat = "legacy"
+	// PlateFormatMercosul is AAA1A23: the fifth character is a letter.
+	PlateFormatMercosul PlateFormat = "mercosul"
+)
+
+// PlacaBR is a Brazilian plate in upper case, without hyphen. A legacy
+// plate converted to Mercosul keeps its characters except the fifth, whose
+// digit becomes the letter at the same position from A (0 is A, 9 is J).
+// Both forms name the same vehicle; Canonical tells them apart from other
+// plates.
 type PlacaBR struct {
 	value string
 }
@@ -34,6 +49,50 @@ func (p PlacaBR) String() string {
 	return p.value
 }
 
+// Format reports whether the plate is legacy or Mercosul. It is empty for
+// the zero PlacaBR.
+func (p PlacaBR) Format() PlateFormat {
+	if p.value == "" {
+		return ""
+	}
+	if p.value[4] >= 'A' && p.value[4] <= 'Z' {
+		return PlateFormatMercosul
+	}
+	return PlateFormatLegacy
+}
+
+// Mercosul returns the plate in Mercosul format. Every legacy plate has one.
+func (p PlacaBR) Mercosul() PlacaBR {
+	if p.Format() != PlateFormatLegacy {
+		return p
+	}
+	return PlacaBR{value: p.value[:4] + string('A'+p.value[4]-'0') + p.value[5:]}
+}
+
+// Legacy returns the plate in legacy format. Only Mercosul plates whose
+// fifth character is A to J were converted from a legacy one; for the rest
+// ok is false.
+func (p PlacaBR) Legacy() (legacy PlacaBR, ok bool) {
+	if p.Format() == PlateFormatLegacy {
+		return p, true
+	}
+	if p.value == "" || p.value[4] > 'J' {
+		return PlacaBR{}, false
+	}
+	return PlacaBR{value: p.value[:4] + string('0'+p.value[4]-'A') + p.value[5:]}, true
+}
+
+// Canonical is the same for both forms of a plate: its Mercosul form.
+func (p PlacaBR) Canonical() string {
+	return p.Mercosul().value
+}
+
+// Equivalent reports whether p and other are the same plate, in either
+// format.
+func (p PlacaBR) Equivalent(other PlacaBR) bool {
+	return p.Canonical() == other.Canonical()
+}
+
 func (p PlacaBR) MarshalJSON() ([]byte, error) {
 	return json.Marshal(p.value)
 }
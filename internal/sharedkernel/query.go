@@ -0,0 +1,41 @@
+package sharedkernel
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidSort   = errors.New("invalid sort field")
+)
+
+// ListQuery describes which page of a listing to return. Repositories apply
+// the filters that make sense for their entity and ignore the others.
+// The zero value lists everything in the repository's default order.
+type ListQuery struct {
+	// Limit is the page size; zero means no limit.
+	Limit int
+	// Cursor is the NextCursor of the previous page, empty for the first one.
+	Cursor string
+	// Sort is the name of the field to order by; empty uses the repository
+	// default. Desc reverses the order.
+	Sort string
+	Desc bool
+
+	// Search is a free-text filter, matched case-insensitively.
+	Search   string
+	Status   []string
+	ClientID *uuid.UUID
+	// From and To bound the creation date, inclusive and exclusive.
+	From *time.Time
+	To   *time.Time
+}
+
+// Page is one page of a listing. NextCursor is empty on the last page.
+type Page[T any] struct {
+	Items      []T
+	NextCursor string
+}
@@ -0,0 +1,109 @@
+package sharedkernel
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var (
+	ErrInvalidVIN = errors.New("invalid VIN")
+)
+
+// VIN is a vehicle identification number (chassis number) as laid out by
+// ISO 3779: 17 characters, digits and letters except I, O and Q. The first
+// three are the WMI, which names the manufacturer; the ninth is a check digit
+// and the tenth the model year. Only North American VINs are required to
+// carry a valid check digit; elsewhere, as in Brazil and Europe, the ninth
+// character is often a filler such as Z.
+type VIN struct {
+	value string
+}
+
+// vinValues transliterates each allowed character for the check digit.
+var vinValues = map[byte]int{
+	'0': 0, '1': 1, '2': 2, '3': 3, '4': 4, '5': 5, '6': 6, '7': 7, '8': 8, '9': 9,
+	'A': 1, 'B': 2, 'C': 3, 'D': 4, 'E': 5, 'F': 6, 'G': 7, 'H': 8,
+	'J': 1, 'K': 2, 'L': 3, 'M': 4, 'N': 5, 'P': 7, 'R': 9,
+	'S': 2, 'T': 3, 'U': 4, 'V': 5, 'W': 6, 'X': 7, 'Y': 8, 'Z': 9,
+}
+
+var vinWeights = [17]int{8, 7, 6, 5, 4, 3, 2, 10, 0, 9, 8, 7, 6, 5, 4, 3, 2}
+
+// vinYears are the model year codes from 1980 on. They repeat every 30
+// years.
+const vinYears = "ABCDEFGHJKLMNPRSTVWXY123456789"
+
+func NewVIN(vin string) (VIN, error) {
+	clean := strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(vin))
+	if len(clean) != 17 {
+		return VIN{}, ErrInvalidVIN
+	}
+
+	sum := 0
+	for i := 0; i < len(clean); i++ {
+		value, ok := vinValues[clean[i]]
+		if !ok {
+			return VIN{}, ErrInvalidVIN
+		}
+		sum += value * vinWeights[i]
+	}
+	if clean[0] >= '1' && clean[0] <= '5' {
+		check := byte('0' + sum%11)
+		if sum%11 == 10 {
+			check = 'X'
+		}
+		if clean[8] != check {
+			return VIN{}, ErrInvalidVIN
+		}
+	}
+
+	return VIN{value: clean}, nil
+}
+
+func (v VIN) String() string {
+	return v.value
+}
+
+func (v VIN) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.value)
+}
+
+// WMI is the world manufacturer identifier: the first three characters.
+func (v VIN) WMI() string {
+	return v.value[:3]
+}
+
+// Region is the part of the world the manufacturer is registered in, read
+// from the first character of the WMI.
+func (v VIN) Region() string {
+	switch c := v.value[0]; {
+	case c >= 'A' && c <= 'H':
+		return "Africa"
+	case c >= 'J' && c <= 'R':
+		return "Asia"
+	case c >= 'S' && c <= 'Z':
+		return "Europe"
+	case c >= '1' && c <= '5':
+		return "North America"
+	case c == '6' || c == '7':
+		return "Oceania"
+	default:
+		return "South America"
+	}
+}
+
+// ModelYear decodes the tenth character. Codes repeat every 30 years, so it
+// returns the latest year the code stands for that is not past next year.
+// ok is false when the character is not a year code.
+func (v VIN) ModelYear(currentYear int) (year int, ok bool) {
+	i := strings.IndexByte(vinYears, v.value[9])
+	if i < 0 {
+		return 0, false
+	}
+	year = 1980 + i
+	for year+30 <= currentYear+1 {
+		year += 30
+	}
+	return year, true
+}
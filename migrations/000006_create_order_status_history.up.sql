@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS order_status_history (
+    id UUID PRIMARY KEY,
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    from_status VARCHAR(50) NOT NULL,
+    to_status VARCHAR(50) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    occurred_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_status_history_order_id ON order_status_history (order_id, occurred_at);
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id UUID PRIMARY KEY,
+    part_id UUID NOT NULL REFERENCES parts(id),
+    type VARCHAR(30) NOT NULL,
+    quantity INTEGER NOT NULL,
+    reference TEXT NOT NULL DEFAULT '',
+    occurred_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_part_id ON stock_movements (part_id, occurred_at);
+
+-- Opening balance for parts created before the ledger existed
+INSERT INTO stock_movements (id, part_id, type, quantity, reference)
+SELECT gen_random_uuid(), id, 'adjustment', stock_qty, 'opening balance'
+FROM parts
+WHERE stock_qty <> 0;
@@ -0,0 +1,2 @@
+ALTER TABLE parts DROP COLUMN IF EXISTS reorder_quantity;
+ALTER TABLE parts DROP COLUMN IF EXISTS min_quantity;
@@ -0,0 +1,2 @@
+ALTER TABLE parts ADD COLUMN IF NOT EXISTS min_quantity INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE parts ADD COLUMN IF NOT EXISTS reorder_quantity INTEGER NOT NULL DEFAULT 0;
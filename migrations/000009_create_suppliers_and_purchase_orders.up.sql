@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS suppliers (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    document VARCHAR(20) NOT NULL UNIQUE,
+    email VARCHAR(255),
+    phone VARCHAR(50),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS purchase_orders (
+    id UUID PRIMARY KEY,
+    supplier_id UUID NOT NULL REFERENCES suppliers(id) ON DELETE RESTRICT,
+    status VARCHAR(30) NOT NULL, -- draft, sent, partially_received, received
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    sent_at TIMESTAMP WITH TIME ZONE,
+    received_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE IF NOT EXISTS purchase_order_lines (
+    id UUID PRIMARY KEY,
+    purchase_order_id UUID NOT NULL REFERENCES purchase_orders(id) ON DELETE CASCADE,
+    part_id UUID NOT NULL REFERENCES parts(id),
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    received_quantity INTEGER NOT NULL DEFAULT 0 CHECK (received_quantity BETWEEN 0 AND quantity),
+    unit_cost DECIMAL(10, 2) NOT NULL,
+    UNIQUE (purchase_order_id, part_id)
+);
+
+CREATE TABLE IF NOT EXISTS purchase_receipts (
+    id UUID PRIMARY KEY,
+    purchase_order_id UUID NOT NULL REFERENCES purchase_orders(id) ON DELETE CASCADE,
+    line_id UUID NOT NULL REFERENCES purchase_order_lines(id) ON DELETE CASCADE,
+    part_id UUID NOT NULL REFERENCES parts(id),
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    unit_cost DECIMAL(10, 2) NOT NULL,
+    received_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_purchase_receipts_order ON purchase_receipts (purchase_order_id, received_at);
@@ -0,0 +1,9 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS total_cost;
+ALTER TABLE order_items DROP COLUMN IF EXISTS unit_cost;
+
+DROP INDEX IF EXISTS idx_parts_sku;
+ALTER TABLE parts
+    DROP COLUMN IF EXISTS cost_price,
+    DROP COLUMN IF EXISTS manufacturer_code,
+    DROP COLUMN IF EXISTS sku;
+ALTER TABLE parts RENAME COLUMN sale_price TO price;
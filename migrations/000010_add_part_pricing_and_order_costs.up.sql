@@ -0,0 +1,14 @@
+ALTER TABLE parts RENAME COLUMN price TO sale_price;
+ALTER TABLE parts
+    ADD COLUMN IF NOT EXISTS sku VARCHAR(64),
+    ADD COLUMN IF NOT EXISTS manufacturer_code VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS cost_price DECIMAL(10, 2) NOT NULL DEFAULT 0;
+
+-- Existing parts get a placeholder SKU derived from their ID
+UPDATE parts SET sku = 'PART-' || UPPER(SUBSTRING(id::text, 1, 8)) WHERE sku IS NULL;
+
+ALTER TABLE parts ALTER COLUMN sku SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_parts_sku ON parts (sku);
+
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS unit_cost DECIMAL(10, 2) NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS total_cost DECIMAL(10, 2) NOT NULL DEFAULT 0;
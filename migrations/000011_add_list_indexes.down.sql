@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_purchase_orders_created_at_id;
+DROP INDEX IF EXISTS idx_orders_client_id;
+DROP INDEX IF EXISTS idx_orders_status;
+DROP INDEX IF EXISTS idx_orders_created_at_id;
+DROP INDEX IF EXISTS idx_suppliers_name_id;
+DROP INDEX IF EXISTS idx_parts_name_id;
+DROP INDEX IF EXISTS idx_services_name_id;
+DROP INDEX IF EXISTS idx_clients_name_id;
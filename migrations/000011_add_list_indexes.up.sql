@@ -0,0 +1,9 @@
+-- Keyset pagination walks these in (sort key, id) order.
+CREATE INDEX IF NOT EXISTS idx_clients_name_id ON clients (name, id);
+CREATE INDEX IF NOT EXISTS idx_services_name_id ON services (name, id);
+CREATE INDEX IF NOT EXISTS idx_parts_name_id ON parts (name, id);
+CREATE INDEX IF NOT EXISTS idx_suppliers_name_id ON suppliers (name, id);
+CREATE INDEX IF NOT EXISTS idx_orders_created_at_id ON orders (created_at, id);
+CREATE INDEX IF NOT EXISTS idx_orders_status ON orders (status);
+CREATE INDEX IF NOT EXISTS idx_orders_client_id ON orders (client_id);
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_created_at_id ON purchase_orders (created_at, id);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS recovery_codes;
+
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS amr;
+
+ALTER TABLE users DROP COLUMN IF EXISTS totp_last_step;
+ALTER TABLE users DROP COLUMN IF EXISTS mfa_enabled_at;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_secret;
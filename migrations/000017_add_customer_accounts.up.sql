@@ -0,0 +1,3 @@
+-- Portal accounts of clients (role 'customer'), at most one per client
+ALTER TABLE users ADD COLUMN IF NOT EXISTS client_id UUID REFERENCES clients(id) ON DELETE CASCADE;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_client_id ON users (client_id);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS budget_approvals (
+    id UUID PRIMARY KEY,
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    -- Filled in once, when the client answers through the link
+    decided_at TIMESTAMP WITH TIME ZONE,
+    approved BOOLEAN,
+    ip VARCHAR(45),
+    user_agent TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_budget_approvals_order_id ON budget_approvals (order_id);
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS maintenance_reminders;
+DROP TABLE IF EXISTS maintenance_plans;
+DROP TABLE IF EXISTS odometer_readings;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS delivery_mileage;
+ALTER TABLE orders DROP COLUMN IF EXISTS reception_mileage;
+
+ALTER TABLE vehicles DROP COLUMN IF EXISTS mileage;
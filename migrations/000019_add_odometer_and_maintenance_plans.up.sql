@@ -0,0 +1,40 @@
+-- Latest odometer reading; only moves forward
+ALTER TABLE vehicles ADD COLUMN IF NOT EXISTS mileage INTEGER NOT NULL DEFAULT 0;
+
+-- NULL for orders opened before mileage was recorded
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS reception_mileage INTEGER;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS delivery_mileage INTEGER;
+
+CREATE TABLE IF NOT EXISTS odometer_readings (
+    id UUID PRIMARY KEY,
+    vehicle_id UUID NOT NULL REFERENCES vehicles(id) ON DELETE CASCADE,
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    mileage INTEGER NOT NULL CHECK (mileage >= 0),
+    source VARCHAR(20) NOT NULL, -- reception, delivery
+    recorded_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_odometer_readings_vehicle_id ON odometer_readings (vehicle_id, recorded_at);
+
+CREATE TABLE IF NOT EXISTS maintenance_plans (
+    id UUID PRIMARY KEY,
+    service_id UUID NOT NULL UNIQUE REFERENCES services(id) ON DELETE CASCADE,
+    interval_km INTEGER NOT NULL DEFAULT 0,
+    interval_months INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- One reminder per plan and service order: the order is the last time the
+-- service was done on the vehicle, so a new one starts the next cycle.
+CREATE TABLE IF NOT EXISTS maintenance_reminders (
+    id UUID PRIMARY KEY,
+    plan_id UUID NOT NULL REFERENCES maintenance_plans(id) ON DELETE CASCADE,
+    vehicle_id UUID NOT NULL REFERENCES vehicles(id) ON DELETE CASCADE,
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    client_id UUID NOT NULL,
+    due_mileage INTEGER,
+    due_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (plan_id, order_id)
+);
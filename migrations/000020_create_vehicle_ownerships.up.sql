@@ -0,0 +1,10 @@
+-- Past owners of a vehicle; the current one is vehicles.client_id
+CREATE TABLE IF NOT EXISTS vehicle_ownerships (
+    id UUID PRIMARY KEY,
+    vehicle_id UUID NOT NULL REFERENCES vehicles(id) ON DELETE CASCADE,
+    client_id UUID NOT NULL,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ended_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_vehicle_ownerships_vehicle_id ON vehicle_ownerships (vehicle_id, ended_at);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_vehicles_plate_key;
+ALTER TABLE vehicles DROP COLUMN IF EXISTS plate_key;
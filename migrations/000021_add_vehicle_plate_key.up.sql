@@ -0,0 +1,15 @@
+-- Mercosul form of the plate: the same for a vehicle before and after its
+-- legacy plate was converted (the fifth character's digit becomes a letter,
+-- 0 is A, 9 is J). Uniqueness is on this key, not on the raw plate.
+ALTER TABLE vehicles ADD COLUMN IF NOT EXISTS plate_key VARCHAR(20);
+
+UPDATE vehicles SET plate_key = CASE
+    WHEN substr(plate, 5, 1) BETWEEN '0' AND '9'
+        THEN substr(plate, 1, 4) || chr(ascii(substr(plate, 5, 1)) + 17) || substr(plate, 6)
+    ELSE plate
+END;
+
+-- Fails if a vehicle was registered twice, under both forms of its plate;
+-- those must be merged by hand first
+ALTER TABLE vehicles ALTER COLUMN plate_key SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_vehicles_plate_key ON vehicles (plate_key);
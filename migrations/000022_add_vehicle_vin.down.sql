@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_vehicles_vin;
+ALTER TABLE vehicles DROP COLUMN IF EXISTS vin;
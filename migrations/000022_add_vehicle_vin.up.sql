@@ -0,0 +1,4 @@
+-- Optional; several vehicles may have none, but no two share one
+ALTER TABLE vehicles ADD COLUMN IF NOT EXISTS vin VARCHAR(17);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_vehicles_vin ON vehicles (vin);
@@ -0,0 +1,3 @@
+-- Parts are archived rather than deleted, so their stock ledger and the
+-- orders and purchase orders that used them keep pointing at a row
+ALTER TABLE parts ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP WITH TIME ZONE;
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 
 	"github.com/noggrj/autorepair/internal/identity/domain"
@@ -21,13 +22,14 @@ func main() {
 	}
 	defer pool.Close()
 
+	ctx := context.Background()
 	userRepo := infrastructure.NewPostgresUserRepository(pool.Pool)
 
 	adminEmail := "admin@autorepair.com"
 	adminPassword := "admin123"
 
 	// Check if admin exists
-	_, err = userRepo.GetByEmail(adminEmail)
+	_, err = userRepo.GetByEmail(ctx, adminEmail)
 	if err == nil {
 		log.Println("Admin user already exists")
 		return
@@ -38,7 +40,7 @@ func main() {
 		log.Fatal(err)
 	}
 
-	if err := userRepo.Save(user); err != nil {
+	if err := userRepo.Save(ctx, user); err != nil {
 		log.Fatal(err)
 	}
 
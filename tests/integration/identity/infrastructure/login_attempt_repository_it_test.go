@@ -0,0 +1,78 @@
+//go:build integration
+
+package infrastructure_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/identity/domain"
+	"github.com/noggrj/autorepair/internal/identity/infrastructure"
+	"github.com/noggrj/autorepair/internal/platform/db"
+)
+
+func TestPostgresLoginAttemptRepository(t *testing.T) {
+	dbURL := os.Getenv("DB_URL")
+	if dbURL == "" {
+		t.Skip("DB_URL not set")
+	}
+
+	pool, err := db.New(dbURL)
+	if err != nil {
+		t.Fatalf("Failed to connect to DB: %v", err)
+	}
+	defer pool.Close()
+
+	repo := infrastructure.NewPostgresLoginAttemptRepository(pool.Pool)
+	ctx := context.Background()
+	key := "itest_" + uuid.New().String() + "@example.com"
+	defer func() { _ = repo.Reset(ctx, domain.LoginScopeEmail, key) }()
+
+	t.Run("Counts failures within the window", func(t *testing.T) {
+		now := time.Now()
+		for i := 1; i <= 3; i++ {
+			a, err := repo.RecordFailure(ctx, domain.LoginScopeEmail, key, now, time.Minute)
+			if err != nil {
+				t.Fatalf("Failed to record failure: %v", err)
+			}
+			if a.Failures != i {
+				t.Errorf("Expected %d failures, got %d", i, a.Failures)
+			}
+		}
+	})
+
+	t.Run("Lock expiry starts a new run", func(t *testing.T) {
+		now := time.Now()
+		if err := repo.Lock(ctx, domain.LoginScopeEmail, key, now.Add(time.Second)); err != nil {
+			t.Fatalf("Failed to lock: %v", err)
+		}
+		a, err := repo.Get(ctx, domain.LoginScopeEmail, key)
+		if err != nil || a.LockedUntil == nil {
+			t.Fatalf("Expected a lock, got %+v (%v)", a, err)
+		}
+
+		a, err = repo.RecordFailure(ctx, domain.LoginScopeEmail, key, now.Add(2*time.Second), time.Minute)
+		if err != nil {
+			t.Fatalf("Failed to record failure: %v", err)
+		}
+		if a.Failures != 1 || a.LockedUntil != nil {
+			t.Errorf("Expected a fresh unlocked run, got %d failures, locked until %v", a.Failures, a.LockedUntil)
+		}
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		if err := repo.Reset(ctx, domain.LoginScopeEmail, key); err != nil {
+			t.Fatalf("Failed to reset: %v", err)
+		}
+		a, err := repo.Get(ctx, domain.LoginScopeEmail, key)
+		if err != nil {
+			t.Fatalf("Failed to get: %v", err)
+		}
+		if a.Failures != 0 {
+			t.Errorf("Expected no failures, got %d", a.Failures)
+		}
+	})
+}
@@ -0,0 +1,64 @@
+//go:build integration
+
+package infrastructure_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/identity/domain"
+	"github.com/noggrj/autorepair/internal/identity/infrastructure"
+	"github.com/noggrj/autorepair/internal/platform/db"
+)
+
+func TestPostgresRecoveryCodeRepository(t *testing.T) {
+	dbURL := os.Getenv("DB_URL")
+	if dbURL == "" {
+		t.Skip("DB_URL not set")
+	}
+
+	pool, err := db.New(dbURL)
+	if err != nil {
+		t.Fatalf("Failed to connect to DB: %v", err)
+	}
+	defer pool.Close()
+
+	ctx := context.Background()
+	users := infrastructure.NewPostgresUserRepository(pool.Pool)
+	repo := infrastructure.NewPostgresRecoveryCodeRepository(pool.Pool)
+
+	user, _ := domain.NewUser("Test User", "itest_"+uuid.New().String()+"@example.com", "secret123", domain.RoleManager)
+	if err := users.Save(ctx, user); err != nil {
+		t.Fatalf("Failed to save user: %v", err)
+	}
+
+	codes, hashes, _ := domain.NewRecoveryCodes()
+	if err := repo.Replace(ctx, user.ID, hashes); err != nil {
+		t.Fatalf("Failed to store codes: %v", err)
+	}
+
+	used, err := repo.Use(ctx, user.ID, domain.HashRecoveryCode(codes[0]), time.Now())
+	if err != nil || !used {
+		t.Fatalf("Expected the code to be used, got %v (%v)", used, err)
+	}
+	used, _ = repo.Use(ctx, user.ID, domain.HashRecoveryCode(codes[0]), time.Now())
+	if used {
+		t.Error("Expected a code to work only once")
+	}
+	used, _ = repo.Use(ctx, uuid.New(), domain.HashRecoveryCode(codes[1]), time.Now())
+	if used {
+		t.Error("Expected a code to work only for its user")
+	}
+
+	// Replacing with nothing drops every code
+	if err := repo.Replace(ctx, user.ID, nil); err != nil {
+		t.Fatalf("Failed to drop codes: %v", err)
+	}
+	used, _ = repo.Use(ctx, user.ID, domain.HashRecoveryCode(codes[1]), time.Now())
+	if used {
+		t.Error("Expected the dropped codes to be gone")
+	}
+}
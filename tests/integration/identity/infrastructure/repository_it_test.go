@@ -3,13 +3,18 @@
 package infrastructure_test
 
 import (
+	"context"
+	"errors"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/noggrj/autorepair/internal/identity/domain"
 	"github.com/noggrj/autorepair/internal/identity/infrastructure"
 	"github.com/noggrj/autorepair/internal/platform/db"
+	serviceDomain "github.com/noggrj/autorepair/internal/service/domain"
+	serviceInfra "github.com/noggrj/autorepair/internal/service/infrastructure"
 )
 
 func TestPostgresUserRepository(t *testing.T) {
@@ -28,14 +33,14 @@ func TestPostgresUserRepository(t *testing.T) {
 
 	t.Run("Save and GetByEmail", func(t *testing.T) {
 		email := "itest_" + uuid.New().String() + "@example.com"
-		user, _ := domain.NewUser("Test User", email, "secret", domain.RoleEmployee)
+		user, _ := domain.NewUser("Test User", email, "secret123", domain.RoleEmployee)
 
-		err := repo.Save(user)
+		err := repo.Save(context.Background(), user)
 		if err != nil {
 			t.Fatalf("Failed to save user: %v", err)
 		}
 
-		fetched, err := repo.GetByEmail(email)
+		fetched, err := repo.GetByEmail(context.Background(), email)
 		if err != nil {
 			t.Fatalf("Failed to get user: %v", err)
 		}
@@ -45,7 +50,7 @@ func TestPostgresUserRepository(t *testing.T) {
 		}
 
 		// Test GetByID
-		fetchedByID, err := repo.GetByID(user.ID)
+		fetchedByID, err := repo.GetByID(context.Background(), user.ID)
 		if err != nil {
 			t.Fatalf("Failed to get user by ID: %v", err)
 		}
@@ -55,7 +60,7 @@ func TestPostgresUserRepository(t *testing.T) {
 	})
 
 	t.Run("GetByEmail Not Found", func(t *testing.T) {
-		_, err := repo.GetByEmail("nonexistent@example.com")
+		_, err := repo.GetByEmail(context.Background(), "nonexistent@example.com")
 		if err == nil {
 			t.Error("Expected error, got nil")
 		}
@@ -65,7 +70,7 @@ func TestPostgresUserRepository(t *testing.T) {
 	})
 
 	t.Run("GetByID Not Found", func(t *testing.T) {
-		_, err := repo.GetByID(uuid.New())
+		_, err := repo.GetByID(context.Background(), uuid.New())
 		if err == nil {
 			t.Error("Expected error, got nil")
 		}
@@ -76,17 +81,96 @@ func TestPostgresUserRepository(t *testing.T) {
 
 	t.Run("Save Duplicate Email", func(t *testing.T) {
 		email := "duplicate_" + uuid.New().String() + "@example.com"
-		user1, _ := domain.NewUser("User 1", email, "secret", domain.RoleEmployee)
-		user2, _ := domain.NewUser("User 2", email, "secret", domain.RoleEmployee)
+		user1, _ := domain.NewUser("User 1", email, "secret123", domain.RoleEmployee)
+		user2, _ := domain.NewUser("User 2", email, "secret123", domain.RoleEmployee)
 
-		err := repo.Save(user1)
+		err := repo.Save(context.Background(), user1)
 		if err != nil {
 			t.Fatalf("Failed to save user 1: %v", err)
 		}
 
-		err = repo.Save(user2)
+		err = repo.Save(context.Background(), user2)
 		if err == nil {
 			t.Error("Expected error due to duplicate email, got nil")
 		}
 	})
+
+	t.Run("Customer Account", func(t *testing.T) {
+		client, _ := serviceDomain.NewClient("Portal Client", "529.982.247-25", "portal@example.com", "11999999999")
+		clients := serviceInfra.NewPostgresClientRepository(pool.Pool)
+		if err := clients.Save(context.Background(), client); err != nil {
+			t.Fatalf("Failed to save client: %v", err)
+		}
+		// The document is unique; deleting the client also deletes its account.
+		defer func() { _ = clients.Delete(context.Background(), client.ID) }()
+
+		user, _ := domain.NewCustomer(client.ID, client.Name, "portal_"+uuid.New().String()+"@example.com")
+		if err := repo.Save(context.Background(), user); err != nil {
+			t.Fatalf("Failed to save customer: %v", err)
+		}
+		fetched, err := repo.GetByID(context.Background(), user.ID)
+		if err != nil {
+			t.Fatalf("Failed to get customer: %v", err)
+		}
+		if !fetched.IsCustomer() || *fetched.ClientID != client.ID {
+			t.Errorf("Expected a customer linked to %v, got %v linked to %v", client.ID, fetched.Role, fetched.ClientID)
+		}
+
+		second, _ := domain.NewCustomer(client.ID, client.Name, "portal_"+uuid.New().String()+"@example.com")
+		if err := repo.Save(context.Background(), second); !errors.Is(err, domain.ErrClientAccountExists) {
+			t.Errorf("Expected ErrClientAccountExists, got %v", err)
+		}
+	})
+}
+
+func TestPostgresRefreshTokenRepository(t *testing.T) {
+	dbURL := os.Getenv("DB_URL")
+	if dbURL == "" {
+		t.Skip("DB_URL not set")
+	}
+
+	pool, err := db.New(dbURL)
+	if err != nil {
+		t.Fatalf("Failed to connect to DB: %v", err)
+	}
+	defer pool.Close()
+
+	users := infrastructure.NewPostgresUserRepository(pool.Pool)
+	repo := infrastructure.NewPostgresRefreshTokenRepository(pool.Pool)
+	ctx := context.Background()
+
+	user, _ := domain.NewUser("Token User", "itest_"+uuid.New().String()+"@example.com", "secret123", domain.RoleEmployee)
+	if err := users.Save(ctx, user); err != nil {
+		t.Fatalf("Failed to save user: %v", err)
+	}
+
+	familyID := uuid.New()
+	token := domain.NewRefreshToken(uuid.New(), user.ID, familyID, "itest.refresh.token", "itest", nil, time.Now().Add(time.Hour))
+	if err := repo.Save(ctx, token); err != nil {
+		t.Fatalf("Failed to save refresh token: %v", err)
+	}
+
+	t.Run("Rotate Once", func(t *testing.T) {
+		rotated, err := repo.MarkUsed(ctx, token.ID, time.Now())
+		if err != nil || !rotated {
+			t.Fatalf("Expected first rotation to succeed, got %v, %v", rotated, err)
+		}
+		rotated, err = repo.MarkUsed(ctx, token.ID, time.Now())
+		if err != nil || rotated {
+			t.Fatalf("Expected second rotation to fail, got %v, %v", rotated, err)
+		}
+	})
+
+	t.Run("Revoke User", func(t *testing.T) {
+		if err := repo.RevokeUser(ctx, user.ID, time.Now()); err != nil {
+			t.Fatalf("Failed to revoke: %v", err)
+		}
+		fetched, err := repo.GetByID(ctx, token.ID)
+		if err != nil {
+			t.Fatalf("Failed to get refresh token: %v", err)
+		}
+		if fetched.RevokedAt == nil || !fetched.Matches("itest.refresh.token") {
+			t.Errorf("Expected a revoked record for the token, got %+v", fetched)
+		}
+	})
 }
@@ -6,12 +6,14 @@ import (
 	"context"
 	"math/rand"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	inventoryDomain "github.com/noggrj/autorepair/internal/inventory/domain"
 	"github.com/noggrj/autorepair/internal/inventory/infrastructure"
 	"github.com/noggrj/autorepair/internal/platform/db"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -47,7 +49,7 @@ func TestPostgresPartRepository(t *testing.T) {
 
 	// Create
 	partName := "Test Part " + randomString(5)
-	part, _ := inventoryDomain.NewPart(partName, "Desc", 50, 100.0) // name, desc, qty, price
+	part, _ := inventoryDomain.NewPart("IT-"+randomString(8), partName, "Desc", 50, sharedkernel.NewMoneyFromFloat(60.0), sharedkernel.NewMoneyFromFloat(100.0)) // sku, name, desc, qty, cost, price
 	err = repo.Save(context.Background(), part)
 	assert.NoError(t, err)
 
@@ -58,20 +60,57 @@ func TestPostgresPartRepository(t *testing.T) {
 		assert.Equal(t, part.Name, fetched.Name)
 	}
 
+	// GetBySKU ignores case
+	bySKU, err := repo.GetBySKU(context.Background(), strings.ToLower(part.SKU))
+	assert.NoError(t, err)
+	if bySKU != nil {
+		assert.Equal(t, part.ID, bySKU.ID)
+	}
+
+	// SKU is unique
+	clash, _ := inventoryDomain.NewPart(part.SKU, "Clash", "Desc", 0, sharedkernel.NewMoneyFromFloat(1.0), sharedkernel.NewMoneyFromFloat(2.0))
+	err = repo.Save(context.Background(), clash)
+	assert.ErrorIs(t, err, inventoryDomain.ErrDuplicateSKU)
+
 	// List
-	list, err := repo.List(context.Background())
+	page, err := repo.List(context.Background(), sharedkernel.ListQuery{Search: part.SKU})
 	assert.NoError(t, err)
-	assert.NotEmpty(t, list)
+	if assert.Len(t, page.Items, 1) {
+		assert.Equal(t, part.ID, page.Items[0].ID)
+	}
 
 	// Decrease Stock (using domain method and update)
-	fetched.RemoveStock(10)
+	err = fetched.RemoveStock(10, inventoryDomain.MovementOrderConsumption, "order:it")
+	assert.NoError(t, err)
 	err = repo.Update(context.Background(), fetched)
 	assert.NoError(t, err)
 
 	fetchedAfter, _ := repo.GetByID(context.Background(), part.ID)
 	assert.Equal(t, 40, fetchedAfter.Quantity)
 
+	// Ledger matches the stored quantity
+	movements, err := repo.ListMovements(context.Background(), part.ID)
+	assert.NoError(t, err)
+	assert.Len(t, movements, 2)
+	ledger, err := repo.LedgerQuantities(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 40, ledger[part.ID])
+
 	// Insufficient Stock
-	err = fetchedAfter.RemoveStock(100)
+	err = fetchedAfter.RemoveStock(100, inventoryDomain.MovementOrderConsumption, "order:it")
 	assert.ErrorIs(t, err, inventoryDomain.ErrInsufficientStock)
+
+	// Archiving hides the part but keeps its ledger
+	assert.NoError(t, repo.Archive(context.Background(), part.ID))
+	_, err = repo.GetByID(context.Background(), part.ID)
+	assert.ErrorIs(t, err, inventoryDomain.ErrPartNotFound)
+	_, err = repo.GetBySKU(context.Background(), part.SKU)
+	assert.ErrorIs(t, err, inventoryDomain.ErrPartNotFound)
+	page, err = repo.List(context.Background(), sharedkernel.ListQuery{Search: part.SKU})
+	assert.NoError(t, err)
+	assert.Empty(t, page.Items)
+	movements, err = repo.ListMovements(context.Background(), part.ID)
+	assert.NoError(t, err)
+	assert.Len(t, movements, 2)
+	assert.ErrorIs(t, repo.Archive(context.Background(), part.ID), inventoryDomain.ErrPartNotFound)
 }
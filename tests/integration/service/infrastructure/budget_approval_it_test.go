@@ -0,0 +1,99 @@
+//go:build integration
+
+package infrastructure_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/platform/db"
+	serviceDomain "github.com/noggrj/autorepair/internal/service/domain"
+	"github.com/noggrj/autorepair/internal/service/infrastructure"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
+)
+
+func TestBudgetApprovalRepository(t *testing.T) {
+	dbURL := os.Getenv("DB_URL")
+	if dbURL == "" {
+		t.Skip("DB_URL not set")
+	}
+
+	pool, err := db.New(dbURL)
+	if err != nil {
+		t.Fatalf("Failed to connect to DB: %v", err)
+	}
+	defer pool.Close()
+
+	ctx := context.Background()
+	clientRepo := infrastructure.NewPostgresClientRepository(pool.Pool)
+	vehicleRepo := infrastructure.NewPostgresVehicleRepository(pool.Pool)
+	orderRepo := infrastructure.NewPostgresOrderRepository(pool.Pool)
+	repo := infrastructure.NewPostgresBudgetApprovalRepository(pool.Pool)
+
+	client, _ := serviceDomain.NewClient("Budget Client", "12345678909", "budget@test.com", "123")
+	doc, _ := sharedkernel.NewDocumentoBR("3" + time.Now().Format("0405000000"))
+	client.Document = doc
+	if err := clientRepo.Save(ctx, client); err != nil {
+		t.Fatalf("Failed to save client: %v", err)
+	}
+
+	vehicle, _ := serviceDomain.NewVehicle(client.ID, "ABC1234", "Brand", "Model", 2020)
+	plate, _ := sharedkernel.NewPlacaBR("BGT" + time.Now().Format("0405"))
+	vehicle.Plate = plate
+	if err := vehicleRepo.Save(ctx, vehicle); err != nil {
+		t.Fatalf("Failed to save vehicle: %v", err)
+	}
+
+	order, _ := serviceDomain.NewOrder(client.ID, vehicle.ID, 0)
+	if err := orderRepo.Save(ctx, order); err != nil {
+		t.Fatalf("Failed to save order: %v", err)
+	}
+
+	first := serviceDomain.NewBudgetApproval(order.ID)
+	if err := repo.Save(ctx, first); err != nil {
+		t.Fatalf("Failed to save approval: %v", err)
+	}
+
+	// Sending the budget again revokes the first link
+	if err := repo.RevokeOrder(ctx, order.ID, time.Now()); err != nil {
+		t.Fatalf("Failed to revoke approvals: %v", err)
+	}
+	second := serviceDomain.NewBudgetApproval(order.ID)
+	if err := repo.Save(ctx, second); err != nil {
+		t.Fatalf("Failed to save approval: %v", err)
+	}
+
+	decision := serviceDomain.BudgetDecision{Approved: true, IP: "203.0.113.7", UserAgent: "it-test", DecidedAt: time.Now()}
+	if decided, err := repo.Decide(ctx, first.ID, decision); err != nil || decided {
+		t.Errorf("Expected revoked link to refuse a decision, got %v, %v", decided, err)
+	}
+	if decided, err := repo.Decide(ctx, second.ID, decision); err != nil || !decided {
+		t.Fatalf("Expected decision to be recorded, got %v, %v", decided, err)
+	}
+	if decided, _ := repo.Decide(ctx, second.ID, decision); decided {
+		t.Error("Expected a link to be answered only once")
+	}
+
+	got, err := repo.GetByID(ctx, second.ID)
+	if err != nil {
+		t.Fatalf("Failed to get approval: %v", err)
+	}
+	if got.Decision == nil || !got.Decision.Approved || got.Decision.IP != "203.0.113.7" || got.Decision.UserAgent != "it-test" {
+		t.Errorf("Expected decision evidence to be stored, got %+v", got.Decision)
+	}
+
+	approvals, err := repo.ListByOrderID(ctx, order.ID)
+	if err != nil {
+		t.Fatalf("Failed to list approvals: %v", err)
+	}
+	if len(approvals) != 2 || approvals[0].RevokedAt == nil || approvals[1].RevokedAt != nil {
+		t.Errorf("Expected the revoked link then the answered one, got %+v", approvals)
+	}
+
+	if _, err := repo.GetByID(ctx, uuid.New()); err != serviceDomain.ErrBudgetApprovalNotFound {
+		t.Errorf("Expected ErrBudgetApprovalNotFound, got %v", err)
+	}
+}
@@ -44,7 +44,7 @@ func TestFullOrderLifecycleE2E(t *testing.T) {
 	// Services
 	notifier := &notificationInfra.MockEmailService{}
 	notifier.On("SendEmail", mock.Anything, mock.Anything, mock.Anything).Return(nil)
-	orderService := serviceApplication.NewOrderService(orderRepo, partRepo, clientRepo, notifier)
+	orderService := serviceApplication.NewOrderService(orderRepo, partRepo, clientRepo, notifier, infrastructure.NewPostgresUnitOfWork(pool.Pool), nil, nil)
 
 	// 1. Setup Data
 	// Client
@@ -54,7 +54,7 @@ func TestFullOrderLifecycleE2E(t *testing.T) {
 	doc, _ := sharedkernel.NewDocumentoBR("3" + docSuffix)
 	client.Document = doc
 	client.ID = clientID
-	clientRepo.Save(client)
+	clientRepo.Save(context.Background(), client)
 
 	// Vehicle
 	vehicleID := uuid.New()
@@ -63,38 +63,38 @@ func TestFullOrderLifecycleE2E(t *testing.T) {
 	plate, _ := sharedkernel.NewPlacaBR("EZE" + plateSuffix)
 	vehicle.Plate = plate
 	vehicle.ID = vehicleID
-	vehicleRepo.Save(vehicle)
+	vehicleRepo.Save(context.Background(), vehicle)
 
 	// Service
 	svcID := uuid.New()
-	svc, _ := serviceDomain.NewService("E2E Service", "Desc", 100.0)
+	svc, _ := serviceDomain.NewService("E2E Service", "Desc", sharedkernel.NewMoneyFromFloat(100.0))
 	svc.ID = svcID
-	serviceRepo.Save(svc)
+	serviceRepo.Save(context.Background(), svc)
 
 	// Part
 	partID := uuid.New()
-	part, _ := inventoryDomain.NewPart("E2E Part", "Desc", 10, 50.0) // qty, price
+	part, _ := inventoryDomain.NewPart("E2E-"+uuid.NewString()[:8], "E2E Part", "Desc", 10, sharedkernel.NewMoneyFromFloat(30.0), sharedkernel.NewMoneyFromFloat(50.0)) // qty, cost, price
 	part.ID = partID
 	partRepo.Save(context.Background(), part)
 
 	// 2. Create Order
-	order, _ := serviceDomain.NewOrder(clientID, vehicleID)
-	order.AddItem(svcID, serviceDomain.ItemTypeService, svc.Name, 1, float64(svc.Price))
-	order.AddItem(partID, serviceDomain.ItemTypePart, part.Name, 2, float64(part.Price)) // 2 * 50 = 100
+	order, _ := serviceDomain.NewOrder(clientID, vehicleID, 0)
+	order.AddItem(svcID, serviceDomain.ItemTypeService, svc.Name, 1, svc.Price, sharedkernel.Money{})
+	order.AddItem(partID, serviceDomain.ItemTypePart, part.Name, 2, part.SalePrice, part.CostPrice) // 2 * 50 = 100
 	// Total should be 100 + 100 = 200
 
-	if err := orderRepo.Save(order); err != nil {
+	if err := orderRepo.Save(context.Background(), order); err != nil {
 		t.Fatalf("Failed to save order: %v", err)
 	}
 
 	// 3. Approve Order
-	err = orderService.ApproveOrder(order.ID)
+	err = orderService.ApproveOrder(context.Background(), order.ID, "tester", "")
 	if err != nil {
 		t.Fatalf("Failed to approve order: %v", err)
 	}
 
 	// 4. Verify Status and Stock
-	updatedOrder, _ := orderRepo.GetByID(order.ID)
+	updatedOrder, _ := orderRepo.GetByID(context.Background(), order.ID)
 	if updatedOrder.Status != serviceDomain.OrderStatusInExecution {
 		t.Errorf("Order status mismatch: %v", updatedOrder.Status)
 	}
@@ -105,18 +105,19 @@ func TestFullOrderLifecycleE2E(t *testing.T) {
 	}
 
 	// 5. Update Status to Completed
-	err = orderService.UpdateStatus(order.ID, serviceDomain.OrderStatusCompleted)
+	err = orderService.UpdateStatus(context.Background(), order.ID, serviceDomain.OrderStatusCompleted, "tester", "")
 	if err != nil {
 		t.Fatalf("Failed to update status: %v", err)
 	}
 
 	// 6. Verify Reporting (Revenue)
 	// We can reuse the List() method on repo to simulate reporting aggregation
-	orders, _ := orderRepo.List()
+	page, _ := orderRepo.List(context.Background(), sharedkernel.ListQuery{})
+	orders := page.Items
 	var totalRevenue float64
 	var found bool
 	for _, o := range orders {
-		totalRevenue += float64(o.Total)
+		totalRevenue += o.Total.Float64()
 		if o.ID == order.ID {
 			found = true
 		}
@@ -0,0 +1,133 @@
+//go:build integration
+
+package infrastructure_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	notificationInfra "github.com/noggrj/autorepair/internal/notification/infrastructure"
+	"github.com/noggrj/autorepair/internal/platform/db"
+	serviceApplication "github.com/noggrj/autorepair/internal/service/application"
+	serviceDomain "github.com/noggrj/autorepair/internal/service/domain"
+	"github.com/noggrj/autorepair/internal/service/infrastructure"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestOdometerAndMaintenanceReminders(t *testing.T) {
+	dbURL := os.Getenv("DB_URL")
+	if dbURL == "" {
+		t.Skip("DB_URL not set")
+	}
+
+	pool, err := db.New(dbURL)
+	if err != nil {
+		t.Fatalf("Failed to connect to DB: %v", err)
+	}
+	defer pool.Close()
+
+	ctx := context.Background()
+	clientRepo := infrastructure.NewPostgresClientRepository(pool.Pool)
+	vehicleRepo := infrastructure.NewPostgresVehicleRepository(pool.Pool)
+	orderRepo := infrastructure.NewPostgresOrderRepository(pool.Pool)
+	serviceRepo := infrastructure.NewPostgresServiceRepository(pool.Pool)
+	odometerRepo := infrastructure.NewPostgresOdometerRepository(pool.Pool)
+	planRepo := infrastructure.NewPostgresMaintenancePlanRepository(pool.Pool)
+	reminderRepo := infrastructure.NewPostgresMaintenanceReminderRepository(pool.Pool)
+	notifier := &notificationInfra.MockEmailService{}
+	notifier.On("SendEmail", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	orderService := serviceApplication.NewOrderService(orderRepo, nil, clientRepo, notifier, infrastructure.NewPostgresUnitOfWork(pool.Pool), nil, nil)
+
+	client, _ := serviceDomain.NewClient("Odometer Client", "12345678909", "odometer@test.com", "123")
+	doc, _ := sharedkernel.NewDocumentoBR("4" + time.Now().Format("0405000000"))
+	client.Document = doc
+	if err := clientRepo.Save(ctx, client); err != nil {
+		t.Fatalf("Failed to save client: %v", err)
+	}
+	vehicle, _ := serviceDomain.NewVehicle(client.ID, "ABC1234", "Brand", "Model", 2020)
+	plate, _ := sharedkernel.NewPlacaBR("ODO" + time.Now().Format("0405"))
+	vehicle.Plate = plate
+	if err := vehicleRepo.Save(ctx, vehicle); err != nil {
+		t.Fatalf("Failed to save vehicle: %v", err)
+	}
+	svc, _ := serviceDomain.NewService("Oil change "+time.Now().Format("150405.000"), "Desc", sharedkernel.NewMoneyFromFloat(120.0))
+	if err := serviceRepo.Save(ctx, svc); err != nil {
+		t.Fatalf("Failed to save service: %v", err)
+	}
+
+	// Reception reading advances the vehicle's mileage
+	order, _ := serviceDomain.NewOrder(client.ID, vehicle.ID, 40000)
+	_ = order.AddItem(svc.ID, serviceDomain.ItemTypeService, svc.Name, 1, svc.Price, sharedkernel.Money{})
+	if err := orderService.Receive(ctx, order); err != nil {
+		t.Fatalf("Failed to receive order: %v", err)
+	}
+	if got, _ := vehicleRepo.GetByID(ctx, vehicle.ID); got.Mileage != 40000 {
+		t.Errorf("Expected vehicle mileage 40000, got %d", got.Mileage)
+	}
+
+	// A lower reading is refused and leaves no order behind
+	backwards, _ := serviceDomain.NewOrder(client.ID, vehicle.ID, 39000)
+	if err := orderService.Receive(ctx, backwards); err != serviceDomain.ErrMileageDecreased {
+		t.Errorf("Expected ErrMileageDecreased, got %v", err)
+	}
+	if _, err := orderRepo.GetByID(ctx, backwards.ID); err != serviceDomain.ErrOrderNotFound {
+		t.Errorf("Expected the refused order to be rolled back, got %v", err)
+	}
+
+	for _, status := range []serviceDomain.OrderStatus{serviceDomain.OrderStatusInExecution, serviceDomain.OrderStatusCompleted} {
+		if err := order.Transition(status, "it-test", ""); err != nil {
+			t.Fatalf("Failed to move order to %s: %v", status, err)
+		}
+	}
+	if err := orderRepo.Save(ctx, order); err != nil {
+		t.Fatalf("Failed to save order: %v", err)
+	}
+	delivery := 40010
+	if err := orderService.DeliverOrder(ctx, order.ID, &delivery, "it-test", ""); err != nil {
+		t.Fatalf("Failed to deliver order: %v", err)
+	}
+
+	readings, err := odometerRepo.ListByVehicleID(ctx, vehicle.ID)
+	if err != nil {
+		t.Fatalf("Failed to list readings: %v", err)
+	}
+	if len(readings) != 2 || readings[1].Source != serviceDomain.OdometerSourceDelivery || readings[1].Mileage != 40010 {
+		t.Errorf("Expected reception then delivery readings, got %+v", readings)
+	}
+
+	// The service is due once the vehicle has run the plan's interval
+	plan, _ := serviceDomain.NewMaintenancePlan(svc.ID, 10000, 12)
+	if err := planRepo.Save(ctx, plan); err != nil {
+		t.Fatalf("Failed to save plan: %v", err)
+	}
+	again, _ := serviceDomain.NewMaintenancePlan(svc.ID, 5000, 0)
+	if err := planRepo.Save(ctx, again); err != serviceDomain.ErrMaintenancePlanExists {
+		t.Errorf("Expected ErrMaintenancePlanExists, got %v", err)
+	}
+	later, _ := serviceDomain.NewOdometerReading(vehicle.ID, order.ID, 50100, serviceDomain.OdometerSourceDelivery)
+	if err := odometerRepo.Record(ctx, later); err != nil {
+		t.Fatalf("Failed to record reading: %v", err)
+	}
+
+	maintenance := serviceApplication.NewMaintenanceService(planRepo, reminderRepo, serviceRepo, clientRepo, notifier)
+	sent, err := maintenance.SendReminders(ctx, time.Now())
+	if err != nil || sent < 1 {
+		t.Fatalf("Expected a reminder to be sent, got %d, %v", sent, err)
+	}
+	candidates, err := reminderRepo.ListCandidates(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list candidates: %v", err)
+	}
+	for _, c := range candidates {
+		if c.Plan.ID == plan.ID && c.VehicleID == vehicle.ID {
+			t.Error("Expected a reminded service order not to be a candidate again")
+		}
+	}
+
+	if err := planRepo.Delete(ctx, plan.ID); err != nil {
+		t.Errorf("Failed to delete plan: %v", err)
+	}
+}
@@ -42,7 +42,7 @@ func TestOrderApprovalFlow(t *testing.T) {
 	// App Service
 	notifier := &notificationInfra.MockEmailService{}
 	notifier.On("SendEmail", mock.Anything, mock.Anything, mock.Anything).Return(nil)
-	orderService := serviceApplication.NewOrderService(orderRepo, partRepo, clientRepo, notifier)
+	orderService := serviceApplication.NewOrderService(orderRepo, partRepo, clientRepo, notifier, infrastructure.NewPostgresUnitOfWork(pool.Pool), nil, nil)
 
 	// 1. Create Dependencies
 	clientID := uuid.New()
@@ -52,7 +52,7 @@ func TestOrderApprovalFlow(t *testing.T) {
 	doc, _ := sharedkernel.NewDocumentoBR(randomDoc)
 	client.Document = doc
 	client.ID = clientID
-	if err := clientRepo.Save(client); err != nil {
+	if err := clientRepo.Save(context.Background(), client); err != nil {
 		t.Fatalf("Failed to save client: %v", err)
 	}
 
@@ -63,37 +63,37 @@ func TestOrderApprovalFlow(t *testing.T) {
 	plate, _ := sharedkernel.NewPlacaBR(plateStr)
 	vehicle.Plate = plate
 	vehicle.ID = vehicleID
-	if err := vehicleRepo.Save(vehicle); err != nil {
+	if err := vehicleRepo.Save(context.Background(), vehicle); err != nil {
 		t.Fatalf("Failed to save vehicle: %v", err)
 	}
 
 	// 2. Create Part with Stock
 	partID := uuid.New()
-	part, _ := inventoryDomain.NewPart("Test Part", "Desc", 10, 50.0) // 10 in stock, args: qty, price
+	part, _ := inventoryDomain.NewPart("IT-"+uuid.NewString()[:8], "Test Part", "Desc", 10, sharedkernel.NewMoneyFromFloat(30.0), sharedkernel.NewMoneyFromFloat(50.0)) // 10 in stock, args: qty, cost, price
 	part.ID = partID
 	if err := partRepo.Save(context.Background(), part); err != nil {
 		t.Fatalf("Failed to save part: %v", err)
 	}
 
 	// 3. Create Order
-	order, _ := serviceDomain.NewOrder(clientID, vehicleID)
+	order, _ := serviceDomain.NewOrder(clientID, vehicleID, 0)
 	// Add 5 parts (Stock 10 -> 5)
-	err = order.AddItem(partID, serviceDomain.ItemTypePart, part.Name, 5, 50.0)
+	err = order.AddItem(partID, serviceDomain.ItemTypePart, part.Name, 5, part.SalePrice, part.CostPrice)
 	if err != nil {
 		t.Fatalf("Failed to add item: %v", err)
 	}
-	if err := orderRepo.Save(order); err != nil {
+	if err := orderRepo.Save(context.Background(), order); err != nil {
 		t.Fatalf("Failed to save order: %v", err)
 	}
 
 	// 4. Approve Order
-	err = orderService.ApproveOrder(order.ID)
+	err = orderService.ApproveOrder(context.Background(), order.ID, "tester", "")
 	if err != nil {
 		t.Fatalf("Failed to approve order: %v", err)
 	}
 
 	// 5. Verify Order Status
-	updatedOrder, _ := orderRepo.GetByID(order.ID)
+	updatedOrder, _ := orderRepo.GetByID(context.Background(), order.ID)
 	if updatedOrder.Status != serviceDomain.OrderStatusInExecution {
 		t.Errorf("Expected InExecution, got %v", updatedOrder.Status)
 	}
@@ -105,18 +105,18 @@ func TestOrderApprovalFlow(t *testing.T) {
 	}
 
 	// 7. Try to approve again (should fail)
-	err = orderService.ApproveOrder(order.ID)
+	err = orderService.ApproveOrder(context.Background(), order.ID, "tester", "")
 	if err == nil {
 		t.Error("Expected error approving already approved order")
 	}
 
 	// 8. Test Insufficient Stock
 	// Create another order for 6 parts (Stock is 5)
-	order2, _ := serviceDomain.NewOrder(clientID, vehicleID)
-	order2.AddItem(partID, serviceDomain.ItemTypePart, part.Name, 6, 50.0)
-	orderRepo.Save(order2)
+	order2, _ := serviceDomain.NewOrder(clientID, vehicleID, 0)
+	order2.AddItem(partID, serviceDomain.ItemTypePart, part.Name, 6, part.SalePrice, part.CostPrice)
+	orderRepo.Save(context.Background(), order2)
 
-	err = orderService.ApproveOrder(order2.ID)
+	err = orderService.ApproveOrder(context.Background(), order2.ID, "tester", "")
 	if err == nil {
 		t.Error("Expected insufficient stock error")
 	}
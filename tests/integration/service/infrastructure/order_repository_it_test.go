@@ -3,15 +3,16 @@
 package infrastructure_test
 
 import (
+	"context"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/noggrj/autorepair/internal/platform/db"
 	serviceDomain "github.com/noggrj/autorepair/internal/service/domain"
 	"github.com/noggrj/autorepair/internal/service/infrastructure"
 	"github.com/noggrj/autorepair/internal/sharedkernel"
-	"github.com/google/uuid"
 )
 
 func TestPostgresOrderRepository(t *testing.T) {
@@ -53,7 +54,7 @@ func TestPostgresOrderRepository(t *testing.T) {
 	}
 	client.Document = doc
 	client.ID = clientID
-	if err := clientRepo.Save(client); err != nil {
+	if err := clientRepo.Save(context.Background(), client); err != nil {
 		t.Fatalf("Failed to save client: %v", err)
 	}
 
@@ -73,28 +74,28 @@ func TestPostgresOrderRepository(t *testing.T) {
 	plate, _ := sharedkernel.NewPlacaBR(plateStr)
 	vehicle.Plate = plate
 	vehicle.ID = vehicleID
-	if err := vehicleRepo.Save(vehicle); err != nil {
+	if err := vehicleRepo.Save(context.Background(), vehicle); err != nil {
 		t.Fatalf("Failed to save vehicle: %v", err)
 	}
 
 	t.Run("Create and Get Order", func(t *testing.T) {
-		order, _ := serviceDomain.NewOrder(clientID, vehicleID)
+		order, _ := serviceDomain.NewOrder(clientID, vehicleID, 0)
 
-		err := order.AddItem(uuid.New(), serviceDomain.ItemTypePart, "Oil Filter", 1, 50.0)
+		err := order.AddItem(uuid.New(), serviceDomain.ItemTypePart, "Oil Filter", 1, sharedkernel.NewMoneyFromFloat(50.0), sharedkernel.NewMoneyFromFloat(30.0))
 		if err != nil {
 			t.Fatalf("Failed to add item: %v", err)
 		}
 
-		err = order.AddItem(uuid.New(), serviceDomain.ItemTypeService, "Oil Change", 1, 100.0)
+		err = order.AddItem(uuid.New(), serviceDomain.ItemTypeService, "Oil Change", 1, sharedkernel.NewMoneyFromFloat(100.0), sharedkernel.Money{})
 		if err != nil {
 			t.Fatalf("Failed to add item: %v", err)
 		}
 
-		if err := orderRepo.Save(order); err != nil {
+		if err := orderRepo.Save(context.Background(), order); err != nil {
 			t.Fatalf("Failed to save order: %v", err)
 		}
 
-		fetched, err := orderRepo.GetByID(order.ID)
+		fetched, err := orderRepo.GetByID(context.Background(), order.ID)
 		if err != nil {
 			t.Fatalf("Failed to get order: %v", err)
 		}
@@ -107,8 +108,26 @@ func TestPostgresOrderRepository(t *testing.T) {
 			t.Errorf("Expected 2 items, got %d", len(fetched.Items))
 		}
 
-		if float64(fetched.Total) != 150.0 {
-			t.Errorf("Expected total 150.0, got %f", fetched.Total)
+		if fetched.Total.Cents() != 15000 {
+			t.Errorf("Expected total 150.00, got %s", fetched.Total)
+		}
+	})
+
+	t.Run("List By Vehicle", func(t *testing.T) {
+		orders, err := orderRepo.ListByVehicleID(context.Background(), vehicleID)
+		if err != nil {
+			t.Fatalf("Failed to list orders: %v", err)
+		}
+		if len(orders) == 0 {
+			t.Fatal("Expected the vehicle's orders")
+		}
+		for _, o := range orders {
+			if o.VehicleID != vehicleID {
+				t.Errorf("Expected only orders of vehicle %s, got %s", vehicleID, o.VehicleID)
+			}
+		}
+		if len(orders[0].Items) != 2 {
+			t.Errorf("Expected 2 items, got %d", len(orders[0].Items))
 		}
 	})
 }
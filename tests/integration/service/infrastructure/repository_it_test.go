@@ -3,15 +3,18 @@
 package infrastructure_test
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/noggrj/autorepair/internal/platform/db"
 	serviceDomain "github.com/noggrj/autorepair/internal/service/domain"
 	"github.com/noggrj/autorepair/internal/service/infrastructure"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -37,6 +40,16 @@ func randomDigit(n int) string {
 	return string(b)
 }
 
+// randomVIN returns a Brazilian VIN with a random serial number. Those carry
+// no check digit, so any serial is valid.
+func randomVIN() sharedkernel.VIN {
+	vin, err := sharedkernel.NewVIN("9BWZZZ377VT" + randomDigit(6))
+	if err != nil {
+		panic(err)
+	}
+	return vin
+}
+
 func generateValidCPF() string {
 	d := make([]int, 9)
 	for i := 0; i < 9; i++ {
@@ -86,24 +99,24 @@ func TestPostgresServiceRepository(t *testing.T) {
 
 	// Create
 	svcName := "Test Service " + randomString(5)
-	svc, _ := serviceDomain.NewService(svcName, "Desc", 150.0)
-	err = repo.Save(svc)
+	svc, _ := serviceDomain.NewService(svcName, "Desc", sharedkernel.NewMoneyFromFloat(150.0))
+	err = repo.Save(context.Background(), svc)
 	assert.NoError(t, err)
 
 	// GetByID
-	fetched, err := repo.GetByID(svc.ID)
+	fetched, err := repo.GetByID(context.Background(), svc.ID)
 	assert.NoError(t, err)
 	if fetched != nil {
 		assert.Equal(t, svc.Name, fetched.Name)
 	}
 
 	// List
-	list, err := repo.List()
+	page, err := repo.List(context.Background(), sharedkernel.ListQuery{})
 	assert.NoError(t, err)
-	assert.NotEmpty(t, list)
+	assert.NotEmpty(t, page.Items)
 
 	found := false
-	for _, s := range list {
+	for _, s := range page.Items {
 		if s.ID == svc.ID {
 			found = true
 			break
@@ -126,7 +139,7 @@ func TestPostgresVehicleRepository(t *testing.T) {
 	client, err := serviceDomain.NewClient("Vehicle Owner", clientDoc, clientEmail, "123")
 	assert.NoError(t, err)
 
-	err = clientRepo.Save(client)
+	err = clientRepo.Save(context.Background(), client)
 	assert.NoError(t, err)
 
 	repo := infrastructure.NewPostgresVehicleRepository(database.Pool)
@@ -137,23 +150,70 @@ func TestPostgresVehicleRepository(t *testing.T) {
 	v, err := serviceDomain.NewVehicle(client.ID, plate, "Ford", "Fiesta", 2019)
 	assert.NoError(t, err)
 
-	err = repo.Save(v)
+	err = repo.Save(context.Background(), v)
 	assert.NoError(t, err)
 
 	// GetByID
-	fetched, err := repo.GetByID(v.ID)
+	fetched, err := repo.GetByID(context.Background(), v.ID)
 	assert.NoError(t, err)
 	if fetched != nil {
 		assert.Equal(t, v.Plate.String(), fetched.Plate.String())
 	}
 
 	// ListByClientID
-	list, err := repo.ListByClientID(client.ID)
+	list, err := repo.ListByClientID(context.Background(), client.ID)
 	assert.NoError(t, err)
 	assert.Len(t, list, 1)
 	if len(list) > 0 {
 		assert.Equal(t, v.ID, list[0].ID)
 	}
+
+	// GetByPlate, as typed at the counter
+	lookup, err := sharedkernel.NewPlacaBR(strings.ToLower(plate[:3]) + "-" + plate[3:])
+	assert.NoError(t, err)
+	byPlate, err := repo.GetByPlate(context.Background(), lookup)
+	assert.NoError(t, err)
+	if byPlate != nil {
+		assert.Equal(t, v.ID, byPlate.ID)
+	}
+
+	// The Mercosul conversion of the plate is the same vehicle
+	mercosul := v.Plate.Mercosul()
+	byPlate, err = repo.GetByPlate(context.Background(), mercosul)
+	assert.NoError(t, err)
+	if byPlate != nil {
+		assert.Equal(t, v.ID, byPlate.ID)
+	}
+
+	duplicate, err := serviceDomain.NewVehicle(client.ID, mercosul.String(), "Ford", "Fiesta", 2019)
+	assert.NoError(t, err)
+	err = repo.Save(context.Background(), duplicate)
+	assert.ErrorIs(t, err, serviceDomain.ErrVehiclePlateExists)
+
+	// Converting the registered plate keeps the vehicle
+	assert.NoError(t, v.Update(mercosul.String(), "", "", 0))
+	assert.NoError(t, repo.Save(context.Background(), v))
+	byPlate, err = repo.GetByPlate(context.Background(), lookup)
+	assert.NoError(t, err)
+	if byPlate != nil {
+		assert.Equal(t, mercosul.String(), byPlate.Plate.String())
+	}
+
+	// VIN is stored and unique
+	vin := randomVIN()
+	v.VIN = &vin
+	assert.NoError(t, repo.Save(context.Background(), v))
+	fetched, err = repo.GetByID(context.Background(), v.ID)
+	assert.NoError(t, err)
+	if fetched != nil && assert.NotNil(t, fetched.VIN) {
+		assert.Equal(t, vin, *fetched.VIN)
+	}
+
+	other, err := serviceDomain.NewVehicle(client.ID, randomString(3)+randomDigit(4), "Ford", "Fiesta", 2019)
+	assert.NoError(t, err)
+	other.VIN = &vin
+	err = repo.Save(context.Background(), other)
+	assert.ErrorIs(t, err, serviceDomain.ErrVehicleVINExists)
 }
 
 func TestPostgresClientRepository_Full(t *testing.T) {
@@ -169,11 +229,31 @@ func TestPostgresClientRepository_Full(t *testing.T) {
 	email := "list" + randomString(5) + "@test.com"
 	doc := generateValidCPF()
 	c, _ := serviceDomain.NewClient("List Test", doc, email, "123")
-	err = repo.Save(c)
+	err = repo.Save(context.Background(), c)
 	assert.NoError(t, err)
 
 	// List
-	list, err := repo.List()
+	page, err := repo.List(context.Background(), sharedkernel.ListQuery{})
 	assert.NoError(t, err)
-	assert.NotEmpty(t, list)
+	assert.NotEmpty(t, page.Items)
+
+	// Page through clients matching a search term, one at a time
+	tag := randomString(8)
+	for i := 0; i < 3; i++ {
+		pc, _ := serviceDomain.NewClient(fmt.Sprintf("Paged %s %d", tag, i), generateValidCPF(), "", "")
+		assert.NoError(t, repo.Save(context.Background(), pc))
+	}
+	q := sharedkernel.ListQuery{Limit: 2, Search: tag}
+	page, err = repo.List(context.Background(), q)
+	assert.NoError(t, err)
+	assert.Len(t, page.Items, 2)
+	assert.NotEmpty(t, page.NextCursor)
+
+	q.Cursor = page.NextCursor
+	page, err = repo.List(context.Background(), q)
+	assert.NoError(t, err)
+	if assert.Len(t, page.Items, 1) {
+		assert.Equal(t, fmt.Sprintf("Paged %s 2", tag), page.Items[0].Name)
+	}
+	assert.Empty(t, page.NextCursor)
 }
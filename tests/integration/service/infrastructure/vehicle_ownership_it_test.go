@@ -0,0 +1,97 @@
+//go:build integration
+
+package infrastructure_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/platform/db"
+	serviceApplication "github.com/noggrj/autorepair/internal/service/application"
+	serviceDomain "github.com/noggrj/autorepair/internal/service/domain"
+	"github.com/noggrj/autorepair/internal/service/infrastructure"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
+)
+
+func TestVehicleOwnershipTransfer(t *testing.T) {
+	dbURL := os.Getenv("DB_URL")
+	if dbURL == "" {
+		t.Skip("DB_URL not set")
+	}
+
+	pool, err := db.New(dbURL)
+	if err != nil {
+		t.Fatalf("Failed to connect to DB: %v", err)
+	}
+	defer pool.Close()
+
+	ctx := context.Background()
+	clientRepo := infrastructure.NewPostgresClientRepository(pool.Pool)
+	vehicleRepo := infrastructure.NewPostgresVehicleRepository(pool.Pool)
+	orderRepo := infrastructure.NewPostgresOrderRepository(pool.Pool)
+	vehicleService := serviceApplication.NewVehicleService(infrastructure.NewPostgresVehicleOwnershipRepository(pool.Pool), clientRepo, nil)
+
+	var clients []*serviceDomain.Client
+	for i, prefix := range []string{"5", "6"} {
+		client, _ := serviceDomain.NewClient("Owner Client", "12345678909", "owner@test.com", "123")
+		doc, _ := sharedkernel.NewDocumentoBR(prefix + time.Now().Format("0405000000"))
+		client.Document = doc
+		if err := clientRepo.Save(ctx, client); err != nil {
+			t.Fatalf("Failed to save client %d: %v", i, err)
+		}
+		clients = append(clients, client)
+	}
+	previousOwner, newOwner := clients[0], clients[1]
+
+	vehicle, _ := serviceDomain.NewVehicle(previousOwner.ID, "ABC1234", "Brand", "Model", 2020)
+	plate, _ := sharedkernel.NewPlacaBR("OWN" + time.Now().Format("0405"))
+	vehicle.Plate = plate
+	if err := vehicleRepo.Save(ctx, vehicle); err != nil {
+		t.Fatalf("Failed to save vehicle: %v", err)
+	}
+	order, _ := serviceDomain.NewOrder(previousOwner.ID, vehicle.ID, 0)
+	if err := orderRepo.Save(ctx, order); err != nil {
+		t.Fatalf("Failed to save order: %v", err)
+	}
+
+	// Unknown client is refused
+	if _, err := vehicleService.TransferOwnership(ctx, vehicle.ID, uuid.New()); err != serviceDomain.ErrClientNotFound {
+		t.Errorf("Expected ErrClientNotFound, got %v", err)
+	}
+
+	transferred, err := vehicleService.TransferOwnership(ctx, vehicle.ID, newOwner.ID)
+	if err != nil {
+		t.Fatalf("Failed to transfer vehicle: %v", err)
+	}
+	if transferred.ClientID != newOwner.ID {
+		t.Errorf("Expected new owner %s, got %s", newOwner.ID, transferred.ClientID)
+	}
+	if got, _ := vehicleRepo.GetByID(ctx, vehicle.ID); got.ClientID != newOwner.ID {
+		t.Errorf("Expected stored owner %s, got %s", newOwner.ID, got.ClientID)
+	}
+
+	// The old order stays with the client that opened it
+	if got, _ := orderRepo.GetByID(ctx, order.ID); got.ClientID != previousOwner.ID {
+		t.Errorf("Expected order to stay with %s, got %s", previousOwner.ID, got.ClientID)
+	}
+
+	pastOwners, err := vehicleService.PastOwners(ctx, vehicle.ID)
+	if err != nil {
+		t.Fatalf("Failed to list past owners: %v", err)
+	}
+	if len(pastOwners) != 1 || pastOwners[0].ClientID != previousOwner.ID {
+		t.Fatalf("Expected the previous owner in the history, got %+v", pastOwners)
+	}
+
+	// Handing it back closes the second period where the first ended
+	if _, err := vehicleService.TransferOwnership(ctx, vehicle.ID, previousOwner.ID); err != nil {
+		t.Fatalf("Failed to transfer vehicle back: %v", err)
+	}
+	pastOwners, _ = vehicleService.PastOwners(ctx, vehicle.ID)
+	if len(pastOwners) != 2 || pastOwners[1].ClientID != newOwner.ID || !pastOwners[1].StartedAt.Equal(pastOwners[0].EndedAt) {
+		t.Errorf("Expected contiguous ownership periods, got %+v", pastOwners)
+	}
+}
@@ -0,0 +1,251 @@
+package application_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/identity/application"
+	"github.com/noggrj/autorepair/internal/identity/domain"
+	notificationInfra "github.com/noggrj/autorepair/internal/notification/infrastructure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockUserTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserTokenRepository) Save(ctx context.Context, token *domain.UserToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockUserTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*domain.UserToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.UserToken), args.Error(1)
+}
+
+func (m *MockUserTokenRepository) MarkUsed(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	args := m.Called(ctx, id, at)
+	return args.Bool(0), args.Error(1)
+}
+
+type accountFixture struct {
+	users    *MockUserRepository
+	tokens   *MockUserTokenRepository
+	sessions *MockRefreshTokenRepository
+	mailer   *notificationInfra.MockEmailService
+	service  *application.AccountService
+}
+
+func newAccountFixture() *accountFixture {
+	f := &accountFixture{
+		users:    new(MockUserRepository),
+		tokens:   new(MockUserTokenRepository),
+		sessions: new(MockRefreshTokenRepository),
+		mailer:   new(notificationInfra.MockEmailService),
+	}
+	f.service = application.NewAccountService(f.users, f.tokens, f.sessions, f.mailer)
+	return f
+}
+
+// mailedToken captures the token stored for purpose and the one mailed out.
+func (f *accountFixture) mailedToken(purpose domain.TokenPurpose) (*domain.UserToken, *string) {
+	stored := new(domain.UserToken)
+	token := new(string)
+	f.tokens.On("Save", mock.Anything, mock.MatchedBy(func(t *domain.UserToken) bool {
+		return t.Purpose == purpose
+	})).Run(func(args mock.Arguments) {
+		*stored = *args.Get(1).(*domain.UserToken)
+	}).Return(nil).Once()
+	f.mailer.On("SendEmail", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		for _, word := range strings.Fields(args.String(2)) {
+			if domain.HashToken(word) == stored.TokenHash {
+				*token = word
+			}
+		}
+	}).Return(nil).Once()
+	return stored, token
+}
+
+func TestAccountService_ForgotAndResetPassword(t *testing.T) {
+	f := newAccountFixture()
+	user, _ := domain.NewUser("Ana", "ana@example.com", "password123", domain.RoleEmployee)
+	f.users.On("GetByEmail", mock.Anything, "ana@example.com").Return(user, nil)
+	stored, token := f.mailedToken(domain.TokenPurposePasswordReset)
+
+	assert.NoError(t, f.service.ForgotPassword(context.Background(), "ana@example.com"))
+	f.mailer.AssertCalled(t, "SendEmail", "ana@example.com", mock.Anything, mock.Anything)
+	assert.NotEmpty(t, *token, "the mailed token must match the stored hash")
+	assert.WithinDuration(t, time.Now().Add(domain.PasswordResetTokenTTL), stored.ExpiresAt, time.Minute)
+
+	f.tokens.On("GetByHash", mock.Anything, stored.TokenHash).Return(stored, nil)
+	f.users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	f.tokens.On("MarkUsed", mock.Anything, stored.ID, mock.Anything).Return(true, nil)
+	f.users.On("Update", mock.Anything, user).Return(nil)
+	f.sessions.On("RevokeUser", mock.Anything, user.ID, mock.Anything).Return(nil)
+
+	assert.NoError(t, f.service.ResetPassword(context.Background(), *token, "newpassword1"))
+	assert.True(t, user.CheckPassword("newpassword1"))
+	assert.NotNil(t, user.PasswordChangedAt)
+	assert.True(t, user.IsEmailVerified())
+	f.sessions.AssertExpectations(t)
+}
+
+func TestAccountService_ForgotPassword_UnknownEmail(t *testing.T) {
+	f := newAccountFixture()
+	f.users.On("GetByEmail", mock.Anything, "nobody@example.com").Return(nil, domain.ErrUserNotFound)
+
+	assert.NoError(t, f.service.ForgotPassword(context.Background(), "nobody@example.com"))
+	f.tokens.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	f.mailer.AssertNotCalled(t, "SendEmail", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAccountService_ForgotPassword_DeactivatedUser(t *testing.T) {
+	f := newAccountFixture()
+	user, _ := domain.NewUser("Ana", "ana@example.com", "password123", domain.RoleEmployee)
+	user.Deactivate()
+	f.users.On("GetByEmail", mock.Anything, "ana@example.com").Return(user, nil)
+
+	assert.NoError(t, f.service.ForgotPassword(context.Background(), "ana@example.com"))
+	f.mailer.AssertNotCalled(t, "SendEmail", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAccountService_ResetPassword_Invalid(t *testing.T) {
+	user, _ := domain.NewUser("Ana", "ana@example.com", "password123", domain.RoleEmployee)
+	newToken := func(purpose domain.TokenPurpose) (*domain.UserToken, string) {
+		stored, token, _ := domain.NewUserToken(user.ID, purpose, time.Hour)
+		return stored, token
+	}
+
+	t.Run("Unknown", func(t *testing.T) {
+		f := newAccountFixture()
+		f.tokens.On("GetByHash", mock.Anything, mock.Anything).Return(nil, domain.ErrUserTokenNotFound)
+
+		err := f.service.ResetPassword(context.Background(), "made-up", "newpassword1")
+		assert.ErrorIs(t, err, domain.ErrInvalidUserToken)
+	})
+
+	t.Run("Wrong Purpose", func(t *testing.T) {
+		f := newAccountFixture()
+		stored, token := newToken(domain.TokenPurposeEmailVerification)
+		f.tokens.On("GetByHash", mock.Anything, stored.TokenHash).Return(stored, nil)
+		f.users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+
+		err := f.service.ResetPassword(context.Background(), token, "newpassword1")
+		assert.ErrorIs(t, err, domain.ErrInvalidUserToken)
+	})
+
+	t.Run("Weak Password Keeps Token", func(t *testing.T) {
+		f := newAccountFixture()
+		stored, token := newToken(domain.TokenPurposePasswordReset)
+		f.tokens.On("GetByHash", mock.Anything, stored.TokenHash).Return(stored, nil)
+		f.users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+
+		err := f.service.ResetPassword(context.Background(), token, "weak")
+		assert.ErrorIs(t, err, domain.ErrWeakPassword)
+		f.tokens.AssertNotCalled(t, "MarkUsed", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Already Used", func(t *testing.T) {
+		f := newAccountFixture()
+		stored, token := newToken(domain.TokenPurposePasswordReset)
+		f.tokens.On("GetByHash", mock.Anything, stored.TokenHash).Return(stored, nil)
+		f.users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+		f.tokens.On("MarkUsed", mock.Anything, stored.ID, mock.Anything).Return(false, nil)
+
+		err := f.service.ResetPassword(context.Background(), token, "newpassword1")
+		assert.ErrorIs(t, err, domain.ErrInvalidUserToken)
+		f.users.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Store Error", func(t *testing.T) {
+		f := newAccountFixture()
+		f.tokens.On("GetByHash", mock.Anything, mock.Anything).Return(nil, errors.New("db error"))
+
+		err := f.service.ResetPassword(context.Background(), "token", "newpassword1")
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, domain.ErrInvalidUserToken)
+	})
+}
+
+func TestAccountService_VerifyEmail(t *testing.T) {
+	f := newAccountFixture()
+	user, _ := domain.NewUser("Ana", "ana@example.com", "password123", domain.RoleEmployee)
+	stored, token := f.mailedToken(domain.TokenPurposeEmailVerification)
+
+	assert.NoError(t, f.service.SendVerification(context.Background(), user))
+	assert.WithinDuration(t, time.Now().Add(domain.EmailVerificationTokenTTL), stored.ExpiresAt, time.Minute)
+
+	f.tokens.On("GetByHash", mock.Anything, stored.TokenHash).Return(stored, nil)
+	f.users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	f.tokens.On("MarkUsed", mock.Anything, stored.ID, mock.Anything).Return(true, nil)
+	f.users.On("Update", mock.Anything, user).Return(nil)
+
+	assert.NoError(t, f.service.VerifyEmail(context.Background(), *token))
+	assert.True(t, user.IsEmailVerified())
+	f.sessions.AssertNotCalled(t, "RevokeUser", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAccountService_SendVerification_AlreadyVerified(t *testing.T) {
+	f := newAccountFixture()
+	user, _ := domain.NewUser("Ana", "ana@example.com", "password123", domain.RoleEmployee)
+	user.VerifyEmail()
+
+	assert.NoError(t, f.service.SendVerification(context.Background(), user))
+	f.tokens.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestAccountService_MagicLink(t *testing.T) {
+	f := newAccountFixture()
+	user, _ := domain.NewCustomer(uuid.New(), "Maria", "maria@example.com")
+	f.users.On("GetByEmail", mock.Anything, "maria@example.com").Return(user, nil)
+	stored, token := f.mailedToken(domain.TokenPurposeMagicLink)
+
+	assert.NoError(t, f.service.SendMagicLink(context.Background(), "maria@example.com"))
+	f.mailer.AssertCalled(t, "SendEmail", "maria@example.com", mock.Anything, mock.Anything)
+	assert.WithinDuration(t, time.Now().Add(domain.MagicLinkTokenTTL), stored.ExpiresAt, time.Minute)
+
+	f.tokens.On("GetByHash", mock.Anything, stored.TokenHash).Return(stored, nil)
+	f.users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	f.tokens.On("MarkUsed", mock.Anything, stored.ID, mock.Anything).Return(true, nil).Once()
+	f.users.On("Update", mock.Anything, user).Return(nil)
+
+	got, err := f.service.RedeemMagicLink(context.Background(), *token)
+	assert.NoError(t, err)
+	assert.Equal(t, user, got)
+	assert.True(t, user.IsEmailVerified())
+
+	f.tokens.On("MarkUsed", mock.Anything, stored.ID, mock.Anything).Return(false, nil)
+	_, err = f.service.RedeemMagicLink(context.Background(), *token)
+	assert.ErrorIs(t, err, domain.ErrInvalidUserToken, "a code works once")
+}
+
+func TestAccountService_SendMagicLink_Staff(t *testing.T) {
+	f := newAccountFixture()
+	user, _ := domain.NewUser("Ana", "ana@example.com", "password123", domain.RoleAdmin)
+	f.users.On("GetByEmail", mock.Anything, "ana@example.com").Return(user, nil)
+
+	assert.NoError(t, f.service.SendMagicLink(context.Background(), "ana@example.com"))
+	f.tokens.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	f.mailer.AssertNotCalled(t, "SendEmail", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAccountService_RedeemMagicLink_WrongPurpose(t *testing.T) {
+	f := newAccountFixture()
+	user, _ := domain.NewCustomer(uuid.New(), "Maria", "maria@example.com")
+	stored, token, _ := domain.NewUserToken(user.ID, domain.TokenPurposePasswordReset, time.Hour)
+	f.tokens.On("GetByHash", mock.Anything, stored.TokenHash).Return(stored, nil)
+	f.users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+
+	_, err := f.service.RedeemMagicLink(context.Background(), token)
+	assert.ErrorIs(t, err, domain.ErrInvalidUserToken)
+	f.tokens.AssertNotCalled(t, "MarkUsed", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,134 @@
+package application_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/identity/application"
+	"github.com/noggrj/autorepair/internal/identity/domain"
+	"github.com/noggrj/autorepair/internal/identity/infrastructure"
+	"github.com/stretchr/testify/assert"
+)
+
+func newLoginGuard() (*application.LoginGuard, *infrastructure.MemoryLoginAttemptRepository, *infrastructure.MemoryAuditLog) {
+	attempts := infrastructure.NewMemoryLoginAttemptRepository()
+	audit := infrastructure.NewMemoryAuditLog()
+	return application.NewLoginGuard(attempts, audit), attempts, audit
+}
+
+func TestLoginGuard_DelaysRepeatedFailures(t *testing.T) {
+	guard, _, audit := newLoginGuard()
+	ctx := context.Background()
+
+	for i := 0; i < domain.EmailLoginPolicy.FreeFailures; i++ {
+		wait, err := guard.Allow(ctx, "ana@example.com", "10.0.0.1")
+		assert.NoError(t, err)
+		assert.Zero(t, wait)
+		assert.NoError(t, guard.Fail(ctx, "ana@example.com", "10.0.0.1"))
+	}
+
+	wait, err := guard.Allow(ctx, "ana@example.com", "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Greater(t, wait, time.Duration(0))
+
+	// The email is counted case-insensitively, from any address.
+	wait, err = guard.Allow(ctx, " ANA@example.com", "10.0.0.2")
+	assert.NoError(t, err)
+	assert.Greater(t, wait, time.Duration(0))
+
+	// Other accounts from the same address are not held up yet.
+	wait, err = guard.Allow(ctx, "bruno@example.com", "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Zero(t, wait)
+	assert.Empty(t, audit.Entries())
+}
+
+func TestLoginGuard_LocksAndAudits(t *testing.T) {
+	guard, attempts, audit := newLoginGuard()
+	ctx := context.Background()
+
+	for i := 0; i < domain.EmailLoginPolicy.LockAfter; i++ {
+		assert.NoError(t, guard.Fail(ctx, "ana@example.com", "10.0.0.1"))
+	}
+
+	a, _ := attempts.Get(ctx, domain.LoginScopeEmail, "ana@example.com")
+	if assert.NotNil(t, a.LockedUntil) {
+		assert.WithinDuration(t, time.Now().Add(domain.EmailLoginPolicy.LockFor), *a.LockedUntil, time.Minute)
+	}
+	wait, err := guard.Allow(ctx, "ana@example.com", "10.0.0.9")
+	assert.NoError(t, err)
+	assert.Greater(t, wait, 10*time.Minute)
+
+	// Further failures while locked are not audited again.
+	assert.NoError(t, guard.Fail(ctx, "ana@example.com", "10.0.0.1"))
+	if assert.Len(t, audit.Entries(), 1) {
+		entry := audit.Entries()[0]
+		assert.Equal(t, domain.AuditLoginLocked, entry.Event)
+		assert.Equal(t, "ana@example.com", entry.Subject)
+		assert.Equal(t, "10.0.0.1", entry.IP)
+		assert.Nil(t, entry.ActorID)
+	}
+}
+
+func TestLoginGuard_LocksIP(t *testing.T) {
+	guard, _, audit := newLoginGuard()
+	ctx := context.Background()
+
+	// Spraying one password over many accounts trips the IP policy.
+	for i := 0; i < domain.IPLoginPolicy.LockAfter; i++ {
+		assert.NoError(t, guard.Fail(ctx, uuid.NewString()+"@example.com", "10.0.0.1"))
+	}
+
+	wait, err := guard.Allow(ctx, "new@example.com", "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Greater(t, wait, 10*time.Minute)
+
+	wait, err = guard.Allow(ctx, "new@example.com", "10.0.0.2")
+	assert.NoError(t, err)
+	assert.Zero(t, wait)
+
+	entries := audit.Entries()
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "10.0.0.1", entries[0].Subject)
+	}
+}
+
+func TestLoginGuard_SucceedClearsEmailOnly(t *testing.T) {
+	guard, attempts, _ := newLoginGuard()
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, guard.Fail(ctx, "ana@example.com", "10.0.0.1"))
+	}
+	assert.NoError(t, guard.Succeed(ctx, "Ana@Example.com"))
+
+	a, _ := attempts.Get(ctx, domain.LoginScopeEmail, "ana@example.com")
+	assert.Zero(t, a.Failures)
+	a, _ = attempts.Get(ctx, domain.LoginScopeIP, "10.0.0.1")
+	assert.Equal(t, 5, a.Failures)
+}
+
+func TestLoginGuard_Unlock(t *testing.T) {
+	guard, _, audit := newLoginGuard()
+	ctx := context.Background()
+
+	for i := 0; i < domain.EmailLoginPolicy.LockAfter; i++ {
+		assert.NoError(t, guard.Fail(ctx, "ana@example.com", "10.0.0.1"))
+	}
+
+	admin := uuid.New()
+	assert.NoError(t, guard.Unlock(ctx, admin, "ana@example.com", "10.0.0.5"))
+
+	wait, err := guard.Allow(ctx, "ana@example.com", "10.0.0.2")
+	assert.NoError(t, err)
+	assert.Zero(t, wait)
+
+	entries := audit.Entries()
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, domain.AuditLoginUnlocked, entries[1].Event)
+		assert.Equal(t, &admin, entries[1].ActorID)
+		assert.Equal(t, "10.0.0.5", entries[1].IP)
+	}
+}
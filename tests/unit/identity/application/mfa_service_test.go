@@ -0,0 +1,161 @@
+package application_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/identity/application"
+	"github.com/noggrj/autorepair/internal/identity/domain"
+	"github.com/noggrj/autorepair/internal/platform/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRecoveryCodeRepository struct {
+	mock.Mock
+}
+
+func (m *MockRecoveryCodeRepository) Replace(ctx context.Context, userID uuid.UUID, hashes []string) error {
+	args := m.Called(ctx, userID, hashes)
+	return args.Error(0)
+}
+
+func (m *MockRecoveryCodeRepository) Use(ctx context.Context, userID uuid.UUID, hash string, at time.Time) (bool, error) {
+	args := m.Called(ctx, userID, hash, at)
+	return args.Bool(0), args.Error(1)
+}
+
+// currentCode returns the code the user's authenticator app shows now.
+func currentCode(t *testing.T, user *domain.User) string {
+	code, err := auth.TOTPCode(user.TOTPSecret, auth.TOTPStep(time.Now()))
+	assert.NoError(t, err)
+	return code
+}
+
+// enrolled returns a user with two-factor authentication on.
+func enrolled(t *testing.T, role domain.Role) *domain.User {
+	user, _ := domain.NewUser("Ana", "ana@example.com", "password123", role)
+	secret, err := auth.GenerateTOTPSecret()
+	assert.NoError(t, err)
+	assert.NoError(t, user.StartMFAEnrolment(secret))
+	assert.NoError(t, user.EnableMFA())
+	return user
+}
+
+func TestMFAService_EnrolAndConfirm(t *testing.T) {
+	users := new(MockUserRepository)
+	codes := new(MockRecoveryCodeRepository)
+	service := application.NewMFAService(users, codes)
+	user, _ := domain.NewUser("Ana", "ana@example.com", "password123", domain.RoleManager)
+	users.On("Update", mock.Anything, user).Return(nil)
+	users.On("UseTOTPStep", mock.Anything, user.ID, mock.Anything).Return(true, nil)
+
+	enrolment, err := service.Enrol(context.Background(), user)
+	assert.NoError(t, err)
+	assert.Equal(t, user.TOTPSecret, enrolment.Secret)
+	assert.Contains(t, enrolment.ProvisioningURI, "secret="+enrolment.Secret)
+	assert.False(t, user.MFAEnabled())
+
+	// A wrong code does not turn it on
+	_, err = service.Confirm(context.Background(), user, "000000")
+	assert.ErrorIs(t, err, domain.ErrInvalidMFACode)
+	assert.False(t, user.MFAEnabled())
+
+	var stored []string
+	codes.On("Replace", mock.Anything, user.ID, mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(2).([]string)
+	}).Return(nil)
+
+	recovery, err := service.Confirm(context.Background(), user, currentCode(t, user))
+	assert.NoError(t, err)
+	assert.True(t, user.MFAEnabled())
+	assert.Len(t, recovery, domain.RecoveryCodeCount)
+	assert.Len(t, stored, domain.RecoveryCodeCount)
+	assert.Equal(t, domain.HashRecoveryCode(recovery[0]), stored[0])
+
+	_, err = service.Enrol(context.Background(), user)
+	assert.ErrorIs(t, err, domain.ErrMFAAlreadyEnabled)
+}
+
+func TestMFAService_Confirm_NotEnrolled(t *testing.T) {
+	service := application.NewMFAService(new(MockUserRepository), new(MockRecoveryCodeRepository))
+	user, _ := domain.NewUser("Ana", "ana@example.com", "password123", domain.RoleManager)
+
+	_, err := service.Confirm(context.Background(), user, "123456")
+	assert.ErrorIs(t, err, domain.ErrMFANotEnrolled)
+}
+
+func TestMFAService_Verify_TOTP(t *testing.T) {
+	users := new(MockUserRepository)
+	codes := new(MockRecoveryCodeRepository)
+	service := application.NewMFAService(users, codes)
+	user := enrolled(t, domain.RoleAdmin)
+	users.On("UseTOTPStep", mock.Anything, user.ID, mock.Anything).Return(true, nil)
+	code := currentCode(t, user)
+
+	assert.NoError(t, service.Verify(context.Background(), user, code))
+	users.AssertNumberOfCalls(t, "UseTOTPStep", 1)
+	users.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+
+	// A code works once; the replay then fails as a recovery code too
+	codes.On("Use", mock.Anything, user.ID, domain.HashRecoveryCode(code), mock.Anything).Return(false, nil)
+	assert.ErrorIs(t, service.Verify(context.Background(), user, code), domain.ErrInvalidMFACode)
+}
+
+func TestMFAService_Verify_TOTPUsedConcurrently(t *testing.T) {
+	users := new(MockUserRepository)
+	codes := new(MockRecoveryCodeRepository)
+	service := application.NewMFAService(users, codes)
+	user := enrolled(t, domain.RoleAdmin)
+	code := currentCode(t, user)
+	// Another login loaded the same user and used the code first
+	users.On("UseTOTPStep", mock.Anything, user.ID, mock.Anything).Return(false, nil)
+	codes.On("Use", mock.Anything, user.ID, domain.HashRecoveryCode(code), mock.Anything).Return(false, nil)
+
+	assert.ErrorIs(t, service.Verify(context.Background(), user, code), domain.ErrInvalidMFACode)
+}
+
+func TestMFAService_Verify_RecoveryCode(t *testing.T) {
+	users := new(MockUserRepository)
+	codes := new(MockRecoveryCodeRepository)
+	service := application.NewMFAService(users, codes)
+	user := enrolled(t, domain.RoleAdmin)
+	codes.On("Use", mock.Anything, user.ID, domain.HashRecoveryCode("abcd-efgh-ijkl-mnop"), mock.Anything).Return(true, nil)
+
+	assert.NoError(t, service.Verify(context.Background(), user, "ABCD-EFGH-IJKL-MNOP"))
+	codes.AssertExpectations(t)
+}
+
+func TestMFAService_Verify_NotEnabled(t *testing.T) {
+	service := application.NewMFAService(new(MockUserRepository), new(MockRecoveryCodeRepository))
+	user, _ := domain.NewUser("Ana", "ana@example.com", "password123", domain.RoleEmployee)
+
+	assert.ErrorIs(t, service.Verify(context.Background(), user, "123456"), domain.ErrMFANotEnrolled)
+}
+
+func TestMFAService_Disable(t *testing.T) {
+	users := new(MockUserRepository)
+	codes := new(MockRecoveryCodeRepository)
+	service := application.NewMFAService(users, codes)
+	user := enrolled(t, domain.RoleEmployee)
+	users.On("Update", mock.Anything, user).Return(nil)
+	users.On("UseTOTPStep", mock.Anything, user.ID, mock.Anything).Return(true, nil)
+	codes.On("Replace", mock.Anything, user.ID, []string(nil)).Return(nil)
+
+	assert.NoError(t, service.Disable(context.Background(), user, currentCode(t, user)))
+	assert.False(t, user.MFAEnabled())
+	codes.AssertExpectations(t)
+}
+
+func TestMFAService_Disable_RequiredByRole(t *testing.T) {
+	users := new(MockUserRepository)
+	service := application.NewMFAService(users, new(MockRecoveryCodeRepository))
+	user := enrolled(t, domain.RoleManager)
+
+	err := service.Disable(context.Background(), user, currentCode(t, user))
+	assert.ErrorIs(t, err, domain.ErrMFARequired)
+	assert.True(t, user.MFAEnabled())
+	users.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
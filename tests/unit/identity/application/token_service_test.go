@@ -0,0 +1,279 @@
+package application_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/identity/application"
+	"github.com/noggrj/autorepair/internal/identity/domain"
+	"github.com/noggrj/autorepair/internal/platform/auth"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) Save(ctx context.Context, user *domain.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Update(ctx context.Context, user *domain.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*domain.User], error) {
+	args := m.Called(ctx, q)
+	return args.Get(0).(sharedkernel.Page[*domain.User]), args.Error(1)
+}
+
+func (m *MockUserRepository) ListByRole(ctx context.Context, role domain.Role) ([]*domain.User, error) {
+	args := m.Called(ctx, role)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) UseTOTPStep(ctx context.Context, id uuid.UUID, step int64) (bool, error) {
+	args := m.Called(ctx, id, step)
+	return args.Bool(0), args.Error(1)
+}
+
+type MockRefreshTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenRepository) Save(ctx context.Context, token *domain.RefreshToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.RefreshToken, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) MarkUsed(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	args := m.Called(ctx, id, at)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID, at time.Time) error {
+	args := m.Called(ctx, familyID, at)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) RevokeUser(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	args := m.Called(ctx, userID, at)
+	return args.Error(0)
+}
+
+// login issues a pair through the service and returns it with the record
+// that was saved for its refresh token.
+func login(t *testing.T, service *application.TokenService, tokens *MockRefreshTokenRepository, user *domain.User) (*application.TokenPair, *domain.RefreshToken) {
+	var stored *domain.RefreshToken
+	tokens.On("Save", mock.Anything, mock.AnythingOfType("*domain.RefreshToken")).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*domain.RefreshToken)
+	}).Return(nil).Once()
+
+	pair, err := service.Issue(context.Background(), user, "curl", []string{auth.AMRPassword, auth.AMROTP, auth.AMRMFA})
+	assert.NoError(t, err)
+	return pair, stored
+}
+
+func TestTokenService_Issue(t *testing.T) {
+	users := new(MockUserRepository)
+	tokens := new(MockRefreshTokenRepository)
+	service := application.NewTokenService(users, tokens)
+	user, _ := domain.NewUser("Ana", "ana@example.com", "password123", domain.RoleManager)
+
+	pair, stored := login(t, service, tokens, user)
+
+	claims, err := auth.ValidateToken(pair.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, string(domain.RoleManager), claims.Role)
+	assert.True(t, claims.HasAMR(auth.AMRMFA))
+
+	refreshClaims, err := auth.ValidateRefreshToken(pair.RefreshToken)
+	assert.NoError(t, err)
+	assert.Equal(t, stored.ID.String(), refreshClaims.ID)
+	assert.Equal(t, user.ID, stored.UserID)
+	assert.Equal(t, "curl", stored.Device)
+	assert.True(t, stored.Matches(pair.RefreshToken))
+}
+
+func TestTokenService_Refresh_Rotates(t *testing.T) {
+	users := new(MockUserRepository)
+	tokens := new(MockRefreshTokenRepository)
+	service := application.NewTokenService(users, tokens)
+	user, _ := domain.NewUser("Ana", "ana@example.com", "password123", domain.RoleManager)
+	pair, stored := login(t, service, tokens, user)
+
+	tokens.On("GetByID", mock.Anything, stored.ID).Return(stored, nil)
+	tokens.On("MarkUsed", mock.Anything, stored.ID, mock.Anything).Return(true, nil)
+	users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	tokens.On("Save", mock.Anything, mock.MatchedBy(func(next *domain.RefreshToken) bool {
+		// The new token joins the family of the one it replaces
+		return next.ID != stored.ID && next.FamilyID == stored.FamilyID && next.Device == "curl"
+	})).Return(nil)
+
+	next, err := service.Refresh(context.Background(), pair.RefreshToken)
+	assert.NoError(t, err)
+	assert.NotEqual(t, pair.RefreshToken, next.RefreshToken)
+	tokens.AssertExpectations(t)
+
+	// The session keeps the methods it was started with
+	claims, err := auth.ValidateToken(next.AccessToken)
+	assert.NoError(t, err)
+	assert.True(t, claims.HasAMR(auth.AMRMFA))
+}
+
+func TestTokenService_Refresh_ReuseRevokesFamily(t *testing.T) {
+	users := new(MockUserRepository)
+	tokens := new(MockRefreshTokenRepository)
+	service := application.NewTokenService(users, tokens)
+	user, _ := domain.NewUser("Ana", "ana@example.com", "password123", domain.RoleManager)
+	pair, stored := login(t, service, tokens, user)
+
+	usedAt := time.Now()
+	stored.UsedAt = &usedAt
+	tokens.On("GetByID", mock.Anything, stored.ID).Return(stored, nil)
+	tokens.On("RevokeFamily", mock.Anything, stored.FamilyID, mock.Anything).Return(nil)
+
+	_, err := service.Refresh(context.Background(), pair.RefreshToken)
+	assert.ErrorIs(t, err, domain.ErrRefreshTokenReused)
+	tokens.AssertExpectations(t)
+	tokens.AssertNotCalled(t, "MarkUsed", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTokenService_Refresh_ConcurrentUseRevokesFamily(t *testing.T) {
+	users := new(MockUserRepository)
+	tokens := new(MockRefreshTokenRepository)
+	service := application.NewTokenService(users, tokens)
+	user, _ := domain.NewUser("Ana", "ana@example.com", "password123", domain.RoleManager)
+	pair, stored := login(t, service, tokens, user)
+
+	// Another request rotated the token between the read and the update
+	tokens.On("GetByID", mock.Anything, stored.ID).Return(stored, nil)
+	tokens.On("MarkUsed", mock.Anything, stored.ID, mock.Anything).Return(false, nil)
+	tokens.On("RevokeFamily", mock.Anything, stored.FamilyID, mock.Anything).Return(nil)
+
+	_, err := service.Refresh(context.Background(), pair.RefreshToken)
+	assert.ErrorIs(t, err, domain.ErrRefreshTokenReused)
+	users.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+}
+
+func TestTokenService_Refresh_Invalid(t *testing.T) {
+	users := new(MockUserRepository)
+	tokens := new(MockRefreshTokenRepository)
+	service := application.NewTokenService(users, tokens)
+	user, _ := domain.NewUser("Ana", "ana@example.com", "password123", domain.RoleManager)
+	pair, stored := login(t, service, tokens, user)
+
+	// Access tokens and garbage are rejected without touching the store
+	_, err := service.Refresh(context.Background(), pair.AccessToken)
+	assert.ErrorIs(t, err, domain.ErrInvalidRefreshToken)
+	_, err = service.Refresh(context.Background(), "not-a-token")
+	assert.ErrorIs(t, err, domain.ErrInvalidRefreshToken)
+
+	// Revoked (logged out) sessions
+	revokedAt := time.Now()
+	revoked := *stored
+	revoked.RevokedAt = &revokedAt
+	tokens.On("GetByID", mock.Anything, stored.ID).Return(&revoked, nil).Once()
+	_, err = service.Refresh(context.Background(), pair.RefreshToken)
+	assert.ErrorIs(t, err, domain.ErrInvalidRefreshToken)
+
+	// Unknown jti
+	tokens.On("GetByID", mock.Anything, stored.ID).Return(nil, domain.ErrRefreshTokenNotFound).Once()
+	_, err = service.Refresh(context.Background(), pair.RefreshToken)
+	assert.ErrorIs(t, err, domain.ErrInvalidRefreshToken)
+
+	// Stored hash does not match the presented token
+	tampered := *stored
+	tampered.TokenHash = domain.HashToken("another token")
+	tokens.On("GetByID", mock.Anything, stored.ID).Return(&tampered, nil).Once()
+	_, err = service.Refresh(context.Background(), pair.RefreshToken)
+	assert.ErrorIs(t, err, domain.ErrInvalidRefreshToken)
+
+	// Store failures are not reported as invalid tokens
+	tokens.On("GetByID", mock.Anything, stored.ID).Return(nil, errors.New("db error")).Once()
+	_, err = service.Refresh(context.Background(), pair.RefreshToken)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, domain.ErrInvalidRefreshToken)
+
+	tokens.AssertNotCalled(t, "RevokeFamily", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTokenService_Logout(t *testing.T) {
+	users := new(MockUserRepository)
+	tokens := new(MockRefreshTokenRepository)
+	service := application.NewTokenService(users, tokens)
+	user, _ := domain.NewUser("Ana", "ana@example.com", "password123", domain.RoleManager)
+	pair, stored := login(t, service, tokens, user)
+
+	tokens.On("GetByID", mock.Anything, stored.ID).Return(stored, nil)
+	tokens.On("RevokeFamily", mock.Anything, stored.FamilyID, mock.Anything).Return(nil)
+	assert.NoError(t, service.Logout(context.Background(), pair.RefreshToken))
+
+	tokens.On("RevokeUser", mock.Anything, user.ID, mock.Anything).Return(nil)
+	assert.NoError(t, service.LogoutAll(context.Background(), user.ID))
+	tokens.AssertExpectations(t)
+}
+
+func TestTokenService_DeactivatedUser(t *testing.T) {
+	users := new(MockUserRepository)
+	tokens := new(MockRefreshTokenRepository)
+	service := application.NewTokenService(users, tokens)
+	user, _ := domain.NewUser("Ana", "ana@example.com", "password123", domain.RoleEmployee)
+	user.Deactivate()
+
+	_, err := service.Issue(context.Background(), user, "curl", []string{auth.AMRPassword})
+	assert.ErrorIs(t, err, domain.ErrUserDeactivated)
+	tokens.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestTokenService_Refresh_AfterPasswordChange(t *testing.T) {
+	users := new(MockUserRepository)
+	tokens := new(MockRefreshTokenRepository)
+	service := application.NewTokenService(users, tokens)
+	user, _ := domain.NewUser("Ana", "ana@example.com", "password123", domain.RoleEmployee)
+	pair, stored := login(t, service, tokens, user)
+
+	changed := stored.CreatedAt.Add(time.Second)
+	user.PasswordChangedAt = &changed
+	tokens.On("GetByID", mock.Anything, stored.ID).Return(stored, nil)
+	tokens.On("MarkUsed", mock.Anything, stored.ID, mock.Anything).Return(true, nil)
+	users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+
+	_, err := service.Refresh(context.Background(), pair.RefreshToken)
+	assert.ErrorIs(t, err, domain.ErrInvalidRefreshToken)
+	tokens.AssertNumberOfCalls(t, "Save", 1)
+}
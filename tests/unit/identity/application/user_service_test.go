@@ -0,0 +1,148 @@
+package application_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/identity/application"
+	"github.com/noggrj/autorepair/internal/identity/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestUserService_CreateCustomer(t *testing.T) {
+	users := new(MockUserRepository)
+	service := application.NewUserService(users, new(MockRefreshTokenRepository))
+	clientID := uuid.New()
+
+	users.On("Save", mock.Anything, mock.MatchedBy(func(u *domain.User) bool {
+		return u.Role == domain.RoleCustomer && u.ClientID != nil && *u.ClientID == clientID
+	})).Return(nil).Once()
+
+	got, err := service.CreateCustomer(context.Background(), clientID, "Maria", "maria@example.com")
+	assert.NoError(t, err)
+	assert.True(t, got.IsCustomer())
+	users.AssertExpectations(t)
+
+	users.On("Save", mock.Anything, mock.Anything).Return(domain.ErrClientAccountExists)
+	_, err = service.CreateCustomer(context.Background(), clientID, "Maria", "maria@example.com")
+	assert.ErrorIs(t, err, domain.ErrClientAccountExists)
+}
+
+func TestUserService_Deactivate(t *testing.T) {
+	users := new(MockUserRepository)
+	tokens := new(MockRefreshTokenRepository)
+	service := application.NewUserService(users, tokens)
+	user, _ := domain.NewUser("Ana", "ana@example.com", "password123", domain.RoleEmployee)
+
+	users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	users.On("Update", mock.Anything, user).Return(nil)
+	tokens.On("RevokeUser", mock.Anything, user.ID, mock.Anything).Return(nil)
+
+	got, err := service.Deactivate(context.Background(), uuid.New(), user.ID)
+	assert.NoError(t, err)
+	assert.False(t, got.IsActive())
+	users.AssertExpectations(t)
+	tokens.AssertExpectations(t)
+}
+
+func TestUserService_Deactivate_Self(t *testing.T) {
+	users := new(MockUserRepository)
+	service := application.NewUserService(users, new(MockRefreshTokenRepository))
+	id := uuid.New()
+
+	_, err := service.Deactivate(context.Background(), id, id)
+	assert.ErrorIs(t, err, application.ErrSelfModification)
+	users.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+}
+
+func TestUserService_Reactivate(t *testing.T) {
+	users := new(MockUserRepository)
+	tokens := new(MockRefreshTokenRepository)
+	service := application.NewUserService(users, tokens)
+	user, _ := domain.NewUser("Ana", "ana@example.com", "password123", domain.RoleEmployee)
+	user.Deactivate()
+
+	users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	users.On("Update", mock.Anything, user).Return(nil)
+
+	got, err := service.Reactivate(context.Background(), user.ID)
+	assert.NoError(t, err)
+	assert.True(t, got.IsActive())
+	tokens.AssertNotCalled(t, "RevokeUser", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUserService_ChangeRole(t *testing.T) {
+	users := new(MockUserRepository)
+	service := application.NewUserService(users, new(MockRefreshTokenRepository))
+	user, _ := domain.NewUser("Ana", "ana@example.com", "password123", domain.RoleEmployee)
+	users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	users.On("Update", mock.Anything, user).Return(nil).Once()
+
+	got, err := service.ChangeRole(context.Background(), uuid.New(), user.ID, domain.RoleManager)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.RoleManager, got.Role)
+
+	// Unknown roles are rejected before anything is written
+	_, err = service.ChangeRole(context.Background(), uuid.New(), user.ID, domain.Role("root"))
+	assert.ErrorIs(t, err, domain.ErrInvalidRole)
+
+	// Admins cannot demote themselves
+	_, err = service.ChangeRole(context.Background(), user.ID, user.ID, domain.RoleEmployee)
+	assert.ErrorIs(t, err, application.ErrSelfModification)
+	users.AssertNumberOfCalls(t, "Update", 1)
+}
+
+func TestUserService_ResetPassword(t *testing.T) {
+	users := new(MockUserRepository)
+	tokens := new(MockRefreshTokenRepository)
+	service := application.NewUserService(users, tokens)
+	user, _ := domain.NewUser("Ana", "ana@example.com", "password123", domain.RoleEmployee)
+
+	users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	users.On("Update", mock.Anything, user).Return(nil)
+	tokens.On("RevokeUser", mock.Anything, user.ID, mock.Anything).Return(nil)
+
+	_, err := service.ResetPassword(context.Background(), user.ID, "weak")
+	assert.ErrorIs(t, err, domain.ErrWeakPassword)
+
+	_, err = service.ResetPassword(context.Background(), user.ID, "newpassword1")
+	assert.NoError(t, err)
+	assert.True(t, user.CheckPassword("newpassword1"))
+	tokens.AssertNumberOfCalls(t, "RevokeUser", 1)
+}
+
+func TestUserService_ResetMFA(t *testing.T) {
+	users := new(MockUserRepository)
+	tokens := new(MockRefreshTokenRepository)
+	service := application.NewUserService(users, tokens)
+	user, _ := domain.NewUser("Ana", "ana@example.com", "password123", domain.RoleManager)
+	_ = user.StartMFAEnrolment("SECRET")
+	_ = user.EnableMFA()
+
+	users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	users.On("Update", mock.Anything, user).Return(nil)
+	tokens.On("RevokeUser", mock.Anything, user.ID, mock.Anything).Return(nil)
+
+	_, err := service.ResetMFA(context.Background(), user.ID)
+	assert.NoError(t, err)
+	assert.False(t, user.MFAEnabled())
+	assert.Empty(t, user.TOTPSecret)
+	tokens.AssertExpectations(t)
+}
+
+func TestUserService_NotFound(t *testing.T) {
+	users := new(MockUserRepository)
+	service := application.NewUserService(users, new(MockRefreshTokenRepository))
+	id := uuid.New()
+	users.On("GetByID", mock.Anything, id).Return(nil, domain.ErrUserNotFound)
+
+	_, err := service.Reactivate(context.Background(), id)
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+
+	users.On("GetByID", mock.Anything, mock.Anything).Return(nil, errors.New("db error"))
+	_, err = service.ResetPassword(context.Background(), uuid.New(), "newpassword1")
+	assert.Error(t, err)
+}
@@ -2,14 +2,20 @@ package http_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	identityApplication "github.com/noggrj/autorepair/internal/identity/application"
 	identityHttp "github.com/noggrj/autorepair/internal/identity/delivery/http"
 	identityDomain "github.com/noggrj/autorepair/internal/identity/domain"
+	identityInfra "github.com/noggrj/autorepair/internal/identity/infrastructure"
+	notificationInfra "github.com/noggrj/autorepair/internal/notification/infrastructure"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -20,39 +26,144 @@ type MockUserRepository struct {
 	mock.Mock
 }
 
-func (m *MockUserRepository) Save(user *identityDomain.User) error {
-	args := m.Called(user)
+func (m *MockUserRepository) Save(ctx context.Context, user *identityDomain.User) error {
+	args := m.Called(ctx, user)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) GetByEmail(email string) (*identityDomain.User, error) {
-	args := m.Called(email)
+func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*identityDomain.User, error) {
+	args := m.Called(ctx, email)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*identityDomain.User), args.Error(1)
 }
 
-func (m *MockUserRepository) GetByID(id uuid.UUID) (*identityDomain.User, error) {
-	args := m.Called(id)
+func (m *MockUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*identityDomain.User, error) {
+	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*identityDomain.User), args.Error(1)
 }
 
+func (m *MockUserRepository) Update(ctx context.Context, user *identityDomain.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*identityDomain.User], error) {
+	args := m.Called(ctx, q)
+	return args.Get(0).(sharedkernel.Page[*identityDomain.User]), args.Error(1)
+}
+
+func (m *MockUserRepository) ListByRole(ctx context.Context, role identityDomain.Role) ([]*identityDomain.User, error) {
+	args := m.Called(ctx, role)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*identityDomain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) UseTOTPStep(ctx context.Context, id uuid.UUID, step int64) (bool, error) {
+	args := m.Called(ctx, id, step)
+	return args.Bool(0), args.Error(1)
+}
+
+type MockRefreshTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenRepository) Save(ctx context.Context, token *identityDomain.RefreshToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) GetByID(ctx context.Context, id uuid.UUID) (*identityDomain.RefreshToken, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*identityDomain.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) MarkUsed(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	args := m.Called(ctx, id, at)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID, at time.Time) error {
+	args := m.Called(ctx, familyID, at)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) RevokeUser(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	args := m.Called(ctx, userID, at)
+	return args.Error(0)
+}
+
+type MockUserTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserTokenRepository) Save(ctx context.Context, token *identityDomain.UserToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockUserTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*identityDomain.UserToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*identityDomain.UserToken), args.Error(1)
+}
+
+func (m *MockUserTokenRepository) MarkUsed(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	args := m.Called(ctx, id, at)
+	return args.Bool(0), args.Error(1)
+}
+
+type MockRecoveryCodeRepository struct {
+	mock.Mock
+}
+
+func (m *MockRecoveryCodeRepository) Replace(ctx context.Context, userID uuid.UUID, hashes []string) error {
+	args := m.Called(ctx, userID, hashes)
+	return args.Error(0)
+}
+
+func (m *MockRecoveryCodeRepository) Use(ctx context.Context, userID uuid.UUID, hash string, at time.Time) (bool, error) {
+	args := m.Called(ctx, userID, hash, at)
+	return args.Bool(0), args.Error(1)
+}
+
+func newAuthHandler(users *MockUserRepository, tokens *MockRefreshTokenRepository) *identityHttp.AuthHandler {
+	guard := identityApplication.NewLoginGuard(identityInfra.NewMemoryLoginAttemptRepository(), identityInfra.NewMemoryAuditLog())
+	// Registration mails a verification token; tests that care about it
+	// build their own AccountService.
+	userTokens := new(MockUserTokenRepository)
+	userTokens.On("Save", mock.Anything, mock.Anything).Return(nil).Maybe()
+	mailer := new(notificationInfra.MockEmailService)
+	mailer.On("SendEmail", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	accounts := identityApplication.NewAccountService(users, userTokens, tokens, mailer)
+	mfa := identityApplication.NewMFAService(users, new(MockRecoveryCodeRepository))
+	return identityHttp.NewAuthHandler(users, identityApplication.NewTokenService(users, tokens), guard, accounts, mfa)
+}
+
 // --- Tests ---
 
 func TestAuthHandler_Login(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	// NewAuthHandler only takes repo now
-	handler := identityHttp.NewAuthHandler(mockRepo)
+	tokenRepo := new(MockRefreshTokenRepository)
+	handler := newAuthHandler(mockRepo, tokenRepo)
 
 	email := "test@test.com"
 	password := "password123"
 	user, _ := identityDomain.NewUser("Test User", email, password, "admin")
 
-	mockRepo.On("GetByEmail", email).Return(user, nil)
+	mockRepo.On("GetByEmail", mock.Anything, email).Return(user, nil)
+	tokenRepo.On("Save", mock.Anything, mock.AnythingOfType("*domain.RefreshToken")).Return(nil)
 
 	reqBody := map[string]string{
 		"email":    email,
@@ -75,13 +186,13 @@ func TestAuthHandler_Login(t *testing.T) {
 
 func TestAuthHandler_Login_InvalidCredentials(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	handler := identityHttp.NewAuthHandler(mockRepo)
+	handler := newAuthHandler(mockRepo, new(MockRefreshTokenRepository))
 
 	email := "test@test.com"
 	password := "wrongpassword"
-	user, _ := identityDomain.NewUser("Test User", email, "correctpassword", "admin")
+	user, _ := identityDomain.NewUser("Test User", email, "correctpassword1", "admin")
 
-	mockRepo.On("GetByEmail", email).Return(user, nil)
+	mockRepo.On("GetByEmail", mock.Anything, email).Return(user, nil)
 
 	reqBody := map[string]string{
 		"email":    email,
@@ -0,0 +1,56 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/noggrj/autorepair/internal/identity/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginPolicy_RetryAfter(t *testing.T) {
+	policy := domain.LoginPolicy{
+		FreeFailures: 3,
+		BaseDelay:    time.Second,
+		MaxDelay:     8 * time.Second,
+		LockAfter:    10,
+		LockFor:      15 * time.Minute,
+		Window:       15 * time.Minute,
+	}
+	now := time.Now()
+	attempts := func(failures int, ago time.Duration) *domain.LoginAttempts {
+		return &domain.LoginAttempts{Failures: failures, LastFailureAt: now.Add(-ago)}
+	}
+
+	tests := []struct {
+		name     string
+		attempts *domain.LoginAttempts
+		want     time.Duration
+	}{
+		{"No failures", &domain.LoginAttempts{}, 0},
+		{"Free failures", attempts(2, 0), 0},
+		{"First delay", attempts(3, 0), time.Second},
+		{"Delay doubles", attempts(5, 0), 4 * time.Second},
+		{"Delay capped", attempts(9, 0), 8 * time.Second},
+		{"Delay partly waited", attempts(5, 3*time.Second), time.Second},
+		{"Delay waited", attempts(5, 5*time.Second), 0},
+		{"Run expired", attempts(9, 20*time.Minute), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, policy.RetryAfter(tt.attempts, now))
+		})
+	}
+
+	t.Run("Locked", func(t *testing.T) {
+		until := now.Add(10 * time.Minute)
+		a := &domain.LoginAttempts{Failures: 10, LastFailureAt: now.Add(-20 * time.Minute), LockedUntil: &until}
+		assert.Equal(t, 10*time.Minute, policy.RetryAfter(a, now))
+	})
+
+	t.Run("Lock expired", func(t *testing.T) {
+		until := now.Add(-time.Second)
+		a := &domain.LoginAttempts{Failures: 10, LastFailureAt: now.Add(-20 * time.Minute), LockedUntil: &until}
+		assert.Zero(t, policy.RetryAfter(a, now))
+	})
+}
@@ -0,0 +1,101 @@
+package domain_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/noggrj/autorepair/internal/identity/domain"
+)
+
+func TestRole_RequiresMFA(t *testing.T) {
+	if !domain.RoleAdmin.RequiresMFA() || !domain.RoleManager.RequiresMFA() {
+		t.Error("Expected admins and managers to require two-factor authentication")
+	}
+	if domain.RoleEmployee.RequiresMFA() {
+		t.Error("Expected two-factor authentication to be optional for employees")
+	}
+}
+
+func TestUser_MFALifecycle(t *testing.T) {
+	user, _ := domain.NewUser("Test User", "test@example.com", "password123", domain.RoleManager)
+
+	if err := user.EnableMFA(); !errors.Is(err, domain.ErrMFANotEnrolled) {
+		t.Errorf("Expected ErrMFANotEnrolled before enrolment, got %v", err)
+	}
+
+	if err := user.StartMFAEnrolment("SECRET"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if user.MFAEnabled() {
+		t.Error("Expected two-factor authentication to stay off until confirmed")
+	}
+	if err := user.EnableMFA(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !user.MFAEnabled() {
+		t.Error("Expected two-factor authentication to be on")
+	}
+
+	if err := user.StartMFAEnrolment("OTHER"); !errors.Is(err, domain.ErrMFAAlreadyEnabled) {
+		t.Errorf("Expected ErrMFAAlreadyEnabled, got %v", err)
+	}
+	if user.TOTPSecret != "SECRET" {
+		t.Error("Expected the enabled secret to be kept")
+	}
+
+	user.DisableMFA()
+	if user.MFAEnabled() || user.TOTPSecret != "" {
+		t.Error("Expected two-factor authentication to be off and the secret gone")
+	}
+}
+
+func TestUser_AcceptTOTPStep(t *testing.T) {
+	user, _ := domain.NewUser("Test User", "test@example.com", "password123", domain.RoleManager)
+
+	if !user.AcceptTOTPStep(100) {
+		t.Error("Expected a first code to be accepted")
+	}
+	if user.AcceptTOTPStep(100) {
+		t.Error("Expected the same code not to be accepted twice")
+	}
+	if user.AcceptTOTPStep(99) {
+		t.Error("Expected an older code not to be accepted")
+	}
+	if !user.AcceptTOTPStep(101) {
+		t.Error("Expected the next code to be accepted")
+	}
+}
+
+func TestNewRecoveryCodes(t *testing.T) {
+	codes, hashes, err := domain.NewRecoveryCodes()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(codes) != domain.RecoveryCodeCount || len(hashes) != domain.RecoveryCodeCount {
+		t.Fatalf("Expected %d codes, got %d", domain.RecoveryCodeCount, len(codes))
+	}
+
+	seen := map[string]bool{}
+	for i, code := range codes {
+		if len(code) != 19 || strings.Count(code, "-") != 3 {
+			t.Errorf("Expected a code like xxxx-xxxx-xxxx-xxxx, got %q", code)
+		}
+		if hashes[i] != domain.HashRecoveryCode(code) {
+			t.Errorf("Expected hash %d to match code %q", i, code)
+		}
+		if seen[code] {
+			t.Errorf("Expected every code to be different, got %q twice", code)
+		}
+		seen[code] = true
+	}
+}
+
+func TestHashRecoveryCode_IgnoresFormatting(t *testing.T) {
+	want := domain.HashRecoveryCode("abcd-efgh-ijkl-mnop")
+	for _, typed := range []string{"ABCD-EFGH-IJKL-MNOP", "abcdefghijklmnop", " abcd efgh ijkl mnop "} {
+		if domain.HashRecoveryCode(typed) != want {
+			t.Errorf("Expected %q to match the code", typed)
+		}
+	}
+}
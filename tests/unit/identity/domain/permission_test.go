@@ -0,0 +1,55 @@
+package domain_test
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/noggrj/autorepair/internal/identity/domain"
+)
+
+func TestRole_Can(t *testing.T) {
+	tests := []struct {
+		role       domain.Role
+		permission domain.Permission
+		want       bool
+	}{
+		{domain.RoleEmployee, domain.PermOrdersWrite, true},
+		{domain.RoleEmployee, domain.PermClientsRead, true},
+		{domain.RoleEmployee, domain.PermClientsDelete, false},
+		{domain.RoleEmployee, domain.PermReportsRead, false},
+		{domain.RoleEmployee, domain.PermOrdersApprove, false},
+		{domain.RoleManager, domain.PermClientsDelete, true},
+		{domain.RoleManager, domain.PermReportsRead, true},
+		{domain.RoleManager, domain.PermUsersManage, false},
+		{domain.RoleAdmin, domain.PermUsersManage, true},
+		{domain.RoleAdmin, domain.PermOrdersApprove, true},
+		{domain.Role("guest"), domain.PermClientsRead, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.role.Can(tt.permission); got != tt.want {
+			t.Errorf("%s.Can(%s) = %v, want %v", tt.role, tt.permission, got, tt.want)
+		}
+	}
+}
+
+func TestRole_Permissions(t *testing.T) {
+	employee := domain.RoleEmployee.Permissions()
+	manager := domain.RoleManager.Permissions()
+	admin := domain.RoleAdmin.Permissions()
+
+	if !sort.SliceIsSorted(admin, func(i, j int) bool { return admin[i] < admin[j] }) {
+		t.Errorf("Expected permissions to be sorted, got %v", admin)
+	}
+	if !(len(employee) < len(manager) && len(manager) < len(admin)) {
+		t.Errorf("Expected employee < manager < admin, got %d, %d, %d", len(employee), len(manager), len(admin))
+	}
+	for _, p := range manager {
+		if !domain.RoleAdmin.Can(p) {
+			t.Errorf("Expected admin to hold manager permission %s", p)
+		}
+	}
+	if got := domain.Role("guest").Permissions(); len(got) != 0 {
+		t.Errorf("Expected no permissions for an unknown role, got %v", got)
+	}
+}
@@ -1,13 +1,16 @@
 package domain_test
 
 import (
+	"errors"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/noggrj/autorepair/internal/identity/domain"
 )
 
 func TestNewUser(t *testing.T) {
-	user, err := domain.NewUser("Test User", "test@example.com", "password", domain.RoleAdmin)
+	user, err := domain.NewUser("Test User", "test@example.com", "password123", domain.RoleAdmin)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -16,7 +19,7 @@ func TestNewUser(t *testing.T) {
 		t.Errorf("Expected email test@example.com, got %s", user.Email)
 	}
 
-	if !user.CheckPassword("password") {
+	if !user.CheckPassword("password123") {
 		t.Error("Expected password validation to pass")
 	}
 
@@ -24,3 +27,141 @@ func TestNewUser(t *testing.T) {
 		t.Error("Expected password validation to fail")
 	}
 }
+
+func TestNewUser_Validation(t *testing.T) {
+	tests := []struct {
+		name, email, password string
+		role                  domain.Role
+		want                  error
+	}{
+		{"", "test@example.com", "password123", domain.RoleEmployee, domain.ErrInvalidName},
+		{"Test", "not-an-email", "password123", domain.RoleEmployee, domain.ErrInvalidEmail},
+		{"Test", "Test <test@example.com>", "password123", domain.RoleEmployee, domain.ErrInvalidEmail},
+		{"Test", "test@example.com", "short1", domain.RoleEmployee, domain.ErrWeakPassword},
+		{"Test", "test@example.com", "onlyletters", domain.RoleEmployee, domain.ErrWeakPassword},
+		{"Test", "test@example.com", "12345678", domain.RoleEmployee, domain.ErrWeakPassword},
+		{"Test", "test@example.com", "password123", domain.Role("root"), domain.ErrInvalidRole},
+	}
+
+	for _, tt := range tests {
+		if _, err := domain.NewUser(tt.name, tt.email, tt.password, tt.role); !errors.Is(err, tt.want) {
+			t.Errorf("NewUser(%q, %q, %q, %q) error = %v, want %v", tt.name, tt.email, tt.password, tt.role, err, tt.want)
+		}
+	}
+
+	user, err := domain.NewUser("  Test  ", " Test@Example.COM ", "password123", domain.RoleEmployee)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if user.Name != "Test" || user.Email != "test@example.com" {
+		t.Errorf("Expected trimmed name and lower-cased email, got %q and %q", user.Name, user.Email)
+	}
+}
+
+func TestUser_Lifecycle(t *testing.T) {
+	user, _ := domain.NewUser("Test User", "test@example.com", "password123", domain.RoleEmployee)
+	if !user.IsActive() {
+		t.Fatal("Expected a new user to be active")
+	}
+
+	user.Deactivate()
+	if user.IsActive() || user.DeactivatedAt == nil {
+		t.Error("Expected the user to be deactivated")
+	}
+	user.Reactivate()
+	if !user.IsActive() {
+		t.Error("Expected the user to be active again")
+	}
+
+	if err := user.ChangeRole(domain.Role("root")); !errors.Is(err, domain.ErrInvalidRole) {
+		t.Errorf("Expected ErrInvalidRole, got %v", err)
+	}
+	if err := user.ChangeRole(domain.RoleManager); err != nil || user.Role != domain.RoleManager {
+		t.Errorf("Expected the role to change to manager, got %v (%v)", user.Role, err)
+	}
+
+	if err := user.SetPassword("weak"); !errors.Is(err, domain.ErrWeakPassword) {
+		t.Errorf("Expected ErrWeakPassword, got %v", err)
+	}
+	if err := user.SetPassword("newpassword1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !user.CheckPassword("newpassword1") || user.CheckPassword("password123") {
+		t.Error("Expected only the new password to match")
+	}
+	if user.PasswordChangedAt == nil {
+		t.Fatal("Expected the password change to be recorded")
+	}
+	if !user.IssuedBeforePasswordChange(user.PasswordChangedAt.Add(-time.Second)) || user.IssuedBeforePasswordChange(time.Now()) {
+		t.Error("Expected only things issued before the change to predate it")
+	}
+}
+
+func TestUser_VerifyEmail(t *testing.T) {
+	user, _ := domain.NewUser("Test User", "test@example.com", "password123", domain.RoleEmployee)
+	if user.IsEmailVerified() || user.PasswordChangedAt != nil {
+		t.Fatal("Expected a new user to be unverified and without a password change")
+	}
+	if user.IssuedBeforePasswordChange(time.Now().Add(-time.Hour)) {
+		t.Error("Expected nothing to predate a password that never changed")
+	}
+
+	user.VerifyEmail()
+	verifiedAt := user.EmailVerifiedAt
+	if !user.IsEmailVerified() {
+		t.Error("Expected the email to be verified")
+	}
+	user.VerifyEmail()
+	if user.EmailVerifiedAt != verifiedAt {
+		t.Error("Expected a second verification to keep the first timestamp")
+	}
+}
+
+func TestNewCustomer(t *testing.T) {
+	clientID := uuid.New()
+	user, err := domain.NewCustomer(clientID, " Maria ", "Maria@Example.com")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if user.Role != domain.RoleCustomer || user.ClientID == nil || *user.ClientID != clientID {
+		t.Errorf("Expected a customer linked to %s, got %v linked to %v", clientID, user.Role, user.ClientID)
+	}
+	if !user.IsCustomer() {
+		t.Error("Expected the user to be a customer")
+	}
+	if user.CheckPassword("") {
+		t.Error("Expected a new customer to have no usable password")
+	}
+	if _, err := domain.NewCustomer(clientID, "Maria", "not-an-email"); !errors.Is(err, domain.ErrInvalidEmail) {
+		t.Errorf("Expected ErrInvalidEmail, got %v", err)
+	}
+
+	if domain.RoleCustomer.Valid() {
+		t.Error("Expected the customer role not to be a staff role")
+	}
+	if _, err := domain.NewUser("Maria", "maria@example.com", "password123", domain.RoleCustomer); !errors.Is(err, domain.ErrInvalidRole) {
+		t.Errorf("Expected NewUser to refuse the customer role, got %v", err)
+	}
+	if err := user.ChangeRole(domain.RoleAdmin); !errors.Is(err, domain.ErrCustomerAccount) {
+		t.Errorf("Expected ErrCustomerAccount, got %v", err)
+	}
+
+	staff, _ := domain.NewUser("Ana", "ana@example.com", "password123", domain.RoleEmployee)
+	if staff.IsCustomer() {
+		t.Error("Expected a staff user not to be a customer")
+	}
+}
+
+func TestRefreshToken_Matches(t *testing.T) {
+	token := domain.NewRefreshToken(uuid.New(), uuid.New(), uuid.New(), "signed.refresh.token", "curl", nil, time.Now().Add(time.Hour))
+
+	if token.TokenHash == "signed.refresh.token" {
+		t.Error("Expected the token to be stored hashed")
+	}
+	if !token.Matches("signed.refresh.token") {
+		t.Error("Expected the issued token to match")
+	}
+	if token.Matches("another.refresh.token") {
+		t.Error("Expected a different token not to match")
+	}
+}
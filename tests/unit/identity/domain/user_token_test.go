@@ -0,0 +1,56 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/noggrj/autorepair/internal/identity/domain"
+)
+
+func TestNewUserToken(t *testing.T) {
+	user, _ := domain.NewUser("Test User", "test@example.com", "password123", domain.RoleEmployee)
+
+	stored, token, err := domain.NewUserToken(user.ID, domain.TokenPurposePasswordReset, time.Hour)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if stored.TokenHash == token || stored.TokenHash != domain.HashToken(token) {
+		t.Error("Expected the token to be stored hashed")
+	}
+	if len(token) < 43 {
+		t.Errorf("Expected at least 256 bits of token, got %q", token)
+	}
+
+	_, other, _ := domain.NewUserToken(user.ID, domain.TokenPurposePasswordReset, time.Hour)
+	if other == token {
+		t.Error("Expected every token to be different")
+	}
+}
+
+func TestUserToken_Redeemable(t *testing.T) {
+	user, _ := domain.NewUser("Test User", "test@example.com", "password123", domain.RoleEmployee)
+	stored, _, _ := domain.NewUserToken(user.ID, domain.TokenPurposePasswordReset, time.Hour)
+	now := time.Now()
+
+	if !stored.Redeemable(domain.TokenPurposePasswordReset, user, now) {
+		t.Error("Expected a fresh token to be redeemable")
+	}
+	if stored.Redeemable(domain.TokenPurposeEmailVerification, user, now) {
+		t.Error("Expected a reset token not to verify an email")
+	}
+	if stored.Redeemable(domain.TokenPurposePasswordReset, user, now.Add(2*time.Hour)) {
+		t.Error("Expected an expired token not to be redeemable")
+	}
+
+	used := *stored
+	used.UsedAt = &now
+	if used.Redeemable(domain.TokenPurposePasswordReset, user, now) {
+		t.Error("Expected a used token not to be redeemable")
+	}
+
+	changed := now.Add(time.Second)
+	user.PasswordChangedAt = &changed
+	if stored.Redeemable(domain.TokenPurposePasswordReset, user, now) {
+		t.Error("Expected a token issued before a password change not to be redeemable")
+	}
+}
@@ -0,0 +1,113 @@
+package infrastructure_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/noggrj/autorepair/internal/identity/domain"
+	"github.com/noggrj/autorepair/internal/identity/infrastructure"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresLoginAttemptRepository_Get(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresLoginAttemptRepository(mock)
+	now := time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT failures, last_failure_at, locked_until FROM login_attempts WHERE scope = $1 AND key = $2`)).
+		WithArgs(domain.LoginScopeEmail, "ana@example.com").
+		WillReturnRows(pgxmock.NewRows([]string{"failures", "last_failure_at", "locked_until"}).AddRow(4, now, nil))
+
+	a, err := repo.Get(context.Background(), domain.LoginScopeEmail, "ana@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, a.Failures)
+	assert.Equal(t, "ana@example.com", a.Key)
+
+	// Nothing recorded
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT failures`)).
+		WithArgs(domain.LoginScopeIP, "10.0.0.1").
+		WillReturnError(pgx.ErrNoRows)
+
+	a, err = repo.Get(context.Background(), domain.LoginScopeIP, "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Zero(t, a.Failures)
+	assert.Nil(t, a.LockedUntil)
+
+	// Error
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT failures`)).
+		WithArgs(domain.LoginScopeIP, "10.0.0.1").
+		WillReturnError(errors.New("db error"))
+
+	_, err = repo.Get(context.Background(), domain.LoginScopeIP, "10.0.0.1")
+	assert.Error(t, err)
+}
+
+func TestPostgresLoginAttemptRepository_RecordFailure(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresLoginAttemptRepository(mock)
+	now := time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO login_attempts (scope, key, failures, last_failure_at)`)).
+		WithArgs(domain.LoginScopeEmail, "ana@example.com", now, now.Add(-15*time.Minute)).
+		WillReturnRows(pgxmock.NewRows([]string{"failures", "last_failure_at", "locked_until"}).AddRow(3, now, nil))
+
+	a, err := repo.RecordFailure(context.Background(), domain.LoginScopeEmail, "ana@example.com", now, 15*time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, a.Failures)
+	assert.Equal(t, now, a.LastFailureAt)
+}
+
+func TestPostgresLoginAttemptRepository_LockAndReset(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresLoginAttemptRepository(mock)
+	until := time.Now().Add(15 * time.Minute)
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE login_attempts SET locked_until = $3 WHERE scope = $1 AND key = $2`)).
+		WithArgs(domain.LoginScopeIP, "10.0.0.1", until).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	assert.NoError(t, repo.Lock(context.Background(), domain.LoginScopeIP, "10.0.0.1", until))
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM login_attempts WHERE scope = $1 AND key = $2`)).
+		WithArgs(domain.LoginScopeIP, "10.0.0.1").
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	assert.NoError(t, repo.Reset(context.Background(), domain.LoginScopeIP, "10.0.0.1"))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresAuditLog_Record(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	log := infrastructure.NewPostgresAuditLog(mock)
+	entry := domain.NewAuditEntry(domain.AuditLoginLocked, nil, "ana@example.com", "10.0.0.1", "email locked")
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO audit_log (id, event, actor_id, subject, ip, detail, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`)).
+		WithArgs(entry.ID, entry.Event, entry.ActorID, "ana@example.com", "10.0.0.1", "email locked", entry.CreatedAt).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	assert.NoError(t, log.Record(context.Background(), entry))
+}
@@ -0,0 +1,81 @@
+package infrastructure_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/identity/infrastructure"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresRecoveryCodeRepository_Replace(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresRecoveryCodeRepository(mock)
+	userID := uuid.New()
+	hashes := []string{"hash1", "hash2"}
+
+	// Success
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM recovery_codes WHERE user_id = $1`)).
+		WithArgs(userID).
+		WillReturnResult(pgxmock.NewResult("DELETE", 10))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO recovery_codes (user_id, code_hash) SELECT $1, unnest($2::text[])`)).
+		WithArgs(userID, hashes).
+		WillReturnResult(pgxmock.NewResult("INSERT", 2))
+	mock.ExpectCommit()
+
+	assert.NoError(t, repo.Replace(context.Background(), userID, hashes))
+
+	// The old codes survive a failed insert
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM recovery_codes`)).
+		WithArgs(userID).
+		WillReturnResult(pgxmock.NewResult("DELETE", 2))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO recovery_codes`)).
+		WithArgs(userID, hashes).
+		WillReturnError(errors.New("db error"))
+	mock.ExpectRollback()
+
+	assert.Error(t, repo.Replace(context.Background(), userID, hashes))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRecoveryCodeRepository_Use(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresRecoveryCodeRepository(mock)
+	userID := uuid.New()
+	now := time.Now()
+	query := regexp.QuoteMeta(`UPDATE recovery_codes SET used_at = $3 WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`)
+
+	mock.ExpectExec(query).WithArgs(userID, "hash1", now).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	used, err := repo.Use(context.Background(), userID, "hash1", now)
+	assert.NoError(t, err)
+	assert.True(t, used)
+
+	// Already used, or not one of the user's codes
+	mock.ExpectExec(query).WithArgs(userID, "hash1", now).WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	used, err = repo.Use(context.Background(), userID, "hash1", now)
+	assert.NoError(t, err)
+	assert.False(t, used)
+
+	mock.ExpectExec(query).WithArgs(userID, "hash1", now).WillReturnError(errors.New("db error"))
+	_, err = repo.Use(context.Background(), userID, "hash1", now)
+	assert.Error(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,119 @@
+package infrastructure_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/noggrj/autorepair/internal/identity/domain"
+	"github.com/noggrj/autorepair/internal/identity/infrastructure"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresRefreshTokenRepository_SaveAndGet(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresRefreshTokenRepository(mock)
+	token := domain.NewRefreshToken(uuid.New(), uuid.New(), uuid.New(), "signed.refresh.token", "curl", nil, time.Now().Add(time.Hour))
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, device, amr, expires_at, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`)).
+		WithArgs(token.ID, token.UserID, token.FamilyID, token.TokenHash, "curl", []string{}, token.ExpiresAt, token.CreatedAt).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	assert.NoError(t, repo.Save(context.Background(), token))
+
+	// Get
+	usedAt := time.Now()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, user_id, family_id, token_hash, device, amr, expires_at, created_at, used_at, revoked_at FROM refresh_tokens WHERE id = $1`)).
+		WithArgs(token.ID).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "user_id", "family_id", "token_hash", "device", "amr", "expires_at", "created_at", "used_at", "revoked_at"}).
+			AddRow(token.ID, token.UserID, token.FamilyID, token.TokenHash, "curl", []string{"pwd"}, token.ExpiresAt, token.CreatedAt, &usedAt, nil))
+
+	fetched, err := repo.GetByID(context.Background(), token.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, token.FamilyID, fetched.FamilyID)
+	assert.True(t, fetched.Matches("signed.refresh.token"))
+	assert.Equal(t, []string{"pwd"}, fetched.AMR)
+	assert.NotNil(t, fetched.UsedAt)
+	assert.Nil(t, fetched.RevokedAt)
+
+	// Not Found
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id`)).
+		WithArgs(token.ID).
+		WillReturnError(pgx.ErrNoRows)
+
+	_, err = repo.GetByID(context.Background(), token.ID)
+	assert.ErrorIs(t, err, domain.ErrRefreshTokenNotFound)
+}
+
+func TestPostgresRefreshTokenRepository_MarkUsed(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresRefreshTokenRepository(mock)
+	id := uuid.New()
+	now := time.Now()
+
+	// Only an unused, unrevoked token can be rotated
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE refresh_tokens SET used_at = $2 WHERE id = $1 AND used_at IS NULL AND revoked_at IS NULL`)).
+		WithArgs(id, now).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	rotated, err := repo.MarkUsed(context.Background(), id, now)
+	assert.NoError(t, err)
+	assert.True(t, rotated)
+
+	// Lost the race
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE refresh_tokens SET used_at`)).
+		WithArgs(id, now).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+	rotated, err = repo.MarkUsed(context.Background(), id, now)
+	assert.NoError(t, err)
+	assert.False(t, rotated)
+
+	// DB Error
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE refresh_tokens SET used_at`)).
+		WillReturnError(errors.New("db error"))
+
+	_, err = repo.MarkUsed(context.Background(), id, now)
+	assert.Error(t, err)
+}
+
+func TestPostgresRefreshTokenRepository_Revoke(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresRefreshTokenRepository(mock)
+	familyID := uuid.New()
+	userID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE refresh_tokens SET revoked_at = $2 WHERE family_id = $1 AND revoked_at IS NULL`)).
+		WithArgs(familyID, now).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 3))
+
+	assert.NoError(t, repo.RevokeFamily(context.Background(), familyID, now))
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE refresh_tokens SET revoked_at = $2 WHERE user_id = $1 AND revoked_at IS NULL`)).
+		WithArgs(userID, now).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 5))
+
+	assert.NoError(t, repo.RevokeUser(context.Background(), userID, now))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -1,6 +1,7 @@
 package infrastructure_test
 
 import (
+	"context"
 	"errors"
 	"regexp"
 	"testing"
@@ -8,8 +9,10 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/noggrj/autorepair/internal/identity/domain"
 	"github.com/noggrj/autorepair/internal/identity/infrastructure"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
 	"github.com/pashagolub/pgxmock/v4"
 	"github.com/stretchr/testify/assert"
 )
@@ -22,21 +25,21 @@ func TestPostgresUserRepository_Save(t *testing.T) {
 	defer mock.Close()
 
 	repo := infrastructure.NewPostgresUserRepository(mock)
-	user, _ := domain.NewUser("John Doe", "john@example.com", "password", domain.RoleAdmin)
+	user, _ := domain.NewUser("John Doe", "john@example.com", "password123", domain.RoleAdmin)
 
 	// Success
-	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO users (id, name, email, password_hash, role, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`)).
-		WithArgs(user.ID, user.Name, user.Email, user.Password, user.Role, user.CreatedAt, user.UpdatedAt).
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO users (id, name, email, password_hash, role, deactivated_at, email_verified_at, password_changed_at, totp_secret, mfa_enabled_at, totp_last_step, client_id, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`)).
+		WithArgs(user.ID, user.Name, user.Email, user.Password, user.Role, user.DeactivatedAt, user.EmailVerifiedAt, user.PasswordChangedAt, user.TOTPSecret, user.MFAEnabledAt, user.TOTPLastStep, user.ClientID, user.CreatedAt, user.UpdatedAt).
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
-	err = repo.Save(user)
+	err = repo.Save(context.Background(), user)
 	assert.NoError(t, err)
 
 	// Error
 	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO users`)).
 		WillReturnError(errors.New("db error"))
 
-	err = repo.Save(user)
+	err = repo.Save(context.Background(), user)
 	assert.Error(t, err)
 }
 
@@ -53,14 +56,14 @@ func TestPostgresUserRepository_GetByEmail(t *testing.T) {
 	now := time.Now()
 
 	// Success
-	rows := pgxmock.NewRows([]string{"id", "name", "email", "password_hash", "role", "created_at", "updated_at"}).
-		AddRow(id, "John Doe", email, "hashed_pass", "admin", now, now)
+	rows := pgxmock.NewRows([]string{"id", "name", "email", "password_hash", "role", "deactivated_at", "email_verified_at", "password_changed_at", "totp_secret", "mfa_enabled_at", "totp_last_step", "client_id", "created_at", "updated_at"}).
+		AddRow(id, "John Doe", email, "hashed_pass", "admin", nil, nil, nil, "", nil, int64(0), nil, now, now)
 
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, email, password_hash, role, created_at, updated_at FROM users WHERE email = $1`)).
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, email, password_hash, role, deactivated_at, email_verified_at, password_changed_at, totp_secret, mfa_enabled_at, totp_last_step, client_id, created_at, updated_at FROM users WHERE lower(email) = lower($1)`)).
 		WithArgs(email).
 		WillReturnRows(rows)
 
-	user, err := repo.GetByEmail(email)
+	user, err := repo.GetByEmail(context.Background(), email)
 	assert.NoError(t, err)
 	assert.NotNil(t, user)
 	assert.Equal(t, email, user.Email)
@@ -71,7 +74,7 @@ func TestPostgresUserRepository_GetByEmail(t *testing.T) {
 		WithArgs(email).
 		WillReturnError(pgx.ErrNoRows)
 
-	_, err = repo.GetByEmail(email)
+	_, err = repo.GetByEmail(context.Background(), email)
 	assert.Error(t, err)
 	assert.Equal(t, "user not found", err.Error())
 
@@ -79,18 +82,18 @@ func TestPostgresUserRepository_GetByEmail(t *testing.T) {
 	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id`)).
 		WillReturnError(errors.New("connection error"))
 
-	_, err = repo.GetByEmail(email)
+	_, err = repo.GetByEmail(context.Background(), email)
 	assert.Error(t, err)
 
 	// Scan Error
-	rowsScanErr := pgxmock.NewRows([]string{"id", "name", "email", "password_hash", "role", "created_at", "updated_at"}).
-		AddRow(id, "John Doe", email, "hashed_pass", "admin", "invalid-time", now)
+	rowsScanErr := pgxmock.NewRows([]string{"id", "name", "email", "password_hash", "role", "deactivated_at", "email_verified_at", "password_changed_at", "totp_secret", "mfa_enabled_at", "totp_last_step", "client_id", "created_at", "updated_at"}).
+		AddRow(id, "John Doe", email, "hashed_pass", "admin", nil, nil, nil, "", nil, int64(0), nil, "invalid-time", now)
 
 	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id`)).
 		WithArgs(email).
 		WillReturnRows(rowsScanErr)
 
-	_, err = repo.GetByEmail(email)
+	_, err = repo.GetByEmail(context.Background(), email)
 	assert.Error(t, err)
 }
 
@@ -107,14 +110,14 @@ func TestPostgresUserRepository_GetByID(t *testing.T) {
 	now := time.Now()
 
 	// Success
-	rows := pgxmock.NewRows([]string{"id", "name", "email", "password_hash", "role", "created_at", "updated_at"}).
-		AddRow(id, "John Doe", email, "hashed_pass", "admin", now, now)
+	rows := pgxmock.NewRows([]string{"id", "name", "email", "password_hash", "role", "deactivated_at", "email_verified_at", "password_changed_at", "totp_secret", "mfa_enabled_at", "totp_last_step", "client_id", "created_at", "updated_at"}).
+		AddRow(id, "John Doe", email, "hashed_pass", "admin", nil, nil, nil, "", nil, int64(0), nil, now, now)
 
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, email, password_hash, role, created_at, updated_at FROM users WHERE id = $1`)).
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, email, password_hash, role, deactivated_at, email_verified_at, password_changed_at, totp_secret, mfa_enabled_at, totp_last_step, client_id, created_at, updated_at FROM users WHERE id = $1`)).
 		WithArgs(id).
 		WillReturnRows(rows)
 
-	user, err := repo.GetByID(id)
+	user, err := repo.GetByID(context.Background(), id)
 	assert.NoError(t, err)
 	assert.NotNil(t, user)
 	assert.Equal(t, id, user.ID)
@@ -124,7 +127,7 @@ func TestPostgresUserRepository_GetByID(t *testing.T) {
 		WithArgs(id).
 		WillReturnError(pgx.ErrNoRows)
 
-	_, err = repo.GetByID(id)
+	_, err = repo.GetByID(context.Background(), id)
 	assert.Error(t, err)
 	assert.Equal(t, "user not found", err.Error())
 
@@ -132,17 +135,176 @@ func TestPostgresUserRepository_GetByID(t *testing.T) {
 	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id`)).
 		WillReturnError(errors.New("connection error"))
 
-	_, err = repo.GetByID(id)
+	_, err = repo.GetByID(context.Background(), id)
 	assert.Error(t, err)
 
 	// Scan Error
-	rowsScanErr := pgxmock.NewRows([]string{"id", "name", "email", "password_hash", "role", "created_at", "updated_at"}).
-		AddRow(id, "John Doe", email, "hashed_pass", "admin", "invalid-time", now)
+	rowsScanErr := pgxmock.NewRows([]string{"id", "name", "email", "password_hash", "role", "deactivated_at", "email_verified_at", "password_changed_at", "totp_secret", "mfa_enabled_at", "totp_last_step", "client_id", "created_at", "updated_at"}).
+		AddRow(id, "John Doe", email, "hashed_pass", "admin", nil, nil, nil, "", nil, int64(0), nil, "invalid-time", now)
 
 	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id`)).
 		WithArgs(id).
 		WillReturnRows(rowsScanErr)
 
-	_, err = repo.GetByID(id)
+	_, err = repo.GetByID(context.Background(), id)
 	assert.Error(t, err)
 }
+
+func TestPostgresUserRepository_ListByRole(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresUserRepository(mock)
+	now := time.Now()
+
+	rows := pgxmock.NewRows([]string{"id", "name", "email", "password_hash", "role", "deactivated_at", "email_verified_at", "password_changed_at", "totp_secret", "mfa_enabled_at", "totp_last_step", "client_id", "created_at", "updated_at"}).
+		AddRow(uuid.New(), "Ana", "ana@example.com", "hashed_pass", "manager", nil, nil, nil, "", nil, int64(0), nil, now, now).
+		AddRow(uuid.New(), "Bruno", "bruno@example.com", "hashed_pass", "manager", nil, nil, nil, "", nil, int64(0), nil, now, now)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, email, password_hash, role, deactivated_at, email_verified_at, password_changed_at, totp_secret, mfa_enabled_at, totp_last_step, client_id, created_at, updated_at FROM users WHERE role = $1 AND deactivated_at IS NULL ORDER BY name`)).
+		WithArgs(domain.RoleManager).
+		WillReturnRows(rows)
+
+	users, err := repo.ListByRole(context.Background(), domain.RoleManager)
+	assert.NoError(t, err)
+	assert.Len(t, users, 2)
+	assert.Equal(t, domain.RoleManager, users[0].Role)
+
+	// Manager directory exposes their emails
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id`)).
+		WithArgs(domain.RoleManager).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "email", "password_hash", "role", "deactivated_at", "email_verified_at", "password_changed_at", "totp_secret", "mfa_enabled_at", "totp_last_step", "client_id", "created_at", "updated_at"}).
+			AddRow(uuid.New(), "Ana", "ana@example.com", "hashed_pass", "manager", nil, nil, nil, "", nil, int64(0), nil, now, now))
+
+	emails, err := infrastructure.NewManagerDirectory(repo).ManagerEmails(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ana@example.com"}, emails)
+
+	// Query Error
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id`)).
+		WillReturnError(errors.New("db error"))
+
+	_, err = repo.ListByRole(context.Background(), domain.RoleManager)
+	assert.Error(t, err)
+}
+
+func TestPostgresUserRepository_Save_EmailTaken(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresUserRepository(mock)
+	user, _ := domain.NewUser("John Doe", "john@example.com", "password123", domain.RoleEmployee)
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO users`)).
+		WithArgs(user.ID, user.Name, user.Email, user.Password, user.Role, user.DeactivatedAt, user.EmailVerifiedAt, user.PasswordChangedAt, user.TOTPSecret, user.MFAEnabledAt, user.TOTPLastStep, user.ClientID, user.CreatedAt, user.UpdatedAt).
+		WillReturnError(&pgconn.PgError{Code: "23505"})
+
+	err = repo.Save(context.Background(), user)
+	assert.ErrorIs(t, err, domain.ErrEmailTaken)
+}
+
+func TestPostgresUserRepository_Save_ClientAccountExists(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresUserRepository(mock)
+	user, _ := domain.NewCustomer(uuid.New(), "Maria", "maria@example.com")
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO users`)).
+		WithArgs(user.ID, user.Name, user.Email, user.Password, user.Role, user.DeactivatedAt, user.EmailVerifiedAt, user.PasswordChangedAt, user.TOTPSecret, user.MFAEnabledAt, user.TOTPLastStep, user.ClientID, user.CreatedAt, user.UpdatedAt).
+		WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "idx_users_client_id"})
+
+	err = repo.Save(context.Background(), user)
+	assert.ErrorIs(t, err, domain.ErrClientAccountExists)
+}
+
+func TestPostgresUserRepository_Update(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresUserRepository(mock)
+	user, _ := domain.NewUser("John Doe", "john@example.com", "password123", domain.RoleEmployee)
+	user.Deactivate()
+
+	// Success
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE users SET name = $2, email = $3, password_hash = $4, role = $5, deactivated_at = $6, email_verified_at = $7, password_changed_at = $8, totp_secret = $9, mfa_enabled_at = $10, totp_last_step = $11, client_id = $12, updated_at = $13 WHERE id = $1`)).
+		WithArgs(user.ID, user.Name, user.Email, user.Password, user.Role, user.DeactivatedAt, user.EmailVerifiedAt, user.PasswordChangedAt, user.TOTPSecret, user.MFAEnabledAt, user.TOTPLastStep, user.ClientID, user.UpdatedAt).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	assert.NoError(t, repo.Update(context.Background(), user))
+
+	// Not Found
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE users`)).
+		WithArgs(user.ID, user.Name, user.Email, user.Password, user.Role, user.DeactivatedAt, user.EmailVerifiedAt, user.PasswordChangedAt, user.TOTPSecret, user.MFAEnabledAt, user.TOTPLastStep, user.ClientID, user.UpdatedAt).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+	assert.ErrorIs(t, repo.Update(context.Background(), user), domain.ErrUserNotFound)
+}
+
+func TestPostgresUserRepository_UseTOTPStep(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresUserRepository(mock)
+	id := uuid.New()
+	query := regexp.QuoteMeta(`UPDATE users SET totp_last_step = $2 WHERE id = $1 AND (totp_last_step IS NULL OR totp_last_step < $2)`)
+
+	// First use
+	mock.ExpectExec(query).WithArgs(id, int64(57000000)).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	used, err := repo.UseTOTPStep(context.Background(), id, 57000000)
+	assert.NoError(t, err)
+	assert.True(t, used)
+
+	// Replay
+	mock.ExpectExec(query).WithArgs(id, int64(57000000)).WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	used, err = repo.UseTOTPStep(context.Background(), id, 57000000)
+	assert.NoError(t, err)
+	assert.False(t, used)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresUserRepository_List(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresUserRepository(mock)
+	now := time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, email, password_hash, role, deactivated_at, email_verified_at, password_changed_at, totp_secret, mfa_enabled_at, totp_last_step, client_id, created_at, updated_at FROM users WHERE (name ILIKE $1 OR email ILIKE $1) AND deactivated_at IS NOT NULL ORDER BY name ASC, id ASC LIMIT $2`)).
+		WithArgs("%ana%", 11).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "email", "password_hash", "role", "deactivated_at", "email_verified_at", "password_changed_at", "totp_secret", "mfa_enabled_at", "totp_last_step", "client_id", "created_at", "updated_at"}).
+			AddRow(uuid.New(), "Ana", "ana@example.com", "hashed_pass", "manager", &now, nil, nil, "", nil, int64(0), nil, now, now))
+
+	page, err := repo.List(context.Background(), sharedkernel.ListQuery{Limit: 10, Search: "ana", Status: []string{domain.UserStatusDeactivated}})
+	assert.NoError(t, err)
+	assert.Len(t, page.Items, 1)
+	assert.False(t, page.Items[0].IsActive())
+	assert.Empty(t, page.NextCursor)
+
+	// Both statuses do not filter
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM users ORDER BY name ASC, id ASC`)).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "email", "password_hash", "role", "deactivated_at", "email_verified_at", "password_changed_at", "totp_secret", "mfa_enabled_at", "totp_last_step", "client_id", "created_at", "updated_at"}))
+
+	_, err = repo.List(context.Background(), sharedkernel.ListQuery{Status: []string{domain.UserStatusActive, domain.UserStatusDeactivated}})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
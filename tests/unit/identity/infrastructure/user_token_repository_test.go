@@ -0,0 +1,81 @@
+package infrastructure_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/noggrj/autorepair/internal/identity/domain"
+	"github.com/noggrj/autorepair/internal/identity/infrastructure"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresUserTokenRepository_SaveAndGet(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresUserTokenRepository(mock)
+	token, _, _ := domain.NewUserToken(uuid.New(), domain.TokenPurposePasswordReset, time.Hour)
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO user_tokens (id, user_id, purpose, token_hash, expires_at, created_at) VALUES ($1, $2, $3, $4, $5, $6)`)).
+		WithArgs(token.ID, token.UserID, token.Purpose, token.TokenHash, token.ExpiresAt, token.CreatedAt).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	assert.NoError(t, repo.Save(context.Background(), token))
+
+	// GetByHash
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, user_id, purpose, token_hash, expires_at, created_at, used_at FROM user_tokens WHERE token_hash = $1`)).
+		WithArgs(token.TokenHash).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "user_id", "purpose", "token_hash", "expires_at", "created_at", "used_at"}).
+			AddRow(token.ID, token.UserID, "password_reset", token.TokenHash, token.ExpiresAt, token.CreatedAt, nil))
+
+	fetched, err := repo.GetByHash(context.Background(), token.TokenHash)
+	assert.NoError(t, err)
+	assert.Equal(t, token.ID, fetched.ID)
+	assert.Equal(t, domain.TokenPurposePasswordReset, fetched.Purpose)
+	assert.Nil(t, fetched.UsedAt)
+
+	// Not Found
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id`)).
+		WithArgs("unknown").
+		WillReturnError(pgx.ErrNoRows)
+
+	_, err = repo.GetByHash(context.Background(), "unknown")
+	assert.ErrorIs(t, err, domain.ErrUserTokenNotFound)
+}
+
+func TestPostgresUserTokenRepository_MarkUsed(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresUserTokenRepository(mock)
+	id := uuid.New()
+	now := time.Now()
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE user_tokens SET used_at = $2 WHERE id = $1 AND used_at IS NULL`)).
+		WithArgs(id, now).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	spent, err := repo.MarkUsed(context.Background(), id, now)
+	assert.NoError(t, err)
+	assert.True(t, spent)
+
+	// Already used
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE user_tokens SET used_at`)).
+		WithArgs(id, now).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+	spent, err = repo.MarkUsed(context.Background(), id, now)
+	assert.NoError(t, err)
+	assert.False(t, spent)
+}
@@ -0,0 +1,93 @@
+package application_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/noggrj/autorepair/internal/inventory/application"
+	"github.com/noggrj/autorepair/internal/inventory/domain"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockManagerDirectory struct {
+	mock.Mock
+}
+
+func (m *MockManagerDirectory) ManagerEmails(ctx context.Context) ([]string, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+type MockEmailService struct {
+	mock.Mock
+}
+
+func (m *MockEmailService) SendEmail(to, subject, body string) error {
+	args := m.Called(to, subject, body)
+	return args.Error(0)
+}
+
+func newPartWithMinimum(t *testing.T, qty, minQty int) *domain.Part {
+	part, err := domain.NewPart("BP-001", "Brake Pad", "Front", qty, sharedkernel.NewMoneyFromFloat(50.0), sharedkernel.NewMoneyFromFloat(80.0))
+	assert.NoError(t, err)
+	assert.NoError(t, part.SetThresholds(minQty, 20))
+	return part
+}
+
+func TestLowStockAlerter_NotifiesManagersOnCrossing(t *testing.T) {
+	directory := new(MockManagerDirectory)
+	notifier := new(MockEmailService)
+	alerter := application.NewLowStockAlerter(directory, notifier)
+
+	crossed := newPartWithMinimum(t, 10, 5)
+	assert.NoError(t, crossed.RemoveStock(6, domain.MovementOrderConsumption, "order:1"))
+	healthy := newPartWithMinimum(t, 10, 5)
+	assert.NoError(t, healthy.RemoveStock(1, domain.MovementOrderConsumption, "order:1"))
+
+	directory.On("ManagerEmails", mock.Anything).Return([]string{"ana@shop.com", "bruno@shop.com"}, nil)
+	notifier.On("SendEmail", mock.Anything, "Low stock: Brake Pad", mock.MatchedBy(func(body string) bool {
+		return strings.Contains(body, "4 in stock") && strings.Contains(body, "Suggested reorder: 20")
+	})).Return(nil)
+
+	alerter.NotifyLowStock(context.Background(), crossed, healthy)
+
+	notifier.AssertNumberOfCalls(t, "SendEmail", 2)
+	notifier.AssertCalled(t, "SendEmail", "ana@shop.com", mock.Anything, mock.Anything)
+	notifier.AssertCalled(t, "SendEmail", "bruno@shop.com", mock.Anything, mock.Anything)
+}
+
+func TestLowStockAlerter_NoCrossing(t *testing.T) {
+	directory := new(MockManagerDirectory)
+	notifier := new(MockEmailService)
+	alerter := application.NewLowStockAlerter(directory, notifier)
+
+	// Already below the minimum before the removal: no repeated alert
+	part := newPartWithMinimum(t, 4, 5)
+	assert.NoError(t, part.RemoveStock(1, domain.MovementLoss, ""))
+
+	alerter.NotifyLowStock(context.Background(), part)
+
+	directory.AssertNotCalled(t, "ManagerEmails", mock.Anything)
+	notifier.AssertNotCalled(t, "SendEmail", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestLowStockAlerter_DirectoryError(t *testing.T) {
+	directory := new(MockManagerDirectory)
+	notifier := new(MockEmailService)
+	alerter := application.NewLowStockAlerter(directory, notifier)
+
+	part := newPartWithMinimum(t, 5, 5)
+	assert.NoError(t, part.RemoveStock(1, domain.MovementLoss, ""))
+	directory.On("ManagerEmails", mock.Anything).Return(nil, errors.New("db error"))
+
+	alerter.NotifyLowStock(context.Background(), part)
+
+	notifier.AssertNotCalled(t, "SendEmail", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,258 @@
+package application_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/inventory/application"
+	"github.com/noggrj/autorepair/internal/inventory/domain"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPartRepository struct {
+	mock.Mock
+}
+
+func (m *MockPartRepository) Save(ctx context.Context, part *domain.Part) error {
+	args := m.Called(ctx, part)
+	return args.Error(0)
+}
+
+func (m *MockPartRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Part, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Part), args.Error(1)
+}
+
+func (m *MockPartRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*domain.Part, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Part), args.Error(1)
+}
+
+func (m *MockPartRepository) GetBySKU(ctx context.Context, sku string) (*domain.Part, error) {
+	args := m.Called(ctx, sku)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Part), args.Error(1)
+}
+
+func (m *MockPartRepository) Update(ctx context.Context, part *domain.Part) error {
+	args := m.Called(ctx, part)
+	return args.Error(0)
+}
+
+func (m *MockPartRepository) Archive(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockPartRepository) List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*domain.Part], error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return sharedkernel.Page[*domain.Part]{}, args.Error(1)
+	}
+	return args.Get(0).(sharedkernel.Page[*domain.Part]), args.Error(1)
+}
+
+func (m *MockPartRepository) ListLowStock(ctx context.Context) ([]*domain.Part, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*domain.Part), args.Error(1)
+}
+
+func (m *MockPartRepository) ListMovements(ctx context.Context, partID uuid.UUID) ([]*domain.StockMovement, error) {
+	args := m.Called(ctx, partID)
+	return args.Get(0).([]*domain.StockMovement), args.Error(1)
+}
+
+func (m *MockPartRepository) LedgerQuantities(ctx context.Context) (map[uuid.UUID]int, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(map[uuid.UUID]int), args.Error(1)
+}
+
+// fakeUnitOfWork hands the mocks to the callback without a real transaction.
+type fakeUnitOfWork struct {
+	repos application.TxRepositories
+}
+
+func newFakeUnitOfWork(parts domain.PartRepository, purchaseOrders domain.PurchaseOrderRepository) *fakeUnitOfWork {
+	return &fakeUnitOfWork{repos: application.TxRepositories{Parts: parts, PurchaseOrders: purchaseOrders}}
+}
+
+func (u *fakeUnitOfWork) Do(ctx context.Context, fn func(repos application.TxRepositories) error) error {
+	return fn(u.repos)
+}
+
+type MockOpenOrderChecker struct {
+	mock.Mock
+}
+
+func (m *MockOpenOrderChecker) HasOpenOrdersWithPart(ctx context.Context, partID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, partID)
+	return args.Bool(0), args.Error(1)
+}
+
+type MockStockAlerter struct {
+	mock.Mock
+}
+
+func (m *MockStockAlerter) NotifyLowStock(ctx context.Context, parts ...*domain.Part) {
+	m.Called(ctx, parts)
+}
+
+func newPartService(repo *MockPartRepository, alerts *MockStockAlerter) *application.PartService {
+	return application.NewPartService(newFakeUnitOfWork(repo, nil), alerts)
+}
+
+func TestPartService_AdjustStock(t *testing.T) {
+	t.Run("Inbound", func(t *testing.T) {
+		repo := new(MockPartRepository)
+		alerts := new(MockStockAlerter)
+		service := newPartService(repo, alerts)
+
+		part := &domain.Part{ID: uuid.New(), Quantity: 2}
+		repo.On("GetByIDForUpdate", mock.Anything, part.ID).Return(part, nil)
+		repo.On("Update", mock.Anything, mock.MatchedBy(func(p *domain.Part) bool {
+			movements := p.PendingMovements()
+			return len(movements) == 1 && movements[0].Type == domain.MovementPurchase && movements[0].Reference == "NF 123"
+		})).Return(nil)
+		alerts.On("NotifyLowStock", mock.Anything, mock.Anything).Return()
+
+		updated, err := service.AdjustStock(context.Background(), part.ID, 10, domain.MovementPurchase, "NF 123")
+		assert.NoError(t, err)
+		assert.Equal(t, 12, updated.Quantity)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Outbound", func(t *testing.T) {
+		repo := new(MockPartRepository)
+		alerts := new(MockStockAlerter)
+		service := newPartService(repo, alerts)
+
+		part := &domain.Part{ID: uuid.New(), Quantity: 5, MinQuantity: 3}
+		repo.On("GetByIDForUpdate", mock.Anything, part.ID).Return(part, nil)
+		repo.On("Update", mock.Anything, part).Return(nil)
+		alerts.On("NotifyLowStock", mock.Anything, []*domain.Part{part}).Return()
+
+		updated, err := service.AdjustStock(context.Background(), part.ID, -3, domain.MovementLoss, "broken")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, updated.Quantity)
+		assert.True(t, updated.CrossedMinimum())
+		alerts.AssertExpectations(t)
+	})
+
+	t.Run("Insufficient Stock", func(t *testing.T) {
+		repo := new(MockPartRepository)
+		alerts := new(MockStockAlerter)
+		service := newPartService(repo, alerts)
+
+		part := &domain.Part{ID: uuid.New(), Quantity: 1}
+		repo.On("GetByIDForUpdate", mock.Anything, part.ID).Return(part, nil)
+
+		_, err := service.AdjustStock(context.Background(), part.ID, -3, domain.MovementLoss, "")
+		assert.ErrorIs(t, err, domain.ErrInsufficientStock)
+		repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		alerts.AssertNotCalled(t, "NotifyLowStock", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Wrong Direction", func(t *testing.T) {
+		repo := new(MockPartRepository)
+		service := newPartService(repo, new(MockStockAlerter))
+
+		part := &domain.Part{ID: uuid.New(), Quantity: 1}
+		repo.On("GetByIDForUpdate", mock.Anything, part.ID).Return(part, nil)
+
+		_, err := service.AdjustStock(context.Background(), part.ID, 3, domain.MovementLoss, "")
+		assert.ErrorIs(t, err, domain.ErrInvalidMovementType)
+	})
+
+	t.Run("Zero Quantity", func(t *testing.T) {
+		repo := new(MockPartRepository)
+		service := newPartService(repo, new(MockStockAlerter))
+
+		_, err := service.AdjustStock(context.Background(), uuid.New(), 0, domain.MovementAdjustment, "")
+		assert.ErrorIs(t, err, domain.ErrInvalidQuantity)
+		repo.AssertNotCalled(t, "GetByIDForUpdate", mock.Anything, mock.Anything)
+	})
+}
+
+func TestPartService_Archive(t *testing.T) {
+	setup := func() (*application.PartService, *MockPartRepository, *MockOpenOrderChecker, *MockPurchaseOrderRepository) {
+		repo := new(MockPartRepository)
+		orders := new(MockOpenOrderChecker)
+		purchases := new(MockPurchaseOrderRepository)
+		uow := newFakeUnitOfWork(repo, purchases)
+		uow.repos.OpenOrders = orders
+		return application.NewPartService(uow, nil), repo, orders, purchases
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		service, repo, orders, purchases := setup()
+
+		id := uuid.New()
+		repo.On("GetByIDForUpdate", mock.Anything, id).Return(&domain.Part{ID: id}, nil)
+		orders.On("HasOpenOrdersWithPart", mock.Anything, id).Return(false, nil)
+		purchases.On("HasOpenWithPart", mock.Anything, id).Return(false, nil)
+		repo.On("Archive", mock.Anything, id).Return(nil)
+
+		assert.NoError(t, service.Archive(context.Background(), id))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		service, repo, orders, _ := setup()
+
+		id := uuid.New()
+		repo.On("GetByIDForUpdate", mock.Anything, id).Return(nil, domain.ErrPartNotFound)
+
+		err := service.Archive(context.Background(), id)
+		assert.ErrorIs(t, err, domain.ErrPartNotFound)
+		orders.AssertNotCalled(t, "HasOpenOrdersWithPart", mock.Anything, mock.Anything)
+	})
+
+	t.Run("On Open Order", func(t *testing.T) {
+		service, repo, orders, purchases := setup()
+
+		id := uuid.New()
+		repo.On("GetByIDForUpdate", mock.Anything, id).Return(&domain.Part{ID: id}, nil)
+		orders.On("HasOpenOrdersWithPart", mock.Anything, id).Return(true, nil)
+
+		err := service.Archive(context.Background(), id)
+		assert.ErrorIs(t, err, domain.ErrPartInUse)
+		purchases.AssertNotCalled(t, "HasOpenWithPart", mock.Anything, mock.Anything)
+		repo.AssertNotCalled(t, "Archive", mock.Anything, mock.Anything)
+	})
+
+	t.Run("On Open Purchase Order", func(t *testing.T) {
+		service, repo, orders, purchases := setup()
+
+		id := uuid.New()
+		repo.On("GetByIDForUpdate", mock.Anything, id).Return(&domain.Part{ID: id}, nil)
+		orders.On("HasOpenOrdersWithPart", mock.Anything, id).Return(false, nil)
+		purchases.On("HasOpenWithPart", mock.Anything, id).Return(true, nil)
+
+		err := service.Archive(context.Background(), id)
+		assert.ErrorIs(t, err, domain.ErrPartInUse)
+		repo.AssertNotCalled(t, "Archive", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Checker Error", func(t *testing.T) {
+		service, repo, orders, _ := setup()
+
+		id := uuid.New()
+		repo.On("GetByIDForUpdate", mock.Anything, id).Return(&domain.Part{ID: id}, nil)
+		orders.On("HasOpenOrdersWithPart", mock.Anything, id).Return(false, errors.New("db error"))
+
+		assert.Error(t, service.Archive(context.Background(), id))
+		repo.AssertNotCalled(t, "Archive", mock.Anything, mock.Anything)
+	})
+}
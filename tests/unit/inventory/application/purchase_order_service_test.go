@@ -0,0 +1,265 @@
+package application_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/inventory/application"
+	"github.com/noggrj/autorepair/internal/inventory/domain"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPurchaseOrderRepository struct {
+	mock.Mock
+}
+
+func (m *MockPurchaseOrderRepository) Save(ctx context.Context, po *domain.PurchaseOrder) error {
+	args := m.Called(ctx, po)
+	return args.Error(0)
+}
+
+func (m *MockPurchaseOrderRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.PurchaseOrder, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PurchaseOrder), args.Error(1)
+}
+
+func (m *MockPurchaseOrderRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*domain.PurchaseOrder, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PurchaseOrder), args.Error(1)
+}
+
+func (m *MockPurchaseOrderRepository) List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*domain.PurchaseOrder], error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return sharedkernel.Page[*domain.PurchaseOrder]{}, args.Error(1)
+	}
+	return args.Get(0).(sharedkernel.Page[*domain.PurchaseOrder]), args.Error(1)
+}
+
+func (m *MockPurchaseOrderRepository) ListReceipts(ctx context.Context, purchaseOrderID uuid.UUID) ([]*domain.PurchaseReceipt, error) {
+	args := m.Called(ctx, purchaseOrderID)
+	return args.Get(0).([]*domain.PurchaseReceipt), args.Error(1)
+}
+
+func (m *MockPurchaseOrderRepository) HasOpenWithPart(ctx context.Context, partID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, partID)
+	return args.Bool(0), args.Error(1)
+}
+
+type MockSupplierRepository struct {
+	mock.Mock
+}
+
+func (m *MockSupplierRepository) Save(ctx context.Context, supplier *domain.Supplier) error {
+	args := m.Called(ctx, supplier)
+	return args.Error(0)
+}
+
+func (m *MockSupplierRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Supplier, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Supplier), args.Error(1)
+}
+
+func (m *MockSupplierRepository) List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*domain.Supplier], error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return sharedkernel.Page[*domain.Supplier]{}, args.Error(1)
+	}
+	return args.Get(0).(sharedkernel.Page[*domain.Supplier]), args.Error(1)
+}
+
+func (m *MockSupplierRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func newPurchaseOrderService(orders *MockPurchaseOrderRepository, suppliers *MockSupplierRepository, parts *MockPartRepository) *application.PurchaseOrderService {
+	return application.NewPurchaseOrderService(orders, suppliers, parts, newFakeUnitOfWork(parts, orders))
+}
+
+// sentPurchaseOrder returns a purchase order already sent, with one line of qty units.
+func sentPurchaseOrder(partID uuid.UUID, qty int, unitCost sharedkernel.Money) (*domain.PurchaseOrder, *domain.PurchaseOrderLine) {
+	po := domain.NewPurchaseOrder(uuid.New())
+	line, _ := po.AddLine(partID, qty, unitCost)
+	_ = po.Send()
+	return po, line
+}
+
+func TestPurchaseOrderService_Create(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		orders, suppliers, parts := new(MockPurchaseOrderRepository), new(MockSupplierRepository), new(MockPartRepository)
+		service := newPurchaseOrderService(orders, suppliers, parts)
+
+		supplierID, partID := uuid.New(), uuid.New()
+		suppliers.On("GetByID", mock.Anything, supplierID).Return(&domain.Supplier{ID: supplierID}, nil)
+		parts.On("GetByID", mock.Anything, partID).Return(&domain.Part{ID: partID}, nil)
+		orders.On("Save", mock.Anything, mock.AnythingOfType("*domain.PurchaseOrder")).Return(nil)
+
+		po, err := service.Create(context.Background(), supplierID, []application.PurchaseOrderLineInput{
+			{PartID: partID, Quantity: 5, UnitCost: sharedkernel.NewMoneyFromCents(2000)},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.PurchaseOrderDraft, po.Status)
+		assert.Len(t, po.Lines, 1)
+		orders.AssertExpectations(t)
+	})
+
+	t.Run("Unknown Supplier", func(t *testing.T) {
+		orders, suppliers, parts := new(MockPurchaseOrderRepository), new(MockSupplierRepository), new(MockPartRepository)
+		service := newPurchaseOrderService(orders, suppliers, parts)
+
+		supplierID := uuid.New()
+		suppliers.On("GetByID", mock.Anything, supplierID).Return(nil, domain.ErrSupplierNotFound)
+
+		_, err := service.Create(context.Background(), supplierID, nil)
+
+		assert.ErrorIs(t, err, domain.ErrSupplierNotFound)
+		orders.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unknown Part", func(t *testing.T) {
+		orders, suppliers, parts := new(MockPurchaseOrderRepository), new(MockSupplierRepository), new(MockPartRepository)
+		service := newPurchaseOrderService(orders, suppliers, parts)
+
+		supplierID, partID := uuid.New(), uuid.New()
+		suppliers.On("GetByID", mock.Anything, supplierID).Return(&domain.Supplier{ID: supplierID}, nil)
+		parts.On("GetByID", mock.Anything, partID).Return(nil, domain.ErrPartNotFound)
+
+		_, err := service.Create(context.Background(), supplierID, []application.PurchaseOrderLineInput{
+			{PartID: partID, Quantity: 5},
+		})
+
+		assert.ErrorIs(t, err, domain.ErrPartNotFound)
+		orders.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
+}
+
+func TestPurchaseOrderService_Send(t *testing.T) {
+	orders, suppliers, parts := new(MockPurchaseOrderRepository), new(MockSupplierRepository), new(MockPartRepository)
+	service := newPurchaseOrderService(orders, suppliers, parts)
+
+	po := domain.NewPurchaseOrder(uuid.New())
+	_, _ = po.AddLine(uuid.New(), 1, sharedkernel.NewMoneyFromCents(100))
+	orders.On("GetByIDForUpdate", mock.Anything, po.ID).Return(po, nil)
+	orders.On("Save", mock.Anything, po).Return(nil)
+
+	sent, err := service.Send(context.Background(), po.ID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.PurchaseOrderSent, sent.Status)
+
+	// Already sent
+	_, err = service.Send(context.Background(), po.ID)
+	assert.ErrorIs(t, err, domain.ErrPurchaseOrderNotDraft)
+	orders.AssertNumberOfCalls(t, "Save", 1)
+}
+
+func TestPurchaseOrderService_ReceiveLine(t *testing.T) {
+	t.Run("Adds Stock At Agreed Cost", func(t *testing.T) {
+		orders, suppliers, parts := new(MockPurchaseOrderRepository), new(MockSupplierRepository), new(MockPartRepository)
+		service := newPurchaseOrderService(orders, suppliers, parts)
+
+		part := &domain.Part{ID: uuid.New(), Quantity: 1}
+		po, line := sentPurchaseOrder(part.ID, 10, sharedkernel.NewMoneyFromCents(1500))
+
+		orders.On("GetByIDForUpdate", mock.Anything, po.ID).Return(po, nil)
+		parts.On("GetByIDForUpdate", mock.Anything, part.ID).Return(part, nil)
+		parts.On("Update", mock.Anything, mock.MatchedBy(func(p *domain.Part) bool {
+			movements := p.PendingMovements()
+			return len(movements) == 1 &&
+				movements[0].Type == domain.MovementPurchase &&
+				movements[0].Reference == "purchase_order:"+po.ID.String()
+		})).Return(nil)
+		orders.On("Save", mock.Anything, mock.MatchedBy(func(p *domain.PurchaseOrder) bool {
+			receipts := p.PendingReceipts()
+			return len(receipts) == 1 && receipts[0].UnitCost.Cents() == 1500
+		})).Return(nil)
+
+		updated, err := service.ReceiveLine(context.Background(), po.ID, line.ID, 4, nil)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 5, part.Quantity)
+		assert.Equal(t, domain.PurchaseOrderPartiallyReceived, updated.Status)
+		parts.AssertExpectations(t)
+		orders.AssertExpectations(t)
+	})
+
+	t.Run("Records Invoiced Cost", func(t *testing.T) {
+		orders, suppliers, parts := new(MockPurchaseOrderRepository), new(MockSupplierRepository), new(MockPartRepository)
+		service := newPurchaseOrderService(orders, suppliers, parts)
+
+		part := &domain.Part{ID: uuid.New()}
+		po, line := sentPurchaseOrder(part.ID, 2, sharedkernel.NewMoneyFromCents(1500))
+		invoiced := sharedkernel.NewMoneyFromCents(1620)
+
+		orders.On("GetByIDForUpdate", mock.Anything, po.ID).Return(po, nil)
+		parts.On("GetByIDForUpdate", mock.Anything, part.ID).Return(part, nil)
+		parts.On("Update", mock.Anything, part).Return(nil)
+		orders.On("Save", mock.Anything, mock.MatchedBy(func(p *domain.PurchaseOrder) bool {
+			receipts := p.PendingReceipts()
+			return len(receipts) == 1 && receipts[0].UnitCost.Equal(invoiced)
+		})).Return(nil)
+
+		updated, err := service.ReceiveLine(context.Background(), po.ID, line.ID, 2, &invoiced)
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.PurchaseOrderReceived, updated.Status)
+		assert.Equal(t, 2, part.Quantity)
+	})
+
+	t.Run("Over Receipt", func(t *testing.T) {
+		orders, suppliers, parts := new(MockPurchaseOrderRepository), new(MockSupplierRepository), new(MockPartRepository)
+		service := newPurchaseOrderService(orders, suppliers, parts)
+
+		po, line := sentPurchaseOrder(uuid.New(), 2, sharedkernel.NewMoneyFromCents(1500))
+		orders.On("GetByIDForUpdate", mock.Anything, po.ID).Return(po, nil)
+
+		_, err := service.ReceiveLine(context.Background(), po.ID, line.ID, 3, nil)
+
+		assert.ErrorIs(t, err, domain.ErrReceivedQuantityExceeded)
+		parts.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		orders.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Part Update Fails", func(t *testing.T) {
+		orders, suppliers, parts := new(MockPurchaseOrderRepository), new(MockSupplierRepository), new(MockPartRepository)
+		service := newPurchaseOrderService(orders, suppliers, parts)
+
+		part := &domain.Part{ID: uuid.New()}
+		po, line := sentPurchaseOrder(part.ID, 2, sharedkernel.NewMoneyFromCents(1500))
+		orders.On("GetByIDForUpdate", mock.Anything, po.ID).Return(po, nil)
+		parts.On("GetByIDForUpdate", mock.Anything, part.ID).Return(part, nil)
+		parts.On("Update", mock.Anything, part).Return(errors.New("db error"))
+
+		_, err := service.ReceiveLine(context.Background(), po.ID, line.ID, 1, nil)
+
+		assert.Error(t, err)
+		orders.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unknown Line", func(t *testing.T) {
+		orders, suppliers, parts := new(MockPurchaseOrderRepository), new(MockSupplierRepository), new(MockPartRepository)
+		service := newPurchaseOrderService(orders, suppliers, parts)
+
+		po, _ := sentPurchaseOrder(uuid.New(), 2, sharedkernel.NewMoneyFromCents(1500))
+		orders.On("GetByIDForUpdate", mock.Anything, po.ID).Return(po, nil)
+
+		_, err := service.ReceiveLine(context.Background(), po.ID, uuid.New(), 1, nil)
+
+		assert.ErrorIs(t, err, domain.ErrPurchaseOrderLineNotFound)
+	})
+}
@@ -4,31 +4,97 @@ import (
 	"testing"
 
 	"github.com/noggrj/autorepair/internal/inventory/domain"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestNewPart(t *testing.T) {
-	p, err := domain.NewPart("Tire", "Rubber tire", 10, 100.0) // name, desc, qty, price
+	p, err := domain.NewPart("tr-100 ", "Tire", "Rubber tire", 10, sharedkernel.NewMoneyFromFloat(70.0), sharedkernel.NewMoneyFromFloat(100.0)) // sku, name, desc, qty, cost, price
 	assert.NoError(t, err)
 	assert.Equal(t, "Tire", p.Name)
+	assert.Equal(t, "TR-100", p.SKU)
 	assert.Equal(t, 10, p.Quantity)
+	assert.Equal(t, "30.00", p.Margin().String())
+
+	_, err = domain.NewPart("  ", "Name", "Desc", 5, sharedkernel.NewMoneyFromFloat(5.0), sharedkernel.NewMoneyFromFloat(10.0))
+	assert.ErrorIs(t, err, domain.ErrSKURequired)
 
 	// Invalid
 	// Assuming NewPart validates negative quantity or price
-	_, err = domain.NewPart("Name", "Desc", -5, 10.0)
+	_, err = domain.NewPart("SKU-1", "Name", "Desc", -5, sharedkernel.NewMoneyFromFloat(5.0), sharedkernel.NewMoneyFromFloat(10.0))
 	assert.Error(t, err)
 
-	_, err = domain.NewPart("Name", "Desc", 5, -10.0)
+	_, err = domain.NewPart("SKU-1", "Name", "Desc", 5, sharedkernel.NewMoneyFromFloat(5.0), sharedkernel.NewMoneyFromFloat(-10.0))
 	assert.Error(t, err)
 }
 
 func TestPart_RemoveStock(t *testing.T) {
-	p, _ := domain.NewPart("Tire", "Desc", 10, 100.0)
+	p, _ := domain.NewPart("TR-100", "Tire", "Desc", 10, sharedkernel.NewMoneyFromFloat(70.0), sharedkernel.NewMoneyFromFloat(100.0))
 
-	err := p.RemoveStock(5)
+	err := p.RemoveStock(5, domain.MovementOrderConsumption, "order:1")
 	assert.NoError(t, err)
 	assert.Equal(t, 5, p.Quantity)
-	
-	err = p.RemoveStock(20) // More than stock
+
+	err = p.RemoveStock(20, domain.MovementLoss, "") // More than stock
 	assert.ErrorIs(t, err, domain.ErrInsufficientStock)
 }
+
+func TestPart_StockMovements(t *testing.T) {
+	p, _ := domain.NewPart("TR-100", "Tire", "Desc", 10, sharedkernel.NewMoneyFromFloat(70.0), sharedkernel.NewMoneyFromFloat(100.0))
+
+	// Opening balance
+	assert.Len(t, p.PendingMovements(), 1)
+	assert.Equal(t, domain.MovementAdjustment, p.PendingMovements()[0].Type)
+	assert.Equal(t, 10, p.PendingMovements()[0].Quantity)
+	p.ClearPendingMovements()
+
+	assert.NoError(t, p.AddStock(4, domain.MovementPurchase, "invoice 123"))
+	assert.NoError(t, p.RemoveStock(3, domain.MovementOrderConsumption, "order:1"))
+	assert.Equal(t, 11, p.Quantity)
+
+	movements := p.PendingMovements()
+	assert.Len(t, movements, 2)
+	assert.Equal(t, 4, movements[0].Quantity)
+	assert.Equal(t, "invoice 123", movements[0].Reference)
+	assert.Equal(t, -3, movements[1].Quantity)
+	assert.Equal(t, p.ID, movements[1].PartID)
+
+	// Direction must match the movement type
+	assert.ErrorIs(t, p.AddStock(1, domain.MovementLoss, ""), domain.ErrInvalidMovementType)
+	assert.ErrorIs(t, p.RemoveStock(1, domain.MovementPurchase, ""), domain.ErrInvalidMovementType)
+	assert.ErrorIs(t, p.AddStock(0, domain.MovementPurchase, ""), domain.ErrInvalidQuantity)
+
+	// Failed operations leave stock and ledger untouched
+	assert.ErrorIs(t, p.RemoveStock(50, domain.MovementLoss, ""), domain.ErrInsufficientStock)
+	assert.Equal(t, 11, p.Quantity)
+	assert.Len(t, p.PendingMovements(), 2)
+}
+
+func TestReconcile(t *testing.T) {
+	p, _ := domain.NewPart("TR-100", "Tire", "Desc", 10, sharedkernel.NewMoneyFromFloat(70.0), sharedkernel.NewMoneyFromFloat(100.0))
+
+	rec := domain.Reconcile(p, 10)
+	assert.False(t, rec.HasDrift())
+
+	rec = domain.Reconcile(p, 7)
+	assert.True(t, rec.HasDrift())
+	assert.Equal(t, 3, rec.Drift)
+	assert.Equal(t, 7, rec.LedgerQuantity)
+}
+
+func TestPart_LowStockThreshold(t *testing.T) {
+	p, _ := domain.NewPart("TR-100", "Tire", "Desc", 10, sharedkernel.NewMoneyFromFloat(70.0), sharedkernel.NewMoneyFromFloat(100.0))
+	assert.ErrorIs(t, p.SetThresholds(-1, 0), domain.ErrInvalidThreshold)
+	assert.NoError(t, p.SetThresholds(4, 12))
+	assert.False(t, p.IsLowStock())
+
+	// Reaching the minimum is still fine
+	assert.NoError(t, p.RemoveStock(6, domain.MovementOrderConsumption, "order:1"))
+	assert.False(t, p.IsLowStock())
+	assert.False(t, p.CrossedMinimum())
+
+	// Going below it crosses the threshold
+	assert.NoError(t, p.RemoveStock(1, domain.MovementOrderConsumption, "order:2"))
+	assert.True(t, p.IsLowStock())
+	assert.True(t, p.CrossedMinimum())
+}
@@ -0,0 +1,99 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/inventory/domain"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSupplier(t *testing.T) {
+	s, err := domain.NewSupplier("Auto Peças Ltda", "11.222.333/0001-81", "vendas@autopecas.com", "11999999999")
+	assert.NoError(t, err)
+	assert.Equal(t, "11222333000181", s.Document.String())
+
+	_, err = domain.NewSupplier("", "11.222.333/0001-81", "", "")
+	assert.Error(t, err)
+
+	_, err = domain.NewSupplier("Auto Peças", "123", "", "")
+	assert.ErrorIs(t, err, sharedkernel.ErrInvalidDocument)
+}
+
+func TestPurchaseOrder_AddLine(t *testing.T) {
+	po := domain.NewPurchaseOrder(uuid.New())
+	assert.Equal(t, domain.PurchaseOrderDraft, po.Status)
+
+	partID := uuid.New()
+	_, err := po.AddLine(partID, 10, sharedkernel.NewMoneyFromCents(1250))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12500), po.Total().Cents())
+
+	_, err = po.AddLine(partID, 1, sharedkernel.NewMoneyFromCents(100))
+	assert.ErrorIs(t, err, domain.ErrDuplicatePurchaseOrderLine)
+
+	_, err = po.AddLine(uuid.New(), 0, sharedkernel.NewMoneyFromCents(100))
+	assert.ErrorIs(t, err, domain.ErrInvalidQuantity)
+
+	_, err = po.AddLine(uuid.New(), 1, sharedkernel.NewMoneyFromCents(-1))
+	assert.ErrorIs(t, err, domain.ErrInvalidUnitCost)
+
+	assert.NoError(t, po.Send())
+	_, err = po.AddLine(uuid.New(), 1, sharedkernel.NewMoneyFromCents(100))
+	assert.ErrorIs(t, err, domain.ErrPurchaseOrderNotDraft)
+}
+
+func TestPurchaseOrder_Send(t *testing.T) {
+	po := domain.NewPurchaseOrder(uuid.New())
+	assert.ErrorIs(t, po.Send(), domain.ErrPurchaseOrderEmpty)
+
+	_, _ = po.AddLine(uuid.New(), 1, sharedkernel.NewMoneyFromCents(100))
+	assert.NoError(t, po.Send())
+	assert.Equal(t, domain.PurchaseOrderSent, po.Status)
+	assert.NotNil(t, po.SentAt)
+
+	assert.ErrorIs(t, po.Send(), domain.ErrPurchaseOrderNotDraft)
+}
+
+func TestPurchaseOrder_Receive(t *testing.T) {
+	po := domain.NewPurchaseOrder(uuid.New())
+	filter, _ := po.AddLine(uuid.New(), 10, sharedkernel.NewMoneyFromCents(1500))
+	pads, _ := po.AddLine(uuid.New(), 2, sharedkernel.NewMoneyFromCents(9000))
+
+	// Draft orders cannot be received
+	_, err := po.Receive(filter.ID, 1, filter.UnitCost)
+	assert.ErrorIs(t, err, domain.ErrPurchaseOrderNotReceivable)
+
+	assert.NoError(t, po.Send())
+
+	receipt, err := po.Receive(filter.ID, 4, sharedkernel.NewMoneyFromCents(1450))
+	assert.NoError(t, err)
+	assert.Equal(t, filter.PartID, receipt.PartID)
+	assert.Equal(t, int64(1450), receipt.UnitCost.Cents())
+	assert.Equal(t, 6, filter.Remaining())
+	assert.Equal(t, domain.PurchaseOrderPartiallyReceived, po.Status)
+	assert.Nil(t, po.ReceivedAt)
+
+	_, err = po.Receive(filter.ID, 7, filter.UnitCost)
+	assert.ErrorIs(t, err, domain.ErrReceivedQuantityExceeded)
+
+	_, err = po.Receive(uuid.New(), 1, filter.UnitCost)
+	assert.ErrorIs(t, err, domain.ErrPurchaseOrderLineNotFound)
+
+	_, err = po.Receive(filter.ID, 6, filter.UnitCost)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.PurchaseOrderPartiallyReceived, po.Status)
+
+	_, err = po.Receive(pads.ID, 2, pads.UnitCost)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.PurchaseOrderReceived, po.Status)
+	assert.NotNil(t, po.ReceivedAt)
+	assert.Len(t, po.PendingReceipts(), 3)
+
+	_, err = po.Receive(pads.ID, 1, pads.UnitCost)
+	assert.ErrorIs(t, err, domain.ErrPurchaseOrderNotReceivable)
+
+	po.ClearPendingReceipts()
+	assert.Empty(t, po.PendingReceipts())
+}
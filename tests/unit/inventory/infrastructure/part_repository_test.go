@@ -5,15 +5,20 @@ import (
 	"errors"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/noggrj/autorepair/internal/inventory/domain"
 	"github.com/noggrj/autorepair/internal/inventory/infrastructure"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
 	"github.com/pashagolub/pgxmock/v4"
 	"github.com/stretchr/testify/assert"
 )
 
+var partColumns = []string{"id", "sku", "manufacturer_code", "name", "description", "stock_qty", "cost_price", "sale_price", "min_quantity", "reorder_quantity"}
+
 func TestPostgresPartRepository_Save(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
@@ -22,15 +27,22 @@ func TestPostgresPartRepository_Save(t *testing.T) {
 	defer mock.Close()
 
 	repo := infrastructure.NewPostgresPartRepository(mock)
-	part, _ := domain.NewPart("Test Part", "Description", 10, 100.0)
+	part, _ := domain.NewPart("TP-001", "Test Part", "Description", 10, sharedkernel.NewMoneyFromFloat(60.0), sharedkernel.NewMoneyFromFloat(100.0))
 
-	// Success
-	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO parts (id, name, description, stock_qty, price) VALUES ($1, $2, $3, $4, $5)`)).
-		WithArgs(part.ID, part.Name, part.Description, part.Quantity, part.Price).
+	// Success: the opening balance is written to the ledger in the same transaction
+	opening := part.PendingMovements()[0]
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO parts (id, sku, manufacturer_code, name, description, stock_qty, cost_price, sale_price, min_quantity, reorder_quantity) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`)).
+		WithArgs(part.ID, part.SKU, part.ManufacturerCode, part.Name, part.Description, part.Quantity, part.CostPrice, part.SalePrice, part.MinQuantity, part.ReorderQuantity).
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO stock_movements (id, part_id, type, quantity, reference, occurred_at) VALUES ($1, $2, $3, $4, $5, $6)`)).
+		WithArgs(opening.ID, part.ID, domain.MovementAdjustment, 10, "initial stock", opening.OccurredAt).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectCommit()
 
 	err = repo.Save(context.Background(), part)
 	assert.NoError(t, err)
+	assert.Empty(t, part.PendingMovements())
 
 	// Error
 	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO parts`)).
@@ -51,10 +63,10 @@ func TestPostgresPartRepository_GetByID(t *testing.T) {
 	id := uuid.New()
 
 	// Success
-	rows := pgxmock.NewRows([]string{"id", "name", "description", "stock_qty", "price"}).
-		AddRow(id, "Part 1", "Desc 1", 5, 50.0)
+	rows := pgxmock.NewRows(partColumns).
+		AddRow(id, "SKU-1", "", "Part 1", "Desc 1", 5, 0.0, 50.0, 0, 0)
 
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, description, stock_qty, price FROM parts WHERE id = $1`)).
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, sku, manufacturer_code, name, description, stock_qty, cost_price, sale_price, min_quantity, reorder_quantity FROM parts WHERE id = $1 AND archived_at IS NULL`)).
 		WithArgs(id).
 		WillReturnRows(rows)
 
@@ -88,11 +100,12 @@ func TestPostgresPartRepository_Update(t *testing.T) {
 	defer mock.Close()
 
 	repo := infrastructure.NewPostgresPartRepository(mock)
-	part, _ := domain.NewPart("Updated", "Desc", 20, 200.0)
+	part, _ := domain.NewPart("UP-001", "Updated", "Desc", 20, sharedkernel.NewMoneyFromFloat(120.0), sharedkernel.NewMoneyFromFloat(200.0))
+	part.ClearPendingMovements()
 
-	// Success
-	mock.ExpectExec(regexp.QuoteMeta(`UPDATE parts SET name = $1, description = $2, stock_qty = $3, price = $4 WHERE id = $5`)).
-		WithArgs(part.Name, part.Description, part.Quantity, part.Price, part.ID).
+	// Success (no stock change)
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE parts SET sku = $1`)).
+		WithArgs(part.SKU, part.ManufacturerCode, part.Name, part.Description, part.Quantity, part.CostPrice, part.SalePrice, part.MinQuantity, part.ReorderQuantity, part.ID).
 		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
 
 	err = repo.Update(context.Background(), part)
@@ -104,6 +117,19 @@ func TestPostgresPartRepository_Update(t *testing.T) {
 
 	err = repo.Update(context.Background(), part)
 	assert.Error(t, err)
+
+	// Stock change: ledger write fails, so the whole update is rolled back
+	_ = part.RemoveStock(5, domain.MovementLoss, "damaged")
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE parts`)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO stock_movements`)).
+		WillReturnError(errors.New("ledger error"))
+	mock.ExpectRollback()
+
+	err = repo.Update(context.Background(), part)
+	assert.Error(t, err)
+	assert.Len(t, part.PendingMovements(), 1)
 }
 
 func TestPostgresPartRepository_List(t *testing.T) {
@@ -116,31 +142,181 @@ func TestPostgresPartRepository_List(t *testing.T) {
 	repo := infrastructure.NewPostgresPartRepository(mock)
 
 	// Success
-	rows := pgxmock.NewRows([]string{"id", "name", "description", "stock_qty", "price"}).
-		AddRow(uuid.New(), "Part 1", "Desc 1", 10, 100.0).
-		AddRow(uuid.New(), "Part 2", "Desc 2", 20, 200.0)
+	rows := pgxmock.NewRows(partColumns).
+		AddRow(uuid.New(), "SKU-2", "", "Part 1", "Desc 1", 10, 0.0, 100.0, 0, 0).
+		AddRow(uuid.New(), "SKU-3", "", "Part 2", "Desc 2", 20, 0.0, 200.0, 0, 0)
 
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, description, stock_qty, price FROM parts`)).
+	// Archived parts are left out
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, sku, manufacturer_code, name, description, stock_qty, cost_price, sale_price, min_quantity, reorder_quantity FROM parts WHERE archived_at IS NULL`)).
 		WillReturnRows(rows)
 
-	parts, err := repo.List(context.Background())
+	page, err := repo.List(context.Background(), sharedkernel.ListQuery{})
 	assert.NoError(t, err)
-	assert.Len(t, parts, 2)
+	assert.Len(t, page.Items, 2)
 
 	// Query Error
 	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id`)).
 		WillReturnError(errors.New("db error"))
 
-	_, err = repo.List(context.Background())
+	_, err = repo.List(context.Background(), sharedkernel.ListQuery{})
 	assert.Error(t, err)
 
 	// Scan Error
-	rowsScanErr := pgxmock.NewRows([]string{"id", "name", "description", "stock_qty", "price"}).
-		AddRow(uuid.New(), "Part 1", "Desc 1", "invalid", 100.0)
+	rowsScanErr := pgxmock.NewRows(partColumns).
+		AddRow(uuid.New(), "SKU-4", "", "Part 1", "Desc 1", "invalid", 0.0, 100.0, 0, 0)
 
 	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id`)).
 		WillReturnRows(rowsScanErr)
 
-	_, err = repo.List(context.Background())
+	_, err = repo.List(context.Background(), sharedkernel.ListQuery{})
 	assert.Error(t, err)
 }
+
+func TestPostgresPartRepository_ListMovements(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresPartRepository(mock)
+	partID := uuid.New()
+	now := time.Now()
+
+	// Success
+	rows := pgxmock.NewRows([]string{"id", "part_id", "type", "quantity", "reference", "occurred_at"}).
+		AddRow(uuid.New(), partID, "adjustment", 10, "initial stock", now).
+		AddRow(uuid.New(), partID, "order_consumption", -2, "order:1", now)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, part_id, type, quantity, reference, occurred_at FROM stock_movements WHERE part_id = $1 ORDER BY occurred_at ASC`)).
+		WithArgs(partID).
+		WillReturnRows(rows)
+
+	movements, err := repo.ListMovements(context.Background(), partID)
+	assert.NoError(t, err)
+	assert.Len(t, movements, 2)
+	assert.Equal(t, domain.MovementOrderConsumption, movements[1].Type)
+	assert.Equal(t, -2, movements[1].Quantity)
+
+	// Query Error
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id`)).
+		WillReturnError(errors.New("db error"))
+
+	_, err = repo.ListMovements(context.Background(), partID)
+	assert.Error(t, err)
+}
+
+func TestPostgresPartRepository_LedgerQuantities(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresPartRepository(mock)
+	partID := uuid.New()
+
+	rows := pgxmock.NewRows([]string{"part_id", "sum"}).AddRow(partID, 8)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT part_id, COALESCE(SUM(quantity), 0) FROM stock_movements GROUP BY part_id`)).
+		WillReturnRows(rows)
+
+	quantities, err := repo.LedgerQuantities(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 8, quantities[partID])
+}
+
+func TestPostgresPartRepository_ListLowStock(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresPartRepository(mock)
+
+	rows := pgxmock.NewRows(partColumns).
+		AddRow(uuid.New(), "SKU-5", "", "Brake Pad", "Front", 1, 0.0, 80.0, 5, 20)
+
+	mock.ExpectQuery(`WHERE stock_qty < min_quantity AND archived_at IS NULL`).
+		WillReturnRows(rows)
+
+	parts, err := repo.ListLowStock(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, parts, 1)
+	assert.Equal(t, 5, parts[0].MinQuantity)
+	assert.Equal(t, 20, parts[0].ReorderQuantity)
+	assert.True(t, parts[0].IsLowStock())
+}
+
+func TestPostgresPartRepository_GetBySKU(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresPartRepository(mock)
+	id := uuid.New()
+
+	// Lookups are normalized to the stored upper-case form
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM parts WHERE sku = $1 AND archived_at IS NULL`)).
+		WithArgs("BP-001").
+		WillReturnRows(pgxmock.NewRows(partColumns).
+			AddRow(id, "BP-001", "BOSCH-0986", "Brake Pad", "Front", 4, 52.3, 89.9, 0, 0))
+
+	part, err := repo.GetBySKU(context.Background(), " bp-001 ")
+	assert.NoError(t, err)
+	assert.Equal(t, id, part.ID)
+	assert.Equal(t, int64(5230), part.CostPrice.Cents())
+	assert.Equal(t, int64(3760), part.Margin().Cents())
+
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM parts WHERE sku = $1`)).
+		WithArgs("NOPE").
+		WillReturnError(pgx.ErrNoRows)
+
+	_, err = repo.GetBySKU(context.Background(), "nope")
+	assert.ErrorIs(t, err, domain.ErrPartNotFound)
+}
+
+func TestPostgresPartRepository_Save_DuplicateSKU(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresPartRepository(mock)
+	part, _ := domain.NewPart("TP-001", "Test Part", "Description", 0, sharedkernel.NewMoneyFromFloat(60.0), sharedkernel.NewMoneyFromFloat(100.0))
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO parts`)).
+		WithArgs(part.ID, part.SKU, part.ManufacturerCode, part.Name, part.Description, part.Quantity, part.CostPrice, part.SalePrice, part.MinQuantity, part.ReorderQuantity).
+		WillReturnError(&pgconn.PgError{Code: "23505"})
+
+	err = repo.Save(context.Background(), part)
+	assert.ErrorIs(t, err, domain.ErrDuplicateSKU)
+}
+
+func TestPostgresPartRepository_Archive(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresPartRepository(mock)
+	id := uuid.New()
+
+	// Success
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE parts SET archived_at = NOW() WHERE id = $1 AND archived_at IS NULL`)).
+		WithArgs(id).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	assert.NoError(t, repo.Archive(context.Background(), id))
+
+	// Not Found, or already archived
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE parts SET archived_at`)).
+		WithArgs(id).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	assert.ErrorIs(t, repo.Archive(context.Background(), id), domain.ErrPartNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,199 @@
+package infrastructure_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/noggrj/autorepair/internal/inventory/domain"
+	"github.com/noggrj/autorepair/internal/inventory/infrastructure"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresPurchaseOrderRepository_Save(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresPurchaseOrderRepository(mock)
+	po := domain.NewPurchaseOrder(uuid.New())
+	line, _ := po.AddLine(uuid.New(), 3, sharedkernel.NewMoneyFromCents(1000))
+	_ = po.Send()
+	receipt, _ := po.Receive(line.ID, 1, line.UnitCost)
+
+	// Success: header, lines and the pending receipt in one transaction
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO purchase_orders`)).
+		WithArgs(po.ID, po.SupplierID, "partially_received", po.CreatedAt, po.UpdatedAt, po.SentAt, po.ReceivedAt).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO purchase_order_lines`)).
+		WithArgs(line.ID, po.ID, line.PartID, 3, 1, line.UnitCost).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO purchase_receipts`)).
+		WithArgs(receipt.ID, po.ID, line.ID, line.PartID, 1, line.UnitCost, receipt.ReceivedAt).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectCommit()
+
+	err = repo.Save(context.Background(), po)
+	assert.NoError(t, err)
+	assert.Empty(t, po.PendingReceipts())
+
+	// Error rolls back
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO purchase_orders`)).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnError(errors.New("db error"))
+	mock.ExpectRollback()
+
+	err = repo.Save(context.Background(), po)
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresPurchaseOrderRepository_GetByID(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresPurchaseOrderRepository(mock)
+	id, supplierID, lineID, partID := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	now := time.Now()
+
+	// Success
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, supplier_id, status, created_at, updated_at, sent_at, received_at FROM purchase_orders WHERE id = $1 FOR UPDATE`)).
+		WithArgs(id).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "supplier_id", "status", "created_at", "updated_at", "sent_at", "received_at"}).
+			AddRow(id, supplierID, "sent", now, now, &now, nil))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, purchase_order_id, part_id, quantity, received_quantity, unit_cost FROM purchase_order_lines WHERE purchase_order_id = $1`)).
+		WithArgs(id).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "purchase_order_id", "part_id", "quantity", "received_quantity", "unit_cost"}).
+			AddRow(lineID, id, partID, 5, 2, 15.0))
+
+	po, err := repo.GetByIDForUpdate(context.Background(), id)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.PurchaseOrderSent, po.Status)
+	assert.Len(t, po.Lines, 1)
+	assert.Equal(t, 3, po.Lines[0].Remaining())
+
+	// Not Found
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id`)).
+		WithArgs(id).
+		WillReturnError(pgx.ErrNoRows)
+
+	_, err = repo.GetByID(context.Background(), id)
+	assert.ErrorIs(t, err, domain.ErrPurchaseOrderNotFound)
+}
+
+func TestPostgresPurchaseOrderRepository_ListReceipts(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresPurchaseOrderRepository(mock)
+	id := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, purchase_order_id, line_id, part_id, quantity, unit_cost, received_at FROM purchase_receipts WHERE purchase_order_id = $1 ORDER BY received_at ASC`)).
+		WithArgs(id).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "purchase_order_id", "line_id", "part_id", "quantity", "unit_cost", "received_at"}).
+			AddRow(uuid.New(), id, uuid.New(), uuid.New(), 2, 16.2, time.Now()))
+
+	receipts, err := repo.ListReceipts(context.Background(), id)
+	assert.NoError(t, err)
+	assert.Len(t, receipts, 1)
+	assert.Equal(t, int64(1620), receipts[0].UnitCost.Cents())
+}
+
+func TestPostgresPurchaseOrderRepository_HasOpenWithPart(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresPurchaseOrderRepository(mock)
+	partID := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS`)).
+		WithArgs(partID, "received").
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+
+	open, err := repo.HasOpenWithPart(context.Background(), partID)
+	assert.NoError(t, err)
+	assert.True(t, open)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS`)).
+		WillReturnError(errors.New("db error"))
+
+	_, err = repo.HasOpenWithPart(context.Background(), partID)
+	assert.Error(t, err)
+}
+
+func TestPostgresSupplierRepository_Delete(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresSupplierRepository(mock)
+	id := uuid.New()
+
+	// Success
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM suppliers WHERE id = $1`)).
+		WithArgs(id).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	assert.NoError(t, repo.Delete(context.Background(), id))
+
+	// Not Found
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM suppliers`)).
+		WithArgs(id).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	assert.ErrorIs(t, repo.Delete(context.Background(), id), domain.ErrSupplierNotFound)
+
+	// Referenced by purchase orders
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM suppliers`)).
+		WithArgs(id).
+		WillReturnError(&pgconn.PgError{Code: "23503"})
+	assert.ErrorIs(t, repo.Delete(context.Background(), id), domain.ErrSupplierInUse)
+}
+
+func TestPostgresSupplierRepository_GetByID(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresSupplierRepository(mock)
+	id := uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, document, email, phone, created_at, updated_at FROM suppliers WHERE id = $1`)).
+		WithArgs(id).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "document", "email", "phone", "created_at", "updated_at"}).
+			AddRow(id, "Auto Peças", "11222333000181", "vendas@autopecas.com", "11999999999", now, now))
+
+	supplier, err := repo.GetByID(context.Background(), id)
+	assert.NoError(t, err)
+	assert.Equal(t, "Auto Peças", supplier.Name)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id`)).
+		WithArgs(id).
+		WillReturnError(pgx.ErrNoRows)
+
+	_, err = repo.GetByID(context.Background(), id)
+	assert.ErrorIs(t, err, domain.ErrSupplierNotFound)
+}
@@ -2,25 +2,25 @@ package auth_test
 
 import (
 	"testing"
+	"time"
 
-	"github.com/noggrj/autorepair/internal/platform/auth"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/platform/auth"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestJWT(t *testing.T) {
-	// auth package uses internal secret key, we don't pass it in constructor anymore
-	// or we might need to check if we can set it.
-	// Current implementation has hardcoded "secret" or similar.
-	
+	// No keyring is installed here, so the package signs with its ephemeral
+	// development key.
+
 	userID := uuid.New()
 	role := "admin"
 
 	// Test Generate Token
-	accessToken, refreshToken, expiresIn, err := auth.GenerateToken(userID, role)
+	accessToken, expiresIn, err := auth.GenerateAccessToken(userID, role, nil)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, accessToken)
-	assert.NotEmpty(t, refreshToken)
 	assert.True(t, expiresIn > 0)
 
 	// Test Validate Token
@@ -28,9 +28,85 @@ func TestJWT(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, userID, claims.UserID)
 	assert.Equal(t, role, claims.Role)
+	assert.Equal(t, auth.TokenTypeAccess, claims.TokenType)
+	assert.Equal(t, auth.DefaultIssuer, claims.Issuer)
+	assert.Equal(t, []string{auth.DefaultAudience}, []string(claims.Audience))
+
+	// An access token is not a refresh token
+	_, err = auth.ValidateRefreshToken(accessToken)
+	assert.ErrorIs(t, err, auth.ErrWrongTokenType)
+}
+
+func TestJWT_RefreshToken(t *testing.T) {
+	userID := uuid.New()
+	jti := uuid.New()
+
+	refreshToken, expiresAt, err := auth.GenerateRefreshToken(userID, jti)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, refreshToken)
+	assert.True(t, expiresAt.After(time.Now().Add(auth.AccessTokenTTL)))
+
+	claims, err := auth.ValidateRefreshToken(refreshToken)
+	assert.NoError(t, err)
+	assert.Equal(t, userID, claims.UserID)
+	assert.Equal(t, jti.String(), claims.ID)
+
+	// A refresh token is not accepted as an access token
+	_, err = auth.ValidateToken(refreshToken)
+	assert.ErrorIs(t, err, auth.ErrWrongTokenType)
+}
+
+func TestJWT_AMR(t *testing.T) {
+	accessToken, _, err := auth.GenerateAccessToken(uuid.New(), "admin", []string{auth.AMRPassword, auth.AMRMFA})
+	assert.NoError(t, err)
+
+	claims, err := auth.ValidateToken(accessToken)
+	assert.NoError(t, err)
+	assert.True(t, claims.HasAMR(auth.AMRMFA))
+	assert.False(t, claims.HasAMR(auth.AMROTP))
+}
+
+func TestJWT_MFAChallengeToken(t *testing.T) {
+	userID := uuid.New()
+
+	challengeToken, expiresIn, err := auth.GenerateMFAChallengeToken(userID)
+	assert.NoError(t, err)
+	assert.True(t, expiresIn > 0 && expiresIn <= int64(auth.MFAChallengeTokenTTL.Seconds()))
+
+	claims, err := auth.ValidateMFAChallengeToken(challengeToken)
+	assert.NoError(t, err)
+	assert.Equal(t, userID, claims.UserID)
+	assert.False(t, claims.HasAMR(auth.AMRMFA))
+
+	// A challenge gets the user nowhere but the second login step
+	_, err = auth.ValidateToken(challengeToken)
+	assert.ErrorIs(t, err, auth.ErrWrongTokenType)
+	_, err = auth.ValidateRefreshToken(challengeToken)
+	assert.ErrorIs(t, err, auth.ErrWrongTokenType)
 }
 
 func TestJWT_InvalidToken(t *testing.T) {
 	_, err := auth.ValidateToken("invalid-token")
 	assert.Error(t, err)
 }
+
+func TestJWT_IssuerAndAudience(t *testing.T) {
+	t.Cleanup(func() { auth.UseIssuer(auth.DefaultIssuer, auth.DefaultAudience) })
+
+	auth.UseIssuer("https://auth.autorepair.com", "autorepair-api")
+	accessToken, _, err := auth.GenerateAccessToken(uuid.New(), "admin", nil)
+	assert.NoError(t, err)
+	claims, err := auth.ValidateToken(accessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://auth.autorepair.com", claims.Issuer)
+
+	// Another issuer's token is refused
+	auth.UseIssuer("https://other.example.com", "autorepair-api")
+	_, err = auth.ValidateToken(accessToken)
+	assert.ErrorIs(t, err, jwt.ErrTokenInvalidIssuer)
+
+	// So is one meant for another audience
+	auth.UseIssuer("https://auth.autorepair.com", "autorepair-mobile")
+	_, err = auth.ValidateToken(accessToken)
+	assert.ErrorIs(t, err, jwt.ErrTokenInvalidAudience)
+}
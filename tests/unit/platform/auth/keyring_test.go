@@ -0,0 +1,210 @@
+package auth_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/platform/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), data, 0o600))
+}
+
+func writeEd25519Key(t *testing.T, dir, kid string) ed25519.PublicKey {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	writePEM(t, dir, kid+".pem", "PRIVATE KEY", der)
+	return public
+}
+
+func writeRSAKey(t *testing.T, dir, kid string, bits int) *rsa.PrivateKey {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, bits)
+	require.NoError(t, err)
+	writePEM(t, dir, kid+".pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(private))
+	return private
+}
+
+// useKeyring installs a keyring for the duration of the test.
+func useKeyring(t *testing.T, k *auth.Keyring) {
+	t.Helper()
+	auth.Use(k)
+	t.Cleanup(func() { auth.Use(nil) })
+}
+
+func TestLoadKeyring_NoKeys(t *testing.T) {
+	_, err := auth.LoadKeyring("", "")
+	assert.ErrorIs(t, err, auth.ErrNoSigningKey)
+
+	_, err = auth.LoadKeyring(t.TempDir(), "")
+	assert.ErrorIs(t, err, auth.ErrNoSigningKey)
+}
+
+func TestLoadKeyring_SigningKeySelection(t *testing.T) {
+	dir := t.TempDir()
+	writeEd25519Key(t, dir, "2026-01")
+
+	k, err := auth.LoadKeyring(dir, "")
+	require.NoError(t, err)
+	assert.Equal(t, "2026-01", k.SigningKeyID())
+
+	writeRSAKey(t, dir, "2026-07", 2048)
+
+	_, err = auth.LoadKeyring(dir, "")
+	assert.Error(t, err, "two private keys need an explicit signing key")
+
+	k, err = auth.LoadKeyring(dir, "2026-07")
+	require.NoError(t, err)
+	assert.Equal(t, "2026-07", k.SigningKeyID())
+
+	_, err = auth.LoadKeyring(dir, "missing")
+	assert.Error(t, err)
+}
+
+func TestLoadKeyring_RejectsWeakAndUnknownKeys(t *testing.T) {
+	dir := t.TempDir()
+	writeRSAKey(t, dir, "weak", 1024)
+	_, err := auth.LoadKeyring(dir, "")
+	assert.Error(t, err)
+
+	dir = t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "garbage.pem"), []byte("not a key"), 0o600))
+	_, err = auth.LoadKeyring(dir, "")
+	assert.Error(t, err)
+}
+
+func TestKeyring_SignsWithKid(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		write func(t *testing.T, dir string)
+		alg   string
+	}{
+		{"EdDSA", func(t *testing.T, dir string) { writeEd25519Key(t, dir, "key-1") }, "EdDSA"},
+		{"RS256", func(t *testing.T, dir string) { writeRSAKey(t, dir, "key-1", 2048) }, "RS256"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tc.write(t, dir)
+			k, err := auth.LoadKeyring(dir, "")
+			require.NoError(t, err)
+			useKeyring(t, k)
+
+			token, _, err := auth.GenerateAccessToken(uuid.New(), "admin", nil)
+			require.NoError(t, err)
+
+			claims, err := auth.ValidateToken(token)
+			require.NoError(t, err)
+			assert.Equal(t, "admin", claims.Role)
+
+			set := auth.PublicKeys()
+			require.Len(t, set.Keys, 1)
+			assert.Equal(t, "key-1", set.Keys[0].Kid)
+			assert.Equal(t, tc.alg, set.Keys[0].Alg)
+		})
+	}
+}
+
+func TestKeyring_Rotation(t *testing.T) {
+	dir := t.TempDir()
+	writeEd25519Key(t, dir, "old")
+	oldRing, err := auth.LoadKeyring(dir, "")
+	require.NoError(t, err)
+	useKeyring(t, oldRing)
+
+	oldToken, _, err := auth.GenerateAccessToken(uuid.New(), "admin", nil)
+	require.NoError(t, err)
+
+	// Rotate: the new key signs, the old one still verifies.
+	writeRSAKey(t, dir, "new", 2048)
+	rotated, err := auth.LoadKeyring(dir, "new")
+	require.NoError(t, err)
+	auth.Use(rotated)
+
+	_, err = auth.ValidateToken(oldToken)
+	assert.NoError(t, err)
+
+	newToken, _, err := auth.GenerateAccessToken(uuid.New(), "admin", nil)
+	require.NoError(t, err)
+	_, err = auth.ValidateToken(newToken)
+	assert.NoError(t, err)
+	assert.Len(t, auth.PublicKeys().Keys, 2)
+
+	// Retire the old key: its tokens stop verifying.
+	require.NoError(t, os.Remove(filepath.Join(dir, "old.pem")))
+	retired, err := auth.LoadKeyring(dir, "new")
+	require.NoError(t, err)
+	auth.Use(retired)
+
+	_, err = auth.ValidateToken(oldToken)
+	assert.Error(t, err)
+	_, err = auth.ValidateToken(newToken)
+	assert.NoError(t, err)
+}
+
+func TestKeyring_VerificationOnlyKey(t *testing.T) {
+	dir := t.TempDir()
+	public := writeEd25519Key(t, dir, "signer")
+	der, err := x509.MarshalPKIXPublicKey(public)
+	require.NoError(t, err)
+
+	verifyDir := t.TempDir()
+	writeEd25519Key(t, verifyDir, "current")
+	writePEM(t, verifyDir, "signer.pem", "PUBLIC KEY", der)
+
+	signer, err := auth.LoadKeyring(dir, "")
+	require.NoError(t, err)
+	verifier, err := auth.LoadKeyring(verifyDir, "")
+	require.NoError(t, err, "public-only keys are not signing candidates")
+	assert.Equal(t, "current", verifier.SigningKeyID())
+
+	useKeyring(t, signer)
+	token, _, err := auth.GenerateAccessToken(uuid.New(), "admin", nil)
+	require.NoError(t, err)
+
+	auth.Use(verifier)
+	_, err = auth.ValidateToken(token)
+	assert.NoError(t, err)
+
+	_, err = auth.LoadKeyring(verifyDir, "signer")
+	assert.Error(t, err, "a public key cannot sign")
+}
+
+func TestKeyring_ForeignToken(t *testing.T) {
+	first, err := auth.NewEphemeralKeyring()
+	require.NoError(t, err)
+	second, err := auth.NewEphemeralKeyring()
+	require.NoError(t, err)
+
+	useKeyring(t, first)
+	token, _, err := auth.GenerateAccessToken(uuid.New(), "admin", nil)
+	require.NoError(t, err)
+
+	// Same kid, different key: the signature does not verify.
+	auth.Use(second)
+	_, err = auth.ValidateToken(token)
+	assert.Error(t, err)
+
+	// A kid the keyring does not know is rejected outright.
+	dir := t.TempDir()
+	writeEd25519Key(t, dir, "other")
+	other, err := auth.LoadKeyring(dir, "")
+	require.NoError(t, err)
+	auth.Use(other)
+	_, err = auth.ValidateToken(token)
+	assert.ErrorContains(t, err, "unknown key id")
+}
@@ -0,0 +1,66 @@
+package auth_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/platform/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLinkSigner(t *testing.T) {
+	_, err := auth.NewLinkSigner(nil)
+	assert.ErrorIs(t, err, auth.ErrNoLinkKey)
+	_, err = auth.NewLinkSigner([]byte("too short"))
+	assert.ErrorIs(t, err, auth.ErrShortLinkKey)
+	_, err = auth.NewLinkSigner(bytes.Repeat([]byte("k"), auth.MinLinkKeyLength))
+	assert.NoError(t, err)
+}
+
+func TestLinkSigner_SignAndVerify(t *testing.T) {
+	signer, err := auth.NewLinkSigner(bytes.Repeat([]byte("k"), auth.MinLinkKeyLength))
+	require.NoError(t, err)
+
+	id := uuid.New()
+	now := time.Now()
+	token := signer.Sign("budget", id, now.Add(time.Hour))
+
+	got, err := signer.Verify("budget", token, now)
+	assert.NoError(t, err)
+	assert.Equal(t, id, got)
+
+	t.Run("Expired", func(t *testing.T) {
+		_, err := signer.Verify("budget", token, now.Add(2*time.Hour))
+		assert.ErrorIs(t, err, auth.ErrInvalidLinkToken)
+	})
+
+	t.Run("Other purpose", func(t *testing.T) {
+		_, err := signer.Verify("invoice", token, now)
+		assert.ErrorIs(t, err, auth.ErrInvalidLinkToken)
+	})
+
+	t.Run("Other key", func(t *testing.T) {
+		other, err := auth.NewEphemeralLinkSigner()
+		require.NoError(t, err)
+		_, err = other.Verify("budget", token, now)
+		assert.ErrorIs(t, err, auth.ErrInvalidLinkToken)
+	})
+
+	t.Run("Tampered", func(t *testing.T) {
+		forged := signer.Sign("budget", uuid.New(), now.Add(time.Hour))
+		payload := forged[:bytes.IndexByte([]byte(forged), '.')]
+		signature := token[bytes.IndexByte([]byte(token), '.'):]
+		_, err := signer.Verify("budget", payload+signature, now)
+		assert.ErrorIs(t, err, auth.ErrInvalidLinkToken)
+	})
+
+	t.Run("Malformed", func(t *testing.T) {
+		for _, bad := range []string{"", "abc", "abc.def", "!!.!!"} {
+			_, err := signer.Verify("budget", bad, now)
+			assert.ErrorIs(t, err, auth.ErrInvalidLinkToken, bad)
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package auth_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/noggrj/autorepair/internal/platform/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+// rfcSecret is the SHA-1 seed of the RFC 6238 test vectors,
+// "12345678901234567890", in base32.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode_RFC6238(t *testing.T) {
+	// The RFC lists 8-digit codes; ours are their last 6 digits.
+	vectors := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for unix, want := range vectors {
+		code, err := auth.TOTPCode(rfcSecret, auth.TOTPStep(time.Unix(unix, 0)))
+		assert.NoError(t, err)
+		assert.Equal(t, want, code, "time %d", unix)
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := auth.TOTPStep(now)
+
+	step, ok := auth.ValidateTOTP(rfcSecret, "005924", now)
+	assert.True(t, ok)
+	assert.Equal(t, current, step)
+
+	// One period of drift either way is forgiven
+	previous, _ := auth.TOTPCode(rfcSecret, current-1)
+	step, ok = auth.ValidateTOTP(rfcSecret, previous, now)
+	assert.True(t, ok)
+	assert.Equal(t, current-1, step)
+
+	stale, _ := auth.TOTPCode(rfcSecret, current-2)
+	_, ok = auth.ValidateTOTP(rfcSecret, stale, now)
+	assert.False(t, ok)
+
+	_, ok = auth.ValidateTOTP(rfcSecret, "", now)
+	assert.False(t, ok)
+	_, ok = auth.ValidateTOTP("not base32!", "005924", now)
+	assert.False(t, ok)
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	a, err := auth.GenerateTOTPSecret()
+	assert.NoError(t, err)
+	b, _ := auth.GenerateTOTPSecret()
+	assert.Len(t, a, 32)
+	assert.NotEqual(t, a, b)
+
+	_, err = auth.TOTPCode(a, 1)
+	assert.NoError(t, err)
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri, err := url.Parse(auth.TOTPProvisioningURI(rfcSecret, "AutoRepair", "ana@example.com"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/AutoRepair:ana@example.com", uri.Path)
+	assert.Equal(t, rfcSecret, uri.Query().Get("secret"))
+	assert.Equal(t, "AutoRepair", uri.Query().Get("issuer"))
+	assert.Equal(t, "6", uri.Query().Get("digits"))
+	assert.Equal(t, "30", uri.Query().Get("period"))
+}
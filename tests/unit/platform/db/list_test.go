@@ -0,0 +1,87 @@
+package db_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/platform/db"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
+	"github.com/stretchr/testify/assert"
+)
+
+type row struct {
+	ID   uuid.UUID
+	Name string
+	At   time.Time
+}
+
+var rowListing = db.Listing[row]{
+	Sorts: map[string]db.SortField[row]{
+		"name": {{Expr: "name", Cast: "text", Value: func(r row) string { return r.Name }}},
+		"recent": {
+			{Expr: "at", Cast: "timestamptz", Value: func(r row) string { return db.FormatTime(r.At) }},
+			{Expr: "name", Cast: "text", Value: func(r row) string { return r.Name }},
+		},
+	},
+	DefaultSort: "name",
+	ID:          func(r row) uuid.UUID { return r.ID },
+}
+
+func TestListing_Query(t *testing.T) {
+	// Zero query: default order, no limit
+	var f db.Filter
+	query, args, err := rowListing.Query(`SELECT id, name FROM rows`, &f, sharedkernel.ListQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, `SELECT id, name FROM rows ORDER BY name ASC, id ASC`, query)
+	assert.Empty(t, args)
+
+	// Filters, descending sort and limit
+	f = db.Filter{}
+	f.Contains("50%_off", "name", "note")
+	f.Where("kind = " + f.Arg("a"))
+	query, args, err = rowListing.Query(`SELECT id, name FROM rows`, &f, sharedkernel.ListQuery{Limit: 10, Sort: "recent", Desc: true})
+	assert.NoError(t, err)
+	assert.Equal(t, `SELECT id, name FROM rows WHERE (name ILIKE $1 OR note ILIKE $1) AND kind = $2 ORDER BY at DESC, name DESC, id DESC LIMIT $3`, query)
+	assert.Equal(t, []any{`%50\%\_off%`, "a", 11}, args)
+
+	// Unknown sort field
+	_, _, err = rowListing.Query(`SELECT id, name FROM rows`, &db.Filter{}, sharedkernel.ListQuery{Sort: "secret"})
+	assert.ErrorIs(t, err, sharedkernel.ErrInvalidSort)
+
+	// Garbage cursor
+	_, _, err = rowListing.Query(`SELECT id, name FROM rows`, &db.Filter{}, sharedkernel.ListQuery{Cursor: "not-a-cursor"})
+	assert.ErrorIs(t, err, sharedkernel.ErrInvalidCursor)
+}
+
+func TestListing_Page(t *testing.T) {
+	rows := []row{
+		{ID: uuid.New(), Name: "a"},
+		{ID: uuid.New(), Name: "b"},
+		{ID: uuid.New(), Name: "c"},
+	}
+	q := sharedkernel.ListQuery{Limit: 2}
+
+	// The extra row means there is a next page
+	page := rowListing.Page(rows, q)
+	assert.Len(t, page.Items, 2)
+	assert.NotEmpty(t, page.NextCursor)
+
+	// The cursor resumes after the last row of the page
+	q.Cursor = page.NextCursor
+	var f db.Filter
+	query, args, err := rowListing.Query(`SELECT id, name FROM rows`, &f, q)
+	assert.NoError(t, err)
+	assert.Equal(t, `SELECT id, name FROM rows WHERE (name, id) > ($1::text, $2) ORDER BY name ASC, id ASC LIMIT $3`, query)
+	assert.Equal(t, []any{"b", rows[1].ID, 3}, args)
+
+	// A cursor only fits the sort order it was issued for
+	q.Sort, q.Desc = "name", true
+	_, _, err = rowListing.Query(`SELECT id, name FROM rows`, &db.Filter{}, q)
+	assert.ErrorIs(t, err, sharedkernel.ErrInvalidCursor)
+
+	// Last page
+	page = rowListing.Page(rows[2:], sharedkernel.ListQuery{Limit: 2})
+	assert.Len(t, page.Items, 1)
+	assert.Empty(t, page.NextCursor)
+}
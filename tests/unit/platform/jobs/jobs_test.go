@@ -0,0 +1,43 @@
+package jobs_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/noggrj/autorepair/internal/platform/jobs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvery(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	runs := make(chan struct{}, 10)
+	done := make(chan struct{})
+
+	go func() {
+		jobs.Every(ctx, time.Millisecond, func(ctx context.Context) {
+			select {
+			case runs <- struct{}{}:
+			default:
+			}
+		})
+		close(done)
+	}()
+
+	for i := 0; i < 3; i++ {
+		select {
+		case <-runs:
+		case <-time.After(time.Second):
+			t.Fatal("job did not run")
+		}
+	}
+
+	// Stops once the context is done
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("job did not stop")
+	}
+	assert.Error(t, ctx.Err())
+}
@@ -14,7 +14,7 @@ import (
 
 func TestAuthMiddleware(t *testing.T) {
 	userID := uuid.New()
-	token, _, _, _ := auth.GenerateToken(userID, "admin")
+	token, _, _ := auth.GenerateAccessToken(userID, "admin", nil)
 
 	mw := middleware.AuthMiddleware
 	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -50,6 +50,14 @@ func TestAuthMiddleware(t *testing.T) {
 	rr = httptest.NewRecorder()
 	mw(nextHandler).ServeHTTP(rr, req)
 	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	// Case 5: Refresh token used as access token
+	refreshToken, _, _ := auth.GenerateRefreshToken(userID, uuid.New())
+	req, _ = http.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+refreshToken)
+	rr = httptest.NewRecorder()
+	mw(nextHandler).ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
 }
 
 func TestRequireRole(t *testing.T) {
@@ -92,3 +100,17 @@ func TestRequireRole(t *testing.T) {
 	mw(nextHandler).ServeHTTP(rr, req)
 	assert.Equal(t, http.StatusUnauthorized, rr.Code)
 }
+
+func TestClientIP(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+
+	req.RemoteAddr = "203.0.113.7:52100"
+	assert.Equal(t, "203.0.113.7", middleware.ClientIP(req))
+
+	req.RemoteAddr = "[2001:db8::1]:52100"
+	assert.Equal(t, "2001:db8::1", middleware.ClientIP(req))
+
+	// RealIP leaves the bare address behind.
+	req.RemoteAddr = "198.51.100.20"
+	assert.Equal(t, "198.51.100.20", middleware.ClientIP(req))
+}
@@ -0,0 +1,236 @@
+package application_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	inventoryDomain "github.com/noggrj/autorepair/internal/inventory/domain"
+	"github.com/noggrj/autorepair/internal/platform/auth"
+	"github.com/noggrj/autorepair/internal/service/application"
+	serviceDomain "github.com/noggrj/autorepair/internal/service/domain"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockBudgetApprovalRepository struct {
+	mock.Mock
+}
+
+func (m *MockBudgetApprovalRepository) Save(ctx context.Context, approval *serviceDomain.BudgetApproval) error {
+	args := m.Called(ctx, approval)
+	return args.Error(0)
+}
+
+func (m *MockBudgetApprovalRepository) GetByID(ctx context.Context, id uuid.UUID) (*serviceDomain.BudgetApproval, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*serviceDomain.BudgetApproval), args.Error(1)
+}
+
+func (m *MockBudgetApprovalRepository) ListByOrderID(ctx context.Context, orderID uuid.UUID) ([]*serviceDomain.BudgetApproval, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*serviceDomain.BudgetApproval), args.Error(1)
+}
+
+func (m *MockBudgetApprovalRepository) RevokeOrder(ctx context.Context, orderID uuid.UUID, at time.Time) error {
+	args := m.Called(ctx, orderID, at)
+	return args.Error(0)
+}
+
+func (m *MockBudgetApprovalRepository) Decide(ctx context.Context, id uuid.UUID, decision serviceDomain.BudgetDecision) (bool, error) {
+	args := m.Called(ctx, id, decision)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockBudgetApprovalRepository) DecideOrder(ctx context.Context, orderID uuid.UUID, decision serviceDomain.BudgetDecision) (bool, error) {
+	args := m.Called(ctx, orderID, decision)
+	return args.Bool(0), args.Error(1)
+}
+
+// budgetFixture is an order service with budget links, and an order whose
+// budget it sent; token is the approval code mailed to the client.
+type budgetFixture struct {
+	service   *application.OrderService
+	orders    *MockOrderRepository
+	parts     *MockPartRepository
+	approvals *MockBudgetApprovalRepository
+	order     *serviceDomain.Order
+	approval  *serviceDomain.BudgetApproval
+	token     string
+}
+
+func newBudgetFixture(t *testing.T, items ...*serviceDomain.OrderItem) *budgetFixture {
+	t.Helper()
+	signer, err := auth.NewEphemeralLinkSigner()
+	require.NoError(t, err)
+
+	f := &budgetFixture{
+		orders:    new(MockOrderRepository),
+		parts:     new(MockPartRepository),
+		approvals: new(MockBudgetApprovalRepository),
+	}
+	clients := new(MockClientRepository)
+	notifier := new(MockNotifier)
+	uow := &fakeUnitOfWork{repos: application.TxRepositories{Orders: f.orders, Parts: f.parts, BudgetApprovals: f.approvals}}
+	f.service = application.NewOrderService(f.orders, f.parts, clients, notifier, uow, nil, application.NewBudgetLinks(f.approvals, signer))
+
+	clientID := uuid.New()
+	f.order = &serviceDomain.Order{
+		ID:       uuid.New(),
+		ClientID: clientID,
+		Status:   serviceDomain.OrderStatusInDiagnosis,
+		Total:    sharedkernel.NewMoneyFromFloat(100.0),
+		Items:    items,
+	}
+
+	f.orders.On("GetByID", mock.Anything, f.order.ID).Return(f.order, nil)
+	f.orders.On("GetByIDForUpdate", mock.Anything, f.order.ID).Return(f.order, nil)
+	f.orders.On("Save", mock.Anything, f.order).Return(nil)
+	f.approvals.On("RevokeOrder", mock.Anything, f.order.ID, mock.Anything).Return(nil)
+	f.approvals.On("Save", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		f.approval = args.Get(1).(*serviceDomain.BudgetApproval)
+	}).Return(nil)
+	clients.On("GetByID", mock.Anything, clientID).Return(&serviceDomain.Client{ID: clientID, Email: "client@test.com"}, nil)
+	notifier.On("SendEmail", "client@test.com", "Order Budget Ready", mock.Anything).Run(func(args mock.Arguments) {
+		body := args.String(2)
+		f.token = body[strings.LastIndex(body, " ")+1:]
+	}).Return(nil)
+	notifier.On("SendEmail", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	require.NoError(t, f.service.SendBudget(context.Background(), f.order.ID, "tester", ""))
+	require.NotNil(t, f.approval)
+	f.approvals.On("GetByID", mock.Anything, f.approval.ID).Return(f.approval, nil)
+	return f
+}
+
+func decision(approved bool) serviceDomain.BudgetDecision {
+	return serviceDomain.BudgetDecision{Approved: approved, IP: "203.0.113.7", UserAgent: "test-agent", DecidedAt: time.Now()}
+}
+
+func TestOrderService_SendBudget_IssuesApprovalLink(t *testing.T) {
+	f := newBudgetFixture(t)
+
+	assert.Equal(t, serviceDomain.OrderStatusAwaitingApproval, f.order.Status)
+	assert.Equal(t, f.order.ID, f.approval.OrderID)
+	assert.NotEmpty(t, f.token)
+	// Older links for the order are revoked before the new one is stored
+	f.approvals.AssertCalled(t, "RevokeOrder", mock.Anything, f.order.ID, f.approval.CreatedAt)
+}
+
+func TestOrderService_SendBudget_LinkError(t *testing.T) {
+	signer, err := auth.NewEphemeralLinkSigner()
+	require.NoError(t, err)
+	orders := new(MockOrderRepository)
+	approvals := new(MockBudgetApprovalRepository)
+	uow := &fakeUnitOfWork{repos: application.TxRepositories{Orders: orders, BudgetApprovals: approvals}}
+	service := application.NewOrderService(orders, nil, nil, nil, uow, nil, application.NewBudgetLinks(approvals, signer))
+
+	order := &serviceDomain.Order{ID: uuid.New(), Status: serviceDomain.OrderStatusInDiagnosis}
+	orders.On("GetByIDForUpdate", mock.Anything, order.ID).Return(order, nil)
+	approvals.On("RevokeOrder", mock.Anything, order.ID, mock.Anything).Return(errors.New("db down"))
+
+	err = service.SendBudget(context.Background(), order.ID, "tester", "")
+	assert.EqualError(t, err, "db down")
+	// The order is only saved with its link, in the same unit of work
+	orders.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestOrderService_AnswerBudget(t *testing.T) {
+	t.Run("Approve", func(t *testing.T) {
+		partID := uuid.New()
+		f := newBudgetFixture(t, &serviceDomain.OrderItem{RefID: partID, Type: serviceDomain.ItemTypePart, Quantity: 2})
+		part := &inventoryDomain.Part{ID: partID, Quantity: 5}
+		f.parts.On("GetByIDForUpdate", mock.Anything, partID).Return(part, nil)
+		f.parts.On("Update", mock.Anything, part).Return(nil)
+		d := decision(true)
+		f.approvals.On("Decide", mock.Anything, f.approval.ID, d).Return(true, nil)
+
+		err := f.service.AnswerBudget(context.Background(), f.order.ID, f.token, d)
+		assert.NoError(t, err)
+		assert.Equal(t, serviceDomain.OrderStatusInExecution, f.order.Status)
+		assert.Equal(t, 3, part.Quantity)
+		f.approvals.AssertExpectations(t)
+	})
+
+	t.Run("Reject", func(t *testing.T) {
+		f := newBudgetFixture(t)
+		d := decision(false)
+		f.approvals.On("Decide", mock.Anything, f.approval.ID, d).Return(true, nil)
+
+		err := f.service.AnswerBudget(context.Background(), f.order.ID, f.token, d)
+		assert.NoError(t, err)
+		assert.Equal(t, serviceDomain.OrderStatusReceived, f.order.Status)
+	})
+
+	t.Run("Forged Token", func(t *testing.T) {
+		f := newBudgetFixture(t)
+
+		err := f.service.AnswerBudget(context.Background(), f.order.ID, f.token+"x", decision(true))
+		assert.ErrorIs(t, err, serviceDomain.ErrInvalidBudgetApproval)
+		assert.Equal(t, serviceDomain.OrderStatusAwaitingApproval, f.order.Status)
+	})
+
+	t.Run("Another Order", func(t *testing.T) {
+		f := newBudgetFixture(t)
+
+		err := f.service.AnswerBudget(context.Background(), uuid.New(), f.token, decision(true))
+		assert.ErrorIs(t, err, serviceDomain.ErrInvalidBudgetApproval)
+	})
+
+	t.Run("Revoked", func(t *testing.T) {
+		f := newBudgetFixture(t)
+		revokedAt := time.Now()
+		f.approval.RevokedAt = &revokedAt
+		f.orders.Calls = nil // forget the lock taken by SendBudget
+
+		err := f.service.AnswerBudget(context.Background(), f.order.ID, f.token, decision(true))
+		assert.ErrorIs(t, err, serviceDomain.ErrInvalidBudgetApproval)
+		f.orders.AssertNotCalled(t, "GetByIDForUpdate", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		f := newBudgetFixture(t)
+		d := decision(true)
+		d.DecidedAt = f.approval.ExpiresAt.Add(time.Second)
+
+		err := f.service.AnswerBudget(context.Background(), f.order.ID, f.token, d)
+		assert.ErrorIs(t, err, serviceDomain.ErrInvalidBudgetApproval)
+	})
+
+	t.Run("Already Answered", func(t *testing.T) {
+		f := newBudgetFixture(t)
+		d := decision(false)
+		f.approvals.On("Decide", mock.Anything, f.approval.ID, d).Return(false, nil)
+
+		err := f.service.AnswerBudget(context.Background(), f.order.ID, f.token, d)
+		assert.ErrorIs(t, err, serviceDomain.ErrInvalidBudgetApproval)
+	})
+
+	t.Run("Not Awaiting Approval", func(t *testing.T) {
+		f := newBudgetFixture(t)
+		f.order.Status = serviceDomain.OrderStatusReceived
+
+		err := f.service.AnswerBudget(context.Background(), f.order.ID, f.token, decision(true))
+		assert.ErrorIs(t, err, serviceDomain.ErrInvalidTransition)
+		assert.Equal(t, serviceDomain.OrderStatusReceived, f.order.Status)
+		f.approvals.AssertNotCalled(t, "Decide", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Without Budget Links", func(t *testing.T) {
+		service := application.NewOrderService(nil, nil, nil, nil, nil, nil, nil)
+
+		err := service.AnswerBudget(context.Background(), uuid.New(), "token", decision(true))
+		assert.ErrorIs(t, err, serviceDomain.ErrInvalidBudgetApproval)
+	})
+}
@@ -0,0 +1,160 @@
+package application_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/service/application"
+	serviceDomain "github.com/noggrj/autorepair/internal/service/domain"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockMaintenancePlanRepository struct {
+	mock.Mock
+}
+
+func (m *MockMaintenancePlanRepository) Save(ctx context.Context, plan *serviceDomain.MaintenancePlan) error {
+	args := m.Called(ctx, plan)
+	return args.Error(0)
+}
+
+func (m *MockMaintenancePlanRepository) GetByID(ctx context.Context, id uuid.UUID) (*serviceDomain.MaintenancePlan, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*serviceDomain.MaintenancePlan), args.Error(1)
+}
+
+func (m *MockMaintenancePlanRepository) List(ctx context.Context) ([]*serviceDomain.MaintenancePlan, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*serviceDomain.MaintenancePlan), args.Error(1)
+}
+
+func (m *MockMaintenancePlanRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// fakeMaintenanceReminderRepository serves fixed candidates and claims each
+// plan and order once, like the unique index does.
+type fakeMaintenanceReminderRepository struct {
+	candidates []*serviceDomain.MaintenanceCandidate
+	claimed    map[[2]uuid.UUID]*serviceDomain.MaintenanceReminder
+}
+
+func (r *fakeMaintenanceReminderRepository) ListCandidates(ctx context.Context) ([]*serviceDomain.MaintenanceCandidate, error) {
+	return r.candidates, nil
+}
+
+func (r *fakeMaintenanceReminderRepository) Claim(ctx context.Context, reminder *serviceDomain.MaintenanceReminder) (bool, error) {
+	if r.claimed == nil {
+		r.claimed = map[[2]uuid.UUID]*serviceDomain.MaintenanceReminder{}
+	}
+	key := [2]uuid.UUID{reminder.PlanID, reminder.OrderID}
+	if _, ok := r.claimed[key]; ok {
+		return false, nil
+	}
+	r.claimed[key] = reminder
+	return true, nil
+}
+
+type MockServiceRepository struct {
+	mock.Mock
+}
+
+func (m *MockServiceRepository) Save(ctx context.Context, service *serviceDomain.Service) error {
+	args := m.Called(ctx, service)
+	return args.Error(0)
+}
+
+func (m *MockServiceRepository) GetByID(ctx context.Context, id uuid.UUID) (*serviceDomain.Service, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*serviceDomain.Service), args.Error(1)
+}
+
+func (m *MockServiceRepository) List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*serviceDomain.Service], error) {
+	args := m.Called(ctx, q)
+	return args.Get(0).(sharedkernel.Page[*serviceDomain.Service]), args.Error(1)
+}
+
+func (m *MockServiceRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func TestMaintenanceService_CreatePlan(t *testing.T) {
+	plans := new(MockMaintenancePlanRepository)
+	services := new(MockServiceRepository)
+	service := application.NewMaintenanceService(plans, nil, services, nil, nil)
+	serviceID := uuid.New()
+
+	// Success
+	services.On("GetByID", mock.Anything, serviceID).Return(&serviceDomain.Service{ID: serviceID, Name: "Oil change"}, nil).Once()
+	plans.On("Save", mock.Anything, mock.Anything).Return(nil).Once()
+	plan, err := service.CreatePlan(context.Background(), serviceID, 10000, 12)
+	assert.NoError(t, err)
+	assert.Equal(t, serviceID, plan.ServiceID)
+
+	// Invalid intervals are refused before any lookup
+	_, err = service.CreatePlan(context.Background(), serviceID, 0, 0)
+	assert.ErrorIs(t, err, serviceDomain.ErrInvalidMaintenancePlan)
+
+	// Unknown service
+	services.On("GetByID", mock.Anything, serviceID).Return(nil, serviceDomain.ErrServiceNotFound).Once()
+	_, err = service.CreatePlan(context.Background(), serviceID, 10000, 12)
+	assert.ErrorIs(t, err, serviceDomain.ErrServiceNotFound)
+
+	plans.AssertNumberOfCalls(t, "Save", 1)
+}
+
+func TestMaintenanceService_SendReminders(t *testing.T) {
+	now := time.Date(2026, 6, 1, 8, 0, 0, 0, time.UTC)
+	doneMileage := 40000
+	plan := &serviceDomain.MaintenancePlan{ID: uuid.New(), ServiceID: uuid.New(), IntervalKm: 10000, IntervalMonths: 12}
+	owner := &serviceDomain.Client{ID: uuid.New(), Name: "Ana", Email: "ana@test.com"}
+	candidate := func(current int, clientID uuid.UUID) *serviceDomain.MaintenanceCandidate {
+		return &serviceDomain.MaintenanceCandidate{
+			Plan: plan, ServiceName: "Oil change", VehicleID: uuid.New(), Plate: "ABC1D23",
+			ClientID: clientID, CurrentMileage: current,
+			OrderID: uuid.New(), DoneMileage: &doneMileage, DoneAt: now.AddDate(0, -3, 0),
+		}
+	}
+
+	due := candidate(50100, owner.ID)
+	notDue := candidate(45000, owner.ID)
+	unknownOwner := candidate(52000, uuid.New())
+	reminders := &fakeMaintenanceReminderRepository{candidates: []*serviceDomain.MaintenanceCandidate{due, notDue, unknownOwner}}
+
+	clients := new(MockClientRepository)
+	clients.On("GetByID", mock.Anything, owner.ID).Return(owner, nil)
+	clients.On("GetByID", mock.Anything, unknownOwner.ClientID).Return(nil, errors.New("not found"))
+	notifier := new(MockNotifier)
+	notifier.On("SendEmail", "ana@test.com", "Maintenance Due: Oil change", mock.MatchedBy(func(body string) bool {
+		return assert.Contains(t, body, "ABC1D23") && assert.Contains(t, body, "at 50000 km")
+	})).Return(nil).Once()
+
+	service := application.NewMaintenanceService(nil, reminders, nil, clients, notifier)
+
+	sent, err := service.SendReminders(context.Background(), now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Len(t, reminders.claimed, 1)
+
+	// A second run does not remind the same service order again
+	sent, err = service.SendReminders(context.Background(), now)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, sent)
+	notifier.AssertExpectations(t)
+}
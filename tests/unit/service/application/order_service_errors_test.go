@@ -1,6 +1,7 @@
 package application_test
 
 import (
+	"context"
 	"errors"
 	"testing"
 
@@ -13,38 +14,38 @@ import (
 )
 
 func TestOrderService_SendBudget_Errors(t *testing.T) {
-	t.Run("GetByID Error", func(t *testing.T) {
+	t.Run("GetByIDForUpdate Error", func(t *testing.T) {
 		mockOrderRepo := new(MockOrderRepository)
-		service := application.NewOrderService(mockOrderRepo, nil, nil, nil)
+		service := application.NewOrderService(mockOrderRepo, nil, nil, nil, newFakeUnitOfWork(mockOrderRepo, nil), nil, nil)
 		orderID := uuid.New()
-		mockOrderRepo.On("GetByID", orderID).Return(nil, errors.New("repo error"))
+		mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(nil, errors.New("repo error"))
 
-		err := service.SendBudget(orderID)
+		err := service.SendBudget(context.Background(), orderID, "tester", "")
 		assert.Error(t, err)
 		assert.Equal(t, "repo error", err.Error())
 	})
 
 	t.Run("Wrong Status", func(t *testing.T) {
 		mockOrderRepo := new(MockOrderRepository)
-		service := application.NewOrderService(mockOrderRepo, nil, nil, nil)
+		service := application.NewOrderService(mockOrderRepo, nil, nil, nil, newFakeUnitOfWork(mockOrderRepo, nil), nil, nil)
 		orderID := uuid.New()
 		order := &serviceDomain.Order{ID: orderID, Status: serviceDomain.OrderStatusReceived}
-		mockOrderRepo.On("GetByID", orderID).Return(order, nil)
+		mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
 
-		err := service.SendBudget(orderID)
+		err := service.SendBudget(context.Background(), orderID, "tester", "")
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "can only be sent from 'In diagnosis' status")
 	})
 
 	t.Run("Save Error", func(t *testing.T) {
 		mockOrderRepo := new(MockOrderRepository)
-		service := application.NewOrderService(mockOrderRepo, nil, nil, nil)
+		service := application.NewOrderService(mockOrderRepo, nil, nil, nil, newFakeUnitOfWork(mockOrderRepo, nil), nil, nil)
 		orderID := uuid.New()
 		order := &serviceDomain.Order{ID: orderID, Status: serviceDomain.OrderStatusInDiagnosis}
-		mockOrderRepo.On("GetByID", orderID).Return(order, nil)
-		mockOrderRepo.On("Save", mock.Anything).Return(errors.New("save error"))
+		mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+		mockOrderRepo.On("Save", mock.Anything, mock.Anything).Return(errors.New("save error"))
 
-		err := service.SendBudget(orderID)
+		err := service.SendBudget(context.Background(), orderID, "tester", "")
 		assert.Error(t, err)
 		assert.Equal(t, "save error", err.Error())
 	})
@@ -52,16 +53,16 @@ func TestOrderService_SendBudget_Errors(t *testing.T) {
 	t.Run("Client Repo Error (Should Log and Continue)", func(t *testing.T) {
 		mockOrderRepo := new(MockOrderRepository)
 		mockClientRepo := new(MockClientRepository)
-		service := application.NewOrderService(mockOrderRepo, nil, mockClientRepo, nil)
+		service := application.NewOrderService(mockOrderRepo, nil, mockClientRepo, nil, newFakeUnitOfWork(mockOrderRepo, nil), nil, nil)
 		orderID := uuid.New()
 		clientID := uuid.New()
 		order := &serviceDomain.Order{ID: orderID, ClientID: clientID, Status: serviceDomain.OrderStatusInDiagnosis}
 
-		mockOrderRepo.On("GetByID", orderID).Return(order, nil)
-		mockOrderRepo.On("Save", mock.Anything).Return(nil)
-		mockClientRepo.On("GetByID", clientID).Return(nil, errors.New("client error"))
+		mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+		mockOrderRepo.On("Save", mock.Anything, mock.Anything).Return(nil)
+		mockClientRepo.On("GetByID", mock.Anything, clientID).Return(nil, errors.New("client error"))
 
-		err := service.SendBudget(orderID)
+		err := service.SendBudget(context.Background(), orderID, "tester", "")
 		assert.NoError(t, err)
 		// Status should still be updated
 		assert.Equal(t, serviceDomain.OrderStatusAwaitingApproval, order.Status)
@@ -71,18 +72,18 @@ func TestOrderService_SendBudget_Errors(t *testing.T) {
 		mockOrderRepo := new(MockOrderRepository)
 		mockClientRepo := new(MockClientRepository)
 		mockNotifier := new(MockNotifier)
-		service := application.NewOrderService(mockOrderRepo, nil, mockClientRepo, mockNotifier)
+		service := application.NewOrderService(mockOrderRepo, nil, mockClientRepo, mockNotifier, newFakeUnitOfWork(mockOrderRepo, nil), nil, nil)
 		orderID := uuid.New()
 		clientID := uuid.New()
 		order := &serviceDomain.Order{ID: orderID, ClientID: clientID, Status: serviceDomain.OrderStatusInDiagnosis}
 		client := &serviceDomain.Client{ID: clientID, Email: "test@test.com"}
 
-		mockOrderRepo.On("GetByID", orderID).Return(order, nil)
-		mockOrderRepo.On("Save", mock.Anything).Return(nil)
-		mockClientRepo.On("GetByID", clientID).Return(client, nil)
+		mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+		mockOrderRepo.On("Save", mock.Anything, mock.Anything).Return(nil)
+		mockClientRepo.On("GetByID", mock.Anything, clientID).Return(client, nil)
 		mockNotifier.On("SendEmail", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("email error"))
 
-		err := service.SendBudget(orderID)
+		err := service.SendBudget(context.Background(), orderID, "tester", "")
 		assert.NoError(t, err)
 		assert.Equal(t, serviceDomain.OrderStatusAwaitingApproval, order.Status)
 	})
@@ -91,22 +92,22 @@ func TestOrderService_SendBudget_Errors(t *testing.T) {
 func TestOrderService_ApproveOrder_Errors(t *testing.T) {
 	t.Run("GetByID Error", func(t *testing.T) {
 		mockOrderRepo := new(MockOrderRepository)
-		service := application.NewOrderService(mockOrderRepo, nil, nil, nil)
+		service := application.NewOrderService(mockOrderRepo, nil, nil, nil, newFakeUnitOfWork(mockOrderRepo, nil), nil, nil)
 		orderID := uuid.New()
-		mockOrderRepo.On("GetByID", orderID).Return(nil, errors.New("repo error"))
+		mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(nil, errors.New("repo error"))
 
-		err := service.ApproveOrder(orderID)
+		err := service.ApproveOrder(context.Background(), orderID, "tester", "")
 		assert.Error(t, err)
 	})
 
 	t.Run("Wrong Status", func(t *testing.T) {
 		mockOrderRepo := new(MockOrderRepository)
-		service := application.NewOrderService(mockOrderRepo, nil, nil, nil)
+		service := application.NewOrderService(mockOrderRepo, nil, nil, nil, newFakeUnitOfWork(mockOrderRepo, nil), nil, nil)
 		orderID := uuid.New()
 		order := &serviceDomain.Order{ID: orderID, Status: serviceDomain.OrderStatusInExecution}
-		mockOrderRepo.On("GetByID", orderID).Return(order, nil)
+		mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
 
-		err := service.ApproveOrder(orderID)
+		err := service.ApproveOrder(context.Background(), orderID, "tester", "")
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "can only be approved from")
 	})
@@ -114,7 +115,7 @@ func TestOrderService_ApproveOrder_Errors(t *testing.T) {
 	t.Run("Part GetByID Error", func(t *testing.T) {
 		mockOrderRepo := new(MockOrderRepository)
 		mockPartRepo := new(MockPartRepository)
-		service := application.NewOrderService(mockOrderRepo, mockPartRepo, nil, nil)
+		service := application.NewOrderService(mockOrderRepo, mockPartRepo, nil, nil, newFakeUnitOfWork(mockOrderRepo, mockPartRepo), nil, nil)
 		orderID := uuid.New()
 		partID := uuid.New()
 		order := &serviceDomain.Order{
@@ -123,10 +124,10 @@ func TestOrderService_ApproveOrder_Errors(t *testing.T) {
 			Items:  []*serviceDomain.OrderItem{{RefID: partID, Type: serviceDomain.ItemTypePart, Quantity: 1}},
 		}
 
-		mockOrderRepo.On("GetByID", orderID).Return(order, nil)
-		mockPartRepo.On("GetByID", mock.Anything, partID).Return(nil, errors.New("part error"))
+		mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+		mockPartRepo.On("GetByIDForUpdate", mock.Anything, partID).Return(nil, errors.New("part error"))
 
-		err := service.ApproveOrder(orderID)
+		err := service.ApproveOrder(context.Background(), orderID, "tester", "")
 		assert.Error(t, err)
 	})
 
@@ -137,7 +138,7 @@ func TestOrderService_ApproveOrder_Errors(t *testing.T) {
 	t.Run("Part Update Error", func(t *testing.T) {
 		mockOrderRepo := new(MockOrderRepository)
 		mockPartRepo := new(MockPartRepository)
-		service := application.NewOrderService(mockOrderRepo, mockPartRepo, nil, nil)
+		service := application.NewOrderService(mockOrderRepo, mockPartRepo, nil, nil, newFakeUnitOfWork(mockOrderRepo, mockPartRepo), nil, nil)
 		orderID := uuid.New()
 		partID := uuid.New()
 		order := &serviceDomain.Order{
@@ -147,25 +148,25 @@ func TestOrderService_ApproveOrder_Errors(t *testing.T) {
 		}
 		part := &inventoryDomain.Part{ID: partID, Quantity: 10}
 
-		mockOrderRepo.On("GetByID", orderID).Return(order, nil)
-		mockPartRepo.On("GetByID", mock.Anything, partID).Return(part, nil)
+		mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+		mockPartRepo.On("GetByIDForUpdate", mock.Anything, partID).Return(part, nil)
 		mockPartRepo.On("Update", mock.Anything, mock.Anything).Return(errors.New("update error"))
 
-		err := service.ApproveOrder(orderID)
+		err := service.ApproveOrder(context.Background(), orderID, "tester", "")
 		assert.Error(t, err)
 		assert.Equal(t, "update error", err.Error())
 	})
 
 	t.Run("Save Error", func(t *testing.T) {
 		mockOrderRepo := new(MockOrderRepository)
-		service := application.NewOrderService(mockOrderRepo, nil, nil, nil)
+		service := application.NewOrderService(mockOrderRepo, nil, nil, nil, newFakeUnitOfWork(mockOrderRepo, nil), nil, nil)
 		orderID := uuid.New()
 		order := &serviceDomain.Order{ID: orderID, Status: serviceDomain.OrderStatusReceived}
 
-		mockOrderRepo.On("GetByID", orderID).Return(order, nil)
-		mockOrderRepo.On("Save", mock.Anything).Return(errors.New("save error"))
+		mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+		mockOrderRepo.On("Save", mock.Anything, mock.Anything).Return(errors.New("save error"))
 
-		err := service.ApproveOrder(orderID)
+		err := service.ApproveOrder(context.Background(), orderID, "tester", "")
 		assert.Error(t, err)
 	})
 }
@@ -173,32 +174,32 @@ func TestOrderService_ApproveOrder_Errors(t *testing.T) {
 func TestOrderService_FinishOrder_Errors(t *testing.T) {
 	t.Run("GetByID Error", func(t *testing.T) {
 		mockOrderRepo := new(MockOrderRepository)
-		service := application.NewOrderService(mockOrderRepo, nil, nil, nil)
+		service := application.NewOrderService(mockOrderRepo, nil, nil, nil, newFakeUnitOfWork(mockOrderRepo, nil), nil, nil)
 		orderID := uuid.New()
-		mockOrderRepo.On("GetByID", orderID).Return(nil, errors.New("repo error"))
-		err := service.FinishOrder(orderID)
+		mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(nil, errors.New("repo error"))
+		err := service.FinishOrder(context.Background(), orderID, "tester", "")
 		assert.Error(t, err)
 	})
 
 	t.Run("Wrong Status", func(t *testing.T) {
 		mockOrderRepo := new(MockOrderRepository)
-		service := application.NewOrderService(mockOrderRepo, nil, nil, nil)
+		service := application.NewOrderService(mockOrderRepo, nil, nil, nil, newFakeUnitOfWork(mockOrderRepo, nil), nil, nil)
 		orderID := uuid.New()
 		order := &serviceDomain.Order{ID: orderID, Status: serviceDomain.OrderStatusReceived}
-		mockOrderRepo.On("GetByID", orderID).Return(order, nil)
-		err := service.FinishOrder(orderID)
+		mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+		err := service.FinishOrder(context.Background(), orderID, "tester", "")
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "can only be finished from 'In execution'")
 	})
 
 	t.Run("Save Error", func(t *testing.T) {
 		mockOrderRepo := new(MockOrderRepository)
-		service := application.NewOrderService(mockOrderRepo, nil, nil, nil)
+		service := application.NewOrderService(mockOrderRepo, nil, nil, nil, newFakeUnitOfWork(mockOrderRepo, nil), nil, nil)
 		orderID := uuid.New()
 		order := &serviceDomain.Order{ID: orderID, Status: serviceDomain.OrderStatusInExecution}
-		mockOrderRepo.On("GetByID", orderID).Return(order, nil)
-		mockOrderRepo.On("Save", mock.Anything).Return(errors.New("save error"))
-		err := service.FinishOrder(orderID)
+		mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+		mockOrderRepo.On("Save", mock.Anything, mock.Anything).Return(errors.New("save error"))
+		err := service.FinishOrder(context.Background(), orderID, "tester", "")
 		assert.Error(t, err)
 	})
 }
@@ -206,32 +207,32 @@ func TestOrderService_FinishOrder_Errors(t *testing.T) {
 func TestOrderService_DeliverOrder_Errors(t *testing.T) {
 	t.Run("GetByID Error", func(t *testing.T) {
 		mockOrderRepo := new(MockOrderRepository)
-		service := application.NewOrderService(mockOrderRepo, nil, nil, nil)
+		service := application.NewOrderService(mockOrderRepo, nil, nil, nil, newFakeUnitOfWork(mockOrderRepo, nil), nil, nil)
 		orderID := uuid.New()
-		mockOrderRepo.On("GetByID", orderID).Return(nil, errors.New("repo error"))
-		err := service.DeliverOrder(orderID)
+		mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(nil, errors.New("repo error"))
+		err := service.DeliverOrder(context.Background(), orderID, nil, "tester", "")
 		assert.Error(t, err)
 	})
 
 	t.Run("Wrong Status", func(t *testing.T) {
 		mockOrderRepo := new(MockOrderRepository)
-		service := application.NewOrderService(mockOrderRepo, nil, nil, nil)
+		service := application.NewOrderService(mockOrderRepo, nil, nil, nil, newFakeUnitOfWork(mockOrderRepo, nil), nil, nil)
 		orderID := uuid.New()
 		order := &serviceDomain.Order{ID: orderID, Status: serviceDomain.OrderStatusReceived}
-		mockOrderRepo.On("GetByID", orderID).Return(order, nil)
-		err := service.DeliverOrder(orderID)
+		mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+		err := service.DeliverOrder(context.Background(), orderID, nil, "tester", "")
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "can only be delivered from 'Completed'")
 	})
 
 	t.Run("Save Error", func(t *testing.T) {
 		mockOrderRepo := new(MockOrderRepository)
-		service := application.NewOrderService(mockOrderRepo, nil, nil, nil)
+		service := application.NewOrderService(mockOrderRepo, nil, nil, nil, newFakeUnitOfWork(mockOrderRepo, nil), nil, nil)
 		orderID := uuid.New()
 		order := &serviceDomain.Order{ID: orderID, Status: serviceDomain.OrderStatusCompleted}
-		mockOrderRepo.On("GetByID", orderID).Return(order, nil)
-		mockOrderRepo.On("Save", mock.Anything).Return(errors.New("save error"))
-		err := service.DeliverOrder(orderID)
+		mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+		mockOrderRepo.On("Save", mock.Anything, mock.Anything).Return(errors.New("save error"))
+		err := service.DeliverOrder(context.Background(), orderID, nil, "tester", "")
 		assert.Error(t, err)
 	})
 }
@@ -239,21 +240,67 @@ func TestOrderService_DeliverOrder_Errors(t *testing.T) {
 func TestOrderService_UpdateStatus_Errors(t *testing.T) {
 	t.Run("GetByID Error", func(t *testing.T) {
 		mockOrderRepo := new(MockOrderRepository)
-		service := application.NewOrderService(mockOrderRepo, nil, nil, nil)
+		service := application.NewOrderService(mockOrderRepo, nil, nil, nil, newFakeUnitOfWork(mockOrderRepo, nil), nil, nil)
 		orderID := uuid.New()
-		mockOrderRepo.On("GetByID", orderID).Return(nil, errors.New("repo error"))
-		err := service.UpdateStatus(orderID, serviceDomain.OrderStatusCompleted)
+		mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(nil, errors.New("repo error"))
+		err := service.UpdateStatus(context.Background(), orderID, serviceDomain.OrderStatusCompleted, "tester", "")
 		assert.Error(t, err)
 	})
 
 	t.Run("Save Error", func(t *testing.T) {
 		mockOrderRepo := new(MockOrderRepository)
-		service := application.NewOrderService(mockOrderRepo, nil, nil, nil)
+		service := application.NewOrderService(mockOrderRepo, nil, nil, nil, newFakeUnitOfWork(mockOrderRepo, nil), nil, nil)
 		orderID := uuid.New()
 		order := &serviceDomain.Order{ID: orderID, Status: serviceDomain.OrderStatusReceived}
-		mockOrderRepo.On("GetByID", orderID).Return(order, nil)
-		mockOrderRepo.On("Save", mock.Anything).Return(errors.New("save error"))
-		err := service.UpdateStatus(orderID, serviceDomain.OrderStatusCompleted)
+		mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+		mockOrderRepo.On("Save", mock.Anything, mock.Anything).Return(errors.New("save error"))
+		err := service.UpdateStatus(context.Background(), orderID, serviceDomain.OrderStatusCompleted, "tester", "")
 		assert.Error(t, err)
 	})
 }
+
+func TestOrderService_UpdateStatus_StateMachine(t *testing.T) {
+	t.Run("Unknown Status", func(t *testing.T) {
+		service := application.NewOrderService(new(MockOrderRepository), nil, nil, nil, newFakeUnitOfWork(nil, nil), nil, nil)
+		err := service.UpdateStatus(context.Background(), uuid.New(), serviceDomain.OrderStatus("Teleported"), "tester", "")
+		assert.ErrorIs(t, err, serviceDomain.ErrInvalidOrderStatus)
+	})
+
+	t.Run("Illegal Transition", func(t *testing.T) {
+		mockOrderRepo := new(MockOrderRepository)
+		service := application.NewOrderService(mockOrderRepo, nil, nil, nil, newFakeUnitOfWork(mockOrderRepo, nil), nil, nil)
+		orderID := uuid.New()
+		order := &serviceDomain.Order{ID: orderID, Status: serviceDomain.OrderStatusReceived}
+		mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+
+		err := service.UpdateStatus(context.Background(), orderID, serviceDomain.OrderStatusDelivered, "tester", "")
+		assert.ErrorIs(t, err, serviceDomain.ErrInvalidTransition)
+		assert.Equal(t, serviceDomain.OrderStatusReceived, order.Status)
+		mockOrderRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
+}
+
+func TestOrderService_History(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockOrderRepo := new(MockOrderRepository)
+		service := application.NewOrderService(mockOrderRepo, nil, nil, nil, newFakeUnitOfWork(mockOrderRepo, nil), nil, nil)
+		orderID := uuid.New()
+		history := []*serviceDomain.StatusTransition{{OrderID: orderID, From: serviceDomain.OrderStatusReceived, To: serviceDomain.OrderStatusInDiagnosis}}
+		mockOrderRepo.On("GetByID", mock.Anything, orderID).Return(&serviceDomain.Order{ID: orderID}, nil)
+		mockOrderRepo.On("ListStatusHistory", mock.Anything, orderID).Return(history, nil)
+
+		result, err := service.History(context.Background(), orderID)
+		assert.NoError(t, err)
+		assert.Equal(t, history, result)
+	})
+
+	t.Run("Order Not Found", func(t *testing.T) {
+		mockOrderRepo := new(MockOrderRepository)
+		service := application.NewOrderService(mockOrderRepo, nil, nil, nil, newFakeUnitOfWork(mockOrderRepo, nil), nil, nil)
+		orderID := uuid.New()
+		mockOrderRepo.On("GetByID", mock.Anything, orderID).Return(nil, serviceDomain.ErrOrderNotFound)
+
+		_, err := service.History(context.Background(), orderID)
+		assert.ErrorIs(t, err, serviceDomain.ErrOrderNotFound)
+	})
+}
@@ -2,12 +2,15 @@ package application_test
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/google/uuid"
 	inventoryDomain "github.com/noggrj/autorepair/internal/inventory/domain"
 	"github.com/noggrj/autorepair/internal/service/application"
 	serviceDomain "github.com/noggrj/autorepair/internal/service/domain"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -18,32 +21,59 @@ type MockOrderRepository struct {
 	mock.Mock
 }
 
-func (m *MockOrderRepository) Save(order *serviceDomain.Order) error {
-	args := m.Called(order)
+func (m *MockOrderRepository) Save(ctx context.Context, order *serviceDomain.Order) error {
+	args := m.Called(ctx, order)
 	return args.Error(0)
 }
 
-func (m *MockOrderRepository) GetByID(id uuid.UUID) (*serviceDomain.Order, error) {
-	args := m.Called(id)
+func (m *MockOrderRepository) GetByID(ctx context.Context, id uuid.UUID) (*serviceDomain.Order, error) {
+	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*serviceDomain.Order), args.Error(1)
 }
 
-func (m *MockOrderRepository) List() ([]*serviceDomain.Order, error) {
-	args := m.Called()
+func (m *MockOrderRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*serviceDomain.Order, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*serviceDomain.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*serviceDomain.Order], error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return sharedkernel.Page[*serviceDomain.Order]{}, args.Error(1)
+	}
+	return args.Get(0).(sharedkernel.Page[*serviceDomain.Order]), args.Error(1)
+}
+
+func (m *MockOrderRepository) ListActive(ctx context.Context) ([]*serviceDomain.Order, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
 	return args.Get(0).([]*serviceDomain.Order), args.Error(1)
 }
 
-func (m *MockOrderRepository) ListActive() ([]*serviceDomain.Order, error) {
-	args := m.Called()
+func (m *MockOrderRepository) ListByVehicleID(ctx context.Context, vehicleID uuid.UUID) ([]*serviceDomain.Order, error) {
+	args := m.Called(ctx, vehicleID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*serviceDomain.Order), args.Error(1)
 }
 
+func (m *MockOrderRepository) ListStatusHistory(ctx context.Context, orderID uuid.UUID) ([]*serviceDomain.StatusTransition, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*serviceDomain.StatusTransition), args.Error(1)
+}
+
 type MockPartRepository struct {
 	mock.Mock
 }
@@ -61,50 +91,145 @@ func (m *MockPartRepository) GetByID(ctx context.Context, id uuid.UUID) (*invent
 	return args.Get(0).(*inventoryDomain.Part), args.Error(1)
 }
 
+func (m *MockPartRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*inventoryDomain.Part, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*inventoryDomain.Part), args.Error(1)
+}
+
+func (m *MockPartRepository) GetBySKU(ctx context.Context, sku string) (*inventoryDomain.Part, error) {
+	args := m.Called(ctx, sku)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*inventoryDomain.Part), args.Error(1)
+}
+
 func (m *MockPartRepository) Update(ctx context.Context, part *inventoryDomain.Part) error {
 	args := m.Called(ctx, part)
 	return args.Error(0)
 }
 
-func (m *MockPartRepository) List(ctx context.Context) ([]*inventoryDomain.Part, error) {
-	args := m.Called(ctx)
-	return args.Get(0).([]*inventoryDomain.Part), args.Error(1)
+func (m *MockPartRepository) List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*inventoryDomain.Part], error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return sharedkernel.Page[*inventoryDomain.Part]{}, args.Error(1)
+	}
+	return args.Get(0).(sharedkernel.Page[*inventoryDomain.Part]), args.Error(1)
 }
 
-func (m *MockPartRepository) Delete(ctx context.Context, id uuid.UUID) error {
+func (m *MockPartRepository) Archive(ctx context.Context, id uuid.UUID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
+func (m *MockPartRepository) ListLowStock(ctx context.Context) ([]*inventoryDomain.Part, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*inventoryDomain.Part), args.Error(1)
+}
+
+func (m *MockPartRepository) ListMovements(ctx context.Context, partID uuid.UUID) ([]*inventoryDomain.StockMovement, error) {
+	args := m.Called(ctx, partID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*inventoryDomain.StockMovement), args.Error(1)
+}
+
+func (m *MockPartRepository) LedgerQuantities(ctx context.Context) (map[uuid.UUID]int, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uuid.UUID]int), args.Error(1)
+}
+
 // Removed DecreaseStock as it's not in interface anymore.
 
+// fakeUnitOfWork hands the mocks to the callback without a real transaction.
+type fakeUnitOfWork struct {
+	repos application.TxRepositories
+}
+
+func newFakeUnitOfWork(orders serviceDomain.OrderRepository, parts inventoryDomain.PartRepository) *fakeUnitOfWork {
+	return &fakeUnitOfWork{repos: application.TxRepositories{Orders: orders, Parts: parts, Odometer: &fakeOdometerRepository{}}}
+}
+
+func (u *fakeUnitOfWork) Do(ctx context.Context, fn func(repos application.TxRepositories) error) error {
+	return fn(u.repos)
+}
+
+// fakeOdometerRepository keeps readings in memory and enforces the same
+// monotonic rule as the Postgres repository.
+type fakeOdometerRepository struct {
+	readings []*serviceDomain.OdometerReading
+}
+
+func (r *fakeOdometerRepository) Record(ctx context.Context, reading *serviceDomain.OdometerReading) error {
+	for _, last := range r.readings {
+		if last.VehicleID == reading.VehicleID {
+			if err := reading.Follows(last.Mileage); err != nil {
+				return err
+			}
+		}
+	}
+	r.readings = append(r.readings, reading)
+	return nil
+}
+
+func (r *fakeOdometerRepository) ListByVehicleID(ctx context.Context, vehicleID uuid.UUID) ([]*serviceDomain.OdometerReading, error) {
+	var readings []*serviceDomain.OdometerReading
+	for _, reading := range r.readings {
+		if reading.VehicleID == vehicleID {
+			readings = append(readings, reading)
+		}
+	}
+	return readings, nil
+}
+
 type MockClientRepository struct {
 	mock.Mock
 }
 
-func (m *MockClientRepository) Save(client *serviceDomain.Client) error {
-	args := m.Called(client)
+func (m *MockClientRepository) Save(ctx context.Context, client *serviceDomain.Client) error {
+	args := m.Called(ctx, client)
 	return args.Error(0)
 }
 
-func (m *MockClientRepository) GetByID(id uuid.UUID) (*serviceDomain.Client, error) {
-	args := m.Called(id)
+func (m *MockClientRepository) GetByID(ctx context.Context, id uuid.UUID) (*serviceDomain.Client, error) {
+	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*serviceDomain.Client), args.Error(1)
 }
 
-func (m *MockClientRepository) List() ([]*serviceDomain.Client, error) {
-	args := m.Called()
-	return args.Get(0).([]*serviceDomain.Client), args.Error(1)
+func (m *MockClientRepository) List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*serviceDomain.Client], error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return sharedkernel.Page[*serviceDomain.Client]{}, args.Error(1)
+	}
+	return args.Get(0).(sharedkernel.Page[*serviceDomain.Client]), args.Error(1)
 }
 
-func (m *MockClientRepository) Delete(id uuid.UUID) error {
-	args := m.Called(id)
+func (m *MockClientRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
+type MockStockAlerter struct {
+	mock.Mock
+}
+
+func (m *MockStockAlerter) NotifyLowStock(ctx context.Context, parts ...*inventoryDomain.Part) {
+	m.Called(ctx, parts)
+}
+
 type MockNotifier struct {
 	mock.Mock
 }
@@ -120,7 +245,7 @@ func TestOrderService_StartDiagnosis(t *testing.T) {
 	mockOrderRepo := new(MockOrderRepository)
 	mockClientRepo := new(MockClientRepository)
 	mockNotifier := new(MockNotifier)
-	service := application.NewOrderService(mockOrderRepo, nil, mockClientRepo, mockNotifier)
+	service := application.NewOrderService(mockOrderRepo, nil, mockClientRepo, mockNotifier, newFakeUnitOfWork(mockOrderRepo, nil), nil, nil)
 
 	orderID := uuid.New()
 	clientID := uuid.New()
@@ -131,14 +256,14 @@ func TestOrderService_StartDiagnosis(t *testing.T) {
 	}
 	client := &serviceDomain.Client{ID: clientID, Email: "test@test.com"}
 
-	mockOrderRepo.On("GetByID", orderID).Return(order, nil)
-	mockOrderRepo.On("Save", mock.MatchedBy(func(o *serviceDomain.Order) bool {
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+	mockOrderRepo.On("Save", mock.Anything, mock.MatchedBy(func(o *serviceDomain.Order) bool {
 		return o.Status == serviceDomain.OrderStatusInDiagnosis
 	})).Return(nil)
-	mockClientRepo.On("GetByID", clientID).Return(client, nil)
+	mockClientRepo.On("GetByID", mock.Anything, clientID).Return(client, nil)
 	mockNotifier.On("SendEmail", "test@test.com", mock.Anything, mock.Anything).Return(nil)
 
-	err := service.StartDiagnosis(orderID)
+	err := service.StartDiagnosis(context.Background(), orderID, "tester", "")
 	assert.NoError(t, err)
 	mockOrderRepo.AssertExpectations(t)
 }
@@ -147,7 +272,7 @@ func TestOrderService_SendBudget(t *testing.T) {
 	mockOrderRepo := new(MockOrderRepository)
 	mockClientRepo := new(MockClientRepository)
 	mockNotifier := new(MockNotifier)
-	service := application.NewOrderService(mockOrderRepo, nil, mockClientRepo, mockNotifier)
+	service := application.NewOrderService(mockOrderRepo, nil, mockClientRepo, mockNotifier, newFakeUnitOfWork(mockOrderRepo, nil), nil, nil)
 
 	orderID := uuid.New()
 	clientID := uuid.New()
@@ -155,18 +280,18 @@ func TestOrderService_SendBudget(t *testing.T) {
 		ID:       orderID,
 		ClientID: clientID,
 		Status:   serviceDomain.OrderStatusInDiagnosis,
-		Total:    100.0,
+		Total:    sharedkernel.NewMoneyFromFloat(100.0),
 	}
 	client := &serviceDomain.Client{ID: clientID, Email: "test@test.com"}
 
-	mockOrderRepo.On("GetByID", orderID).Return(order, nil)
-	mockOrderRepo.On("Save", mock.MatchedBy(func(o *serviceDomain.Order) bool {
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+	mockOrderRepo.On("Save", mock.Anything, mock.MatchedBy(func(o *serviceDomain.Order) bool {
 		return o.Status == serviceDomain.OrderStatusAwaitingApproval
 	})).Return(nil)
-	mockClientRepo.On("GetByID", clientID).Return(client, nil)
+	mockClientRepo.On("GetByID", mock.Anything, clientID).Return(client, nil)
 	mockNotifier.On("SendEmail", "test@test.com", mock.Anything, mock.Anything).Return(nil)
 
-	err := service.SendBudget(orderID)
+	err := service.SendBudget(context.Background(), orderID, "tester", "")
 	assert.NoError(t, err)
 	mockOrderRepo.AssertExpectations(t)
 	mockNotifier.AssertExpectations(t)
@@ -177,7 +302,7 @@ func TestOrderService_ApproveOrder(t *testing.T) {
 	mockPartRepo := new(MockPartRepository)
 	mockClientRepo := new(MockClientRepository)
 	mockNotifier := new(MockNotifier)
-	service := application.NewOrderService(mockOrderRepo, mockPartRepo, mockClientRepo, mockNotifier)
+	service := application.NewOrderService(mockOrderRepo, mockPartRepo, mockClientRepo, mockNotifier, newFakeUnitOfWork(mockOrderRepo, mockPartRepo), nil, nil)
 
 	orderID := uuid.New()
 	clientID := uuid.New()
@@ -193,22 +318,22 @@ func TestOrderService_ApproveOrder(t *testing.T) {
 	}
 	client := &serviceDomain.Client{ID: clientID, Email: "test@test.com"}
 
-	mockOrderRepo.On("GetByID", orderID).Return(order, nil)
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
 
 	// Mock Part GetByID and Update (since DecreaseStock is now logical op on entity)
 	part := &inventoryDomain.Part{ID: partID, Quantity: 10} // Enough stock
-	mockPartRepo.On("GetByID", mock.Anything, partID).Return(part, nil)
+	mockPartRepo.On("GetByIDForUpdate", mock.Anything, partID).Return(part, nil)
 	mockPartRepo.On("Update", mock.Anything, mock.MatchedBy(func(p *inventoryDomain.Part) bool {
 		return p.ID == partID && p.Quantity == 8 // 10 - 2
 	})).Return(nil)
 
-	mockOrderRepo.On("Save", mock.MatchedBy(func(o *serviceDomain.Order) bool {
+	mockOrderRepo.On("Save", mock.Anything, mock.MatchedBy(func(o *serviceDomain.Order) bool {
 		return o.Status == serviceDomain.OrderStatusInExecution && o.StartedAt != nil
 	})).Return(nil)
-	mockClientRepo.On("GetByID", clientID).Return(client, nil)
+	mockClientRepo.On("GetByID", mock.Anything, clientID).Return(client, nil)
 	mockNotifier.On("SendEmail", "test@test.com", mock.Anything, mock.Anything).Return(nil)
 
-	err := service.ApproveOrder(orderID)
+	err := service.ApproveOrder(context.Background(), orderID, "tester", "")
 	assert.NoError(t, err)
 	mockOrderRepo.AssertExpectations(t)
 	mockPartRepo.AssertExpectations(t)
@@ -217,7 +342,7 @@ func TestOrderService_ApproveOrder(t *testing.T) {
 func TestOrderService_ApproveOrder_InsufficientStock(t *testing.T) {
 	mockOrderRepo := new(MockOrderRepository)
 	mockPartRepo := new(MockPartRepository)
-	service := application.NewOrderService(mockOrderRepo, mockPartRepo, nil, nil)
+	service := application.NewOrderService(mockOrderRepo, mockPartRepo, nil, nil, newFakeUnitOfWork(mockOrderRepo, mockPartRepo), nil, nil)
 
 	orderID := uuid.New()
 	partID := uuid.New()
@@ -230,22 +355,236 @@ func TestOrderService_ApproveOrder_InsufficientStock(t *testing.T) {
 		},
 	}
 
-	mockOrderRepo.On("GetByID", orderID).Return(order, nil)
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
 
 	// Mock Part GetByID returning low stock
 	part := &inventoryDomain.Part{ID: partID, Quantity: 5} // Less than 10
-	mockPartRepo.On("GetByID", mock.Anything, partID).Return(part, nil)
+	mockPartRepo.On("GetByIDForUpdate", mock.Anything, partID).Return(part, nil)
 
-	err := service.ApproveOrder(orderID)
+	err := service.ApproveOrder(context.Background(), orderID, "tester", "")
 	assert.ErrorIs(t, err, inventoryDomain.ErrInsufficientStock)
 	mockOrderRepo.AssertExpectations(t)
 }
 
+func TestOrderService_ApproveOrder_AlertsLowStock(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockPartRepo := new(MockPartRepository)
+	mockClientRepo := new(MockClientRepository)
+	mockAlerter := new(MockStockAlerter)
+	service := application.NewOrderService(mockOrderRepo, mockPartRepo, mockClientRepo, nil, newFakeUnitOfWork(mockOrderRepo, mockPartRepo), mockAlerter, nil)
+
+	orderID := uuid.New()
+	partID := uuid.New()
+	order := &serviceDomain.Order{
+		ID:     orderID,
+		Status: serviceDomain.OrderStatusReceived,
+		Items:  []*serviceDomain.OrderItem{{RefID: partID, Type: serviceDomain.ItemTypePart, Quantity: 3}},
+	}
+	part := &inventoryDomain.Part{ID: partID, Quantity: 5, MinQuantity: 4}
+
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+	mockPartRepo.On("GetByIDForUpdate", mock.Anything, partID).Return(part, nil)
+	mockPartRepo.On("Update", mock.Anything, part).Return(nil)
+	mockOrderRepo.On("Save", mock.Anything, mock.Anything).Return(nil)
+	mockClientRepo.On("GetByID", mock.Anything, mock.Anything).Return(nil, errors.New("not found"))
+	mockAlerter.On("NotifyLowStock", mock.Anything, []*inventoryDomain.Part{part}).Return()
+
+	err := service.ApproveOrder(context.Background(), orderID, "tester", "")
+	assert.NoError(t, err)
+	assert.True(t, part.CrossedMinimum())
+	mockAlerter.AssertExpectations(t)
+}
+
+func TestOrderService_ApproveOrder_AllOrNothing(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockPartRepo := new(MockPartRepository)
+	service := application.NewOrderService(mockOrderRepo, mockPartRepo, nil, nil, newFakeUnitOfWork(mockOrderRepo, mockPartRepo), nil, nil)
+
+	orderID := uuid.New()
+	partID := uuid.New()
+
+	// Two lines for the same part must be checked against the stock as a whole
+	order := &serviceDomain.Order{
+		ID:     orderID,
+		Status: serviceDomain.OrderStatusReceived,
+		Items: []*serviceDomain.OrderItem{
+			{RefID: partID, Type: serviceDomain.ItemTypePart, Quantity: 3},
+			{RefID: partID, Type: serviceDomain.ItemTypePart, Quantity: 3},
+		},
+	}
+	part := &inventoryDomain.Part{ID: partID, Quantity: 5}
+
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+	mockPartRepo.On("GetByIDForUpdate", mock.Anything, partID).Return(part, nil).Once()
+
+	err := service.ApproveOrder(context.Background(), orderID, "tester", "")
+	assert.ErrorIs(t, err, inventoryDomain.ErrInsufficientStock)
+	assert.Equal(t, 5, part.Quantity)
+	mockPartRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	mockOrderRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestOrderService_CancelOrder_RestoresStock(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockPartRepo := new(MockPartRepository)
+	mockClientRepo := new(MockClientRepository)
+	mockNotifier := new(MockNotifier)
+	service := application.NewOrderService(mockOrderRepo, mockPartRepo, mockClientRepo, mockNotifier, newFakeUnitOfWork(mockOrderRepo, mockPartRepo), nil, nil)
+
+	orderID := uuid.New()
+	clientID := uuid.New()
+	partID := uuid.New()
+
+	order := &serviceDomain.Order{
+		ID:       orderID,
+		ClientID: clientID,
+		Status:   serviceDomain.OrderStatusInExecution,
+		Items: []*serviceDomain.OrderItem{
+			{RefID: partID, Type: serviceDomain.ItemTypePart, Quantity: 2},
+			{RefID: uuid.New(), Type: serviceDomain.ItemTypeService, Quantity: 1},
+		},
+	}
+	part := &inventoryDomain.Part{ID: partID, Quantity: 8}
+	client := &serviceDomain.Client{ID: clientID, Email: "test@test.com"}
+
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+	mockPartRepo.On("GetByIDForUpdate", mock.Anything, partID).Return(part, nil)
+	mockPartRepo.On("Update", mock.Anything, mock.MatchedBy(func(p *inventoryDomain.Part) bool {
+		movements := p.PendingMovements()
+		return p.Quantity == 10 && len(movements) == 1 && movements[0].Type == inventoryDomain.MovementReturn
+	})).Return(nil)
+	mockOrderRepo.On("Save", mock.Anything, mock.MatchedBy(func(o *serviceDomain.Order) bool {
+		transitions := o.PendingTransitions()
+		return o.Status == serviceDomain.OrderStatusCancelled && len(transitions) == 1 && transitions[0].Reason == "client gave up"
+	})).Return(nil)
+	mockClientRepo.On("GetByID", mock.Anything, clientID).Return(client, nil)
+	mockNotifier.On("SendEmail", "test@test.com", mock.Anything, mock.MatchedBy(func(body string) bool {
+		return strings.Contains(body, "client gave up")
+	})).Return(nil)
+
+	err := service.CancelOrder(context.Background(), orderID, "tester", "client gave up")
+	assert.NoError(t, err)
+	mockOrderRepo.AssertExpectations(t)
+	mockPartRepo.AssertExpectations(t)
+	mockNotifier.AssertExpectations(t)
+}
+
+func TestOrderService_CancelOrder_BeforeApproval(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockPartRepo := new(MockPartRepository)
+	mockClientRepo := new(MockClientRepository)
+	mockNotifier := new(MockNotifier)
+	service := application.NewOrderService(mockOrderRepo, mockPartRepo, mockClientRepo, mockNotifier, newFakeUnitOfWork(mockOrderRepo, mockPartRepo), nil, nil)
+
+	orderID := uuid.New()
+	order := &serviceDomain.Order{
+		ID:     orderID,
+		Status: serviceDomain.OrderStatusAwaitingApproval,
+		Items:  []*serviceDomain.OrderItem{{RefID: uuid.New(), Type: serviceDomain.ItemTypePart, Quantity: 2}},
+	}
+
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+	mockOrderRepo.On("Save", mock.Anything, mock.Anything).Return(nil)
+	mockClientRepo.On("GetByID", mock.Anything, mock.Anything).Return(nil, errors.New("not found"))
+
+	err := service.CancelOrder(context.Background(), orderID, "tester", "duplicate order")
+	assert.NoError(t, err)
+	assert.Equal(t, serviceDomain.OrderStatusCancelled, order.Status)
+	// Nothing was deducted yet, so nothing is returned
+	mockPartRepo.AssertNotCalled(t, "GetByIDForUpdate", mock.Anything, mock.Anything)
+}
+
+func TestOrderService_CancelOrder_Errors(t *testing.T) {
+	t.Run("Missing Reason", func(t *testing.T) {
+		mockOrderRepo := new(MockOrderRepository)
+		service := application.NewOrderService(mockOrderRepo, nil, nil, nil, newFakeUnitOfWork(mockOrderRepo, nil), nil, nil)
+
+		err := service.CancelOrder(context.Background(), uuid.New(), "tester", "  ")
+		assert.ErrorIs(t, err, serviceDomain.ErrReasonRequired)
+		mockOrderRepo.AssertNotCalled(t, "GetByIDForUpdate", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Already Completed", func(t *testing.T) {
+		mockOrderRepo := new(MockOrderRepository)
+		service := application.NewOrderService(mockOrderRepo, nil, nil, nil, newFakeUnitOfWork(mockOrderRepo, nil), nil, nil)
+		orderID := uuid.New()
+		order := &serviceDomain.Order{ID: orderID, Status: serviceDomain.OrderStatusCompleted}
+		mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+
+		err := service.CancelOrder(context.Background(), orderID, "tester", "too late")
+		assert.ErrorIs(t, err, serviceDomain.ErrInvalidTransition)
+		mockOrderRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
+}
+
+func TestOrderService_RemoveItem_RestoresStock(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockPartRepo := new(MockPartRepository)
+	service := application.NewOrderService(mockOrderRepo, mockPartRepo, nil, nil, newFakeUnitOfWork(mockOrderRepo, mockPartRepo), nil, nil)
+
+	partID := uuid.New()
+	order, _ := serviceDomain.NewOrder(uuid.New(), uuid.New(), 0)
+	_ = order.AddItem(partID, serviceDomain.ItemTypePart, "Filter", 2, sharedkernel.NewMoneyFromFloat(50.0), sharedkernel.NewMoneyFromFloat(30.0))
+	_ = order.AddItem(uuid.New(), serviceDomain.ItemTypeService, "Labor", 1, sharedkernel.NewMoneyFromFloat(80.0), sharedkernel.Money{})
+	order.Status = serviceDomain.OrderStatusInExecution
+	itemID := order.Items[0].ID
+	part := &inventoryDomain.Part{ID: partID, Quantity: 8}
+
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, order.ID).Return(order, nil)
+	mockPartRepo.On("GetByIDForUpdate", mock.Anything, partID).Return(part, nil)
+	mockPartRepo.On("Update", mock.Anything, mock.MatchedBy(func(p *inventoryDomain.Part) bool {
+		movements := p.PendingMovements()
+		return p.Quantity == 10 && len(movements) == 1 &&
+			movements[0].Type == inventoryDomain.MovementReturn && movements[0].Reference == "order:"+order.ID.String()
+	})).Return(nil)
+	mockOrderRepo.On("Save", mock.Anything, mock.MatchedBy(func(o *serviceDomain.Order) bool {
+		return len(o.Items) == 1 && o.Total.String() == "80.00" && o.TotalParts.IsZero()
+	})).Return(nil)
+
+	updated, err := service.RemoveItem(context.Background(), order.ID, itemID)
+	assert.NoError(t, err)
+	assert.Equal(t, "80.00", updated.Total.String())
+	mockOrderRepo.AssertExpectations(t)
+	mockPartRepo.AssertExpectations(t)
+}
+
+func TestOrderService_RemoveItem_BeforeApproval(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockPartRepo := new(MockPartRepository)
+	service := application.NewOrderService(mockOrderRepo, mockPartRepo, nil, nil, newFakeUnitOfWork(mockOrderRepo, mockPartRepo), nil, nil)
+
+	order, _ := serviceDomain.NewOrder(uuid.New(), uuid.New(), 0)
+	_ = order.AddItem(uuid.New(), serviceDomain.ItemTypePart, "Filter", 2, sharedkernel.NewMoneyFromFloat(50.0), sharedkernel.Money{})
+
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, order.ID).Return(order, nil)
+	mockOrderRepo.On("Save", mock.Anything, mock.Anything).Return(nil)
+
+	_, err := service.RemoveItem(context.Background(), order.ID, order.Items[0].ID)
+	assert.NoError(t, err)
+	assert.Empty(t, order.Items)
+	// Nothing was deducted yet, so nothing is returned
+	mockPartRepo.AssertNotCalled(t, "GetByIDForUpdate", mock.Anything, mock.Anything)
+}
+
+func TestOrderService_RemoveItem_Closed(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	service := application.NewOrderService(mockOrderRepo, nil, nil, nil, newFakeUnitOfWork(mockOrderRepo, nil), nil, nil)
+
+	order, _ := serviceDomain.NewOrder(uuid.New(), uuid.New(), 0)
+	_ = order.AddItem(uuid.New(), serviceDomain.ItemTypePart, "Filter", 2, sharedkernel.NewMoneyFromFloat(50.0), sharedkernel.Money{})
+	order.Status = serviceDomain.OrderStatusDelivered
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, order.ID).Return(order, nil)
+
+	_, err := service.RemoveItem(context.Background(), order.ID, order.Items[0].ID)
+	assert.ErrorIs(t, err, serviceDomain.ErrOrderClosed)
+	mockOrderRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
 func TestOrderService_FinishOrder(t *testing.T) {
 	mockOrderRepo := new(MockOrderRepository)
 	mockClientRepo := new(MockClientRepository)
 	mockNotifier := new(MockNotifier)
-	service := application.NewOrderService(mockOrderRepo, nil, mockClientRepo, mockNotifier)
+	service := application.NewOrderService(mockOrderRepo, nil, mockClientRepo, mockNotifier, newFakeUnitOfWork(mockOrderRepo, nil), nil, nil)
 
 	orderID := uuid.New()
 	clientID := uuid.New()
@@ -256,22 +595,37 @@ func TestOrderService_FinishOrder(t *testing.T) {
 	}
 	client := &serviceDomain.Client{ID: clientID, Email: "test@test.com"}
 
-	mockOrderRepo.On("GetByID", orderID).Return(order, nil)
-	mockOrderRepo.On("Save", mock.MatchedBy(func(o *serviceDomain.Order) bool {
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+	mockOrderRepo.On("Save", mock.Anything, mock.MatchedBy(func(o *serviceDomain.Order) bool {
 		return o.Status == serviceDomain.OrderStatusCompleted && o.FinishedAt != nil
 	})).Return(nil)
-	mockClientRepo.On("GetByID", clientID).Return(client, nil)
+	mockClientRepo.On("GetByID", mock.Anything, clientID).Return(client, nil)
 	mockNotifier.On("SendEmail", "test@test.com", mock.Anything, mock.Anything).Return(nil)
 
-	err := service.FinishOrder(orderID)
+	err := service.FinishOrder(context.Background(), orderID, "tester", "")
 	assert.NoError(t, err)
 }
 
+func TestOrderService_RejectBudget_AfterApproval(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	service := application.NewOrderService(mockOrderRepo, nil, nil, nil, newFakeUnitOfWork(mockOrderRepo, nil), nil, nil)
+
+	// The locked read sees the approval that committed first
+	order := &serviceDomain.Order{ID: uuid.New(), Status: serviceDomain.OrderStatusInExecution}
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, order.ID).Return(order, nil)
+
+	err := service.RejectBudget(context.Background(), order.ID, "tester", "")
+	assert.ErrorIs(t, err, serviceDomain.ErrInvalidTransition)
+	assert.Equal(t, serviceDomain.OrderStatusInExecution, order.Status)
+	mockOrderRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	mockOrderRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
 func TestOrderService_DeliverOrder(t *testing.T) {
 	mockOrderRepo := new(MockOrderRepository)
 	mockClientRepo := new(MockClientRepository)
 	mockNotifier := new(MockNotifier)
-	service := application.NewOrderService(mockOrderRepo, nil, mockClientRepo, mockNotifier)
+	service := application.NewOrderService(mockOrderRepo, nil, mockClientRepo, mockNotifier, newFakeUnitOfWork(mockOrderRepo, nil), nil, nil)
 
 	orderID := uuid.New()
 	clientID := uuid.New()
@@ -282,49 +636,105 @@ func TestOrderService_DeliverOrder(t *testing.T) {
 	}
 	client := &serviceDomain.Client{ID: clientID, Email: "test@test.com"}
 
-	mockOrderRepo.On("GetByID", orderID).Return(order, nil)
-	mockOrderRepo.On("Save", mock.MatchedBy(func(o *serviceDomain.Order) bool {
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+	mockOrderRepo.On("Save", mock.Anything, mock.MatchedBy(func(o *serviceDomain.Order) bool {
 		return o.Status == serviceDomain.OrderStatusDelivered
 	})).Return(nil)
-	mockClientRepo.On("GetByID", clientID).Return(client, nil)
+	mockClientRepo.On("GetByID", mock.Anything, clientID).Return(client, nil)
 	mockNotifier.On("SendEmail", "test@test.com", mock.Anything, mock.Anything).Return(nil)
 
-	err := service.DeliverOrder(orderID)
+	err := service.DeliverOrder(context.Background(), orderID, nil, "tester", "")
 	assert.NoError(t, err)
 }
 
+func TestOrderService_DeliverOrder_Mileage(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockClientRepo := new(MockClientRepository)
+	mockNotifier := new(MockNotifier)
+	odometer := &fakeOdometerRepository{}
+	uow := &fakeUnitOfWork{repos: application.TxRepositories{Orders: mockOrderRepo, Odometer: odometer}}
+	service := application.NewOrderService(mockOrderRepo, nil, mockClientRepo, mockNotifier, uow, nil, nil)
+
+	received, _ := serviceDomain.NewOrder(uuid.New(), uuid.New(), 41000)
+	received.Status = serviceDomain.OrderStatusCompleted
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, received.ID).Return(received, nil)
+	mockClientRepo.On("GetByID", mock.Anything, received.ClientID).Return(nil, errors.New("not found"))
+
+	// Below the reception mileage: the order stays completed
+	mileage := 40000
+	err := service.DeliverOrder(context.Background(), received.ID, &mileage, "tester", "")
+	assert.ErrorIs(t, err, serviceDomain.ErrMileageDecreased)
+	mockOrderRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	assert.Empty(t, odometer.readings)
+
+	// Valid: the reading is recorded and the order saved with it
+	received.Status = serviceDomain.OrderStatusCompleted
+	mockOrderRepo.On("Save", mock.Anything, mock.MatchedBy(func(o *serviceDomain.Order) bool {
+		return o.Status == serviceDomain.OrderStatusDelivered && o.DeliveryMileage != nil && *o.DeliveryMileage == 41012
+	})).Return(nil)
+	mileage = 41012
+	err = service.DeliverOrder(context.Background(), received.ID, &mileage, "tester", "")
+	assert.NoError(t, err)
+	if assert.Len(t, odometer.readings, 1) {
+		assert.Equal(t, serviceDomain.OdometerSourceDelivery, odometer.readings[0].Source)
+	}
+}
+
+func TestOrderService_Receive(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	odometer := &fakeOdometerRepository{}
+	uow := &fakeUnitOfWork{repos: application.TxRepositories{Orders: mockOrderRepo, Odometer: odometer}}
+	service := application.NewOrderService(mockOrderRepo, nil, nil, nil, uow, nil, nil)
+	vehicleID := uuid.New()
+
+	first, _ := serviceDomain.NewOrder(uuid.New(), vehicleID, 41000)
+	mockOrderRepo.On("Save", mock.Anything, first).Return(nil)
+	assert.NoError(t, service.Receive(context.Background(), first))
+	if assert.Len(t, odometer.readings, 1) {
+		assert.Equal(t, first.ID, odometer.readings[0].OrderID)
+		assert.Equal(t, serviceDomain.OdometerSourceReception, odometer.readings[0].Source)
+	}
+
+	// The odometer cannot go back, whoever brings the vehicle in
+	second, _ := serviceDomain.NewOrder(uuid.New(), vehicleID, 39000)
+	mockOrderRepo.On("Save", mock.Anything, second).Return(nil)
+	err := service.Receive(context.Background(), second)
+	assert.ErrorIs(t, err, serviceDomain.ErrMileageDecreased)
+	assert.Len(t, odometer.readings, 1)
+}
+
 func TestOrderService_UpdateStatus(t *testing.T) {
 	mockOrderRepo := new(MockOrderRepository)
 	mockClientRepo := new(MockClientRepository)
 	mockNotifier := new(MockNotifier)
-	service := application.NewOrderService(mockOrderRepo, nil, mockClientRepo, mockNotifier)
+	service := application.NewOrderService(mockOrderRepo, nil, mockClientRepo, mockNotifier, newFakeUnitOfWork(mockOrderRepo, nil), nil, nil)
 
 	orderID := uuid.New()
 	clientID := uuid.New()
 	order := &serviceDomain.Order{ID: orderID, ClientID: clientID, Status: serviceDomain.OrderStatusReceived}
 	client := &serviceDomain.Client{ID: clientID, Email: "test@test.com"}
 
-	mockOrderRepo.On("GetByID", orderID).Return(order, nil)
-	mockOrderRepo.On("Save", mock.MatchedBy(func(o *serviceDomain.Order) bool {
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+	mockOrderRepo.On("Save", mock.Anything, mock.MatchedBy(func(o *serviceDomain.Order) bool {
 		return o.Status == serviceDomain.OrderStatusInExecution
 	})).Return(nil)
-	mockClientRepo.On("GetByID", clientID).Return(client, nil)
+	mockClientRepo.On("GetByID", mock.Anything, clientID).Return(client, nil)
 	mockNotifier.On("SendEmail", "test@test.com", mock.Anything, mock.Anything).Return(nil)
 
-	err := service.UpdateStatus(orderID, serviceDomain.OrderStatusInExecution)
+	err := service.UpdateStatus(context.Background(), orderID, serviceDomain.OrderStatusInExecution, "tester", "")
 	assert.NoError(t, err)
 }
 
 func TestOrderService_StartDiagnosis_WrongStatus(t *testing.T) {
 	mockOrderRepo := new(MockOrderRepository)
-	service := application.NewOrderService(mockOrderRepo, nil, nil, nil)
+	service := application.NewOrderService(mockOrderRepo, nil, nil, nil, newFakeUnitOfWork(mockOrderRepo, nil), nil, nil)
 
 	orderID := uuid.New()
 	order := &serviceDomain.Order{ID: orderID, Status: serviceDomain.OrderStatusCompleted}
 
-	mockOrderRepo.On("GetByID", orderID).Return(order, nil)
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
 
-	err := service.StartDiagnosis(orderID)
+	err := service.StartDiagnosis(context.Background(), orderID, "tester", "")
 	assert.Error(t, err)
 	assert.Equal(t, "diagnosis can only be started from 'Received' status", err.Error())
 }
@@ -0,0 +1,60 @@
+package application_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/noggrj/autorepair/internal/service/application"
+	serviceDomain "github.com/noggrj/autorepair/internal/service/domain"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockVehicleSpecProvider struct {
+	mock.Mock
+}
+
+func (m *MockVehicleSpecProvider) Lookup(ctx context.Context, vin sharedkernel.VIN) (*serviceDomain.VehicleSpec, error) {
+	args := m.Called(ctx, vin)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*serviceDomain.VehicleSpec), args.Error(1)
+}
+
+func TestVehicleService_Prefill(t *testing.T) {
+	ctx := context.Background()
+	// R is 1994 or 2024
+	known, _ := sharedkernel.NewVIN("9BWAB45Z3R4012345")
+	unknown, _ := sharedkernel.NewVIN("1M8GDM9AXKP042788")
+
+	specs := new(MockVehicleSpecProvider)
+	specs.On("Lookup", mock.Anything, known).Return(&serviceDomain.VehicleSpec{Brand: "Volkswagen", Model: "Gol"}, nil)
+	specs.On("Lookup", mock.Anything, unknown).Return(nil, serviceDomain.ErrVehicleSpecNotFound)
+	service := application.NewVehicleService(nil, nil, specs)
+
+	details, err := service.Prefill(ctx, known, application.VehicleDetails{})
+	assert.NoError(t, err)
+	assert.Equal(t, application.VehicleDetails{Brand: "Volkswagen", Model: "Gol", Year: 2024}, details)
+
+	// What was typed is kept
+	details, err = service.Prefill(ctx, known, application.VehicleDetails{Model: "Gol G5", Year: 2023})
+	assert.NoError(t, err)
+	assert.Equal(t, application.VehicleDetails{Brand: "Volkswagen", Model: "Gol G5", Year: 2023}, details)
+
+	// Nothing to look up
+	details, err = service.Prefill(ctx, unknown, application.VehicleDetails{Brand: "Ford", Model: "F-150", Year: 2019})
+	assert.NoError(t, err)
+	assert.Equal(t, application.VehicleDetails{Brand: "Ford", Model: "F-150", Year: 2019}, details)
+
+	// An unknown VIN still gives its year
+	details, err = service.Prefill(ctx, unknown, application.VehicleDetails{})
+	assert.NoError(t, err)
+	assert.Equal(t, application.VehicleDetails{Year: 2019}, details)
+
+	specs.On("Lookup", mock.Anything, mock.Anything).Return(nil, assert.AnError)
+	other, _ := sharedkernel.NewVIN("9BWZZZ377VT004251")
+	_, err = service.Prefill(ctx, other, application.VehicleDetails{})
+	assert.ErrorIs(t, err, assert.AnError)
+}
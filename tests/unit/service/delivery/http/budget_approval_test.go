@@ -0,0 +1,138 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/platform/auth"
+	serviceApplication "github.com/noggrj/autorepair/internal/service/application"
+	serviceHttp "github.com/noggrj/autorepair/internal/service/delivery/http"
+	serviceDomain "github.com/noggrj/autorepair/internal/service/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// setupBudgetLinkHandler returns an order handler with budget links for an
+// order whose budget was sent, and the approval code mailed for it.
+func setupBudgetLinkHandler(t *testing.T) (*serviceHttp.OrderHandler, *MockBudgetApprovalRepository, *serviceDomain.Order, *serviceDomain.BudgetApproval, string) {
+	t.Helper()
+	signer, err := auth.NewEphemeralLinkSigner()
+	require.NoError(t, err)
+
+	mockOrderRepo := new(MockOrderRepository)
+	mockPartRepo := new(MockPartRepository)
+	mockClientRepo := new(MockClientRepository)
+	mockNotifier := new(MockNotifier)
+	mockApprovals := new(MockBudgetApprovalRepository)
+	uow := &fakeUnitOfWork{repos: serviceApplication.TxRepositories{Orders: mockOrderRepo, Parts: mockPartRepo, BudgetApprovals: mockApprovals}}
+	links := serviceApplication.NewBudgetLinks(mockApprovals, signer)
+	orderService := serviceApplication.NewOrderService(mockOrderRepo, mockPartRepo, mockClientRepo, mockNotifier, uow, nil, links)
+	handler := serviceHttp.NewOrderHandler(mockOrderRepo, mockPartRepo, new(MockServiceRepository), orderService)
+
+	clientID := uuid.New()
+	order := &serviceDomain.Order{ID: uuid.New(), ClientID: clientID, Status: serviceDomain.OrderStatusInDiagnosis}
+	var approval *serviceDomain.BudgetApproval
+	var token string
+
+	mockOrderRepo.On("GetByID", mock.Anything, order.ID).Return(order, nil)
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, order.ID).Return(order, nil)
+	mockOrderRepo.On("Save", mock.Anything, order).Return(nil)
+	mockApprovals.On("RevokeOrder", mock.Anything, order.ID, mock.Anything).Return(nil)
+	mockApprovals.On("Save", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		approval = args.Get(1).(*serviceDomain.BudgetApproval)
+	}).Return(nil)
+	mockClientRepo.On("GetByID", mock.Anything, clientID).Return(&serviceDomain.Client{ID: clientID, Email: "test@test.com"}, nil)
+	mockNotifier.On("SendEmail", "test@test.com", "Order Budget Ready", mock.Anything).Run(func(args mock.Arguments) {
+		body := args.String(2)
+		token = body[strings.LastIndex(body, " ")+1:]
+	}).Return(nil)
+	mockNotifier.On("SendEmail", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	require.NoError(t, orderService.SendBudget(context.Background(), order.ID, "tester", ""))
+	mockApprovals.On("GetByID", mock.Anything, approval.ID).Return(approval, nil)
+	return handler, mockApprovals, order, approval, token
+}
+
+func budgetResponseRequest(orderID uuid.UUID, body interface{}) *http.Request {
+	payload, _ := json.Marshal(body)
+	req, _ := http.NewRequest("POST", "/orders/"+orderID.String()+"/budget-response", bytes.NewBuffer(payload))
+	req.RemoteAddr = "203.0.113.7:51234"
+	req.Header.Set("User-Agent", "test-agent")
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", orderID.String())
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestOrderHandler_ApproveBudget(t *testing.T) {
+	handler, mockApprovals, order, approval, token := setupBudgetLinkHandler(t)
+	mockApprovals.On("Decide", mock.Anything, approval.ID, mock.MatchedBy(func(d serviceDomain.BudgetDecision) bool {
+		return !d.Approved && d.IP == "203.0.113.7" && d.UserAgent == "test-agent" && !d.DecidedAt.IsZero()
+	})).Return(true, nil)
+
+	rr := httptest.NewRecorder()
+	handler.ApproveBudget(rr, budgetResponseRequest(order.ID, serviceHttp.BudgetAnswerRequest{Token: token, Approved: false}))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, serviceDomain.OrderStatusReceived, order.Status)
+	mockApprovals.AssertExpectations(t)
+}
+
+func TestOrderHandler_ApproveBudget_MissingToken(t *testing.T) {
+	handler, _, order, _, _ := setupBudgetLinkHandler(t)
+
+	rr := httptest.NewRecorder()
+	handler.ApproveBudget(rr, budgetResponseRequest(order.ID, map[string]bool{"approved": true}))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, serviceDomain.OrderStatusAwaitingApproval, order.Status)
+}
+
+func TestOrderHandler_ApproveBudget_InvalidToken(t *testing.T) {
+	handler, _, order, _, token := setupBudgetLinkHandler(t)
+
+	// A code for one order does not answer another
+	rr := httptest.NewRecorder()
+	handler.ApproveBudget(rr, budgetResponseRequest(uuid.New(), serviceHttp.BudgetAnswerRequest{Token: token, Approved: true}))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	rr = httptest.NewRecorder()
+	handler.ApproveBudget(rr, budgetResponseRequest(order.ID, serviceHttp.BudgetAnswerRequest{Token: "forged", Approved: true}))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Equal(t, serviceDomain.OrderStatusAwaitingApproval, order.Status)
+}
+
+func TestOrderHandler_BudgetApprovals(t *testing.T) {
+	handler, mockApprovals, order, approval, _ := setupBudgetLinkHandler(t)
+	answered := *approval
+	answered.Decision = &serviceDomain.BudgetDecision{Approved: true, IP: "203.0.113.7", UserAgent: "test-agent", DecidedAt: time.Now()}
+	mockApprovals.On("ListByOrderID", mock.Anything, order.ID).Return([]*serviceDomain.BudgetApproval{&answered}, nil)
+
+	req, _ := http.NewRequest("GET", "/admin/orders/"+order.ID.String()+"/budget-approvals", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", order.ID.String())
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rr := httptest.NewRecorder()
+
+	handler.BudgetApprovals(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp []serviceHttp.BudgetApprovalResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	if assert.Len(t, resp, 1) {
+		assert.Equal(t, approval.ID, resp[0].ID)
+		assert.Equal(t, "203.0.113.7", resp[0].IP)
+		assert.Equal(t, "test-agent", resp[0].UserAgent)
+		if assert.NotNil(t, resp[0].Approved) {
+			assert.True(t, *resp[0].Approved)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package http_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -9,6 +10,7 @@ import (
 
 	serviceHttp "github.com/noggrj/autorepair/internal/service/delivery/http"
 	serviceDomain "github.com/noggrj/autorepair/internal/service/domain"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -27,7 +29,7 @@ func TestClientHandler_Create(t *testing.T) {
 	req, _ := http.NewRequest("POST", "/admin/clients", bytes.NewBuffer(body))
 	rr := httptest.NewRecorder()
 
-	mockRepo.On("Save", mock.Anything).Return(nil)
+	mockRepo.On("Save", mock.Anything, mock.Anything).Return(nil)
 
 	handler.Create(rr, req)
 
@@ -42,7 +44,7 @@ func TestClientHandler_List(t *testing.T) {
 	clients := []*serviceDomain.Client{
 		{Name: "John Doe"},
 	}
-	mockRepo.On("List").Return(clients, nil)
+	mockRepo.On("List", mock.Anything, mock.Anything).Return(sharedkernel.Page[*serviceDomain.Client]{Items: clients}, nil)
 
 	req, _ := http.NewRequest("GET", "/admin/clients", nil)
 	rr := httptest.NewRecorder()
@@ -52,6 +54,61 @@ func TestClientHandler_List(t *testing.T) {
 	assert.Equal(t, http.StatusOK, rr.Code)
 }
 
+func TestClientHandler_List_UsesRequestContext(t *testing.T) {
+	mockRepo := new(MockClientRepository)
+	handler := serviceHttp.NewClientHandler(mockRepo)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	mockRepo.On("List", ctx, mock.Anything).Return(sharedkernel.Page[*serviceDomain.Client]{}, context.Canceled)
+
+	req := httptest.NewRequest("GET", "/admin/clients", nil).WithContext(ctx)
+	rr := httptest.NewRecorder()
+
+	handler.List(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestClientHandler_List_Paged(t *testing.T) {
+	mockRepo := new(MockClientRepository)
+	handler := serviceHttp.NewClientHandler(mockRepo)
+
+	expected := sharedkernel.ListQuery{Limit: 1, Sort: "created_at", Desc: true, Search: "john"}
+	page := sharedkernel.Page[*serviceDomain.Client]{Items: []*serviceDomain.Client{{Name: "John Doe"}}, NextCursor: "abc"}
+	mockRepo.On("List", mock.Anything, expected).Return(page, nil)
+
+	req, _ := http.NewRequest("GET", "/admin/clients?limit=1&sort=-created_at&q=john", nil)
+	rr := httptest.NewRecorder()
+
+	handler.List(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "abc", rr.Header().Get("X-Next-Cursor"))
+	assert.Equal(t, `</admin/clients?cursor=abc&limit=1&q=john&sort=-created_at>; rel="next"`, rr.Header().Get("Link"))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestClientHandler_List_InvalidQuery(t *testing.T) {
+	mockRepo := new(MockClientRepository)
+	handler := serviceHttp.NewClientHandler(mockRepo)
+
+	for _, query := range []string{"limit=0", "limit=1000", "from=yesterday"} {
+		req, _ := http.NewRequest("GET", "/admin/clients?"+query, nil)
+		rr := httptest.NewRecorder()
+		handler.List(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+	}
+
+	// Errors from the repository about the cursor or sort are the caller's fault
+	mockRepo.On("List", mock.Anything, mock.Anything).Return(nil, sharedkernel.ErrInvalidCursor)
+	req, _ := http.NewRequest("GET", "/admin/clients?cursor=bogus", nil)
+	rr := httptest.NewRecorder()
+	handler.List(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
 func TestClientHandler_Create_InvalidJSON(t *testing.T) {
 	handler := serviceHttp.NewClientHandler(nil)
 
@@ -67,7 +124,7 @@ func TestClientHandler_List_Error(t *testing.T) {
 	mockRepo := new(MockClientRepository)
 	handler := serviceHttp.NewClientHandler(mockRepo)
 
-	mockRepo.On("List").Return(nil, assert.AnError)
+	mockRepo.On("List", mock.Anything, mock.Anything).Return(nil, assert.AnError)
 
 	req, _ := http.NewRequest("GET", "/admin/clients", nil)
 	rr := httptest.NewRecorder()
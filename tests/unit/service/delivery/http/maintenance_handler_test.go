@@ -0,0 +1,86 @@
+package http_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	serviceApplication "github.com/noggrj/autorepair/internal/service/application"
+	serviceHttp "github.com/noggrj/autorepair/internal/service/delivery/http"
+	serviceDomain "github.com/noggrj/autorepair/internal/service/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupMaintenancePlanHandler() (*serviceHttp.MaintenancePlanHandler, *MockMaintenancePlanRepository, *MockServiceRepository) {
+	plans := new(MockMaintenancePlanRepository)
+	services := new(MockServiceRepository)
+	service := serviceApplication.NewMaintenanceService(plans, nil, services, nil, nil)
+	return serviceHttp.NewMaintenancePlanHandler(service), plans, services
+}
+
+func TestMaintenancePlanHandler_Create(t *testing.T) {
+	handler, plans, services := setupMaintenancePlanHandler()
+	serviceID := uuid.New()
+	create := func(body map[string]interface{}) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		req, _ := http.NewRequest("POST", "/admin/maintenance-plans", bytes.NewBuffer(b))
+		rr := httptest.NewRecorder()
+		handler.Create(rr, req)
+		return rr
+	}
+
+	services.On("GetByID", mock.Anything, serviceID).Return(&serviceDomain.Service{ID: serviceID}, nil)
+	plans.On("Save", mock.Anything, mock.Anything).Return(nil).Once()
+
+	rr := create(map[string]interface{}{"service_id": serviceID.String(), "interval_km": 10000, "interval_months": 12})
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var plan map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &plan))
+	assert.Equal(t, float64(10000), plan["IntervalKm"])
+
+	// No interval
+	rr = create(map[string]interface{}{"service_id": serviceID.String()})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// Invalid service ID
+	rr = create(map[string]interface{}{"service_id": "nope", "interval_km": 10000})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// Service already on a plan
+	plans.On("Save", mock.Anything, mock.Anything).Return(serviceDomain.ErrMaintenancePlanExists).Once()
+	rr = create(map[string]interface{}{"service_id": serviceID.String(), "interval_months": 6})
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	// Unknown service
+	missing := uuid.New()
+	services.On("GetByID", mock.Anything, missing).Return(nil, serviceDomain.ErrServiceNotFound)
+	rr = create(map[string]interface{}{"service_id": missing.String(), "interval_months": 6})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestMaintenancePlanHandler_ListAndDelete(t *testing.T) {
+	handler, plans, _ := setupMaintenancePlanHandler()
+	id := uuid.New()
+
+	plans.On("List", mock.Anything).Return([]*serviceDomain.MaintenancePlan{{ID: id, IntervalMonths: 6}}, nil)
+	req, _ := http.NewRequest("GET", "/admin/maintenance-plans", nil)
+	rr := httptest.NewRecorder()
+	handler.List(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), id.String())
+
+	plans.On("Delete", mock.Anything, id).Return(nil).Once()
+	req, _ = http.NewRequest("DELETE", "/admin/maintenance-plans/"+id.String(), nil)
+	rr = httptest.NewRecorder()
+	handler.Delete(rr, withURLParam(req, "id", id.String()))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	plans.On("Delete", mock.Anything, id).Return(serviceDomain.ErrMaintenancePlanNotFound).Once()
+	rr = httptest.NewRecorder()
+	handler.Delete(rr, withURLParam(req, "id", id.String()))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
@@ -2,10 +2,15 @@ package http_test
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
+	identityDomain "github.com/noggrj/autorepair/internal/identity/domain"
+	inventoryApplication "github.com/noggrj/autorepair/internal/inventory/application"
 	inventoryDomain "github.com/noggrj/autorepair/internal/inventory/domain"
+	serviceApplication "github.com/noggrj/autorepair/internal/service/application"
 	serviceDomain "github.com/noggrj/autorepair/internal/service/domain"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -15,35 +20,59 @@ type MockOrderRepository struct {
 	mock.Mock
 }
 
-func (m *MockOrderRepository) Save(order *serviceDomain.Order) error {
-	args := m.Called(order)
+func (m *MockOrderRepository) Save(ctx context.Context, order *serviceDomain.Order) error {
+	args := m.Called(ctx, order)
 	return args.Error(0)
 }
 
-func (m *MockOrderRepository) GetByID(id uuid.UUID) (*serviceDomain.Order, error) {
-	args := m.Called(id)
+func (m *MockOrderRepository) GetByID(ctx context.Context, id uuid.UUID) (*serviceDomain.Order, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*serviceDomain.Order), args.Error(1)
+}
+
+func (m *MockOrderRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*serviceDomain.Order, error) {
+	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*serviceDomain.Order), args.Error(1)
 }
 
-func (m *MockOrderRepository) List() ([]*serviceDomain.Order, error) {
-	args := m.Called()
+func (m *MockOrderRepository) List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*serviceDomain.Order], error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return sharedkernel.Page[*serviceDomain.Order]{}, args.Error(1)
+	}
+	return args.Get(0).(sharedkernel.Page[*serviceDomain.Order]), args.Error(1)
+}
+
+func (m *MockOrderRepository) ListActive(ctx context.Context) ([]*serviceDomain.Order, error) {
+	args := m.Called(ctx)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*serviceDomain.Order), args.Error(1)
 }
 
-func (m *MockOrderRepository) ListActive() ([]*serviceDomain.Order, error) {
-	args := m.Called()
+func (m *MockOrderRepository) ListByVehicleID(ctx context.Context, vehicleID uuid.UUID) ([]*serviceDomain.Order, error) {
+	args := m.Called(ctx, vehicleID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*serviceDomain.Order), args.Error(1)
 }
 
+func (m *MockOrderRepository) ListStatusHistory(ctx context.Context, orderID uuid.UUID) ([]*serviceDomain.StatusTransition, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*serviceDomain.StatusTransition), args.Error(1)
+}
+
 type MockPartRepository struct {
 	mock.Mock
 }
@@ -61,12 +90,41 @@ func (m *MockPartRepository) GetByID(ctx context.Context, id uuid.UUID) (*invent
 	return args.Get(0).(*inventoryDomain.Part), args.Error(1)
 }
 
+func (m *MockPartRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*inventoryDomain.Part, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*inventoryDomain.Part), args.Error(1)
+}
+
+func (m *MockPartRepository) GetBySKU(ctx context.Context, sku string) (*inventoryDomain.Part, error) {
+	args := m.Called(ctx, sku)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*inventoryDomain.Part), args.Error(1)
+}
+
 func (m *MockPartRepository) Update(ctx context.Context, part *inventoryDomain.Part) error {
 	args := m.Called(ctx, part)
 	return args.Error(0)
 }
 
-func (m *MockPartRepository) List(ctx context.Context) ([]*inventoryDomain.Part, error) {
+func (m *MockPartRepository) List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*inventoryDomain.Part], error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return sharedkernel.Page[*inventoryDomain.Part]{}, args.Error(1)
+	}
+	return args.Get(0).(sharedkernel.Page[*inventoryDomain.Part]), args.Error(1)
+}
+
+func (m *MockPartRepository) Archive(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockPartRepository) ListLowStock(ctx context.Context) ([]*inventoryDomain.Part, error) {
 	args := m.Called(ctx)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -74,9 +132,20 @@ func (m *MockPartRepository) List(ctx context.Context) ([]*inventoryDomain.Part,
 	return args.Get(0).([]*inventoryDomain.Part), args.Error(1)
 }
 
-func (m *MockPartRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	args := m.Called(ctx, id)
-	return args.Error(0)
+func (m *MockPartRepository) ListMovements(ctx context.Context, partID uuid.UUID) ([]*inventoryDomain.StockMovement, error) {
+	args := m.Called(ctx, partID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*inventoryDomain.StockMovement), args.Error(1)
+}
+
+func (m *MockPartRepository) LedgerQuantities(ctx context.Context) (map[uuid.UUID]int, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uuid.UUID]int), args.Error(1)
 }
 
 // Removed DecreaseStock as it's not in the interface anymore.
@@ -85,59 +154,221 @@ type MockServiceRepository struct {
 	mock.Mock
 }
 
-func (m *MockServiceRepository) Save(service *serviceDomain.Service) error {
-	args := m.Called(service)
+func (m *MockServiceRepository) Save(ctx context.Context, service *serviceDomain.Service) error {
+	args := m.Called(ctx, service)
 	return args.Error(0)
 }
 
-func (m *MockServiceRepository) GetByID(id uuid.UUID) (*serviceDomain.Service, error) {
-	args := m.Called(id)
+func (m *MockServiceRepository) GetByID(ctx context.Context, id uuid.UUID) (*serviceDomain.Service, error) {
+	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*serviceDomain.Service), args.Error(1)
 }
 
-func (m *MockServiceRepository) List() ([]*serviceDomain.Service, error) {
-	args := m.Called()
+func (m *MockServiceRepository) List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*serviceDomain.Service], error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return sharedkernel.Page[*serviceDomain.Service]{}, args.Error(1)
+	}
+	return args.Get(0).(sharedkernel.Page[*serviceDomain.Service]), args.Error(1)
+}
+
+func (m *MockServiceRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// fakeUnitOfWork hands the mocks to the callback without a real transaction.
+type fakeUnitOfWork struct {
+	repos serviceApplication.TxRepositories
+}
+
+func newFakeUnitOfWork(orders serviceDomain.OrderRepository, parts inventoryDomain.PartRepository) *fakeUnitOfWork {
+	return &fakeUnitOfWork{repos: serviceApplication.TxRepositories{Orders: orders, Parts: parts, Odometer: &fakeOdometerRepository{}}}
+}
+
+func (u *fakeUnitOfWork) Do(ctx context.Context, fn func(repos serviceApplication.TxRepositories) error) error {
+	return fn(u.repos)
+}
+
+// fakeOdometerRepository keeps readings in memory and enforces the same
+// monotonic rule as the Postgres repository.
+type fakeOdometerRepository struct {
+	readings []*serviceDomain.OdometerReading
+}
+
+func (r *fakeOdometerRepository) Record(ctx context.Context, reading *serviceDomain.OdometerReading) error {
+	for _, last := range r.readings {
+		if last.VehicleID == reading.VehicleID {
+			if err := reading.Follows(last.Mileage); err != nil {
+				return err
+			}
+		}
+	}
+	r.readings = append(r.readings, reading)
+	return nil
+}
+
+func (r *fakeOdometerRepository) ListByVehicleID(ctx context.Context, vehicleID uuid.UUID) ([]*serviceDomain.OdometerReading, error) {
+	var readings []*serviceDomain.OdometerReading
+	for _, reading := range r.readings {
+		if reading.VehicleID == vehicleID {
+			readings = append(readings, reading)
+		}
+	}
+	return readings, nil
+}
+
+// fakeVehicleOwnershipRepository transfers the vehicles it holds in memory.
+type fakeVehicleOwnershipRepository struct {
+	vehicles   map[uuid.UUID]*serviceDomain.Vehicle
+	ownerships []*serviceDomain.VehicleOwnership
+}
+
+func (r *fakeVehicleOwnershipRepository) Transfer(ctx context.Context, vehicleID, clientID uuid.UUID) (*serviceDomain.Vehicle, error) {
+	vehicle, ok := r.vehicles[vehicleID]
+	if !ok {
+		return nil, serviceDomain.ErrVehicleNotFound
+	}
+	since := vehicle.CreatedAt
+	for _, o := range r.ownerships {
+		if o.VehicleID == vehicleID {
+			since = o.EndedAt
+		}
+	}
+	ownership, err := vehicle.TransferTo(clientID, since)
+	if err != nil {
+		return nil, err
+	}
+	r.ownerships = append(r.ownerships, ownership)
+	return vehicle, nil
+}
+
+func (r *fakeVehicleOwnershipRepository) ListByVehicleID(ctx context.Context, vehicleID uuid.UUID) ([]*serviceDomain.VehicleOwnership, error) {
+	var ownerships []*serviceDomain.VehicleOwnership
+	for _, o := range r.ownerships {
+		if o.VehicleID == vehicleID {
+			ownerships = append(ownerships, o)
+		}
+	}
+	return ownerships, nil
+}
+
+// inventoryUnitOfWork is fakeUnitOfWork for the inventory services.
+type inventoryUnitOfWork struct {
+	repos inventoryApplication.TxRepositories
+}
+
+func newInventoryUnitOfWork(parts inventoryDomain.PartRepository, purchaseOrders inventoryDomain.PurchaseOrderRepository) *inventoryUnitOfWork {
+	return &inventoryUnitOfWork{repos: inventoryApplication.TxRepositories{Parts: parts, PurchaseOrders: purchaseOrders}}
+}
+
+func (u *inventoryUnitOfWork) Do(ctx context.Context, fn func(repos inventoryApplication.TxRepositories) error) error {
+	return fn(u.repos)
+}
+
+type MockSupplierRepository struct {
+	mock.Mock
+}
+
+func (m *MockSupplierRepository) Save(ctx context.Context, supplier *inventoryDomain.Supplier) error {
+	args := m.Called(ctx, supplier)
+	return args.Error(0)
+}
+
+func (m *MockSupplierRepository) GetByID(ctx context.Context, id uuid.UUID) (*inventoryDomain.Supplier, error) {
+	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).([]*serviceDomain.Service), args.Error(1)
+	return args.Get(0).(*inventoryDomain.Supplier), args.Error(1)
+}
+
+func (m *MockSupplierRepository) List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*inventoryDomain.Supplier], error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return sharedkernel.Page[*inventoryDomain.Supplier]{}, args.Error(1)
+	}
+	return args.Get(0).(sharedkernel.Page[*inventoryDomain.Supplier]), args.Error(1)
+}
+
+func (m *MockSupplierRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+type MockPurchaseOrderRepository struct {
+	mock.Mock
 }
 
-func (m *MockServiceRepository) Delete(id uuid.UUID) error {
-	args := m.Called(id)
+func (m *MockPurchaseOrderRepository) Save(ctx context.Context, po *inventoryDomain.PurchaseOrder) error {
+	args := m.Called(ctx, po)
 	return args.Error(0)
 }
 
+func (m *MockPurchaseOrderRepository) GetByID(ctx context.Context, id uuid.UUID) (*inventoryDomain.PurchaseOrder, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*inventoryDomain.PurchaseOrder), args.Error(1)
+}
+
+func (m *MockPurchaseOrderRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*inventoryDomain.PurchaseOrder, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*inventoryDomain.PurchaseOrder), args.Error(1)
+}
+
+func (m *MockPurchaseOrderRepository) List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*inventoryDomain.PurchaseOrder], error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return sharedkernel.Page[*inventoryDomain.PurchaseOrder]{}, args.Error(1)
+	}
+	return args.Get(0).(sharedkernel.Page[*inventoryDomain.PurchaseOrder]), args.Error(1)
+}
+
+func (m *MockPurchaseOrderRepository) ListReceipts(ctx context.Context, purchaseOrderID uuid.UUID) ([]*inventoryDomain.PurchaseReceipt, error) {
+	args := m.Called(ctx, purchaseOrderID)
+	return args.Get(0).([]*inventoryDomain.PurchaseReceipt), args.Error(1)
+}
+
+func (m *MockPurchaseOrderRepository) HasOpenWithPart(ctx context.Context, partID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, partID)
+	return args.Bool(0), args.Error(1)
+}
+
 type MockClientRepository struct {
 	mock.Mock
 }
 
-func (m *MockClientRepository) Save(client *serviceDomain.Client) error {
-	args := m.Called(client)
+func (m *MockClientRepository) Save(ctx context.Context, client *serviceDomain.Client) error {
+	args := m.Called(ctx, client)
 	return args.Error(0)
 }
 
-func (m *MockClientRepository) GetByID(id uuid.UUID) (*serviceDomain.Client, error) {
-	args := m.Called(id)
+func (m *MockClientRepository) GetByID(ctx context.Context, id uuid.UUID) (*serviceDomain.Client, error) {
+	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*serviceDomain.Client), args.Error(1)
 }
 
-func (m *MockClientRepository) List() ([]*serviceDomain.Client, error) {
-	args := m.Called()
+func (m *MockClientRepository) List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*serviceDomain.Client], error) {
+	args := m.Called(ctx, q)
 	if args.Get(0) == nil {
-		return nil, args.Error(1)
+		return sharedkernel.Page[*serviceDomain.Client]{}, args.Error(1)
 	}
-	return args.Get(0).([]*serviceDomain.Client), args.Error(1)
+	return args.Get(0).(sharedkernel.Page[*serviceDomain.Client]), args.Error(1)
 }
 
-func (m *MockClientRepository) Delete(id uuid.UUID) error {
-	args := m.Called(id)
+func (m *MockClientRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
@@ -150,29 +381,37 @@ type MockVehicleRepository struct {
 	mock.Mock
 }
 
-func (m *MockVehicleRepository) Save(vehicle *serviceDomain.Vehicle) error {
-	args := m.Called(vehicle)
+func (m *MockVehicleRepository) Save(ctx context.Context, vehicle *serviceDomain.Vehicle) error {
+	args := m.Called(ctx, vehicle)
 	return args.Error(0)
 }
 
-func (m *MockVehicleRepository) GetByID(id uuid.UUID) (*serviceDomain.Vehicle, error) {
-	args := m.Called(id)
+func (m *MockVehicleRepository) GetByID(ctx context.Context, id uuid.UUID) (*serviceDomain.Vehicle, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*serviceDomain.Vehicle), args.Error(1)
+}
+
+func (m *MockVehicleRepository) GetByPlate(ctx context.Context, plate sharedkernel.PlacaBR) (*serviceDomain.Vehicle, error) {
+	args := m.Called(ctx, plate)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*serviceDomain.Vehicle), args.Error(1)
 }
 
-func (m *MockVehicleRepository) ListByClientID(clientID uuid.UUID) ([]*serviceDomain.Vehicle, error) {
-	args := m.Called(clientID)
+func (m *MockVehicleRepository) ListByClientID(ctx context.Context, clientID uuid.UUID) ([]*serviceDomain.Vehicle, error) {
+	args := m.Called(ctx, clientID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*serviceDomain.Vehicle), args.Error(1)
 }
 
-func (m *MockVehicleRepository) Delete(id uuid.UUID) error {
-	args := m.Called(id)
+func (m *MockVehicleRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
@@ -184,3 +423,175 @@ func (m *MockNotifier) SendEmail(to, subject, body string) error {
 	args := m.Called(to, subject, body)
 	return args.Error(0)
 }
+
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) Save(ctx context.Context, user *identityDomain.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) Update(ctx context.Context, user *identityDomain.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*identityDomain.User, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*identityDomain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*identityDomain.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*identityDomain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) List(ctx context.Context, q sharedkernel.ListQuery) (sharedkernel.Page[*identityDomain.User], error) {
+	args := m.Called(ctx, q)
+	return args.Get(0).(sharedkernel.Page[*identityDomain.User]), args.Error(1)
+}
+
+func (m *MockUserRepository) ListByRole(ctx context.Context, role identityDomain.Role) ([]*identityDomain.User, error) {
+	args := m.Called(ctx, role)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*identityDomain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) UseTOTPStep(ctx context.Context, id uuid.UUID, step int64) (bool, error) {
+	args := m.Called(ctx, id, step)
+	return args.Bool(0), args.Error(1)
+}
+
+type MockRefreshTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenRepository) Save(ctx context.Context, token *identityDomain.RefreshToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) GetByID(ctx context.Context, id uuid.UUID) (*identityDomain.RefreshToken, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*identityDomain.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) MarkUsed(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	args := m.Called(ctx, id, at)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID, at time.Time) error {
+	args := m.Called(ctx, familyID, at)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) RevokeUser(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	args := m.Called(ctx, userID, at)
+	return args.Error(0)
+}
+
+type MockUserTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserTokenRepository) Save(ctx context.Context, token *identityDomain.UserToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockUserTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*identityDomain.UserToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*identityDomain.UserToken), args.Error(1)
+}
+
+func (m *MockUserTokenRepository) MarkUsed(ctx context.Context, id uuid.UUID, at time.Time) (bool, error) {
+	args := m.Called(ctx, id, at)
+	return args.Bool(0), args.Error(1)
+}
+
+type MockBudgetApprovalRepository struct {
+	mock.Mock
+}
+
+func (m *MockBudgetApprovalRepository) Save(ctx context.Context, approval *serviceDomain.BudgetApproval) error {
+	args := m.Called(ctx, approval)
+	return args.Error(0)
+}
+
+func (m *MockBudgetApprovalRepository) GetByID(ctx context.Context, id uuid.UUID) (*serviceDomain.BudgetApproval, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*serviceDomain.BudgetApproval), args.Error(1)
+}
+
+func (m *MockBudgetApprovalRepository) ListByOrderID(ctx context.Context, orderID uuid.UUID) ([]*serviceDomain.BudgetApproval, error) {
+	args := m.Called(ctx, orderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*serviceDomain.BudgetApproval), args.Error(1)
+}
+
+func (m *MockBudgetApprovalRepository) RevokeOrder(ctx context.Context, orderID uuid.UUID, at time.Time) error {
+	args := m.Called(ctx, orderID, at)
+	return args.Error(0)
+}
+
+func (m *MockBudgetApprovalRepository) Decide(ctx context.Context, id uuid.UUID, decision serviceDomain.BudgetDecision) (bool, error) {
+	args := m.Called(ctx, id, decision)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockBudgetApprovalRepository) DecideOrder(ctx context.Context, orderID uuid.UUID, decision serviceDomain.BudgetDecision) (bool, error) {
+	args := m.Called(ctx, orderID, decision)
+	return args.Bool(0), args.Error(1)
+}
+
+type MockMaintenancePlanRepository struct {
+	mock.Mock
+}
+
+func (m *MockMaintenancePlanRepository) Save(ctx context.Context, plan *serviceDomain.MaintenancePlan) error {
+	args := m.Called(ctx, plan)
+	return args.Error(0)
+}
+
+func (m *MockMaintenancePlanRepository) GetByID(ctx context.Context, id uuid.UUID) (*serviceDomain.MaintenancePlan, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*serviceDomain.MaintenancePlan), args.Error(1)
+}
+
+func (m *MockMaintenancePlanRepository) List(ctx context.Context) ([]*serviceDomain.MaintenancePlan, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*serviceDomain.MaintenancePlan), args.Error(1)
+}
+
+func (m *MockMaintenancePlanRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
@@ -12,10 +12,14 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	identityDomain "github.com/noggrj/autorepair/internal/identity/domain"
 	inventoryDomain "github.com/noggrj/autorepair/internal/inventory/domain"
+	"github.com/noggrj/autorepair/internal/platform/auth"
+	authMiddleware "github.com/noggrj/autorepair/internal/platform/middleware"
 	serviceApplication "github.com/noggrj/autorepair/internal/service/application"
 	serviceHttp "github.com/noggrj/autorepair/internal/service/delivery/http"
 	serviceDomain "github.com/noggrj/autorepair/internal/service/domain"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -29,7 +33,7 @@ func setupOrderHandler() (*serviceHttp.OrderHandler, *MockOrderRepository, *Mock
 	mockClientRepo := new(MockClientRepository)
 	mockNotifier := new(MockNotifier)
 
-	orderService := serviceApplication.NewOrderService(mockOrderRepo, mockPartRepo, mockClientRepo, mockNotifier)
+	orderService := serviceApplication.NewOrderService(mockOrderRepo, mockPartRepo, mockClientRepo, mockNotifier, newFakeUnitOfWork(mockOrderRepo, mockPartRepo), nil, nil)
 	handler := serviceHttp.NewOrderHandler(mockOrderRepo, mockPartRepo, mockServiceRepo, orderService)
 
 	return handler, mockOrderRepo, mockPartRepo, mockServiceRepo, mockClientRepo
@@ -48,6 +52,7 @@ func TestOrderHandler_Create(t *testing.T) {
 	reqBody := map[string]interface{}{
 		"client_id":  clientID.String(),
 		"vehicle_id": vehicleID.String(),
+		"mileage":    41000,
 		"items": []map[string]interface{}{
 			{"type": "part", "ref_id": partID.String(), "quantity": 1},
 			{"type": "service", "ref_id": serviceID.String(), "quantity": 1},
@@ -59,9 +64,9 @@ func TestOrderHandler_Create(t *testing.T) {
 	rr := httptest.NewRecorder()
 
 	// Update to use context match
-	mockPartRepo.On("GetByID", mock.Anything, partID).Return(&inventoryDomain.Part{ID: partID, Name: "Tire", Price: 50.0}, nil)
-	mockServiceRepo.On("GetByID", serviceID).Return(&serviceDomain.Service{ID: serviceID, Name: "Fix", Price: 100.0}, nil)
-	mockOrderRepo.On("Save", mock.Anything).Return(nil)
+	mockPartRepo.On("GetByID", mock.Anything, partID).Return(&inventoryDomain.Part{ID: partID, Name: "Tire", CostPrice: sharedkernel.NewMoneyFromFloat(30.0), SalePrice: sharedkernel.NewMoneyFromFloat(50.0)}, nil)
+	mockServiceRepo.On("GetByID", mock.Anything, serviceID).Return(&serviceDomain.Service{ID: serviceID, Name: "Fix", Price: sharedkernel.NewMoneyFromFloat(100.0)}, nil)
+	mockOrderRepo.On("Save", mock.Anything, mock.Anything).Return(nil)
 
 	handler.Create(rr, req)
 
@@ -73,9 +78,9 @@ func TestOrderHandler_Get(t *testing.T) {
 	handler, mockOrderRepo, _, _, _ := setupOrderHandler()
 
 	orderID := uuid.New()
-	order := &serviceDomain.Order{ID: orderID, Total: 150.0}
+	order := &serviceDomain.Order{ID: orderID, Total: sharedkernel.NewMoneyFromFloat(150.0)}
 
-	mockOrderRepo.On("GetByID", orderID).Return(order, nil)
+	mockOrderRepo.On("GetByID", mock.Anything, orderID).Return(order, nil)
 
 	req, _ := http.NewRequest("GET", "/admin/orders/"+orderID.String(), nil)
 	rctx := chi.NewRouteContext()
@@ -97,7 +102,7 @@ func TestOrderHandler_Get_NotFound(t *testing.T) {
 	handler, mockOrderRepo, _, _, _ := setupOrderHandler()
 
 	orderID := uuid.New()
-	mockOrderRepo.On("GetByID", orderID).Return(nil, serviceDomain.ErrOrderNotFound)
+	mockOrderRepo.On("GetByID", mock.Anything, orderID).Return(nil, serviceDomain.ErrOrderNotFound)
 
 	req, _ := http.NewRequest("GET", "/admin/orders/"+orderID.String(), nil)
 	rctx := chi.NewRouteContext()
@@ -123,7 +128,7 @@ func setupOrderHandlerWithNotifier() (*serviceHttp.OrderHandler, *MockOrderRepos
 	mockClientRepo := new(MockClientRepository)
 	mockNotifier := new(MockNotifier)
 
-	orderService := serviceApplication.NewOrderService(mockOrderRepo, mockPartRepo, mockClientRepo, mockNotifier)
+	orderService := serviceApplication.NewOrderService(mockOrderRepo, mockPartRepo, mockClientRepo, mockNotifier, newFakeUnitOfWork(mockOrderRepo, mockPartRepo), nil, nil)
 	handler := serviceHttp.NewOrderHandler(mockOrderRepo, mockPartRepo, mockServiceRepo, orderService)
 
 	return handler, mockOrderRepo, mockPartRepo, mockServiceRepo, mockClientRepo, mockNotifier
@@ -137,9 +142,9 @@ func TestOrderHandler_Approve_Full(t *testing.T) {
 	order := &serviceDomain.Order{ID: orderID, ClientID: clientID, Status: serviceDomain.OrderStatusReceived}
 	client := &serviceDomain.Client{ID: clientID, Email: "test@test.com"}
 
-	mockOrderRepo.On("GetByID", orderID).Return(order, nil)
-	mockOrderRepo.On("Save", mock.Anything).Return(nil)
-	mockClientRepo.On("GetByID", clientID).Return(client, nil)
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+	mockOrderRepo.On("Save", mock.Anything, mock.Anything).Return(nil)
+	mockClientRepo.On("GetByID", mock.Anything, clientID).Return(client, nil)
 	mockNotifier.On("SendEmail", "test@test.com", mock.Anything, mock.Anything).Return(nil)
 
 	req, _ := http.NewRequest("PATCH", "/admin/orders/"+orderID.String()+"/approve", nil)
@@ -158,10 +163,10 @@ func TestOrderHandler_ReportRevenue(t *testing.T) {
 	handler, mockOrderRepo, _, _, _ := setupOrderHandler()
 
 	orders := []*serviceDomain.Order{
-		{Total: 100.0},
-		{Total: 200.0},
+		{Total: sharedkernel.NewMoneyFromFloat(100.0)},
+		{Total: sharedkernel.NewMoneyFromFloat(200.0)},
 	}
-	mockOrderRepo.On("List").Return(orders, nil)
+	mockOrderRepo.On("List", mock.Anything, mock.Anything).Return(sharedkernel.Page[*serviceDomain.Order]{Items: orders}, nil)
 
 	req, _ := http.NewRequest("GET", "/admin/reports/revenue", nil)
 	rr := httptest.NewRecorder()
@@ -175,6 +180,63 @@ func TestOrderHandler_ReportRevenue(t *testing.T) {
 	assert.Equal(t, 300.0, resp["total_revenue"])
 }
 
+func TestOrderHandler_ReportMargin(t *testing.T) {
+	handler, mockOrderRepo, _, _, _ := setupOrderHandler()
+
+	orders := []*serviceDomain.Order{
+		{Status: serviceDomain.OrderStatusDelivered, Total: sharedkernel.NewMoneyFromFloat(300.0), TotalCost: sharedkernel.NewMoneyFromFloat(120.0)},
+		{Status: serviceDomain.OrderStatusInExecution, Total: sharedkernel.NewMoneyFromFloat(100.0), TotalCost: sharedkernel.NewMoneyFromFloat(40.0)},
+		{Status: serviceDomain.OrderStatusCancelled, Total: sharedkernel.NewMoneyFromFloat(500.0), TotalCost: sharedkernel.NewMoneyFromFloat(200.0)},
+	}
+	mockOrderRepo.On("List", mock.Anything, mock.Anything).Return(sharedkernel.Page[*serviceDomain.Order]{Items: orders}, nil)
+
+	req, _ := http.NewRequest("GET", "/admin/reports/margin", nil)
+	rr := httptest.NewRecorder()
+
+	handler.ReportMargin(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp map[string]interface{}
+	err := json.Unmarshal(rr.Body.Bytes(), &resp)
+	assert.NoError(t, err)
+	assert.Equal(t, 400.0, resp["revenue"])
+	assert.Equal(t, 160.0, resp["cost"])
+	assert.Equal(t, 240.0, resp["gross_margin"])
+	assert.Len(t, resp["orders"], 2)
+}
+
+func TestOrderHandler_List(t *testing.T) {
+	handler, mockOrderRepo, _, _, _ := setupOrderHandler()
+
+	isActiveQueue := func(q sharedkernel.ListQuery) bool {
+		return q.Sort == "priority" && q.Limit == 50 && len(q.Status) == 4
+	}
+	mockOrderRepo.On("List", mock.Anything, mock.MatchedBy(isActiveQueue)).Return(sharedkernel.Page[*serviceDomain.Order]{}, nil).Once()
+
+	// Defaults to the active queue
+	req, _ := http.NewRequest("GET", "/admin/orders", nil)
+	rr := httptest.NewRecorder()
+	handler.List(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, "[]", rr.Body.String())
+
+	// status=all lifts the status filter
+	isAll := func(q sharedkernel.ListQuery) bool { return q.Status == nil && q.Sort == "created_at" }
+	mockOrderRepo.On("List", mock.Anything, mock.MatchedBy(isAll)).Return(sharedkernel.Page[*serviceDomain.Order]{}, nil).Once()
+	req, _ = http.NewRequest("GET", "/admin/orders?status=all&sort=created_at", nil)
+	rr = httptest.NewRecorder()
+	handler.List(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// Unknown status
+	req, _ = http.NewRequest("GET", "/admin/orders?status=Lost", nil)
+	rr = httptest.NewRecorder()
+	handler.List(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	mockOrderRepo.AssertExpectations(t)
+}
+
 func TestOrderHandler_StartDiagnosis(t *testing.T) {
 	handler, mockOrderRepo, _, _, mockClientRepo, mockNotifier := setupOrderHandlerWithNotifier()
 
@@ -183,11 +245,11 @@ func TestOrderHandler_StartDiagnosis(t *testing.T) {
 	order := &serviceDomain.Order{ID: orderID, ClientID: clientID, Status: serviceDomain.OrderStatusReceived}
 	client := &serviceDomain.Client{ID: clientID, Email: "test@test.com"}
 
-	mockOrderRepo.On("GetByID", orderID).Return(order, nil)
-	mockOrderRepo.On("Save", mock.MatchedBy(func(o *serviceDomain.Order) bool {
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+	mockOrderRepo.On("Save", mock.Anything, mock.MatchedBy(func(o *serviceDomain.Order) bool {
 		return o.Status == serviceDomain.OrderStatusInDiagnosis
 	})).Return(nil)
-	mockClientRepo.On("GetByID", clientID).Return(client, nil)
+	mockClientRepo.On("GetByID", mock.Anything, clientID).Return(client, nil)
 	mockNotifier.On("SendEmail", "test@test.com", mock.Anything, mock.Anything).Return(nil)
 
 	req, _ := http.NewRequest("POST", "/admin/orders/"+orderID.String()+"/diagnosis:start", nil)
@@ -210,11 +272,11 @@ func TestOrderHandler_SendBudget(t *testing.T) {
 	order := &serviceDomain.Order{ID: orderID, ClientID: clientID, Status: serviceDomain.OrderStatusInDiagnosis}
 	client := &serviceDomain.Client{ID: clientID, Email: "test@test.com"}
 
-	mockOrderRepo.On("GetByID", orderID).Return(order, nil)
-	mockOrderRepo.On("Save", mock.MatchedBy(func(o *serviceDomain.Order) bool {
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+	mockOrderRepo.On("Save", mock.Anything, mock.MatchedBy(func(o *serviceDomain.Order) bool {
 		return o.Status == serviceDomain.OrderStatusAwaitingApproval
 	})).Return(nil)
-	mockClientRepo.On("GetByID", clientID).Return(client, nil)
+	mockClientRepo.On("GetByID", mock.Anything, clientID).Return(client, nil)
 	mockNotifier.On("SendEmail", "test@test.com", mock.Anything, mock.Anything).Return(nil)
 
 	req, _ := http.NewRequest("POST", "/admin/orders/"+orderID.String()+"/budget:send", nil)
@@ -237,11 +299,11 @@ func TestOrderHandler_FinishOrder(t *testing.T) {
 	order := &serviceDomain.Order{ID: orderID, ClientID: clientID, Status: serviceDomain.OrderStatusInExecution}
 	client := &serviceDomain.Client{ID: clientID, Email: "test@test.com"}
 
-	mockOrderRepo.On("GetByID", orderID).Return(order, nil)
-	mockOrderRepo.On("Save", mock.MatchedBy(func(o *serviceDomain.Order) bool {
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+	mockOrderRepo.On("Save", mock.Anything, mock.MatchedBy(func(o *serviceDomain.Order) bool {
 		return o.Status == serviceDomain.OrderStatusCompleted
 	})).Return(nil)
-	mockClientRepo.On("GetByID", clientID).Return(client, nil)
+	mockClientRepo.On("GetByID", mock.Anything, clientID).Return(client, nil)
 	mockNotifier.On("SendEmail", "test@test.com", mock.Anything, mock.Anything).Return(nil)
 
 	req, _ := http.NewRequest("POST", "/admin/orders/"+orderID.String()+"/finish", nil)
@@ -264,11 +326,11 @@ func TestOrderHandler_DeliverOrder(t *testing.T) {
 	order := &serviceDomain.Order{ID: orderID, ClientID: clientID, Status: serviceDomain.OrderStatusCompleted}
 	client := &serviceDomain.Client{ID: clientID, Email: "test@test.com"}
 
-	mockOrderRepo.On("GetByID", orderID).Return(order, nil)
-	mockOrderRepo.On("Save", mock.MatchedBy(func(o *serviceDomain.Order) bool {
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+	mockOrderRepo.On("Save", mock.Anything, mock.MatchedBy(func(o *serviceDomain.Order) bool {
 		return o.Status == serviceDomain.OrderStatusDelivered
 	})).Return(nil)
-	mockClientRepo.On("GetByID", clientID).Return(client, nil)
+	mockClientRepo.On("GetByID", mock.Anything, clientID).Return(client, nil)
 	mockNotifier.On("SendEmail", "test@test.com", mock.Anything, mock.Anything).Return(nil)
 
 	req, _ := http.NewRequest("POST", "/admin/orders/"+orderID.String()+"/deliver", nil)
@@ -291,17 +353,19 @@ func TestOrderHandler_UpdateStatus(t *testing.T) {
 	order := &serviceDomain.Order{ID: orderID, ClientID: clientID, Status: serviceDomain.OrderStatusReceived}
 	client := &serviceDomain.Client{ID: clientID, Email: "test@test.com"}
 
-	mockOrderRepo.On("GetByID", orderID).Return(order, nil)
-	mockOrderRepo.On("Save", mock.Anything).Return(nil)
-	mockClientRepo.On("GetByID", clientID).Return(client, nil)
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+	mockOrderRepo.On("Save", mock.Anything, mock.Anything).Return(nil)
+	mockClientRepo.On("GetByID", mock.Anything, clientID).Return(client, nil)
 	mockNotifier.On("SendEmail", "test@test.com", mock.Anything, mock.Anything).Return(nil)
 
-	reqBody := map[string]string{"status": "in_execution"}
+	reqBody := map[string]string{"status": "In execution"}
 	body, _ := json.Marshal(reqBody)
 	req, _ := http.NewRequest("PATCH", "/admin/orders/"+orderID.String()+"/status", bytes.NewBuffer(body))
 	rctx := chi.NewRouteContext()
 	rctx.URLParams.Add("id", orderID.String())
-	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	ctx = context.WithValue(ctx, authMiddleware.UserContextKey, &auth.Claims{UserID: uuid.New(), Role: string(identityDomain.RoleManager)})
+	req = req.WithContext(ctx)
 
 	rr := httptest.NewRecorder()
 
@@ -318,7 +382,7 @@ func TestOrderHandler_ReportAvgExecutionTime(t *testing.T) {
 	orders := []*serviceDomain.Order{
 		{Status: serviceDomain.OrderStatusCompleted, StartedAt: &start, FinishedAt: &end},
 	}
-	mockOrderRepo.On("List").Return(orders, nil)
+	mockOrderRepo.On("List", mock.Anything, mock.Anything).Return(sharedkernel.Page[*serviceDomain.Order]{Items: orders}, nil)
 
 	req, _ := http.NewRequest("GET", "/admin/reports/avg-execution-time", nil)
 	rr := httptest.NewRecorder()
@@ -365,15 +429,15 @@ func TestOrderHandler_TrackOrder(t *testing.T) {
 	order := &serviceDomain.Order{
 		ID:        orderID,
 		Status:    serviceDomain.OrderStatusReceived,
-		Total:     150.0,
+		Total:     sharedkernel.NewMoneyFromFloat(150.0),
 		CreatedAt: time.Now(),
 		Items: []*serviceDomain.OrderItem{
-			{Name: "Item 1", Quantity: 1, Total: 50.0},
-			{Name: "Item 2", Quantity: 2, Total: 100.0},
+			{Name: "Item 1", Quantity: 1, Total: sharedkernel.NewMoneyFromFloat(50.0)},
+			{Name: "Item 2", Quantity: 2, Total: sharedkernel.NewMoneyFromFloat(100.0)},
 		},
 	}
 
-	mockOrderRepo.On("GetByID", orderID).Return(order, nil)
+	mockOrderRepo.On("GetByID", mock.Anything, orderID).Return(order, nil)
 
 	req, _ := http.NewRequest("GET", "/orders/"+orderID.String()+"/track", nil)
 	rctx := chi.NewRouteContext()
@@ -398,7 +462,7 @@ func TestOrderHandler_TrackOrder_NotFound(t *testing.T) {
 	handler, mockOrderRepo, _, _, _ := setupOrderHandler()
 
 	orderID := uuid.New()
-	mockOrderRepo.On("GetByID", orderID).Return(nil, serviceDomain.ErrOrderNotFound)
+	mockOrderRepo.On("GetByID", mock.Anything, orderID).Return(nil, serviceDomain.ErrOrderNotFound)
 
 	req, _ := http.NewRequest("GET", "/orders/"+orderID.String()+"/track", nil)
 	rctx := chi.NewRouteContext()
@@ -438,6 +502,7 @@ func TestOrderHandler_Create_SaveError(t *testing.T) {
 	reqBody := map[string]interface{}{
 		"client_id":  clientID.String(),
 		"vehicle_id": vehicleID.String(),
+		"mileage":    41000,
 		"items": []map[string]interface{}{
 			{"type": "part", "ref_id": partID.String(), "quantity": 1},
 			{"type": "service", "ref_id": serviceID.String(), "quantity": 1},
@@ -448,15 +513,35 @@ func TestOrderHandler_Create_SaveError(t *testing.T) {
 	req, _ := http.NewRequest("POST", "/admin/orders", bytes.NewBuffer(body))
 	rr := httptest.NewRecorder()
 
-	mockPartRepo.On("GetByID", mock.Anything, partID).Return(&inventoryDomain.Part{ID: partID, Name: "Tire", Price: 50.0}, nil)
-	mockServiceRepo.On("GetByID", serviceID).Return(&serviceDomain.Service{ID: serviceID, Name: "Fix", Price: 100.0}, nil)
-	mockOrderRepo.On("Save", mock.Anything).Return(errors.New("db error"))
+	mockPartRepo.On("GetByID", mock.Anything, partID).Return(&inventoryDomain.Part{ID: partID, Name: "Tire", CostPrice: sharedkernel.NewMoneyFromFloat(30.0), SalePrice: sharedkernel.NewMoneyFromFloat(50.0)}, nil)
+	mockServiceRepo.On("GetByID", mock.Anything, serviceID).Return(&serviceDomain.Service{ID: serviceID, Name: "Fix", Price: sharedkernel.NewMoneyFromFloat(100.0)}, nil)
+	mockOrderRepo.On("Save", mock.Anything, mock.Anything).Return(errors.New("db error"))
 
 	handler.Create(rr, req)
 
 	assert.Equal(t, http.StatusInternalServerError, rr.Code)
 }
 
+func TestOrderHandler_Create_UnknownVehicle(t *testing.T) {
+	handler, mockOrderRepo, _, _, _ := setupOrderHandler()
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"client_id":  uuid.New().String(),
+		"vehicle_id": uuid.New().String(),
+		"mileage":    41000,
+	})
+	req, _ := http.NewRequest("POST", "/admin/orders", bytes.NewBuffer(body))
+	rr := httptest.NewRecorder()
+
+	// The orders.vehicle_id foreign key rejects the order before any reading.
+	mockOrderRepo.On("Save", mock.Anything, mock.Anything).Return(serviceDomain.ErrVehicleNotFound)
+
+	handler.Create(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Vehicle not found")
+}
+
 func TestOrderHandler_Create_InvalidUUID(t *testing.T) {
 	handler, _, _, _, _ := setupOrderHandler()
 
@@ -534,11 +619,11 @@ func TestOrderHandler_Approve_InsufficientStock(t *testing.T) {
 		},
 	}
 
-	mockOrderRepo.On("GetByID", orderID).Return(order, nil)
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
 
 	// Mock part behavior for insufficient stock
 	part := &inventoryDomain.Part{ID: partID, Quantity: 5} // Less than 10
-	mockPartRepo.On("GetByID", mock.Anything, partID).Return(part, nil)
+	mockPartRepo.On("GetByIDForUpdate", mock.Anything, partID).Return(part, nil)
 	// RemoveStock will be called on domain object inside service, we don't mock it here directly if we return a real part object.
 	// But since we return a pointer, the service modifies it.
 	// The service will call part.RemoveStock(10) which returns error.
@@ -566,6 +651,7 @@ func TestOrderHandler_Create_ServiceNotFound(t *testing.T) {
 	reqBody := map[string]interface{}{
 		"client_id":  clientID.String(),
 		"vehicle_id": vehicleID.String(),
+		"mileage":    41000,
 		"items": []map[string]interface{}{
 			{"type": "service", "ref_id": serviceID.String(), "quantity": 1},
 		},
@@ -575,7 +661,7 @@ func TestOrderHandler_Create_ServiceNotFound(t *testing.T) {
 	req, _ := http.NewRequest("POST", "/admin/orders", bytes.NewBuffer(body))
 	rr := httptest.NewRecorder()
 
-	mockServiceRepo.On("GetByID", serviceID).Return(nil, errors.New("not found"))
+	mockServiceRepo.On("GetByID", mock.Anything, serviceID).Return(nil, errors.New("not found"))
 
 	handler.Create(rr, req)
 
@@ -592,6 +678,7 @@ func TestOrderHandler_Create_PartNotFound(t *testing.T) {
 	reqBody := map[string]interface{}{
 		"client_id":  clientID.String(),
 		"vehicle_id": vehicleID.String(),
+		"mileage":    41000,
 		"items": []map[string]interface{}{
 			{"type": "part", "ref_id": partID.String(), "quantity": 1},
 		},
@@ -613,7 +700,7 @@ func TestOrderHandler_StartDiagnosis_ServiceError(t *testing.T) {
 	handler, mockOrderRepo, _, _, _ := setupOrderHandler()
 
 	orderID := uuid.New()
-	mockOrderRepo.On("GetByID", orderID).Return(nil, errors.New("db error"))
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(nil, errors.New("db error"))
 
 	req, _ := http.NewRequest("POST", "/admin/orders/"+orderID.String()+"/diagnosis:start", nil)
 	rctx := chi.NewRouteContext()
@@ -637,3 +724,255 @@ func TestOrderHandler_Create_InvalidInput(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, rr.Code)
 }
+
+func TestOrderHandler_UpdateStatus_UnknownStatus(t *testing.T) {
+	handler, _, _, _, _ := setupOrderHandler()
+
+	orderID := uuid.New()
+	body, _ := json.Marshal(map[string]string{"status": "in_execution"})
+	req, _ := http.NewRequest("PATCH", "/admin/orders/"+orderID.String()+"/status", bytes.NewBuffer(body))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", orderID.String())
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	rr := httptest.NewRecorder()
+
+	handler.UpdateStatus(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestOrderHandler_UpdateStatus_IllegalTransition(t *testing.T) {
+	handler, mockOrderRepo, _, _, _ := setupOrderHandler()
+
+	orderID := uuid.New()
+	order := &serviceDomain.Order{ID: orderID, Status: serviceDomain.OrderStatusReceived}
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+
+	body, _ := json.Marshal(map[string]string{"status": "Delivered"})
+	req, _ := http.NewRequest("PATCH", "/admin/orders/"+orderID.String()+"/status", bytes.NewBuffer(body))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", orderID.String())
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	rr := httptest.NewRecorder()
+
+	handler.UpdateStatus(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	mockOrderRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestOrderHandler_History(t *testing.T) {
+	handler, mockOrderRepo, _, _, _ := setupOrderHandler()
+
+	orderID := uuid.New()
+	history := []*serviceDomain.StatusTransition{
+		{ID: uuid.New(), OrderID: orderID, From: serviceDomain.OrderStatusReceived, To: serviceDomain.OrderStatusInDiagnosis, Actor: "tester", OccurredAt: time.Now()},
+	}
+	mockOrderRepo.On("GetByID", mock.Anything, orderID).Return(&serviceDomain.Order{ID: orderID}, nil)
+	mockOrderRepo.On("ListStatusHistory", mock.Anything, orderID).Return(history, nil)
+
+	req, _ := http.NewRequest("GET", "/admin/orders/"+orderID.String()+"/history", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", orderID.String())
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	rr := httptest.NewRecorder()
+
+	handler.History(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp []map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Len(t, resp, 1)
+	assert.Equal(t, "In diagnosis", resp[0]["To"])
+}
+
+func TestOrderHandler_History_NotFound(t *testing.T) {
+	handler, mockOrderRepo, _, _, _ := setupOrderHandler()
+
+	orderID := uuid.New()
+	mockOrderRepo.On("GetByID", mock.Anything, orderID).Return(nil, serviceDomain.ErrOrderNotFound)
+
+	req, _ := http.NewRequest("GET", "/admin/orders/"+orderID.String()+"/history", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", orderID.String())
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	rr := httptest.NewRecorder()
+
+	handler.History(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestOrderHandler_CancelOrder(t *testing.T) {
+	handler, mockOrderRepo, _, _, mockClientRepo, mockNotifier := setupOrderHandlerWithNotifier()
+
+	orderID := uuid.New()
+	clientID := uuid.New()
+	order := &serviceDomain.Order{ID: orderID, ClientID: clientID, Status: serviceDomain.OrderStatusReceived}
+	client := &serviceDomain.Client{ID: clientID, Email: "test@test.com"}
+
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+	mockOrderRepo.On("Save", mock.Anything, mock.Anything).Return(nil)
+	mockClientRepo.On("GetByID", mock.Anything, clientID).Return(client, nil)
+	mockNotifier.On("SendEmail", "test@test.com", mock.Anything, mock.Anything).Return(nil)
+
+	body, _ := json.Marshal(map[string]string{"reason": "client request"})
+	req, _ := http.NewRequest("POST", "/admin/orders/"+orderID.String()+"/cancel", bytes.NewBuffer(body))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", orderID.String())
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	rr := httptest.NewRecorder()
+
+	handler.CancelOrder(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, serviceDomain.OrderStatusCancelled, order.Status)
+}
+
+func TestOrderHandler_CancelOrder_MissingReason(t *testing.T) {
+	handler, mockOrderRepo, _, _, _ := setupOrderHandler()
+
+	orderID := uuid.New()
+	req, _ := http.NewRequest("POST", "/admin/orders/"+orderID.String()+"/cancel", bytes.NewBufferString(`{}`))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", orderID.String())
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	rr := httptest.NewRecorder()
+
+	handler.CancelOrder(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockOrderRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestOrderHandler_RemoveItem(t *testing.T) {
+	handler, mockOrderRepo, _, _, _ := setupOrderHandler()
+
+	order, _ := serviceDomain.NewOrder(uuid.New(), uuid.New(), 0)
+	_ = order.AddItem(uuid.New(), serviceDomain.ItemTypeService, "Labor", 1, sharedkernel.NewMoneyFromFloat(80.0), sharedkernel.Money{})
+	itemID := order.Items[0].ID
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, order.ID).Return(order, nil)
+	mockOrderRepo.On("Save", mock.Anything, mock.Anything).Return(nil)
+
+	remove := func(itemID string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("DELETE", "/admin/orders/"+order.ID.String()+"/items/"+itemID, nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", order.ID.String())
+		rctx.URLParams.Add("itemID", itemID)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		rr := httptest.NewRecorder()
+		handler.RemoveItem(rr, req)
+		return rr
+	}
+
+	rr := remove(itemID.String())
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, order.Items)
+
+	assert.Equal(t, http.StatusNotFound, remove(itemID.String()).Code)
+	assert.Equal(t, http.StatusBadRequest, remove("bad").Code)
+
+	order.Status = serviceDomain.OrderStatusCancelled
+	_ = order.AddItem(uuid.New(), serviceDomain.ItemTypeService, "Labor", 1, sharedkernel.NewMoneyFromFloat(80.0), sharedkernel.Money{})
+	assert.Equal(t, http.StatusConflict, remove(order.Items[0].ID.String()).Code)
+}
+
+func TestOrderHandler_Mileage(t *testing.T) {
+	mockOrderRepo := new(MockOrderRepository)
+	mockClientRepo := new(MockClientRepository)
+	odometer := &fakeOdometerRepository{}
+	uow := &fakeUnitOfWork{repos: serviceApplication.TxRepositories{Orders: mockOrderRepo, Odometer: odometer}}
+	orderService := serviceApplication.NewOrderService(mockOrderRepo, nil, mockClientRepo, new(MockNotifier), uow, nil, nil)
+	handler := serviceHttp.NewOrderHandler(mockOrderRepo, nil, new(MockServiceRepository), orderService)
+
+	clientID := uuid.New()
+	vehicleID := uuid.New()
+	create := func(mileage int) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]interface{}{
+			"client_id":  clientID.String(),
+			"vehicle_id": vehicleID.String(),
+			"mileage":    mileage,
+		})
+		req, _ := http.NewRequest("POST", "/admin/orders", bytes.NewBuffer(body))
+		rr := httptest.NewRecorder()
+		handler.Create(rr, req)
+		return rr
+	}
+	mockOrderRepo.On("Save", mock.Anything, mock.Anything).Return(nil)
+	mockClientRepo.On("GetByID", mock.Anything, clientID).Return(nil, errors.New("not found"))
+
+	// Reception reading
+	rr := create(41000)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var created map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	assert.Equal(t, float64(41000), created["ReceptionMileage"])
+
+	// Lower than the last reading, or negative
+	assert.Equal(t, http.StatusConflict, create(40000).Code)
+	assert.Equal(t, http.StatusBadRequest, create(-1).Code)
+
+	// Mileage is required on reception
+	body, _ := json.Marshal(map[string]interface{}{"client_id": clientID.String(), "vehicle_id": vehicleID.String()})
+	req, _ := http.NewRequest("POST", "/admin/orders", bytes.NewBuffer(body))
+	rr = httptest.NewRecorder()
+	handler.Create(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "mileage is required")
+
+	// Delivery reading, optional in the body
+	orderID, _ := uuid.Parse(created["ID"].(string))
+	order := &serviceDomain.Order{ID: orderID, ClientID: clientID, VehicleID: vehicleID, Status: serviceDomain.OrderStatusCompleted}
+	mockOrderRepo.On("GetByIDForUpdate", mock.Anything, orderID).Return(order, nil)
+	deliver := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/admin/orders/"+orderID.String()+"/deliver", bytes.NewBufferString(body))
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", orderID.String())
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		rr := httptest.NewRecorder()
+		handler.DeliverOrder(rr, req)
+		return rr
+	}
+
+	assert.Equal(t, http.StatusBadRequest, deliver(`{"mileage":`).Code)
+	assert.Equal(t, http.StatusConflict, deliver(`{"mileage": 39000}`).Code)
+	assert.Len(t, odometer.readings, 1)
+
+	// The refused transition was rolled back
+	order.Status = serviceDomain.OrderStatusCompleted
+	assert.Equal(t, http.StatusOK, deliver(`{"mileage": 41012}`).Code)
+	if assert.Len(t, odometer.readings, 2) {
+		assert.Equal(t, 41012, odometer.readings[1].Mileage)
+	}
+}
+
+func TestOrderHandler_UpdateStatus_RequiresTargetPermission(t *testing.T) {
+	for _, status := range []string{"In execution", "Cancelled"} {
+		t.Run(status, func(t *testing.T) {
+			handler, mockOrderRepo, _, _, _ := setupOrderHandler()
+			orderID := uuid.New()
+
+			body, _ := json.Marshal(map[string]string{"status": status, "reason": "customer gave up"})
+			req, _ := http.NewRequest("PATCH", "/admin/orders/"+orderID.String()+"/status", bytes.NewBuffer(body))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", orderID.String())
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			ctx = context.WithValue(ctx, authMiddleware.UserContextKey, &auth.Claims{UserID: uuid.New(), Role: string(identityDomain.RoleEmployee)})
+			req = req.WithContext(ctx)
+
+			rr := httptest.NewRecorder()
+
+			handler.UpdateStatus(rr, req)
+
+			assert.Equal(t, http.StatusForbidden, rr.Code)
+			mockOrderRepo.AssertNotCalled(t, "GetByIDForUpdate", mock.Anything, mock.Anything)
+			mockOrderRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+		})
+	}
+}
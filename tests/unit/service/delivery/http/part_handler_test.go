@@ -2,25 +2,32 @@ package http_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	inventoryApplication "github.com/noggrj/autorepair/internal/inventory/application"
 	inventoryDomain "github.com/noggrj/autorepair/internal/inventory/domain"
 	serviceHttp "github.com/noggrj/autorepair/internal/service/delivery/http"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
 func TestPartHandler_Create(t *testing.T) {
 	mockRepo := new(MockPartRepository)
-	handler := serviceHttp.NewPartHandler(mockRepo)
+	handler := serviceHttp.NewPartHandler(mockRepo, nil)
 
 	reqBody := map[string]interface{}{
+		"sku":         "OF-TOY-01",
 		"name":        "Oil Filter",
 		"description": "Filter for Toyota",
-		"price":       50.0,
+		"cost_price":  28.0,
+		"sale_price":  50.0,
 		"stock_qty":   10,
 	}
 	body, _ := json.Marshal(reqBody)
@@ -38,13 +45,13 @@ func TestPartHandler_Create(t *testing.T) {
 
 func TestPartHandler_List(t *testing.T) {
 	mockRepo := new(MockPartRepository)
-	handler := serviceHttp.NewPartHandler(mockRepo)
+	handler := serviceHttp.NewPartHandler(mockRepo, nil)
 
 	parts := []*inventoryDomain.Part{
 		{Name: "Oil Filter"},
 	}
 	// Update to use context match
-	mockRepo.On("List", mock.Anything).Return(parts, nil)
+	mockRepo.On("List", mock.Anything, mock.Anything).Return(sharedkernel.Page[*inventoryDomain.Part]{Items: parts}, nil)
 
 	req, _ := http.NewRequest("GET", "/admin/parts", nil)
 	rr := httptest.NewRecorder()
@@ -54,8 +61,48 @@ func TestPartHandler_List(t *testing.T) {
 	assert.Equal(t, http.StatusOK, rr.Code)
 }
 
+func TestPartHandler_List_BySKU(t *testing.T) {
+	mockRepo := new(MockPartRepository)
+	handler := serviceHttp.NewPartHandler(mockRepo, nil)
+
+	part := &inventoryDomain.Part{ID: uuid.New(), SKU: "OF-TOY-01", Name: "Oil Filter"}
+	mockRepo.On("GetBySKU", mock.Anything, "of-toy-01").Return(part, nil)
+	mockRepo.On("GetBySKU", mock.Anything, "missing").Return(nil, inventoryDomain.ErrPartNotFound)
+
+	req, _ := http.NewRequest("GET", "/admin/parts?sku=of-toy-01", nil)
+	rr := httptest.NewRecorder()
+	handler.List(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "OF-TOY-01")
+
+	// Unknown SKU is an empty result, not an error
+	req, _ = http.NewRequest("GET", "/admin/parts?sku=missing", nil)
+	rr = httptest.NewRecorder()
+	handler.List(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, "[]", rr.Body.String())
+	mockRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+}
+
+func TestPartHandler_Create_DuplicateSKU(t *testing.T) {
+	mockRepo := new(MockPartRepository)
+	handler := serviceHttp.NewPartHandler(mockRepo, nil)
+
+	body, _ := json.Marshal(map[string]interface{}{"sku": "OF-TOY-01", "name": "Oil Filter", "sale_price": 50.0})
+	req, _ := http.NewRequest("POST", "/admin/parts", bytes.NewBuffer(body))
+	rr := httptest.NewRecorder()
+
+	mockRepo.On("Save", mock.Anything, mock.Anything).Return(inventoryDomain.ErrDuplicateSKU)
+
+	handler.Create(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
+
 func TestPartHandler_Create_InvalidJSON(t *testing.T) {
-	handler := serviceHttp.NewPartHandler(nil)
+	handler := serviceHttp.NewPartHandler(nil, nil)
 
 	req, _ := http.NewRequest("POST", "/admin/parts", bytes.NewBuffer([]byte("{invalid")))
 	rr := httptest.NewRecorder()
@@ -67,10 +114,10 @@ func TestPartHandler_Create_InvalidJSON(t *testing.T) {
 
 func TestPartHandler_List_Error(t *testing.T) {
 	mockRepo := new(MockPartRepository)
-	handler := serviceHttp.NewPartHandler(mockRepo)
+	handler := serviceHttp.NewPartHandler(mockRepo, nil)
 
 	// Update to use context match
-	mockRepo.On("List", mock.Anything).Return(nil, assert.AnError)
+	mockRepo.On("List", mock.Anything, mock.Anything).Return(nil, assert.AnError)
 
 	req, _ := http.NewRequest("GET", "/admin/parts", nil)
 	rr := httptest.NewRecorder()
@@ -79,3 +126,302 @@ func TestPartHandler_List_Error(t *testing.T) {
 
 	assert.Equal(t, http.StatusInternalServerError, rr.Code)
 }
+
+func TestPartHandler_Movements(t *testing.T) {
+	mockRepo := new(MockPartRepository)
+	handler := serviceHttp.NewPartHandler(mockRepo, nil)
+
+	partID := uuid.New()
+	movements := []*inventoryDomain.StockMovement{
+		{ID: uuid.New(), PartID: partID, Type: inventoryDomain.MovementPurchase, Quantity: 5},
+	}
+	mockRepo.On("GetByID", mock.Anything, partID).Return(&inventoryDomain.Part{ID: partID}, nil)
+	mockRepo.On("ListMovements", mock.Anything, partID).Return(movements, nil)
+
+	req, _ := http.NewRequest("GET", "/admin/parts/"+partID.String()+"/movements", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", partID.String())
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rr := httptest.NewRecorder()
+
+	handler.Movements(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp []map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Len(t, resp, 1)
+	assert.Equal(t, "purchase", resp[0]["Type"])
+}
+
+func TestPartHandler_Movements_NotFound(t *testing.T) {
+	mockRepo := new(MockPartRepository)
+	handler := serviceHttp.NewPartHandler(mockRepo, nil)
+
+	partID := uuid.New()
+	mockRepo.On("GetByID", mock.Anything, partID).Return(nil, assert.AnError)
+
+	req, _ := http.NewRequest("GET", "/admin/parts/"+partID.String()+"/movements", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", partID.String())
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rr := httptest.NewRecorder()
+
+	handler.Movements(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestPartHandler_Reconcile(t *testing.T) {
+	mockRepo := new(MockPartRepository)
+	handler := serviceHttp.NewPartHandler(mockRepo, nil)
+
+	inSync := &inventoryDomain.Part{ID: uuid.New(), Name: "Oil Filter", Quantity: 10}
+	drifted := &inventoryDomain.Part{ID: uuid.New(), Name: "Brake Pad", Quantity: 4}
+	mockRepo.On("List", mock.Anything, mock.Anything).Return(sharedkernel.Page[*inventoryDomain.Part]{Items: []*inventoryDomain.Part{inSync, drifted}}, nil)
+	mockRepo.On("LedgerQuantities", mock.Anything).Return(map[uuid.UUID]int{inSync.ID: 10, drifted.ID: 6}, nil)
+
+	req, _ := http.NewRequest("GET", "/admin/parts/reconciliation", nil)
+	rr := httptest.NewRecorder()
+
+	handler.Reconcile(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp []serviceHttp.StockDriftResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Len(t, resp, 1)
+	assert.Equal(t, drifted.ID, resp[0].PartID)
+	assert.Equal(t, 6, resp[0].LedgerQuantity)
+	assert.Equal(t, -2, resp[0].Drift)
+}
+
+func TestPartHandler_LowStock(t *testing.T) {
+	mockRepo := new(MockPartRepository)
+	handler := serviceHttp.NewPartHandler(mockRepo, nil)
+
+	parts := []*inventoryDomain.Part{
+		{ID: uuid.New(), Name: "Brake Pad", Quantity: 1, MinQuantity: 5, ReorderQuantity: 20},
+	}
+	mockRepo.On("ListLowStock", mock.Anything).Return(parts, nil)
+
+	req, _ := http.NewRequest("GET", "/admin/parts/low-stock", nil)
+	rr := httptest.NewRecorder()
+
+	handler.LowStock(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp []map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Len(t, resp, 1)
+	assert.Equal(t, 20.0, resp[0]["ReorderQuantity"])
+}
+
+func TestPartHandler_Create_WithThresholds(t *testing.T) {
+	mockRepo := new(MockPartRepository)
+	handler := serviceHttp.NewPartHandler(mockRepo, nil)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"sku":              "BP-001",
+		"name":             "Brake Pad",
+		"sale_price":       80.0,
+		"stock_qty":        10,
+		"min_quantity":     3,
+		"reorder_quantity": 12,
+	})
+	req, _ := http.NewRequest("POST", "/admin/parts", bytes.NewBuffer(body))
+	rr := httptest.NewRecorder()
+
+	mockRepo.On("Save", mock.Anything, mock.MatchedBy(func(p *inventoryDomain.Part) bool {
+		return p.MinQuantity == 3 && p.ReorderQuantity == 12
+	})).Return(nil)
+
+	handler.Create(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	mockRepo.AssertExpectations(t)
+
+	// Negative thresholds are rejected
+	body, _ = json.Marshal(map[string]interface{}{"name": "X", "price": 1.0, "min_quantity": -1})
+	req, _ = http.NewRequest("POST", "/admin/parts", bytes.NewBuffer(body))
+	rr = httptest.NewRecorder()
+
+	handler.Create(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+type stubOpenOrders bool
+
+func (s stubOpenOrders) HasOpenOrdersWithPart(ctx context.Context, partID uuid.UUID) (bool, error) {
+	return bool(s), nil
+}
+
+type noopStockAlerter struct{}
+
+func (noopStockAlerter) NotifyLowStock(ctx context.Context, parts ...*inventoryDomain.Part) {}
+
+func newPartHandlerWithService(repo *MockPartRepository, inOpenOrders bool) *serviceHttp.PartHandler {
+	purchases := new(MockPurchaseOrderRepository)
+	purchases.On("HasOpenWithPart", mock.Anything, mock.Anything).Return(false, nil)
+	return newPartHandlerWithPurchases(repo, inOpenOrders, purchases)
+}
+
+func newPartHandlerWithPurchases(repo *MockPartRepository, inOpenOrders bool, purchases *MockPurchaseOrderRepository) *serviceHttp.PartHandler {
+	uow := newInventoryUnitOfWork(repo, purchases)
+	uow.repos.OpenOrders = stubOpenOrders(inOpenOrders)
+	service := inventoryApplication.NewPartService(uow, noopStockAlerter{})
+	return serviceHttp.NewPartHandler(repo, service)
+}
+
+func withID(req *http.Request, id uuid.UUID) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id.String())
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestPartHandler_Get(t *testing.T) {
+	mockRepo := new(MockPartRepository)
+	handler := serviceHttp.NewPartHandler(mockRepo, nil)
+
+	part := &inventoryDomain.Part{ID: uuid.New(), Name: "Oil Filter"}
+	mockRepo.On("GetByID", mock.Anything, part.ID).Return(part, nil)
+
+	req, _ := http.NewRequest("GET", "/admin/parts/"+part.ID.String(), nil)
+	rr := httptest.NewRecorder()
+
+	handler.Get(rr, withID(req, part.ID))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// Not Found
+	missing := uuid.New()
+	mockRepo.On("GetByID", mock.Anything, missing).Return(nil, inventoryDomain.ErrPartNotFound)
+
+	req, _ = http.NewRequest("GET", "/admin/parts/"+missing.String(), nil)
+	rr = httptest.NewRecorder()
+
+	handler.Get(rr, withID(req, missing))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestPartHandler_Update_IgnoresStock(t *testing.T) {
+	mockRepo := new(MockPartRepository)
+	handler := serviceHttp.NewPartHandler(mockRepo, nil)
+
+	part := &inventoryDomain.Part{ID: uuid.New(), Name: "Oil Filter", Quantity: 7}
+	mockRepo.On("GetByID", mock.Anything, part.ID).Return(part, nil)
+	mockRepo.On("Update", mock.Anything, part).Return(nil)
+
+	body, _ := json.Marshal(map[string]interface{}{"name": "Oil Filter XL", "stock_qty": 0})
+	req, _ := http.NewRequest("PUT", "/admin/parts/"+part.ID.String(), bytes.NewBuffer(body))
+	rr := httptest.NewRecorder()
+
+	handler.Update(rr, withID(req, part.ID))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "Oil Filter XL", part.Name)
+	assert.Equal(t, 7, part.Quantity)
+}
+
+func TestPartHandler_Delete(t *testing.T) {
+	mockRepo := new(MockPartRepository)
+	handler := newPartHandlerWithService(mockRepo, false)
+
+	id := uuid.New()
+	mockRepo.On("GetByIDForUpdate", mock.Anything, id).Return(&inventoryDomain.Part{ID: id}, nil)
+	mockRepo.On("Archive", mock.Anything, id).Return(nil)
+
+	req, _ := http.NewRequest("DELETE", "/admin/parts/"+id.String(), nil)
+	rr := httptest.NewRecorder()
+
+	handler.Delete(rr, withID(req, id))
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPartHandler_Delete_InOpenOrder(t *testing.T) {
+	mockRepo := new(MockPartRepository)
+	handler := newPartHandlerWithService(mockRepo, true)
+
+	id := uuid.New()
+	mockRepo.On("GetByIDForUpdate", mock.Anything, id).Return(&inventoryDomain.Part{ID: id}, nil)
+	req, _ := http.NewRequest("DELETE", "/admin/parts/"+id.String(), nil)
+	rr := httptest.NewRecorder()
+
+	handler.Delete(rr, withID(req, id))
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	mockRepo.AssertNotCalled(t, "Archive", mock.Anything, mock.Anything)
+}
+
+func TestPartHandler_Delete_OnOpenPurchaseOrder(t *testing.T) {
+	mockRepo := new(MockPartRepository)
+	purchases := new(MockPurchaseOrderRepository)
+	handler := newPartHandlerWithPurchases(mockRepo, false, purchases)
+
+	id := uuid.New()
+	mockRepo.On("GetByIDForUpdate", mock.Anything, id).Return(&inventoryDomain.Part{ID: id}, nil)
+	purchases.On("HasOpenWithPart", mock.Anything, id).Return(true, nil)
+	req, _ := http.NewRequest("DELETE", "/admin/parts/"+id.String(), nil)
+	rr := httptest.NewRecorder()
+
+	handler.Delete(rr, withID(req, id))
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	mockRepo.AssertNotCalled(t, "Archive", mock.Anything, mock.Anything)
+}
+
+func TestPartHandler_AdjustStock(t *testing.T) {
+	mockRepo := new(MockPartRepository)
+	handler := newPartHandlerWithService(mockRepo, false)
+
+	part := &inventoryDomain.Part{ID: uuid.New(), Quantity: 3}
+	mockRepo.On("GetByIDForUpdate", mock.Anything, part.ID).Return(part, nil)
+	mockRepo.On("Update", mock.Anything, part).Return(nil)
+
+	body, _ := json.Marshal(map[string]interface{}{"quantity": 5, "type": "purchase", "reference": "NF 42"})
+	req, _ := http.NewRequest("POST", "/admin/parts/"+part.ID.String()+"/stock", bytes.NewBuffer(body))
+	rr := httptest.NewRecorder()
+
+	handler.AdjustStock(rr, withID(req, part.ID))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, 8, part.Quantity)
+}
+
+func TestPartHandler_AdjustStock_Errors(t *testing.T) {
+	mockRepo := new(MockPartRepository)
+	handler := newPartHandlerWithService(mockRepo, false)
+
+	part := &inventoryDomain.Part{ID: uuid.New(), Quantity: 3}
+	missing := uuid.New()
+	mockRepo.On("GetByIDForUpdate", mock.Anything, part.ID).Return(part, nil)
+	mockRepo.On("GetByIDForUpdate", mock.Anything, missing).Return(nil, inventoryDomain.ErrPartNotFound)
+
+	cases := []struct {
+		name string
+		id   uuid.UUID
+		body map[string]interface{}
+		code int
+	}{
+		{"Insufficient", part.ID, map[string]interface{}{"quantity": -5, "type": "loss"}, http.StatusConflict},
+		{"Wrong Type", part.ID, map[string]interface{}{"quantity": 5, "type": "loss"}, http.StatusBadRequest},
+		{"Unknown Type", part.ID, map[string]interface{}{"quantity": 5, "type": "gift"}, http.StatusBadRequest},
+		{"Zero", part.ID, map[string]interface{}{"quantity": 0, "type": "adjustment"}, http.StatusBadRequest},
+		{"Not Found", missing, map[string]interface{}{"quantity": 1, "type": "purchase"}, http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			body, _ := json.Marshal(tc.body)
+			req, _ := http.NewRequest("POST", "/admin/parts/"+tc.id.String()+"/stock", bytes.NewBuffer(body))
+			rr := httptest.NewRecorder()
+
+			handler.AdjustStock(rr, withID(req, tc.id))
+
+			assert.Equal(t, tc.code, rr.Code)
+		})
+	}
+	assert.Equal(t, 3, part.Quantity)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
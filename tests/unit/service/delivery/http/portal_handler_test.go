@@ -0,0 +1,263 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	identityApplication "github.com/noggrj/autorepair/internal/identity/application"
+	identityDomain "github.com/noggrj/autorepair/internal/identity/domain"
+	"github.com/noggrj/autorepair/internal/platform/auth"
+	authMiddleware "github.com/noggrj/autorepair/internal/platform/middleware"
+	serviceApplication "github.com/noggrj/autorepair/internal/service/application"
+	serviceHttp "github.com/noggrj/autorepair/internal/service/delivery/http"
+	serviceDomain "github.com/noggrj/autorepair/internal/service/domain"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// --- Helper ---
+
+type portalFixture struct {
+	handler   *serviceHttp.PortalHandler
+	router    chi.Router
+	users     *MockUserRepository
+	tokens    *MockUserTokenRepository
+	clients   *MockClientRepository
+	vehicles  *MockVehicleRepository
+	orders    *MockOrderRepository
+	approvals *MockBudgetApprovalRepository
+	notifier  *MockNotifier
+}
+
+func setupPortalHandler() *portalFixture {
+	f := &portalFixture{
+		users:     new(MockUserRepository),
+		tokens:    new(MockUserTokenRepository),
+		clients:   new(MockClientRepository),
+		vehicles:  new(MockVehicleRepository),
+		orders:    new(MockOrderRepository),
+		approvals: new(MockBudgetApprovalRepository),
+		notifier:  new(MockNotifier),
+	}
+	sessions := new(MockRefreshTokenRepository)
+	parts := new(MockPartRepository)
+	userService := identityApplication.NewUserService(f.users, sessions)
+	accounts := identityApplication.NewAccountService(f.users, f.tokens, sessions, f.notifier)
+	uow := &fakeUnitOfWork{repos: serviceApplication.TxRepositories{Orders: f.orders, Parts: parts, BudgetApprovals: f.approvals}}
+	orderService := serviceApplication.NewOrderService(f.orders, parts, f.clients, f.notifier, uow, nil, nil)
+	f.handler = serviceHttp.NewPortalHandler(f.users, userService, accounts, f.clients, f.vehicles, f.orders, orderService)
+
+	f.router = chi.NewRouter()
+	f.router.Use(f.handler.RequireCustomer)
+	f.router.Get("/portal/orders", f.handler.Orders)
+	f.router.Get("/portal/orders/{id}", f.handler.Order)
+	f.router.Get("/portal/invoices", f.handler.Invoices)
+	f.router.Get("/portal/vehicles", f.handler.Vehicles)
+	f.router.Post("/portal/budgets/{id}/response", f.handler.RespondBudget)
+	return f
+}
+
+// customer registers a portal account for a new client and returns both.
+func (f *portalFixture) customer(t *testing.T) (*identityDomain.User, uuid.UUID) {
+	clientID := uuid.New()
+	user, err := identityDomain.NewCustomer(clientID, "Maria", "maria@example.com")
+	assert.NoError(t, err)
+	f.users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	return user, clientID
+}
+
+// serve sends a request through the portal routes as the given user.
+func (f *portalFixture) serve(method, path string, body []byte, user *identityDomain.User) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, bytes.NewBuffer(body))
+	claims := &auth.Claims{UserID: user.ID, Role: string(user.Role)}
+	req = req.WithContext(context.WithValue(req.Context(), authMiddleware.UserContextKey, claims))
+	rr := httptest.NewRecorder()
+	f.router.ServeHTTP(rr, req)
+	return rr
+}
+
+// --- Tests ---
+
+func TestPortalHandler_RequireCustomer(t *testing.T) {
+	f := setupPortalHandler()
+
+	staff := newTestUser(t)
+	f.users.On("GetByID", mock.Anything, staff.ID).Return(staff, nil)
+	rr := f.serve("GET", "/portal/vehicles", nil, staff)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	user, _ := f.customer(t)
+	user.Deactivate()
+	rr = f.serve("GET", "/portal/vehicles", nil, user)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	req := httptest.NewRequest("GET", "/portal/vehicles", nil)
+	rr = httptest.NewRecorder()
+	f.router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	f.vehicles.AssertNotCalled(t, "ListByClientID", mock.Anything, mock.Anything)
+}
+
+func TestPortalHandler_Vehicles(t *testing.T) {
+	f := setupPortalHandler()
+	user, clientID := f.customer(t)
+	vehicle, _ := serviceDomain.NewVehicle(clientID, "ABC1234", "Fiat", "Uno", 2010)
+	f.vehicles.On("ListByClientID", mock.Anything, clientID).Return([]*serviceDomain.Vehicle{vehicle}, nil)
+
+	rr := f.serve("GET", "/portal/vehicles", nil, user)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	f.vehicles.AssertExpectations(t)
+}
+
+func TestPortalHandler_Orders_ScopedToClient(t *testing.T) {
+	f := setupPortalHandler()
+	user, clientID := f.customer(t)
+	order, _ := serviceDomain.NewOrder(clientID, uuid.New(), 0)
+	_ = order.AddItem(uuid.New(), serviceDomain.ItemTypePart, "Filter", 1, sharedkernel.NewMoneyFromCents(5000), sharedkernel.NewMoneyFromCents(3000))
+	f.orders.On("List", mock.Anything, mock.MatchedBy(func(q sharedkernel.ListQuery) bool {
+		return q.ClientID != nil && *q.ClientID == clientID
+	})).Return(sharedkernel.Page[*serviceDomain.Order]{Items: []*serviceDomain.Order{order}}, nil)
+
+	// A client_id in the query cannot widen the listing.
+	rr := f.serve("GET", "/portal/orders?client_id="+uuid.New().String(), nil, user)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var orders []serviceHttp.PortalOrder
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &orders))
+	assert.Len(t, orders, 1)
+	assert.NotContains(t, rr.Body.String(), "cost", "the shop's costs stay private")
+	f.orders.AssertExpectations(t)
+}
+
+func TestPortalHandler_Invoices(t *testing.T) {
+	f := setupPortalHandler()
+	user, clientID := f.customer(t)
+	f.orders.On("List", mock.Anything, mock.MatchedBy(func(q sharedkernel.ListQuery) bool {
+		return *q.ClientID == clientID && assert.ObjectsAreEqual([]string{"Completed", "Delivered"}, q.Status)
+	})).Return(sharedkernel.Page[*serviceDomain.Order]{}, nil)
+
+	rr := f.serve("GET", "/portal/invoices?status=Received", nil, user)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, "[]", rr.Body.String())
+	f.orders.AssertExpectations(t)
+}
+
+func TestPortalHandler_Order_OtherClient(t *testing.T) {
+	f := setupPortalHandler()
+	user, _ := f.customer(t)
+	order, _ := serviceDomain.NewOrder(uuid.New(), uuid.New(), 0)
+	f.orders.On("GetByID", mock.Anything, order.ID).Return(order, nil)
+
+	rr := f.serve("GET", "/portal/orders/"+order.ID.String(), nil, user)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	body, _ := json.Marshal(serviceHttp.BudgetResponseRequest{Approved: true})
+	rr = f.serve("POST", "/portal/budgets/"+order.ID.String()+"/response", body, user)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	f.orders.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
+func TestPortalHandler_RespondBudget(t *testing.T) {
+	f := setupPortalHandler()
+	user, clientID := f.customer(t)
+	order, _ := serviceDomain.NewOrder(clientID, uuid.New(), 0)
+	f.orders.On("GetByID", mock.Anything, order.ID).Return(order, nil)
+	f.orders.On("GetByIDForUpdate", mock.Anything, order.ID).Return(order, nil)
+
+	body, _ := json.Marshal(serviceHttp.BudgetResponseRequest{Approved: false})
+
+	// Nothing to answer while the order is only received.
+	rr := f.serve("POST", "/portal/budgets/"+order.ID.String()+"/response", body, user)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	f.approvals.AssertNotCalled(t, "DecideOrder", mock.Anything, mock.Anything, mock.Anything)
+
+	_ = order.Transition(serviceDomain.OrderStatusInDiagnosis, "system", "")
+	_ = order.Transition(serviceDomain.OrderStatusAwaitingApproval, "system", "")
+	f.orders.On("Save", mock.Anything, order).Return(nil)
+	f.clients.On("GetByID", mock.Anything, clientID).Return(nil, serviceDomain.ErrClientNotFound)
+	// The answer spends the link mailed with the budget and keeps who gave it.
+	f.approvals.On("DecideOrder", mock.Anything, order.ID, mock.MatchedBy(func(d serviceDomain.BudgetDecision) bool {
+		return !d.Approved && d.IP == "192.0.2.1" && d.UserAgent == "portal-test" && !d.DecidedAt.IsZero()
+	})).Return(true, nil).Once()
+
+	req := httptest.NewRequest("POST", "/portal/budgets/"+order.ID.String()+"/response", bytes.NewBuffer(body))
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Set("User-Agent", "portal-test")
+	req = req.WithContext(context.WithValue(req.Context(), authMiddleware.UserContextKey, &auth.Claims{UserID: user.ID, Role: string(user.Role)}))
+	rr = httptest.NewRecorder()
+	f.router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, serviceDomain.OrderStatusReceived, order.Status)
+	assert.Contains(t, rr.Body.String(), "rejected")
+	f.approvals.AssertExpectations(t)
+}
+
+func TestPortalHandler_RespondBudget_WithoutLink(t *testing.T) {
+	f := setupPortalHandler()
+	user, clientID := f.customer(t)
+	order, _ := serviceDomain.NewOrder(clientID, uuid.New(), 0)
+	_ = order.Transition(serviceDomain.OrderStatusInDiagnosis, "system", "")
+	_ = order.Transition(serviceDomain.OrderStatusAwaitingApproval, "system", "")
+	f.orders.On("GetByID", mock.Anything, order.ID).Return(order, nil)
+	f.orders.On("GetByIDForUpdate", mock.Anything, order.ID).Return(order, nil)
+	f.orders.On("Save", mock.Anything, order).Return(nil)
+	f.clients.On("GetByID", mock.Anything, clientID).Return(nil, serviceDomain.ErrClientNotFound)
+
+	// The budget was sent without a link, so the decision gets its own record.
+	f.approvals.On("DecideOrder", mock.Anything, order.ID, mock.Anything).Return(false, nil)
+	var saved *serviceDomain.BudgetApproval
+	f.approvals.On("Save", mock.Anything, mock.MatchedBy(func(a *serviceDomain.BudgetApproval) bool {
+		saved = a
+		return a.OrderID == order.ID
+	})).Return(nil)
+	f.approvals.On("Decide", mock.Anything, mock.Anything, mock.MatchedBy(func(d serviceDomain.BudgetDecision) bool {
+		return !d.Approved
+	})).Return(true, nil)
+
+	body, _ := json.Marshal(serviceHttp.BudgetResponseRequest{Approved: false})
+	rr := f.serve("POST", "/portal/budgets/"+order.ID.String()+"/response", body, user)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	if assert.NotNil(t, saved) {
+		f.approvals.AssertCalled(t, "Decide", mock.Anything, saved.ID, mock.Anything)
+	}
+}
+
+func TestPortalHandler_Invite(t *testing.T) {
+	f := setupPortalHandler()
+	client, _ := serviceDomain.NewClient("Maria", "529.982.247-25", "maria@example.com", "11999999999")
+	f.clients.On("GetByID", mock.Anything, client.ID).Return(client, nil)
+	f.users.On("Save", mock.Anything, mock.MatchedBy(func(u *identityDomain.User) bool {
+		return u.IsCustomer() && *u.ClientID == client.ID && u.Email == "maria@example.com"
+	})).Return(nil).Once()
+	f.users.On("GetByEmail", mock.Anything, "maria@example.com").Return(nil, identityDomain.ErrUserNotFound)
+
+	req := httptest.NewRequest("POST", "/admin/clients/"+client.ID.String()+"/portal-account", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", client.ID.String())
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rr := httptest.NewRecorder()
+	f.handler.Invite(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	f.users.AssertExpectations(t)
+
+	f.users.On("Save", mock.Anything, mock.Anything).Return(identityDomain.ErrClientAccountExists)
+	req = httptest.NewRequest("POST", "/admin/clients/"+client.ID.String()+"/portal-account", nil)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rr = httptest.NewRecorder()
+	f.handler.Invite(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
@@ -0,0 +1,139 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	inventoryApplication "github.com/noggrj/autorepair/internal/inventory/application"
+	inventoryDomain "github.com/noggrj/autorepair/internal/inventory/domain"
+	serviceHttp "github.com/noggrj/autorepair/internal/service/delivery/http"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupPurchaseOrderHandler() (*serviceHttp.PurchaseOrderHandler, *MockPurchaseOrderRepository, *MockSupplierRepository, *MockPartRepository) {
+	orders, suppliers, parts := new(MockPurchaseOrderRepository), new(MockSupplierRepository), new(MockPartRepository)
+	service := inventoryApplication.NewPurchaseOrderService(orders, suppliers, parts, newInventoryUnitOfWork(parts, orders))
+	return serviceHttp.NewPurchaseOrderHandler(orders, service), orders, suppliers, parts
+}
+
+func TestSupplierHandler_Create(t *testing.T) {
+	mockRepo := new(MockSupplierRepository)
+	handler := serviceHttp.NewSupplierHandler(mockRepo)
+
+	mockRepo.On("Save", mock.Anything, mock.AnythingOfType("*domain.Supplier")).Return(nil)
+
+	body, _ := json.Marshal(serviceHttp.CreateSupplierRequest{Name: "Auto Peças", Document: "11.222.333/0001-81"})
+	req, _ := http.NewRequest("POST", "/admin/suppliers", bytes.NewBuffer(body))
+	rr := httptest.NewRecorder()
+
+	handler.Create(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	// Invalid document
+	body, _ = json.Marshal(serviceHttp.CreateSupplierRequest{Name: "Auto Peças", Document: "123"})
+	req, _ = http.NewRequest("POST", "/admin/suppliers", bytes.NewBuffer(body))
+	rr = httptest.NewRecorder()
+
+	handler.Create(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	mockRepo.AssertNumberOfCalls(t, "Save", 1)
+}
+
+func TestSupplierHandler_Delete_InUse(t *testing.T) {
+	mockRepo := new(MockSupplierRepository)
+	handler := serviceHttp.NewSupplierHandler(mockRepo)
+
+	id := uuid.New()
+	mockRepo.On("Delete", mock.Anything, id).Return(inventoryDomain.ErrSupplierInUse)
+
+	req, _ := http.NewRequest("DELETE", "/admin/suppliers/"+id.String(), nil)
+	rr := httptest.NewRecorder()
+
+	handler.Delete(rr, withID(req, id))
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
+
+func TestPurchaseOrderHandler_Create(t *testing.T) {
+	handler, orders, suppliers, parts := setupPurchaseOrderHandler()
+
+	supplierID, partID := uuid.New(), uuid.New()
+	suppliers.On("GetByID", mock.Anything, supplierID).Return(&inventoryDomain.Supplier{ID: supplierID}, nil)
+	parts.On("GetByID", mock.Anything, partID).Return(&inventoryDomain.Part{ID: partID}, nil)
+	orders.On("Save", mock.Anything, mock.AnythingOfType("*domain.PurchaseOrder")).Return(nil)
+
+	body := []byte(`{"supplier_id":"` + supplierID.String() + `","lines":[{"part_id":"` + partID.String() + `","quantity":4,"unit_cost":12.50}]}`)
+	req, _ := http.NewRequest("POST", "/admin/purchase-orders", bytes.NewBuffer(body))
+	rr := httptest.NewRecorder()
+
+	handler.Create(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var resp serviceHttp.PurchaseOrderResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, "draft", resp.Status)
+	assert.Equal(t, int64(5000), resp.Total.Cents())
+}
+
+func TestPurchaseOrderHandler_ReceiveLine(t *testing.T) {
+	handler, orders, _, parts := setupPurchaseOrderHandler()
+
+	part := &inventoryDomain.Part{ID: uuid.New(), Quantity: 1}
+	po := inventoryDomain.NewPurchaseOrder(uuid.New())
+	line, _ := po.AddLine(part.ID, 5, sharedkernel.NewMoneyFromCents(1000))
+	_ = po.Send()
+
+	orders.On("GetByIDForUpdate", mock.Anything, po.ID).Return(po, nil)
+	parts.On("GetByIDForUpdate", mock.Anything, part.ID).Return(part, nil)
+	parts.On("Update", mock.Anything, part).Return(nil)
+	orders.On("Save", mock.Anything, po).Return(nil)
+
+	receive := func(qty int) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]interface{}{"quantity": qty})
+		req, _ := http.NewRequest("POST", "/admin/purchase-orders/"+po.ID.String()+"/lines/"+line.ID.String()+"/receive", bytes.NewBuffer(body))
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", po.ID.String())
+		rctx.URLParams.Add("lineID", line.ID.String())
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		rr := httptest.NewRecorder()
+		handler.ReceiveLine(rr, req)
+		return rr
+	}
+
+	rr := receive(3)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, 4, part.Quantity)
+	assert.Equal(t, int64(1000), part.CostPrice.Cents())
+	assert.Contains(t, rr.Body.String(), `"status":"partially_received"`)
+
+	// More than what is still expected
+	rr = receive(3)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	assert.Equal(t, 4, part.Quantity)
+}
+
+func TestPurchaseOrderHandler_Send_Empty(t *testing.T) {
+	handler, orders, _, _ := setupPurchaseOrderHandler()
+
+	po := inventoryDomain.NewPurchaseOrder(uuid.New())
+	orders.On("GetByIDForUpdate", mock.Anything, po.ID).Return(po, nil)
+
+	req, _ := http.NewRequest("POST", "/admin/purchase-orders/"+po.ID.String()+"/send", nil)
+	rr := httptest.NewRecorder()
+
+	handler.Send(rr, withID(req, po.ID))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	orders.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
@@ -9,6 +9,7 @@ import (
 
 	serviceHttp "github.com/noggrj/autorepair/internal/service/delivery/http"
 	serviceDomain "github.com/noggrj/autorepair/internal/service/domain"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -26,7 +27,7 @@ func TestServiceHandler_Create(t *testing.T) {
 	req, _ := http.NewRequest("POST", "/admin/services", bytes.NewBuffer(body))
 	rr := httptest.NewRecorder()
 
-	mockRepo.On("Save", mock.Anything).Return(nil)
+	mockRepo.On("Save", mock.Anything, mock.Anything).Return(nil)
 
 	handler.Create(rr, req)
 
@@ -41,7 +42,7 @@ func TestServiceHandler_List(t *testing.T) {
 	services := []*serviceDomain.Service{
 		{Name: "Oil Change"},
 	}
-	mockRepo.On("List").Return(services, nil)
+	mockRepo.On("List", mock.Anything, mock.Anything).Return(sharedkernel.Page[*serviceDomain.Service]{Items: services}, nil)
 
 	req, _ := http.NewRequest("GET", "/admin/services", nil)
 	rr := httptest.NewRecorder()
@@ -66,7 +67,7 @@ func TestServiceHandler_List_Error(t *testing.T) {
 	mockRepo := new(MockServiceRepository)
 	handler := serviceHttp.NewServiceHandler(mockRepo)
 
-	mockRepo.On("List").Return(nil, assert.AnError)
+	mockRepo.On("List", mock.Anything, mock.Anything).Return(nil, assert.AnError)
 
 	req, _ := http.NewRequest("GET", "/admin/services", nil)
 	rr := httptest.NewRecorder()
@@ -0,0 +1,272 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	identityApplication "github.com/noggrj/autorepair/internal/identity/application"
+	identityDomain "github.com/noggrj/autorepair/internal/identity/domain"
+	identityInfra "github.com/noggrj/autorepair/internal/identity/infrastructure"
+	"github.com/noggrj/autorepair/internal/platform/auth"
+	authMiddleware "github.com/noggrj/autorepair/internal/platform/middleware"
+	serviceHttp "github.com/noggrj/autorepair/internal/service/delivery/http"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// --- Helper ---
+
+func setupUserHandler() (*serviceHttp.UserHandler, *MockUserRepository, *MockRefreshTokenRepository) {
+	handler, users, tokens, _, _ := setupUserHandlerWithGuard()
+	return handler, users, tokens
+}
+
+func setupUserHandlerWithGuard() (*serviceHttp.UserHandler, *MockUserRepository, *MockRefreshTokenRepository, *identityInfra.MemoryLoginAttemptRepository, *identityInfra.MemoryAuditLog) {
+	users := new(MockUserRepository)
+	tokens := new(MockRefreshTokenRepository)
+	attempts := identityInfra.NewMemoryLoginAttemptRepository()
+	audit := identityInfra.NewMemoryAuditLog()
+	service := identityApplication.NewUserService(users, tokens)
+	guard := identityApplication.NewLoginGuard(attempts, audit)
+	return serviceHttp.NewUserHandler(users, service, guard), users, tokens, attempts, audit
+}
+
+// userRequest builds a request for the user with the given ID, made by the
+// admin whose ID is actor.
+func userRequest(method, id string, body []byte, actor uuid.UUID) *http.Request {
+	req, _ := http.NewRequest(method, "/admin/users/"+id, bytes.NewBuffer(body))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	ctx = context.WithValue(ctx, authMiddleware.UserContextKey, &auth.Claims{UserID: actor, Role: string(identityDomain.RoleAdmin)})
+	return req.WithContext(ctx)
+}
+
+func newTestUser(t *testing.T) *identityDomain.User {
+	user, err := identityDomain.NewUser("Maria", "maria@example.com", "password123", identityDomain.RoleEmployee)
+	assert.NoError(t, err)
+	return user
+}
+
+// --- Tests ---
+
+func TestUserHandler_List(t *testing.T) {
+	handler, users, _ := setupUserHandler()
+
+	user := newTestUser(t)
+	users.On("List", mock.Anything, mock.MatchedBy(func(q sharedkernel.ListQuery) bool {
+		return len(q.Status) == 1 && q.Status[0] == identityDomain.UserStatusDeactivated
+	})).Return(sharedkernel.Page[*identityDomain.User]{Items: []*identityDomain.User{user}}, nil)
+
+	req, _ := http.NewRequest("GET", "/admin/users?status=deactivated", nil)
+	rr := httptest.NewRecorder()
+
+	handler.List(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), user.Password)
+	users.AssertExpectations(t)
+}
+
+func TestUserHandler_List_InvalidStatus(t *testing.T) {
+	handler, _, _ := setupUserHandler()
+
+	req, _ := http.NewRequest("GET", "/admin/users?status=banned", nil)
+	rr := httptest.NewRecorder()
+
+	handler.List(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestUserHandler_Get_NotFound(t *testing.T) {
+	handler, users, _ := setupUserHandler()
+
+	id := uuid.New()
+	users.On("GetByID", mock.Anything, id).Return(nil, identityDomain.ErrUserNotFound)
+
+	rr := httptest.NewRecorder()
+	handler.Get(rr, userRequest("GET", id.String(), nil, uuid.New()))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestUserHandler_Deactivate(t *testing.T) {
+	handler, users, tokens := setupUserHandler()
+
+	user := newTestUser(t)
+	users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	users.On("Update", mock.Anything, user).Return(nil)
+	tokens.On("RevokeUser", mock.Anything, user.ID, mock.Anything).Return(nil)
+
+	rr := httptest.NewRecorder()
+	handler.Deactivate(rr, userRequest("POST", user.ID.String(), nil, uuid.New()))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp identityDomain.User
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.NotNil(t, resp.DeactivatedAt)
+	users.AssertExpectations(t)
+	tokens.AssertExpectations(t)
+}
+
+func TestUserHandler_Deactivate_Self(t *testing.T) {
+	handler, users, _ := setupUserHandler()
+
+	id := uuid.New()
+	rr := httptest.NewRecorder()
+	handler.Deactivate(rr, userRequest("POST", id.String(), nil, id))
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	users.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestUserHandler_Deactivate_InvalidID(t *testing.T) {
+	handler, _, _ := setupUserHandler()
+
+	rr := httptest.NewRecorder()
+	handler.Deactivate(rr, userRequest("POST", "not-a-uuid", nil, uuid.New()))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestUserHandler_Reactivate(t *testing.T) {
+	handler, users, tokens := setupUserHandler()
+
+	user := newTestUser(t)
+	user.Deactivate()
+	users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	users.On("Update", mock.Anything, user).Return(nil)
+
+	rr := httptest.NewRecorder()
+	handler.Reactivate(rr, userRequest("POST", user.ID.String(), nil, uuid.New()))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.True(t, user.IsActive())
+	tokens.AssertNotCalled(t, "RevokeUser", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUserHandler_ChangeRole(t *testing.T) {
+	handler, users, _ := setupUserHandler()
+
+	user := newTestUser(t)
+	users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	users.On("Update", mock.Anything, user).Return(nil)
+
+	body, _ := json.Marshal(serviceHttp.ChangeRoleRequest{Role: "manager"})
+	rr := httptest.NewRecorder()
+	handler.ChangeRole(rr, userRequest("PUT", user.ID.String(), body, uuid.New()))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, identityDomain.RoleManager, user.Role)
+}
+
+func TestUserHandler_ChangeRole_InvalidRole(t *testing.T) {
+	handler, users, _ := setupUserHandler()
+
+	user := newTestUser(t)
+	users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+
+	body, _ := json.Marshal(serviceHttp.ChangeRoleRequest{Role: "owner"})
+	rr := httptest.NewRecorder()
+	handler.ChangeRole(rr, userRequest("PUT", user.ID.String(), body, uuid.New()))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	users.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestUserHandler_ResetPassword(t *testing.T) {
+	handler, users, tokens := setupUserHandler()
+
+	user := newTestUser(t)
+	users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	users.On("Update", mock.Anything, user).Return(nil)
+	tokens.On("RevokeUser", mock.Anything, user.ID, mock.Anything).Return(nil)
+
+	body, _ := json.Marshal(serviceHttp.ResetPasswordRequest{Password: "newpassword1"})
+	rr := httptest.NewRecorder()
+	handler.ResetPassword(rr, userRequest("POST", user.ID.String(), body, uuid.New()))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.True(t, user.CheckPassword("newpassword1"))
+	tokens.AssertExpectations(t)
+}
+
+func TestUserHandler_ResetPassword_Weak(t *testing.T) {
+	handler, users, _ := setupUserHandler()
+
+	user := newTestUser(t)
+	users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+
+	body, _ := json.Marshal(serviceHttp.ResetPasswordRequest{Password: "short"})
+	rr := httptest.NewRecorder()
+	handler.ResetPassword(rr, userRequest("POST", user.ID.String(), body, uuid.New()))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	users.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestUserHandler_Unlock(t *testing.T) {
+	handler, users, _, attempts, audit := setupUserHandlerWithGuard()
+
+	user := newTestUser(t)
+	users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	ctx := context.Background()
+	_, _ = attempts.RecordFailure(ctx, identityDomain.LoginScopeEmail, user.Email, time.Now(), time.Hour)
+	_ = attempts.Lock(ctx, identityDomain.LoginScopeEmail, user.Email, time.Now().Add(time.Hour))
+
+	admin := uuid.New()
+	rr := httptest.NewRecorder()
+	handler.Unlock(rr, userRequest("POST", user.ID.String(), nil, admin))
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	a, _ := attempts.Get(ctx, identityDomain.LoginScopeEmail, user.Email)
+	assert.Nil(t, a.LockedUntil)
+	assert.Equal(t, 0, a.Failures)
+	if assert.Len(t, audit.Entries(), 1) {
+		entry := audit.Entries()[0]
+		assert.Equal(t, identityDomain.AuditLoginUnlocked, entry.Event)
+		assert.Equal(t, &admin, entry.ActorID)
+		assert.Equal(t, user.Email, entry.Subject)
+	}
+}
+
+func TestUserHandler_Unlock_NotFound(t *testing.T) {
+	handler, users, _, _, audit := setupUserHandlerWithGuard()
+
+	id := uuid.New()
+	users.On("GetByID", mock.Anything, id).Return(nil, identityDomain.ErrUserNotFound)
+
+	rr := httptest.NewRecorder()
+	handler.Unlock(rr, userRequest("POST", id.String(), nil, uuid.New()))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Empty(t, audit.Entries())
+}
+
+func TestUserHandler_ResetMFA(t *testing.T) {
+	handler, users, tokens := setupUserHandler()
+
+	user := newTestUser(t)
+	_ = user.StartMFAEnrolment("SECRET")
+	_ = user.EnableMFA()
+	users.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	users.On("Update", mock.Anything, user).Return(nil)
+	tokens.On("RevokeUser", mock.Anything, user.ID, mock.Anything).Return(nil)
+
+	rr := httptest.NewRecorder()
+	handler.ResetMFA(rr, userRequest("POST", user.ID.String(), nil, uuid.New()))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.False(t, user.MFAEnabled())
+	assert.NotContains(t, rr.Body.String(), "SECRET")
+	tokens.AssertExpectations(t)
+}
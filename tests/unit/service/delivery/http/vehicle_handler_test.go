@@ -2,21 +2,26 @@ package http_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	serviceApplication "github.com/noggrj/autorepair/internal/service/application"
 	serviceHttp "github.com/noggrj/autorepair/internal/service/delivery/http"
 	serviceDomain "github.com/noggrj/autorepair/internal/service/domain"
+	serviceInfra "github.com/noggrj/autorepair/internal/service/infrastructure"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
 func TestVehicleHandler_Create(t *testing.T) {
 	mockRepo := new(MockVehicleRepository)
-	handler := serviceHttp.NewVehicleHandler(mockRepo)
+	handler := serviceHttp.NewVehicleHandler(mockRepo, nil, nil, nil)
 
 	clientID := uuid.New()
 	reqBody := map[string]interface{}{
@@ -30,7 +35,7 @@ func TestVehicleHandler_Create(t *testing.T) {
 	req, _ := http.NewRequest("POST", "/admin/vehicles", bytes.NewBuffer(body))
 	rr := httptest.NewRecorder()
 
-	mockRepo.On("Save", mock.Anything).Return(nil)
+	mockRepo.On("Save", mock.Anything, mock.Anything).Return(nil)
 
 	handler.Create(rr, req)
 
@@ -40,13 +45,13 @@ func TestVehicleHandler_Create(t *testing.T) {
 
 func TestVehicleHandler_ListByClient(t *testing.T) {
 	mockRepo := new(MockVehicleRepository)
-	handler := serviceHttp.NewVehicleHandler(mockRepo)
+	handler := serviceHttp.NewVehicleHandler(mockRepo, nil, nil, nil)
 
 	clientID := uuid.New()
 	vehicles := []*serviceDomain.Vehicle{
 		{Brand: "Toyota"},
 	}
-	mockRepo.On("ListByClientID", clientID).Return(vehicles, nil)
+	mockRepo.On("ListByClientID", mock.Anything, clientID).Return(vehicles, nil)
 
 	req, _ := http.NewRequest("GET", "/admin/vehicles?client_id="+clientID.String(), nil)
 	rr := httptest.NewRecorder()
@@ -57,7 +62,7 @@ func TestVehicleHandler_ListByClient(t *testing.T) {
 }
 
 func TestVehicleHandler_Create_InvalidJSON(t *testing.T) {
-	handler := serviceHttp.NewVehicleHandler(nil)
+	handler := serviceHttp.NewVehicleHandler(nil, nil, nil, nil)
 
 	req, _ := http.NewRequest("POST", "/admin/vehicles", bytes.NewBuffer([]byte("{invalid")))
 	rr := httptest.NewRecorder()
@@ -69,7 +74,7 @@ func TestVehicleHandler_Create_InvalidJSON(t *testing.T) {
 
 func TestVehicleHandler_Create_DomainError(t *testing.T) {
 	mockRepo := new(MockVehicleRepository)
-	handler := serviceHttp.NewVehicleHandler(mockRepo)
+	handler := serviceHttp.NewVehicleHandler(mockRepo, nil, nil, nil)
 
 	clientID := uuid.New()
 	reqBody := map[string]interface{}{
@@ -90,7 +95,7 @@ func TestVehicleHandler_Create_DomainError(t *testing.T) {
 
 func TestVehicleHandler_Create_RepoError(t *testing.T) {
 	mockRepo := new(MockVehicleRepository)
-	handler := serviceHttp.NewVehicleHandler(mockRepo)
+	handler := serviceHttp.NewVehicleHandler(mockRepo, nil, nil, nil)
 
 	clientID := uuid.New()
 	reqBody := map[string]interface{}{
@@ -104,7 +109,7 @@ func TestVehicleHandler_Create_RepoError(t *testing.T) {
 	req, _ := http.NewRequest("POST", "/admin/vehicles", bytes.NewBuffer(body))
 	rr := httptest.NewRecorder()
 
-	mockRepo.On("Save", mock.Anything).Return(assert.AnError)
+	mockRepo.On("Save", mock.Anything, mock.Anything).Return(assert.AnError)
 
 	handler.Create(rr, req)
 
@@ -112,12 +117,92 @@ func TestVehicleHandler_Create_RepoError(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestVehicleHandler_Create_PlateExists(t *testing.T) {
+	mockRepo := new(MockVehicleRepository)
+	handler := serviceHttp.NewVehicleHandler(mockRepo, nil, nil, nil)
+
+	reqBody := map[string]interface{}{
+		"client_id": uuid.New().String(),
+		"plate":     "ABC1C34",
+		"brand":     "Toyota",
+		"model":     "Corolla",
+		"year":      2020,
+	}
+	body, _ := json.Marshal(reqBody)
+	req, _ := http.NewRequest("POST", "/admin/vehicles", bytes.NewBuffer(body))
+	rr := httptest.NewRecorder()
+
+	mockRepo.On("Save", mock.Anything, mock.Anything).Return(serviceDomain.ErrVehiclePlateExists)
+
+	handler.Create(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestVehicleHandler_Create_VIN(t *testing.T) {
+	mockRepo := new(MockVehicleRepository)
+	specs := serviceInfra.NewTableVehicleSpecProvider(map[string]serviceDomain.VehicleSpec{
+		"9BWAB45": {Brand: "Volkswagen", Model: "Gol"},
+	})
+	handler := serviceHttp.NewVehicleHandler(mockRepo, nil, nil, serviceApplication.NewVehicleService(nil, nil, specs))
+
+	create := func(reqBody map[string]interface{}) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(reqBody)
+		req, _ := http.NewRequest("POST", "/admin/vehicles", bytes.NewBuffer(body))
+		rr := httptest.NewRecorder()
+		handler.Create(rr, req)
+		return rr
+	}
+
+	// Brand, model and year come from the VIN
+	mockRepo.On("Save", mock.Anything, mock.Anything).Return(nil).Once()
+	rr := create(map[string]interface{}{
+		"client_id": uuid.New().String(),
+		"plate":     "ABC-1234",
+		"vin":       "9bwab45z3r4012345",
+	})
+	assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var resp map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, "9BWAB45Z3R4012345", resp["VIN"])
+	assert.Equal(t, "Volkswagen", resp["Brand"])
+	assert.Equal(t, "Gol", resp["Model"])
+	assert.Equal(t, float64(2024), resp["Year"])
+
+	// Invalid check digit on a North American VIN
+	rr = create(map[string]interface{}{
+		"client_id": uuid.New().String(),
+		"plate":     "ABC-1234",
+		"vin":       "1M8GDM9A1KP042788",
+	})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// Unknown VIN and no brand: nothing to prefill from
+	rr = create(map[string]interface{}{
+		"client_id": uuid.New().String(),
+		"plate":     "ABC-1234",
+		"vin":       "1M8GDM9AXKP042788",
+	})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// VIN taken by another vehicle
+	mockRepo.On("Save", mock.Anything, mock.Anything).Return(serviceDomain.ErrVehicleVINExists).Once()
+	rr = create(map[string]interface{}{
+		"client_id": uuid.New().String(),
+		"plate":     "ABC-1234",
+		"vin":       "9BWAB45Z3R4012345",
+	})
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestVehicleHandler_ListByClient_RepoError(t *testing.T) {
 	mockRepo := new(MockVehicleRepository)
-	handler := serviceHttp.NewVehicleHandler(mockRepo)
+	handler := serviceHttp.NewVehicleHandler(mockRepo, nil, nil, nil)
 
 	clientID := uuid.New()
-	mockRepo.On("ListByClientID", clientID).Return(nil, assert.AnError)
+	mockRepo.On("ListByClientID", mock.Anything, clientID).Return(nil, assert.AnError)
 
 	req, _ := http.NewRequest("GET", "/admin/vehicles?client_id="+clientID.String(), nil)
 	rr := httptest.NewRecorder()
@@ -129,7 +214,7 @@ func TestVehicleHandler_ListByClient_RepoError(t *testing.T) {
 }
 
 func TestVehicleHandler_Create_InvalidUUID(t *testing.T) {
-	handler := serviceHttp.NewVehicleHandler(nil)
+	handler := serviceHttp.NewVehicleHandler(nil, nil, nil, nil)
 
 	reqBody := map[string]interface{}{
 		"client_id": "invalid-uuid",
@@ -144,7 +229,7 @@ func TestVehicleHandler_Create_InvalidUUID(t *testing.T) {
 }
 
 func TestVehicleHandler_ListByClient_InvalidUUID(t *testing.T) {
-	handler := serviceHttp.NewVehicleHandler(nil)
+	handler := serviceHttp.NewVehicleHandler(nil, nil, nil, nil)
 
 	req, _ := http.NewRequest("GET", "/admin/vehicles?client_id=invalid", nil)
 	rr := httptest.NewRecorder()
@@ -155,7 +240,7 @@ func TestVehicleHandler_ListByClient_InvalidUUID(t *testing.T) {
 }
 
 func TestVehicleHandler_ListByClient_MissingClientID(t *testing.T) {
-	handler := serviceHttp.NewVehicleHandler(nil)
+	handler := serviceHttp.NewVehicleHandler(nil, nil, nil, nil)
 
 	req, _ := http.NewRequest("GET", "/admin/vehicles", nil)
 	rr := httptest.NewRecorder()
@@ -164,3 +249,212 @@ func TestVehicleHandler_ListByClient_MissingClientID(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, rr.Code)
 }
+
+func withURLParam(req *http.Request, key, value string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add(key, value)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestVehicleHandler_GetByPlate(t *testing.T) {
+	mockRepo := new(MockVehicleRepository)
+	handler := serviceHttp.NewVehicleHandler(mockRepo, nil, nil, nil)
+
+	plate, _ := sharedkernel.NewPlacaBR("ABC1D23")
+	vehicle := &serviceDomain.Vehicle{ID: uuid.New(), Plate: plate, Brand: "Toyota"}
+	mockRepo.On("GetByPlate", mock.Anything, plate).Return(vehicle, nil)
+
+	// Lower case and hyphen are normalized away
+	req, _ := http.NewRequest("GET", "/admin/vehicles/by-plate/abc-1d23", nil)
+	rr := httptest.NewRecorder()
+
+	handler.GetByPlate(rr, withURLParam(req, "plate", "abc-1d23"))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, "ABC1D23", resp["Plate"])
+	mockRepo.AssertExpectations(t)
+}
+
+func TestVehicleHandler_GetByPlate_EitherForm(t *testing.T) {
+	mockRepo := new(MockVehicleRepository)
+	handler := serviceHttp.NewVehicleHandler(mockRepo, nil, nil, nil)
+
+	// Registered after the plate was converted to Mercosul
+	registered, _ := sharedkernel.NewPlacaBR("ABC1C34")
+	vehicle := &serviceDomain.Vehicle{ID: uuid.New(), Plate: registered}
+	mockRepo.On("GetByPlate", mock.Anything, mock.MatchedBy(func(p sharedkernel.PlacaBR) bool {
+		return p.Equivalent(registered)
+	})).Return(vehicle, nil)
+
+	for _, typed := range []string{"ABC-1234", "abc1c34"} {
+		req, _ := http.NewRequest("GET", "/admin/vehicles/by-plate/"+typed, nil)
+		rr := httptest.NewRecorder()
+		handler.GetByPlate(rr, withURLParam(req, "plate", typed))
+
+		assert.Equal(t, http.StatusOK, rr.Code, typed)
+		var resp map[string]interface{}
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, vehicle.ID.String(), resp["ID"])
+	}
+}
+
+func TestVehicleHandler_GetByPlate_Errors(t *testing.T) {
+	mockRepo := new(MockVehicleRepository)
+	handler := serviceHttp.NewVehicleHandler(mockRepo, nil, nil, nil)
+
+	req, _ := http.NewRequest("GET", "/admin/vehicles/by-plate/12345", nil)
+	rr := httptest.NewRecorder()
+	handler.GetByPlate(rr, withURLParam(req, "plate", "12345"))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	mockRepo.On("GetByPlate", mock.Anything, mock.Anything).Return(nil, serviceDomain.ErrVehicleNotFound)
+	req, _ = http.NewRequest("GET", "/admin/vehicles/by-plate/ABC1234", nil)
+	rr = httptest.NewRecorder()
+	handler.GetByPlate(rr, withURLParam(req, "plate", "ABC1234"))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestVehicleHandler_History(t *testing.T) {
+	mockRepo := new(MockVehicleRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	odometer := &fakeOdometerRepository{}
+	ownerships := &fakeVehicleOwnershipRepository{}
+	handler := serviceHttp.NewVehicleHandler(mockRepo, mockOrderRepo, odometer, serviceApplication.NewVehicleService(ownerships, nil, nil))
+
+	vehicleID := uuid.New()
+	previousOwner := uuid.New()
+	currentOwner := uuid.New()
+	vehicle := &serviceDomain.Vehicle{ID: vehicleID, ClientID: currentOwner}
+	orders := []*serviceDomain.Order{
+		{ID: uuid.New(), ClientID: previousOwner, VehicleID: vehicleID, Status: serviceDomain.OrderStatusDelivered,
+			Items: []*serviceDomain.OrderItem{{ID: uuid.New(), Name: "Oil change", Quantity: 1}}},
+		{ID: uuid.New(), ClientID: currentOwner, VehicleID: vehicleID, Status: serviceDomain.OrderStatusReceived},
+	}
+	mockRepo.On("GetByID", mock.Anything, vehicleID).Return(vehicle, nil)
+	mockOrderRepo.On("ListByVehicleID", mock.Anything, vehicleID).Return(orders, nil)
+	reception, _ := serviceDomain.NewOdometerReading(vehicleID, orders[0].ID, 41000, serviceDomain.OdometerSourceReception)
+	delivery, _ := serviceDomain.NewOdometerReading(vehicleID, orders[0].ID, 41012, serviceDomain.OdometerSourceDelivery)
+	assert.NoError(t, odometer.Record(context.Background(), reception))
+	assert.NoError(t, odometer.Record(context.Background(), delivery))
+	ownerships.ownerships = []*serviceDomain.VehicleOwnership{
+		{ID: uuid.New(), VehicleID: vehicleID, ClientID: previousOwner},
+	}
+
+	req, _ := http.NewRequest("GET", "/admin/vehicles/"+vehicleID.String()+"/history", nil)
+	rr := httptest.NewRecorder()
+
+	handler.History(rr, withURLParam(req, "id", vehicleID.String()))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp struct {
+		Vehicle    map[string]interface{}   `json:"vehicle"`
+		Orders     []map[string]interface{} `json:"orders"`
+		Readings   []map[string]interface{} `json:"readings"`
+		PastOwners []map[string]interface{} `json:"past_owners"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, vehicleID.String(), resp.Vehicle["ID"])
+	if assert.Len(t, resp.Orders, 2) {
+		assert.Equal(t, previousOwner.String(), resp.Orders[0]["ClientID"])
+		assert.Len(t, resp.Orders[0]["Items"], 1)
+	}
+	if assert.Len(t, resp.Readings, 2) {
+		assert.Equal(t, "reception", resp.Readings[0]["Source"])
+		assert.Equal(t, float64(41012), resp.Readings[1]["Mileage"])
+	}
+	if assert.Len(t, resp.PastOwners, 1) {
+		assert.Equal(t, previousOwner.String(), resp.PastOwners[0]["ClientID"])
+	}
+}
+
+func TestVehicleHandler_History_NotFound(t *testing.T) {
+	mockRepo := new(MockVehicleRepository)
+	handler := serviceHttp.NewVehicleHandler(mockRepo, new(MockOrderRepository), &fakeOdometerRepository{}, nil)
+
+	vehicleID := uuid.New()
+	mockRepo.On("GetByID", mock.Anything, vehicleID).Return(nil, serviceDomain.ErrVehicleNotFound)
+
+	req, _ := http.NewRequest("GET", "/admin/vehicles/"+vehicleID.String()+"/history", nil)
+	rr := httptest.NewRecorder()
+
+	handler.History(rr, withURLParam(req, "id", vehicleID.String()))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestVehicleHandler_Update(t *testing.T) {
+	clientID := uuid.New()
+	newVehicle := func() *serviceDomain.Vehicle {
+		v, _ := serviceDomain.NewVehicle(clientID, "ABC1234", "Ford", "Fiesta", 2019)
+		return v
+	}
+
+	tests := []struct {
+		name       string
+		body       map[string]interface{}
+		wantStatus int
+	}{
+		{"updates details", map[string]interface{}{"plate": "abc-1d23", "year": 2020}, http.StatusOK},
+		{"same owner", map[string]interface{}{"client_id": clientID.String(), "brand": "Fiat"}, http.StatusOK},
+		{"invalid plate", map[string]interface{}{"plate": "12345"}, http.StatusBadRequest},
+		{"invalid year", map[string]interface{}{"year": 1800}, http.StatusBadRequest},
+		{"invalid client ID", map[string]interface{}{"client_id": "invalid-uuid"}, http.StatusBadRequest},
+		{"owner change", map[string]interface{}{"client_id": uuid.New().String()}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockVehicleRepository)
+			handler := serviceHttp.NewVehicleHandler(mockRepo, nil, nil, nil)
+			vehicle := newVehicle()
+			mockRepo.On("GetByID", mock.Anything, vehicle.ID).Return(vehicle, nil)
+			mockRepo.On("Save", mock.Anything, vehicle).Return(nil)
+
+			body, _ := json.Marshal(tt.body)
+			req, _ := http.NewRequest("PUT", "/admin/vehicles/"+vehicle.ID.String(), bytes.NewBuffer(body))
+			rr := httptest.NewRecorder()
+
+			handler.Update(rr, withURLParam(req, "id", vehicle.ID.String()))
+
+			assert.Equal(t, tt.wantStatus, rr.Code, rr.Body.String())
+			assert.Equal(t, clientID, vehicle.ClientID)
+			if tt.wantStatus != http.StatusOK {
+				mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestVehicleHandler_Transfer(t *testing.T) {
+	previousOwner := uuid.New()
+	newOwner := uuid.New()
+	vehicle, _ := serviceDomain.NewVehicle(previousOwner, "ABC1234", "Ford", "Fiesta", 2019)
+	ownerships := &fakeVehicleOwnershipRepository{vehicles: map[uuid.UUID]*serviceDomain.Vehicle{vehicle.ID: vehicle}}
+	clientRepo := new(MockClientRepository)
+	clientRepo.On("GetByID", mock.Anything, newOwner).Return(&serviceDomain.Client{ID: newOwner}, nil)
+	clientRepo.On("GetByID", mock.Anything, mock.Anything).Return(nil, serviceDomain.ErrClientNotFound)
+	handler := serviceHttp.NewVehicleHandler(nil, nil, nil, serviceApplication.NewVehicleService(ownerships, clientRepo, nil))
+
+	transfer := func(vehicleID, clientID string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"client_id": clientID})
+		req, _ := http.NewRequest("POST", "/admin/vehicles/"+vehicleID+"/transfer", bytes.NewBuffer(body))
+		rr := httptest.NewRecorder()
+		handler.Transfer(rr, withURLParam(req, "id", vehicleID))
+		return rr
+	}
+
+	assert.Equal(t, http.StatusBadRequest, transfer(vehicle.ID.String(), "invalid-uuid").Code)
+	assert.Equal(t, http.StatusBadRequest, transfer(vehicle.ID.String(), uuid.New().String()).Code)
+	assert.Equal(t, http.StatusNotFound, transfer(uuid.New().String(), newOwner.String()).Code)
+
+	rr := transfer(vehicle.ID.String(), newOwner.String())
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, newOwner, vehicle.ClientID)
+	if assert.Len(t, ownerships.ownerships, 1) {
+		assert.Equal(t, previousOwner, ownerships.ownerships[0].ClientID)
+		assert.Equal(t, vehicle.CreatedAt, ownerships.ownerships[0].StartedAt)
+	}
+
+	assert.Equal(t, http.StatusConflict, transfer(vehicle.ID.String(), newOwner.String()).Code)
+}
@@ -2,9 +2,11 @@ package domain_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/noggrj/autorepair/internal/service/domain"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -51,16 +53,16 @@ func TestNewVehicle(t *testing.T) {
 
 func TestNewService(t *testing.T) {
 	// Valid
-	s, err := domain.NewService("Oil Change", "Desc", 100.0)
+	s, err := domain.NewService("Oil Change", "Desc", sharedkernel.NewMoneyFromFloat(100.0))
 	assert.NoError(t, err)
 	assert.NotNil(t, s)
 
 	// Invalid Price
-	_, err = domain.NewService("Oil Change", "Desc", -10.0)
+	_, err = domain.NewService("Oil Change", "Desc", sharedkernel.NewMoneyFromFloat(-10.0))
 	assert.Error(t, err)
 
 	// Invalid Name
-	_, err = domain.NewService("", "Desc", 100.0)
+	_, err = domain.NewService("", "Desc", sharedkernel.NewMoneyFromFloat(100.0))
 	assert.Error(t, err)
 }
 
@@ -69,31 +71,101 @@ func TestNewOrder(t *testing.T) {
 	vehicleID := uuid.New()
 
 	// Valid
-	o, err := domain.NewOrder(clientID, vehicleID)
+	o, err := domain.NewOrder(clientID, vehicleID, 0)
 	assert.NoError(t, err)
 	assert.NotNil(t, o)
 
 	// Invalid IDs
-	_, err = domain.NewOrder(uuid.Nil, vehicleID)
+	_, err = domain.NewOrder(uuid.Nil, vehicleID, 0)
 	assert.Error(t, err)
 
-	_, err = domain.NewOrder(clientID, uuid.Nil)
+	_, err = domain.NewOrder(clientID, uuid.Nil, 0)
 	assert.Error(t, err)
 }
 
 func TestOrder_AddItem(t *testing.T) {
-	o, _ := domain.NewOrder(uuid.New(), uuid.New())
+	o, _ := domain.NewOrder(uuid.New(), uuid.New(), 0)
 	refID := uuid.New()
 
 	// Valid
-	err := o.AddItem(refID, domain.ItemTypeService, "Service", 1, 100.0)
+	err := o.AddItem(refID, domain.ItemTypeService, "Service", 1, sharedkernel.NewMoneyFromFloat(100.0), sharedkernel.Money{})
 	assert.NoError(t, err)
 
 	// Invalid Qty
-	err = o.AddItem(refID, domain.ItemTypeService, "Service", 0, 100.0)
+	err = o.AddItem(refID, domain.ItemTypeService, "Service", 0, sharedkernel.NewMoneyFromFloat(100.0), sharedkernel.Money{})
 	assert.Error(t, err)
 
 	// Invalid Price
-	err = o.AddItem(refID, domain.ItemTypeService, "Service", 1, -10.0)
+	err = o.AddItem(refID, domain.ItemTypeService, "Service", 1, sharedkernel.NewMoneyFromFloat(-10.0), sharedkernel.Money{})
 	assert.Error(t, err)
 }
+
+func TestOrder_CalculateTotal_ExactCents(t *testing.T) {
+	o, _ := domain.NewOrder(uuid.New(), uuid.New(), 0)
+
+	_ = o.AddItem(uuid.New(), domain.ItemTypePart, "Filter", 3, sharedkernel.NewMoneyFromFloat(33.33), sharedkernel.Money{})
+	_ = o.AddItem(uuid.New(), domain.ItemTypeService, "Labor", 1, sharedkernel.NewMoneyFromFloat(0.01), sharedkernel.Money{})
+	_ = o.AddItem(uuid.New(), domain.ItemTypePart, "Bolt", 1000, sharedkernel.NewMoneyFromFloat(0.1), sharedkernel.Money{})
+
+	assert.Equal(t, int64(19999), o.TotalParts.Cents())
+	assert.Equal(t, int64(1), o.TotalService.Cents())
+	assert.Equal(t, "200.00", o.Total.String())
+}
+
+func TestOrder_GrossMargin(t *testing.T) {
+	o, _ := domain.NewOrder(uuid.New(), uuid.New(), 0)
+
+	_ = o.AddItem(uuid.New(), domain.ItemTypePart, "Filter", 2, sharedkernel.NewMoneyFromFloat(50.0), sharedkernel.NewMoneyFromFloat(30.0))
+	_ = o.AddItem(uuid.New(), domain.ItemTypeService, "Labor", 1, sharedkernel.NewMoneyFromFloat(80.0), sharedkernel.Money{})
+
+	assert.Equal(t, "30.00", o.Items[0].UnitCost.String())
+	assert.Equal(t, "60.00", o.TotalCost.String())
+
+	margin, err := o.GrossMargin()
+	assert.NoError(t, err)
+	assert.Equal(t, "120.00", margin.String())
+
+	// Negative unit cost is rejected
+	err = o.AddItem(uuid.New(), domain.ItemTypePart, "Bolt", 1, sharedkernel.NewMoneyFromFloat(1.0), sharedkernel.NewMoneyFromFloat(-1.0))
+	assert.Error(t, err)
+}
+
+func TestOrder_RemoveItem(t *testing.T) {
+	o, _ := domain.NewOrder(uuid.New(), uuid.New(), 0)
+	_ = o.AddItem(uuid.New(), domain.ItemTypePart, "Filter", 2, sharedkernel.NewMoneyFromFloat(50.0), sharedkernel.NewMoneyFromFloat(30.0))
+	_ = o.AddItem(uuid.New(), domain.ItemTypeService, "Labor", 1, sharedkernel.NewMoneyFromFloat(80.0), sharedkernel.Money{})
+	filter := o.Items[0]
+
+	removed, err := o.RemoveItem(filter.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, filter, removed)
+	assert.Len(t, o.Items, 1)
+	assert.Equal(t, "0.00", o.TotalParts.String())
+	assert.Equal(t, "80.00", o.Total.String())
+	assert.Equal(t, "0.00", o.TotalCost.String())
+
+	_, err = o.RemoveItem(filter.ID)
+	assert.ErrorIs(t, err, domain.ErrOrderItemNotFound)
+
+	o.Status = domain.OrderStatusCompleted
+	_, err = o.RemoveItem(o.Items[0].ID)
+	assert.ErrorIs(t, err, domain.ErrOrderClosed)
+	assert.Len(t, o.Items, 1)
+}
+
+func TestBudgetApproval_Usable(t *testing.T) {
+	a := domain.NewBudgetApproval(uuid.New())
+	now := time.Now()
+
+	assert.True(t, a.Usable(now))
+	assert.Equal(t, domain.BudgetApprovalTTL, a.ExpiresAt.Sub(a.CreatedAt))
+	assert.False(t, a.Usable(a.ExpiresAt))
+
+	revoked := *a
+	revoked.RevokedAt = &now
+	assert.False(t, revoked.Usable(now))
+
+	decided := *a
+	decided.Decision = &domain.BudgetDecision{Approved: true, DecidedAt: now}
+	assert.False(t, decided.Usable(now))
+}
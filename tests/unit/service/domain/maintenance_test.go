@@ -0,0 +1,87 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/service/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewMaintenancePlan(t *testing.T) {
+	serviceID := uuid.New()
+
+	p, err := domain.NewMaintenancePlan(serviceID, 10000, 12)
+	assert.NoError(t, err)
+	assert.Equal(t, serviceID, p.ServiceID)
+
+	// One interval is enough
+	_, err = domain.NewMaintenancePlan(serviceID, 0, 6)
+	assert.NoError(t, err)
+	_, err = domain.NewMaintenancePlan(serviceID, 5000, 0)
+	assert.NoError(t, err)
+
+	// Neither, or a negative one
+	_, err = domain.NewMaintenancePlan(serviceID, 0, 0)
+	assert.ErrorIs(t, err, domain.ErrInvalidMaintenancePlan)
+	_, err = domain.NewMaintenancePlan(serviceID, -1, 12)
+	assert.ErrorIs(t, err, domain.ErrInvalidMaintenancePlan)
+
+	_, err = domain.NewMaintenancePlan(uuid.Nil, 10000, 12)
+	assert.Error(t, err)
+}
+
+func TestMaintenancePlan_Next(t *testing.T) {
+	doneAt := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	mileage := 40000
+	plan := &domain.MaintenancePlan{IntervalKm: 10000, IntervalMonths: 12}
+
+	dueMileage, dueAt := plan.Next(&mileage, doneAt)
+	if assert.NotNil(t, dueMileage) && assert.NotNil(t, dueAt) {
+		assert.Equal(t, 50000, *dueMileage)
+		assert.Equal(t, time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC), *dueAt)
+	}
+
+	// Unknown mileage: only the date is checked
+	dueMileage, dueAt = plan.Next(nil, doneAt)
+	assert.Nil(t, dueMileage)
+	assert.NotNil(t, dueAt)
+
+	// Mileage-only plan
+	dueMileage, dueAt = (&domain.MaintenancePlan{IntervalKm: 10000}).Next(&mileage, doneAt)
+	assert.NotNil(t, dueMileage)
+	assert.Nil(t, dueAt)
+}
+
+func TestMaintenanceCandidate_Reminder(t *testing.T) {
+	doneAt := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	mileage := 40000
+	candidate := func(current int) *domain.MaintenanceCandidate {
+		return &domain.MaintenanceCandidate{
+			Plan:           &domain.MaintenancePlan{ID: uuid.New(), IntervalKm: 10000, IntervalMonths: 12},
+			VehicleID:      uuid.New(),
+			ClientID:       uuid.New(),
+			CurrentMileage: current,
+			OrderID:        uuid.New(),
+			DoneMileage:    &mileage,
+			DoneAt:         doneAt,
+		}
+	}
+
+	// Neither interval reached
+	assert.Nil(t, candidate(45000).Reminder(doneAt.AddDate(0, 6, 0)))
+
+	// Due by mileage first
+	c := candidate(50000)
+	r := c.Reminder(doneAt.AddDate(0, 6, 0))
+	if assert.NotNil(t, r) {
+		assert.Equal(t, c.Plan.ID, r.PlanID)
+		assert.Equal(t, c.OrderID, r.OrderID)
+		assert.Equal(t, c.ClientID, r.ClientID)
+		assert.Equal(t, 50000, *r.DueMileage)
+	}
+
+	// Due by date with little mileage
+	assert.NotNil(t, candidate(41000).Reminder(doneAt.AddDate(1, 0, 0)))
+}
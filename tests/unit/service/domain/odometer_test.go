@@ -0,0 +1,68 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/service/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewOrder_Mileage(t *testing.T) {
+	o, err := domain.NewOrder(uuid.New(), uuid.New(), 41000)
+	assert.NoError(t, err)
+	if assert.NotNil(t, o.ReceptionMileage) {
+		assert.Equal(t, 41000, *o.ReceptionMileage)
+	}
+	assert.Nil(t, o.DeliveryMileage)
+
+	reading, err := o.ReceptionReading()
+	assert.NoError(t, err)
+	assert.Equal(t, o.ID, reading.OrderID)
+	assert.Equal(t, o.VehicleID, reading.VehicleID)
+	assert.Equal(t, domain.OdometerSourceReception, reading.Source)
+
+	_, err = domain.NewOrder(uuid.New(), uuid.New(), -1)
+	assert.ErrorIs(t, err, domain.ErrInvalidMileage)
+}
+
+func TestOrder_RecordDeliveryMileage(t *testing.T) {
+	o, _ := domain.NewOrder(uuid.New(), uuid.New(), 41000)
+
+	// Below reception
+	_, err := o.RecordDeliveryMileage(40999)
+	assert.ErrorIs(t, err, domain.ErrMileageDecreased)
+	assert.Nil(t, o.DeliveryMileage)
+
+	// Negative
+	_, err = o.RecordDeliveryMileage(-5)
+	assert.ErrorIs(t, err, domain.ErrInvalidMileage)
+
+	// Valid
+	reading, err := o.RecordDeliveryMileage(41012)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.OdometerSourceDelivery, reading.Source)
+	assert.Equal(t, 41012, reading.Mileage)
+	if assert.NotNil(t, o.DeliveryMileage) {
+		assert.Equal(t, 41012, *o.DeliveryMileage)
+	}
+
+	// Orders opened before mileage was recorded take any delivery reading
+	legacy := &domain.Order{ID: uuid.New(), VehicleID: uuid.New()}
+	_, err = legacy.ReceptionReading()
+	assert.ErrorIs(t, err, domain.ErrInvalidMileage)
+	_, err = legacy.RecordDeliveryMileage(100)
+	assert.NoError(t, err)
+}
+
+func TestOdometerReading_Follows(t *testing.T) {
+	reading, err := domain.NewOdometerReading(uuid.New(), uuid.New(), 1000, domain.OdometerSourceReception)
+	assert.NoError(t, err)
+
+	assert.NoError(t, reading.Follows(999))
+	assert.NoError(t, reading.Follows(1000))
+	assert.ErrorIs(t, reading.Follows(1001), domain.ErrMileageDecreased)
+
+	_, err = domain.NewOdometerReading(uuid.New(), uuid.New(), -1, domain.OdometerSourceDelivery)
+	assert.ErrorIs(t, err, domain.ErrInvalidMileage)
+}
@@ -0,0 +1,95 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/service/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOrderStatus(t *testing.T) {
+	status, err := domain.ParseOrderStatus("In execution")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.OrderStatusInExecution, status)
+
+	_, err = domain.ParseOrderStatus("in_execution")
+	assert.ErrorIs(t, err, domain.ErrInvalidOrderStatus)
+}
+
+func TestOrder_Transition_HappyPath(t *testing.T) {
+	o, _ := domain.NewOrder(uuid.New(), uuid.New(), 0)
+
+	steps := []domain.OrderStatus{
+		domain.OrderStatusInDiagnosis,
+		domain.OrderStatusAwaitingApproval,
+		domain.OrderStatusInExecution,
+		domain.OrderStatusCompleted,
+		domain.OrderStatusDelivered,
+	}
+	for _, to := range steps {
+		assert.NoError(t, o.Transition(to, "tester", ""))
+		assert.Equal(t, to, o.Status)
+	}
+
+	assert.NotNil(t, o.StartedAt)
+	assert.NotNil(t, o.FinishedAt)
+
+	history := o.PendingTransitions()
+	assert.Len(t, history, len(steps))
+	assert.Equal(t, domain.OrderStatusReceived, history[0].From)
+	assert.Equal(t, domain.OrderStatusInDiagnosis, history[0].To)
+	assert.Equal(t, "tester", history[0].Actor)
+	assert.Equal(t, o.ID, history[0].OrderID)
+
+	o.ClearPendingTransitions()
+	assert.Empty(t, o.PendingTransitions())
+}
+
+func TestOrder_Transition_Rejected(t *testing.T) {
+	o, _ := domain.NewOrder(uuid.New(), uuid.New(), 0)
+
+	// Skipping steps is not allowed
+	err := o.Transition(domain.OrderStatusCompleted, "tester", "")
+	assert.ErrorIs(t, err, domain.ErrInvalidTransition)
+	assert.Equal(t, domain.OrderStatusReceived, o.Status)
+
+	// Unknown statuses are rejected
+	err = o.Transition(domain.OrderStatus("Teleported"), "tester", "")
+	assert.ErrorIs(t, err, domain.ErrInvalidOrderStatus)
+
+	// Delivered is terminal
+	o.Status = domain.OrderStatusDelivered
+	err = o.Transition(domain.OrderStatusReceived, "tester", "")
+	assert.ErrorIs(t, err, domain.ErrInvalidTransition)
+
+	assert.Empty(t, o.PendingTransitions())
+}
+
+func TestOrder_Transition_BudgetRejectionRecordsReason(t *testing.T) {
+	o, _ := domain.NewOrder(uuid.New(), uuid.New(), 0)
+	o.Status = domain.OrderStatusAwaitingApproval
+
+	err := o.Transition(domain.OrderStatusReceived, "client", "too expensive")
+	assert.NoError(t, err)
+	assert.Equal(t, "too expensive", o.PendingTransitions()[0].Reason)
+}
+
+func TestOrderStatus_Cancelled(t *testing.T) {
+	for _, from := range []domain.OrderStatus{
+		domain.OrderStatusReceived,
+		domain.OrderStatusInDiagnosis,
+		domain.OrderStatusAwaitingApproval,
+		domain.OrderStatusInExecution,
+	} {
+		assert.True(t, from.CanTransitionTo(domain.OrderStatusCancelled), from)
+	}
+
+	// Finished work cannot be cancelled, and Cancelled is terminal
+	assert.False(t, domain.OrderStatusCompleted.CanTransitionTo(domain.OrderStatusCancelled))
+	assert.False(t, domain.OrderStatusDelivered.CanTransitionTo(domain.OrderStatusCancelled))
+	assert.False(t, domain.OrderStatusCancelled.CanTransitionTo(domain.OrderStatusReceived))
+
+	assert.True(t, domain.OrderStatusInExecution.HoldsStock())
+	assert.False(t, domain.OrderStatusAwaitingApproval.HoldsStock())
+}
@@ -0,0 +1,50 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/noggrj/autorepair/internal/service/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVehicle_TransferTo(t *testing.T) {
+	previousOwner := uuid.New()
+	v, _ := domain.NewVehicle(previousOwner, "ABC1234", "Ford", "Fiesta", 2020)
+	since := time.Now().Add(-24 * time.Hour)
+
+	newOwner := uuid.New()
+	ownership, err := v.TransferTo(newOwner, since)
+	assert.NoError(t, err)
+	assert.Equal(t, newOwner, v.ClientID)
+	assert.Equal(t, v.ID, ownership.VehicleID)
+	assert.Equal(t, previousOwner, ownership.ClientID)
+	assert.Equal(t, since, ownership.StartedAt)
+	assert.Equal(t, v.UpdatedAt, ownership.EndedAt)
+
+	_, err = v.TransferTo(newOwner, since)
+	assert.ErrorIs(t, err, domain.ErrSameOwner)
+
+	_, err = v.TransferTo(uuid.Nil, since)
+	assert.Error(t, err)
+	assert.Equal(t, newOwner, v.ClientID)
+}
+
+func TestVehicle_Update(t *testing.T) {
+	clientID := uuid.New()
+	v, _ := domain.NewVehicle(clientID, "ABC1234", "Ford", "Fiesta", 2019)
+
+	assert.NoError(t, v.Update("abc-1d23", "", "Ka", 0))
+	assert.Equal(t, "ABC1D23", v.Plate.String())
+	assert.Equal(t, "Ford", v.Brand)
+	assert.Equal(t, "Ka", v.Model)
+	assert.Equal(t, 2019, v.Year)
+	assert.Equal(t, clientID, v.ClientID)
+
+	// Invalid values are refused and nothing changes
+	assert.Error(t, v.Update("12345", "Fiat", "", 0))
+	assert.ErrorIs(t, v.Update("", "Fiat", "", 1800), domain.ErrInvalidYear)
+	assert.Equal(t, "ABC1D23", v.Plate.String())
+	assert.Equal(t, "Ford", v.Brand)
+}
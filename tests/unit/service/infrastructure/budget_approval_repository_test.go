@@ -0,0 +1,195 @@
+package infrastructure_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/noggrj/autorepair/internal/service/domain"
+	"github.com/noggrj/autorepair/internal/service/infrastructure"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+var budgetApprovalColumns = []string{"id", "order_id", "expires_at", "created_at", "revoked_at", "decided_at", "approved", "ip", "user_agent"}
+
+func TestPostgresBudgetApprovalRepository_Save(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresBudgetApprovalRepository(mock)
+	approval := domain.NewBudgetApproval(uuid.New())
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO budget_approvals`)).
+		WithArgs(approval.ID, approval.OrderID, approval.ExpiresAt, approval.CreatedAt).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	err = repo.Save(context.Background(), approval)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresBudgetApprovalRepository_GetByID(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresBudgetApprovalRepository(mock)
+	id := uuid.New()
+	orderID := uuid.New()
+	now := time.Now()
+	approved := true
+	ip := "203.0.113.7"
+	userAgent := "test-agent"
+
+	t.Run("Decided", func(t *testing.T) {
+		rows := pgxmock.NewRows(budgetApprovalColumns).
+			AddRow(id, orderID, now.Add(time.Hour), now, nil, &now, &approved, &ip, &userAgent)
+		mock.ExpectQuery(regexp.QuoteMeta(`FROM budget_approvals WHERE id = $1`)).
+			WithArgs(id).
+			WillReturnRows(rows)
+
+		approval, err := repo.GetByID(context.Background(), id)
+		assert.NoError(t, err)
+		assert.Equal(t, orderID, approval.OrderID)
+		if assert.NotNil(t, approval.Decision) {
+			assert.True(t, approval.Decision.Approved)
+			assert.Equal(t, ip, approval.Decision.IP)
+			assert.Equal(t, userAgent, approval.Decision.UserAgent)
+		}
+	})
+
+	t.Run("Open", func(t *testing.T) {
+		rows := pgxmock.NewRows(budgetApprovalColumns).
+			AddRow(id, orderID, now.Add(time.Hour), now, nil, nil, nil, nil, nil)
+		mock.ExpectQuery(regexp.QuoteMeta(`FROM budget_approvals WHERE id = $1`)).
+			WithArgs(id).
+			WillReturnRows(rows)
+
+		approval, err := repo.GetByID(context.Background(), id)
+		assert.NoError(t, err)
+		assert.Nil(t, approval.Decision)
+		assert.True(t, approval.Usable(now))
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`FROM budget_approvals WHERE id = $1`)).
+			WithArgs(id).
+			WillReturnError(pgx.ErrNoRows)
+
+		_, err := repo.GetByID(context.Background(), id)
+		assert.ErrorIs(t, err, domain.ErrBudgetApprovalNotFound)
+	})
+}
+
+func TestPostgresBudgetApprovalRepository_ListByOrderID(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresBudgetApprovalRepository(mock)
+	orderID := uuid.New()
+	now := time.Now()
+
+	rows := pgxmock.NewRows(budgetApprovalColumns).
+		AddRow(uuid.New(), orderID, now.Add(time.Hour), now.Add(-time.Hour), &now, nil, nil, nil, nil).
+		AddRow(uuid.New(), orderID, now.Add(2*time.Hour), now, nil, nil, nil, nil, nil)
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM budget_approvals WHERE order_id = $1 ORDER BY created_at`)).
+		WithArgs(orderID).
+		WillReturnRows(rows)
+
+	approvals, err := repo.ListByOrderID(context.Background(), orderID)
+	assert.NoError(t, err)
+	assert.Len(t, approvals, 2)
+	assert.NotNil(t, approvals[0].RevokedAt)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM budget_approvals`)).
+		WillReturnError(errors.New("db error"))
+	_, err = repo.ListByOrderID(context.Background(), orderID)
+	assert.Error(t, err)
+}
+
+func TestPostgresBudgetApprovalRepository_RevokeOrder(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresBudgetApprovalRepository(mock)
+	orderID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE budget_approvals SET revoked_at = $2 WHERE order_id = $1 AND revoked_at IS NULL AND decided_at IS NULL`)).
+		WithArgs(orderID, now).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+
+	assert.NoError(t, repo.RevokeOrder(context.Background(), orderID, now))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresBudgetApprovalRepository_Decide(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresBudgetApprovalRepository(mock)
+	id := uuid.New()
+	decision := domain.BudgetDecision{Approved: true, IP: "203.0.113.7", UserAgent: "test-agent", DecidedAt: time.Now()}
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE budget_approvals SET decided_at = $2`)).
+		WithArgs(id, decision.DecidedAt, decision.Approved, decision.IP, decision.UserAgent).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	decided, err := repo.Decide(context.Background(), id, decision)
+	assert.NoError(t, err)
+	assert.True(t, decided)
+
+	// A second answer finds the link already decided
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE budget_approvals SET decided_at = $2`)).
+		WithArgs(id, decision.DecidedAt, decision.Approved, decision.IP, decision.UserAgent).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	decided, err = repo.Decide(context.Background(), id, decision)
+	assert.NoError(t, err)
+	assert.False(t, decided)
+}
+
+func TestPostgresBudgetApprovalRepository_DecideOrder(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresBudgetApprovalRepository(mock)
+	orderID := uuid.New()
+	decision := domain.BudgetDecision{Approved: false, IP: "203.0.113.7", UserAgent: "test-agent", DecidedAt: time.Now()}
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE budget_approvals SET decided_at = $2`)).
+		WithArgs(orderID, decision.DecidedAt, decision.Approved, decision.IP, decision.UserAgent).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	decided, err := repo.DecideOrder(context.Background(), orderID, decision)
+	assert.NoError(t, err)
+	assert.True(t, decided)
+
+	// No unanswered link left for the order
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE budget_approvals SET decided_at = $2`)).
+		WithArgs(orderID, decision.DecidedAt, decision.Approved, decision.IP, decision.UserAgent).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	decided, err = repo.DecideOrder(context.Background(), orderID, decision)
+	assert.NoError(t, err)
+	assert.False(t, decided)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
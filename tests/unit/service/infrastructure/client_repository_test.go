@@ -1,6 +1,7 @@
 package infrastructure_test
 
 import (
+	"context"
 	"errors"
 	"regexp"
 	"testing"
@@ -10,6 +11,7 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/noggrj/autorepair/internal/service/domain"
 	"github.com/noggrj/autorepair/internal/service/infrastructure"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
 	"github.com/pashagolub/pgxmock/v4"
 	"github.com/stretchr/testify/assert"
 )
@@ -29,14 +31,14 @@ func TestPostgresClientRepository_Save(t *testing.T) {
 		WithArgs(client.ID, client.Name, client.Document.String(), client.Email, client.Phone, client.CreatedAt, client.UpdatedAt).
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
-	err = repo.Save(client)
+	err = repo.Save(context.Background(), client)
 	assert.NoError(t, err)
 
 	// Error
 	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO clients`)).
 		WillReturnError(errors.New("db error"))
 
-	err = repo.Save(client)
+	err = repo.Save(context.Background(), client)
 	assert.Error(t, err)
 }
 
@@ -59,7 +61,7 @@ func TestPostgresClientRepository_GetByID(t *testing.T) {
 		WithArgs(id).
 		WillReturnRows(rows)
 
-	client, err := repo.GetByID(id)
+	client, err := repo.GetByID(context.Background(), id)
 	assert.NoError(t, err)
 	assert.NotNil(t, client)
 	assert.Equal(t, id, client.ID)
@@ -69,7 +71,7 @@ func TestPostgresClientRepository_GetByID(t *testing.T) {
 		WithArgs(id).
 		WillReturnError(pgx.ErrNoRows)
 
-	_, err = repo.GetByID(id)
+	_, err = repo.GetByID(context.Background(), id)
 	assert.Error(t, err)
 	assert.Equal(t, domain.ErrClientNotFound, err)
 
@@ -77,7 +79,7 @@ func TestPostgresClientRepository_GetByID(t *testing.T) {
 	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id`)).
 		WillReturnError(errors.New("connection error"))
 
-	_, err = repo.GetByID(id)
+	_, err = repo.GetByID(context.Background(), id)
 	assert.Error(t, err)
 
 	// Scan Error (Invalid Document format in DB)
@@ -91,7 +93,7 @@ func TestPostgresClientRepository_GetByID(t *testing.T) {
 		WithArgs(id).
 		WillReturnRows(rowsScanErr)
 
-	_, err = repo.GetByID(id)
+	_, err = repo.GetByID(context.Background(), id)
 	assert.Error(t, err)
 }
 
@@ -113,15 +115,16 @@ func TestPostgresClientRepository_List(t *testing.T) {
 	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, document, email, phone, created_at, updated_at FROM clients`)).
 		WillReturnRows(rows)
 
-	clients, err := repo.List()
+	page, err := repo.List(context.Background(), sharedkernel.ListQuery{})
 	assert.NoError(t, err)
-	assert.Len(t, clients, 2)
+	assert.Len(t, page.Items, 2)
+	assert.Empty(t, page.NextCursor)
 
 	// Query Error
 	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id`)).
 		WillReturnError(errors.New("db error"))
 
-	_, err = repo.List()
+	_, err = repo.List(context.Background(), sharedkernel.ListQuery{})
 	assert.Error(t, err)
 
 	// Scan Error
@@ -131,6 +134,6 @@ func TestPostgresClientRepository_List(t *testing.T) {
 	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id`)).
 		WillReturnRows(rowsScanErr)
 
-	_, err = repo.List()
+	_, err = repo.List(context.Background(), sharedkernel.ListQuery{})
 	assert.Error(t, err)
 }
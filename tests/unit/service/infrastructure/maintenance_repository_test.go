@@ -0,0 +1,161 @@
+package infrastructure_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/noggrj/autorepair/internal/service/domain"
+	"github.com/noggrj/autorepair/internal/service/infrastructure"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresMaintenancePlanRepository_Save(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresMaintenancePlanRepository(mock)
+	plan, _ := domain.NewMaintenancePlan(uuid.New(), 10000, 12)
+
+	// Success
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO maintenance_plans`)).
+		WithArgs(plan.ID, plan.ServiceID, 10000, 12, plan.CreatedAt, plan.UpdatedAt).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	assert.NoError(t, repo.Save(context.Background(), plan))
+
+	// The service already has a plan
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO maintenance_plans`)).
+		WithArgs(plan.ID, plan.ServiceID, 10000, 12, plan.CreatedAt, plan.UpdatedAt).
+		WillReturnError(&pgconn.PgError{Code: "23505"})
+
+	assert.ErrorIs(t, repo.Save(context.Background(), plan), domain.ErrMaintenancePlanExists)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresMaintenancePlanRepository_GetListDelete(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresMaintenancePlanRepository(mock)
+	id := uuid.New()
+	serviceID := uuid.New()
+	now := time.Now()
+	columns := []string{"id", "service_id", "interval_km", "interval_months", "created_at", "updated_at"}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM maintenance_plans WHERE id = $1`)).
+		WithArgs(id).
+		WillReturnRows(pgxmock.NewRows(columns).AddRow(id, serviceID, 10000, 12, now, now))
+	plan, err := repo.GetByID(context.Background(), id)
+	assert.NoError(t, err)
+	assert.Equal(t, serviceID, plan.ServiceID)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM maintenance_plans WHERE id = $1`)).
+		WithArgs(id).
+		WillReturnError(pgx.ErrNoRows)
+	_, err = repo.GetByID(context.Background(), id)
+	assert.ErrorIs(t, err, domain.ErrMaintenancePlanNotFound)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM maintenance_plans ORDER BY created_at`)).
+		WillReturnRows(pgxmock.NewRows(columns).AddRow(id, serviceID, 0, 6, now, now))
+	plans, err := repo.List(context.Background())
+	assert.NoError(t, err)
+	if assert.Len(t, plans, 1) {
+		assert.Equal(t, 6, plans[0].IntervalMonths)
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM maintenance_plans WHERE id = $1`)).
+		WithArgs(id).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	assert.NoError(t, repo.Delete(context.Background(), id))
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM maintenance_plans WHERE id = $1`)).
+		WithArgs(id).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	assert.ErrorIs(t, repo.Delete(context.Background(), id), domain.ErrMaintenancePlanNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresMaintenanceReminderRepository_ListCandidates(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresMaintenanceReminderRepository(mock)
+	planID, serviceID, vehicleID, clientID, orderID := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	now := time.Now()
+	doneMileage := 40000
+
+	// Success: only completed or delivered orders with the service count
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT DISTINCT ON (p.id, v.id)`)).
+		WithArgs("service", "Completed", "Delivered").
+		WillReturnRows(pgxmock.NewRows([]string{"plan_id", "service_id", "interval_km", "interval_months", "created_at", "updated_at",
+			"name", "vehicle_id", "plate", "client_id", "mileage", "order_id", "done_mileage", "done_at"}).
+			AddRow(planID, serviceID, 10000, 12, now, now, "Oil change", vehicleID, "ABC1D23", clientID, 50500, orderID, &doneMileage, now))
+
+	candidates, err := repo.ListCandidates(context.Background())
+	assert.NoError(t, err)
+	if assert.Len(t, candidates, 1) {
+		c := candidates[0]
+		assert.Equal(t, planID, c.Plan.ID)
+		assert.Equal(t, "Oil change", c.ServiceName)
+		assert.Equal(t, clientID, c.ClientID)
+		assert.Equal(t, 50500, c.CurrentMileage)
+		assert.Equal(t, 40000, *c.DoneMileage)
+	}
+
+	// DB Error
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM maintenance_plans`)).
+		WithArgs("service", "Completed", "Delivered").
+		WillReturnError(errors.New("db error"))
+	_, err = repo.ListCandidates(context.Background())
+	assert.Error(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresMaintenanceReminderRepository_Claim(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresMaintenanceReminderRepository(mock)
+	dueMileage := 50000
+	reminder := &domain.MaintenanceReminder{ID: uuid.New(), PlanID: uuid.New(), VehicleID: uuid.New(), OrderID: uuid.New(),
+		ClientID: uuid.New(), DueMileage: &dueMileage, CreatedAt: time.Now()}
+
+	// First claim wins
+	mock.ExpectExec(regexp.QuoteMeta(`ON CONFLICT (plan_id, order_id) DO NOTHING`)).
+		WithArgs(reminder.ID, reminder.PlanID, reminder.VehicleID, reminder.OrderID, reminder.ClientID, reminder.DueMileage, reminder.DueAt, reminder.CreatedAt).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	claimed, err := repo.Claim(context.Background(), reminder)
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+
+	// Already sent
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO maintenance_reminders`)).
+		WithArgs(reminder.ID, reminder.PlanID, reminder.VehicleID, reminder.OrderID, reminder.ClientID, reminder.DueMileage, reminder.DueAt, reminder.CreatedAt).
+		WillReturnResult(pgxmock.NewResult("INSERT", 0))
+	claimed, err = repo.Claim(context.Background(), reminder)
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
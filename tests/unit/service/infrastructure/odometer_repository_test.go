@@ -0,0 +1,101 @@
+package infrastructure_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/noggrj/autorepair/internal/service/domain"
+	"github.com/noggrj/autorepair/internal/service/infrastructure"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresOdometerRepository_Record(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresOdometerRepository(mock)
+	vehicleID := uuid.New()
+	reading, _ := domain.NewOdometerReading(vehicleID, uuid.New(), 41000, domain.OdometerSourceReception)
+
+	// Success: the reading is stored and the vehicle's mileage advanced
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT mileage FROM vehicles WHERE id = $1 FOR UPDATE`)).
+		WithArgs(vehicleID).
+		WillReturnRows(pgxmock.NewRows([]string{"mileage"}).AddRow(40000))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO odometer_readings`)).
+		WithArgs(reading.ID, vehicleID, reading.OrderID, 41000, "reception", reading.RecordedAt).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE vehicles SET mileage = $2 WHERE id = $1`)).
+		WithArgs(vehicleID, 41000).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectCommit()
+
+	assert.NoError(t, repo.Record(context.Background(), reading))
+
+	// Below the last reading: nothing is written
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT mileage FROM vehicles`)).
+		WithArgs(vehicleID).
+		WillReturnRows(pgxmock.NewRows([]string{"mileage"}).AddRow(41001))
+	mock.ExpectRollback()
+
+	err = repo.Record(context.Background(), reading)
+	assert.ErrorIs(t, err, domain.ErrMileageDecreased)
+
+	// Unknown vehicle
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT mileage FROM vehicles`)).
+		WithArgs(vehicleID).
+		WillReturnError(pgx.ErrNoRows)
+	mock.ExpectRollback()
+
+	err = repo.Record(context.Background(), reading)
+	assert.ErrorIs(t, err, domain.ErrVehicleNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresOdometerRepository_ListByVehicleID(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresOdometerRepository(mock)
+	vehicleID := uuid.New()
+	orderID := uuid.New()
+	now := time.Now()
+
+	// Success
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM odometer_readings WHERE vehicle_id = $1 ORDER BY recorded_at`)).
+		WithArgs(vehicleID).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "vehicle_id", "order_id", "mileage", "source", "recorded_at"}).
+			AddRow(uuid.New(), vehicleID, orderID, 41000, "reception", now.Add(-time.Hour)).
+			AddRow(uuid.New(), vehicleID, orderID, 41012, "delivery", now))
+
+	readings, err := repo.ListByVehicleID(context.Background(), vehicleID)
+	assert.NoError(t, err)
+	if assert.Len(t, readings, 2) {
+		assert.Equal(t, domain.OdometerSourceReception, readings[0].Source)
+		assert.Equal(t, 41012, readings[1].Mileage)
+	}
+
+	// DB Error
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM odometer_readings`)).
+		WithArgs(vehicleID).
+		WillReturnError(errors.New("db error"))
+
+	_, err = repo.ListByVehicleID(context.Background(), vehicleID)
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
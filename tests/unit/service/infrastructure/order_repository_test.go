@@ -1,6 +1,7 @@
 package infrastructure_test
 
 import (
+	"context"
 	"errors"
 	"regexp"
 	"testing"
@@ -8,8 +9,10 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/noggrj/autorepair/internal/service/domain"
 	"github.com/noggrj/autorepair/internal/service/infrastructure"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
 	"github.com/pashagolub/pgxmock/v4"
 	"github.com/stretchr/testify/assert"
 )
@@ -22,24 +25,24 @@ func TestPostgresOrderRepository_Save(t *testing.T) {
 	defer mock.Close()
 
 	repo := infrastructure.NewPostgresOrderRepository(mock)
-	order, _ := domain.NewOrder(uuid.New(), uuid.New())
+	order, _ := domain.NewOrder(uuid.New(), uuid.New(), 0)
 
 	// Success
 	mock.ExpectBegin()
 	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO orders`)).
-		WithArgs(order.ID, order.ClientID, order.VehicleID, order.Status, float64(order.TotalService), float64(order.TotalParts), float64(order.Total), order.CreatedAt, order.UpdatedAt, order.StartedAt, order.FinishedAt).
+		WithArgs(order.ID, order.ClientID, order.VehicleID, order.Status, order.TotalService, order.TotalParts, order.Total, order.TotalCost, order.CreatedAt, order.UpdatedAt, order.StartedAt, order.FinishedAt, order.ReceptionMileage, order.DeliveryMileage).
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM order_items`)).
 		WithArgs(order.ID).
 		WillReturnResult(pgxmock.NewResult("DELETE", 0))
 	mock.ExpectCommit()
 
-	err = repo.Save(order)
+	err = repo.Save(context.Background(), order)
 	assert.NoError(t, err)
 
 	// Begin Error
 	mock.ExpectBegin().WillReturnError(errors.New("tx error"))
-	err = repo.Save(order)
+	err = repo.Save(context.Background(), order)
 	assert.Error(t, err)
 
 	// Exec Error (Order)
@@ -48,7 +51,7 @@ func TestPostgresOrderRepository_Save(t *testing.T) {
 		WillReturnError(errors.New("db error"))
 	mock.ExpectRollback()
 
-	err = repo.Save(order)
+	err = repo.Save(context.Background(), order)
 	assert.Error(t, err)
 
 	// Exec Error (Delete Items)
@@ -59,10 +62,37 @@ func TestPostgresOrderRepository_Save(t *testing.T) {
 		WillReturnError(errors.New("delete error"))
 	mock.ExpectRollback()
 
-	err = repo.Save(order)
+	err = repo.Save(context.Background(), order)
 	assert.Error(t, err)
 }
 
+func TestPostgresOrderRepository_Save_UnknownReference(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresOrderRepository(mock)
+	order, _ := domain.NewOrder(uuid.New(), uuid.New(), 0)
+	args := []interface{}{order.ID, order.ClientID, order.VehicleID, order.Status, order.TotalService, order.TotalParts, order.Total, order.TotalCost, order.CreatedAt, order.UpdatedAt, order.StartedAt, order.FinishedAt, order.ReceptionMileage, order.DeliveryMileage}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO orders`)).
+		WithArgs(args...).
+		WillReturnError(&pgconn.PgError{Code: "23503", ConstraintName: "orders_vehicle_id_fkey"})
+	mock.ExpectRollback()
+	assert.ErrorIs(t, repo.Save(context.Background(), order), domain.ErrVehicleNotFound)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO orders`)).
+		WithArgs(args...).
+		WillReturnError(&pgconn.PgError{Code: "23503", ConstraintName: "orders_client_id_fkey"})
+	mock.ExpectRollback()
+	assert.ErrorIs(t, repo.Save(context.Background(), order), domain.ErrClientNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestPostgresOrderRepository_Save_WithItems(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
@@ -71,40 +101,40 @@ func TestPostgresOrderRepository_Save_WithItems(t *testing.T) {
 	defer mock.Close()
 
 	repo := infrastructure.NewPostgresOrderRepository(mock)
-	order, _ := domain.NewOrder(uuid.New(), uuid.New())
-	_ = order.AddItem(uuid.New(), domain.ItemTypeService, "S1", 1, 100.0)
+	order, _ := domain.NewOrder(uuid.New(), uuid.New(), 0)
+	_ = order.AddItem(uuid.New(), domain.ItemTypeService, "S1", 1, sharedkernel.NewMoneyFromFloat(100.0), sharedkernel.Money{})
 
 	// Success
 	mock.ExpectBegin()
 	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO orders`)).
-		WithArgs(order.ID, order.ClientID, order.VehicleID, order.Status, float64(order.TotalService), float64(order.TotalParts), float64(order.Total), order.CreatedAt, order.UpdatedAt, order.StartedAt, order.FinishedAt).
+		WithArgs(order.ID, order.ClientID, order.VehicleID, order.Status, order.TotalService, order.TotalParts, order.Total, order.TotalCost, order.CreatedAt, order.UpdatedAt, order.StartedAt, order.FinishedAt, order.ReceptionMileage, order.DeliveryMileage).
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM order_items`)).
 		WithArgs(order.ID).
 		WillReturnResult(pgxmock.NewResult("DELETE", 0))
 	item := order.Items[0]
 	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO order_items`)).
-		WithArgs(item.ID, item.OrderID, item.RefID, item.Type, item.Name, item.Quantity, float64(item.UnitPrice), float64(item.Total)).
+		WithArgs(item.ID, item.OrderID, item.RefID, item.Type, item.Name, item.Quantity, item.UnitPrice, item.UnitCost, item.Total).
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 	mock.ExpectCommit()
 
-	err = repo.Save(order)
+	err = repo.Save(context.Background(), order)
 	assert.NoError(t, err)
 
 	// Item Error
 	mock.ExpectBegin()
 	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO orders`)).
-		WithArgs(order.ID, order.ClientID, order.VehicleID, order.Status, float64(order.TotalService), float64(order.TotalParts), float64(order.Total), order.CreatedAt, order.UpdatedAt, order.StartedAt, order.FinishedAt).
+		WithArgs(order.ID, order.ClientID, order.VehicleID, order.Status, order.TotalService, order.TotalParts, order.Total, order.TotalCost, order.CreatedAt, order.UpdatedAt, order.StartedAt, order.FinishedAt, order.ReceptionMileage, order.DeliveryMileage).
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM order_items`)).
 		WithArgs(order.ID).
 		WillReturnResult(pgxmock.NewResult("DELETE", 0))
 	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO order_items`)).
-		WithArgs(item.ID, item.OrderID, item.RefID, item.Type, item.Name, item.Quantity, float64(item.UnitPrice), float64(item.Total)).
+		WithArgs(item.ID, item.OrderID, item.RefID, item.Type, item.Name, item.Quantity, item.UnitPrice, item.UnitCost, item.Total).
 		WillReturnError(errors.New("item error"))
 	mock.ExpectRollback()
 
-	err = repo.Save(order)
+	err = repo.Save(context.Background(), order)
 	assert.Error(t, err)
 }
 
@@ -121,33 +151,39 @@ func TestPostgresOrderRepository_GetByID(t *testing.T) {
 	vehicleID := uuid.New()
 	now := time.Now()
 
+	receptionMileage := 41000
+
 	// Success
-	rows := pgxmock.NewRows([]string{"id", "client_id", "vehicle_id", "status", "total_service", "total_parts", "total", "created_at", "updated_at", "started_at", "finished_at"}).
-		AddRow(id, clientID, vehicleID, "Received", 100.0, 0.0, 100.0, now, now, nil, nil)
+	rows := pgxmock.NewRows([]string{"id", "client_id", "vehicle_id", "status", "total_service", "total_parts", "total", "total_cost", "created_at", "updated_at", "started_at", "finished_at", "reception_mileage", "delivery_mileage"}).
+		AddRow(id, clientID, vehicleID, "Received", 100.0, 0.0, 100.0, 0.0, now, now, nil, nil, &receptionMileage, nil)
 
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, client_id, vehicle_id, status, total_service, total_parts, total, created_at, updated_at, started_at, finished_at FROM orders WHERE id = $1`)).
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, client_id, vehicle_id, status, total_service, total_parts, total, total_cost, created_at, updated_at, started_at, finished_at, reception_mileage, delivery_mileage FROM orders WHERE id = $1`)).
 		WithArgs(id).
 		WillReturnRows(rows)
 
-	itemRows := pgxmock.NewRows([]string{"id", "order_id", "ref_id", "type", "name", "quantity", "unit_price", "total"}).
-		AddRow(uuid.New(), id, uuid.New(), "service", "S1", 1, 100.0, 100.0)
+	itemRows := pgxmock.NewRows([]string{"id", "order_id", "ref_id", "type", "name", "quantity", "unit_price", "unit_cost", "total"}).
+		AddRow(uuid.New(), id, uuid.New(), "service", "S1", 1, 100.0, 0.0, 100.0)
 
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, order_id, ref_id, type, name, quantity, unit_price, total FROM order_items WHERE order_id = $1`)).
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, order_id, ref_id, type, name, quantity, unit_price, unit_cost, total FROM order_items WHERE order_id = $1`)).
 		WithArgs(id).
 		WillReturnRows(itemRows)
 
-	order, err := repo.GetByID(id)
+	order, err := repo.GetByID(context.Background(), id)
 	assert.NoError(t, err)
 	assert.NotNil(t, order)
 	assert.Equal(t, id, order.ID)
 	assert.Len(t, order.Items, 1)
+	if assert.NotNil(t, order.ReceptionMileage) {
+		assert.Equal(t, 41000, *order.ReceptionMileage)
+	}
+	assert.Nil(t, order.DeliveryMileage)
 
 	// Not Found
 	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id`)).
 		WithArgs(id).
 		WillReturnError(pgx.ErrNoRows)
 
-	_, err = repo.GetByID(id)
+	_, err = repo.GetByID(context.Background(), id)
 	assert.Error(t, err)
 	assert.Equal(t, domain.ErrOrderNotFound, err)
 
@@ -155,7 +191,7 @@ func TestPostgresOrderRepository_GetByID(t *testing.T) {
 	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id`)).
 		WillReturnError(errors.New("connection error"))
 
-	_, err = repo.GetByID(id)
+	_, err = repo.GetByID(context.Background(), id)
 	assert.Error(t, err)
 
 	// Items DB Error
@@ -167,7 +203,7 @@ func TestPostgresOrderRepository_GetByID(t *testing.T) {
 		WithArgs(id).
 		WillReturnError(errors.New("items error"))
 
-	_, err = repo.GetByID(id)
+	_, err = repo.GetByID(context.Background(), id)
 	assert.Error(t, err)
 
 	// Items Scan Error
@@ -175,14 +211,14 @@ func TestPostgresOrderRepository_GetByID(t *testing.T) {
 		WithArgs(id).
 		WillReturnRows(rows)
 
-	itemRowsScanErr := pgxmock.NewRows([]string{"id", "order_id", "ref_id", "type", "name", "quantity", "unit_price", "total"}).
-		AddRow(uuid.New(), id, uuid.New(), "service", "S1", "invalid-qty", 100.0, 100.0)
+	itemRowsScanErr := pgxmock.NewRows([]string{"id", "order_id", "ref_id", "type", "name", "quantity", "unit_price", "unit_cost", "total"}).
+		AddRow(uuid.New(), id, uuid.New(), "service", "S1", "invalid-qty", 100.0, 0.0, 100.0)
 
 	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id`)).
 		WithArgs(id).
 		WillReturnRows(itemRowsScanErr)
 
-	_, err = repo.GetByID(id)
+	_, err = repo.GetByID(context.Background(), id)
 	assert.Error(t, err)
 }
 
@@ -197,30 +233,217 @@ func TestPostgresOrderRepository_List(t *testing.T) {
 	now := time.Now()
 
 	// Success
-	rows := pgxmock.NewRows([]string{"id", "client_id", "vehicle_id", "status", "total_service", "total_parts", "total", "created_at", "updated_at", "started_at", "finished_at"}).
-		AddRow(uuid.New(), uuid.New(), uuid.New(), "Received", 100.0, 0.0, 100.0, now, now, nil, nil)
+	rows := pgxmock.NewRows([]string{"id", "client_id", "vehicle_id", "status", "total_service", "total_parts", "total", "total_cost", "created_at", "updated_at", "started_at", "finished_at", "reception_mileage", "delivery_mileage"}).
+		AddRow(uuid.New(), uuid.New(), uuid.New(), "Received", 100.0, 0.0, 100.0, 0.0, now, now, nil, nil, nil, nil)
 
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, client_id, vehicle_id, status, total_service, total_parts, total, created_at, updated_at, started_at, finished_at FROM orders`)).
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, client_id, vehicle_id, status, total_service, total_parts, total, total_cost, created_at, updated_at, started_at, finished_at, reception_mileage, delivery_mileage FROM orders`)).
 		WillReturnRows(rows)
 
-	orders, err := repo.List()
+	page, err := repo.List(context.Background(), sharedkernel.ListQuery{})
 	assert.NoError(t, err)
-	assert.Len(t, orders, 1)
+	assert.Len(t, page.Items, 1)
 
 	// DB Error
 	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id`)).
 		WillReturnError(errors.New("db error"))
 
-	_, err = repo.List()
+	_, err = repo.List(context.Background(), sharedkernel.ListQuery{})
 	assert.Error(t, err)
 
 	// Scan Error
-	rowsScanErr := pgxmock.NewRows([]string{"id", "client_id", "vehicle_id", "status", "total_service", "total_parts", "total", "created_at", "updated_at", "started_at", "finished_at"}).
-		AddRow(uuid.New(), uuid.New(), uuid.New(), "Received", "invalid-total", 0.0, 100.0, now, now, nil, nil)
+	rowsScanErr := pgxmock.NewRows([]string{"id", "client_id", "vehicle_id", "status", "total_service", "total_parts", "total", "total_cost", "created_at", "updated_at", "started_at", "finished_at", "reception_mileage", "delivery_mileage"}).
+		AddRow(uuid.New(), uuid.New(), uuid.New(), "Received", "invalid-total", 0.0, 100.0, 0.0, now, now, nil, nil, nil, nil)
 
 	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id`)).
 		WillReturnRows(rowsScanErr)
 
-	_, err = repo.List()
+	_, err = repo.List(context.Background(), sharedkernel.ListQuery{})
+	assert.Error(t, err)
+}
+
+func TestPostgresOrderRepository_List_FilteredPage(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresOrderRepository(mock)
+	clientID := uuid.New()
+	now := time.Now()
+	columns := []string{"id", "client_id", "vehicle_id", "status", "total_service", "total_parts", "total", "total_cost", "created_at", "updated_at", "started_at", "finished_at", "reception_mileage", "delivery_mileage"}
+
+	q := sharedkernel.ListQuery{Limit: 1, Sort: "priority", Status: []string{"Received", "In execution"}, ClientID: &clientID}
+
+	// Two rows for a page of one: there is a next page
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM orders WHERE status = ANY($1) AND client_id = $2 ORDER BY CASE status WHEN 'In execution' THEN 1`)).
+		WithArgs(q.Status, clientID, 2).
+		WillReturnRows(pgxmock.NewRows(columns).
+			AddRow(uuid.New(), clientID, uuid.New(), "In execution", 0.0, 0.0, 0.0, 0.0, now, now, nil, nil, nil, nil).
+			AddRow(uuid.New(), clientID, uuid.New(), "Received", 0.0, 0.0, 0.0, 0.0, now, now, nil, nil, nil, nil))
+
+	page, err := repo.List(context.Background(), q)
+	assert.NoError(t, err)
+	assert.Len(t, page.Items, 1)
+	assert.NotEmpty(t, page.NextCursor)
+
+	// The next page resumes after the last order of the first one
+	q.Cursor = page.NextCursor
+	mock.ExpectQuery(regexp.QuoteMeta(`AND (CASE status`)).
+		WithArgs(q.Status, clientID, "1", pgxmock.AnyArg(), page.Items[0].ID, 2).
+		WillReturnRows(pgxmock.NewRows(columns).
+			AddRow(uuid.New(), clientID, uuid.New(), "Received", 0.0, 0.0, 0.0, 0.0, now, now, nil, nil, nil, nil))
+
+	page, err = repo.List(context.Background(), q)
+	assert.NoError(t, err)
+	assert.Len(t, page.Items, 1)
+	assert.Empty(t, page.NextCursor)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// Unknown sort field
+	_, err = repo.List(context.Background(), sharedkernel.ListQuery{Sort: "vehicle_id"})
+	assert.ErrorIs(t, err, sharedkernel.ErrInvalidSort)
+}
+
+func TestPostgresOrderRepository_Save_WithTransitions(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresOrderRepository(mock)
+	order, _ := domain.NewOrder(uuid.New(), uuid.New(), 0)
+	_ = order.Transition(domain.OrderStatusInDiagnosis, "tester", "checking noise")
+	transition := order.PendingTransitions()[0]
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO orders`)).
+		WithArgs(order.ID, order.ClientID, order.VehicleID, order.Status, order.TotalService, order.TotalParts, order.Total, order.TotalCost, order.CreatedAt, order.UpdatedAt, order.StartedAt, order.FinishedAt, order.ReceptionMileage, order.DeliveryMileage).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM order_items`)).
+		WithArgs(order.ID).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO order_status_history`)).
+		WithArgs(transition.ID, order.ID, "Received", "In diagnosis", "tester", "checking noise", transition.OccurredAt).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectCommit()
+
+	err = repo.Save(context.Background(), order)
+	assert.NoError(t, err)
+	assert.Empty(t, order.PendingTransitions())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresOrderRepository_ListStatusHistory(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresOrderRepository(mock)
+	orderID := uuid.New()
+	now := time.Now()
+
+	// Success
+	rows := pgxmock.NewRows([]string{"id", "order_id", "from_status", "to_status", "actor", "reason", "occurred_at"}).
+		AddRow(uuid.New(), orderID, "Received", "In diagnosis", "tester", "", now)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, order_id, from_status, to_status, actor, reason, occurred_at`)).
+		WithArgs(orderID).
+		WillReturnRows(rows)
+
+	history, err := repo.ListStatusHistory(context.Background(), orderID)
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+	assert.Equal(t, domain.OrderStatusInDiagnosis, history[0].To)
+
+	// DB Error
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id`)).
+		WithArgs(orderID).
+		WillReturnError(errors.New("db error"))
+
+	_, err = repo.ListStatusHistory(context.Background(), orderID)
+	assert.Error(t, err)
+}
+
+func TestPostgresOrderRepository_ListByVehicleID(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresOrderRepository(mock)
+	vehicleID := uuid.New()
+	first, second := uuid.New(), uuid.New()
+	previousOwner, currentOwner := uuid.New(), uuid.New()
+	now := time.Now()
+
+	// Success: orders of every owner, items loaded in one query
+	rows := pgxmock.NewRows([]string{"id", "client_id", "vehicle_id", "status", "total_service", "total_parts", "total", "total_cost", "created_at", "updated_at", "started_at", "finished_at", "reception_mileage", "delivery_mileage"}).
+		AddRow(first, previousOwner, vehicleID, "Delivered", 100.0, 0.0, 100.0, 0.0, now.Add(-time.Hour), now, nil, nil, nil, nil).
+		AddRow(second, currentOwner, vehicleID, "Received", 0.0, 50.0, 50.0, 30.0, now, now, nil, nil, nil, nil)
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM orders WHERE vehicle_id = $1 ORDER BY created_at ASC`)).
+		WithArgs(vehicleID).
+		WillReturnRows(rows)
+
+	itemRows := pgxmock.NewRows([]string{"id", "order_id", "ref_id", "type", "name", "quantity", "unit_price", "unit_cost", "total"}).
+		AddRow(uuid.New(), first, uuid.New(), "service", "S1", 1, 100.0, 0.0, 100.0).
+		AddRow(uuid.New(), second, uuid.New(), "part", "P1", 1, 50.0, 30.0, 50.0)
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM order_items WHERE order_id = ANY($1)`)).
+		WithArgs([]uuid.UUID{first, second}).
+		WillReturnRows(itemRows)
+
+	orders, err := repo.ListByVehicleID(context.Background(), vehicleID)
+	assert.NoError(t, err)
+	if assert.Len(t, orders, 2) {
+		assert.Equal(t, previousOwner, orders[0].ClientID)
+		assert.Len(t, orders[0].Items, 1)
+		assert.Equal(t, domain.ItemTypePart, orders[1].Items[0].Type)
+	}
+
+	// No orders: items are not queried
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM orders WHERE vehicle_id = $1`)).
+		WithArgs(vehicleID).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "client_id", "vehicle_id", "status", "total_service", "total_parts", "total", "total_cost", "created_at", "updated_at", "started_at", "finished_at", "reception_mileage", "delivery_mileage"}))
+
+	orders, err = repo.ListByVehicleID(context.Background(), vehicleID)
+	assert.NoError(t, err)
+	assert.Empty(t, orders)
+
+	// DB Error
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM orders`)).
+		WithArgs(vehicleID).
+		WillReturnError(errors.New("db error"))
+
+	_, err = repo.ListByVehicleID(context.Background(), vehicleID)
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresOrderRepository_HasOpenOrdersWithPart(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresOrderRepository(mock)
+	partID := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS`)).
+		WithArgs(partID, "part", "Delivered", "Cancelled").
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+
+	inUse, err := repo.HasOpenOrdersWithPart(context.Background(), partID)
+	assert.NoError(t, err)
+	assert.True(t, inUse)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS`)).
+		WillReturnError(errors.New("db error"))
+
+	_, err = repo.HasOpenOrdersWithPart(context.Background(), partID)
 	assert.Error(t, err)
 }
@@ -1,6 +1,7 @@
 package infrastructure_test
 
 import (
+	"context"
 	"errors"
 	"regexp"
 	"testing"
@@ -10,6 +11,7 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/noggrj/autorepair/internal/service/domain"
 	"github.com/noggrj/autorepair/internal/service/infrastructure"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
 	"github.com/pashagolub/pgxmock/v4"
 	"github.com/stretchr/testify/assert"
 )
@@ -22,21 +24,21 @@ func TestPostgresServiceRepository_Save(t *testing.T) {
 	defer mock.Close()
 
 	repo := infrastructure.NewPostgresServiceRepository(mock)
-	service, _ := domain.NewService("Oil Change", "Desc", 100.0)
+	service, _ := domain.NewService("Oil Change", "Desc", sharedkernel.NewMoneyFromFloat(100.0))
 
 	// Success
 	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO services`)).
-		WithArgs(service.ID, service.Name, service.Description, float64(service.Price), service.CreatedAt, service.UpdatedAt).
+		WithArgs(service.ID, service.Name, service.Description, service.Price, service.CreatedAt, service.UpdatedAt).
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
-	err = repo.Save(service)
+	err = repo.Save(context.Background(), service)
 	assert.NoError(t, err)
 
 	// Error
 	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO services`)).
 		WillReturnError(errors.New("db error"))
 
-	err = repo.Save(service)
+	err = repo.Save(context.Background(), service)
 	assert.Error(t, err)
 }
 
@@ -59,7 +61,7 @@ func TestPostgresServiceRepository_GetByID(t *testing.T) {
 		WithArgs(id).
 		WillReturnRows(rows)
 
-	service, err := repo.GetByID(id)
+	service, err := repo.GetByID(context.Background(), id)
 	assert.NoError(t, err)
 	assert.NotNil(t, service)
 	assert.Equal(t, id, service.ID)
@@ -69,7 +71,7 @@ func TestPostgresServiceRepository_GetByID(t *testing.T) {
 		WithArgs(id).
 		WillReturnError(pgx.ErrNoRows)
 
-	_, err = repo.GetByID(id)
+	_, err = repo.GetByID(context.Background(), id)
 	assert.Error(t, err)
 	assert.Equal(t, domain.ErrServiceNotFound, err)
 
@@ -77,7 +79,7 @@ func TestPostgresServiceRepository_GetByID(t *testing.T) {
 	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id`)).
 		WillReturnError(errors.New("connection error"))
 
-	_, err = repo.GetByID(id)
+	_, err = repo.GetByID(context.Background(), id)
 	assert.Error(t, err)
 }
 
@@ -99,15 +101,15 @@ func TestPostgresServiceRepository_List(t *testing.T) {
 	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, description, price, created_at, updated_at FROM services`)).
 		WillReturnRows(rows)
 
-	services, err := repo.List()
+	page, err := repo.List(context.Background(), sharedkernel.ListQuery{})
 	assert.NoError(t, err)
-	assert.Len(t, services, 2)
+	assert.Len(t, page.Items, 2)
 
 	// Query Error
 	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id`)).
 		WillReturnError(errors.New("db error"))
 
-	_, err = repo.List()
+	_, err = repo.List(context.Background(), sharedkernel.ListQuery{})
 	assert.Error(t, err)
 
 	// Scan Error
@@ -117,6 +119,6 @@ func TestPostgresServiceRepository_List(t *testing.T) {
 	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id`)).
 		WillReturnRows(rowsScanErr)
 
-	_, err = repo.List()
+	_, err = repo.List(context.Background(), sharedkernel.ListQuery{})
 	assert.Error(t, err)
 }
@@ -0,0 +1,77 @@
+package infrastructure_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/google/uuid"
+	inventoryDomain "github.com/noggrj/autorepair/internal/inventory/domain"
+	"github.com/noggrj/autorepair/internal/service/application"
+	"github.com/noggrj/autorepair/internal/service/infrastructure"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresUnitOfWork_Do(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	uow := infrastructure.NewPostgresUnitOfWork(mock)
+	partID := uuid.New()
+
+	// Success: repositories share the transaction, which is committed
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, sku, manufacturer_code, name, description, stock_qty, cost_price, sale_price, min_quantity, reorder_quantity FROM parts WHERE id = $1 AND archived_at IS NULL FOR UPDATE`)).
+		WithArgs(partID).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "sku", "manufacturer_code", "name", "description", "stock_qty", "cost_price", "sale_price", "min_quantity", "reorder_quantity"}).
+			AddRow(partID, "PART-1", "", "Part", "Desc", 5, 6.0, 10.0, 0, 0))
+	// The part update and its ledger entry run in a nested transaction (savepoint)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE parts`)).
+		WithArgs("PART-1", "", "Part", "Desc", 3, pgxmock.AnyArg(), pgxmock.AnyArg(), 0, 0, partID).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO stock_movements`)).
+		WithArgs(pgxmock.AnyArg(), partID, inventoryDomain.MovementOrderConsumption, -2, "order:test", pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectCommit()
+	mock.ExpectCommit()
+
+	err = uow.Do(context.Background(), func(repos application.TxRepositories) error {
+		part, err := repos.Parts.GetByIDForUpdate(context.Background(), partID)
+		if err != nil {
+			return err
+		}
+		if err := part.RemoveStock(2, inventoryDomain.MovementOrderConsumption, "order:test"); err != nil {
+			return err
+		}
+		return repos.Parts.Update(context.Background(), part)
+	})
+	assert.NoError(t, err)
+
+	// Callback error: nothing is committed
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	err = uow.Do(context.Background(), func(repos application.TxRepositories) error {
+		return errors.New("boom")
+	})
+	assert.EqualError(t, err, "boom")
+
+	// Begin error: callback never runs
+	mock.ExpectBegin().WillReturnError(errors.New("tx error"))
+
+	called := false
+	err = uow.Do(context.Background(), func(repos application.TxRepositories) error {
+		called = true
+		return nil
+	})
+	assert.Error(t, err)
+	assert.False(t, called)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,128 @@
+package infrastructure_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/noggrj/autorepair/internal/service/domain"
+	"github.com/noggrj/autorepair/internal/service/infrastructure"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+var vehicleColumns = []string{"id", "client_id", "plate", "vin", "brand", "model", "year", "mileage", "created_at", "updated_at"}
+
+func TestPostgresVehicleOwnershipRepository_Transfer(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresVehicleOwnershipRepository(mock)
+	vehicleID := uuid.New()
+	previousOwner := uuid.New()
+	newOwner := uuid.New()
+	createdAt := time.Now().Add(-48 * time.Hour)
+	lastTransfer := time.Now().Add(-24 * time.Hour)
+	vehicleRow := func() *pgxmock.Rows {
+		return pgxmock.NewRows(vehicleColumns).
+			AddRow(vehicleID, previousOwner, "ABC1234", nil, "Ford", "Fiesta", 2020, 41000, createdAt, createdAt)
+	}
+
+	// Success: the previous owner's period starts at the last transfer
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM vehicles WHERE id = $1 FOR UPDATE`)).
+		WithArgs(vehicleID).
+		WillReturnRows(vehicleRow())
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT MAX(ended_at) FROM vehicle_ownerships WHERE vehicle_id = $1`)).
+		WithArgs(vehicleID).
+		WillReturnRows(pgxmock.NewRows([]string{"max"}).AddRow(&lastTransfer))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO vehicle_ownerships`)).
+		WithArgs(pgxmock.AnyArg(), vehicleID, previousOwner, lastTransfer, pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE vehicles SET client_id = $2, updated_at = $3 WHERE id = $1`)).
+		WithArgs(vehicleID, newOwner, pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectCommit()
+
+	vehicle, err := repo.Transfer(context.Background(), vehicleID, newOwner)
+	assert.NoError(t, err)
+	assert.Equal(t, newOwner, vehicle.ClientID)
+
+	// First transfer: the period starts when the vehicle was registered
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM vehicles WHERE id = $1 FOR UPDATE`)).
+		WithArgs(vehicleID).
+		WillReturnRows(vehicleRow())
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT MAX(ended_at)`)).
+		WithArgs(vehicleID).
+		WillReturnRows(pgxmock.NewRows([]string{"max"}).AddRow((*time.Time)(nil)))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO vehicle_ownerships`)).
+		WithArgs(pgxmock.AnyArg(), vehicleID, previousOwner, createdAt, pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE vehicles SET client_id`)).
+		WithArgs(vehicleID, newOwner, pgxmock.AnyArg()).
+		WillReturnError(&pgconn.PgError{Code: "23503"})
+	mock.ExpectRollback()
+
+	_, err = repo.Transfer(context.Background(), vehicleID, newOwner)
+	assert.ErrorIs(t, err, domain.ErrClientNotFound)
+
+	// Same owner: nothing is written
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM vehicles WHERE id = $1 FOR UPDATE`)).
+		WithArgs(vehicleID).
+		WillReturnRows(vehicleRow())
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT MAX(ended_at)`)).
+		WithArgs(vehicleID).
+		WillReturnRows(pgxmock.NewRows([]string{"max"}).AddRow((*time.Time)(nil)))
+	mock.ExpectRollback()
+
+	_, err = repo.Transfer(context.Background(), vehicleID, previousOwner)
+	assert.ErrorIs(t, err, domain.ErrSameOwner)
+
+	// Unknown vehicle
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM vehicles WHERE id = $1 FOR UPDATE`)).
+		WithArgs(vehicleID).
+		WillReturnError(pgx.ErrNoRows)
+	mock.ExpectRollback()
+
+	_, err = repo.Transfer(context.Background(), vehicleID, newOwner)
+	assert.ErrorIs(t, err, domain.ErrVehicleNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresVehicleOwnershipRepository_ListByVehicleID(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresVehicleOwnershipRepository(mock)
+	vehicleID := uuid.New()
+	clientID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, vehicle_id, client_id, started_at, ended_at FROM vehicle_ownerships WHERE vehicle_id = $1 ORDER BY ended_at`)).
+		WithArgs(vehicleID).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "vehicle_id", "client_id", "started_at", "ended_at"}).
+			AddRow(uuid.New(), vehicleID, clientID, now.Add(-time.Hour), now))
+
+	ownerships, err := repo.ListByVehicleID(context.Background(), vehicleID)
+	assert.NoError(t, err)
+	if assert.Len(t, ownerships, 1) {
+		assert.Equal(t, clientID, ownerships[0].ClientID)
+		assert.Equal(t, now, ownerships[0].EndedAt)
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
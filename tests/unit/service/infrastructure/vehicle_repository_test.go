@@ -1,6 +1,7 @@
 package infrastructure_test
 
 import (
+	"context"
 	"errors"
 	"regexp"
 	"testing"
@@ -8,8 +9,10 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/noggrj/autorepair/internal/service/domain"
 	"github.com/noggrj/autorepair/internal/service/infrastructure"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
 	"github.com/pashagolub/pgxmock/v4"
 	"github.com/stretchr/testify/assert"
 )
@@ -27,17 +30,36 @@ func TestPostgresVehicleRepository_Save(t *testing.T) {
 
 	// Success
 	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO vehicles`)).
-		WithArgs(vehicle.ID, vehicle.ClientID, vehicle.Plate.String(), vehicle.Brand, vehicle.Model, vehicle.Year, vehicle.CreatedAt, vehicle.UpdatedAt).
+		WithArgs(vehicle.ID, vehicle.ClientID, "ABC1234", "ABC1C34", (*string)(nil), vehicle.Brand, vehicle.Model, vehicle.Year, vehicle.CreatedAt, vehicle.UpdatedAt).
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
-	err = repo.Save(vehicle)
+	err = repo.Save(context.Background(), vehicle)
 	assert.NoError(t, err)
 
+	// The plate is taken by another vehicle, in either format
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO vehicles`)).
+		WithArgs(vehicle.ID, vehicle.ClientID, "ABC1234", "ABC1C34", (*string)(nil), vehicle.Brand, vehicle.Model, vehicle.Year, vehicle.CreatedAt, vehicle.UpdatedAt).
+		WillReturnError(&pgconn.PgError{Code: "23505"})
+
+	err = repo.Save(context.Background(), vehicle)
+	assert.ErrorIs(t, err, domain.ErrVehiclePlateExists)
+
+	// The VIN is stored when known, and unique too
+	vin, _ := sharedkernel.NewVIN("9BWZZZ377VT004251")
+	vehicle.VIN = &vin
+	vinValue := "9BWZZZ377VT004251"
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO vehicles`)).
+		WithArgs(vehicle.ID, vehicle.ClientID, "ABC1234", "ABC1C34", &vinValue, vehicle.Brand, vehicle.Model, vehicle.Year, vehicle.CreatedAt, vehicle.UpdatedAt).
+		WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "idx_vehicles_vin"})
+
+	err = repo.Save(context.Background(), vehicle)
+	assert.ErrorIs(t, err, domain.ErrVehicleVINExists)
+
 	// Error
 	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO vehicles`)).
 		WillReturnError(errors.New("db error"))
 
-	err = repo.Save(vehicle)
+	err = repo.Save(context.Background(), vehicle)
 	assert.Error(t, err)
 }
 
@@ -54,24 +76,38 @@ func TestPostgresVehicleRepository_GetByID(t *testing.T) {
 	now := time.Now()
 
 	// Success
-	rows := pgxmock.NewRows([]string{"id", "client_id", "plate", "brand", "model", "year", "created_at", "updated_at"}).
-		AddRow(id, clientID, "ABC-1234", "Ford", "Fiesta", 2020, now, now)
+	rows := pgxmock.NewRows([]string{"id", "client_id", "plate", "vin", "brand", "model", "year", "mileage", "created_at", "updated_at"}).
+		AddRow(id, clientID, "ABC-1234", nil, "Ford", "Fiesta", 2020, 0, now, now)
 
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, client_id, plate, brand, model, year, created_at, updated_at FROM vehicles WHERE id = $1`)).
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, client_id, plate, vin, brand, model, year, mileage, created_at, updated_at FROM vehicles WHERE id = $1`)).
 		WithArgs(id).
 		WillReturnRows(rows)
 
-	vehicle, err := repo.GetByID(id)
+	vehicle, err := repo.GetByID(context.Background(), id)
 	assert.NoError(t, err)
 	assert.NotNil(t, vehicle)
 	assert.Equal(t, id, vehicle.ID)
+	assert.Nil(t, vehicle.VIN)
+
+	// With a VIN
+	vinValue := "9BWZZZ377VT004251"
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id`)).
+		WithArgs(id).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "client_id", "plate", "vin", "brand", "model", "year", "mileage", "created_at", "updated_at"}).
+			AddRow(id, clientID, "ABC-1234", &vinValue, "Ford", "Fiesta", 2020, 0, now, now))
+
+	vehicle, err = repo.GetByID(context.Background(), id)
+	assert.NoError(t, err)
+	if assert.NotNil(t, vehicle.VIN) {
+		assert.Equal(t, vinValue, vehicle.VIN.String())
+	}
 
 	// Not Found
 	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id`)).
 		WithArgs(id).
 		WillReturnError(pgx.ErrNoRows)
 
-	_, err = repo.GetByID(id)
+	_, err = repo.GetByID(context.Background(), id)
 	assert.Error(t, err)
 	assert.Equal(t, domain.ErrVehicleNotFound, err)
 
@@ -79,21 +115,63 @@ func TestPostgresVehicleRepository_GetByID(t *testing.T) {
 	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id`)).
 		WillReturnError(errors.New("connection error"))
 
-	_, err = repo.GetByID(id)
+	_, err = repo.GetByID(context.Background(), id)
 	assert.Error(t, err)
 
 	// Scan Error (Invalid Plate)
-	rowsScanErr := pgxmock.NewRows([]string{"id", "client_id", "plate", "brand", "model", "year", "created_at", "updated_at"}).
-		AddRow(id, clientID, "invalid", "Ford", "Fiesta", 2020, now, now)
+	rowsScanErr := pgxmock.NewRows([]string{"id", "client_id", "plate", "vin", "brand", "model", "year", "mileage", "created_at", "updated_at"}).
+		AddRow(id, clientID, "invalid", nil, "Ford", "Fiesta", 2020, 0, now, now)
 
 	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id`)).
 		WithArgs(id).
 		WillReturnRows(rowsScanErr)
 
-	_, err = repo.GetByID(id)
+	_, err = repo.GetByID(context.Background(), id)
 	assert.Error(t, err)
 }
 
+func TestPostgresVehicleRepository_GetByPlate(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close()
+
+	repo := infrastructure.NewPostgresVehicleRepository(mock)
+	plate, _ := sharedkernel.NewPlacaBR("abc-1d23")
+	now := time.Now()
+
+	// Success: the plate is looked up in its normalized form
+	rows := pgxmock.NewRows([]string{"id", "client_id", "plate", "vin", "brand", "model", "year", "mileage", "created_at", "updated_at"}).
+		AddRow(uuid.New(), uuid.New(), "ABC1D23", nil, "Ford", "Fiesta", 2020, 0, now, now)
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM vehicles WHERE plate_key = $1`)).
+		WithArgs("ABC1D23").
+		WillReturnRows(rows)
+
+	vehicle, err := repo.GetByPlate(context.Background(), plate)
+	assert.NoError(t, err)
+	assert.Equal(t, plate, vehicle.Plate)
+
+	// A legacy plate finds the vehicle under its Mercosul conversion
+	legacy, _ := sharedkernel.NewPlacaBR("ABC1323")
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM vehicles WHERE plate_key = $1`)).
+		WithArgs("ABC1D23").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "client_id", "plate", "vin", "brand", "model", "year", "mileage", "created_at", "updated_at"}).
+			AddRow(uuid.New(), uuid.New(), "ABC1D23", nil, "Ford", "Fiesta", 2020, 0, now, now))
+
+	vehicle, err = repo.GetByPlate(context.Background(), legacy)
+	assert.NoError(t, err)
+	assert.True(t, legacy.Equivalent(vehicle.Plate))
+
+	// Not Found
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM vehicles WHERE plate_key = $1`)).
+		WithArgs("ABC1D23").
+		WillReturnError(pgx.ErrNoRows)
+
+	_, err = repo.GetByPlate(context.Background(), plate)
+	assert.ErrorIs(t, err, domain.ErrVehicleNotFound)
+}
+
 func TestPostgresVehicleRepository_ListByClientID(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
@@ -106,14 +184,14 @@ func TestPostgresVehicleRepository_ListByClientID(t *testing.T) {
 	now := time.Now()
 
 	// Success
-	rows := pgxmock.NewRows([]string{"id", "client_id", "plate", "brand", "model", "year", "created_at", "updated_at"}).
-		AddRow(uuid.New(), clientID, "ABC-1234", "Ford", "Fiesta", 2020, now, now)
+	rows := pgxmock.NewRows([]string{"id", "client_id", "plate", "vin", "brand", "model", "year", "mileage", "created_at", "updated_at"}).
+		AddRow(uuid.New(), clientID, "ABC-1234", nil, "Ford", "Fiesta", 2020, 0, now, now)
 
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, client_id, plate, brand, model, year, created_at, updated_at FROM vehicles WHERE client_id = $1`)).
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, client_id, plate, vin, brand, model, year, mileage, created_at, updated_at FROM vehicles WHERE client_id = $1`)).
 		WithArgs(clientID).
 		WillReturnRows(rows)
 
-	vehicles, err := repo.ListByClientID(clientID)
+	vehicles, err := repo.ListByClientID(context.Background(), clientID)
 	assert.NoError(t, err)
 	assert.Len(t, vehicles, 1)
 
@@ -121,16 +199,16 @@ func TestPostgresVehicleRepository_ListByClientID(t *testing.T) {
 	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id`)).
 		WillReturnError(errors.New("db error"))
 
-	_, err = repo.ListByClientID(clientID)
+	_, err = repo.ListByClientID(context.Background(), clientID)
 	assert.Error(t, err)
 
 	// Scan Error
-	rowsScanErr := pgxmock.NewRows([]string{"id", "client_id", "plate", "brand", "model", "year", "created_at", "updated_at"}).
-		AddRow(uuid.New(), clientID, "invalid", "Ford", "Fiesta", 2020, now, now)
+	rowsScanErr := pgxmock.NewRows([]string{"id", "client_id", "plate", "vin", "brand", "model", "year", "mileage", "created_at", "updated_at"}).
+		AddRow(uuid.New(), clientID, "invalid", nil, "Ford", "Fiesta", 2020, 0, now, now)
 
 	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id`)).
 		WillReturnRows(rowsScanErr)
 
-	_, err = repo.ListByClientID(clientID)
+	_, err = repo.ListByClientID(context.Background(), clientID)
 	assert.Error(t, err)
 }
@@ -0,0 +1,48 @@
+package infrastructure_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/noggrj/autorepair/internal/service/domain"
+	"github.com/noggrj/autorepair/internal/service/infrastructure"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTableVehicleSpecProvider_Lookup(t *testing.T) {
+	provider := infrastructure.NewTableVehicleSpecProvider(map[string]domain.VehicleSpec{
+		"9BW":     {Brand: "Volkswagen"},
+		"9BWAB45": {Brand: "Volkswagen", Model: "Gol"},
+	})
+	ctx := context.Background()
+
+	// The longest prefix wins
+	vin, _ := sharedkernel.NewVIN("9BWAB45Z3R4012345")
+	spec, err := provider.Lookup(ctx, vin)
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.VehicleSpec{Brand: "Volkswagen", Model: "Gol"}, spec)
+
+	// Only the manufacturer is known
+	vin, _ = sharedkernel.NewVIN("9BWZZZ377VT004251")
+	spec, err = provider.Lookup(ctx, vin)
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.VehicleSpec{Brand: "Volkswagen"}, spec)
+
+	vin, _ = sharedkernel.NewVIN("1M8GDM9AXKP042788")
+	_, err = provider.Lookup(ctx, vin)
+	assert.ErrorIs(t, err, domain.ErrVehicleSpecNotFound)
+}
+
+func TestDefaultVehicleSpecs(t *testing.T) {
+	provider := infrastructure.NewTableVehicleSpecProvider(infrastructure.DefaultVehicleSpecs)
+
+	vin, _ := sharedkernel.NewVIN("9BWZZZ377VT004251")
+	spec, err := provider.Lookup(context.Background(), vin)
+	assert.NoError(t, err)
+	assert.Equal(t, "Volkswagen", spec.Brand)
+
+	for prefix := range infrastructure.DefaultVehicleSpecs {
+		assert.Len(t, prefix, 3, "default specs are keyed by WMI")
+	}
+}
@@ -0,0 +1,125 @@
+package sharedkernel_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/noggrj/autorepair/internal/sharedkernel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		input string
+		cents int64
+		err   bool
+	}{
+		{"1234.56", 123456, false},
+		{"0.1", 10, false},
+		{"10", 1000, false},
+		{"-2.50", -250, false},
+		{"1.005", 101, false},   // half away from zero
+		{"-1.005", -101, false}, // half away from zero
+		{"1.004", 100, false},
+		{".99", 99, false},
+		{"", 0, true},
+		{"abc", 0, true},
+		{"1,50", 0, true},
+	}
+
+	for _, tt := range tests {
+		m, err := sharedkernel.ParseMoney(tt.input)
+		if tt.err {
+			assert.ErrorIs(t, err, sharedkernel.ErrInvalidMoney, tt.input)
+			continue
+		}
+		assert.NoError(t, err, tt.input)
+		assert.Equal(t, tt.cents, m.Cents(), tt.input)
+	}
+}
+
+func TestMoney_FromFloatDoesNotDrift(t *testing.T) {
+	// 0.1 + 0.2 != 0.3 in float64, but must be exact in Money
+	a := sharedkernel.NewMoneyFromFloat(0.1)
+	b := sharedkernel.NewMoneyFromFloat(0.2)
+	sum, err := a.Add(b)
+	assert.NoError(t, err)
+	assert.True(t, sum.Equal(sharedkernel.NewMoneyFromFloat(0.3)))
+
+	// Large budgets keep every cent
+	unit := sharedkernel.NewMoneyFromFloat(19.99)
+	assert.Equal(t, int64(19990000), unit.Mul(10000).Cents())
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	a := sharedkernel.NewMoneyFromCents(1000)
+	b := sharedkernel.NewMoneyFromCents(250)
+
+	diff, err := a.Sub(b)
+	assert.NoError(t, err)
+	assert.Equal(t, "7.50", diff.String())
+
+	total, err := sharedkernel.Sum(a, b, b)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1500), total.Cents())
+
+	usd := sharedkernel.NewMoneyInCurrency(100, "USD")
+	_, err = a.Add(usd)
+	assert.ErrorIs(t, err, sharedkernel.ErrCurrencyMismatch)
+
+	assert.Equal(t, sharedkernel.CurrencyBRL, sharedkernel.Money{}.Currency())
+	assert.Equal(t, sharedkernel.NewMoneyFromCents(100), sharedkernel.NewMoneyInCurrency(100, sharedkernel.CurrencyBRL))
+	assert.Equal(t, "-0.05", sharedkernel.NewMoneyFromCents(-5).String())
+}
+
+func TestMoney_JSON(t *testing.T) {
+	data, err := json.Marshal(sharedkernel.NewMoneyFromCents(123450))
+	assert.NoError(t, err)
+	assert.Equal(t, "1234.50", string(data))
+
+	var fromNumber, fromString sharedkernel.Money
+	assert.NoError(t, json.Unmarshal([]byte("19.99"), &fromNumber))
+	assert.NoError(t, json.Unmarshal([]byte(`"19.99"`), &fromString))
+	assert.Equal(t, int64(1999), fromNumber.Cents())
+	assert.Equal(t, fromNumber, fromString)
+
+	var fromExp sharedkernel.Money
+	assert.NoError(t, json.Unmarshal([]byte("1.5e2"), &fromExp))
+	assert.Equal(t, int64(15000), fromExp.Cents())
+
+	var invalid sharedkernel.Money
+	assert.Error(t, json.Unmarshal([]byte(`"abc"`), &invalid))
+}
+
+func TestMoney_Database(t *testing.T) {
+	var m sharedkernel.Money
+
+	// DECIMAL(10,2) as pgtype.Numeric: 12345 * 10^-2
+	assert.NoError(t, m.ScanNumeric(pgtype.Numeric{Int: big.NewInt(12345), Exp: -2, Valid: true}))
+	assert.Equal(t, int64(12345), m.Cents())
+
+	// More than two decimals rounds half away from zero
+	assert.NoError(t, m.ScanNumeric(pgtype.Numeric{Int: big.NewInt(-10050), Exp: -4, Valid: true}))
+	assert.Equal(t, int64(-101), m.Cents())
+
+	// NULL reads as zero
+	assert.NoError(t, m.ScanNumeric(pgtype.Numeric{}))
+	assert.True(t, m.IsZero())
+
+	n, err := sharedkernel.NewMoneyFromCents(999).NumericValue()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(999), n.Int.Int64())
+	assert.Equal(t, int32(-2), n.Exp)
+
+	assert.NoError(t, m.Scan("42.10"))
+	assert.Equal(t, int64(4210), m.Cents())
+	assert.NoError(t, m.Scan(42.1))
+	assert.Equal(t, int64(4210), m.Cents())
+	assert.Error(t, m.Scan(true))
+
+	v, err := sharedkernel.NewMoneyFromCents(4210).Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "42.10", v)
+}
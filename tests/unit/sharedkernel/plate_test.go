@@ -29,6 +29,45 @@ func TestNewPlacaBR(t *testing.T) {
 	assert.Equal(t, `"ABC1D23"`, string(b))
 }
 
+func TestPlacaBR_Conversion(t *testing.T) {
+	tests := []struct {
+		plate    string
+		format   sharedkernel.PlateFormat
+		mercosul string
+		legacy   string
+	}{
+		{"ABC1234", sharedkernel.PlateFormatLegacy, "ABC1C34", "ABC1234"},
+		{"ABC1034", sharedkernel.PlateFormatLegacy, "ABC1A34", "ABC1034"},
+		{"ABC1934", sharedkernel.PlateFormatLegacy, "ABC1J34", "ABC1934"},
+		{"ABC1C34", sharedkernel.PlateFormatMercosul, "ABC1C34", "ABC1234"},
+		{"ABC1K34", sharedkernel.PlateFormatMercosul, "ABC1K34", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.plate, func(t *testing.T) {
+			p, err := sharedkernel.NewPlacaBR(tt.plate)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.format, p.Format())
+			assert.Equal(t, tt.mercosul, p.Mercosul().String())
+			assert.Equal(t, tt.mercosul, p.Canonical())
+
+			legacy, ok := p.Legacy()
+			assert.Equal(t, tt.legacy != "", ok)
+			assert.Equal(t, tt.legacy, legacy.String())
+		})
+	}
+
+	legacy, _ := sharedkernel.NewPlacaBR("abc-1234")
+	mercosul, _ := sharedkernel.NewPlacaBR("ABC1C34")
+	other, _ := sharedkernel.NewPlacaBR("ABC1D34")
+	assert.True(t, legacy.Equivalent(mercosul))
+	assert.True(t, mercosul.Equivalent(legacy))
+	assert.False(t, legacy.Equivalent(other))
+
+	var zero sharedkernel.PlacaBR
+	assert.Equal(t, sharedkernel.PlateFormat(""), zero.Format())
+	assert.Equal(t, "", zero.Canonical())
+}
+
 func TestDocumentoBR_JSON(t *testing.T) {
 	d, _ := sharedkernel.NewDocumentoBR("12345678909")
 	b, err := json.Marshal(d)
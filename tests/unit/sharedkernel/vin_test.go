@@ -0,0 +1,92 @@
+package sharedkernel_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/noggrj/autorepair/internal/sharedkernel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewVIN(t *testing.T) {
+	// Check digit X, spaces, hyphens and case are normalized
+	v, err := sharedkernel.NewVIN("1m8gdm9a-xkp 042788")
+	assert.NoError(t, err)
+	assert.Equal(t, "1M8GDM9AXKP042788", v.String())
+
+	// Outside North America the check digit is not required: real VINs of
+	// VW Brazil and Germany that do not verify
+	v, err = sharedkernel.NewVIN("WVWZZZ1JZ3W386752")
+	assert.NoError(t, err)
+	assert.Equal(t, "WVWZZZ1JZ3W386752", v.String())
+
+	v, err = sharedkernel.NewVIN("9BWZZZ377VT004251")
+	assert.NoError(t, err)
+	assert.Equal(t, "9BWZZZ377VT004251", v.String())
+
+	invalid := []string{
+		"",
+		"9BWZZZ377VT00425",   // too short
+		"9BWZZZ377VT0042511", // too long
+		"1M8GDM9A1KP042788",  // wrong check digit on a North American VIN
+		"9BWZZZ377VT0O4251",  // O is not allowed
+		"9BWZZZ377VT0I4251",  // I is not allowed
+		"9BWZZZ377VT0Q4251",  // Q is not allowed
+	}
+	for _, vin := range invalid {
+		_, err := sharedkernel.NewVIN(vin)
+		assert.ErrorIs(t, err, sharedkernel.ErrInvalidVIN, vin)
+	}
+
+	// JSON Marshalling
+	b, err := json.Marshal(v)
+	assert.NoError(t, err)
+	assert.Equal(t, `"9BWZZZ377VT004251"`, string(b))
+}
+
+func TestVIN_Decode(t *testing.T) {
+	tests := []struct {
+		vin    string
+		wmi    string
+		region string
+	}{
+		{"9BWZZZ377VT004251", "9BW", "South America"},
+		{"1M8GDM9AXKP042788", "1M8", "North America"},
+		{"WVWZZZ1J93W386752", "WVW", "Europe"},
+		{"JHMCM56557C404453", "JHM", "Asia"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.vin, func(t *testing.T) {
+			v, err := sharedkernel.NewVIN(tt.vin)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.wmi, v.WMI())
+				assert.Equal(t, tt.region, v.Region())
+			}
+		})
+	}
+}
+
+func TestVIN_ModelYear(t *testing.T) {
+	v, _ := sharedkernel.NewVIN("1M8GDM9AXKP042788")
+
+	// K is 1989 or 2019: the latest one not past next year wins
+	year, ok := v.ModelYear(2000)
+	assert.True(t, ok)
+	assert.Equal(t, 1989, year)
+
+	year, ok = v.ModelYear(2026)
+	assert.True(t, ok)
+	assert.Equal(t, 2019, year)
+
+	// V is 1997 or 2027, which is already sold the year before
+	v, _ = sharedkernel.NewVIN("9BWZZZ377VT004251")
+	year, _ = v.ModelYear(2025)
+	assert.Equal(t, 1997, year)
+	year, _ = v.ModelYear(2026)
+	assert.Equal(t, 2027, year)
+
+	// U, Z and 0 are not year codes
+	v, _ = sharedkernel.NewVIN("9BWZZZ377UT004251")
+	_, ok = v.ModelYear(2026)
+	assert.False(t, ok)
+}